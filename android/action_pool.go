@@ -0,0 +1,82 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/blueprint"
+)
+
+// ActionPool is a named ninja pool limiting how many memory-heavy actions of a kind, like metalava,
+// d8 on the largest jars or lld on the largest libraries, run in parallel, so that machines with
+// less RAM don't run out of memory in builds with a high -j. The product sets the depth of the
+// pool and the modules whose actions run in it in ActionPools. The actions of the other modules,
+// and all of them if the product doesn't configure the pool, run in their usual pool.
+type ActionPool struct {
+	name string
+	pctx PackageContext
+	pool blueprint.Pool
+}
+
+// ActionPoolContext is the subset of ModuleContext needed to find whether the actions of a module
+// run in an action pool.
+type ActionPoolContext interface {
+	Config() Config
+	ModuleName() string
+}
+
+// ActionPool returns the action pool with the given name. It may only be called during a Go
+// package's initialization - either from the init() function or as part of a package-scoped
+// variable's initialization.
+func (p PackageContext) ActionPool(name string) ActionPool {
+	pool := p.PoolFunc(name+"Pool", func(ctx PackagePoolContext) blueprint.PoolParams {
+		depth := 0
+		if c := ctx.Config().actionPool(name); c != nil {
+			depth = c.Depth
+		}
+		if depth <= 0 {
+			ctx.Errorf("action pool %q must have a positive depth in ActionPools, got %d", name, depth)
+		}
+		return blueprint.PoolParams{
+			Comment: fmt.Sprintf("limits the %s actions of the modules listed in ActionPools", name),
+			Depth:   depth,
+		}
+	})
+	return ActionPool{name: name, pctx: p, pool: pool}
+}
+
+// Contains returns true if the actions of the module run in the pool.
+func (a ActionPool) Contains(ctx ActionPoolContext) bool {
+	c := ctx.Config().actionPool(a.name)
+	if c == nil || c.Depth <= 0 {
+		return false
+	}
+	return len(c.Modules) == 0 || InList(ctx.ModuleName(), c.Modules)
+}
+
+// StaticRule returns a rule running the given command in the pool, for the modules that Contains
+// returns true for. The remote execution templates of the command, like "$reTemplate", are removed,
+// as the actions that run remotely don't use the local memory.
+func (a ActionPool) StaticRule(name string, params blueprint.RuleParams, templates []string,
+	argNames ...string) blueprint.Rule {
+
+	for _, template := range templates {
+		params.Command = strings.ReplaceAll(params.Command, template, "")
+	}
+	params.Pool = a.pool
+	return a.pctx.StaticRule(name, params, argNames...)
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// When soong_build is run with --analysis-times-report it records the time spent generating the
+// build actions of the modules of each directory, which soong_ui checks against the analysis
+// time budgets of the projects of the tree.
+
+// AnalysisTimesReportFileName is the name of the report, in the Soong output directory.
+const AnalysisTimesReportFileName = "analysis_times.json"
+
+// analysisTimes maps each directory to the time spent generating the build actions of its
+// modules.
+type analysisTimes struct {
+	lock sync.Mutex
+	dirs map[string]time.Duration
+}
+
+// recordAnalysisTime adds d to the time spent generating the build actions of the modules in dir.
+func (c *config) recordAnalysisTime(dir string, d time.Duration) {
+	times := c.analysisTimes
+	times.lock.Lock()
+	defer times.lock.Unlock()
+	times.dirs[dir] += d
+}
+
+// AnalysisTimesReportEnabled returns true if soong_build records the time spent generating the
+// build actions of each directory.
+func (c *config) AnalysisTimesReportEnabled() bool {
+	return c.analysisTimes != nil
+}
+
+// AnalysisTimesReport returns the time spent generating the build actions of the modules of each
+// directory as JSON, in milliseconds.
+func (c *config) AnalysisTimesReport() ([]byte, error) {
+	report := make(map[string]int64)
+	if times := c.analysisTimes; times != nil {
+		times.lock.Lock()
+		for dir, d := range times.dirs {
+			report[dir] = d.Milliseconds()
+		}
+		times.lock.Unlock()
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal analysis times report: %s", err.Error())
+	}
+	return append(data, '\n'), nil
+}
@@ -356,6 +356,10 @@ type Target struct {
 	// For example, linux_glibc_x86 returns true on a regular x86/i686/Linux machines, but returns false
 	// on Mac (different OS), or on 64-bit only i686/Linux machines (unsupported arch).
 	HostCross bool
+
+	// DeviceName is the name of the additional device of a multi-device product that the module
+	// is compiled for, or empty for the primary device.
+	DeviceName string
 }
 
 // NativeBridgeSupport is an enum that specifies if a Target supports NativeBridge.
@@ -379,8 +383,11 @@ func (target Target) OsVariation() string {
 // ArchVariation returns the name of the variation used by the archMutator for the Target.
 func (target Target) ArchVariation() string {
 	var variation string
+	if target.DeviceName != "" {
+		variation = target.DeviceName + "_"
+	}
 	if target.NativeBridge {
-		variation = "native_bridge_"
+		variation += "native_bridge_"
 	}
 	variation += target.Arch.String()
 
@@ -439,7 +446,7 @@ func osMutator(bpctx blueprint.BottomUpMutatorContext) {
 	var moduleOSList []OsType
 	for _, os := range osTypeList {
 		for _, t := range mctx.Config().Targets[os] {
-			if base.supportsTarget(t) {
+			if base.supportsTarget(t) && (!isHostMuslTarget(mctx.Config(), t) || base.HostMusl()) {
 				moduleOSList = append(moduleOSList, os)
 				break
 			}
@@ -604,6 +611,18 @@ func archMutator(bpctx blueprint.BottomUpMutatorContext) {
 		osTargets = targets
 	}
 
+	// Filter the musl host targets of builds against glibc unless the module opts in to them.
+	if os == LinuxMusl && !base.HostMusl() {
+		var targets []Target
+		for _, t := range osTargets {
+			if !isHostMuslTarget(mctx.Config(), t) {
+				targets = append(targets, t)
+			}
+		}
+
+		osTargets = targets
+	}
+
 	// only the primary arch in the ramdisk / vendor_ramdisk / recovery partition
 	if os == Android && (module.InstallInRecovery() || module.InstallInRamdisk() || module.InstallInVendorRamdisk() || module.InstallInDebugRamdisk()) {
 		osTargets = []Target{osTargets[0]}
@@ -647,6 +666,24 @@ func archMutator(bpctx blueprint.BottomUpMutatorContext) {
 		multiTargets = filterToArch(multiTargets, primaryArch, Common)
 	}
 
+	// Add the Targets of the additional devices of a multi-device product, selected with the same
+	// multilib from the Targets of each device.  Only the core variants are built for them, and
+	// the common Target is shared with the primary device.
+	if os == Android && image == CoreVariation && !(module.InstallInRecovery() || module.InstallInRamdisk() || module.InstallInVendorRamdisk() || module.InstallInDebugRamdisk()) {
+		for _, name := range mctx.Config().DeviceNames()[1:] {
+			deviceTargets, _ := mctx.Config().DeviceTargets(name)
+			namedTargets, err := decodeMultilibTargets(multilib, deviceTargets, prefer32)
+			if err != nil {
+				mctx.ModuleErrorf("%s", err.Error())
+			}
+			for _, target := range namedTargets {
+				if target.Arch.ArchType != Common {
+					targets = append(targets, target)
+				}
+			}
+		}
+	}
+
 	// If there are no supported targets disable the module.
 	if len(targets) == 0 {
 		base.Disable()
@@ -670,6 +707,11 @@ func archMutator(bpctx blueprint.BottomUpMutatorContext) {
 		if os == Darwin && targets[i].HostCross {
 			m.base().commonProperties.SkipInstall = true
 		}
+
+		// Make only knows about the primary device.
+		if targets[i].DeviceName != "" {
+			m.base().HideFromMake()
+		}
 	}
 
 	// Create a dependency for Darwin Universal binaries from the primary to secondary
@@ -1486,6 +1528,12 @@ func determineBuildOS(config *config) {
 		switch runtime.GOARCH {
 		case "amd64":
 			return X86_64
+		case "arm64":
+			// Apple Silicon machines build host tools natively.
+			if runtime.GOOS == "darwin" {
+				return Arm64
+			}
+			panic(fmt.Sprintf("unsupported Arch: %s", runtime.GOARCH))
 		default:
 			panic(fmt.Sprintf("unsupported Arch: %s", runtime.GOARCH))
 		}
@@ -1493,6 +1541,13 @@ func determineBuildOS(config *config) {
 
 }
 
+// isHostMuslTarget returns true if t is the musl host target that is added to builds against glibc
+// for the modules that set host_musl: true.
+func isHostMuslTarget(config Config, t Target) bool {
+	return t.Os == LinuxMusl && t.HostCross && config.BuildOS == Linux &&
+		t.Arch.ArchType == config.BuildOSTarget.Arch.ArchType
+}
+
 // Convert the arch product variables into a list of targets for each OsType.
 func decodeTargetProductVariables(config *config) (map[OsType][]Target, error) {
 	variables := config.productVariables
@@ -1509,6 +1564,7 @@ func decodeTargetProductVariables(config *config) (map[OsType][]Target, error) {
 		nativeBridgeEnabled      NativeBridgeSupport
 		nativeBridgeHostArchName *string
 		nativeBridgeRelativePath *string
+		hostCross                bool
 	}
 
 	addTarget := func(target targetConfig) {
@@ -1521,6 +1577,15 @@ func decodeTargetProductVariables(config *config) (map[OsType][]Target, error) {
 			targetErr = err
 			return
 		}
+
+		// The native code of unbundled apps runs on every device of the ABI of the product, so it
+		// can't use the instructions of a newer arch variant, e.g. TARGET_ARCH_VARIANT=armv9-a.
+		if target.os == Android && target.nativeBridgeEnabled == NativeBridgeDisabled && config.UnbundledBuildApps() {
+			if err := checkNdkAbiCompatible(arch); err != nil {
+				targetErr = fmt.Errorf("unbundled apps: %s", err)
+				return
+			}
+		}
 		nativeBridgeRelativePathStr := String(target.nativeBridgeRelativePath)
 		nativeBridgeHostArchNameStr := String(target.nativeBridgeHostArchName)
 
@@ -1554,7 +1619,7 @@ func decodeTargetProductVariables(config *config) (map[OsType][]Target, error) {
 			} else {
 				archSupported = false
 			}
-			if !osSupported || !archSupported {
+			if !osSupported || !archSupported || target.hostCross {
 				hostCross = true
 			}
 		}
@@ -1574,6 +1639,13 @@ func decodeTargetProductVariables(config *config) (map[OsType][]Target, error) {
 		return nil, fmt.Errorf("No host primary architecture set")
 	}
 
+	// The host tools are run from the directory of the BuildArch, see HostToolPath, so the primary
+	// host target has to be built for it, e.g. arm64 on Apple Silicon machines.
+	if *variables.HostArch != config.BuildArch.Name {
+		return nil, fmt.Errorf("HostArch %q does not match the architecture of the build machine %q",
+			*variables.HostArch, config.BuildArch.Name)
+	}
+
 	// The primary host target, which must always exist.
 	addTarget(targetConfig{os: config.BuildOS, archName: *variables.HostArch, nativeBridgeEnabled: NativeBridgeDisabled})
 
@@ -1582,6 +1654,27 @@ func decodeTargetProductVariables(config *config) (map[OsType][]Target, error) {
 		addTarget(targetConfig{os: config.BuildOS, archName: *variables.HostSecondaryArch, nativeBridgeEnabled: NativeBridgeDisabled})
 	}
 
+	// The musl host target of builds against glibc, for the modules that set host_musl: true. It is
+	// a host cross target so that Make, which only knows about the glibc host modules, ignores it.
+	if config.BuildOS == Linux {
+		addTarget(targetConfig{os: LinuxMusl, archName: *variables.HostArch, nativeBridgeEnabled: NativeBridgeDisabled,
+			hostCross: true})
+	}
+
+	// The optional Linux host target of another architecture, e.g. to cross-compile the host tools
+	// for arm64 build servers. It uses musl, whose toolchain supports all the Linux host
+	// architectures, and its host tools are installed in out/host/linux-<arch>.
+	if hostCrossArch := String(variables.HostCrossArch); hostCrossArch != "" {
+		if !config.BuildOS.Linux() {
+			return nil, fmt.Errorf("HostCrossArch is only supported on Linux build hosts")
+		}
+		if hostCrossArch == *variables.HostArch {
+			return nil, fmt.Errorf("HostCrossArch %q must differ from HostArch", hostCrossArch)
+		}
+		addTarget(targetConfig{os: LinuxMusl, archName: hostCrossArch, nativeBridgeEnabled: NativeBridgeDisabled,
+			hostCross: true})
+	}
+
 	// Optional cross-compiled host targets, generally Windows.
 	if String(variables.CrossHost) != "" {
 		crossHostOs := osByName(*variables.CrossHost)
@@ -1700,6 +1793,30 @@ func getNdkAbisConfig() []archConfig {
 	}
 }
 
+// selectNdkAbisConfig returns the archConfigs of getNdkAbisConfig for the given ABIs, or all of
+// them if abis is empty.
+func selectNdkAbisConfig(abis []string) ([]archConfig, error) {
+	all := getNdkAbisConfig()
+	if len(abis) == 0 {
+		return all, nil
+	}
+	var selected []archConfig
+	for _, abi := range abis {
+		found := false
+		for _, c := range all {
+			if InList(abi, c.Abi) {
+				selected = append(selected, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Ndk_abis_list: unknown NDK ABI %q", abi)
+		}
+	}
+	return selected, nil
+}
+
 // getAmlAbisConfig returns a list of archConfigs for the ABIs supported by mainline modules.
 func getAmlAbisConfig() []archConfig {
 	return []archConfig{
@@ -1710,7 +1827,31 @@ func getAmlAbisConfig() []archConfig {
 	}
 }
 
+// ndkAbiArchVariants lists the arch variants whose code runs on every device of the ABI of their
+// arch type. The NDK and the mainline modules are only built for these arch variants, or for the
+// generic arch variant, as they run on devices of any arch variant.
+var ndkAbiArchVariants = map[ArchType][]string{
+	Arm:   {"armv7-a-neon"},
+	Arm64: {"armv8-a", "armv8-a-branchprot"},
+}
+
+// checkNdkAbiCompatible returns an error if code built for arch may not run on every device of its
+// ABI, like code built for the armv9-a arch variant that uses instructions armv8-a devices lack. It
+// applies to the NDK and mainline module ABIs, and to the device targets of unbundled app builds.
+func checkNdkAbiCompatible(arch Arch) error {
+	if arch.ArchVariant != "" && !InList(arch.ArchVariant, ndkAbiArchVariants[arch.ArchType]) {
+		return fmt.Errorf("[%q] arch variant %q is not compatible with the ABI %q, supported variants: %q",
+			arch.ArchType, arch.ArchVariant, arch.Abi, ndkAbiArchVariants[arch.ArchType])
+	}
+	if arch.CpuVariant != "" {
+		return fmt.Errorf("[%q] cpu variant %q is not compatible with the ABI %q",
+			arch.ArchType, arch.CpuVariant, arch.Abi)
+	}
+	return nil
+}
+
 // decodeArchSettings converts a list of archConfigs into a list of Targets for the given OsType.
+// The archConfigs are those of the NDK or mainline module ABIs, and must be compatible with them.
 func decodeAndroidArchSettings(archConfigs []archConfig) ([]Target, error) {
 	var ret []Target
 
@@ -1720,6 +1861,9 @@ func decodeAndroidArchSettings(archConfigs []archConfig) ([]Target, error) {
 		if err != nil {
 			return nil, err
 		}
+		if err := checkNdkAbiCompatible(arch); err != nil {
+			return nil, err
+		}
 
 		ret = append(ret, Target{
 			Os:   Android,
@@ -1730,6 +1874,57 @@ func decodeAndroidArchSettings(archConfigs []archConfig) ([]Target, error) {
 	return ret, nil
 }
 
+// decodeNamedDeviceTargets converts the DeviceTargets product variable into the compilation
+// targets of each additional device of a multi-device product.
+func decodeNamedDeviceTargets(config *config) (map[string][]Target, error) {
+	deviceTargets := make(map[string][]Target)
+	for _, name := range SortedStringKeys(config.productVariables.DeviceTargets) {
+		variables := config.productVariables.DeviceTargets[name]
+		if name == "" {
+			return nil, fmt.Errorf("DeviceTargets: device name must not be empty")
+		}
+		if name == String(config.productVariables.DeviceName) {
+			return nil, fmt.Errorf("DeviceTargets: %q is already the name of the primary device", name)
+		}
+		if String(variables.DeviceArch) == "" {
+			return nil, fmt.Errorf("DeviceTargets: no primary architecture set for device %q", name)
+		}
+
+		arch, err := decodeArch(Android, *variables.DeviceArch, variables.DeviceArchVariant,
+			variables.DeviceCpuVariant, variables.DeviceAbi)
+		if err != nil {
+			return nil, fmt.Errorf("DeviceTargets: device %q: %s", name, err)
+		}
+		targets := []Target{{Os: Android, Arch: arch, NativeBridge: NativeBridgeDisabled, DeviceName: name}}
+
+		if String(variables.DeviceSecondaryArch) != "" {
+			arch, err := decodeArch(Android, *variables.DeviceSecondaryArch, variables.DeviceSecondaryArchVariant,
+				variables.DeviceSecondaryCpuVariant, variables.DeviceSecondaryAbi)
+			if err != nil {
+				return nil, fmt.Errorf("DeviceTargets: device %q: %s", name, err)
+			}
+			targets = append(targets, Target{Os: Android, Arch: arch, NativeBridge: NativeBridgeDisabled, DeviceName: name})
+		}
+
+		deviceTargets[name] = targets
+	}
+	return deviceTargets, nil
+}
+
+// RegisterCpuVariant adds a cpu variant of archType that products can select with
+// TARGET_CPU_VARIANT, and that modules can select properties for like the built-in ones, e.g.
+// arch: { arm64: { kryo: { ... } } }. It must be called from the init function of a Go package,
+// before any module is created.
+func RegisterCpuVariant(archType ArchType, variant string) {
+	if variant == "" || variant == "generic" || variant == archType.Name {
+		panic(fmt.Errorf("invalid cpu variant name %q", variant))
+	}
+	if InList(variant, cpuVariants[archType]) {
+		panic(fmt.Errorf("cpu variant %q of %q is already registered", variant, archType))
+	}
+	cpuVariants[archType] = append(cpuVariants[archType], variant)
+}
+
 // decodeArch converts a set of strings from product variables into an Arch struct.
 func decodeArch(os OsType, arch string, archVariant, cpuVariant *string, abi []string) (Arch, error) {
 	// Verify the arch is valid
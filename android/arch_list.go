@@ -28,6 +28,11 @@ var archVariants = map[ArchType][]string{
 		"armv8-2a-dotprod",
 		"armv9-a",
 	},
+	Riscv64: {
+		"rv64gc-zb",
+		"rv64gcv",
+		"rv64gcv-zb",
+	},
 	X86: {
 		"amberlake",
 		"atom",
@@ -109,6 +114,10 @@ var archFeatures = map[ArchType][]string{
 	Arm64: {
 		"dotprod",
 	},
+	Riscv64: {
+		"rvv",
+		"zb",
+	},
 	X86: {
 		"ssse3",
 		"sse4",
@@ -154,6 +163,18 @@ var androidArchFeatureMap = map[ArchType]map[string][]string{
 			"dotprod",
 		},
 	},
+	Riscv64: {
+		"rv64gc-zb": {
+			"zb",
+		},
+		"rv64gcv": {
+			"rvv",
+		},
+		"rv64gcv-zb": {
+			"rvv",
+			"zb",
+		},
+	},
 	X86: {
 		"amberlake": {
 			"ssse3",
@@ -549,10 +549,10 @@ func TestArchMutatorNativeBridge(t *testing.T) {
 				// Prepare for native bridge test
 				FixtureModifyConfig(func(config Config) {
 					config.Targets[Android] = []Target{
-						{Android, Arch{ArchType: X86_64, ArchVariant: "silvermont", Abi: []string{"arm64-v8a"}}, NativeBridgeDisabled, "", "", false},
-						{Android, Arch{ArchType: X86, ArchVariant: "silvermont", Abi: []string{"armeabi-v7a"}}, NativeBridgeDisabled, "", "", false},
-						{Android, Arch{ArchType: Arm64, ArchVariant: "armv8-a", Abi: []string{"arm64-v8a"}}, NativeBridgeEnabled, "x86_64", "arm64", false},
-						{Android, Arch{ArchType: Arm, ArchVariant: "armv7-a-neon", Abi: []string{"armeabi-v7a"}}, NativeBridgeEnabled, "x86", "arm", false},
+						{Android, Arch{ArchType: X86_64, ArchVariant: "silvermont", Abi: []string{"arm64-v8a"}}, NativeBridgeDisabled, "", "", false, ""},
+						{Android, Arch{ArchType: X86, ArchVariant: "silvermont", Abi: []string{"armeabi-v7a"}}, NativeBridgeDisabled, "", "", false, ""},
+						{Android, Arch{ArchType: Arm64, ArchVariant: "armv8-a", Abi: []string{"arm64-v8a"}}, NativeBridgeEnabled, "x86_64", "arm64", false, ""},
+						{Android, Arch{ArchType: Arm, ArchVariant: "armv7-a-neon", Abi: []string{"armeabi-v7a"}}, NativeBridgeEnabled, "x86", "arm", false, ""},
 					}
 				}),
 				FixtureWithRootAndroidBp(bp),
@@ -575,6 +575,190 @@ func TestArchMutatorNativeBridge(t *testing.T) {
 	}
 }
 
+type namedDeviceTestModule struct {
+	ModuleBase
+	props struct {
+		Deps []string
+	}
+
+	deviceName  string
+	installPath InstallPath
+	depTargets  []string
+}
+
+func (m *namedDeviceTestModule) DepsMutator(ctx BottomUpMutatorContext) {
+	ctx.AddDependency(ctx.Module(), nil, m.props.Deps...)
+}
+
+func (m *namedDeviceTestModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+	m.deviceName = ctx.DeviceConfig().Name()
+	m.installPath = PathForModuleInstall(ctx, "bin")
+	ctx.VisitDirectDeps(func(dep Module) {
+		m.depTargets = append(m.depTargets, dep.Target().String())
+	})
+}
+
+func namedDeviceTestModuleFactory() Module {
+	m := &namedDeviceTestModule{}
+	m.AddProperties(&m.props)
+	InitAndroidArchModule(m, DeviceSupported, MultilibBoth)
+	return m
+}
+
+func TestArchMutatorNamedDevices(t *testing.T) {
+	bp := `
+		module {
+			name: "foo",
+			deps: ["bar"],
+		}
+
+		module {
+			name: "bar",
+		}
+
+		module {
+			name: "baz",
+			compile_multilib: "first",
+		}
+	`
+
+	result := GroupFixturePreparers(
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("module", namedDeviceTestModuleFactory)
+		}),
+		PrepareForTestWithArchMutator,
+		FixtureModifyConfig(func(config Config) {
+			config.productVariables.DeviceTargets = map[string]DeviceTargetVariables{
+				"watch": {
+					DeviceArch:        proptools.StringPtr("arm"),
+					DeviceArchVariant: proptools.StringPtr("armv7-a-neon"),
+					DeviceCpuVariant:  proptools.StringPtr("generic"),
+					DeviceAbi:         []string{"armeabi-v7a"},
+				},
+			}
+			if err := config.configureNamedDevices(); err != nil {
+				t.Fatal(err)
+			}
+		}),
+		FixtureWithRootAndroidBp(bp),
+	).RunTest(t)
+	ctx := result.TestContext
+
+	AssertDeepEquals(t, "foo variants",
+		[]string{"android_arm64_armv8-a", "android_arm_armv7-a-neon", "android_watch_arm_armv7-a-neon"},
+		ctx.ModuleVariantsForTests("foo"))
+	AssertDeepEquals(t, "baz variants",
+		[]string{"android_arm64_armv8-a", "android_watch_arm_armv7-a-neon"},
+		ctx.ModuleVariantsForTests("baz"))
+
+	primary := ctx.ModuleForTests("foo", "android_arm_armv7-a-neon").Module().(*namedDeviceTestModule)
+	AssertStringEquals(t, "primary device", "test_device", primary.deviceName)
+	AssertBoolEquals(t, "primary hidden from make", false, primary.IsHideFromMake())
+
+	watch := ctx.ModuleForTests("foo", "android_watch_arm_armv7-a-neon").Module().(*namedDeviceTestModule)
+	AssertStringEquals(t, "watch target device", "watch", watch.Target().DeviceName)
+	AssertStringEquals(t, "watch device", "watch", watch.deviceName)
+	AssertBoolEquals(t, "watch hidden from make", true, watch.IsHideFromMake())
+	AssertPathRelativeToTopEquals(t, "watch install path", "out/soong/target/product/watch/system/bin",
+		watch.installPath)
+	AssertDeepEquals(t, "watch deps", []string{"android_watch_arm_armv7-a-neon"}, watch.depTargets)
+}
+
+func TestArchMutatorHostMusl(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skipf("requires runtime.GOOS linux")
+	}
+
+	bp := `
+		module {
+			name: "foo",
+			host_supported: true,
+		}
+
+		module {
+			name: "bar",
+			host_supported: true,
+			host_musl: true,
+		}
+	`
+
+	result := GroupFixturePreparers(
+		prepareForArchTest,
+		FixtureModifyConfig(func(config Config) {
+			config.Targets[LinuxMusl] = []Target{
+				{LinuxMusl, Arch{ArchType: X86_64}, NativeBridgeDisabled, "", "", true, ""},
+			}
+		}),
+		FixtureWithRootAndroidBp(bp),
+	).RunTest(t)
+
+	AssertDeepEquals(t, "foo variants",
+		[]string{"linux_glibc_x86_64", "linux_glibc_x86", "android_arm64_armv8-a", "android_arm_armv7-a-neon"},
+		result.ModuleVariantsForTests("foo"))
+	AssertDeepEquals(t, "bar variants",
+		[]string{"linux_glibc_x86_64", "linux_glibc_x86", "linux_musl_x86_64", "android_arm64_armv8-a", "android_arm_armv7-a-neon"},
+		result.ModuleVariantsForTests("bar"))
+}
+
+func TestHostCrossArch(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skipf("requires runtime.GOOS linux")
+	}
+
+	config := TestConfig(t.TempDir(), nil, "", nil)
+	config.productVariables.HostArch = proptools.StringPtr("x86_64")
+	config.productVariables.HostCrossArch = proptools.StringPtr("arm64")
+	targets, err := decodeTargetProductVariables(config.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hostCrossTargets []string
+	for _, target := range targets[LinuxMusl] {
+		if target.HostCross && target.Arch.ArchType == Arm64 {
+			hostCrossTargets = append(hostCrossTargets, target.String())
+		}
+	}
+	AssertDeepEquals(t, "HostCrossArch targets", []string{"linux_musl_arm64"}, hostCrossTargets)
+
+	ctx := PathContextForTesting(config)
+	AssertPathRelativeToTopEquals(t, "host tool for HostCrossArch",
+		"out/soong/host/linux-arm64/bin/aapt2", config.HostToolPathForArch(ctx, Arm64, "aapt2"))
+	AssertPathRelativeToTopEquals(t, "host tool for BuildArch",
+		"out/soong/host/linux-x86/bin/aapt2", config.HostToolPathForArch(ctx, X86_64, "aapt2"))
+}
+
+func TestDarwinArm64HostArch(t *testing.T) {
+	newConfig := func(hostArch string) Config {
+		config := TestConfig(t.TempDir(), nil, "", nil)
+		config.BuildOS = Darwin
+		config.BuildArch = Arm64
+		config.productVariables.HostArch = proptools.StringPtr(hostArch)
+		config.productVariables.HostSecondaryArch = nil
+		config.productVariables.CrossHost = nil
+		return config
+	}
+
+	config := newConfig("arm64")
+	targets, err := decodeTargetProductVariables(config.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hostTargets []string
+	for _, target := range targets[Darwin] {
+		if !target.HostCross {
+			hostTargets = append(hostTargets, target.String())
+		}
+	}
+	AssertDeepEquals(t, "host targets", []string{"darwin_arm64"}, hostTargets)
+	AssertPathRelativeToTopEquals(t, "host tool", "out/soong/host/darwin-arm64/bin/aapt2",
+		config.HostToolPath(PathContextForTesting(config), "aapt2"))
+
+	_, err = decodeTargetProductVariables(newConfig("x86_64").config)
+	AssertErrorMessageEquals(t, "HostArch of another architecture",
+		`HostArch "x86_64" does not match the architecture of the build machine "arm64"`, err)
+}
+
 type testArchPropertiesModule struct {
 	ModuleBase
 	properties struct {
@@ -685,8 +869,8 @@ func TestArchProperties(t *testing.T) {
 			goOS: "linux",
 			preparer: FixtureModifyConfig(func(config Config) {
 				config.Targets[Windows] = []Target{
-					{Windows, Arch{ArchType: X86_64}, NativeBridgeDisabled, "", "", true},
-					{Windows, Arch{ArchType: X86}, NativeBridgeDisabled, "", "", true},
+					{Windows, Arch{ArchType: X86_64}, NativeBridgeDisabled, "", "", true, ""},
+					{Windows, Arch{ArchType: X86}, NativeBridgeDisabled, "", "", true, ""},
 				}
 			}),
 			results: []result{
@@ -759,3 +943,61 @@ func TestArchProperties(t *testing.T) {
 		})
 	}
 }
+
+func TestSelectNdkAbisConfig(t *testing.T) {
+	all, err := selectNdkAbisConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, "all NDK ABIs", getNdkAbisConfig(), all)
+
+	selected, err := selectNdkAbisConfig([]string{"x86_64", "arm64-v8a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var arches []string
+	for _, c := range selected {
+		arches = append(arches, c.Arch)
+	}
+	AssertArrayString(t, "selected NDK ABIs", []string{"x86_64", "arm64"}, arches)
+
+	_, err = selectNdkAbisConfig([]string{"mips"})
+	AssertErrorMessageEquals(t, "unknown NDK ABI", `Ndk_abis_list: unknown NDK ABI "mips"`, err)
+}
+
+func TestNdkAbiArchVariants(t *testing.T) {
+	for _, archConfigs := range [][]archConfig{getNdkAbisConfig(), getAmlAbisConfig()} {
+		if _, err := decodeAndroidArchSettings(archConfigs); err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+	}
+
+	_, err := decodeAndroidArchSettings([]archConfig{{"arm64", "armv9-a", "", []string{"arm64-v8a"}}})
+	AssertErrorMessageEquals(t, "armv9-a", `["arm64"] arch variant "armv9-a" is not compatible with the ABI ["arm64-v8a"], supported variants: ["armv8-a" "armv8-a-branchprot"]`, err)
+
+	_, err = decodeAndroidArchSettings([]archConfig{{"arm64", "armv8-a", "cortex-a76", []string{"arm64-v8a"}}})
+	AssertErrorMessageEquals(t, "cpu variant", `["arm64"] cpu variant "cortex-a76" is not compatible with the ABI ["arm64-v8a"]`, err)
+}
+
+func TestUnbundledAppsArchVariant(t *testing.T) {
+	newConfig := func(archVariant string, unbundledApps []string) Config {
+		config := TestConfig(t.TempDir(), nil, "", nil)
+		config.productVariables.HostArch = proptools.StringPtr(config.BuildArch.Name)
+		config.productVariables.DeviceArch = proptools.StringPtr("arm64")
+		config.productVariables.DeviceArchVariant = proptools.StringPtr(archVariant)
+		config.productVariables.DeviceAbi = []string{"arm64-v8a"}
+		config.productVariables.Unbundled_build_apps = unbundledApps
+		return config
+	}
+
+	if _, err := decodeTargetProductVariables(newConfig("armv8-a", []string{"Foo"}).config); err != nil {
+		t.Errorf("unexpected error for armv8-a: %s", err)
+	}
+	if _, err := decodeTargetProductVariables(newConfig("armv9-a", nil).config); err != nil {
+		t.Errorf("unexpected error for an armv9-a device: %s", err)
+	}
+
+	_, err := decodeTargetProductVariables(newConfig("armv9-a", []string{"Foo"}).config)
+	AssertErrorMessageEquals(t, "armv9-a unbundled apps",
+		`unbundled apps: ["arm64"] arch variant "armv9-a" is not compatible with the ABI ["arm64-v8a"], supported variants: ["armv8-a" "armv8-a-branchprot"]`, err)
+}
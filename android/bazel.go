@@ -17,6 +17,7 @@ package android
 import (
 	"bufio"
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/google/blueprint"
@@ -53,6 +54,10 @@ type BazelConversionStatus struct {
 
 	// MissingBp2buildDep stores the module names of direct dependency that were not found
 	MissingDeps []string `blueprint:"mutated"`
+
+	// Blockers stores the reasons why bp2build doesn't convert the module, other than its
+	// dependencies, like allowlists or properties that the converter doesn't support.
+	Blockers []string `blueprint:"mutated"`
 }
 
 type bazelModuleProperties struct {
@@ -117,6 +122,7 @@ type Bazelable interface {
 	GetBazelLabel(ctx BazelConversionPathContext, module blueprint.Module) string
 	ShouldConvertWithBp2build(ctx BazelConversionContext) bool
 	shouldConvertWithBp2build(ctx bazelOtherModuleContext, module blueprint.Module) bool
+	bp2buildBlocker(ctx bazelOtherModuleContext, module blueprint.Module) string
 	ConvertWithBp2build(ctx TopDownMutatorContext)
 
 	// namespacedVariableProps is a map from a soong config variable namespace
@@ -389,15 +395,23 @@ type bazelOtherModuleContext interface {
 }
 
 func (b *BazelModuleBase) shouldConvertWithBp2build(ctx bazelOtherModuleContext, module blueprint.Module) bool {
+	return b.bp2buildBlocker(ctx, module) == ""
+}
+
+// bp2buildBlocker returns the reason why bp2build doesn't convert the module, regardless of its
+// dependencies, or "" if it converts it.
+func (b *BazelModuleBase) bp2buildBlocker(ctx bazelOtherModuleContext, module blueprint.Module) string {
 	if !b.bazelProps().Bazel_module.CanConvertToBazel {
-		return false
+		return fmt.Sprintf("module type %s has no bp2build converter", ctx.OtherModuleType(module))
 	}
 
 	// In api_bp2build mode, all soong modules that can provide API contributions should be converted
 	// This is irrespective of its presence/absence in bp2build allowlists
 	if ctx.Config().BuildMode == ApiBp2build {
-		_, providesApis := module.(ApiProvider)
-		return providesApis
+		if _, providesApis := module.(ApiProvider); !providesApis {
+			return "the module doesn't provide APIs"
+		}
+		return ""
 	}
 
 	propValue := b.bazelProperties.Bazel_module.Bp2build_available
@@ -407,7 +421,7 @@ func (b *BazelModuleBase) shouldConvertWithBp2build(ctx bazelOtherModuleContext,
 	// trigger this conditional because unit tests run under the "." package path
 	isTestModule := packagePath == Bp2BuildTopLevel && proptools.BoolDefault(propValue, false)
 	if isTestModule {
-		return true
+		return ""
 	}
 
 	moduleName := module.Name()
@@ -417,14 +431,14 @@ func (b *BazelModuleBase) shouldConvertWithBp2build(ctx bazelOtherModuleContext,
 	allowlistConvert := moduleNameAllowed || moduleTypeAllowed
 	if moduleNameAllowed && moduleTypeAllowed {
 		ctx.ModuleErrorf("A module cannot be in moduleAlwaysConvert and also be in moduleTypeAlwaysConvert")
-		return false
+		return "the module is in both Bp2buildModuleAlwaysConvertList and Bp2buildModuleTypeAlwaysConvertList"
 	}
 
 	if allowlist.moduleDoNotConvert[moduleName] {
 		if moduleNameAllowed {
 			ctx.ModuleErrorf("a module cannot be in moduleDoNotConvert and also be in moduleAlwaysConvert")
 		}
-		return false
+		return "the module is in Bp2buildModuleDoNotConvertList"
 	}
 
 	// This is a tristate value: true, false, or unset.
@@ -433,15 +447,26 @@ func (b *BazelModuleBase) shouldConvertWithBp2build(ctx bazelOtherModuleContext,
 			ctx.ModuleErrorf("A module cannot be in a directory marked Bp2BuildDefaultTrue"+
 				" or Bp2BuildDefaultTrueRecursively and also be in moduleAlwaysConvert. Directory: '%s'"+
 				" Module: '%s'", directoryPath, moduleName)
-			return false
+			return "the module is in Bp2buildModuleAlwaysConvertList and in directory " + directoryPath +
+				" enabled in Bp2buildDefaultConfig"
 		}
 
 		// Allow modules to explicitly opt-out.
-		return proptools.BoolDefault(propValue, true)
+		if !proptools.BoolDefault(propValue, true) {
+			return "the module sets bazel_module: { bp2build_available: false }"
+		}
+		return ""
 	}
 
 	// Allow modules to explicitly opt-in.
-	return proptools.BoolDefault(propValue, allowlistConvert)
+	if !proptools.BoolDefault(propValue, allowlistConvert) {
+		if propValue != nil {
+			return "the module sets bazel_module: { bp2build_available: false }"
+		}
+		return "directory " + packagePath + " is not enabled in Bp2buildDefaultConfig, and the module " +
+			"is not in Bp2buildModuleAlwaysConvertList"
+	}
+	return ""
 }
 
 // bp2buildDefaultTrueRecursively checks that the package contains a prefix from the
@@ -494,7 +519,14 @@ func registerBp2buildConversionMutator(ctx RegisterMutatorsContext) {
 
 func convertWithBp2build(ctx TopDownMutatorContext) {
 	bModule, ok := ctx.Module().(Bazelable)
-	if !ok || !bModule.shouldConvertWithBp2build(ctx, ctx.Module()) {
+	if !ok {
+		ctx.AddBp2buildBlocker(fmt.Sprintf("module type %s has no bp2build converter", ctx.ModuleType()))
+		return
+	}
+	if blocker := bModule.bp2buildBlocker(ctx, ctx.Module()); blocker != "" {
+		if !bModule.HasHandcraftedLabel() {
+			ctx.AddBp2buildBlocker(blocker)
+		}
 		return
 	}
 
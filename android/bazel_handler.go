@@ -593,12 +593,7 @@ func NewBazelContext(c *config) (BazelContext, error) {
 		return nil, fmt.Errorf("missing required env vars to use bazel: %s", missing)
 	}
 
-	targetBuildVariant := "user"
-	if c.Eng() {
-		targetBuildVariant = "eng"
-	} else if c.Debuggable() {
-		targetBuildVariant = "userdebug"
-	}
+	targetBuildVariant := string(c.BuildType())
 	targetProduct := "unknown"
 	if c.HasDeviceProduct() {
 		targetProduct = c.DeviceProduct()
@@ -210,7 +210,7 @@ func (bcc *TestBazelConversionContext) ModuleErrorf(format string, args ...inter
 
 func (bcc *TestBazelConversionContext) Config() Config {
 	return Config{
-		&config{
+		config: &config{
 			Bp2buildPackageConfig: bcc.allowlist,
 		},
 	}
@@ -0,0 +1,337 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+
+	"github.com/google/blueprint/proptools"
+)
+
+// Build flags.
+//
+// Build flags are named boolean or string values that gate features of a release, declared in a
+// JSON flags file, by default build_flags.json in the soong output directory:
+//
+//	{
+//	    "Flags": [
+//	        {"Name": "RELEASE_FOO", "Type": "bool", "Value": "true"},
+//	        {"Name": "RELEASE_BAR_VERSION", "Type": "string", "Value": "2"}
+//	    ]
+//	}
+//
+// Go code reads them with Config.ReleaseFlag and Config.ReleaseFlagBool, and Android.bp files with
+// the build_flag property, which sets properties of the module depending on the value of a flag:
+//
+//	cc_library {
+//	    name: "libfoo",
+//	    build_flag: [
+//	        {
+//	            flag: "RELEASE_FOO",
+//	            then: { cflags: ["-DFOO"] },
+//	            otherwise: { cflags: ["-DNO_FOO"] },
+//	        },
+//	        {
+//	            flag: "RELEASE_BAR_VERSION",
+//	            value: "2",
+//	            then: { srcs: ["bar_v2.cpp"] },
+//	        },
+//	    ],
+//	}
+//
+// They replace product variables that only exist to turn a feature on or off.
+
+const buildFlagsFileName = "build_flags.json"
+
+func init() {
+	registerBuildFlagBuildComponents(InitRegistrationContext)
+}
+
+func registerBuildFlagBuildComponents(ctx RegistrationContext) {
+	ctx.PreDepsMutators(func(ctx RegisterMutatorsContext) {
+		ctx.BottomUp("build_flag", buildFlagMutator).Parallel()
+	})
+}
+
+var PrepareForTestWithBuildFlags = FixtureRegisterWithContext(registerBuildFlagBuildComponents)
+
+// BuildFlagType is the type of a build flag.
+type BuildFlagType string
+
+const (
+	// BoolBuildFlag flags are set to "true" or "false".
+	BoolBuildFlag BuildFlagType = "bool"
+
+	// StringBuildFlag flags can be set to any value.
+	StringBuildFlag BuildFlagType = "string"
+)
+
+// BuildFlag is a build flag declared in the flags file.
+type BuildFlag struct {
+	Name  string
+	Type  BuildFlagType
+	Value string
+}
+
+type buildFlagsFile struct {
+	Flags []BuildFlag
+}
+
+var buildFlagNameRegexp = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// loadBuildFlags reads the build flags declared in the flags file. The default flags file is
+// optional, a flags file given on the command line is not.
+func loadBuildFlags(filename string, optional bool) (map[string]BuildFlag, error) {
+	data, err := os.ReadFile(absolutePath(filename))
+	if os.IsNotExist(err) && optional {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("build flags: cannot read %s: %s", filename, err.Error())
+	}
+	return parseBuildFlags(filename, data)
+}
+
+func parseBuildFlags(filename string, data []byte) (map[string]BuildFlag, error) {
+	var file buildFlagsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("build flags: %s is not a valid flags file: %s", filename, err.Error())
+	}
+
+	flags := make(map[string]BuildFlag, len(file.Flags))
+	for _, flag := range file.Flags {
+		if !buildFlagNameRegexp.MatchString(flag.Name) {
+			return nil, fmt.Errorf("build flags: %s: invalid flag name %q", filename, flag.Name)
+		}
+		if _, exists := flags[flag.Name]; exists {
+			return nil, fmt.Errorf("build flags: %s: %s is declared more than once", filename, flag.Name)
+		}
+		switch flag.Type {
+		case BoolBuildFlag:
+			if flag.Value != "true" && flag.Value != "false" {
+				return nil, fmt.Errorf("build flags: %s: bool flag %s must be set to \"true\" or \"false\", not %q",
+					filename, flag.Name, flag.Value)
+			}
+		case StringBuildFlag:
+		default:
+			return nil, fmt.Errorf("build flags: %s: %s has unknown type %q, expected %q or %q",
+				filename, flag.Name, flag.Type, BoolBuildFlag, StringBuildFlag)
+		}
+		flags[flag.Name] = flag
+	}
+	return flags, nil
+}
+
+// BuildFlagsFile returns the path of the flags file that declares the build flags.
+func (c *config) BuildFlagsFile() string {
+	if c.buildFlagsFile != "" {
+		return c.buildFlagsFile
+	}
+	return filepath.Join(c.soongOutDir, buildFlagsFileName)
+}
+
+// BuildFlags returns all the build flags, sorted by name.
+func (c *config) BuildFlags() []BuildFlag {
+	flags := make([]BuildFlag, 0, len(c.buildFlags))
+	for _, name := range SortedStringKeys(c.buildFlags) {
+		flags = append(flags, c.buildFlags[name])
+	}
+	return flags
+}
+
+// ReleaseFlag returns the value of the named build flag, or "" if it is not declared.
+func (c *config) ReleaseFlag(name string) string {
+	return c.buildFlags[name].Value
+}
+
+// ReleaseFlagBool returns true if the named bool build flag is set to true, and false if it is
+// set to false or not declared.
+func (c *config) ReleaseFlagBool(name string) bool {
+	flag, ok := c.buildFlags[name]
+	if !ok {
+		return false
+	}
+	if flag.Type != BoolBuildFlag {
+		panic(fmt.Errorf("build flag %s is a %s flag, not a bool flag", name, flag.Type))
+	}
+	return flag.Value == "true"
+}
+
+type buildFlagProperties struct {
+	// Properties set depending on the value of build flags.
+	Build_flag []buildFlagCondition
+}
+
+type buildFlagCondition struct {
+	// The name of the build flag.
+	Flag *string
+
+	// The value a string build flag is compared to. Cannot be set for bool build flags.
+	Value *string
+
+	// Properties set when the bool build flag is true, or the string build flag is equal to value.
+	Then buildFlagValueProperties
+
+	// Properties set otherwise.
+	Otherwise buildFlagValueProperties
+}
+
+// buildFlagValueProperties are the properties the build_flag property can set. Setting one that the
+// module type doesn't have is an error.
+type buildFlagValueProperties struct {
+	Enabled *bool
+
+	Srcs         []string
+	Exclude_srcs []string
+
+	Cflags   []string
+	Cppflags []string
+	Asflags  []string
+	Ldflags  []string
+
+	Static_libs       []string
+	Shared_libs       []string
+	Whole_static_libs []string
+	Header_libs       []string
+	Libs              []string
+	Required          []string
+
+	Javacflags         []string
+	Java_resource_dirs []string
+
+	Init_rc []string
+}
+
+var buildFlagPropTypeMap OncePer
+
+func initBuildFlagModule(m Module) {
+	base := m.base()
+	base.buildFlagProperties = &buildFlagProperties{}
+	m.AddProperties(base.buildFlagProperties)
+}
+
+// buildFlagValuePropertiesType returns the type of a struct containing the fields of
+// buildFlagValueProperties that exist in the property structs of a module, or nil if there are
+// none. It caches the type for each list of property structs.
+func buildFlagValuePropertiesType(moduleTypeProps []interface{}) reflect.Type {
+	key := sliceToTypeArray(moduleTypeProps)
+	typ, _ := buildFlagPropTypeMap.Once(NewCustomOnceKey(key), func() interface{} {
+		typ, _ := proptools.FilterPropertyStruct(reflect.TypeOf(buildFlagValueProperties{}),
+			func(field reflect.StructField, prefix string) (bool, reflect.StructField) {
+				for _, p := range moduleTypeProps {
+					if fieldExistsByNameRecursive(reflect.TypeOf(p).Elem(), prefix, field.Name) {
+						return true, field
+					}
+				}
+				return false, field
+			})
+		return typ
+	}).(reflect.Type)
+	return typ
+}
+
+// buildFlagMutator applies the properties of the build_flag property that match the build flags.
+func buildFlagMutator(ctx BottomUpMutatorContext) {
+	m, ok := ctx.Module().(Module)
+	if !ok {
+		return
+	}
+	props := m.base().buildFlagProperties
+	if props == nil {
+		return
+	}
+
+	for i, condition := range props.Build_flag {
+		name := String(condition.Flag)
+		property := fmt.Sprintf("build_flag[%d]", i)
+
+		flag, ok := ctx.Config().buildFlags[name]
+		if !ok {
+			ctx.PropertyErrorf(property, "undeclared build flag %q", name)
+			continue
+		}
+
+		var matches bool
+		switch flag.Type {
+		case BoolBuildFlag:
+			if condition.Value != nil {
+				ctx.PropertyErrorf(property, "value cannot be set for bool build flag %s", name)
+				continue
+			}
+			matches = flag.Value == "true"
+		case StringBuildFlag:
+			if condition.Value == nil {
+				ctx.PropertyErrorf(property, "value must be set for string build flag %s", name)
+				continue
+			}
+			matches = flag.Value == *condition.Value
+		}
+
+		if matches {
+			applyBuildFlagValueProperties(ctx, m, property+".then", condition.Then)
+		} else {
+			applyBuildFlagValueProperties(ctx, m, property+".otherwise", condition.Otherwise)
+		}
+	}
+}
+
+// applyBuildFlagValueProperties appends the properties that are set to the properties of the
+// module.
+func applyBuildFlagValueProperties(ctx BottomUpMutatorContext, m Module, property string,
+	values buildFlagValueProperties) {
+
+	src := reflect.ValueOf(values)
+	if src.IsZero() {
+		return
+	}
+
+	// Copy the properties into a struct that only has the properties the module type has.
+	typ := buildFlagValuePropertiesType(m.GetProperties())
+	var filtered reflect.Value
+	if typ != nil {
+		filtered = reflect.New(typ)
+	}
+	for i := 0; i < src.NumField(); i++ {
+		if src.Field(i).IsZero() {
+			continue
+		}
+		name := src.Type().Field(i).Name
+		if typ == nil {
+			ctx.PropertyErrorf(property+"."+proptools.PropertyNameForField(name), "not supported by this module type")
+			continue
+		}
+		if field := filtered.Elem().FieldByName(name); field.IsValid() {
+			field.Set(src.Field(i))
+		} else {
+			ctx.PropertyErrorf(property+"."+proptools.PropertyNameForField(name), "not supported by this module type")
+		}
+	}
+	if typ == nil {
+		return
+	}
+
+	err := proptools.AppendMatchingProperties(m.GetProperties(), filtered.Interface(), nil)
+	if err != nil {
+		if propertyErr, ok := err.(*proptools.ExtendPropertyError); ok {
+			ctx.PropertyErrorf(property+"."+propertyErr.Property, "%s", propertyErr.Err.Error())
+		} else {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+)
+
+func TestParseBuildFlags(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{
+			name: "valid",
+			data: `{"Flags": [
+				{"Name": "RELEASE_FOO", "Type": "bool", "Value": "true"},
+				{"Name": "RELEASE_BAR", "Type": "string", "Value": "bar"}
+			]}`,
+		},
+		{
+			name: "invalid name",
+			data: `{"Flags": [{"Name": "release_foo", "Type": "bool", "Value": "true"}]}`,
+			err:  `build flags: build_flags.json: invalid flag name "release_foo"`,
+		},
+		{
+			name: "duplicate",
+			data: `{"Flags": [
+				{"Name": "RELEASE_FOO", "Type": "bool", "Value": "true"},
+				{"Name": "RELEASE_FOO", "Type": "bool", "Value": "false"}
+			]}`,
+			err: `build flags: build_flags.json: RELEASE_FOO is declared more than once`,
+		},
+		{
+			name: "invalid bool",
+			data: `{"Flags": [{"Name": "RELEASE_FOO", "Type": "bool", "Value": "yes"}]}`,
+			err:  `build flags: build_flags.json: bool flag RELEASE_FOO must be set to "true" or "false", not "yes"`,
+		},
+		{
+			name: "unknown type",
+			data: `{"Flags": [{"Name": "RELEASE_FOO", "Type": "int", "Value": "1"}]}`,
+			err:  `build flags: build_flags.json: RELEASE_FOO has unknown type "int", expected "bool" or "string"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseBuildFlags("build_flags.json", []byte(tc.data))
+			if tc.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				AssertErrorMessageEquals(t, "error", tc.err, err)
+			}
+		})
+	}
+}
+
+var prepareForBuildFlagTest = GroupFixturePreparers(
+	PrepareForTestWithBuildFlags,
+	FixtureRegisterWithContext(func(ctx RegistrationContext) {
+		ctx.RegisterModuleType("test_module", testProductVariableModuleFactoryFactory(&struct {
+			Cflags []string
+		}{}))
+	}),
+	FixtureModifyConfig(func(config Config) {
+		config.buildFlags = map[string]BuildFlag{
+			"RELEASE_FOO":     {Name: "RELEASE_FOO", Type: BoolBuildFlag, Value: "true"},
+			"RELEASE_BAR":     {Name: "RELEASE_BAR", Type: BoolBuildFlag, Value: "false"},
+			"RELEASE_VERSION": {Name: "RELEASE_VERSION", Type: StringBuildFlag, Value: "2"},
+		}
+	}),
+)
+
+func TestBuildFlagProperty(t *testing.T) {
+	result := prepareForBuildFlagTest.RunTestWithBp(t, `
+		test_module {
+			name: "foo",
+			cflags: ["-DBASE"],
+			build_flag: [
+				{
+					flag: "RELEASE_FOO",
+					then: { cflags: ["-DFOO"] },
+					otherwise: { cflags: ["-DNO_FOO"] },
+				},
+				{
+					flag: "RELEASE_BAR",
+					then: { cflags: ["-DBAR"] },
+					otherwise: { cflags: ["-DNO_BAR"] },
+				},
+				{
+					flag: "RELEASE_VERSION",
+					value: "1",
+					then: { cflags: ["-DV1"] },
+				},
+				{
+					flag: "RELEASE_VERSION",
+					value: "2",
+					then: { cflags: ["-DV2"] },
+				},
+			],
+		}
+	`)
+
+	foo := result.ModuleForTests("foo", "").Module().(*testProductVariableModule)
+	var cflags []string
+	for _, p := range foo.GetProperties() {
+		if props, ok := p.(*struct{ Cflags []string }); ok {
+			cflags = props.Cflags
+		}
+	}
+	AssertArrayString(t, "cflags", []string{"-DBASE", "-DFOO", "-DNO_BAR", "-DV2"}, cflags)
+
+	AssertStringEquals(t, "ReleaseFlag", "2", result.Config.ReleaseFlag("RELEASE_VERSION"))
+	AssertBoolEquals(t, "ReleaseFlagBool", true, result.Config.ReleaseFlagBool("RELEASE_FOO"))
+	AssertBoolEquals(t, "undeclared ReleaseFlagBool", false, result.Config.ReleaseFlagBool("RELEASE_UNDECLARED"))
+}
+
+func TestBuildFlagPropertyErrors(t *testing.T) {
+	prepareForBuildFlagTest.
+		ExtendWithErrorHandler(FixtureExpectsAllErrorsToMatchAPattern([]string{
+			`module "foo": build_flag\[0\]: undeclared build flag "RELEASE_TYPO"`,
+			`module "foo": build_flag\[1\]: value cannot be set for bool build flag RELEASE_FOO`,
+			`module "foo": build_flag\[2\]: value must be set for string build flag RELEASE_VERSION`,
+			`module "foo": build_flag\[3\].then.srcs: not supported by this module type`,
+		})).
+		RunTestWithBp(t, `
+		test_module {
+			name: "foo",
+			build_flag: [
+				{flag: "RELEASE_TYPO", then: { cflags: ["-DFOO"] }},
+				{flag: "RELEASE_FOO", value: "true", then: { cflags: ["-DFOO"] }},
+				{flag: "RELEASE_VERSION", then: { cflags: ["-DFOO"] }},
+				{flag: "RELEASE_FOO", then: { srcs: ["foo.c"] }},
+			],
+		}
+	`)
+}
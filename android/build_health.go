@@ -0,0 +1,170 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// The build health summary gathers the indicators of the debt of a tree into a single file,
+// out/soong/build_health.json, to be tracked release over release: the compiler warnings by
+// category, the BUILD_BROKEN_* flags and the neverallow waivers of the product, the modules built
+// with missing dependencies, the modules that mixed builds fall back to Soong for, and the size of
+// the outputs with a size budget against their budget. `m build_health` builds it, and it is
+// copied to the dist directory with droidcore.
+//
+// The parts known at analysis time are written by soong_build, and the build_health tool adds the
+// warnings and the sizes of the outputs once they are built.
+
+func init() {
+	InitRegistrationContext.RegisterSingletonType("build_health", buildHealthSingletonFactory)
+}
+
+// CompilerWarningsModule is implemented by the modules that keep the compiler warnings of their
+// sources in files, which the build health summary counts by category.
+type CompilerWarningsModule interface {
+	CompilerWarningFiles() Paths
+}
+
+// SizeBudgetModule is implemented by the modules whose output has a size budget.
+type SizeBudgetModule interface {
+	// SizeBudget returns the output with a size budget, and the budget in bytes, or nil and 0 if
+	// the module doesn't have one.
+	SizeBudget() (Path, int64)
+}
+
+// buildHealthAnalysis is the part of the build health summary known at analysis time, which the
+// build_health tool reads.
+type buildHealthAnalysis struct {
+	BuildBrokenFlags    []string
+	NeverallowWaivers   []string
+	MissingDependencies map[string][]string
+	MixedBuildFallbacks []string
+}
+
+// buildBrokenFlags returns the names of the BuildBroken product variables that are set.
+func buildBrokenFlags(config Config) []string {
+	var flags []string
+	v := reflect.ValueOf(config.productVariables).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name, field := v.Type().Field(i).Name, v.Field(i)
+		if !strings.HasPrefix(name, "BuildBroken") || field.IsZero() {
+			continue
+		}
+		// A *bool flag explicitly set to false isn't in use.
+		if field.Kind() == reflect.Ptr && field.Elem().Kind() == reflect.Bool && !field.Elem().Bool() {
+			continue
+		}
+		flags = append(flags, name)
+	}
+	sort.Strings(flags)
+	return flags
+}
+
+// neverallowWaivers returns the names of the product variables that waive neverallow checks.
+func neverallowWaivers(config Config) []string {
+	var waivers []string
+	if config.productVariables.BuildBrokenTrebleSyspropNeverallow {
+		waivers = append(waivers, "BuildBrokenTrebleSyspropNeverallow")
+	}
+	if config.SelinuxIgnoreNeverallows() {
+		waivers = append(waivers, "SelinuxIgnoreNeverallows")
+	}
+	return waivers
+}
+
+// mixedBuildFallbacks returns the modules that mixed builds build with Soong instead of Bazel.
+func (c *config) mixedBuildFallbacks() []string {
+	c.mixedBuildsLock.Lock()
+	defer c.mixedBuildsLock.Unlock()
+	var modules []string
+	for module := range c.mixedBuildDisabledModules {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+	return modules
+}
+
+func buildHealthSingletonFactory() Singleton {
+	return &buildHealthSingleton{}
+}
+
+type buildHealthSingleton struct {
+	buildHealthFile OptionalPath
+}
+
+func (s *buildHealthSingleton) GenerateBuildActions(ctx SingletonContext) {
+	analysis := buildHealthAnalysis{
+		BuildBrokenFlags:    buildBrokenFlags(ctx.Config()),
+		NeverallowWaivers:   neverallowWaivers(ctx.Config()),
+		MissingDependencies: make(map[string][]string),
+		MixedBuildFallbacks: ctx.Config().mixedBuildFallbacks(),
+	}
+
+	var warningFiles Paths
+	var sizeBudgets []string
+	var budgetedOutputs Paths
+	ctx.VisitAllModules(func(module Module) {
+		if !module.Enabled() {
+			return
+		}
+		name := ctx.ModuleName(module)
+		if missingDeps := module.base().missingDeps; len(missingDeps) > 0 {
+			analysis.MissingDependencies[name] = FirstUniqueStrings(
+				append(analysis.MissingDependencies[name], missingDeps...))
+		}
+		if m, ok := module.(CompilerWarningsModule); ok {
+			warningFiles = append(warningFiles, m.CompilerWarningFiles()...)
+		}
+		if m, ok := module.(SizeBudgetModule); ok {
+			if output, budget := m.SizeBudget(); output != nil && budget > 0 {
+				sizeBudgets = append(sizeBudgets, fmt.Sprintf("%s=%s:%d", name, output.String(), budget))
+				budgetedOutputs = append(budgetedOutputs, output)
+			}
+		}
+	})
+
+	data, err := json.MarshalIndent(analysis, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to write the build health summary: %s", err)
+		return
+	}
+	analysisFile := PathForOutput(ctx, "build_health", "analysis.json")
+	WriteFileRule(ctx, analysisFile, string(data))
+
+	buildHealthFile := PathForOutput(ctx, "build_health.json")
+	rule := NewRuleBuilder(pctx, ctx)
+	rule.Command().
+		BuiltTool("build_health").
+		FlagWithOutput("-o ", buildHealthFile).
+		FlagWithInput("-analysis ", analysisFile).
+		FlagForEachArg("-size_budget ", sizeBudgets).
+		Implicits(budgetedOutputs).
+		FlagWithRspFileInputList("@", PathForOutput(ctx, "build_health", "warnings.rsp"), warningFiles)
+	rule.Build("build_health", "build health summary")
+
+	ctx.Phony("build_health", buildHealthFile)
+	s.buildHealthFile = OptionalPathForPath(buildHealthFile)
+}
+
+func (s *buildHealthSingleton) MakeVars(ctx MakeVarsContext) {
+	if s.buildHealthFile.Valid() {
+		ctx.DistForGoals([]string{"droidcore", "build_health"}, s.buildHealthFile.Path())
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/blueprint/proptools"
+)
+
+func TestBuildHealth(t *testing.T) {
+	t.Parallel()
+	bp := `
+		deps {
+			name: "foo",
+			deps: ["missing"],
+		}
+		deps {
+			name: "bar",
+		}
+	`
+	result := GroupFixturePreparers(
+		prepareForModuleTests,
+		PrepareForTestWithArchMutator,
+		PrepareForTestWithAllowMissingDependencies,
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterSingletonType("build_health", buildHealthSingletonFactory)
+		}),
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.BuildBrokenClangProperty = true
+			variables.BuildBrokenDepfile = proptools.BoolPtr(false)
+			variables.BuildBrokenInputDirModules = []string{"baz"}
+			variables.SelinuxIgnoreNeverallows = true
+		}),
+	).RunTestWithBp(t, bp)
+	singleton := result.SingletonForTests("build_health")
+
+	var analysis buildHealthAnalysis
+	content := ContentFromFileRuleForTests(t, singleton.Output("build_health/analysis.json"))
+	if err := json.Unmarshal([]byte(content), &analysis); err != nil {
+		t.Fatalf("failed to parse the analysis part of the build health summary: %s", err)
+	}
+	AssertDeepEquals(t, "BuildBrokenFlags",
+		[]string{"BuildBrokenClangProperty", "BuildBrokenInputDirModules"}, analysis.BuildBrokenFlags)
+	AssertDeepEquals(t, "NeverallowWaivers", []string{"SelinuxIgnoreNeverallows"}, analysis.NeverallowWaivers)
+	AssertDeepEquals(t, "MissingDependencies",
+		map[string][]string{"foo": {"missing"}}, analysis.MissingDependencies)
+
+	rule := singleton.Rule("build_health")
+	AssertStringDoesContain(t, "build health command", rule.RuleParams.Command,
+		"-o out/soong/build_health.json -analysis out/soong/build_health/analysis.json")
+}
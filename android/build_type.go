@@ -0,0 +1,121 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// The behaviors that depend on the build type (TARGET_BUILD_VARIANT) are declared in
+// BuildTypeTable instead of with Eng() and Debuggable() checks where they are used, so that they
+// can be reviewed and adjusted in one place, e.g. by forks that want their userdebug builds to
+// behave differently. The settings of the build type of the product are exported to
+// out/soong/build_type_settings.json.
+
+func init() {
+	RegisterSingletonType("build_type_settings", buildTypeSettingsSingletonFactory)
+}
+
+// BuildType is the type of the build, derived from the Eng and Debuggable product variables.
+type BuildType string
+
+const (
+	BuildTypeUser      BuildType = "user"
+	BuildTypeUserdebug BuildType = "userdebug"
+	BuildTypeEng       BuildType = "eng"
+)
+
+// BuildTypeSettings are the flags and the default properties of a build type.
+type BuildTypeSettings struct {
+	// Compiler flags added to the global flags of the C and C++ sources of the device.
+	Cflags []string `json:",omitempty"`
+
+	// Whether R8 keeps the debug information of the Java code it optimizes.
+	R8KeepDebugInfo bool
+
+	// Whether MinimizeJavaDebugInfo of the product is honored.
+	MinimizeJavaDebugInfo bool
+
+	// Whether the stripped native binaries and shared libraries get a .gnu_debuglink section
+	// pointing to their unstripped files.
+	StripAddGnuDebuglink bool
+
+	// The system properties written to the build.prop files generated by Soong.
+	BuildProperties map[string]string `json:",omitempty"`
+}
+
+// BuildTypeTable maps each build type to its settings.
+var BuildTypeTable = map[BuildType]*BuildTypeSettings{
+	BuildTypeUser: {
+		MinimizeJavaDebugInfo: true,
+		BuildProperties: map[string]string{
+			"ro.build.type": "user",
+		},
+	},
+	BuildTypeUserdebug: {
+		MinimizeJavaDebugInfo: true,
+		StripAddGnuDebuglink:  true,
+		BuildProperties: map[string]string{
+			"ro.build.type": "userdebug",
+		},
+	},
+	BuildTypeEng: {
+		R8KeepDebugInfo:      true,
+		StripAddGnuDebuglink: true,
+		BuildProperties: map[string]string{
+			"ro.build.type": "eng",
+		},
+	},
+}
+
+// BuildType returns the type of the build.
+func (c *config) BuildType() BuildType {
+	if Bool(c.productVariables.Eng) {
+		return BuildTypeEng
+	} else if Bool(c.productVariables.Debuggable) {
+		return BuildTypeUserdebug
+	}
+	return BuildTypeUser
+}
+
+// BuildTypeSettings returns the settings of the type of the build from BuildTypeTable.
+func (c *config) BuildTypeSettings() *BuildTypeSettings {
+	buildType := c.BuildType()
+	settings, ok := BuildTypeTable[buildType]
+	if !ok {
+		panic(fmt.Errorf("build type %q is missing from BuildTypeTable", buildType))
+	}
+	return settings
+}
+
+func buildTypeSettingsSingletonFactory() Singleton {
+	return &buildTypeSettingsSingleton{}
+}
+
+type buildTypeSettingsSingleton struct{}
+
+// GenerateBuildActions exports the build type and its settings.
+func (s *buildTypeSettingsSingleton) GenerateBuildActions(ctx SingletonContext) {
+	data, err := json.MarshalIndent(struct {
+		BuildType BuildType
+		Settings  *BuildTypeSettings
+	}{ctx.Config().BuildType(), ctx.Config().BuildTypeSettings()}, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to marshal the build type settings: %s", err)
+		return
+	}
+	WriteFileRule(ctx, PathForOutput(ctx, "build_type_settings.json"), string(data))
+}
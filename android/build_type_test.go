@@ -0,0 +1,48 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+
+	"github.com/google/blueprint/proptools"
+)
+
+func TestBuildTypeSettings(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		eng, debuggable       bool
+		expectedBuildType     BuildType
+		expectedMinimizeDebug bool
+	}{
+		{expectedBuildType: BuildTypeUser, expectedMinimizeDebug: true},
+		{debuggable: true, expectedBuildType: BuildTypeUserdebug, expectedMinimizeDebug: true},
+		{eng: true, debuggable: true, expectedBuildType: BuildTypeEng, expectedMinimizeDebug: false},
+	}
+	for _, tc := range testCases {
+		config := TestConfig(t.TempDir(), nil, "", nil)
+		config.productVariables.Eng = proptools.BoolPtr(tc.eng)
+		config.productVariables.Debuggable = proptools.BoolPtr(tc.debuggable)
+		config.productVariables.MinimizeJavaDebugInfo = proptools.BoolPtr(true)
+
+		buildType := config.BuildType()
+		AssertStringEquals(t, "build type", string(tc.expectedBuildType), string(buildType))
+		AssertSame(t, "settings", BuildTypeTable[buildType], config.BuildTypeSettings())
+		AssertBoolEquals(t, string(buildType)+" MinimizeJavaDebugInfo", tc.expectedMinimizeDebug,
+			config.MinimizeJavaDebugInfo())
+		AssertStringEquals(t, string(buildType)+" ro.build.type", string(buildType),
+			config.BuildTypeSettings().BuildProperties["ro.build.type"])
+	}
+}
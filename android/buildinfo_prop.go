@@ -90,12 +90,9 @@ func (p *buildinfoPropModule) GenerateAndroidBuildActions(ctx ModuleContext) {
 	writeProp("ro.build.version.min_supported_target_sdk", config.PlatformMinSupportedTargetSdkVersion())
 	writeProp("ro.build.version.known_codenames", config.PlatformVersionKnownCodenames())
 
-	if config.Eng() {
-		writeProp("ro.build.type", "eng")
-	} else if config.Debuggable() {
-		writeProp("ro.build.type", "userdebug")
-	} else {
-		writeProp("ro.build.type", "user")
+	buildProperties := config.BuildTypeSettings().BuildProperties
+	for _, name := range SortedKeys(buildProperties) {
+		writeProp(name, buildProperties[name])
 	}
 
 	// Currently, only a few properties are implemented to unblock microdroid use case.
@@ -21,6 +21,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -28,6 +30,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/blueprint"
 	"github.com/google/blueprint/bootstrap"
@@ -71,9 +74,17 @@ var FutureApiLevel = ApiLevel{
 // The product variables file name, containing product config from Kati.
 const productVariablesFileName = "soong.variables"
 
+// The directory name, next to the product variables file, containing drop-in
+// JSON fragments that are layered on top of the product variables file.
+const productVariablesFragmentsDirName = productVariablesFileName + ".d"
+
 // A Config object represents the entire build configuration for Android.
 type Config struct {
 	*config
+
+	// The module whose context returned this Config, when recording which modules read
+	// each environment variable or product variable.
+	envDepsModule string
 }
 
 type SoongBuildMode int
@@ -90,7 +101,10 @@ type CmdArgs struct {
 	BazelApiBp2buildDir string
 	ModuleGraphFile     string
 	ModuleActionsFile   string
+	OutputCompression   string
+	ZstdTool            string
 	DocFile             string
+	ConfigDumpFile      string
 
 	MultitreeBuild bool
 
@@ -102,6 +116,21 @@ type CmdArgs struct {
 	UseBazelProxy bool
 
 	BuildFromTextStub bool
+
+	ExtraVariablesFile string
+
+	ModuleEnvDepsReport bool
+
+	ProductVariableDepsReport bool
+
+	AnalysisTimesReport bool
+
+	PathToolsAudit bool
+
+	SerializeMutators  bool
+	MutatorCheckpoints string
+
+	BuildFlagsFile string
 }
 
 // Build modes that soong_build can run as.
@@ -129,6 +158,9 @@ const (
 	// Generate a documentation file for module type definitions and exit.
 	GenerateDocFile
 
+	// Write the effective configuration as JSON and exit.
+	DumpConfig
+
 	// Use bazel during analysis of many allowlisted build modules. The allowlist
 	// is considered a "developer mode" allowlist, as some modules may be
 	// allowlisted on an experimental basis.
@@ -144,6 +176,18 @@ const (
 	BazelProdMode
 )
 
+// ProductVariablesFragments returns the product variables fragments that were
+// layered on top of the product variables file, in the order they were applied.
+func (c Config) ProductVariablesFragments() []string {
+	return c.productVariablesFragments
+}
+
+// ProductVariablesFragmentsDir returns the directory that is searched for drop-in
+// product variables fragments.
+func (c Config) ProductVariablesFragmentsDir() string {
+	return filepath.Join(filepath.Dir(c.ProductVariablesFileName), productVariablesFragmentsDirName)
+}
+
 // SoongOutDir returns the build output directory for the configuration.
 func (c Config) SoongOutDir() string {
 	return c.soongOutDir
@@ -181,9 +225,15 @@ func (c Config) MaxPageSizeSupported() string {
 	return String(c.config.productVariables.DeviceMaxPageSizeSupported)
 }
 
+// PrebuiltElfCheckAllowlist returns the names of the prebuilt shared libraries whose ELF files
+// aren't checked against the architecture and the page size of the device.
+func (c Config) PrebuiltElfCheckAllowlist() []string {
+	return c.config.productVariables.PrebuiltElfCheckAllowlist
+}
+
 // A DeviceConfig object represents the configuration for a particular device
-// being built. For now there will only be one of these, but in the future there
-// may be multiple devices being built.
+// being built. Multi-device products have one for each device set in the
+// DeviceTargets product variable in addition to the one for the primary device.
 type DeviceConfig struct {
 	*deviceConfig
 }
@@ -206,6 +256,18 @@ type config struct {
 
 	ProductVariablesFileName string
 
+	// The product variables fragments that were layered on top of
+	// ProductVariablesFileName, in the order they were applied.
+	productVariablesFragments []string
+
+	// An additional product variables file passed with --extra-variables-file,
+	// applied after all fragments in the soong.variables.d directory.
+	extraVariablesFile string
+
+	// The flags file that declares the build flags, and the build flags keyed by name.
+	buildFlagsFile string
+	buildFlags     map[string]BuildFlag
+
 	// BuildOS stores the OsType for the OS that the build is running on.
 	BuildOS OsType
 
@@ -224,6 +286,11 @@ type config struct {
 
 	deviceConfig *deviceConfig
 
+	// The compilation targets and device configs of the additional devices of a multi-device
+	// product, keyed by device name.
+	namedDeviceTargets map[string][]Target
+	namedDeviceConfigs map[string]*deviceConfig
+
 	outDir         string // The output directory (usually out/)
 	soongOutDir    string
 	moduleListFile string // the path to the file which lists blueprint files to parse.
@@ -235,6 +302,28 @@ type config struct {
 	envDeps   map[string]string
 	envFrozen bool
 
+	// The modules that read each environment variable, only recorded when the module env
+	// deps report is enabled.
+	moduleEnvDeps *moduleEnvDeps
+
+	// The modules that read each product variable, or nil if they aren't recorded.
+	productVariableDeps *productVariableDeps
+
+	// The time spent generating the build actions of the modules of each directory, or nil if it
+	// isn't recorded.
+	analysisTimes *analysisTimes
+
+	// The tools that the commands of each module run from the PATH, or nil if they aren't
+	// recorded.
+	pathToolsAudit *pathToolsAudit
+
+	// Mutator debugging options, see mutator_checkpoints.go.
+	serializeMutators  bool
+	mutatorCheckpoints []string
+
+	// The values of the environment variables declared as secrets, keyed by name.
+	secretEnvValues map[string]string
+
 	// Changes behavior based on whether Kati runs after soong_build, or if soong_build
 	// runs standalone.
 	katiEnabled bool
@@ -273,6 +362,11 @@ type config struct {
 	mixedBuildEnabledModules  map[string]struct{}
 	mixedBuildDisabledModules map[string]struct{}
 
+	// The apps dexpreopted with their profile in the DexpreoptCloudProfilesDir directory of the
+	// product, counted in the soong_build metrics.
+	dexpreoptCloudProfilesLock sync.Mutex
+	dexpreoptCloudProfiledApps map[string]struct{}
+
 	// These are modules to be built with Bazel beyond the allowlisted/build-mode
 	// specified modules. They are passed via the command-line flag
 	// "--bazel-force-enabled-modules"
@@ -290,6 +384,11 @@ type config struct {
 type deviceConfig struct {
 	config *config
 	OncePer
+
+	// The name and compilation targets of an additional device of a multi-device product,
+	// empty for the primary device.
+	name    string
+	targets []Target
 }
 
 type jsonConfigurable interface {
@@ -297,12 +396,71 @@ type jsonConfigurable interface {
 }
 
 func loadConfig(config *config) error {
-	return loadFromConfigFile(&config.productVariables, absolutePath(config.ProductVariablesFileName))
+	fragments, err := findProductVariablesFragments(config.ProductVariablesFileName, config.extraVariablesFile)
+	if err != nil {
+		return err
+	}
+	config.productVariablesFragments = fragments
+
+	absFragments := make([]string, len(fragments))
+	for i, fragment := range fragments {
+		absFragments[i] = absolutePath(fragment)
+	}
+	filename := absolutePath(config.ProductVariablesFileName)
+
+	// Skip decoding the product variables if none of the inputs changed since the
+	// last run. The cache key can't be computed if soong.variables doesn't exist yet,
+	// in which case loadFromConfigFile creates it.
+	cacheFile := absolutePath(config.productVariablesCacheFile())
+	cacheKey, err := productVariablesCacheKey(filename, absFragments)
+	if err == nil && loadProductVariablesCache(&config.productVariables, cacheFile, cacheKey) {
+		return saveToBazelConfigFile(&config.productVariables, filepath.Dir(filename))
+	}
+
+	if err := loadFromConfigFile(&config.productVariables, filename, absFragments...); err != nil {
+		return err
+	}
+
+	if cacheKey != "" {
+		// The cache only saves time on the next run, failing to write it isn't an error.
+		if err := saveProductVariablesCache(&config.productVariables, cacheFile, cacheKey); err != nil {
+			log.Printf("warning: the product variables are not cached: %s", err)
+		}
+	}
+	return nil
+}
+
+// findProductVariablesFragments returns the JSON fragments that should be layered
+// on top of the product variables file: every *.json file in the soong.variables.d
+// directory next to it in lexical order, followed by extraFile if it is set.
+func findProductVariablesFragments(filename, extraFile string) ([]string, error) {
+	var fragments []string
+
+	dir := filepath.Join(filepath.Dir(filename), productVariablesFragmentsDirName)
+	entries, err := os.ReadDir(absolutePath(dir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("config file: could not read %s: %s", dir, err.Error())
+	}
+	// os.ReadDir returns the entries sorted by filename, which keeps the merge order
+	// deterministic.
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		fragments = append(fragments, filepath.Join(dir, entry.Name()))
+	}
+
+	if extraFile != "" {
+		fragments = append(fragments, extraFile)
+	}
+
+	return fragments, nil
 }
 
 // loadFromConfigFile loads and decodes configuration options from a JSON file
-// in the current working directory.
-func loadFromConfigFile(configurable *productVariables, filename string) error {
+// in the current working directory. The optional fragments are applied in order
+// on top of the decoded file, see applyProductVariablesFragments.
+func loadFromConfigFile(configurable *productVariables, filename string, fragments ...string) error {
 	// Try to open the file
 	configFileReader, err := os.Open(filename)
 	defer configFileReader.Close()
@@ -319,18 +477,21 @@ func loadFromConfigFile(configurable *productVariables, filename string) error {
 	} else if err != nil {
 		return fmt.Errorf("config file: could not open %s: %s", filename, err.Error())
 	} else {
-		// Make a decoder for it
-		jsonDecoder := json.NewDecoder(configFileReader)
-		err = jsonDecoder.Decode(configurable)
+		data, err := io.ReadAll(configFileReader)
 		if err != nil {
+			return fmt.Errorf("config file: could not read %s: %s", filename, err.Error())
+		}
+		if err := decodeProductVariables(configurable, data); err != nil {
 			return fmt.Errorf("config file: %s did not parse correctly: %s", filename, err.Error())
 		}
 	}
 
-	if Bool(configurable.GcovCoverage) && Bool(configurable.ClangCoverage) {
-		return fmt.Errorf("GcovCoverage and ClangCoverage cannot both be set")
+	if err := applyProductVariablesFragments(configurable, fragments); err != nil {
+		return err
 	}
 
+	// Invalid combinations of variables are reported by Config.Validate once the whole
+	// configuration has been loaded.
 	configurable.Native_coverage = proptools.BoolPtr(
 		Bool(configurable.GcovCoverage) ||
 			Bool(configurable.ClangCoverage))
@@ -341,8 +502,6 @@ func loadFromConfigFile(configurable *productVariables, filename string) error {
 		if configurable.Platform_sdk_version != nil {
 			configurable.Platform_sdk_version_or_codename =
 				proptools.StringPtr(strconv.Itoa(*(configurable.Platform_sdk_version)))
-		} else {
-			return fmt.Errorf("Platform_sdk_version cannot be pointed by a NULL pointer")
 		}
 	} else {
 		configurable.Platform_sdk_version_or_codename =
@@ -352,6 +511,81 @@ func loadFromConfigFile(configurable *productVariables, filename string) error {
 	return saveToBazelConfigFile(configurable, filepath.Dir(filename))
 }
 
+// applyProductVariablesFragments decodes each of the JSON fragments on top of
+// configurable in order, so that variables set in a later fragment override the
+// same variables set in the product variables file or an earlier fragment.
+//
+// Fragments in the soong.variables.d directory are expected to be independent of
+// each other, so setting a variable to different values in two of them is reported
+// as a conflict. The last fragment is allowed to override earlier ones when it is
+// the file passed with --extra-variables-file, which is always applied last.
+func applyProductVariablesFragments(configurable *productVariables, fragments []string) error {
+	// The fragment that set each variable, and the value that it set it to.
+	setBy := make(map[string]string)
+	setValues := make(map[string]string)
+	var conflicts []string
+
+	for _, fragment := range fragments {
+		data, err := os.ReadFile(fragment)
+		if err != nil {
+			return fmt.Errorf("config file: could not read fragment %s: %s", fragment, err.Error())
+		}
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return fmt.Errorf("config file: fragment %s did not parse correctly: %s", fragment, err.Error())
+		}
+
+		isFragmentDir := filepath.Base(filepath.Dir(fragment)) == productVariablesFragmentsDirName
+		for _, name := range SortedKeys(fields) {
+			compacted := &bytes.Buffer{}
+			if err := json.Compact(compacted, fields[name]); err != nil {
+				return fmt.Errorf("config file: fragment %s did not parse correctly: %s", fragment, err.Error())
+			}
+			value := compacted.String()
+			if prev, exists := setBy[name]; exists && isFragmentDir && setValues[name] != value {
+				conflicts = append(conflicts, fmt.Sprintf("%s: set to %s by %s and to %s by %s",
+					name, setValues[name], prev, value, fragment))
+			}
+			setBy[name] = fragment
+			setValues[name] = value
+		}
+
+		if err := decodeProductVariables(configurable, data); err != nil {
+			return fmt.Errorf("config file: fragment %s did not parse correctly: %s", fragment, err.Error())
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("config file: conflicting product variables in fragments:\n    %s",
+			strings.Join(conflicts, "\n    "))
+	}
+
+	return nil
+}
+
+// decodeProductVariables decodes the JSON product variables in data, from the product variables
+// file or one of its fragments, on top of configurable. Variables that are neither fields of
+// productVariables nor registered product variables are reported as errors, as they are usually
+// misspelled.
+func decodeProductVariables(configurable *productVariables, data []byte) error {
+	// Registered variables aren't fields of productVariables, decode them separately.
+	if len(registeredProductVariables) > 0 {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+		var err error
+		data, err = json.Marshal(splitRegisteredProductVariables(configurable, fields))
+		if err != nil {
+			return err
+		}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(configurable)
+}
+
 // atomically writes the config file in case two copies of soong_build are running simultaneously
 // (for example, docs generation and ninja manifest generation)
 func saveToConfigFile(config *productVariables, filename string) error {
@@ -486,12 +720,39 @@ func NewConfig(cmdArgs CmdArgs, availableEnv map[string]string) (Config, error)
 		mixedBuildEnabledModules:  make(map[string]struct{}),
 		bazelForceEnabledModules:  make(map[string]struct{}),
 
+		dexpreoptCloudProfiledApps: make(map[string]struct{}),
+
 		MultitreeBuild: cmdArgs.MultitreeBuild,
 		UseBazelProxy:  cmdArgs.UseBazelProxy,
 
 		buildFromTextStub: cmdArgs.BuildFromTextStub,
+
+		extraVariablesFile: cmdArgs.ExtraVariablesFile,
+
+		buildFlagsFile: cmdArgs.BuildFlagsFile,
+
+		serializeMutators: cmdArgs.SerializeMutators,
+	}
+
+	if cmdArgs.MutatorCheckpoints != "" {
+		config.mutatorCheckpoints = strings.Split(cmdArgs.MutatorCheckpoints, ",")
+	}
+
+	if cmdArgs.ModuleEnvDepsReport {
+		config.moduleEnvDeps = &moduleEnvDeps{modules: make(map[string]map[string]bool)}
+	}
+	if cmdArgs.ProductVariableDepsReport {
+		config.productVariableDeps = &productVariableDeps{modules: make(map[string]map[string]bool)}
+	}
+	if cmdArgs.AnalysisTimesReport {
+		config.analysisTimes = &analysisTimes{dirs: make(map[string]time.Duration)}
+	}
+	if cmdArgs.PathToolsAudit {
+		config.pathToolsAudit = &pathToolsAudit{modules: make(map[string]map[string]bool)}
 	}
 
+	config.secretEnvValues = loadSecretEnvValues(config)
+
 	config.deviceConfig = &deviceConfig{
 		config: config,
 	}
@@ -518,6 +779,12 @@ func NewConfig(cmdArgs CmdArgs, availableEnv map[string]string) (Config, error)
 		return Config{}, err
 	}
 
+	// Only the default flags file is optional.
+	config.buildFlags, err = loadBuildFlags(config.BuildFlagsFile(), config.buildFlagsFile == "")
+	if err != nil {
+		return Config{}, err
+	}
+
 	KatiEnabledMarkerFile := filepath.Join(cmdArgs.SoongOutDir, ".soong.kati_enabled")
 	if _, err := os.Stat(absolutePath(KatiEnabledMarkerFile)); err == nil {
 		config.katiEnabled = true
@@ -537,7 +804,10 @@ func NewConfig(cmdArgs CmdArgs, availableEnv map[string]string) (Config, error)
 
 	var archConfig []archConfig
 	if config.NdkAbis() {
-		archConfig = getNdkAbisConfig()
+		archConfig, err = selectNdkAbisConfig(config.productVariables.Ndk_abis_list)
+		if err != nil {
+			return Config{}, err
+		}
 	} else if config.AmlAbis() {
 		archConfig = getAmlAbisConfig()
 	}
@@ -561,6 +831,11 @@ func NewConfig(cmdArgs CmdArgs, availableEnv map[string]string) (Config, error)
 	// Map of OS to compilation targets.
 	config.Targets = targets
 
+	// Compilation targets of the additional devices of a multi-device product.
+	if err := config.configureNamedDevices(); err != nil {
+		return Config{}, err
+	}
+
 	// Compilation targets for host tools.
 	config.BuildOSTarget = config.Targets[config.BuildOS][0]
 	config.BuildOSCommonTarget = getCommonTargets(config.Targets[config.BuildOS])[0]
@@ -595,6 +870,7 @@ func NewConfig(cmdArgs CmdArgs, availableEnv map[string]string) (Config, error)
 	setBuildMode(cmdArgs.BazelApiBp2buildDir, ApiBp2build)
 	setBuildMode(cmdArgs.ModuleGraphFile, GenerateModuleGraph)
 	setBuildMode(cmdArgs.DocFile, GenerateDocFile)
+	setBuildMode(cmdArgs.ConfigDumpFile, DumpConfig)
 	setBazelMode(cmdArgs.BazelModeDev, "--bazel-mode-dev", BazelDevMode)
 	setBazelMode(cmdArgs.BazelMode, "--bazel-mode", BazelProdMode)
 	setBazelMode(cmdArgs.BazelModeStaging, "--bazel-mode-staging", BazelStagingMode)
@@ -604,8 +880,15 @@ func NewConfig(cmdArgs CmdArgs, availableEnv map[string]string) (Config, error)
 	}
 	config.BazelContext, err = NewBazelContext(config)
 	config.Bp2buildPackageConfig = GetBp2BuildAllowList()
+	if err != nil {
+		return Config{}, err
+	}
 
-	return Config{config}, err
+	if err := (Config{config: config}).Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return Config{config: config}, nil
 }
 
 // mockFileSystem replaces all reads with accesses to the provided map of
@@ -688,6 +971,19 @@ func (c *config) HostToolPath(ctx PathContext, tool string) Path {
 	return path
 }
 
+// HostToolPathForArch returns the path of the host tool built for the architecture, which is either
+// the BuildArch or the HostCrossArch of the product, e.g. out/host/linux-arm64/bin/<tool> for
+// arm64 build servers.
+func (c *config) HostToolPathForArch(ctx PathContext, arch ArchType, tool string) Path {
+	if arch == c.BuildArch {
+		return c.HostToolPath(ctx, tool)
+	}
+	if arch.Name != String(c.productVariables.HostCrossArch) {
+		ReportPathErrorf(ctx, "no host target for %s, set HostCrossArch to build the host tools for it", arch)
+	}
+	return pathForInstall(ctx, LinuxMusl, arch, "bin", false, tool)
+}
+
 func (c *config) HostJNIToolPath(ctx PathContext, lib string) Path {
 	ext := ".so"
 	if runtime.GOOS == "darwin" {
@@ -702,12 +998,16 @@ func (c *config) HostJavaToolPath(ctx PathContext, tool string) Path {
 	return path
 }
 
-// PrebuiltOS returns the name of the host OS used in prebuilts directories.
+// PrebuiltOS returns the name of the host OS used in prebuilts directories, which is also the
+// name of the directory of the host tools in the output directory.
 func (c *config) PrebuiltOS() string {
 	switch runtime.GOOS {
 	case "linux":
 		return "linux-x86"
 	case "darwin":
+		if runtime.GOARCH == "arm64" {
+			return "darwin-arm64"
+		}
 		return "darwin-x86"
 	default:
 		panic("Unknown GOOS")
@@ -802,12 +1102,71 @@ func (c *config) BuildNumberFile(ctx PathContext) Path {
 	return PathForOutput(ctx, String(c.productVariables.BuildNumberFile))
 }
 
+// BuildTimestampSource returns the path of the file in the source tree with the timestamp of the
+// files of the reproducible filesystem images, or an invalid path if the product doesn't set it.
+func (c *config) BuildTimestampSource(ctx PathContext) OptionalPath {
+	if source := String(c.productVariables.BuildTimestampSource); source != "" {
+		return OptionalPathForPath(PathForSource(ctx, source))
+	}
+	return OptionalPath{}
+}
+
 // DeviceName returns the name of the current device target.
 // TODO: take an AndroidModuleContext to select the device name for multi-device builds
 func (c *config) DeviceName() string {
 	return *c.productVariables.DeviceName
 }
 
+// configureNamedDevices decodes the Targets of the additional devices of a multi-device product
+// set in the DeviceTargets product variable, and creates their DeviceConfigs.
+func (c *config) configureNamedDevices() error {
+	namedDeviceTargets, err := decodeNamedDeviceTargets(c)
+	if err != nil {
+		return err
+	}
+	c.namedDeviceTargets = namedDeviceTargets
+	c.namedDeviceConfigs = make(map[string]*deviceConfig)
+	for name, deviceTargets := range c.namedDeviceTargets {
+		c.namedDeviceConfigs[name] = &deviceConfig{
+			config:  c,
+			name:    name,
+			targets: deviceTargets,
+		}
+	}
+	return nil
+}
+
+// DeviceNames returns the names of all the devices of a multi-device product, the primary
+// device first followed by the additional devices set in DeviceTargets in sorted order.
+//
+// The archMutator creates a variant of the core modules for the Targets of each additional
+// device; these variants are hidden from Make and report the device in Target().DeviceName.
+func (c *config) DeviceNames() []string {
+	return append([]string{c.DeviceName()}, SortedStringKeys(c.namedDeviceTargets)...)
+}
+
+// DeviceTargets returns the compilation targets of the named device, and false if the product
+// has no such device.
+func (c *config) DeviceTargets(name string) ([]Target, bool) {
+	if name == c.DeviceName() {
+		return c.Targets[Android], true
+	}
+	targets, ok := c.namedDeviceTargets[name]
+	return targets, ok
+}
+
+// DeviceConfigForDevice returns the DeviceConfig of the named device, and false if the product
+// has no such device.
+func (c *config) DeviceConfigForDevice(name string) (DeviceConfig, bool) {
+	if name == c.DeviceName() {
+		return DeviceConfig{c.deviceConfig}, true
+	}
+	if deviceConfig, ok := c.namedDeviceConfigs[name]; ok {
+		return DeviceConfig{deviceConfig}, true
+	}
+	return DeviceConfig{}, false
+}
+
 // DeviceProduct returns the current product target. There could be multiple of
 // these per device type.
 //
@@ -979,6 +1338,23 @@ func (c *config) ProductAAPTPrebuiltDPI() []string {
 	return c.productVariables.AAPTPrebuiltDPI
 }
 
+// AAPTResourceFilter returns the resource filtering policy of the app in partition, from the
+// AAPTResourceFilters entry that lists the app, or else the one that lists its partition.
+func (c *deviceConfig) AAPTResourceFilter(app, partition string) (AAPTResourceFilter, bool) {
+	filters := c.config.productVariables.AAPTResourceFilters
+	for _, filter := range filters {
+		if InList(app, filter.Apps) {
+			return filter, true
+		}
+	}
+	for _, filter := range filters {
+		if InList(partition, filter.Partitions) {
+			return filter, true
+		}
+	}
+	return AAPTResourceFilter{}, false
+}
+
 func (c *config) DefaultAppCertificateDir(ctx PathContext) SourcePath {
 	defaultCert := String(c.productVariables.DefaultAppCertificate)
 	if defaultCert != "" {
@@ -1047,7 +1423,7 @@ func (c *config) AlwaysUsePrebuiltSdks() bool {
 }
 
 func (c *config) MinimizeJavaDebugInfo() bool {
-	return Bool(c.productVariables.MinimizeJavaDebugInfo) && !Bool(c.productVariables.Eng)
+	return Bool(c.productVariables.MinimizeJavaDebugInfo) && c.BuildTypeSettings().MinimizeJavaDebugInfo
 }
 
 func (c *config) Debuggable() bool {
@@ -1071,6 +1447,23 @@ func (c *config) SanitizeHost() []string {
 	return append([]string(nil), c.productVariables.SanitizeHost...)
 }
 
+// SanitizeHostTool returns true if the host tool with the given module name should also be
+// built with the sanitizers returned by SanitizeHostToolsSanitizers, to debug host tool
+// crashes during the build. The sanitized tool is installed next to the regular one, in
+// the sanitized directory of the host, and HostToolPath keeps returning the regular one.
+func (c *config) SanitizeHostTool(name string) bool {
+	return InList(name, c.productVariables.SanitizeHostTools)
+}
+
+// SanitizeHostToolsSanitizers returns the sanitizers to enable for the host tools
+// selected with SanitizeHostTools. Defaults to ASan and UBSan.
+func (c *config) SanitizeHostToolsSanitizers() []string {
+	if len(c.productVariables.SanitizeHostToolsSanitizers) == 0 {
+		return []string{"address", "undefined"}
+	}
+	return append([]string(nil), c.productVariables.SanitizeHostToolsSanitizers...)
+}
+
 func (c *config) SanitizeDevice() []string {
 	return append([]string(nil), c.productVariables.SanitizeDevice...)
 }
@@ -1090,6 +1483,50 @@ func (c *config) EnableCFI() bool {
 	return *c.productVariables.EnableCFI
 }
 
+// ThinLTOCacheDir returns the directory of the incremental ThinLTO cache shared by all the links,
+// from the THINLTO_CACHE_DIR environment variable or else the ThinLTOCacheDir product variable, or
+// "" if none is set.
+func (c *config) ThinLTOCacheDir() string {
+	if dir := c.Getenv("THINLTO_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	return String(c.productVariables.ThinLTOCacheDir)
+}
+
+// HostLinker returns the linker, "lld" or "mold", that the Linux host modules that don't set the
+// linker property are linked with, or "" for the default lld.
+func (c *config) HostLinker() string {
+	return String(c.productVariables.HostLinker)
+}
+
+// ThinLTOCachePolicy returns the pruning policy of the ThinLTO cache, in the format of the
+// --thinlto-cache-policy linker flag. By default the cache is limited to the lesser of 10% of the
+// available disk space and 10GB, and pruned with the default interval and expiration of the
+// linker.
+func (c *config) ThinLTOCachePolicy() string {
+	sizePercent := 10
+	if c.productVariables.ThinLTOCacheSizePercent != nil {
+		sizePercent = *c.productVariables.ThinLTOCacheSizePercent
+	}
+	policy := []string{
+		fmt.Sprintf("cache_size=%d%%", sizePercent),
+		"cache_size_bytes=" + StringDefault(c.productVariables.ThinLTOCacheSizeBytes, "10g"),
+	}
+	if interval := String(c.productVariables.ThinLTOCachePruneInterval); interval != "" {
+		policy = append(policy, "prune_interval="+interval)
+	}
+	if expiration := String(c.productVariables.ThinLTOCachePruneAfter); expiration != "" {
+		policy = append(policy, "prune_after="+expiration)
+	}
+	return strings.Join(policy, ":")
+}
+
+// SoongBuildMemoryLimit returns the memory soong_build throttles its parallelism to stay below, as a
+// number of bytes with an optional k, m or g suffix, or an empty string if it isn't limited.
+func (c *config) SoongBuildMemoryLimit() string {
+	return String(c.productVariables.SoongBuildMemoryLimit)
+}
+
 func (c *config) DisableScudo() bool {
 	return Bool(c.productVariables.DisableScudo)
 }
@@ -1112,6 +1549,22 @@ func (c *config) UseRBE() bool {
 	return Bool(c.productVariables.UseRBE)
 }
 
+// UseRBECacheOnly returns true if the actions supporting RBE run locally, and only read their
+// results from and write them to the remote cache, with the UseRBECacheOnly product variable or
+// the USE_RBE_CACHE_ONLY environment variable.
+func (c *config) UseRBECacheOnly() bool {
+	return c.UseRBE() && (Bool(c.productVariables.UseRBECacheOnly) || c.IsEnvTrue("USE_RBE_CACHE_ONLY"))
+}
+
+// RBEWrapperFlags returns the flags added to the remote execution wrapper command lines after
+// the flags of the actions.
+func (c *config) RBEWrapperFlags() string {
+	if c.UseRBECacheOnly() {
+		return remoteexec.CacheOnlyFlags
+	}
+	return ""
+}
+
 func (c *config) UseRBEJAVAC() bool {
 	return Bool(c.productVariables.UseRBEJAVAC)
 }
@@ -1180,6 +1633,79 @@ func (c *config) TidyChecks() string {
 	return *c.productVariables.TidyChecks
 }
 
+// dirEntry returns the fields that follow the directory in the entry of entries with the longest
+// directory that contains dir, or nil if there is none. The entries have the
+// <dir>:<field>[:<field>...] format of TidyProfiles and TidyBaselines.
+func dirEntry(entries []string, dir string) []string {
+	var fields []string
+	longest := -1
+	for _, entry := range entries {
+		split := strings.Split(entry, ":")
+		prefix := strings.TrimSuffix(split[0], "/") + "/"
+		if strings.HasPrefix(dir+"/", prefix) && len(prefix) > longest {
+			fields = split[1:]
+			longest = len(prefix)
+		}
+	}
+	return fields
+}
+
+// TidyProfile returns the clang-tidy checks of the modules in dir, and the checks that are errors
+// for them, from the entry of TidyProfiles for the closest directory containing dir. The entries
+// have the <dir>:<checks>:<checks-as-errors> format with comma separated lists of checks. ok is
+// false if there is no profile for dir.
+func (c *config) TidyProfile(dir string) (checks, checksAsErrors string, ok bool) {
+	if fields := dirEntry(c.productVariables.TidyProfiles, dir); len(fields) == 2 {
+		return fields[0], fields[1], true
+	}
+	return "", "", false
+}
+
+// TidyBaseline returns the pre-existing clang-tidy findings of the modules in dir that must not be
+// errors, by source file, from the baseline file of the entry of TidyBaselines for the closest
+// directory containing dir. The entries have the <dir>:<baseline-file> format. Each line of a
+// baseline file has the <source-file>:<check> format, with paths relative to the top of the tree,
+// and lines starting with # are comments.
+func (c *config) TidyBaseline(ctx PathContext, dir string) (map[string][]string, error) {
+	fields := dirEntry(c.productVariables.TidyBaselines, dir)
+	if len(fields) != 1 {
+		return nil, nil
+	}
+	file := fields[0]
+	ctx.AddNinjaFileDeps(file)
+
+	type result struct {
+		baseline map[string][]string
+		err      error
+	}
+	key := NewCustomOnceKey(struct{ tidyBaseline string }{file})
+	r := c.Once(key, func() interface{} {
+		f, err := c.fs.Open(file)
+		if err != nil {
+			return result{err: fmt.Errorf("cannot read tidy baseline: %s", err)}
+		}
+		defer f.Close()
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return result{err: fmt.Errorf("cannot read tidy baseline: %s", err)}
+		}
+		baseline := make(map[string][]string)
+		for i, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			src, check, found := strings.Cut(line, ":")
+			if !found || src == "" || check == "" {
+				return result{err: fmt.Errorf("%s:%d: expected <source-file>:<check>, got %q", file, i+1, line)}
+			}
+			baseline[src] = append(baseline[src], check)
+		}
+		return result{baseline: baseline}
+	}).(result)
+	return r.baseline, r.err
+}
+
 func (c *config) LibartImgHostBaseAddress() string {
 	return "0x60000000"
 }
@@ -1219,6 +1745,10 @@ func (c *config) ExportedNamespaces() []string {
 	return append([]string(nil), c.productVariables.NamespacesToExport...)
 }
 
+func (c *config) ModuleAliases() map[string]string {
+	return c.productVariables.ModuleAliases
+}
+
 func (c *config) SourceRootDirs() []string {
 	return c.productVariables.SourceRootDirs
 }
@@ -1250,6 +1780,19 @@ func (c *config) DexpreoptGlobalConfigPath(ctx PathContext) OptionalPath {
 		pathForBuildToolDep(ctx, *c.productVariables.DexpreoptGlobalConfig))
 }
 
+// ReadSourceFile returns the contents of a file in the source tree, and adds it to the
+// dependencies of the ninja manifest so that soong_build reruns when it changes. It is meant for
+// the module types that generate modules from the contents of a file.
+func (c *config) ReadSourceFile(ctx PathContext, path string) ([]byte, error) {
+	ctx.AddNinjaFileDeps(path)
+	f, err := c.fs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
 // DexpreoptGlobalConfig returns the raw byte contents of the dexpreopt global
 // configuration. Since the configuration file was created by Kati during
 // product configuration (externally of soong_build), it's not tracked, so we
@@ -1291,12 +1834,28 @@ func (c *config) BazelModulesForceEnabledByFlag() map[string]struct{} {
 
 func (c *deviceConfig) Arches() []Arch {
 	var arches []Arch
-	for _, target := range c.config.Targets[Android] {
+	for _, target := range c.Targets() {
 		arches = append(arches, target.Arch)
 	}
 	return arches
 }
 
+// Name returns the name of the device.
+func (c *deviceConfig) Name() string {
+	if c.name != "" {
+		return c.name
+	}
+	return String(c.config.productVariables.DeviceName)
+}
+
+// Targets returns the compilation targets of the device.
+func (c *deviceConfig) Targets() []Target {
+	if c.name != "" {
+		return c.targets
+	}
+	return c.config.Targets[Android]
+}
+
 func (c *deviceConfig) BinderBitness() string {
 	is32BitBinder := c.config.productVariables.Binder32bit
 	if is32BitBinder != nil && *is32BitBinder {
@@ -1445,25 +2004,136 @@ func (c *deviceConfig) NativeCoverageEnabledForPath(path string) bool {
 	return coverage
 }
 
+func (c *deviceConfig) NativeCoveragePaths() []string {
+	return c.config.productVariables.NativeCoveragePaths
+}
+
+func (c *deviceConfig) NativeCoverageExcludePaths() []string {
+	return c.config.productVariables.NativeCoverageExcludePaths
+}
+
 func (c *deviceConfig) PgoAdditionalProfileDirs() []string {
 	return c.config.productVariables.PgoAdditionalProfileDirs
 }
 
-// AfdoProfile returns fully qualified path associated to the given module name
-func (c *deviceConfig) AfdoProfile(name string) (*string, error) {
-	for _, afdoProfile := range c.config.productVariables.AfdoProfiles {
-		split := strings.Split(afdoProfile, ":")
+// moduleProfile returns the two fields that follow the module name in the entry of profiles for
+// the given module name, or nil if there is none. The entries of profiles have the
+// <module>:<field>:<field> format of AfdoProfiles and PropellerProfiles.
+func moduleProfile(profiles []string, name string) ([]string, error) {
+	for _, profile := range profiles {
+		split := strings.Split(profile, ":")
 		if len(split) != 3 {
-			return nil, fmt.Errorf("AFDO_PROFILES has invalid value: %s. "+
-				"The expected format is <module>:<fully-qualified-path-to-fdo_profile>", afdoProfile)
+			return nil, fmt.Errorf("invalid value: %s", profile)
 		}
 		if split[0] == name {
-			return proptools.StringPtr(strings.Join([]string{split[1], split[2]}, ":")), nil
+			return split[1:], nil
 		}
 	}
 	return nil, nil
 }
 
+// AfdoProfile returns fully qualified path associated to the given module name
+func (c *deviceConfig) AfdoProfile(name string) (*string, error) {
+	profile, err := moduleProfile(c.config.productVariables.AfdoProfiles, name)
+	if err != nil {
+		return nil, fmt.Errorf("AFDO_PROFILES has %s. "+
+			"The expected format is <module>:<fully-qualified-path-to-fdo_profile>", err.Error())
+	}
+	if profile == nil {
+		return nil, nil
+	}
+	return proptools.StringPtr(strings.Join(profile, ":")), nil
+}
+
+// PropellerProfile returns the paths of the Propeller compiler and linker profiles of the given
+// module name, or "" if the product has no Propeller profiles for it.
+func (c *deviceConfig) PropellerProfile(name string) (ccProfile, ldProfile string, err error) {
+	profile, err := moduleProfile(c.config.productVariables.PropellerProfiles, name)
+	if err != nil {
+		return "", "", fmt.Errorf("PROPELLER_PROFILES has %s. "+
+			"The expected format is <module>:<cc-profile>:<ld-profile>", err.Error())
+	}
+	if profile == nil {
+		return "", "", nil
+	}
+	return profile[0], profile[1], nil
+}
+
+// DexpreoptAppProfile returns the path of the binary profile that guides the dexpreopt of the
+// given module, or "" if the product doesn't supply one. The entries of DexpreoptAppProfiles have
+// the <module>:<path-to-profile> format, where the path is relative to the top of the tree.
+func (c *deviceConfig) DexpreoptAppProfile(name string) string {
+	for _, profile := range c.config.productVariables.DexpreoptAppProfiles {
+		if module, path, ok := strings.Cut(profile, ":"); ok && module == name {
+			return path
+		}
+	}
+	return ""
+}
+
+// DexpreoptCloudProfilesDir returns the directory of the binary profiles collected from the
+// devices in the field, named <package>.prof after the package of the app they guide the dexpreopt
+// of, or "" if the product doesn't supply one. The path is relative to the top of the tree.
+func (c *deviceConfig) DexpreoptCloudProfilesDir() string {
+	return String(c.config.productVariables.DexpreoptCloudProfilesDir)
+}
+
+// DexpreoptDisabledForPackage returns true if the given package matches one of the globs of
+// DexpreoptDisabledPackages, e.g. "com.android.browser*", which the product doesn't dexpreopt as
+// they are updated frequently. The globs are checked by Config.Validate.
+func (c *deviceConfig) DexpreoptDisabledForPackage(name string) bool {
+	for _, glob := range c.config.productVariables.DexpreoptDisabledPackages {
+		if matched, _ := filepath.Match(glob, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// BootImageProfile returns the path of the text profile that guides the compilation of the boot
+// image, relative to the top of the tree, or "" to use the default profile of the platform.
+func (c *config) BootImageProfile() string {
+	return String(c.productVariables.BootImageProfile)
+}
+
+// BootImageCompilerFilter returns the dex2oat compiler filter of the ART and framework boot
+// images, e.g. "space-profile" or "verify" to trade boot time for space on low-RAM devices, or ""
+// to use the default "speed-profile".
+func (c *config) BootImageCompilerFilter() string {
+	return String(c.productVariables.BootImageCompilerFilter)
+}
+
+// BootImageFormat returns the format of the boot image of the given architecture, one of
+// "uncompressed", "lz4" and "lz4hc", or "" to use the default. The entries of BootImageFormats
+// have the <arch>:<format> format.
+func (c *config) BootImageFormat(arch string) string {
+	for _, format := range c.productVariables.BootImageFormats {
+		if a, f, ok := strings.Cut(format, ":"); ok && a == arch {
+			return f
+		}
+	}
+	return ""
+}
+
+// GpuDriverVersion returns the version of the gpu_driver_prebuilt module selected by the product,
+// or "" to use its default version. The entries of GpuDriverVersions have the <module>:<version>
+// format.
+func (c *deviceConfig) GpuDriverVersion(name string) string {
+	for _, entry := range c.config.productVariables.GpuDriverVersions {
+		if module, version, ok := strings.Cut(entry, ":"); ok && module == name {
+			return version
+		}
+	}
+	return ""
+}
+
+// PropFiles returns the property files of the device tree that are added to the build.prop of
+// each partition, as <partition>:<path> entries where the path is relative to the top of the
+// tree.
+func (c *deviceConfig) PropFiles() []string {
+	return c.config.productVariables.PropFiles
+}
+
 func (c *deviceConfig) VendorSepolicyDirs() []string {
 	return c.config.productVariables.BoardVendorSepolicyDirs
 }
@@ -1576,13 +2246,26 @@ func (c *config) HWASanEnabledForPath(path string) bool {
 }
 
 func (c *config) VendorConfig(name string) VendorConfig {
-	return soongconfig.Config(c.productVariables.VendorVars[name])
+	vars := c.productVariables.VendorVars[name]
+	if schema := VendorConfigSchema(name); schema != nil {
+		vars = schema.ApplyDefaults(vars)
+	}
+	return soongconfig.Config(vars)
 }
 
 func (c *config) NdkAbis() bool {
 	return Bool(c.productVariables.Ndk_abis)
 }
 
+// NdkApiLevels returns the API levels the NDK stub libraries are generated for when building the
+// NDK, in addition to the current one, or nil if they are generated for every supported API level.
+func (c *config) NdkApiLevels() []string {
+	if !c.NdkAbis() {
+		return nil
+	}
+	return c.productVariables.Ndk_api_levels
+}
+
 func (c *config) AmlAbis() bool {
 	return Bool(c.productVariables.Aml_abis)
 }
@@ -1627,6 +2310,215 @@ func (c *config) InstallExtraFlattenedApexes() bool {
 	return Bool(c.productVariables.InstallExtraFlattenedApexes)
 }
 
+// EnforceApexSharedLibDedup returns true if bundling more than one copy of a native shared
+// library that has stubs into the APEXes and the platform is an error.
+func (c *config) EnforceApexSharedLibDedup() bool {
+	return Bool(c.productVariables.EnforceApexSharedLibDedup)
+}
+
+// ApexSharedLibDedupAllowlist returns the shared libraries that are allowed to be bundled more
+// than once when EnforceApexSharedLibDedup is set.
+func (c *config) ApexSharedLibDedupAllowlist() []string {
+	return c.productVariables.ApexSharedLibDedupAllowlist
+}
+
+// WarningsAllowedPaths returns the directories, in addition to the default ones, whose native
+// modules are not built with -Werror.
+func (c *config) WarningsAllowedPaths() []string {
+	return c.productVariables.WarningsAllowedPaths
+}
+
+// RestrictedFlagsAllowedPaths returns the directories, in addition to the default ones, whose
+// native modules may use the given restricted cflag or ldflag.
+func (c *config) RestrictedFlagsAllowedPaths(flag string) []string {
+	return c.productVariables.RestrictedFlagsAllowedPaths[flag]
+}
+
+// EnforceRestrictedFlags returns true if the native modules that use restricted cflags or ldflags
+// outside of the allowed directories fail the build. By default they are only reported in
+// SOONG_MODULES_USING_RESTRICTED_FLAGS, so that products can clean them up or allow them with
+// RestrictedFlagsAllowedPaths before opting in.
+func (c *config) EnforceRestrictedFlags() bool {
+	return Bool(c.productVariables.EnforceRestrictedFlags)
+}
+
+// SdclangPath returns the bin directory of the Snapdragon LLVM toolchain, or "" if the product
+// doesn't use it.
+func (c *config) SdclangPath() string {
+	return String(c.productVariables.SdclangPath)
+}
+
+// SdclangPaths returns the directories whose native modules are compiled with the Snapdragon LLVM
+// toolchain unless they set sdclang: false.
+func (c *config) SdclangPaths() []string {
+	return c.productVariables.SdclangPaths
+}
+
+// SdclangExcludePaths returns the directories of SdclangPaths whose native modules are compiled
+// with the platform clang unless they set sdclang: true.
+func (c *config) SdclangExcludePaths() []string {
+	return c.productVariables.SdclangExcludePaths
+}
+
+// CcCompilerCache returns the compiler cache that the C, C++ and assembly compilations run
+// through, "ccache" or "sccache", or "" if they don't use one. Compilations that run remotely with
+// Goma or RBE never use the compiler cache.
+func (c *config) CcCompilerCache() string {
+	if c.UseGoma() || c.UseRBE() {
+		return ""
+	}
+	return String(c.productVariables.CcCompilerCache)
+}
+
+// CcCompilerCacheDir returns the directory of the compiler cache, or "" for the default directory
+// of the compiler cache.
+func (c *config) CcCompilerCacheDir() string {
+	return String(c.productVariables.CcCompilerCacheDir)
+}
+
+// TargetGlobalExtraCflags returns the compiler flags that the product adds to the global flags of
+// all the native modules of the device.
+func (c *config) TargetGlobalExtraCflags() []string {
+	return c.productVariables.TargetGlobalExtraCflags
+}
+
+// TargetGlobalExtraLdflags returns the linker flags that the product adds to the global flags of
+// all the native modules of the device.
+func (c *config) TargetGlobalExtraLdflags() []string {
+	return c.productVariables.TargetGlobalExtraLdflags
+}
+
+// Riscv64Isa returns the ISA string, like "rv64gcv_zba_zbb_zbs", that the native modules of a
+// riscv64 device are compiled for instead of the one of the arch variant, or "" to use the one of
+// the arch variant.
+func (c *config) Riscv64Isa() string {
+	return String(c.productVariables.Riscv64Isa)
+}
+
+// SplitDwarfPaths returns the directories whose native device modules split the debug info of
+// their C and C++ sources into .dwo files, which are packaged into .dwp files for the symbols.
+func (c *config) SplitDwarfPaths() []string {
+	return c.productVariables.SplitDwarfPaths
+}
+
+// SplitDwarfExcludePaths returns the directories of SplitDwarfPaths whose native modules keep
+// their debug info in their object files.
+func (c *config) SplitDwarfExcludePaths() []string {
+	return c.productVariables.SplitDwarfExcludePaths
+}
+
+// StaticAnalysisSarifPaths returns the directories whose native modules collect the diagnostics
+// of clang-tidy and of the SDClang static analyzer into SARIF files, or nil for all of them.
+func (c *config) StaticAnalysisSarifPaths() []string {
+	return c.productVariables.StaticAnalysisSarifPaths
+}
+
+// StaticAnalysisSarifExcludePaths returns the directories of StaticAnalysisSarifPaths whose
+// native modules don't collect their diagnostics into SARIF files.
+func (c *config) StaticAnalysisSarifExcludePaths() []string {
+	return c.productVariables.StaticAnalysisSarifExcludePaths
+}
+
+// ClangPlugins returns the prebuilt clang plugins that the product loads into the compiles of the
+// native modules in their directories.
+func (c *config) ClangPlugins() []ClangPlugin {
+	return c.productVariables.ClangPlugins
+}
+
+// NetworkIsolatedActions returns true if the genrule actions run without network access, so that
+// accidental network fetches fail deterministically instead of making the build non-hermetic.
+func (c *config) NetworkIsolatedActions() bool {
+	return Bool(c.productVariables.NetworkIsolatedActions)
+}
+
+// AllowNetworkActions returns the names of the modules whose actions may opt out of the network
+// isolation with allow_network: true.
+func (c *config) AllowNetworkActions() []string {
+	return c.productVariables.AllowNetworkActions
+}
+
+// SandboxedGenrules returns true if the genrule and gensrcs modules run their commands in a
+// sandbox containing only their declared inputs unless they opt out of it.
+func (c *config) SandboxedGenrules() bool {
+	return Bool(c.productVariables.SandboxedGenrules)
+}
+
+// UnsandboxedGenrules returns the names of the genrule and gensrcs modules that don't run in a
+// sandbox yet when SandboxedGenrules is set, because they use undeclared inputs.
+func (c *config) UnsandboxedGenrules() []string {
+	return c.productVariables.UnsandboxedGenrules
+}
+
+// GenruleRemoteExecutionPaths returns the directories whose genrule and gensrcs modules run their
+// commands remotely with RBE by default when the build uses it.
+func (c *config) GenruleRemoteExecutionPaths() []string {
+	return c.productVariables.GenruleRemoteExecutionPaths
+}
+
+// actionPool returns the product configuration of the action pool with the given name, or nil if
+// the product doesn't configure it.
+func (c *config) actionPool(name string) *ActionPoolConfig {
+	for i := range c.productVariables.ActionPools {
+		if c.productVariables.ActionPools[i].Name == name {
+			return &c.productVariables.ActionPools[i]
+		}
+	}
+	return nil
+}
+
+// OutputAttestationLockfile returns the path of the checked-in lockfile with the expected hashes
+// of the installed files of OutputAttestationModules, or "" if they aren't attested.
+func (c *config) OutputAttestationLockfile() string {
+	return String(c.productVariables.OutputAttestationLockfile)
+}
+
+// OutputAttestationModules returns the names of the critical modules, like the boot jars, the
+// apexes and the key system libraries, whose installed files are attested against
+// OutputAttestationLockfile.
+func (c *config) OutputAttestationModules() []string {
+	return c.productVariables.OutputAttestationModules
+}
+
+// KernelModuleSigningKey returns the path of the private key, in PEM format, that the kernel
+// modules that are signed are signed with by default, or "" if the product doesn't set it.
+func (c *config) KernelModuleSigningKey() string {
+	return String(c.productVariables.KernelModuleSigningKey)
+}
+
+// KernelModuleSigningCert returns the path of the X.509 certificate of KernelModuleSigningKey.
+func (c *config) KernelModuleSigningCert() string {
+	return String(c.productVariables.KernelModuleSigningCert)
+}
+
+// ImageInstallTags returns the install tags enabled in the images of the product, see the
+// install_tags property of the modules.
+func (c *config) ImageInstallTags() []string {
+	return c.productVariables.ImageInstallTags
+}
+
+// WarningsAsErrorsPaths returns the directories whose native modules are built with -Werror even
+// if they are in a directory where warnings are allowed.
+func (c *config) WarningsAsErrorsPaths() []string {
+	return c.productVariables.WarningsAsErrorsPaths
+}
+
+// WarningBaseline returns the directory and the baseline file of the entry of WarningBaselines
+// for the closest directory containing dir, and false if there is none. The entries have the
+// <dir>:<baseline-file> format, and the baseline files the number of warnings of the native
+// sources of each directory under dir, which must not increase.
+func (c *config) WarningBaseline(dir string) (baselineDir, baselineFile string, ok bool) {
+	longest := -1
+	for _, entry := range c.productVariables.WarningBaselines {
+		entryDir, file, _ := strings.Cut(entry, ":")
+		prefix := strings.TrimSuffix(entryDir, "/") + "/"
+		if strings.HasPrefix(dir+"/", prefix) && len(prefix) > longest {
+			baselineDir, baselineFile, ok = strings.TrimSuffix(entryDir, "/"), file, true
+			longest = len(prefix)
+		}
+	}
+	return baselineDir, baselineFile, ok
+}
+
 func (c *config) ProductHiddenAPIStubs() []string {
 	return c.productVariables.ProductHiddenAPIStubs
 }
@@ -1683,6 +2575,39 @@ func (c *deviceConfig) BoardKernelBinaries() []string {
 	return c.config.productVariables.BoardKernelBinaries
 }
 
+// StgAbiMonitoring returns "enforce" or "warn" if the ABI of the NDK and LLNDK libraries is
+// monitored with STG, failing the build or only warning on incompatible changes, or "" if not.
+func (c *deviceConfig) StgAbiMonitoring() string {
+	return String(c.config.productVariables.StgAbiMonitoring)
+}
+
+// StgAbiReferenceDir returns the directory of the reference STG ABI dumps of the NDK and LLNDK
+// libraries, in <arch>/<lib>.stg.
+func (c *deviceConfig) StgAbiReferenceDir() string {
+	return StringDefault(c.config.productVariables.StgAbiReferenceDir, "prebuilts/abi-dumps/stg")
+}
+
+func (c *deviceConfig) SystemSharedLibsOverrides() []SystemSharedLibsOverride {
+	return c.config.productVariables.SystemSharedLibsOverrides
+}
+
+// SystemSharedLibsReplacements returns the replacements of the default system shared libraries
+// for the native modules in dir, from the SystemSharedLibsOverrides entry with the deepest
+// matching path, or nil if there are none.
+func (c *deviceConfig) SystemSharedLibsReplacements(dir string) map[string]string {
+	var replacements map[string]string
+	longest := -1
+	for _, override := range c.config.productVariables.SystemSharedLibsOverrides {
+		for _, path := range override.Paths {
+			path = filepath.Clean(path)
+			if len(path) > longest && isAncestor(path, dir) {
+				replacements, longest = override.Replacements, len(path)
+			}
+		}
+	}
+	return replacements
+}
+
 func (c *deviceConfig) BoardKernelModuleInterfaceVersions() []string {
 	return c.config.productVariables.BoardKernelModuleInterfaceVersions
 }
@@ -1862,6 +2787,10 @@ func (c *deviceConfig) BuildBrokenVendorPropertyNamespace() bool {
 	return c.config.productVariables.BuildBrokenVendorPropertyNamespace
 }
 
+func (c *deviceConfig) BuildBrokenSyspropConflicts() bool {
+	return c.config.productVariables.BuildBrokenSyspropConflicts
+}
+
 func (c *deviceConfig) BuildBrokenInputDir(name string) bool {
 	return InList(name, c.config.productVariables.BuildBrokenInputDirModules)
 }
@@ -1933,6 +2862,14 @@ func (c *config) LogMixedBuild(ctx BaseModuleContext, useBazel bool) {
 	}
 }
 
+// LogDexpreoptCloudProfile records that the app is dexpreopted with its profile in the
+// DexpreoptCloudProfilesDir directory of the product.
+func (c *config) LogDexpreoptCloudProfile(ctx BaseModuleContext) {
+	c.dexpreoptCloudProfilesLock.Lock()
+	defer c.dexpreoptCloudProfilesLock.Unlock()
+	c.dexpreoptCloudProfiledApps[ctx.Module().Name()] = struct{}{}
+}
+
 // ApiSurfaces directory returns the source path inside the api_surfaces repo
 // (relative to workspace root).
 func (c *config) ApiSurfacesDir(s ApiSurface, version string) string {
@@ -0,0 +1,302 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// The caches of the decoded configuration, next to the product variables file.
+// They let incremental runs of soong_build skip decoding soong.variables and its
+// fragments, and the dexpreopt config, when none of them changed.
+//
+// A cache file holds a gob stream of the key of its inputs, the paths of the
+// pointers to zero values in the decoded value, and the decoded value. gob
+// doesn't distinguish between a nil pointer and a pointer to a zero value, so
+// the latter are restored from their paths after decoding.
+const productVariablesCacheFileName = productVariablesFileName + ".cache"
+
+// The directory next to the product variables file holding the caches written by
+// DecodeJSONWithCache.
+const configCacheDirName = "config_cache"
+
+// writeTypeLayout writes the layout of t to h, recursing into the types of its
+// fields and elements, so that a cache is invalidated when soong_build itself
+// changes the types it decodes.
+func writeTypeLayout(h io.Writer, t reflect.Type, seen map[reflect.Type]bool) {
+	fmt.Fprintln(h, t.String())
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			fmt.Fprintln(h, t.Field(i).Name)
+			writeTypeLayout(h, t.Field(i).Type, seen)
+		}
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		writeTypeLayout(h, t.Elem(), seen)
+	case reflect.Map:
+		writeTypeLayout(h, t.Key(), seen)
+		writeTypeLayout(h, t.Elem(), seen)
+	}
+}
+
+func newConfigCacheHash(t reflect.Type) hash.Hash {
+	h := sha256.New()
+	writeTypeLayout(h, t, make(map[reflect.Type]bool))
+	return h
+}
+
+// productVariablesCacheKey returns a hash of the contents of the product variables
+// file and its fragments, and of the layout of the productVariables struct and of
+// the registered product variables.
+func productVariablesCacheKey(filename string, fragments []string) (string, error) {
+	h := newConfigCacheHash(reflect.TypeOf(productVariables{}))
+	for _, v := range registeredProductVariablesLayout() {
+		fmt.Fprintln(h, v)
+	}
+
+	for _, input := range append([]string{filename}, fragments...) {
+		f, err := os.Open(input)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintln(h, input)
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// loadProductVariablesCache decodes the product variables from the cache file if
+// it was written for key, returning false if the cache is missing or stale.
+func loadProductVariablesCache(configurable *productVariables, cacheFile, key string) bool {
+	var cached productVariables
+	if !loadConfigCache(cacheFile, key, &cached) {
+		return false
+	}
+	*configurable = cached
+	return true
+}
+
+// saveProductVariablesCache writes the decoded product variables to the cache file.
+func saveProductVariablesCache(configurable *productVariables, cacheFile, key string) error {
+	return saveConfigCache(cacheFile, key, configurable)
+}
+
+// productVariablesCacheFile returns the path of the product variables cache file.
+func (c *config) productVariablesCacheFile() string {
+	return filepath.Join(filepath.Dir(c.ProductVariablesFileName), productVariablesCacheFileName)
+}
+
+// configCacheDir returns the path of the directory of the caches written by DecodeJSONWithCache.
+func (c *config) configCacheDir() string {
+	return filepath.Join(filepath.Dir(c.ProductVariablesFileName), configCacheDirName)
+}
+
+// DecodeJSONWithCache decodes the JSON data into v, a pointer, like json.Unmarshal. The decoded
+// value is cached next to the product variables file in config_cache/<name>.cache, so that the
+// next runs of soong_build skip decoding the same data. It is meant for the large configuration
+// files that soong_build reads on every run, like the dexpreopt config. Failing to write the
+// cache is only logged.
+func (c Config) DecodeJSONWithCache(name string, data []byte, v interface{}) error {
+	cacheDir := absolutePath(c.configCacheDir())
+	cacheFile := filepath.Join(cacheDir, name+".cache")
+	h := newConfigCacheHash(reflect.TypeOf(v))
+	h.Write(data)
+	key := hex.EncodeToString(h.Sum(nil))
+
+	if loadConfigCache(cacheFile, key, v) {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	err := os.MkdirAll(cacheDir, 0777)
+	if err == nil {
+		err = saveConfigCache(cacheFile, key, v)
+	}
+	if err != nil {
+		log.Printf("warning: %s is not cached: %s", name, err)
+	}
+	return nil
+}
+
+// InvalidateConfigCache removes the caches of the decoded product variables and of the
+// configuration files decoded with DecodeJSONWithCache, forcing the next run of soong_build to
+// decode them again.
+func (c Config) InvalidateConfigCache() error {
+	err := os.Remove(absolutePath(c.productVariablesCacheFile()))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.RemoveAll(absolutePath(c.configCacheDir()))
+}
+
+// loadConfigCache decodes the cache file into v, a pointer, if it was written for key, returning
+// false if the cache is missing or stale.
+func loadConfigCache(cacheFile, key string, v interface{}) bool {
+	f, err := os.Open(cacheFile)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	dec := gob.NewDecoder(f)
+	var cacheKey string
+	if err := dec.Decode(&cacheKey); err != nil || cacheKey != key {
+		return false
+	}
+	var zeroPointers [][]string
+	if err := dec.Decode(&zeroPointers); err != nil {
+		return false
+	}
+	value := reflect.New(reflect.TypeOf(v).Elem())
+	if err := dec.Decode(value.Interface()); err != nil {
+		return false
+	}
+	for _, path := range zeroPointers {
+		if !restoreZeroPointer(value.Elem(), path) {
+			return false
+		}
+	}
+	reflect.ValueOf(v).Elem().Set(value.Elem())
+	return true
+}
+
+// saveConfigCache writes v, a pointer, to the cache file for key.
+func saveConfigCache(cacheFile, key string, v interface{}) error {
+	var zeroPointers [][]string
+	findZeroPointers(reflect.ValueOf(v).Elem(), nil, &zeroPointers)
+
+	// Write atomically in case two copies of soong_build are running simultaneously.
+	f, err := os.CreateTemp(filepath.Dir(cacheFile), "config_cache")
+	if err != nil {
+		return fmt.Errorf("cannot create config cache %s: %s", cacheFile, err.Error())
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	enc := gob.NewEncoder(f)
+	for _, x := range []interface{}{key, zeroPointers, v} {
+		if err := enc.Encode(x); err != nil {
+			return fmt.Errorf("config cache %s could not be written: %s", cacheFile, err.Error())
+		}
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("config cache %s could not be written: %s", cacheFile, err.Error())
+	}
+	return os.Rename(f.Name(), cacheFile)
+}
+
+// findZeroPointers appends to paths the paths of the non-nil pointers to zero values in v, made of
+// the names of the struct fields, the indexes of the slice elements and the keys of the maps.
+func findZeroPointers(v reflect.Value, path []string, paths *[][]string) {
+	child := func(name string) []string {
+		return append(append([]string(nil), path...), name)
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return
+		}
+		if v.Elem().IsZero() {
+			*paths = append(*paths, path)
+			return
+		}
+		findZeroPointers(v.Elem(), path, paths)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				findZeroPointers(v.Field(i), child(v.Type().Field(i).Name), paths)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			findZeroPointers(v.Index(i), child(strconv.Itoa(i)), paths)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, k := range keys {
+			findZeroPointers(v.MapIndex(k), child(k.String()), paths)
+		}
+	}
+}
+
+// restoreZeroPointer sets the nil pointer at path in v, a settable value, to a pointer to a zero
+// value, returning false if path doesn't lead to a pointer.
+func restoreZeroPointer(v reflect.Value, path []string) bool {
+	if len(path) == 0 {
+		if v.Kind() != reflect.Pointer {
+			return false
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		return !v.IsNil() && restoreZeroPointer(v.Elem(), path)
+	case reflect.Struct:
+		field := v.FieldByName(path[0])
+		return field.IsValid() && restoreZeroPointer(field, path[1:])
+	case reflect.Slice, reflect.Array:
+		i, err := strconv.Atoi(path[0])
+		if err != nil || i < 0 || i >= v.Len() {
+			return false
+		}
+		return restoreZeroPointer(v.Index(i), path[1:])
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return false
+		}
+		key := reflect.ValueOf(path[0]).Convert(v.Type().Key())
+		elem := v.MapIndex(key)
+		if !elem.IsValid() {
+			return false
+		}
+		// Map elements aren't addressable, restore the pointer in a copy.
+		copied := reflect.New(elem.Type()).Elem()
+		copied.Set(elem)
+		if !restoreZeroPointer(copied, path[1:]) {
+			return false
+		}
+		v.SetMapIndex(key, copied)
+		return true
+	}
+	return false
+}
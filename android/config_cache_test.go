@@ -0,0 +1,148 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/blueprint/proptools"
+)
+
+func TestProductVariablesCache(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, productVariablesFileName)
+	cacheFile := filepath.Join(dir, productVariablesCacheFileName)
+	if err := os.WriteFile(filename, []byte(`{"DeviceName": "foo"}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := productVariablesCacheKey(filename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := productVariables{}
+	v.SetDefaultConfig()
+	v.DeviceName = proptools.StringPtr("foo")
+	v.Eng = proptools.BoolPtr(false)
+	v.BootJars = CreateTestConfiguredJarList([]string{"platform:framework", "com.android.art:core-oj"})
+	v.DeviceTargets = map[string]DeviceTargetVariables{
+		"foo_companion": {DeviceArch: proptools.StringPtr("arm"), DeviceArchVariant: proptools.StringPtr("")},
+	}
+	v.AAPTResourceFilters = []AAPTResourceFilter{
+		{Partitions: []string{"product"}, PreferredDensity: proptools.StringPtr("")},
+	}
+	if err := saveProductVariablesCache(&v, cacheFile, key); err != nil {
+		t.Fatal(err)
+	}
+
+	var loaded productVariables
+	if !loadProductVariablesCache(&loaded, cacheFile, key) {
+		t.Fatalf("expected cache hit")
+	}
+	AssertStringEquals(t, "DeviceName", "foo", String(loaded.DeviceName))
+	AssertDeepEquals(t, "Platform_sdk_version", v.Platform_sdk_version, loaded.Platform_sdk_version)
+	AssertDeepEquals(t, "DeviceAbi", v.DeviceAbi, loaded.DeviceAbi)
+	AssertStringEquals(t, "BootJars", "platform:framework,com.android.art:core-oj", loaded.BootJars.String())
+	if loaded.Eng == nil || *loaded.Eng {
+		t.Errorf("expected pointer to false to survive the cache, got %v", loaded.Eng)
+	}
+	AssertDeepEquals(t, "DeviceTargets", v.DeviceTargets, loaded.DeviceTargets)
+	AssertDeepEquals(t, "AAPTResourceFilters", v.AAPTResourceFilters, loaded.AAPTResourceFilters)
+
+	if err := os.WriteFile(filename, []byte(`{"DeviceName": "bar"}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := productVariablesCacheKey(filename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newKey == key {
+		t.Errorf("expected cache key to change when soong.variables changes")
+	}
+	if loadProductVariablesCache(&loaded, cacheFile, newKey) {
+		t.Errorf("expected cache miss for stale cache")
+	}
+}
+
+func TestDecodeJSONWithCache(t *testing.T) {
+	type value struct {
+		Name    *string
+		Enabled *bool
+		Nested  map[string]*struct{ Level *int }
+	}
+	config := Config{config: &config{ProductVariablesFileName: filepath.Join(t.TempDir(), productVariablesFileName)}}
+	data := []byte(`{"Name": "", "Enabled": false, "Nested": {"foo": {"Level": 0}}}`)
+
+	var expected value
+	if err := json.Unmarshal(data, &expected); err != nil {
+		t.Fatal(err)
+	}
+	for _, run := range []string{"first run", "cached run"} {
+		var v value
+		if err := config.DecodeJSONWithCache("test.json", data, &v); err != nil {
+			t.Fatal(err)
+		}
+		AssertDeepEquals(t, run, expected, v)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(config.ProductVariablesFileName), "test.json.cache")); err != nil {
+		t.Errorf("expected the cache to be written: %s", err)
+	}
+}
+
+func TestDecodeJSONWithCacheUnwritable(t *testing.T) {
+	// The cache directory can't be created under a regular file.
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	config := Config{config: &config{ProductVariablesFileName: filepath.Join(file, productVariablesFileName)}}
+
+	var v struct{ Name string }
+	if err := config.DecodeJSONWithCache("test.json", []byte(`{"Name": "foo"}`), &v); err != nil {
+		t.Fatalf("expected the cache write failure to be ignored, got %s", err)
+	}
+	AssertStringEquals(t, "Name", "foo", v.Name)
+}
+
+func TestInvalidateConfigCache(t *testing.T) {
+	dir := t.TempDir()
+	config := Config{config: &config{ProductVariablesFileName: filepath.Join(dir, productVariablesFileName)}}
+	cacheFile := filepath.Join(dir, productVariablesCacheFileName)
+	if err := os.WriteFile(cacheFile, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	var v struct{ Name string }
+	if err := config.DecodeJSONWithCache("test.json", []byte(`{"Name": "foo"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := config.InvalidateConfigCache(); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{cacheFile, filepath.Join(dir, configCacheDirName)} {
+		if _, err := os.Stat(f); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed, got %v", f, err)
+		}
+	}
+
+	// Invalidating a missing cache isn't an error.
+	if err := config.InvalidateConfigCache(); err != nil {
+		t.Errorf("unexpected error invalidating a missing cache: %s", err)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"android/soong/android/allowlists"
+)
+
+var soongBuildModeNames = map[SoongBuildMode]string{
+	AnalysisNoBazel:     "analysis_no_bazel",
+	SymlinkForest:       "symlink_forest",
+	Bp2build:            "bp2build",
+	GenerateQueryView:   "queryview",
+	ApiBp2build:         "api_bp2build",
+	GenerateModuleGraph: "module_graph",
+	GenerateDocFile:     "doc_file",
+	DumpConfig:          "dump_config",
+	BazelDevMode:        "bazel_dev",
+	BazelStagingMode:    "bazel_staging",
+	BazelProdMode:       "bazel_prod",
+}
+
+func (m SoongBuildMode) String() string {
+	if name, ok := soongBuildModeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("SoongBuildMode(%d)", int(m))
+}
+
+// effectiveConfig is the JSON representation of the fully resolved configuration
+// written by the DumpConfig build mode.
+type effectiveConfig struct {
+	BuildMode string
+
+	ProductVariables          productVariables
+	ProductVariablesFragments []string
+	BuildFlags                []BuildFlag
+
+	Targets                  map[string][]string
+	BuildOSTarget            string
+	BuildOSCommonTarget      string
+	AndroidCommonTarget      string
+	AndroidFirstDeviceTarget string
+
+	// The compilation targets of the additional devices of a multi-device product.
+	DeviceTargets map[string][]string `json:",omitempty"`
+
+	// The environment variables read while loading the configuration, without the values of the
+	// secret environment variables.
+	EnvDeps map[string]string
+
+	BazelForceEnabledModules   []string
+	BazelProdEnabledModules    []string
+	BazelStagingEnabledModules []string
+	BazelDevDisabledModules    []string
+}
+
+// DumpEffectiveConfig returns the fully resolved configuration as indented JSON, for
+// diffing the configuration of two products.
+func (c Config) DumpEffectiveConfig() ([]byte, error) {
+	targets := make(map[string][]string)
+	for os, osTargets := range c.Targets {
+		for _, target := range osTargets {
+			targets[os.Name] = append(targets[os.Name], target.String())
+		}
+	}
+
+	var deviceTargets map[string][]string
+	for name, namedTargets := range c.namedDeviceTargets {
+		if deviceTargets == nil {
+			deviceTargets = make(map[string][]string)
+		}
+		for _, target := range namedTargets {
+			deviceTargets[name] = append(deviceTargets[name], target.String())
+		}
+	}
+
+	dump := effectiveConfig{
+		BuildMode: c.BuildMode.String(),
+
+		ProductVariables:          c.productVariables,
+		ProductVariablesFragments: c.productVariablesFragments,
+		BuildFlags:                c.BuildFlags(),
+
+		Targets:                  targets,
+		BuildOSTarget:            c.BuildOSTarget.String(),
+		BuildOSCommonTarget:      c.BuildOSCommonTarget.String(),
+		AndroidCommonTarget:      c.AndroidCommonTarget.String(),
+		AndroidFirstDeviceTarget: c.AndroidFirstDeviceTarget.String(),
+		DeviceTargets:            deviceTargets,
+
+		EnvDeps: c.redactSecrets(c.EnvDeps()),
+
+		BazelForceEnabledModules:   SortedStringKeys(c.BazelModulesForceEnabledByFlag()),
+		BazelProdEnabledModules:    GetBazelEnabledModules(BazelProdMode),
+		BazelStagingEnabledModules: GetBazelEnabledModules(BazelStagingMode),
+		BazelDevDisabledModules:    SortedUniqueStrings(allowlists.MixedBuildsDisabledList),
+	}
+
+	data, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal effective config: %s", err.Error())
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDumpEffectiveConfig(t *testing.T) {
+	config := TestArchConfig(t.TempDir(), map[string]string{"FOO": "bar"}, "", nil)
+	config.Getenv("FOO")
+	config.BuildMode = DumpConfig
+
+	data, err := config.DumpEffectiveConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dump effectiveConfig
+	if err := json.Unmarshal(data, &dump); err != nil {
+		t.Fatalf("dump is not valid JSON: %s", err)
+	}
+
+	AssertStringEquals(t, "BuildMode", "dump_config", dump.BuildMode)
+	AssertStringEquals(t, "DeviceName", "test_device", String(dump.ProductVariables.DeviceName))
+	AssertStringEquals(t, "AndroidFirstDeviceTarget", "android_arm64_armv8-a", dump.AndroidFirstDeviceTarget)
+	AssertStringListContains(t, "android targets", dump.Targets["android"], "android_arm_armv7-a-neon")
+	AssertStringEquals(t, "EnvDeps", "bar", dump.EnvDeps["FOO"])
+}
+
+func TestDumpEffectiveConfigRedactsSecrets(t *testing.T) {
+	config := TestArchConfig(t.TempDir(), map[string]string{
+		"BUILD_SECRET_ENV_VARS": "SIGNING_TOKEN",
+		"SIGNING_TOKEN":         "s3cr3t-t0k3n",
+		"SIGNING_URL":           "https://signer/?token=s3cr3t-t0k3n",
+	}, "", nil)
+	config.secretEnvValues = loadSecretEnvValues(config.config)
+	config.Getenv("SIGNING_TOKEN")
+	config.Getenv("SIGNING_URL")
+
+	data, err := config.DumpEffectiveConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dump effectiveConfig
+	if err := json.Unmarshal(data, &dump); err != nil {
+		t.Fatalf("dump is not valid JSON: %s", err)
+	}
+
+	AssertStringEquals(t, "secret", "<redacted:SIGNING_TOKEN>", dump.EnvDeps["SIGNING_TOKEN"])
+	AssertStringEquals(t, "secret in another variable", "https://signer/?token=<redacted:SIGNING_TOKEN>",
+		dump.EnvDeps["SIGNING_URL"])
+	AssertStringDoesNotContain(t, "dump", string(data), "s3cr3t-t0k3n")
+}
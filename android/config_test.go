@@ -16,10 +16,13 @@ package android
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/google/blueprint/proptools"
 )
 
 func validateConfigAnnotations(configurable jsonConfigurable) (err error) {
@@ -180,3 +183,140 @@ func TestConfiguredJarList(t *testing.T) {
 		assertStringEquals(t, "apex1:jarA", list5.String())
 	})
 }
+
+func TestProductVariablesFragments(t *testing.T) {
+	writeFile := func(t *testing.T, path, contents string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("merge order", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, productVariablesFileName)
+		writeFile(t, path, `{"DeviceName": "main", "Platform_sdk_codename": "main"}`)
+		writeFile(t, filepath.Join(dir, productVariablesFragmentsDirName, "20-b.json"), `{"DeviceProduct": "b"}`)
+		writeFile(t, filepath.Join(dir, productVariablesFragmentsDirName, "10-a.json"), `{"DeviceName": "a"}`)
+		writeFile(t, filepath.Join(dir, productVariablesFragmentsDirName, "README"), `not a fragment`)
+		extra := filepath.Join(dir, "extra.json")
+		writeFile(t, extra, `{"DeviceName": "extra"}`)
+
+		fragments, err := findProductVariablesFragments(path, extra)
+		if err != nil {
+			t.Fatal(err)
+		}
+		AssertArrayString(t, "fragments", []string{
+			filepath.Join(dir, productVariablesFragmentsDirName, "10-a.json"),
+			filepath.Join(dir, productVariablesFragmentsDirName, "20-b.json"),
+			extra,
+		}, fragments)
+
+		var v productVariables
+		if err := loadFromConfigFile(&v, path, fragments...); err != nil {
+			t.Fatal(err)
+		}
+		AssertStringEquals(t, "DeviceName", "extra", String(v.DeviceName))
+		AssertStringEquals(t, "DeviceProduct", "b", String(v.DeviceProduct))
+		AssertStringEquals(t, "Platform_sdk_codename", "main", String(v.Platform_sdk_codename))
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, productVariablesFileName)
+		writeFile(t, path, `{}`)
+		writeFile(t, filepath.Join(dir, productVariablesFragmentsDirName, "a.json"), `{"DeviceName": "a", "DeviceProduct": "same"}`)
+		writeFile(t, filepath.Join(dir, productVariablesFragmentsDirName, "b.json"), `{"DeviceName": "b", "DeviceProduct": "same"}`)
+
+		fragments, err := findProductVariablesFragments(path, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v productVariables
+		err = loadFromConfigFile(&v, path, fragments...)
+		AssertErrorMessageEquals(t, "conflict", fmt.Sprintf("config file: conflicting product variables in fragments:\n"+
+			"    DeviceName: set to \"a\" by %s and to \"b\" by %s", fragments[0], fragments[1]), err)
+	})
+
+	t.Run("unknown variable", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, productVariablesFileName)
+		writeFile(t, path, `{}`)
+		fragment := filepath.Join(dir, productVariablesFragmentsDirName, "a.json")
+		writeFile(t, fragment, `{"DeviceNmae": "a"}`)
+
+		var v productVariables
+		err := loadFromConfigFile(&v, path, fragment)
+		AssertStringDoesContain(t, "unknown variable", err.Error(), `unknown field "DeviceNmae"`)
+	})
+
+	t.Run("unknown variable in the product variables file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, productVariablesFileName)
+		writeFile(t, path, `{"DeviceNmae": "a"}`)
+
+		var v productVariables
+		err := loadFromConfigFile(&v, path)
+		AssertStringDoesContain(t, "unknown variable", err.Error(), `unknown field "DeviceNmae"`)
+	})
+}
+
+func TestNamedDeviceTargets(t *testing.T) {
+	newConfig := func(deviceTargets map[string]DeviceTargetVariables) *config {
+		return &config{
+			productVariables: productVariables{
+				DeviceName:    proptools.StringPtr("phone"),
+				DeviceTargets: deviceTargets,
+			},
+		}
+	}
+
+	t.Run("decode", func(t *testing.T) {
+		c := newConfig(map[string]DeviceTargetVariables{
+			"watch": {
+				DeviceArch:                 proptools.StringPtr("arm64"),
+				DeviceArchVariant:          proptools.StringPtr("armv8-a"),
+				DeviceCpuVariant:           proptools.StringPtr("generic"),
+				DeviceAbi:                  []string{"arm64-v8a"},
+				DeviceSecondaryArch:        proptools.StringPtr("arm"),
+				DeviceSecondaryArchVariant: proptools.StringPtr("armv8-a"),
+				DeviceSecondaryCpuVariant:  proptools.StringPtr("generic"),
+				DeviceSecondaryAbi:         []string{"armeabi-v7a", "armeabi"},
+			},
+		})
+		deviceTargets, err := decodeNamedDeviceTargets(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var arches []string
+		for _, target := range deviceTargets["watch"] {
+			AssertStringEquals(t, "os", Android.Name, target.Os.Name)
+			AssertStringEquals(t, "device name", "watch", target.DeviceName)
+			arches = append(arches, target.Arch.ArchType.Name)
+		}
+		AssertArrayString(t, "arches", []string{"arm64", "arm"}, arches)
+
+		c.namedDeviceTargets = deviceTargets
+		AssertArrayString(t, "device names", []string{"phone", "watch"}, c.DeviceNames())
+		_, ok := c.DeviceTargets("tablet")
+		AssertBoolEquals(t, "unknown device", false, ok)
+	})
+
+	t.Run("duplicate of the primary device", func(t *testing.T) {
+		c := newConfig(map[string]DeviceTargetVariables{
+			"phone": {DeviceArch: proptools.StringPtr("arm64")},
+		})
+		_, err := decodeNamedDeviceTargets(c)
+		AssertErrorMessageEquals(t, "error", `DeviceTargets: "phone" is already the name of the primary device`, err)
+	})
+
+	t.Run("missing arch", func(t *testing.T) {
+		c := newConfig(map[string]DeviceTargetVariables{
+			"watch": {DeviceAbi: []string{"arm64-v8a"}},
+		})
+		_, err := decodeNamedDeviceTargets(c)
+		AssertErrorMessageEquals(t, "error", `DeviceTargets: no primary architecture set for device "watch"`, err)
+	})
+}
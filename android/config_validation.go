@@ -0,0 +1,709 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ProductVariableError describes a product variable invariant that doesn't hold.
+type ProductVariableError struct {
+	// The names of the product variables involved, and their values.
+	Variables []string
+	Values    []string
+
+	// Why the values are invalid.
+	Message string
+}
+
+func (e ProductVariableError) String() string {
+	var assignments []string
+	for i := range e.Variables {
+		assignments = append(assignments, e.Variables[i]+"="+e.Values[i])
+	}
+	return strings.Join(assignments, ", ") + ": " + e.Message
+}
+
+// ProductVariableErrors is the error returned by Config.Validate, listing every
+// invariant that doesn't hold.
+type ProductVariableErrors []ProductVariableError
+
+func (e ProductVariableErrors) Error() string {
+	var lines []string
+	for _, err := range e {
+		lines = append(lines, "    "+err.String())
+	}
+	return fmt.Sprintf("invalid product variables in %s:\n%s", productVariablesFileName, strings.Join(lines, "\n"))
+}
+
+// productVariablesCheck checks an invariant of the product variables, returning an
+// error for each violation.
+type productVariablesCheck func(v *productVariables) []ProductVariableError
+
+var productVariablesChecks = []productVariablesCheck{
+	checkCoverageVariables,
+	checkPlatformSdkVariables,
+	checkAfdoProfiles,
+	checkPropellerProfiles,
+	checkDexpreoptAppProfiles,
+	checkDexpreoptDisabledPackages,
+	checkGpuDriverVersions,
+	checkPropFiles,
+	checkTidyVariables,
+	checkWarningBaselines,
+	checkThinLTOCacheVariables,
+	checkSoongBuildMemoryLimit,
+	checkCompilerCacheVariables,
+	checkGlobalExtraFlags,
+	checkHostLinker,
+	checkRiscv64Isa,
+	checkSigningVariables,
+	checkDefaultVisibility,
+	checkModuleAliases,
+	checkVendorVars,
+	checkSystemSharedLibsOverrides,
+	checkAAPTResourceFilters,
+	checkStgAbiMonitoring,
+	checkGwpAsanVariables,
+	checkOutputAttestationVariables,
+	checkKernelModuleSigningVariables,
+	checkRegisteredProductVariables,
+	checkNdkVariables,
+}
+
+// The flags that products can't add to the global flags of the device with
+// TargetGlobalExtraCflags and TargetGlobalExtraLdflags, as they apply to every native module of
+// the device. The flags that end with "=" match all their values.
+var bannedGlobalExtraCflags, bannedGlobalExtraLdflags []string
+
+// BanGlobalExtraCflags bans flags from TargetGlobalExtraCflags. It must be called from init
+// functions, before the configuration is loaded.
+func BanGlobalExtraCflags(flags ...string) {
+	bannedGlobalExtraCflags = append(bannedGlobalExtraCflags, flags...)
+}
+
+// BanGlobalExtraLdflags bans flags from TargetGlobalExtraLdflags. It must be called from init
+// functions, before the configuration is loaded.
+func BanGlobalExtraLdflags(flags ...string) {
+	bannedGlobalExtraLdflags = append(bannedGlobalExtraLdflags, flags...)
+}
+
+// Validate checks the invariants between product variables, returning a
+// ProductVariableErrors listing every failure if any of them doesn't hold.
+func (c Config) Validate() error {
+	var errs ProductVariableErrors
+	for _, check := range productVariablesChecks {
+		errs = append(errs, check(&c.productVariables)...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func formatBoolVariable(b *bool) string {
+	if b == nil {
+		return "<unset>"
+	}
+	return fmt.Sprint(*b)
+}
+
+func formatStringVariable(s *string) string {
+	if s == nil {
+		return "<unset>"
+	}
+	return fmt.Sprintf("%q", *s)
+}
+
+func formatIntVariable(i *int) string {
+	if i == nil {
+		return "<unset>"
+	}
+	return fmt.Sprint(*i)
+}
+
+func checkCoverageVariables(v *productVariables) []ProductVariableError {
+	if Bool(v.GcovCoverage) && Bool(v.ClangCoverage) {
+		return []ProductVariableError{{
+			Variables: []string{"GcovCoverage", "ClangCoverage"},
+			Values:    []string{formatBoolVariable(v.GcovCoverage), formatBoolVariable(v.ClangCoverage)},
+			Message:   "GcovCoverage and ClangCoverage cannot both be set",
+		}}
+	}
+	return nil
+}
+
+func checkPlatformSdkVariables(v *productVariables) []ProductVariableError {
+	if Bool(v.Platform_sdk_final) && v.Platform_sdk_version == nil {
+		return []ProductVariableError{{
+			Variables: []string{"Platform_sdk_final", "Platform_sdk_version"},
+			Values:    []string{formatBoolVariable(v.Platform_sdk_final), formatIntVariable(v.Platform_sdk_version)},
+			Message:   "Platform_sdk_version must be set when Platform_sdk_final is true",
+		}}
+	}
+	return nil
+}
+
+func checkAfdoProfiles(v *productVariables) []ProductVariableError {
+	var errs []ProductVariableError
+	for _, afdoProfile := range v.AfdoProfiles {
+		if split := strings.Split(afdoProfile, ":"); len(split) != 3 || split[0] == "" {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{"AfdoProfiles"},
+				Values:    []string{fmt.Sprintf("%q", afdoProfile)},
+				Message:   "expected format is <module>:<fully-qualified-path-to-fdo_profile>",
+			})
+		}
+	}
+	return errs
+}
+
+func checkPropellerProfiles(v *productVariables) []ProductVariableError {
+	var errs []ProductVariableError
+	for _, propellerProfile := range v.PropellerProfiles {
+		if split := strings.Split(propellerProfile, ":"); len(split) != 3 || split[0] == "" ||
+			split[1] == "" || split[2] == "" {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{"PropellerProfiles"},
+				Values:    []string{fmt.Sprintf("%q", propellerProfile)},
+				Message:   "expected format is <module>:<cc-profile>:<ld-profile>",
+			})
+		}
+	}
+	return errs
+}
+
+func checkDexpreoptAppProfiles(v *productVariables) []ProductVariableError {
+	var errs []ProductVariableError
+	modules := make(map[string]bool)
+	for _, profile := range v.DexpreoptAppProfiles {
+		module, path, _ := strings.Cut(profile, ":")
+		if module == "" || path == "" {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{"DexpreoptAppProfiles"},
+				Values:    []string{fmt.Sprintf("%q", profile)},
+				Message:   "expected format is <module>:<path-to-profile>",
+			})
+		} else if modules[module] {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{"DexpreoptAppProfiles"},
+				Values:    []string{fmt.Sprintf("%q", profile)},
+				Message:   fmt.Sprintf("module %q already has a profile", module),
+			})
+		}
+		modules[module] = true
+	}
+	return errs
+}
+
+func checkDexpreoptDisabledPackages(v *productVariables) []ProductVariableError {
+	var errs []ProductVariableError
+	for _, glob := range v.DexpreoptDisabledPackages {
+		if _, err := filepath.Match(glob, ""); err != nil {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{"DexpreoptDisabledPackages"},
+				Values:    []string{fmt.Sprintf("%q", glob)},
+				Message:   "invalid glob: " + err.Error(),
+			})
+		}
+	}
+	return errs
+}
+
+func checkGpuDriverVersions(v *productVariables) []ProductVariableError {
+	var errs []ProductVariableError
+	modules := make(map[string]bool)
+	for _, entry := range v.GpuDriverVersions {
+		module, version, _ := strings.Cut(entry, ":")
+		if module == "" || version == "" {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{"GpuDriverVersions"},
+				Values:    []string{fmt.Sprintf("%q", entry)},
+				Message:   "expected format is <module>:<version>",
+			})
+		} else if modules[module] {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{"GpuDriverVersions"},
+				Values:    []string{fmt.Sprintf("%q", entry)},
+				Message:   fmt.Sprintf("module %q already has a version", module),
+			})
+		}
+		modules[module] = true
+	}
+	return errs
+}
+
+func checkPropFiles(v *productVariables) []ProductVariableError {
+	var errs []ProductVariableError
+	for _, entry := range v.PropFiles {
+		partition, path, _ := strings.Cut(entry, ":")
+		if !InList(partition, syspropPartitions) || path == "" {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{"PropFiles"},
+				Values:    []string{fmt.Sprintf("%q", entry)},
+				Message:   fmt.Sprintf("expected format is <partition>:<path>, where partition is one of %s", strings.Join(syspropPartitions, ", ")),
+			})
+		}
+	}
+	return errs
+}
+
+func checkTidyVariables(v *productVariables) []ProductVariableError {
+	var errs []ProductVariableError
+	for _, profile := range v.TidyProfiles {
+		if split := strings.Split(profile, ":"); len(split) != 3 || split[0] == "" || split[1] == "" {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{"TidyProfiles"},
+				Values:    []string{fmt.Sprintf("%q", profile)},
+				Message:   "expected format is <dir>:<checks>:<checks-as-errors>",
+			})
+		}
+	}
+	for _, baseline := range v.TidyBaselines {
+		if split := strings.Split(baseline, ":"); len(split) != 2 || split[0] == "" || split[1] == "" {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{"TidyBaselines"},
+				Values:    []string{fmt.Sprintf("%q", baseline)},
+				Message:   "expected format is <dir>:<baseline-file>",
+			})
+		}
+	}
+	return errs
+}
+
+func checkWarningBaselines(v *productVariables) []ProductVariableError {
+	var errs []ProductVariableError
+	for _, baseline := range v.WarningBaselines {
+		if split := strings.Split(baseline, ":"); len(split) != 2 || split[0] == "" || split[1] == "" {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{"WarningBaselines"},
+				Values:    []string{fmt.Sprintf("%q", baseline)},
+				Message:   "expected format is <dir>:<baseline-file>",
+			})
+		}
+	}
+	return errs
+}
+
+var (
+	bytesRegexp                = regexp.MustCompile(`^[0-9]+[kmg]?$`)
+	thinLTOCacheDurationRegexp = regexp.MustCompile(`^[0-9]+[smh]$`)
+)
+
+func checkThinLTOCacheVariables(v *productVariables) []ProductVariableError {
+	var errs []ProductVariableError
+	if p := v.ThinLTOCacheSizePercent; p != nil && (*p < 1 || *p > 100) {
+		errs = append(errs, ProductVariableError{
+			Variables: []string{"ThinLTOCacheSizePercent"},
+			Values:    []string{formatIntVariable(p)},
+			Message:   "must be between 1 and 100",
+		})
+	}
+	if b := v.ThinLTOCacheSizeBytes; b != nil && !bytesRegexp.MatchString(*b) {
+		errs = append(errs, ProductVariableError{
+			Variables: []string{"ThinLTOCacheSizeBytes"},
+			Values:    []string{formatStringVariable(b)},
+			Message:   "expected a number of bytes with an optional k, m or g suffix",
+		})
+	}
+	durations := []struct {
+		name  string
+		value *string
+	}{
+		{"ThinLTOCachePruneInterval", v.ThinLTOCachePruneInterval},
+		{"ThinLTOCachePruneAfter", v.ThinLTOCachePruneAfter},
+	}
+	for _, d := range durations {
+		if d.value != nil && !thinLTOCacheDurationRegexp.MatchString(*d.value) {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{d.name},
+				Values:    []string{formatStringVariable(d.value)},
+				Message:   "expected a duration with an s, m or h suffix",
+			})
+		}
+	}
+	return errs
+}
+
+func checkSoongBuildMemoryLimit(v *productVariables) []ProductVariableError {
+	if b := v.SoongBuildMemoryLimit; b != nil && !bytesRegexp.MatchString(*b) {
+		return []ProductVariableError{{
+			Variables: []string{"SoongBuildMemoryLimit"},
+			Values:    []string{formatStringVariable(b)},
+			Message:   "expected a number of bytes with an optional k, m or g suffix",
+		}}
+	}
+	return nil
+}
+
+func checkGlobalExtraFlags(v *productVariables) []ProductVariableError {
+	return append(checkGlobalExtraFlagList("TargetGlobalExtraCflags", v.TargetGlobalExtraCflags, bannedGlobalExtraCflags),
+		checkGlobalExtraFlagList("TargetGlobalExtraLdflags", v.TargetGlobalExtraLdflags, bannedGlobalExtraLdflags)...)
+}
+
+func checkGlobalExtraFlagList(variable string, flags []string, banned []string) []ProductVariableError {
+	var errs []ProductVariableError
+	for _, flag := range flags {
+		if !strings.HasPrefix(flag, "-") {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{variable},
+				Values:    []string{fmt.Sprintf("%q", flag)},
+				Message:   "flags must start with `-`",
+			})
+			continue
+		}
+		for _, b := range banned {
+			if flag == b || (strings.HasSuffix(b, "=") && strings.HasPrefix(flag, b)) {
+				errs = append(errs, ProductVariableError{
+					Variables: []string{variable},
+					Values:    []string{fmt.Sprintf("%q", flag)},
+					Message:   "the flag is not allowed in the global flags of a device",
+				})
+				break
+			}
+		}
+	}
+	return errs
+}
+
+func checkHostLinker(v *productVariables) []ProductVariableError {
+	switch String(v.HostLinker) {
+	case "", "lld", "mold":
+		return nil
+	default:
+		return []ProductVariableError{{
+			Variables: []string{"HostLinker"},
+			Values:    []string{formatStringVariable(v.HostLinker)},
+			Message:   "expected lld or mold",
+		}}
+	}
+}
+
+func checkCompilerCacheVariables(v *productVariables) []ProductVariableError {
+	switch String(v.CcCompilerCache) {
+	case "":
+		if v.CcCompilerCacheDir != nil {
+			return []ProductVariableError{{
+				Variables: []string{"CcCompilerCache", "CcCompilerCacheDir"},
+				Values:    []string{formatStringVariable(v.CcCompilerCache), formatStringVariable(v.CcCompilerCacheDir)},
+				Message:   "CcCompilerCacheDir can only be set with CcCompilerCache",
+			}}
+		}
+	case "ccache", "sccache":
+	default:
+		return []ProductVariableError{{
+			Variables: []string{"CcCompilerCache"},
+			Values:    []string{formatStringVariable(v.CcCompilerCache)},
+			Message:   "expected one of ccache, sccache",
+		}}
+	}
+	return nil
+}
+
+var riscv64IsaRegexp = regexp.MustCompile(`^rv64[a-z]+(_[a-z][a-z0-9]*)*$`)
+
+func checkRiscv64Isa(v *productVariables) []ProductVariableError {
+	if v.Riscv64Isa == nil {
+		return nil
+	}
+	isa := *v.Riscv64Isa
+	if !riscv64IsaRegexp.MatchString(isa) {
+		return []ProductVariableError{{
+			Variables: []string{"Riscv64Isa"},
+			Values:    []string{formatStringVariable(v.Riscv64Isa)},
+			Message:   "expected an ISA string like rv64gcv_zba_zbb_zbs",
+		}}
+	}
+	if String(v.DeviceArch) != Riscv64.Name {
+		return []ProductVariableError{{
+			Variables: []string{"Riscv64Isa", "DeviceArch"},
+			Values:    []string{formatStringVariable(v.Riscv64Isa), formatStringVariable(v.DeviceArch)},
+			Message:   "Riscv64Isa can only be set for riscv64 devices",
+		}}
+	}
+
+	// Modules rely on the arch features of the arch variant, which the ISA string must keep.
+	extensions := strings.Split(isa, "_")
+	features := androidArchFeatureMap[Riscv64][String(v.DeviceArchVariant)]
+	var missing []string
+	if InList("rvv", features) && !strings.Contains(strings.TrimPrefix(extensions[0], "rv64"), "v") {
+		missing = append(missing, "v")
+	}
+	if InList("zb", features) {
+		missing = append(missing, RemoveListFromList([]string{"zba", "zbb", "zbs"}, extensions[1:])...)
+	}
+	if len(missing) > 0 {
+		return []ProductVariableError{{
+			Variables: []string{"Riscv64Isa", "DeviceArchVariant"},
+			Values:    []string{formatStringVariable(v.Riscv64Isa), formatStringVariable(v.DeviceArchVariant)},
+			Message:   "missing the extensions " + strings.Join(missing, ", ") + " of the arch variant",
+		}}
+	}
+	return nil
+}
+
+func checkSigningVariables(v *productVariables) []ProductVariableError {
+	name := StringDefault(v.Signing_backend, "local")
+	if _, ok := signingBackends[name]; !ok {
+		return []ProductVariableError{{
+			Variables: []string{"Signing_backend"},
+			Values:    []string{formatStringVariable(v.Signing_backend)},
+			Message:   "expected one of " + strings.Join(SortedStringKeys(signingBackends), ", "),
+		}}
+	}
+	if name == "hsm" && String(v.Signing_hsm_provider_class) == "" {
+		return []ProductVariableError{{
+			Variables: []string{"Signing_backend", "Signing_hsm_provider_class"},
+			Values:    []string{formatStringVariable(v.Signing_backend), formatStringVariable(v.Signing_hsm_provider_class)},
+			Message:   "the hsm signing backend requires Signing_hsm_provider_class",
+		}}
+	}
+	return nil
+}
+
+func checkDefaultVisibility(v *productVariables) []ProductVariableError {
+	var errs []ProductVariableError
+	for _, dir := range SortedStringKeys(v.DefaultVisibility) {
+		visibility := v.DefaultVisibility[dir]
+		if _, err := parseDefaultVisibility(normalizeDefaultVisibilityDir(dir), visibility); err != nil {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{"DefaultVisibility[" + dir + "]"},
+				Values:    []string{fmt.Sprintf("%q", visibility)},
+				Message:   err.Error(),
+			})
+		}
+	}
+	return errs
+}
+
+// isNamespaceAlias returns true if name is the "//path" of a namespace rather than the name of a
+// module.
+func isNamespaceAlias(name string) bool {
+	return strings.HasPrefix(name, "//") && !strings.Contains(name, ":")
+}
+
+func checkModuleAliases(v *productVariables) []ProductVariableError {
+	var errs []ProductVariableError
+	for _, oldName := range SortedStringKeys(v.ModuleAliases) {
+		newName := v.ModuleAliases[oldName]
+		var message string
+		switch {
+		case oldName == "" || newName == "":
+			message = "module names must not be empty"
+		case oldName == newName:
+			message = "a module must not be an alias of itself"
+		case isNamespaceAlias(oldName) != isNamespaceAlias(newName):
+			message = "the path of a namespace must be an alias of the path of a namespace"
+		default:
+			if _, chained := v.ModuleAliases[newName]; chained {
+				message = fmt.Sprintf("%q is itself an alias, use the name it is an alias of", newName)
+			}
+		}
+		if message != "" {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{"ModuleAliases[" + oldName + "]"},
+				Values:    []string{fmt.Sprintf("%q", newName)},
+				Message:   message,
+			})
+		}
+	}
+	return errs
+}
+
+func checkVendorVars(v *productVariables) []ProductVariableError {
+	var errs []ProductVariableError
+	for _, namespace := range SortedStringKeys(v.VendorVars) {
+		schema := VendorConfigSchema(namespace)
+		if schema == nil {
+			continue
+		}
+		vars := v.VendorVars[namespace]
+		for _, err := range schema.Validate(vars) {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{"VendorVars[" + namespace + "]"},
+				Values:    []string{fmt.Sprintf("%q", vars)},
+				Message:   err.Error(),
+			})
+		}
+	}
+	return errs
+}
+
+var replaceableSystemSharedLibs = []string{"libc", "libm", "libdl"}
+
+func checkSystemSharedLibsOverrides(v *productVariables) []ProductVariableError {
+	var errs []ProductVariableError
+	seenPaths := make(map[string]bool)
+	for i, override := range v.SystemSharedLibsOverrides {
+		variable := fmt.Sprintf("SystemSharedLibsOverrides[%d]", i)
+		if len(override.Paths) == 0 {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{variable + ".Paths"},
+				Values:    []string{"[]"},
+				Message:   "must list at least one directory",
+			})
+		}
+		for _, path := range override.Paths {
+			path = filepath.Clean(path)
+			if seenPaths[path] {
+				errs = append(errs, ProductVariableError{
+					Variables: []string{variable + ".Paths"},
+					Values:    []string{fmt.Sprintf("%q", path)},
+					Message:   "directory is listed in more than one override",
+				})
+			}
+			seenPaths[path] = true
+		}
+		for _, lib := range SortedStringKeys(override.Replacements) {
+			if !InList(lib, replaceableSystemSharedLibs) {
+				errs = append(errs, ProductVariableError{
+					Variables: []string{variable + ".Replacements"},
+					Values:    []string{fmt.Sprintf("%q", lib)},
+					Message:   fmt.Sprintf("only %s can be replaced", strings.Join(replaceableSystemSharedLibs, ", ")),
+				})
+			} else if override.Replacements[lib] == "" {
+				errs = append(errs, ProductVariableError{
+					Variables: []string{variable + ".Replacements"},
+					Values:    []string{fmt.Sprintf("%q", lib)},
+					Message:   "replacement must not be empty",
+				})
+			}
+		}
+	}
+	return errs
+}
+
+func checkAAPTResourceFilters(v *productVariables) []ProductVariableError {
+	var errs []ProductVariableError
+	seenApps, seenPartitions := make(map[string]bool), make(map[string]bool)
+	checkDuplicates := func(variable string, names []string, seen map[string]bool) {
+		for _, name := range names {
+			if seen[name] {
+				errs = append(errs, ProductVariableError{
+					Variables: []string{variable},
+					Values:    []string{fmt.Sprintf("%q", name)},
+					Message:   "is listed in more than one filter",
+				})
+			}
+			seen[name] = true
+		}
+	}
+	for i, filter := range v.AAPTResourceFilters {
+		variable := fmt.Sprintf("AAPTResourceFilters[%d]", i)
+		if len(filter.Apps) == 0 && len(filter.Partitions) == 0 {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{variable + ".Apps", variable + ".Partitions"},
+				Values:    []string{"[]", "[]"},
+				Message:   "must list at least one app or partition",
+			})
+		}
+		checkDuplicates(variable+".Apps", filter.Apps, seenApps)
+		checkDuplicates(variable+".Partitions", filter.Partitions, seenPartitions)
+	}
+	return errs
+}
+
+func checkStgAbiMonitoring(v *productVariables) []ProductVariableError {
+	if mode := String(v.StgAbiMonitoring); mode != "" && mode != "warn" && mode != "enforce" {
+		return []ProductVariableError{{
+			Variables: []string{"StgAbiMonitoring"},
+			Values:    []string{formatStringVariable(v.StgAbiMonitoring)},
+			Message:   "must be warn or enforce",
+		}}
+	}
+	return nil
+}
+
+func checkGwpAsanVariables(v *productVariables) []ProductVariableError {
+	var errs []ProductVariableError
+	variables := []struct {
+		name  string
+		value *int
+	}{
+		{"Gwp_asan_sample_rate", v.Gwp_asan_sample_rate},
+		{"Gwp_asan_max_allocs", v.Gwp_asan_max_allocs},
+	}
+	for _, variable := range variables {
+		if variable.value != nil && *variable.value < 1 {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{variable.name},
+				Values:    []string{formatIntVariable(variable.value)},
+				Message:   "must be at least 1",
+			})
+		}
+	}
+	return errs
+}
+
+func checkOutputAttestationVariables(v *productVariables) []ProductVariableError {
+	if len(v.OutputAttestationModules) > 0 && String(v.OutputAttestationLockfile) == "" {
+		return []ProductVariableError{{
+			Variables: []string{"OutputAttestationModules", "OutputAttestationLockfile"},
+			Values:    []string{fmt.Sprintf("%q", v.OutputAttestationModules), formatStringVariable(v.OutputAttestationLockfile)},
+			Message:   "OutputAttestationModules requires an OutputAttestationLockfile",
+		}}
+	}
+	return nil
+}
+
+func checkKernelModuleSigningVariables(v *productVariables) []ProductVariableError {
+	if (String(v.KernelModuleSigningKey) == "") != (String(v.KernelModuleSigningCert) == "") {
+		return []ProductVariableError{{
+			Variables: []string{"KernelModuleSigningKey", "KernelModuleSigningCert"},
+			Values:    []string{formatStringVariable(v.KernelModuleSigningKey), formatStringVariable(v.KernelModuleSigningCert)},
+			Message:   "KernelModuleSigningKey and KernelModuleSigningCert must be set together",
+		}}
+	}
+	return nil
+}
+
+func checkNdkVariables(v *productVariables) []ProductVariableError {
+	var errs []ProductVariableError
+	if !Bool(v.Ndk_abis) {
+		if len(v.Ndk_abis_list) > 0 || len(v.Ndk_api_levels) > 0 {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{"Ndk_abis", "Ndk_abis_list", "Ndk_api_levels"},
+				Values: []string{formatBoolVariable(v.Ndk_abis), fmt.Sprintf("%q", v.Ndk_abis_list),
+					fmt.Sprintf("%q", v.Ndk_api_levels)},
+				Message: "Ndk_abis_list and Ndk_api_levels can only be set when building the NDK with Ndk_abis",
+			})
+		}
+		return errs
+	}
+	for _, level := range v.Ndk_api_levels {
+		if InList(level, v.Platform_version_active_codenames) {
+			continue
+		}
+		n, err := strconv.Atoi(level)
+		if err != nil {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{"Ndk_api_levels"},
+				Values:    []string{fmt.Sprintf("%q", level)},
+				Message:   "must be an API level number or one of Platform_version_active_codenames",
+			})
+		} else if n < 1 || (v.Platform_sdk_version != nil && n > *v.Platform_sdk_version) {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{"Ndk_api_levels", "Platform_sdk_version"},
+				Values:    []string{fmt.Sprintf("%q", level), formatIntVariable(v.Platform_sdk_version)},
+				Message:   "NDK API levels must be between 1 and Platform_sdk_version",
+			})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,333 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+
+	"github.com/google/blueprint/proptools"
+)
+
+func TestConfigValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		modify   func(v *productVariables)
+		expected string
+	}{
+		{
+			name:   "valid",
+			modify: func(v *productVariables) {},
+		},
+		{
+			name: "all failures",
+			modify: func(v *productVariables) {
+				v.GcovCoverage = proptools.BoolPtr(true)
+				v.ClangCoverage = proptools.BoolPtr(true)
+				v.Platform_sdk_final = proptools.BoolPtr(true)
+				v.Platform_sdk_version = nil
+				v.AfdoProfiles = []string{"foo:path/to/foo.afdo", "bar:path:to:bar.afdo", ":path/to/baz.afdo"}
+				v.Signing_backend = proptools.StringPtr("hsm")
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    GcovCoverage=true, ClangCoverage=true: GcovCoverage and ClangCoverage cannot both be set\n" +
+				"    Platform_sdk_final=true, Platform_sdk_version=<unset>: Platform_sdk_version must be set when Platform_sdk_final is true\n" +
+				"    AfdoProfiles=\"foo:path/to/foo.afdo\": expected format is <module>:<fully-qualified-path-to-fdo_profile>\n" +
+				"    AfdoProfiles=\"bar:path:to:bar.afdo\": expected format is <module>:<fully-qualified-path-to-fdo_profile>\n" +
+				"    Signing_backend=\"hsm\", Signing_hsm_provider_class=<unset>: the hsm signing backend requires Signing_hsm_provider_class",
+		},
+		{
+			name: "invalid propeller profiles",
+			modify: func(v *productVariables) {
+				v.PropellerProfiles = []string{"foo:foo_cc.txt:foo_ld.txt", "bar:bar_cc.txt", "baz::baz_ld.txt"}
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    PropellerProfiles=\"bar:bar_cc.txt\": expected format is <module>:<cc-profile>:<ld-profile>\n" +
+				"    PropellerProfiles=\"baz::baz_ld.txt\": expected format is <module>:<cc-profile>:<ld-profile>",
+		},
+		{
+			name: "invalid dexpreopt app profiles",
+			modify: func(v *productVariables) {
+				v.DexpreoptAppProfiles = []string{"foo:foo.prof", "bar:", ":baz.prof", "foo:other/foo.prof"}
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    DexpreoptAppProfiles=\"bar:\": expected format is <module>:<path-to-profile>\n" +
+				"    DexpreoptAppProfiles=\":baz.prof\": expected format is <module>:<path-to-profile>\n" +
+				"    DexpreoptAppProfiles=\"foo:other/foo.prof\": module \"foo\" already has a profile",
+		},
+		{
+			name: "invalid dexpreopt disabled packages",
+			modify: func(v *productVariables) {
+				v.DexpreoptDisabledPackages = []string{"com.android.browser*", "com.acme.[a-"}
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    DexpreoptDisabledPackages=\"com.acme.[a-\": invalid glob: syntax error in pattern",
+		},
+		{
+			name: "invalid gpu driver versions",
+			modify: func(v *productVariables) {
+				v.GpuDriverVersions = []string{"AcmeGpuDriver:2023.1", "OtherDriver", "AcmeGpuDriver:2023.2"}
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    GpuDriverVersions=\"OtherDriver\": expected format is <module>:<version>\n" +
+				"    GpuDriverVersions=\"AcmeGpuDriver:2023.2\": module \"AcmeGpuDriver\" already has a version",
+		},
+		{
+			name: "invalid prop files",
+			modify: func(v *productVariables) {
+				v.PropFiles = []string{"vendor:device/acme/foo/vendor.prop", "boot:device/acme/foo/boot.prop", "odm:"}
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    PropFiles=\"boot:device/acme/foo/boot.prop\": expected format is <partition>:<path>, where partition is one of system, system_ext, product, vendor, odm\n" +
+				"    PropFiles=\"odm:\": expected format is <partition>:<path>, where partition is one of system, system_ext, product, vendor, odm",
+		},
+		{
+			name: "invalid tidy variables",
+			modify: func(v *productVariables) {
+				v.TidyProfiles = []string{"vendor/acme:-*,bugprone-*:bugprone-*", "device/acme:cert-*", ":cert-*:"}
+				v.TidyBaselines = []string{"vendor/acme:vendor/acme/tidy_baseline.txt", "device/acme"}
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    TidyProfiles=\"device/acme:cert-*\": expected format is <dir>:<checks>:<checks-as-errors>\n" +
+				"    TidyProfiles=\":cert-*:\": expected format is <dir>:<checks>:<checks-as-errors>\n" +
+				"    TidyBaselines=\"device/acme\": expected format is <dir>:<baseline-file>",
+		},
+		{
+			name: "invalid warning baselines",
+			modify: func(v *productVariables) {
+				v.WarningBaselines = []string{"vendor/acme:vendor/acme/warning_baseline.txt", "device/acme", ":baseline.txt"}
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    WarningBaselines=\"device/acme\": expected format is <dir>:<baseline-file>\n" +
+				"    WarningBaselines=\":baseline.txt\": expected format is <dir>:<baseline-file>",
+		},
+		{
+			name: "invalid thinlto cache policy",
+			modify: func(v *productVariables) {
+				v.ThinLTOCacheSizePercent = intPtr(0)
+				v.ThinLTOCacheSizeBytes = proptools.StringPtr("10GB")
+				v.ThinLTOCachePruneInterval = proptools.StringPtr("20m")
+				v.ThinLTOCachePruneAfter = proptools.StringPtr("1w")
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    ThinLTOCacheSizePercent=0: must be between 1 and 100\n" +
+				"    ThinLTOCacheSizeBytes=\"10GB\": expected a number of bytes with an optional k, m or g suffix\n" +
+				"    ThinLTOCachePruneAfter=\"1w\": expected a duration with an s, m or h suffix",
+		},
+		{
+			name: "invalid soong_build memory limit",
+			modify: func(v *productVariables) {
+				v.SoongBuildMemoryLimit = proptools.StringPtr("12GB")
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    SoongBuildMemoryLimit=\"12GB\": expected a number of bytes with an optional k, m or g suffix",
+		},
+		{
+			name: "unknown compiler cache",
+			modify: func(v *productVariables) {
+				v.CcCompilerCache = proptools.StringPtr("distcc")
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    CcCompilerCache=\"distcc\": expected one of ccache, sccache",
+		},
+		{
+			name: "compiler cache dir without compiler cache",
+			modify: func(v *productVariables) {
+				v.CcCompilerCacheDir = proptools.StringPtr("/ssd/ccache")
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    CcCompilerCache=<unset>, CcCompilerCacheDir=\"/ssd/ccache\": CcCompilerCacheDir can only be set with CcCompilerCache",
+		},
+		{
+			name: "valid riscv64 isa",
+			modify: func(v *productVariables) {
+				v.DeviceArch = proptools.StringPtr("riscv64")
+				v.DeviceArchVariant = proptools.StringPtr("rv64gcv-zb")
+				v.Riscv64Isa = proptools.StringPtr("rv64gcv_zba_zbb_zbs_zicond")
+			},
+		},
+		{
+			name: "invalid riscv64 isa",
+			modify: func(v *productVariables) {
+				v.Riscv64Isa = proptools.StringPtr("RV64GCV")
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    Riscv64Isa=\"RV64GCV\": expected an ISA string like rv64gcv_zba_zbb_zbs",
+		},
+		{
+			name: "riscv64 isa for another arch",
+			modify: func(v *productVariables) {
+				v.Riscv64Isa = proptools.StringPtr("rv64gcv")
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    Riscv64Isa=\"rv64gcv\", DeviceArch=\"arm64\": Riscv64Isa can only be set for riscv64 devices",
+		},
+		{
+			name: "riscv64 isa missing extensions of the arch variant",
+			modify: func(v *productVariables) {
+				v.DeviceArch = proptools.StringPtr("riscv64")
+				v.DeviceArchVariant = proptools.StringPtr("rv64gcv-zb")
+				v.Riscv64Isa = proptools.StringPtr("rv64gc_zbb")
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    Riscv64Isa=\"rv64gc_zbb\", DeviceArchVariant=\"rv64gcv-zb\": missing the extensions v, zba, zbs of the arch variant",
+		},
+		{
+			name: "unknown signing backend",
+			modify: func(v *productVariables) {
+				v.Signing_backend = proptools.StringPtr("hms")
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    Signing_backend=\"hms\": expected one of hsm, local, remote",
+		},
+		{
+			name: "invalid default visibility",
+			modify: func(v *productVariables) {
+				v.DefaultVisibility = map[string][]string{
+					"device":         {"//device:__subpackages__"},
+					"device/google":  {"//visibility:override"},
+					"vendor/example": {"//visibility:private", "//vendor:__subpackages__"},
+				}
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    DefaultVisibility[device/google]=[\"//visibility:override\"]: unrecognized visibility rule \"//visibility:override\"\n" +
+				"    DefaultVisibility[vendor/example]=[\"//visibility:private\" \"//vendor:__subpackages__\"]: cannot mix \"//visibility:private\" with any other visibility rules",
+		},
+		{
+			name: "invalid module aliases",
+			modify: func(v *productVariables) {
+				v.ModuleAliases = map[string]string{
+					"libfoo":            "libbar",
+					"libbar":            "libbaz",
+					"libqux":            "libqux",
+					"//vendor/old":      "//vendor/new:libfoo",
+					"//vendor/old:libx": "//vendor/new:libx",
+				}
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    ModuleAliases[//vendor/old]=\"//vendor/new:libfoo\": the path of a namespace must be an alias of the path of a namespace\n" +
+				"    ModuleAliases[libfoo]=\"libbar\": \"libbar\" is itself an alias, use the name it is an alias of\n" +
+				"    ModuleAliases[libqux]=\"libqux\": a module must not be an alias of itself",
+		},
+		{
+			name: "invalid aapt resource filters",
+			modify: func(v *productVariables) {
+				v.AAPTResourceFilters = []AAPTResourceFilter{
+					{Partitions: []string{"product"}, Apps: []string{"Foo"}, Locales: []string{"en_US"}},
+					{Densities: []string{"xhdpi"}},
+					{Partitions: []string{"product", "system_ext"}, Apps: []string{"Foo"}},
+				}
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    AAPTResourceFilters[1].Apps=[], AAPTResourceFilters[1].Partitions=[]: must list at least one app or partition\n" +
+				"    AAPTResourceFilters[2].Apps=\"Foo\": is listed in more than one filter\n" +
+				"    AAPTResourceFilters[2].Partitions=\"product\": is listed in more than one filter",
+		},
+		{
+			name: "invalid stg abi monitoring",
+			modify: func(v *productVariables) {
+				v.StgAbiMonitoring = proptools.StringPtr("fail")
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    StgAbiMonitoring=\"fail\": must be warn or enforce",
+		},
+		{
+			name: "invalid gwp-asan sampling",
+			modify: func(v *productVariables) {
+				v.Gwp_asan_sample_rate = intPtr(0)
+				v.Gwp_asan_max_allocs = intPtr(16)
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    Gwp_asan_sample_rate=0: must be at least 1",
+		},
+		{
+			name: "output attestation without lockfile",
+			modify: func(v *productVariables) {
+				v.OutputAttestationModules = []string{"framework", "com.android.art"}
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    OutputAttestationModules=[\"framework\" \"com.android.art\"], OutputAttestationLockfile=<unset>: " +
+				"OutputAttestationModules requires an OutputAttestationLockfile",
+		},
+		{
+			name: "kernel module signing key without certificate",
+			modify: func(v *productVariables) {
+				v.KernelModuleSigningKey = proptools.StringPtr("device/google/signing_key.pem")
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    KernelModuleSigningKey=\"device/google/signing_key.pem\", KernelModuleSigningCert=<unset>: " +
+				"KernelModuleSigningKey and KernelModuleSigningCert must be set together",
+		},
+		{
+			name: "unknown host linker",
+			modify: func(v *productVariables) {
+				v.HostLinker = proptools.StringPtr("gold")
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    HostLinker=\"gold\": expected lld or mold",
+		},
+		{
+			name: "invalid system shared libs overrides",
+			modify: func(v *productVariables) {
+				v.SystemSharedLibsOverrides = []SystemSharedLibsOverride{
+					{
+						Paths:        []string{"vendor/experiments"},
+						Replacements: map[string]string{"libc": "libc_experimental", "libz": "libz_experimental"},
+					},
+					{
+						Paths:        []string{"vendor/experiments/"},
+						Replacements: map[string]string{"libm": ""},
+					},
+				}
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    SystemSharedLibsOverrides[0].Replacements=\"libz\": only libc, libm, libdl can be replaced\n" +
+				"    SystemSharedLibsOverrides[1].Paths=\"vendor/experiments\": directory is listed in more than one override\n" +
+				"    SystemSharedLibsOverrides[1].Replacements=\"libm\": replacement must not be empty",
+		},
+		{
+			name: "ndk api levels without ndk abis",
+			modify: func(v *productVariables) {
+				v.Ndk_api_levels = []string{"21"}
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    Ndk_abis=<unset>, Ndk_abis_list=[], Ndk_api_levels=[\"21\"]: Ndk_abis_list and Ndk_api_levels can only be set when building the NDK with Ndk_abis",
+		},
+		{
+			name: "invalid ndk api levels",
+			modify: func(v *productVariables) {
+				v.Ndk_abis = proptools.BoolPtr(true)
+				v.Ndk_api_levels = []string{"21", "S", "31", "foo"}
+			},
+			expected: "invalid product variables in soong.variables:\n" +
+				"    Ndk_api_levels=\"31\", Platform_sdk_version=30: NDK API levels must be between 1 and Platform_sdk_version\n" +
+				"    Ndk_api_levels=\"foo\": must be an API level number or one of Platform_version_active_codenames",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := TestConfig(t.TempDir(), nil, "", nil)
+			tc.modify(&config.productVariables)
+			err := config.Validate()
+			if tc.expected == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+			AssertErrorMessageEquals(t, "validation error", tc.expected, err)
+		})
+	}
+}
@@ -228,6 +228,17 @@ func (l *ConfiguredJarList) MarshalJSON() ([]byte, error) {
 	return json.Marshal(list)
 }
 
+// GobEncode encodes the ConfiguredJarList for the product variables cache, which
+// can't encode the unexported fields directly.
+func (l ConfiguredJarList) GobEncode() ([]byte, error) {
+	return l.MarshalJSON()
+}
+
+// GobDecode decodes a ConfiguredJarList encoded by GobEncode.
+func (l *ConfiguredJarList) GobDecode(b []byte) error {
+	return l.UnmarshalJSON(b)
+}
+
 // ModuleStem hardcodes the stem of framework-minus-apex to return "framework".
 //
 // TODO(b/139391334): hard coded until we find a good way to query the stem of a
@@ -194,6 +194,7 @@ func InitDefaultsModule(module DefaultsModule) {
 	InitBazelModule(module)
 	initAndroidModuleBase(module)
 	initProductVariableModule(module)
+	initBuildFlagModule(module)
 	initArchModule(module)
 	InitDefaultableModule(module)
 
@@ -137,6 +137,9 @@ func init() {
 	pctx.VariableFunc("RBEWrapper", func(ctx PackageVarContext) string {
 		return ctx.Config().RBEWrapper()
 	})
+	pctx.VariableFunc("RBEWrapperFlags", func(ctx PackageVarContext) string {
+		return ctx.Config().RBEWrapperFlags()
+	})
 
 	exportedVars.ExportStringList("NeverAllowNotInIncludeDir", neverallowNotInIncludeDir)
 	exportedVars.ExportStringList("NeverAllowNoUseIncludeDir", neverallowNoUseIncludeDir)
@@ -0,0 +1,108 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// The golden files are the files checked in the source tree that are also generated by the build
+// and checked against it, like the API files of the droidstubs modules. After large merges,
+// branch maintainers can refresh all of them at once: `m update-golden-files` copies the generated
+// files over the checked-in ones, and writes the diff of the updated files to
+// out/soong/golden_files/updated.diff. `m golden-files-diff` only writes the diff of the
+// checked-in and the generated files to out/soong/golden_files/golden_files.diff.
+
+func init() {
+	RegisterSingletonType("golden_files", goldenFilesSingletonFactory)
+}
+
+// GoldenFile is a file checked in the source tree that is also generated by the build.
+type GoldenFile struct {
+	// The file in the source tree.
+	CheckedIn Path
+
+	// The file generated by the build that the checked-in file must match.
+	Generated Path
+}
+
+// GoldenFilesProducer is implemented by the modules whose outputs are also checked in, so that
+// `m update-golden-files` updates them.
+type GoldenFilesProducer interface {
+	GoldenFiles() []GoldenFile
+}
+
+func goldenFilesSingletonFactory() Singleton {
+	return &goldenFilesSingleton{}
+}
+
+type goldenFilesSingleton struct{}
+
+func (s *goldenFilesSingleton) GenerateBuildActions(ctx SingletonContext) {
+	var files []GoldenFile
+	seen := make(map[string]bool)
+	ctx.VisitAllModules(func(module Module) {
+		producer, ok := module.(GoldenFilesProducer)
+		if !ok || !module.Enabled() {
+			return
+		}
+		for _, file := range producer.GoldenFiles() {
+			// The variants of a module may have the same golden files, the first one updates them.
+			if !seen[file.CheckedIn.String()] {
+				seen[file.CheckedIn.String()] = true
+				files = append(files, file)
+			}
+		}
+	})
+	if len(files) == 0 {
+		return
+	}
+
+	// Diff command line, showing the added and removed files too.
+	diff := func(rule *RuleBuilder, file GoldenFile, diffFile WritablePath) *RuleBuilderCommand {
+		return rule.Command().
+			Text("(diff -u -N").
+			Text(file.CheckedIn.String()).
+			Text(file.Generated.String()).
+			Text("|| true) >>").
+			Text(diffFile.String()).
+			Implicit(file.Generated)
+	}
+
+	diffFile := PathForOutput(ctx, "golden_files", "golden_files.diff")
+	rule := NewRuleBuilder(pctx, ctx)
+	rule.Command().Text("rm -f").Output(diffFile)
+	for _, file := range files {
+		diff(rule, file, diffFile).Implicit(file.CheckedIn)
+	}
+	rule.Build("golden_files_diff", "diff golden files")
+	ctx.Phony("golden-files-diff", diffFile)
+
+	// The checked-in files aren't inputs of the update, which writes them.
+	updatedDiff := PathForOutput(ctx, "golden_files", "updated.diff")
+	rule = NewRuleBuilder(pctx, ctx)
+	rule.Command().Text("rm -f").Output(updatedDiff)
+	for _, file := range files {
+		diff(rule, file, updatedDiff)
+		rule.Command().Text("mkdir -p").Text(filepath.Dir(file.CheckedIn.String()))
+		rule.Command().Text("cp -f").Text(file.Generated.String()).Text(file.CheckedIn.String())
+	}
+	rule.Command().
+		Text(fmt.Sprintf(`echo "Updated $(grep -c '^+++ ' %s) of %d golden files, see %s"`,
+			updatedDiff, len(files), updatedDiff))
+	rule.Build("update_golden_files", "update golden files")
+	ctx.Phony("update-golden-files", updatedDiff)
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+)
+
+type goldenFileTestModule struct {
+	ModuleBase
+	properties struct {
+		Golden_file *string `android:"path"`
+	}
+	goldenFiles []GoldenFile
+}
+
+func goldenFileTestModuleFactory() Module {
+	m := &goldenFileTestModule{}
+	m.AddProperties(&m.properties)
+	InitAndroidModule(m)
+	return m
+}
+
+func (m *goldenFileTestModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+	generated := PathForModuleOut(ctx, "golden.txt")
+	WriteFileRule(ctx, generated, ctx.ModuleName())
+	m.goldenFiles = []GoldenFile{{PathForModuleSrc(ctx, String(m.properties.Golden_file)), generated}}
+}
+
+func (m *goldenFileTestModule) GoldenFiles() []GoldenFile {
+	return m.goldenFiles
+}
+
+func TestGoldenFiles(t *testing.T) {
+	t.Parallel()
+	result := GroupFixturePreparers(
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("golden_file", goldenFileTestModuleFactory)
+			ctx.RegisterSingletonType("golden_files", goldenFilesSingletonFactory)
+		}),
+		FixtureAddFile("foo/golden.txt", nil),
+		FixtureAddFile("bar/golden.txt", nil),
+	).RunTestWithBp(t, `
+		golden_file {
+			name: "foo",
+			golden_file: "foo/golden.txt",
+		}
+		golden_file {
+			name: "bar",
+			golden_file: "bar/golden.txt",
+		}
+	`)
+	singleton := result.SingletonForTests("golden_files")
+
+	diff := singleton.Rule("golden_files_diff")
+	AssertStringDoesContain(t, "diff command", diff.RuleParams.Command,
+		"(diff -u -N foo/golden.txt out/soong/.intermediates/foo/golden.txt || true) >> out/soong/golden_files/golden_files.diff")
+	AssertStringListContains(t, "diff inputs", PathsRelativeToTop(diff.Implicits), "bar/golden.txt")
+
+	update := singleton.Rule("update_golden_files")
+	AssertPathRelativeToTopEquals(t, "update output", "out/soong/golden_files/updated.diff", update.Output)
+	AssertStringDoesContain(t, "update command", update.RuleParams.Command,
+		"cp -f out/soong/.intermediates/bar/golden.txt bar/golden.txt")
+	AssertStringListDoesNotContain(t, "update inputs", PathsRelativeToTop(update.Implicits), "bar/golden.txt")
+}
@@ -0,0 +1,256 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The init .rc files listed in the init_rc property of modules are checked in two steps:
+//
+// During analysis, the structure of each source .rc file is parsed with the same section rules as
+// init so that a malformed file fails the build instead of being silently ignored at boot.
+//
+// During the build, the check-init-rc phony target runs host_init_verifier on the .rc files of
+// all device modules, which also checks the commands, the service options and the users, groups
+// and properties that they refer to.
+
+func init() {
+	RegisterSingletonType("init_rc_verifier", initRcVerifierSingletonFactory)
+}
+
+// initRcSection is a section of an init .rc file.
+type initRcSection struct {
+	keyword string
+	args    []string
+	line    int
+}
+
+// initRcSetprop is a setprop command of an action of an init .rc file.
+type initRcSetprop struct {
+	name  string
+	value string
+	line  int
+}
+
+// initRcFile is the result of parsing an init .rc file.
+type initRcFile struct {
+	sections []initRcSection
+	setprops []initRcSetprop
+}
+
+// tokenizeInitRc splits the contents of an init .rc file into lines of arguments, following the
+// rules of init: arguments are separated by whitespace, can be quoted and can contain escaped
+// characters, lines can be continued with a trailing backslash and comments start with a '#' at
+// the start of an argument. It returns the line number at which each line starts.
+func tokenizeInitRc(contents string) (lines [][]string, lineNumbers []int, err error) {
+	var args []string
+	var arg strings.Builder
+	inArg, inQuote, inComment := false, false, false
+	line, startLine := 1, 1
+
+	endArg := func() {
+		if inArg {
+			args = append(args, arg.String())
+			arg.Reset()
+			inArg = false
+		}
+	}
+	endLine := func() {
+		endArg()
+		if len(args) > 0 {
+			lines = append(lines, args)
+			lineNumbers = append(lineNumbers, startLine)
+			args = nil
+		}
+	}
+
+	for i := 0; i < len(contents); i++ {
+		c := contents[i]
+		if inComment {
+			if c == '\n' {
+				inComment = false
+				endLine()
+				line++
+				startLine = line
+			}
+			continue
+		}
+		switch {
+		case c == '\\' && i+1 < len(contents):
+			i++
+			if contents[i] == '\n' {
+				// Line continuation.
+				line++
+				endArg()
+				continue
+			}
+			inArg = true
+			arg.WriteByte(contents[i])
+		case c == '"':
+			inArg = true
+			inQuote = !inQuote
+		case inQuote:
+			if c == '\n' {
+				return nil, nil, fmt.Errorf("%d: unterminated quoted string", startLine)
+			}
+			arg.WriteByte(c)
+		case c == '#' && !inArg:
+			inComment = true
+		case c == '\n':
+			endLine()
+			line++
+			startLine = line
+		case c == ' ' || c == '\t' || c == '\r':
+			endArg()
+		default:
+			inArg = true
+			arg.WriteByte(c)
+		}
+	}
+	if inQuote {
+		return nil, nil, fmt.Errorf("%d: unterminated quoted string", startLine)
+	}
+	endLine()
+	return lines, lineNumbers, nil
+}
+
+// parseInitRc parses the sections of an init .rc file and returns the errors that init would
+// report for it, prefixed with the name of the file and the line number.
+func parseInitRc(name string, r io.Reader) (*initRcFile, []error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, []error{fmt.Errorf("%s: %s", name, err.Error())}
+	}
+	lines, lineNumbers, err := tokenizeInitRc(string(data))
+	if err != nil {
+		return nil, []error{fmt.Errorf("%s:%s", name, err.Error())}
+	}
+
+	file := &initRcFile{}
+	var errs []error
+	errorf := func(line int, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s:%d: %s", name, line, fmt.Sprintf(format, args...)))
+	}
+
+	services := make(map[string]int)
+	inSection, inAction := false, false
+	for i, args := range lines {
+		line := lineNumbers[i]
+		switch args[0] {
+		case "on":
+			if len(args) < 2 {
+				errorf(line, "actions must have a trigger")
+			}
+		case "service":
+			if len(args) < 3 {
+				errorf(line, "services must have a name and a program")
+			} else if prev, exists := services[args[1]]; exists {
+				errorf(line, "duplicate definition of service %q, first defined at line %d", args[1], prev)
+			} else {
+				services[args[1]] = line
+			}
+		case "import":
+			if len(args) != 2 {
+				errorf(line, "single argument needed for import")
+			}
+		default:
+			if !inSection {
+				errorf(line, "invalid section keyword %q found", args[0])
+			} else if inAction && args[0] == "setprop" && len(args) == 3 {
+				file.setprops = append(file.setprops, initRcSetprop{name: args[1], value: args[2], line: line})
+			}
+			continue
+		}
+		inSection = true
+		inAction = args[0] == "on"
+		file.sections = append(file.sections, initRcSection{keyword: args[0], args: args[1:], line: line})
+	}
+	return file, errs
+}
+
+// checkInitRcFiles parses the source init .rc files of the module and reports the errors in them.
+// Generated .rc files are only checked by host_init_verifier during the build. It returns the
+// properties set by the actions of the files, which are checked for conflicts by the
+// sysprop_conflicts singleton.
+func checkInitRcFiles(ctx ModuleContext, paths Paths) []SyspropDefinition {
+	var setprops []SyspropDefinition
+	for _, path := range paths {
+		if _, ok := path.(SourcePath); !ok {
+			continue
+		}
+		f, err := ctx.Config().fs.Open(path.String())
+		if err != nil {
+			// Missing files are reported by PathsForModuleSrc.
+			continue
+		}
+		ctx.AddNinjaFileDeps(path.String())
+		file, errs := parseInitRc(path.String(), f)
+		f.Close()
+		for _, err := range errs {
+			ctx.PropertyErrorf("init_rc", "%s", err.Error())
+		}
+		if file == nil {
+			continue
+		}
+		for _, setprop := range file.setprops {
+			// Properties whose name is only known at boot can't be checked.
+			if strings.Contains(setprop.name, "$") {
+				continue
+			}
+			setprops = append(setprops, SyspropDefinition{
+				Name:      setprop.name,
+				Value:     setprop.value,
+				Partition: ctx.Module().base().PartitionTag(ctx.DeviceConfig()),
+				Module:    ctx.ModuleName(),
+				Source:    fmt.Sprintf("%s:%d", path.String(), setprop.line),
+			})
+		}
+	}
+	return setprops
+}
+
+func initRcVerifierSingletonFactory() Singleton {
+	return &initRcVerifierSingleton{}
+}
+
+type initRcVerifierSingleton struct{}
+
+// GenerateBuildActions adds the check-init-rc phony target, which runs host_init_verifier on the
+// init .rc files of all the modules installed on the device.
+func (s *initRcVerifierSingleton) GenerateBuildActions(ctx SingletonContext) {
+	var rcFiles Paths
+	ctx.VisitAllModules(func(module Module) {
+		if module.Enabled() && module.Os() == Android {
+			rcFiles = append(rcFiles, module.InitRc()...)
+		}
+	})
+	if len(rcFiles) == 0 {
+		return
+	}
+	rcFiles = SortedUniquePaths(rcFiles)
+
+	stamp := PathForOutput(ctx, "init_rc_verifier", "check-init-rc.stamp")
+	rule := NewRuleBuilder(pctx, ctx)
+	for _, rcFile := range rcFiles {
+		rule.Command().BuiltTool("host_init_verifier").Input(rcFile)
+	}
+	rule.Command().Text("touch").Output(stamp)
+	rule.Build("init_rc_verifier", "verify init rc files")
+
+	ctx.Phony("check-init-rc", stamp)
+}
@@ -0,0 +1,135 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseInitRc(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rc       string
+		sections []string
+		setprops []initRcSetprop
+		errs     []string
+	}{
+		{
+			name: "valid",
+			rc: `
+# A comment
+import /vendor/etc/init/hw/init.${ro.hardware}.rc
+
+on early-init && property:ro.debuggable=1
+    write /proc/sys/kernel/printk "4 4 1 7" # trailing comment
+    setprop sys.foo.ready 1
+
+service foo /system/bin/foo \
+        --flag
+    class main
+    user system
+`,
+			sections: []string{"import", "on", "service"},
+			setprops: []initRcSetprop{{name: "sys.foo.ready", value: "1", line: 7}},
+		},
+		{
+			name:     "command outside of a section",
+			rc:       "setprop foo bar\non boot\n",
+			sections: []string{"on"},
+			errs:     []string{`test.rc:1: invalid section keyword "setprop" found`},
+		},
+		{
+			name: "invalid sections",
+			rc: `
+on
+service foo
+import a b
+service bar /system/bin/bar
+service bar /system/bin/bar2
+`,
+			sections: []string{"on", "service", "import", "service", "service"},
+			errs: []string{
+				"test.rc:2: actions must have a trigger",
+				"test.rc:3: services must have a name and a program",
+				"test.rc:4: single argument needed for import",
+				`test.rc:6: duplicate definition of service "bar", first defined at line 5`,
+			},
+		},
+		{
+			name: "unterminated quote",
+			rc:   "on boot\n    write /dev/foo \"bar\n",
+			errs: []string{"test.rc:2: unterminated quoted string"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file, errs := parseInitRc("test.rc", strings.NewReader(tc.rc))
+			var errStrings []string
+			for _, err := range errs {
+				errStrings = append(errStrings, err.Error())
+			}
+			AssertDeepEquals(t, "errors", tc.errs, errStrings)
+
+			var sections []string
+			var setprops []initRcSetprop
+			if file != nil {
+				for _, section := range file.sections {
+					sections = append(sections, section.keyword)
+				}
+				setprops = file.setprops
+			}
+			AssertDeepEquals(t, "sections", tc.sections, sections)
+			AssertDeepEquals(t, "setprops", tc.setprops, setprops)
+		})
+	}
+}
+
+var prepareForInitRcTest = GroupFixturePreparers(
+	prepareForModuleTests,
+	FixtureRegisterWithContext(func(ctx RegistrationContext) {
+		ctx.RegisterSingletonType("init_rc_verifier", initRcVerifierSingletonFactory)
+	}),
+	FixtureMergeMockFs(MockFS{
+		"foo.rc": []byte("service foo /system/bin/foo\n    class main\n"),
+		"bar.rc": []byte("class main\n"),
+	}),
+)
+
+func TestInitRcVerifier(t *testing.T) {
+	result := prepareForInitRcTest.RunTestWithBp(t, `
+		deps {
+			name: "foo",
+			init_rc: ["foo.rc"],
+		}
+	`)
+
+	rule := result.SingletonForTests("init_rc_verifier").Rule("init_rc_verifier")
+	AssertStringDoesContain(t, "command", rule.RuleParams.Command, "host_init_verifier foo.rc")
+	AssertPathRelativeToTopEquals(t, "stamp", "out/soong/init_rc_verifier/check-init-rc.stamp", rule.Output)
+}
+
+func TestInitRcErrors(t *testing.T) {
+	prepareForInitRcTest.
+		ExtendWithErrorHandler(FixtureExpectsAtLeastOneErrorMatchingPattern(
+			`init_rc: bar.rc:1: invalid section keyword "class" found`)).
+		RunTestWithBp(t, `
+			deps {
+				name: "bar",
+				init_rc: ["bar.rc"],
+			}
+		`)
+}
@@ -104,6 +104,8 @@ func collectMetrics(config Config, eventHandler *metrics.EventHandler) *soong_me
 	mixedBuildsInfo.MixedBuildDisabledModules = mixedBuildDisabledModules
 	metrics.MixedBuildsInfo = &mixedBuildsInfo
 
+	metrics.DexpreoptCloudProfiledApps = proto.Uint32(uint32(len(config.dexpreoptCloudProfiledApps)))
+
 	return metrics
 }
 
@@ -25,6 +25,7 @@ import (
 	"sort"
 	"strings"
 	"text/scanner"
+	"time"
 
 	"android/soong/bazel"
 
@@ -360,6 +361,13 @@ type BaseModuleContext interface {
 	// AddMissingBp2buildDep stores the module name of a direct dependency that was not found.
 	AddMissingBp2buildDep(dep string)
 
+	// AddBp2buildBlocker stores a reason why bp2build doesn't convert the module.
+	AddBp2buildBlocker(reason string)
+
+	// AddUnsupportedBp2buildProperty stores a property of the module that its bp2build converter
+	// doesn't support yet.
+	AddUnsupportedBp2buildProperty(property, reason string)
+
 	Target() Target
 	TargetPrimary() bool
 
@@ -562,6 +570,7 @@ type Module interface {
 	Bp2buildTargets() []bp2buildInfo
 	GetUnconvertedBp2buildDeps() []string
 	GetMissingBp2buildDeps() []string
+	GetBp2buildBlockers() []string
 
 	BuildParamsForTests() []BuildParams
 	RuleParamsForTests() map[blueprint.Rule]blueprint.RuleParams
@@ -725,6 +734,17 @@ type commonProperties struct {
 	// and so prevent early detection of changes that have broken those modules.
 	Enabled *bool `android:"arch_variant"`
 
+	// If set to true, also build a linux_musl variant of the module for the host when the host
+	// modules are built against glibc, e.g. for host tools that are shipped to other machines.
+	// Binaries of the variant are static by default, and the dependencies of the module must set
+	// host_musl: true too. The variant is installed in out/host/linux_musl-<arch>.
+	Host_musl *bool
+
+	// Install tags of the module, e.g. "debug", "eng" or "factory". The module is only packaged in
+	// the images of the products whose ImageInstallTags contain all of them, and so are the
+	// dependencies that are only installed because of the module.
+	Install_tags []string
+
 	// Controls the visibility of this module to other modules. Allowable values are one or more of
 	// these formats:
 	//
@@ -1160,6 +1180,7 @@ func InitAndroidModule(m Module) {
 		&base.distProperties)
 
 	initProductVariableModule(m)
+	initBuildFlagModule(m)
 
 	// The default_visibility property needs to be checked and parsed by the visibility module during
 	// its checking and parsing phases so make it the primary visibility property.
@@ -1456,6 +1477,7 @@ type ModuleBase struct {
 	commonProperties        commonProperties
 	distProperties          distProperties
 	variableProperties      interface{}
+	buildFlagProperties     *buildFlagProperties
 	hostAndDeviceProperties hostAndDeviceProperties
 
 	// Arch specific versions of structs in GetProperties() prior to
@@ -1492,6 +1514,10 @@ type ModuleBase struct {
 	// The files to copy to the dist as explicitly specified in the .bp file.
 	distFiles TaggedDistFiles
 
+	// The dependencies that were missing when the module generated its build actions, with
+	// AllowMissingDependencies, for the build health summary.
+	missingDeps []string
+
 	// Used by buildTargetSingleton to create checkbuild and per-directory build targets
 	// Only set on the final variant of each module
 	installTarget    WritablePath
@@ -1508,6 +1534,7 @@ type ModuleBase struct {
 	variables   map[string]string
 
 	initRcPaths         Paths
+	initRcSetprops      []SyspropDefinition
 	vintfFragmentsPaths Paths
 
 	// set of dependency module:location mappings used to populate the license metadata for
@@ -1579,6 +1606,18 @@ func (b *baseModuleContext) AddMissingBp2buildDep(dep string) {
 	*missingDeps = append(*missingDeps, dep)
 }
 
+// AddBp2buildBlocker stores a reason why bp2build doesn't convert the module.
+func (b *baseModuleContext) AddBp2buildBlocker(reason string) {
+	blockers := &b.Module().base().commonProperties.BazelConversionStatus.Blockers
+	*blockers = append(*blockers, reason)
+}
+
+// AddUnsupportedBp2buildProperty stores a property of the module that its bp2build converter
+// doesn't support yet.
+func (b *baseModuleContext) AddUnsupportedBp2buildProperty(property, reason string) {
+	b.AddBp2buildBlocker(fmt.Sprintf("unsupported property %s: %s", property, reason))
+}
+
 // GetUnconvertedBp2buildDeps returns the list of module names of this module's direct dependencies that
 // were not converted to Bazel.
 func (m *ModuleBase) GetUnconvertedBp2buildDeps() []string {
@@ -1590,6 +1629,12 @@ func (m *ModuleBase) GetMissingBp2buildDeps() []string {
 	return FirstUniqueStrings(m.commonProperties.BazelConversionStatus.MissingDeps)
 }
 
+// GetBp2buildBlockers returns the reasons why bp2build doesn't convert the module, other than its
+// dependencies.
+func (m *ModuleBase) GetBp2buildBlockers() []string {
+	return FirstUniqueStrings(m.commonProperties.BazelConversionStatus.Blockers)
+}
+
 func (m *ModuleBase) AddJSONData(d *map[string]interface{}) {
 	(*d)["Android"] = map[string]interface{}{
 		// Properties set in Blueprint or in blueprint of a defaults modules
@@ -1719,23 +1764,28 @@ func (m *ModuleBase) BuildParamsForTests() []BuildParams {
 	for i := range buildParams {
 		newArgs := make(map[string]string)
 		for k, v := range buildParams[i].Args {
-			newArgs[k] = v
-			// Replaces both ${flags1} and $flags1 syntax.
-			if strings.HasPrefix(v, "${") && strings.HasSuffix(v, "}") {
-				if value, found := vars[v[2:len(v)-1]]; found {
-					newArgs[k] = value
-				}
-			} else if strings.HasPrefix(v, "$") {
-				if value, found := vars[v[1:]]; found {
-					newArgs[k] = value
-				}
-			}
+			newArgs[k] = expandModuleVariablesForTests(v, vars)
 		}
 		buildParams[i].Args = newArgs
 	}
 	return buildParams
 }
 
+var moduleVariableRefRegexp = regexp.MustCompile(`\$\$|\$\{(\w+)\}|\$(\w+)`)
+
+// expandModuleVariablesForTests replaces both ${flags1} and $flags1 references to module variables
+// in s, including the ones in the values of the module variables, like the shared flag groups that
+// are part of the shared flags of cc modules.
+func expandModuleVariablesForTests(s string, vars map[string]string) string {
+	return moduleVariableRefRegexp.ReplaceAllStringFunc(s, func(ref string) string {
+		name := strings.Trim(ref, "${}")
+		if value, found := vars[name]; found && ref != "$$" {
+			return expandModuleVariablesForTests(value, vars)
+		}
+		return ref
+	})
+}
+
 func (m *ModuleBase) RuleParamsForTests() map[blueprint.Rule]blueprint.RuleParams {
 	return m.ruleParams
 }
@@ -1913,6 +1963,16 @@ func (m *ModuleBase) HostCrossSupported() bool {
 	return hod&hostCrossSupported != 0 && hostEnabled
 }
 
+// HostMusl returns true if the module is built for the musl host target in builds against glibc.
+func (m *ModuleBase) HostMusl() bool {
+	return Bool(m.commonProperties.Host_musl)
+}
+
+// InstallTags returns the install tags of the module, see the install_tags property.
+func (m *ModuleBase) InstallTags() []string {
+	return m.commonProperties.Install_tags
+}
+
 func (m *ModuleBase) Platform() bool {
 	return !m.DeviceSpecific() && !m.SocSpecific() && !m.ProductSpecific() && !m.SystemExtSpecific()
 }
@@ -2402,6 +2462,10 @@ func (m *ModuleBase) GenerateBuildActions(blueprintCtx blueprint.ModuleContext)
 
 		if mixedBuildMod, handled := m.isHandledByBazel(ctx); handled {
 			mixedBuildMod.ProcessBazelQueryResponse(ctx)
+		} else if ctx.AConfig().analysisTimes != nil {
+			start := time.Now()
+			m.module.GenerateAndroidBuildActions(ctx)
+			ctx.AConfig().recordAnalysisTime(ctx.ModuleDir(), time.Since(start))
 		} else {
 			m.module.GenerateAndroidBuildActions(ctx)
 		}
@@ -2410,6 +2474,7 @@ func (m *ModuleBase) GenerateBuildActions(blueprintCtx blueprint.ModuleContext)
 		}
 
 		m.initRcPaths = PathsForModuleSrc(ctx, m.commonProperties.Init_rc)
+		m.initRcSetprops = checkInitRcFiles(ctx, m.initRcPaths)
 		rcDir := PathForModuleInstall(ctx, "etc", "init")
 		for _, src := range m.initRcPaths {
 			ctx.PackageFile(rcDir, filepath.Base(src.String()), src)
@@ -2435,6 +2500,9 @@ func (m *ModuleBase) GenerateBuildActions(blueprintCtx blueprint.ModuleContext)
 		m.packagingSpecs = append(m.packagingSpecs, ctx.packagingSpecs...)
 		m.katiInstalls = append(m.katiInstalls, ctx.katiInstalls...)
 		m.katiSymlinks = append(m.katiSymlinks, ctx.katiSymlinks...)
+		if ctx.Config().AllowMissingDependencies() {
+			m.missingDeps = ctx.GetMissingDependencies()
+		}
 	} else if ctx.Config().AllowMissingDependencies() {
 		// If the module is not enabled it will not create any build rules, nothing will call
 		// ctx.GetMissingDependencies(), and blueprint will consider the missing dependencies to be unhandled
@@ -2532,7 +2600,11 @@ func (e *earlyModuleContext) Module() Module {
 }
 
 func (e *earlyModuleContext) Config() Config {
-	return e.EarlyModuleContext.Config().(Config)
+	config := e.EarlyModuleContext.Config().(Config)
+	if config.moduleEnvDeps != nil || config.productVariableDeps != nil {
+		config.envDepsModule = qualifiedModuleName{e.ModuleDir(), e.ModuleName()}.String()
+	}
+	return config
 }
 
 func (e *earlyModuleContext) AConfig() Config {
@@ -2808,6 +2880,10 @@ func (m *moduleContext) Rule(pctx PackageContext, name string, params blueprint.
 		}
 	}
 
+	if err := checkRuleParamsForSecrets(m.config.config, params); err != nil {
+		m.ModuleErrorf("rule %s %s", name, err)
+	}
+
 	rule := m.bp.Rule(pctx.PackageContext, name, params, argNames...)
 
 	if m.config.captureBuild {
@@ -2839,6 +2915,9 @@ func (m *moduleContext) Build(pctx PackageContext, params BuildParams) {
 			m.ModuleName(),
 			err.Error())
 	}
+	if err := checkBuildParamsForSecrets(m.config.config, bparams); err != nil {
+		m.ModuleErrorf("build statement for %s %s", bparams.Outputs, err)
+	}
 	m.bp.Build(pctx.PackageContext, bparams)
 }
 
@@ -3145,6 +3224,17 @@ func (b *baseModuleContext) Target() Target {
 	return b.target
 }
 
+// DeviceConfig returns the DeviceConfig of the device the module variant is compiled for, which is
+// one of the additional devices of a multi-device product if Target().DeviceName is set.
+func (b *baseModuleContext) DeviceConfig() DeviceConfig {
+	if b.target.DeviceName != "" {
+		if deviceConfig, ok := b.config.DeviceConfigForDevice(b.target.DeviceName); ok {
+			return deviceConfig
+		}
+	}
+	return b.earlyModuleContext.DeviceConfig()
+}
+
 func (b *baseModuleContext) TargetPrimary() bool {
 	return b.targetPrimary
 }
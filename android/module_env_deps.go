@@ -0,0 +1,98 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// The environment variables a build depends on are aggregated by EnvDeps, and a change to any
+// of them reruns the analysis of the whole tree. When soong_build is run with
+// --module-env-deps-report it also records which modules read each environment variable, so
+// that the modules responsible for a tree-wide reanalysis can be found and fixed.
+
+// ModuleEnvDepsReportFileName is the name of the report, in the Soong output directory.
+const ModuleEnvDepsReportFileName = "module_env_deps.json"
+
+// moduleEnvDeps maps each environment variable to the modules that read it.
+type moduleEnvDeps struct {
+	lock    sync.Mutex
+	modules map[string]map[string]bool
+}
+
+// recordModuleEnvDep records that the module whose context returned this Config read the
+// environment variable key.
+func (c Config) recordModuleEnvDep(key string) {
+	if c.moduleEnvDeps == nil || c.envDepsModule == "" {
+		return
+	}
+	deps := c.moduleEnvDeps
+	deps.lock.Lock()
+	defer deps.lock.Unlock()
+	if deps.modules[key] == nil {
+		deps.modules[key] = make(map[string]bool)
+	}
+	deps.modules[key][c.envDepsModule] = true
+}
+
+// The environment accessors of Config shadow those of config so that reads from module
+// contexts can be attributed to the module.
+
+func (c Config) Getenv(key string) string {
+	c.recordModuleEnvDep(key)
+	return c.config.Getenv(key)
+}
+
+func (c Config) GetenvWithDefault(key string, defaultValue string) string {
+	c.recordModuleEnvDep(key)
+	return c.config.GetenvWithDefault(key, defaultValue)
+}
+
+func (c Config) IsEnvTrue(key string) bool {
+	c.recordModuleEnvDep(key)
+	return c.config.IsEnvTrue(key)
+}
+
+func (c Config) IsEnvFalse(key string) bool {
+	c.recordModuleEnvDep(key)
+	return c.config.IsEnvFalse(key)
+}
+
+// ModuleEnvDepsReportEnabled returns true if soong_build records which modules read each
+// environment variable.
+func (c *config) ModuleEnvDepsReportEnabled() bool {
+	return c.moduleEnvDeps != nil
+}
+
+// ModuleEnvDepsReport returns the modules that read each environment variable as JSON, with
+// the variables and the modules sorted.
+func (c *config) ModuleEnvDepsReport() ([]byte, error) {
+	report := make(map[string][]string)
+	if deps := c.moduleEnvDeps; deps != nil {
+		deps.lock.Lock()
+		for key, modules := range deps.modules {
+			report[key] = SortedStringKeys(modules)
+		}
+		deps.lock.Unlock()
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal module env deps report: %s", err.Error())
+	}
+	return append(data, '\n'), nil
+}
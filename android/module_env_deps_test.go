@@ -0,0 +1,80 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+)
+
+type envReadingModule struct {
+	ModuleBase
+	properties struct {
+		Env []string
+	}
+}
+
+func envReadingModuleFactory() Module {
+	m := &envReadingModule{}
+	m.AddProperties(&m.properties)
+	InitAndroidModule(m)
+	return m
+}
+
+func (m *envReadingModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+	for _, key := range m.properties.Env {
+		ctx.Config().Getenv(key)
+	}
+}
+
+func TestModuleEnvDepsReport(t *testing.T) {
+	result := GroupFixturePreparers(
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("env_reading_module", envReadingModuleFactory)
+		}),
+		FixtureModifyConfig(func(config Config) {
+			config.moduleEnvDeps = &moduleEnvDeps{modules: make(map[string]map[string]bool)}
+		}),
+		FixtureMergeEnv(map[string]string{"BUILD_NUMBER": "123"}),
+		MockFS{
+			"a/Android.bp": []byte(`
+				env_reading_module {
+					name: "a",
+					env: ["BUILD_NUMBER", "FOO"],
+				}`),
+			"b/Android.bp": []byte(`
+				env_reading_module {
+					name: "b",
+					env: ["BUILD_NUMBER"],
+				}`),
+		}.AddToFixture(),
+	).RunTest(t)
+
+	AssertBoolEquals(t, "ModuleEnvDepsReportEnabled", true, result.Config.ModuleEnvDepsReportEnabled())
+
+	data, err := result.Config.ModuleEnvDepsReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertStringEquals(t, "report", `{
+  "BUILD_NUMBER": [
+    "//a:a",
+    "//b:b"
+  ],
+  "FOO": [
+    "//a:a"
+  ]
+}
+`, string(data))
+}
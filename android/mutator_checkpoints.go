@@ -0,0 +1,199 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"android/soong/shared"
+
+	"github.com/google/blueprint"
+)
+
+// Mutator debugging options, for tracking down interactions between mutators:
+//
+// --serialize-mutators runs every mutator on one module at a time, in a deterministic order, so
+// that a panic or an unexpected change can be reproduced and stepped through in a debugger.
+//
+// --mutator-checkpoints=<mutator>[,<mutator>...] dumps the state of every module after each of
+// the named mutators to out/soong/mutator_checkpoints/<mutator>.jsonl, one JSON object per
+// module variant. The checkpoints are written as the modules are visited so that they survive a
+// panic in a later mutator. The dump of the previous run is kept as <mutator>.prev.jsonl so that
+// the effect of a change can be seen by diffing the two.
+//
+// Blueprint can't restore module state, so a build can't be resumed from a checkpoint; the
+// checkpoints are only meant to be compared.
+
+// MutatorCheckpointsDirName is the name of the directory in the Soong output directory that
+// holds the mutator checkpoints.
+const MutatorCheckpointsDirName = "mutator_checkpoints"
+
+// mutatorCheckpointModule is the state of a module variant written to a mutator checkpoint.
+type mutatorCheckpointModule struct {
+	Module     string
+	Type       string
+	Deps       []string        `json:",omitempty"`
+	Properties json.RawMessage `json:",omitempty"`
+	Error      string          `json:",omitempty"`
+}
+
+// SerializeMutators returns true if every mutator runs on one module at a time.
+func (c *config) SerializeMutators() bool {
+	return c.serializeMutators
+}
+
+// MutatorCheckpoints returns the names of the mutators after which the state of the modules is
+// dumped.
+func (c *config) MutatorCheckpoints() []string {
+	return c.mutatorCheckpoints
+}
+
+func (c Config) mutatorCheckpointPath(name string) string {
+	return shared.JoinPath(absSrcDir, c.SoongOutDir(), MutatorCheckpointsDirName, name+".jsonl")
+}
+
+// applyMutatorDebugOptions returns the mutators with the mutator debugging options applied:
+// mutators are made serial if requested, and a checkpoint mutator is inserted after each of the
+// mutators named in MutatorCheckpoints.
+func applyMutatorDebugOptions(config Config, mutators sortableComponents) sortableComponents {
+	if !config.SerializeMutators() && len(config.MutatorCheckpoints()) == 0 {
+		return mutators
+	}
+
+	// The names are checked by soong_build with UnknownMutatorCheckpoints, unknown ones are
+	// ignored.
+	checkpoints := make(map[string]bool)
+	for _, name := range config.MutatorCheckpoints() {
+		checkpoints[name] = true
+	}
+
+	var ret sortableComponents
+	for _, c := range mutators {
+		ret = append(ret, c)
+		m, ok := c.(*mutator)
+		if !ok {
+			continue
+		}
+		if config.SerializeMutators() {
+			m.parallel = false
+		}
+		if checkpoints[m.name] {
+			delete(checkpoints, m.name)
+			ret = append(ret, newMutatorCheckpoint(config, m.name))
+		}
+	}
+	return ret
+}
+
+// UnknownMutatorCheckpoints returns the names in MutatorCheckpoints that aren't registered
+// mutators.
+func (c Config) UnknownMutatorCheckpoints() []string {
+	return unknownMutators(c.MutatorCheckpoints(), collateGloballyRegisteredMutators())
+}
+
+func unknownMutators(names []string, mutators sortableComponents) []string {
+	known := make(map[string]bool)
+	for _, c := range mutators {
+		if m, ok := c.(*mutator); ok {
+			known[m.name] = true
+		}
+	}
+	var unknown []string
+	for _, name := range names {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown
+}
+
+// newMutatorCheckpoint returns a serial mutator that appends the state of each module to the
+// checkpoint of the mutator name. The checkpoint of the previous run is kept next to it.
+func newMutatorCheckpoint(config Config, name string) *mutator {
+	path := config.mutatorCheckpointPath(name)
+	prevPath := strings.TrimSuffix(path, ".jsonl") + ".prev.jsonl"
+
+	var once sync.Once
+	var setupErr error
+	setup := func() {
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			setupErr = err
+			return
+		}
+		if err := os.Rename(path, prevPath); err != nil && !os.IsNotExist(err) {
+			setupErr = err
+		}
+	}
+
+	return &mutator{
+		name: "checkpoint_" + name,
+		bottomUpMutator: func(ctx blueprint.BottomUpMutatorContext) {
+			m, ok := ctx.Module().(Module)
+			if !ok {
+				return
+			}
+			once.Do(setup)
+			if setupErr != nil {
+				ctx.ModuleErrorf("cannot write mutator checkpoint %s: %s", path, setupErr)
+				return
+			}
+
+			data, err := json.Marshal(mutatorCheckpointModuleFor(ctx, m))
+			if err != nil {
+				ctx.ModuleErrorf("cannot marshal mutator checkpoint: %s", err)
+				return
+			}
+			if err := appendToFile(path, append(data, '\n')); err != nil {
+				ctx.ModuleErrorf("cannot write mutator checkpoint %s: %s", path, err)
+			}
+		},
+	}
+}
+
+func mutatorCheckpointModuleFor(ctx blueprint.BottomUpMutatorContext, m Module) mutatorCheckpointModule {
+	ret := mutatorCheckpointModule{
+		Module: m.base().String(),
+		Type:   ctx.ModuleType(),
+	}
+	ctx.VisitDirectDeps(func(dep blueprint.Module) {
+		if d, ok := dep.(Module); ok {
+			ret.Deps = append(ret.Deps, d.base().String())
+		} else {
+			ret.Deps = append(ret.Deps, ctx.OtherModuleName(dep))
+		}
+	})
+	if props, err := json.Marshal(m.GetProperties()); err != nil {
+		ret.Error = err.Error()
+	} else {
+		ret.Properties = props
+	}
+	return ret
+}
+
+func appendToFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,71 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMutatorCheckpoints(t *testing.T) {
+	bp := `
+		test {
+			name: "foo",
+			deps_missing_deps: ["bar"],
+		}
+
+		test {
+			name: "bar",
+		}
+	`
+
+	result := GroupFixturePreparers(
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("test", mutatorTestModuleFactory)
+		}),
+		FixtureModifyConfig(func(config Config) {
+			config.serializeMutators = true
+			config.mutatorCheckpoints = []string{"deps"}
+		}),
+		FixtureWithRootAndroidBp(bp),
+	).RunTest(t)
+
+	data, err := os.ReadFile(result.Config.mutatorCheckpointPath("deps"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	AssertIntEquals(t, "number of modules", 2, len(lines))
+
+	deps := make(map[string][]string)
+	for _, line := range lines {
+		var m mutatorCheckpointModule
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatal(err)
+		}
+		AssertStringEquals(t, "module type", "test", m.Type)
+		AssertStringEquals(t, "error", "", m.Error)
+		deps[strings.SplitN(m.Module, "{", 2)[0]] = m.Deps
+	}
+	AssertIntEquals(t, "number of deps of foo", 1, len(deps["foo"]))
+	AssertIntEquals(t, "number of deps of bar", 0, len(deps["bar"]))
+}
+
+func TestMutatorCheckpointsUnknownMutator(t *testing.T) {
+	AssertArrayString(t, "unknown mutators", []string{"not_a_mutator"},
+		unknownMutators([]string{"deps", "not_a_mutator"}, collateRegisteredMutators(nil, nil, nil, nil)))
+}
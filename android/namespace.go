@@ -17,6 +17,7 @@ package android
 import (
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
@@ -89,6 +90,13 @@ type NameResolver struct {
 
 	// func telling whether to export a namespace to Kati
 	namespaceExportFilter func(*Namespace) bool
+
+	// Map from the old name of a relocated module, or the old "//path" of a relocated namespace,
+	// to its new one.
+	moduleAliases map[string]string
+
+	// Set of the aliases that a deprecation warning was printed for.
+	warnedAliases sync.Map
 }
 
 // NameResolverConfig provides the subset of the Config interface needed by the
@@ -97,6 +105,10 @@ type NameResolverConfig interface {
 	// ExportedNamespaces is the list of namespaces that Soong must export to
 	// make.
 	ExportedNamespaces() []string
+
+	// ModuleAliases maps the old names of relocated modules, or the old
+	// "//path" of relocated namespaces, to their new ones.
+	ModuleAliases() map[string]string
 }
 
 func NewNameResolver(config NameResolverConfig) *NameResolver {
@@ -115,6 +127,7 @@ func NewNameResolver(config NameResolverConfig) *NameResolver {
 	r := &NameResolver{
 		namespacesByDir:       sync.Map{},
 		namespaceExportFilter: namespaceExportFilter,
+		moduleAliases:         config.ModuleAliases(),
 	}
 	r.rootNamespace = r.newNamespace(".")
 	r.rootNamespace.visibleNamespaces = []*Namespace{r.rootNamespace}
@@ -278,6 +291,44 @@ func (r *NameResolver) getNamespacesToSearchForModule(sourceNamespace blueprint.
 }
 
 func (r *NameResolver) ModuleFromName(name string, namespace blueprint.Namespace) (group blueprint.ModuleGroup, found bool) {
+	group, found = r.moduleFromName(name, namespace)
+	if found {
+		return group, true
+	}
+	// A module that was moved or renamed keeps being found by its old name while the
+	// references to it are migrated, as long as no module has the old name anymore.
+	if newName, ok := r.resolveAlias(name); ok {
+		group, found = r.moduleFromName(newName, namespace)
+		if found {
+			r.warnAlias(name, newName)
+		}
+	}
+	return group, found
+}
+
+// resolveAlias returns the new name of a module from the ModuleAliases of the product, either
+// by its old name or, for a fully qualified name, by the old path of its namespace.
+func (r *NameResolver) resolveAlias(name string) (string, bool) {
+	if newName, ok := r.moduleAliases[name]; ok {
+		return newName, true
+	}
+	if nsName, moduleName, isAbs := r.parseFullyQualifiedName(name); isAbs {
+		if newNsName, ok := r.moduleAliases["//"+nsName]; ok {
+			return newNsName + ":" + moduleName, true
+		}
+	}
+	return "", false
+}
+
+// warnAlias prints a deprecation warning the first time a module is found through an alias.
+func (r *NameResolver) warnAlias(name, newName string) {
+	if _, warned := r.warnedAliases.LoadOrStore(name, true); !warned {
+		fmt.Fprintf(os.Stderr, "warning: %q is a deprecated alias of %q in ModuleAliases, use the new name instead\n",
+			name, newName)
+	}
+}
+
+func (r *NameResolver) moduleFromName(name string, namespace blueprint.Namespace) (group blueprint.ModuleGroup, found bool) {
 	// handle fully qualified references like "//namespace_path:module_name"
 	nsName, moduleName, isAbs := r.parseFullyQualifiedName(name)
 	if isAbs {
@@ -635,6 +635,48 @@ func TestNamespace_Exports(t *testing.T) {
 	AssertBoolEquals(t, "b not exported", false, bModule.ExportedToMake())
 }
 
+func TestModuleAliases(t *testing.T) {
+	result := GroupFixturePreparers(
+		prepareForTestWithNamespace,
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.ModuleAliases = map[string]string{
+				"a_old":  "a",
+				"//dir1": "//dir2",
+			}
+		}),
+		dirBpToPreparer(map[string]string{
+			".": `
+				test_module {
+					name: "a",
+				}
+			`,
+			"dir2": `
+				soong_namespace {
+				}
+				test_module {
+					name: "b",
+				}
+			`,
+			"dir3": `
+				test_module {
+					name: "c",
+					deps: ["a_old", "//dir1:b"],
+				}
+			`,
+		}),
+	).RunTest(t)
+
+	a := getModule(result, "a")
+	b := getModule(result, "b")
+	c := getModule(result, "c")
+	if !dependsOn(result, c, a) {
+		t.Errorf("module c does not depend on module a through its alias a_old")
+	}
+	if !dependsOn(result, c, b) {
+		t.Errorf("module c does not depend on module b through the alias of its namespace")
+	}
+}
+
 // some utils to support the tests
 
 var prepareForTestWithNamespace = GroupFixturePreparers(
@@ -0,0 +1,93 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"strings"
+)
+
+// The output attestation is a tripwire for release branches against tampered or regressed
+// critical outputs. When the product sets OutputAttestationLockfile, `m attest_outputs` hashes
+// the files installed on the device by the OutputAttestationModules, like the boot jars, the
+// apexes and the key system libraries, and fails if they don't match the expected hashes of the
+// checked-in lockfile. With UPDATE_OUTPUT_ATTESTATION=true the lockfile is rewritten with the
+// hashes of the build instead, to be reviewed and checked in.
+
+func init() {
+	InitRegistrationContext.RegisterSingletonType("output_attestation", outputAttestationSingletonFactory)
+}
+
+func outputAttestationSingletonFactory() Singleton {
+	return &outputAttestationSingleton{}
+}
+
+type outputAttestationSingleton struct{}
+
+func (s *outputAttestationSingleton) GenerateBuildActions(ctx SingletonContext) {
+	lockfile := ctx.Config().OutputAttestationLockfile()
+	if lockfile == "" {
+		return
+	}
+
+	// The installed device files of the modules, by their path on the device.
+	files := make(map[string]Path)
+	attested := make(map[string]bool)
+	ctx.VisitAllModules(func(module Module) {
+		name := ctx.ModuleName(module)
+		if !InList(name, ctx.Config().OutputAttestationModules()) || !module.Enabled() ||
+			module.Target().Os.Class != Device {
+			return
+		}
+		for _, installed := range module.FilesToInstall() {
+			files[InstallPathToOnDevicePath(ctx, installed)] = installed
+			attested[name] = true
+		}
+	})
+	for _, name := range ctx.Config().OutputAttestationModules() {
+		if !attested[name] {
+			ctx.Errorf("OutputAttestationModules: module %q doesn't install any file on the device", name)
+		}
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	// The manifest maps the path on the device of each file to the path of its installed file.
+	var manifest []string
+	var inputs Paths
+	for _, onDevicePath := range SortedStringKeys(files) {
+		manifest = append(manifest, onDevicePath+" "+files[onDevicePath].String())
+		inputs = append(inputs, files[onDevicePath])
+	}
+	manifestFile := PathForOutput(ctx, "attestation", "output_manifest.txt")
+	WriteFileRule(ctx, manifestFile, strings.Join(manifest, "\n"))
+
+	hashes := PathForOutput(ctx, "attestation", "output_hashes.txt")
+	rule := NewRuleBuilder(pctx, ctx)
+	cmd := rule.Command().
+		BuiltTool("attest_outputs").
+		FlagWithOutput("-o ", hashes).
+		FlagWithInput("-manifest ", manifestFile).
+		Implicits(inputs)
+	if ctx.Config().IsEnvTrue("UPDATE_OUTPUT_ATTESTATION") {
+		// The lockfile is written by the rule, and may not exist yet.
+		cmd.Flag("-update").FlagWithArg("-lockfile ", MaybeExistentPathForSource(ctx, lockfile).String())
+	} else {
+		cmd.FlagWithInput("-lockfile ", PathForSource(ctx, lockfile))
+	}
+	rule.Build("output_attestation", "attest critical outputs")
+
+	ctx.Phony("attest_outputs", hashes)
+}
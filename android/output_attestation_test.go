@@ -0,0 +1,86 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+
+	"github.com/google/blueprint/proptools"
+)
+
+func TestOutputAttestation(t *testing.T) {
+	t.Parallel()
+	bp := `
+		deps {
+			name: "foo",
+		}
+		deps {
+			name: "bar",
+		}
+	`
+	prepareForOutputAttestationTest := GroupFixturePreparers(
+		prepareForModuleTests,
+		PrepareForTestWithArchMutator,
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterSingletonType("output_attestation", outputAttestationSingletonFactory)
+		}),
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.OutputAttestationLockfile = proptools.StringPtr("build/attestation.lock")
+			variables.OutputAttestationModules = []string{"foo"}
+		}),
+		FixtureAddFile("build/attestation.lock", nil),
+	)
+
+	result := prepareForOutputAttestationTest.RunTestWithBp(t, bp)
+	singleton := result.SingletonForTests("output_attestation")
+
+	manifest := ContentFromFileRuleForTests(t, singleton.Output("attestation/output_manifest.txt"))
+	AssertStringEquals(t, "attestation manifest",
+		"/system/foo out/soong/target/product/test_device/system/foo\n"+
+			"/system/symlinks/foo out/soong/target/product/test_device/system/symlinks/foo",
+		StringRelativeToTop(result.Config, manifest))
+
+	rule := singleton.Rule("output_attestation")
+	AssertStringDoesContain(t, "attestation command", rule.RuleParams.Command, "-lockfile build/attestation.lock")
+	AssertStringDoesNotContain(t, "attestation command", rule.RuleParams.Command, "-update")
+	implicits := PathsRelativeToTop(rule.Implicits)
+	for _, input := range []string{
+		"build/attestation.lock",
+		"out/soong/attestation/output_manifest.txt",
+		"out/soong/target/product/test_device/system/foo",
+		"out/soong/target/product/test_device/system/symlinks/foo",
+	} {
+		AssertStringListContains(t, "attestation inputs", implicits, input)
+	}
+	AssertStringListDoesNotContain(t, "attestation inputs", implicits,
+		"out/soong/target/product/test_device/system/bar")
+
+	update := GroupFixturePreparers(
+		prepareForOutputAttestationTest,
+		FixtureMergeEnv(map[string]string{"UPDATE_OUTPUT_ATTESTATION": "true"}),
+	).RunTestWithBp(t, bp)
+	AssertStringDoesContain(t, "attestation update command",
+		update.SingletonForTests("output_attestation").Rule("output_attestation").RuleParams.Command,
+		"-update -lockfile build/attestation.lock")
+
+	GroupFixturePreparers(
+		prepareForOutputAttestationTest,
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.OutputAttestationModules = []string{"foo", "missing"}
+		}),
+	).ExtendWithErrorHandler(FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`OutputAttestationModules: module "missing" doesn't install any file on the device`)).
+		RunTestWithBp(t, bp)
+}
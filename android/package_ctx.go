@@ -266,9 +266,9 @@ func (p PackageContext) AndroidRemoteStaticRule(name string, supports RemoteRule
 			params.Pool = localPool
 		}
 
-		if ctx.Config().UseRBE() && !supports.RBE {
-			// When USE_RBE=true is set and the rule is not supported by RBE, restrict jobs to the
-			// local parallelism value
+		if ctx.Config().UseRBE() && (!supports.RBE || ctx.Config().UseRBECacheOnly()) {
+			// When USE_RBE=true is set and the rule is not supported by RBE, or RBE only caches
+			// the results of the rules run locally, restrict jobs to the local parallelism value
 			params.Pool = localPool
 		}
 
@@ -42,6 +42,10 @@ type PackagingSpec struct {
 	effectiveLicenseFiles *Paths
 
 	partition string
+
+	// The install tags of the module of the artifact and of the modules through which it was
+	// packaged, see InstallTags of ModuleBase.
+	installTags []string
 }
 
 // Get file name of installed package
@@ -73,6 +77,12 @@ func (p *PackagingSpec) Partition() string {
 	return p.partition
 }
 
+// InstallTags returns the install tags for which the artifact was packaged, which are empty if it
+// is packaged regardless of the install tags enabled by the product.
+func (p *PackagingSpec) InstallTags() []string {
+	return p.installTags
+}
+
 type PackageModule interface {
 	Module
 	packagingBase() *PackagingBase
@@ -221,17 +231,40 @@ func (p *PackagingBase) AddDeps(ctx BottomUpMutatorContext, depTag blueprint.Dep
 }
 
 // See PackageModule.GatherPackagingSpecs
+//
+// Modules with install tags that are not all enabled by the ImageInstallTags of the product are
+// skipped, together with the dependencies that are only installed because of them.
 func (p *PackagingBase) GatherPackagingSpecs(ctx ModuleContext) map[string]PackagingSpec {
 	m := make(map[string]PackagingSpec)
-	ctx.VisitDirectDeps(func(child Module) {
-		if pi, ok := ctx.OtherModuleDependencyTag(child).(PackagingItem); !ok || !pi.IsPackagingItem() {
-			return
+	enabledTags := ctx.Config().ImageInstallTags()
+	// The install tags of the modules and of the modules through which they are packaged.
+	moduleTags := make(map[Module][]string)
+	ctx.WalkDeps(func(child, parent Module) bool {
+		tag := ctx.OtherModuleDependencyTag(child)
+		if parent == ctx.Module() {
+			if pi, ok := tag.(PackagingItem); !ok || !pi.IsPackagingItem() {
+				return false
+			}
+		} else if !isInstallDepNeeded(child, tag) {
+			return false
+		}
+		for _, installTag := range child.base().InstallTags() {
+			if !InList(installTag, enabledTags) {
+				return false
+			}
+		}
+		if _, ok := moduleTags[child]; ok {
+			return true
 		}
-		for _, ps := range child.TransitivePackagingSpecs() {
+		tags := SortedUniqueStrings(append(CopyOf(moduleTags[parent]), child.base().InstallTags()...))
+		moduleTags[child] = tags
+		for _, ps := range child.PackagingSpecs() {
 			if _, ok := m[ps.relPathInPackage]; !ok {
+				ps.installTags = tags
 				m[ps.relPathInPackage] = ps
 			}
 		}
+		return true
 	})
 	return m
 }
@@ -98,7 +98,7 @@ func (m *packageTestModule) GenerateAndroidBuildActions(ctx ModuleContext) {
 	m.entries = m.CopyDepsToZip(ctx, m.GatherPackagingSpecs(ctx), zipFile)
 }
 
-func runPackagingTest(t *testing.T, multitarget bool, bp string, expected []string) {
+func runPackagingTest(t *testing.T, multitarget bool, bp string, expected []string, preparers ...FixturePreparer) {
 	t.Helper()
 
 	var archVariant string
@@ -118,6 +118,7 @@ func runPackagingTest(t *testing.T, multitarget bool, bp string, expected []stri
 			ctx.RegisterModuleType("package_module", moduleFactory)
 		}),
 		FixtureWithRootAndroidBp(bp),
+		GroupFixturePreparers(preparers...),
 	).RunTest(t)
 
 	p := result.Module("package", archVariant).(*packageTestModule)
@@ -398,3 +399,45 @@ func TestPackagingWithSkipInstallDeps(t *testing.T) {
 		}
 		`, []string{"lib64/foo", "lib64/bar", "lib64/baz"})
 }
+
+func TestPackagingWithInstallTags(t *testing.T) {
+	// package -[dep]-> foo
+	//         -[dep]-> bar (debug) -[dep]-> baz
+	//         -[dep]-> qux (debug, factory)
+	// Modules with install tags that are not enabled are skipped with their dependencies.
+	bp := `
+		component {
+			name: "foo",
+		}
+
+		component {
+			name: "bar",
+			deps: ["baz"],
+			install_tags: ["debug"],
+		}
+
+		component {
+			name: "baz",
+		}
+
+		component {
+			name: "qux",
+			install_tags: ["debug", "factory"],
+		}
+
+		package_module {
+			name: "package",
+			deps: ["foo", "bar", "qux"],
+		}
+		`
+	multiTarget := false
+	runPackagingTest(t, multiTarget, bp, []string{"lib64/foo"})
+	runPackagingTest(t, multiTarget, bp, []string{"lib64/foo", "lib64/bar", "lib64/baz"},
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.ImageInstallTags = []string{"debug"}
+		}))
+	runPackagingTest(t, multiTarget, bp, []string{"lib64/foo", "lib64/bar", "lib64/baz", "lib64/qux"},
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.ImageInstallTags = []string{"debug", "factory"}
+		}))
+}
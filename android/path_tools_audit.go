@@ -0,0 +1,131 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// When soong_build is run with --path-tools-audit it scans the commands of the rules built with
+// RuleBuilder, like the ones of genrules, for the tools they run from the PATH instead of from
+// the hermetic prebuilts or from the outputs of the build, e.g. "sed" instead of $(location sed),
+// which resolve to the toybox or coreutils tools of the host or to the ones the path interposer
+// allows. The report lists them by module, to move the actions toward a hermetic usage of their
+// tools.
+
+// PathToolsAuditReportFileName is the name of the report, in the Soong output directory.
+const PathToolsAuditReportFileName = "path_tools_audit.json"
+
+// pathToolsAudit maps each module to the tools its commands run from the PATH.
+type pathToolsAudit struct {
+	lock    sync.Mutex
+	modules map[string]map[string]bool
+}
+
+var (
+	// shellCommandSeparator splits a command line into its simple commands.
+	shellCommandSeparator = regexp.MustCompile("&&|\\|\\||[;|&()`\n]|\\$\\(")
+
+	// pathToolRegexp matches a word that is run from the PATH, as opposed to a path, a variable
+	// or a quoted string.
+	pathToolRegexp = regexp.MustCompile(`^[A-Za-z0-9_+-][A-Za-z0-9_.+-]*$`)
+
+	// shellAssignmentRegexp matches a variable assignment before the command of a simple command.
+	shellAssignmentRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+)
+
+// The reserved words of the shell that can precede the command of a simple command.
+var shellPrefixKeywords = map[string]bool{
+	"!": true, "{": true, "}": true, "if": true, "then": true, "else": true, "elif": true,
+	"fi": true, "while": true, "until": true, "do": true, "done": true, "esac": true,
+	"exec": true, "command": true, "time": true,
+}
+
+// The shell builtins, which aren't tools from the PATH.
+var shellBuiltins = map[string]bool{
+	".": true, ":": true, "[": true, "[[": true, "alias": true, "cd": true, "echo": true,
+	"eval": true, "exit": true, "export": true, "false": true, "getopts": true, "local": true,
+	"printf": true, "pwd": true, "read": true, "readonly": true, "return": true, "set": true,
+	"shift": true, "source": true, "test": true, "trap": true, "true": true, "type": true,
+	"ulimit": true, "umask": true, "unset": true, "wait": true,
+}
+
+// pathTools returns the tools that command runs from the PATH.
+func pathTools(command string) []string {
+	var tools []string
+	for _, simpleCommand := range shellCommandSeparator.Split(command, -1) {
+		for _, word := range strings.Fields(simpleCommand) {
+			if shellPrefixKeywords[word] || shellAssignmentRegexp.MatchString(word) {
+				continue
+			}
+			// The words of for and case statements aren't commands.
+			if word != "for" && word != "case" && !shellBuiltins[word] && pathToolRegexp.MatchString(word) {
+				tools = append(tools, word)
+			}
+			break
+		}
+	}
+	return FirstUniqueStrings(tools)
+}
+
+// recordPathTools records the tools that command, from a rule of the module whose context
+// returned this Config, runs from the PATH.
+func (c Config) recordPathTools(module, command string) {
+	audit := c.pathToolsAudit
+	if audit == nil {
+		return
+	}
+	tools := pathTools(command)
+	if len(tools) == 0 {
+		return
+	}
+	audit.lock.Lock()
+	defer audit.lock.Unlock()
+	if audit.modules[module] == nil {
+		audit.modules[module] = make(map[string]bool)
+	}
+	for _, tool := range tools {
+		audit.modules[module][tool] = true
+	}
+}
+
+// PathToolsAuditEnabled returns true if soong_build records the tools that the commands of each
+// module run from the PATH.
+func (c *config) PathToolsAuditEnabled() bool {
+	return c.pathToolsAudit != nil
+}
+
+// PathToolsAuditReport returns the tools that the commands of each module run from the PATH as
+// JSON, with the modules and the tools sorted.
+func (c *config) PathToolsAuditReport() ([]byte, error) {
+	report := make(map[string][]string)
+	if audit := c.pathToolsAudit; audit != nil {
+		audit.lock.Lock()
+		for module, tools := range audit.modules {
+			report[module] = SortedStringKeys(tools)
+		}
+		audit.lock.Unlock()
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal path tools audit report: %s", err.Error())
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+)
+
+func TestPathTools(t *testing.T) {
+	testCases := []struct {
+		command  string
+		expected []string
+	}{
+		{
+			command:  "out/host/linux-x86/bin/aapt2 compile -o out.flat in.xml",
+			expected: nil,
+		},
+		{
+			command:  "sed -e 's/a;b/c/' in | tr a-z A-Z > out && echo done",
+			expected: []string{"sed", "tr"},
+		},
+		{
+			command:  "if [ -f in ]; then LC_ALL=C sort in > out; else touch out; fi",
+			expected: []string{"sort", "touch"},
+		},
+		{
+			command:  "for f in a b; do cp $f out/; done && x=$(basename in)",
+			expected: []string{"cp", "basename"},
+		},
+		{
+			command:  "__SBOX_SANDBOX_DIR__/tools/out/bin/gen -o $(realpath out) ${config.Tool} in",
+			expected: []string{"realpath"},
+		},
+	}
+	for _, tc := range testCases {
+		AssertDeepEquals(t, tc.command, tc.expected, pathTools(tc.command))
+	}
+}
+
+type pathToolsTestModule struct {
+	ModuleBase
+	properties struct {
+		Cmd string
+	}
+}
+
+func pathToolsTestModuleFactory() Module {
+	m := &pathToolsTestModule{}
+	m.AddProperties(&m.properties)
+	InitAndroidModule(m)
+	return m
+}
+
+func (m *pathToolsTestModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+	rule := NewRuleBuilder(pctx, ctx)
+	rule.Command().Text(m.properties.Cmd).Output(PathForModuleOut(ctx, "out"))
+	rule.Build("cmd", "cmd")
+}
+
+func TestPathToolsAuditReport(t *testing.T) {
+	result := GroupFixturePreparers(
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("path_tools_test_module", pathToolsTestModuleFactory)
+		}),
+		FixtureModifyConfig(func(config Config) {
+			config.pathToolsAudit = &pathToolsAudit{modules: make(map[string]map[string]bool)}
+		}),
+		MockFS{
+			"a/Android.bp": []byte(`
+				path_tools_test_module {
+					name: "a",
+					cmd: "sed -e s/a/b/ a.txt | sort >",
+				}
+				path_tools_test_module {
+					name: "hermetic",
+					cmd: "prebuilts/build-tools/linux-x86/bin/ckati >",
+				}`),
+		}.AddToFixture(),
+	).RunTest(t)
+
+	AssertBoolEquals(t, "PathToolsAuditEnabled", true, result.Config.PathToolsAuditEnabled())
+
+	data, err := result.Config.PathToolsAuditReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertStringEquals(t, "report", `{
+  "//a:a": [
+    "sed",
+    "sort"
+  ]
+}
+`, string(data))
+}
@@ -1762,7 +1762,12 @@ func pathForInstall(ctx PathContext, os OsType, arch ArchType, partition string,
 	var partitionPaths []string
 
 	if os.Class == Device {
-		partitionPaths = []string{"target", "product", ctx.Config().DeviceName(), partition}
+		deviceName := ctx.Config().DeviceName()
+		if mctx, ok := ctx.(BaseModuleContext); ok && mctx.Target().DeviceName != "" {
+			// The variants of the additional devices of a multi-device product.
+			deviceName = mctx.Target().DeviceName
+		}
+		partitionPaths = []string{"target", "product", deviceName, partition}
 	} else {
 		osName := os.String()
 		if os == Linux {
@@ -1774,14 +1779,23 @@ func pathForInstall(ctx PathContext, os OsType, arch ArchType, partition string,
 			// compiling we will still use "linux_musl".
 			osName = "linux"
 		}
+		if os == LinuxMusl && arch.Name == String(ctx.Config().productVariables.HostCrossArch) {
+			// The host tools cross-compiled for HostCrossArch are installed in linux-<arch>.
+			osName = "linux"
+		}
 
 		// SOONG_HOST_OUT is set to out/host/$(HOST_OS)-$(HOST_PREBUILT_ARCH)
 		// and HOST_PREBUILT_ARCH is forcibly set to x86 even on x86_64 hosts. We don't seem
 		// to have a plan to fix it (see the comment in build/make/core/envsetup.mk).
 		// Let's keep using x86 for the existing cases until we have a need to support
 		// other architectures.
+		if os.Class == Host && arch == Common {
+			// Install arch-independent host modules next to the native host tools, e.g. in
+			// darwin-arm64 on Apple Silicon machines.
+			arch = ctx.Config().BuildArch
+		}
 		archName := arch.String()
-		if os.Class == Host && (arch == X86_64 || arch == Common) {
+		if os.Class == Host && arch == X86_64 {
 			archName = "x86"
 		}
 		partitionPaths = []string{"host", osName + "-" + archName, partition}
@@ -1827,16 +1841,30 @@ func PathForMainlineSdksInstall(ctx PathContext, paths ...string) InstallPath {
 	return pathForNdkOrSdkInstall(ctx, "mainline-sdks", paths)
 }
 
+// PathForCoverageInstall returns an InstallPath under out/coverage, where the coverage profiles
+// pulled from the devices and the coverage reports are kept.
+func PathForCoverageInstall(ctx PathContext, paths ...string) InstallPath {
+	return pathForNdkOrSdkInstall(ctx, "coverage", paths).ToMakePath()
+}
+
 func InstallPathToOnDevicePath(ctx PathContext, path InstallPath) string {
 	rel := Rel(ctx, strings.TrimSuffix(path.PartitionDir(), path.partition), path.String())
 	return "/" + rel
 }
 
+// The host install directory that host tools built with SanitizeHostTools are installed
+// into, e.g. out/host/linux-x86/sanitized/bin.
+const hostSanitizerPartition = "sanitized"
+
 func modulePartition(ctx ModuleInstallPathContext, os OsType) string {
 	var partition string
 	if ctx.InstallInTestcases() {
 		// "testcases" install directory can be used for host or device modules.
 		partition = "testcases"
+	} else if os.Class == Host {
+		if ctx.InstallInSanitizerDir() {
+			partition = hostSanitizerPartition
+		}
 	} else if os.Class == Device {
 		if ctx.InstallInData() {
 			partition = "data"
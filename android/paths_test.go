@@ -260,6 +260,19 @@ func TestPathForModuleInstall(t *testing.T) {
 			out:          "host/linux-x86/bin/my_test",
 			partitionDir: "host/linux-x86",
 		},
+		{
+			name: "sanitized host binary",
+			ctx: &testModuleInstallPathContext{
+				baseModuleContext: baseModuleContext{
+					os:     hostTarget.Os,
+					target: hostTarget,
+				},
+				inSanitizerDir: true,
+			},
+			in:           []string{"bin", "my_test"},
+			out:          "host/linux-x86/sanitized/bin/my_test",
+			partitionDir: "host/linux-x86/sanitized",
+		},
 
 		{
 			name: "system binary",
@@ -0,0 +1,149 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/blueprint"
+)
+
+// Soong plugins are the Go packages of vendor trees that are compiled into soong_build with
+// pluginFor: ["soong_build"] to register their own module types, mutators and singletons. They
+// declare the version of the plugin API they were written against with RequirePluginApiVersion
+// from their init function, and soong_build refuses to run with a plugin it doesn't support,
+// naming the plugin and the versions, instead of failing later in obscure ways. Plugins that only
+// use the Plugin* interfaces below for the contexts they are passed keep compiling as long as the
+// major version of the API doesn't change.
+
+const (
+	// PluginApiVersion is the version of the plugin API of this Soong. It is increased when the
+	// Plugin* interfaces or the behavior of the functions plugins rely on change.
+	PluginApiVersion = 1
+
+	// MinPluginApiVersion is the oldest version of the plugin API that plugins may still be
+	// written against. It is increased when a change to the plugin API isn't backward compatible.
+	MinPluginApiVersion = 1
+)
+
+type pluginApiRequirement struct {
+	plugin  string
+	version int
+}
+
+var pluginApiRequirements struct {
+	sync.Mutex
+	list []pluginApiRequirement
+}
+
+// RequirePluginApiVersion declares that the plugin, usually the path of its Go package, was
+// written against the given version of the plugin API. It must be called from the init function
+// of the plugin, and is checked by soong_build before the module types are registered.
+func RequirePluginApiVersion(plugin string, version int) {
+	pluginApiRequirements.Lock()
+	defer pluginApiRequirements.Unlock()
+	pluginApiRequirements.list = append(pluginApiRequirements.list, pluginApiRequirement{plugin, version})
+}
+
+// checkPluginApiRequirements returns an error listing the plugins whose version of the plugin
+// API isn't supported, and how to fix them.
+func checkPluginApiRequirements(requirements []pluginApiRequirement) error {
+	var problems []string
+	for _, r := range requirements {
+		switch {
+		case r.version > PluginApiVersion:
+			problems = append(problems, fmt.Sprintf(
+				"plugin %q requires plugin API version %d, but this Soong only supports up to version %d; "+
+					"update Soong or use an older version of the plugin", r.plugin, r.version, PluginApiVersion))
+		case r.version < MinPluginApiVersion:
+			problems = append(problems, fmt.Sprintf(
+				"plugin %q was written against plugin API version %d, but this Soong requires at least version %d; "+
+					"port the plugin to the current plugin API and declare version %d",
+				r.plugin, r.version, MinPluginApiVersion, PluginApiVersion))
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("incompatible Soong plugins:\n    %s", strings.Join(problems, "\n    "))
+}
+
+// CheckPluginApiVersions returns an error if a plugin requires a version of the plugin API that
+// this Soong doesn't support.
+func CheckPluginApiVersions() error {
+	pluginApiRequirements.Lock()
+	defer pluginApiRequirements.Unlock()
+	return checkPluginApiRequirements(pluginApiRequirements.list)
+}
+
+// PluginEarlyModuleContext is the part of the contexts of the modules that plugins may rely on in
+// every version of the plugin API.
+type PluginEarlyModuleContext interface {
+	ModuleName() string
+	ModuleDir() string
+	ModuleType() string
+	Config() Config
+	DeviceConfig() DeviceConfig
+	Glob(globPattern string, excludes []string) Paths
+	ModuleErrorf(fmt string, args ...interface{})
+	PropertyErrorf(property, fmt string, args ...interface{})
+	Failed() bool
+}
+
+// PluginLoadHookContext is the part of LoadHookContext that plugins may rely on, typically to
+// set the properties of the modules of their module types from the product variables.
+type PluginLoadHookContext interface {
+	PluginEarlyModuleContext
+
+	AppendProperties(...interface{})
+	PrependProperties(...interface{})
+	CreateModule(ModuleFactory, ...interface{}) Module
+}
+
+// PluginModuleContext is the part of ModuleContext that plugins may rely on to generate the
+// build actions of their modules.
+type PluginModuleContext interface {
+	PluginEarlyModuleContext
+
+	Target() Target
+	Arch() Arch
+	Os() OsType
+	Host() bool
+	Device() bool
+
+	OtherModuleName(m blueprint.Module) string
+	VisitDirectDepsWithTag(tag blueprint.DependencyTag, visit func(Module))
+	OtherModuleProvider(m blueprint.Module, provider blueprint.ProviderKey) interface{}
+	SetProvider(provider blueprint.ProviderKey, value interface{})
+
+	ExpandSources(srcFiles, excludes []string) Paths
+	ExpandSource(srcFile, prop string) Path
+
+	Build(pctx PackageContext, params BuildParams)
+	Phony(phony string, deps ...Path)
+	InstallFile(installPath InstallPath, name string, srcPath Path, deps ...Path) InstallPath
+	InstallExecutable(installPath InstallPath, name string, srcPath Path, deps ...Path) InstallPath
+}
+
+// The contexts must keep implementing the Plugin* interfaces, or PluginApiVersion and
+// MinPluginApiVersion must be increased along with the change of the interfaces.
+var (
+	_ PluginLoadHookContext = LoadHookContext(nil)
+	_ PluginModuleContext   = ModuleContext(nil)
+)
@@ -0,0 +1,40 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCheckPluginApiRequirements(t *testing.T) {
+	t.Parallel()
+	AssertSame(t, "supported plugins", nil, checkPluginApiRequirements([]pluginApiRequirement{
+		{"vendor/acme/soong", PluginApiVersion},
+		{"vendor/acme/soong/old", MinPluginApiVersion},
+	}))
+
+	err := checkPluginApiRequirements([]pluginApiRequirement{
+		{"vendor/acme/soong", PluginApiVersion},
+		{"vendor/acme/soong/new", PluginApiVersion + 1},
+		{"vendor/acme/soong/old", MinPluginApiVersion - 1},
+	})
+	AssertErrorMessageEquals(t, "unsupported plugins", fmt.Sprintf("incompatible Soong plugins:\n"+
+		"    plugin \"vendor/acme/soong/new\" requires plugin API version %d, but this Soong only supports up to version %d; "+
+		"update Soong or use an older version of the plugin\n"+
+		"    plugin \"vendor/acme/soong/old\" was written against plugin API version %d, but this Soong requires at least version %d; "+
+		"port the plugin to the current plugin API and declare version %d",
+		PluginApiVersion+1, PluginApiVersion, MinPluginApiVersion-1, MinPluginApiVersion, PluginApiVersion), err)
+}
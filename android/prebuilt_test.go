@@ -407,7 +407,7 @@ func TestPrebuilts(t *testing.T) {
 				// Add a Windows target to the configuration.
 				FixtureModifyConfig(func(config Config) {
 					config.Targets[Windows] = []Target{
-						{Windows, Arch{ArchType: X86_64}, NativeBridgeDisabled, "", "", true},
+						{Windows, Arch{ArchType: X86_64}, NativeBridgeDisabled, "", "", true, ""},
 					}
 				}),
 				fs.AddToFixture(),
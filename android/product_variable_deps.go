@@ -0,0 +1,154 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// When soong_build is run with --product-variable-deps-report it records which modules read
+// each product variable, either through the product_variables property of their Android.bp
+// definition or through the Config accessors below, and writes the index to
+// out/soong/product_variable_deps.json. It answers which modules need to be looked at when a
+// product variable changes.
+//
+// Only the accessors that shadow those of config record reads. Variables read elsewhere, e.g.
+// through DeviceConfig or from singletons, are not attributed to any module.
+
+// ProductVariableDepsReportFileName is the name of the report, in the Soong output directory.
+const ProductVariableDepsReportFileName = "product_variable_deps.json"
+
+// productVariableDeps maps each product variable to the modules that read it.
+type productVariableDeps struct {
+	lock    sync.Mutex
+	modules map[string]map[string]bool
+}
+
+// recordProductVariableDep records that the module whose context returned this Config read the
+// product variable name.
+func (c Config) recordProductVariableDep(name string) {
+	if c.productVariableDeps == nil || c.envDepsModule == "" {
+		return
+	}
+	deps := c.productVariableDeps
+	deps.lock.Lock()
+	defer deps.lock.Unlock()
+	if deps.modules[name] == nil {
+		deps.modules[name] = make(map[string]bool)
+	}
+	deps.modules[name][c.envDepsModule] = true
+}
+
+// The product variable accessors of Config that shadow those of config so that reads from module
+// contexts can be attributed to the module. They cover the accessors most used by module types.
+
+func (c Config) AlwaysUsePrebuiltSdks() bool {
+	c.recordProductVariableDep("Always_use_prebuilt_sdks")
+	return c.config.AlwaysUsePrebuiltSdks()
+}
+
+func (c Config) UnbundledBuild() bool {
+	c.recordProductVariableDep("Unbundled_build")
+	return c.config.UnbundledBuild()
+}
+
+func (c Config) UnbundledBuildApps() bool {
+	c.recordProductVariableDep("Unbundled_build_apps")
+	return c.config.UnbundledBuildApps()
+}
+
+func (c Config) SanitizeDevice() []string {
+	c.recordProductVariableDep("SanitizeDevice")
+	return c.config.SanitizeDevice()
+}
+
+func (c Config) PlatformSdkVersion() ApiLevel {
+	c.recordProductVariableDep("Platform_sdk_version")
+	return c.config.PlatformSdkVersion()
+}
+
+func (c Config) PlatformSdkCodename() string {
+	c.recordProductVariableDep("Platform_sdk_codename")
+	return c.config.PlatformSdkCodename()
+}
+
+func (c Config) EnforceProductPartitionInterface() bool {
+	c.recordProductVariableDep("EnforceProductPartitionInterface")
+	return c.config.EnforceProductPartitionInterface()
+}
+
+func (c Config) DeviceName() string {
+	c.recordProductVariableDep("DeviceName")
+	return c.config.DeviceName()
+}
+
+func (c Config) Eng() bool {
+	c.recordProductVariableDep("Eng")
+	return c.config.Eng()
+}
+
+func (c Config) Debuggable() bool {
+	c.recordProductVariableDep("Debuggable")
+	return c.config.Debuggable()
+}
+
+func (c Config) BuildType() BuildType {
+	c.recordProductVariableDep("Eng")
+	c.recordProductVariableDep("Debuggable")
+	return c.config.BuildType()
+}
+
+func (c Config) BuildTypeSettings() *BuildTypeSettings {
+	c.recordProductVariableDep("Eng")
+	c.recordProductVariableDep("Debuggable")
+	return c.config.BuildTypeSettings()
+}
+
+func (c Config) FlattenApex() bool {
+	c.recordProductVariableDep("Flatten_apex")
+	return c.config.FlattenApex()
+}
+
+func (c Config) UncompressPrivAppDex() bool {
+	c.recordProductVariableDep("UncompressPrivAppDex")
+	return c.config.UncompressPrivAppDex()
+}
+
+// ProductVariableDepsReportEnabled returns true if soong_build records which modules read each
+// product variable.
+func (c *config) ProductVariableDepsReportEnabled() bool {
+	return c.productVariableDeps != nil
+}
+
+// ProductVariableDepsReport returns the modules that read each product variable as JSON, with
+// the variables and the modules sorted.
+func (c *config) ProductVariableDepsReport() ([]byte, error) {
+	report := make(map[string][]string)
+	if deps := c.productVariableDeps; deps != nil {
+		deps.lock.Lock()
+		for name, modules := range deps.modules {
+			report[name] = SortedStringKeys(modules)
+		}
+		deps.lock.Unlock()
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal product variable deps report: %s", err.Error())
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+)
+
+type debuggableReadingModule struct {
+	ModuleBase
+}
+
+func debuggableReadingModuleFactory() Module {
+	m := &debuggableReadingModule{}
+	InitAndroidModule(m)
+	return m
+}
+
+func (m *debuggableReadingModule) GenerateAndroidBuildActions(ctx ModuleContext) {
+	ctx.Config().Debuggable()
+}
+
+func TestProductVariableDepsReport(t *testing.T) {
+	result := GroupFixturePreparers(
+		PrepareForTestWithVariables,
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("debuggable_reading_module", debuggableReadingModuleFactory)
+			ctx.RegisterModuleType("product_variable_module", testProductVariableModuleFactoryFactory(&struct {
+				Cflags []string
+			}{}))
+		}),
+		FixtureModifyConfig(func(config Config) {
+			config.productVariableDeps = &productVariableDeps{modules: make(map[string]map[string]bool)}
+		}),
+		MockFS{
+			"a/Android.bp": []byte(`
+				debuggable_reading_module {
+					name: "a",
+				}`),
+			"b/Android.bp": []byte(`
+				product_variable_module {
+					name: "b",
+					product_variables: {
+						eng: {
+							cflags: ["-DENG"],
+						},
+					},
+				}
+				product_variable_module {
+					name: "c",
+				}`),
+		}.AddToFixture(),
+	).RunTest(t)
+
+	AssertBoolEquals(t, "ProductVariableDepsReportEnabled", true, result.Config.ProductVariableDepsReportEnabled())
+
+	data, err := result.Config.ProductVariableDepsReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertStringEquals(t, "report", `{
+  "Debuggable": [
+    "//a:a"
+  ],
+  "Eng": [
+    "//b:b"
+  ]
+}
+`, string(data))
+}
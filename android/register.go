@@ -203,6 +203,7 @@ func (ctx *Context) Register() {
 	}
 
 	mutators := collateGloballyRegisteredMutators()
+	mutators = applyMutatorDebugOptions(ctx.config, mutators)
 	mutators.registerAll(ctx)
 
 	singletons := collateGloballyRegisteredSingletons()
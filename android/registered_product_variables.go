@@ -0,0 +1,165 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+// Registered product variables.
+//
+// Go packages built into soong_build, e.g. a vendor plugin in vendor/acme/build/soong, can
+// declare their own product variables instead of adding fields to productVariables. They are
+// decoded from soong.variables and its fragments like the built-in variables:
+//
+//	func init() {
+//	    android.RegisterProductVariable("Acme_feature_level", 0)
+//	}
+//
+//	level := ctx.DeviceConfig().ProductVariable("Acme_feature_level").(int)
+//
+// Variables must be registered from init functions, before the configuration is loaded.
+
+type registeredProductVariable struct {
+	name         string
+	typ          reflect.Type
+	defaultValue interface{}
+}
+
+var registeredProductVariables = map[string]registeredProductVariable{}
+
+var productVariableNameRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// RegisterProductVariable registers a product variable named name, whose value has the type of
+// defaultValue and is defaultValue when the product doesn't set it.
+func RegisterProductVariable(name string, defaultValue interface{}) {
+	if !productVariableNameRegexp.MatchString(name) {
+		panic(fmt.Errorf("invalid product variable name %q", name))
+	}
+	if defaultValue == nil {
+		panic(fmt.Errorf("product variable %s must have a typed default value", name))
+	}
+	if isBuiltinProductVariable(name) {
+		panic(fmt.Errorf("product variable %s is already a built-in product variable", name))
+	}
+	if _, exists := registeredProductVariables[name]; exists {
+		panic(fmt.Errorf("product variable %s is already registered", name))
+	}
+	registeredProductVariables[name] = registeredProductVariable{
+		name:         name,
+		typ:          reflect.TypeOf(defaultValue),
+		defaultValue: defaultValue,
+	}
+}
+
+func isBuiltinProductVariable(name string) bool {
+	_, ok := reflect.TypeOf(productVariables{}).FieldByName(name)
+	return ok
+}
+
+func isRegisteredProductVariable(name string) bool {
+	_, ok := registeredProductVariables[name]
+	return ok
+}
+
+// registeredProductVariablesLayout describes the registered product variables, so that the
+// cache of the decoded product variables is invalidated when they change.
+func registeredProductVariablesLayout() []string {
+	var layout []string
+	for _, name := range SortedStringKeys(registeredProductVariables) {
+		layout = append(layout, name+" "+registeredProductVariables[name].typ.String())
+	}
+	return layout
+}
+
+// splitRegisteredProductVariables moves the registered product variables out of fields into
+// configurable, returning the remaining, built-in, variables.
+func splitRegisteredProductVariables(configurable *productVariables,
+	fields map[string]json.RawMessage) map[string]json.RawMessage {
+
+	builtin := make(map[string]json.RawMessage, len(fields))
+	for name, value := range fields {
+		if isRegisteredProductVariable(name) {
+			if configurable.RegisteredVariables == nil {
+				configurable.RegisteredVariables = make(map[string]json.RawMessage)
+			}
+			configurable.RegisteredVariables[name] = value
+		} else {
+			builtin[name] = value
+		}
+	}
+	return builtin
+}
+
+// decodeRegisteredProductVariable decodes the value the product set for a registered variable.
+func decodeRegisteredProductVariable(v registeredProductVariable, data json.RawMessage) (interface{}, error) {
+	value := reflect.New(v.typ)
+	if err := json.Unmarshal(data, value.Interface()); err != nil {
+		return nil, err
+	}
+	return value.Elem().Interface(), nil
+}
+
+var registeredProductVariablesKey = NewOnceKey("registeredProductVariables")
+
+// ProductVariable returns the value of a registered product variable, or its default value if
+// the product doesn't set it. It panics if the variable isn't registered.
+func (c *deviceConfig) ProductVariable(name string) interface{} {
+	v, ok := registeredProductVariables[name]
+	if !ok {
+		panic(fmt.Errorf("product variable %s is not registered", name))
+	}
+
+	values := c.config.Once(registeredProductVariablesKey, func() interface{} {
+		values := make(map[string]interface{})
+		for name, data := range c.config.productVariables.RegisteredVariables {
+			variable, ok := registeredProductVariables[name]
+			if !ok {
+				continue
+			}
+			// Invalid values are reported by Config.Validate.
+			if value, err := decodeRegisteredProductVariable(variable, data); err == nil {
+				values[name] = value
+			}
+		}
+		return values
+	}).(map[string]interface{})
+
+	if value, ok := values[name]; ok {
+		return value
+	}
+	return v.defaultValue
+}
+
+func checkRegisteredProductVariables(v *productVariables) []ProductVariableError {
+	var errs []ProductVariableError
+	for _, name := range SortedStringKeys(v.RegisteredVariables) {
+		variable, ok := registeredProductVariables[name]
+		if !ok {
+			continue
+		}
+		if _, err := decodeRegisteredProductVariable(variable, v.RegisteredVariables[name]); err != nil {
+			errs = append(errs, ProductVariableError{
+				Variables: []string{name},
+				Values:    []string{string(v.RegisteredVariables[name])},
+				Message:   fmt.Sprintf("expected a value of type %s: %s", variable.typ, err.Error()),
+			})
+		}
+	}
+	return errs
+}
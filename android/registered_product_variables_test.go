@@ -0,0 +1,72 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func init() {
+	RegisterProductVariable("Test_registered_level", 3)
+	RegisterProductVariable("Test_registered_features", []string(nil))
+}
+
+func TestRegisteredProductVariables(t *testing.T) {
+	writeFile := func(t *testing.T, path, contents string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, productVariablesFileName)
+	writeFile(t, path, `{"DeviceName": "main", "Test_registered_level": 5}`)
+	fragment := filepath.Join(dir, productVariablesFragmentsDirName, "a.json")
+	writeFile(t, fragment, `{"DeviceProduct": "a", "Test_registered_features": ["foo", "bar"]}`)
+
+	var v productVariables
+	if err := loadFromConfigFile(&v, path, fragment); err != nil {
+		t.Fatal(err)
+	}
+	AssertStringEquals(t, "DeviceName", "main", String(v.DeviceName))
+	AssertStringEquals(t, "DeviceProduct", "a", String(v.DeviceProduct))
+
+	config := TestConfig(t.TempDir(), nil, "", nil)
+	config.TestProductVariables.RegisteredVariables = v.RegisteredVariables
+	AssertDeepEquals(t, "Test_registered_level", 5,
+		DeviceConfig{config.deviceConfig}.ProductVariable("Test_registered_level"))
+	AssertDeepEquals(t, "Test_registered_features", []string{"foo", "bar"},
+		DeviceConfig{config.deviceConfig}.ProductVariable("Test_registered_features"))
+
+	config = TestConfig(t.TempDir(), nil, "", nil)
+	AssertDeepEquals(t, "default Test_registered_level", 3,
+		DeviceConfig{config.deviceConfig}.ProductVariable("Test_registered_level"))
+}
+
+func TestRegisteredProductVariablesValidation(t *testing.T) {
+	config := TestConfig(t.TempDir(), nil, "", nil)
+	config.TestProductVariables.RegisteredVariables = map[string]json.RawMessage{
+		"Test_registered_level": json.RawMessage(`"high"`),
+	}
+	AssertErrorMessageEquals(t, "validation error", "invalid product variables in soong.variables:\n"+
+		"    Test_registered_level=\"high\": expected a value of type int: "+
+		"json: cannot unmarshal string into Go value of type int", config.Validate())
+}
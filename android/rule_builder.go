@@ -50,11 +50,13 @@ type RuleBuilder struct {
 	restat           bool
 	sbox             bool
 	highmem          bool
+	actionPool       blueprint.Pool
 	remoteable       RemoteRuleSupports
 	rbeParams        *remoteexec.REParams
 	outDir           WritablePath
 	sboxTools        bool
 	sboxInputs       bool
+	sboxNoNetwork    bool
 	sboxManifestPath WritablePath
 	missingDeps      []string
 }
@@ -113,6 +115,15 @@ func (r *RuleBuilder) HighMem() *RuleBuilder {
 	return r
 }
 
+// ActionPool runs the rule in the given action pool, which limits how many memory-heavy rules of
+// its kind run in parallel, if the product puts the actions of the module in it.
+func (r *RuleBuilder) ActionPool(ctx ActionPoolContext, pool ActionPool) *RuleBuilder {
+	if pool.Contains(ctx) {
+		r.actionPool = pool.pool
+	}
+	return r
+}
+
 // Remoteable marks the rule as supporting remote execution.
 func (r *RuleBuilder) Remoteable(supports RemoteRuleSupports) *RuleBuilder {
 	r.remoteable = supports
@@ -181,6 +192,16 @@ func (r *RuleBuilder) SandboxInputs() *RuleBuilder {
 	return r
 }
 
+// NoNetwork makes sbox run the commands of the rule without network access where the host
+// supports it, so that accidental network fetches fail deterministically.
+func (r *RuleBuilder) NoNetwork() *RuleBuilder {
+	if !r.sbox {
+		panic("NoNetwork() must be called after Sbox()")
+	}
+	r.sboxNoNetwork = true
+	return r
+}
+
 // Install associates an output of the rule with an install location, which can be retrieved later using
 // RuleBuilder.Installs.
 func (r *RuleBuilder) Install(from Path, to string) {
@@ -515,6 +536,14 @@ func (r *RuleBuilder) Build(name string, desc string) {
 
 	commandString := strings.Join(commands, " && ")
 
+	if r.ctx.Config().pathToolsAudit != nil {
+		owner := "singleton " + name
+		if mctx, ok := r.ctx.(ModuleContext); ok {
+			owner = qualifiedModuleName{mctx.ModuleDir(), mctx.ModuleName()}.String()
+		}
+		r.ctx.Config().recordPathTools(owner, commandString)
+	}
+
 	if r.sbox {
 		// If running the command inside sbox, write the rule data out to an sbox
 		// manifest.textproto.
@@ -635,6 +664,10 @@ func (r *RuleBuilder) Build(name string, desc string) {
 			sboxCmd.Flag("--write-if-changed")
 		}
 
+		if r.sboxNoNetwork {
+			sboxCmd.Flag("--no-network")
+		}
+
 		// Replace the command string, and add the sbox tool and manifest textproto to the
 		// dependencies of the final sbox rule.
 		commandString = sboxCmd.buf.String()
@@ -666,7 +699,7 @@ func (r *RuleBuilder) Build(name string, desc string) {
 
 			r.rbeParams.OutputFiles = outputs.Strings()
 			r.rbeParams.RSPFiles = remoteRspFiles.Strings()
-			rewrapperCommand := r.rbeParams.NoVarTemplate(r.ctx.Config().RBEWrapper())
+			rewrapperCommand := r.rbeParams.NoVarTemplate(r.ctx.Config().RBEWrapper(), r.ctx.Config().RBEWrapperFlags())
 			commandString = rewrapperCommand + " bash -c '" + strings.ReplaceAll(commandString, `'`, `'\''`) + "'"
 		}
 	} else {
@@ -706,9 +739,12 @@ func (r *RuleBuilder) Build(name string, desc string) {
 	var pool blueprint.Pool
 	if r.ctx.Config().UseGoma() && r.remoteable.Goma {
 		// When USE_GOMA=true is set and the rule is supported by goma, allow jobs to run outside the local pool.
-	} else if r.ctx.Config().UseRBE() && r.remoteable.RBE {
-		// When USE_RBE=true is set and the rule is supported by RBE, use the remotePool.
+	} else if r.ctx.Config().UseRBE() && r.remoteable.RBE && !r.ctx.Config().UseRBECacheOnly() {
+		// When USE_RBE=true is set and the rule is supported by RBE, use the remotePool, unless
+		// RBE only caches the results of the rules run locally.
 		pool = remotePool
+	} else if r.actionPool != nil {
+		pool = r.actionPool
 	} else if r.highmem {
 		pool = highmemPool
 	} else if r.ctx.Config().UseRemoteBuild() {
@@ -755,6 +791,15 @@ type RuleBuilderCommand struct {
 	tools          Paths
 	packagedTools  []PackagingSpec
 	rspFiles       []rspFileAndPaths
+	rewrapper      *commandRewrapper
+}
+
+// commandRewrapper runs the text of a command after offset remotely with RBE.
+type commandRewrapper struct {
+	wrapper string
+	flags   string
+	params  *remoteexec.REParams
+	offset  int
 }
 
 type rspFileAndPaths struct {
@@ -1291,7 +1336,48 @@ func (c *RuleBuilderCommand) FlagWithRspFileInputList(flag string, rspFile Writa
 
 // String returns the command line.
 func (c *RuleBuilderCommand) String() string {
-	return c.buf.String()
+	if c.rewrapper == nil {
+		return c.buf.String()
+	}
+	return c.rewrappedString()
+}
+
+// Rewrapper runs the rest of the command remotely with RBE, by inserting the given rewrapper and
+// flags before the text added to the command after this call. The inputs, tools and rsp files of
+// the command are the inputs of the remote action, and its outputs are the outputs of the action,
+// so they must all be added to this command. Unlike RuleBuilder.Rewrapper, it doesn't need the
+// rule to be sandboxed or a Config, and it is used for the commands that may run outside Soong,
+// like the dex2oat commands of dexpreopt_gen.
+func (c *RuleBuilderCommand) Rewrapper(wrapper, flags string, params *remoteexec.REParams) *RuleBuilderCommand {
+	c.rewrapper = &commandRewrapper{
+		wrapper: wrapper,
+		flags:   flags,
+		params:  params,
+		offset:  c.buf.Len(),
+	}
+	return c
+}
+
+func (c *RuleBuilderCommand) rewrappedString() string {
+	params := *c.rewrapper.params
+	inputs := append([]string(nil), params.Inputs...)
+	inputs = append(inputs, c.inputs.Strings()...)
+	inputs = append(inputs, c.implicits.Strings()...)
+	inputs = append(inputs, c.tools.Strings()...)
+	var rspFiles []string
+	for _, rspFile := range c.rspFiles {
+		rspFiles = append(rspFiles, rspFile.file.String())
+	}
+	params.Inputs = FirstUniqueStrings(append(inputs, rspFiles...))
+	params.RSPFiles = append(append([]string(nil), params.RSPFiles...), rspFiles...)
+	params.OutputFiles = FirstUniqueStrings(append(append([]string(nil), params.OutputFiles...), c.outputs.Strings()...))
+
+	s := c.buf.String()
+	prefix, rest := s[:c.rewrapper.offset], strings.TrimPrefix(s[c.rewrapper.offset:], " ")
+	if prefix != "" {
+		prefix += " "
+	}
+	return prefix + params.NoVarTemplate(c.rewrapper.wrapper, c.rewrapper.flags) + rest
 }
 
 // RuleBuilderSboxProtoForTests takes the BuildParams for the manifest passed to RuleBuilder.Sbox()
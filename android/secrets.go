@@ -0,0 +1,103 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/blueprint"
+
+	"android/soong/ui/status"
+)
+
+// Secret environment variables.
+//
+// The environment variables listed in BUILD_SECRET_ENV_VARS hold secrets, e.g. the tokens used by
+// remote signing actions. soong_ui redacts their values from its logs, metrics and error
+// messages, and soong_build reports an error for any rule or build statement that would write
+// one of their values to the ninja files. Actions that need a secret must read it from the
+// environment when they run instead.
+
+// Values shorter than this are ignored, as they would match too much unrelated text, e.g. a
+// secret set to "1". This matches the redaction in soong_ui.
+const minSecretLength = 4
+
+// loadSecretEnvValues returns the values of the environment variables listed in
+// BUILD_SECRET_ENV_VARS, keyed by name. The values are read without recording them as
+// dependencies, as that would write them to soong.environment.used.
+func loadSecretEnvValues(c *config) map[string]string {
+	values := make(map[string]string)
+	for _, name := range strings.Fields(c.Getenv("BUILD_SECRET_ENV_VARS")) {
+		if value := c.env[name]; len(value) >= minSecretLength {
+			values[name] = value
+		}
+	}
+	return values
+}
+
+// findSecrets returns the names of the secret environment variables whose values appear in any
+// of strs.
+func (c *config) findSecrets(strs ...string) []string {
+	var found []string
+	for _, name := range SortedStringKeys(c.secretEnvValues) {
+		for _, s := range strs {
+			if strings.Contains(s, c.secretEnvValues[name]) {
+				found = append(found, name)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// redactSecrets returns a copy of env with the values of the secret environment variables replaced
+// by a placeholder naming them, the same redaction soong_ui applies to its logs.
+func (c *config) redactSecrets(env map[string]string) map[string]string {
+	redactor := status.NewRedactor(c.secretEnvValues)
+	ret := make(map[string]string, len(env))
+	for name, value := range env {
+		ret[name] = redactor.Redact(value)
+	}
+	return ret
+}
+
+// checkForSecrets returns an error if any of strs, the parts of a rule or build statement that
+// are written to the ninja files, contains the value of a secret environment variable.
+func checkForSecrets(c *config, strs ...string) error {
+	if len(c.secretEnvValues) == 0 {
+		return nil
+	}
+	if found := c.findSecrets(strs...); len(found) > 0 {
+		return fmt.Errorf("contains the value of the secret environment variables %s, read them "+
+			"from the environment when the action runs instead", strings.Join(found, ", "))
+	}
+	return nil
+}
+
+// checkRuleParamsForSecrets checks the parts of a rule that are written to the ninja files.
+func checkRuleParamsForSecrets(c *config, params blueprint.RuleParams) error {
+	return checkForSecrets(c, params.Command, params.Description, params.Rspfile, params.RspfileContent)
+}
+
+// checkBuildParamsForSecrets checks the parts of a build statement that are written to the ninja
+// files.
+func checkBuildParamsForSecrets(c *config, params blueprint.BuildParams) error {
+	strs := []string{params.Description}
+	for _, arg := range params.Args {
+		strs = append(strs, arg)
+	}
+	return checkForSecrets(c, strs...)
+}
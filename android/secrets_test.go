@@ -0,0 +1,49 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+
+	"github.com/google/blueprint"
+)
+
+func TestSecretEnvValues(t *testing.T) {
+	c := &config{
+		env: map[string]string{
+			"BUILD_SECRET_ENV_VARS": "SIGNING_TOKEN SHORT_TOKEN UNSET_TOKEN",
+			"SIGNING_TOKEN":         "s3cr3t-t0k3n",
+			"SHORT_TOKEN":           "1",
+		},
+	}
+	c.secretEnvValues = loadSecretEnvValues(c)
+	AssertDeepEquals(t, "secrets", map[string]string{"SIGNING_TOKEN": "s3cr3t-t0k3n"}, c.secretEnvValues)
+	AssertDeepEquals(t, "env deps", map[string]string{
+		"BUILD_SECRET_ENV_VARS": "SIGNING_TOKEN SHORT_TOKEN UNSET_TOKEN",
+	}, c.envDeps)
+
+	AssertErrorMessageEquals(t, "rule with secret",
+		"contains the value of the secret environment variables SIGNING_TOKEN, read them from the "+
+			"environment when the action runs instead",
+		checkRuleParamsForSecrets(c, blueprint.RuleParams{Command: "sign --token s3cr3t-t0k3n $in"}))
+	AssertErrorMessageEquals(t, "build with secret",
+		"contains the value of the secret environment variables SIGNING_TOKEN, read them from the "+
+			"environment when the action runs instead",
+		checkBuildParamsForSecrets(c, blueprint.BuildParams{Args: map[string]string{"token": "s3cr3t-t0k3n"}}))
+
+	if err := checkRuleParamsForSecrets(c, blueprint.RuleParams{Command: `sign --token "$$SIGNING_TOKEN" $in`}); err != nil {
+		t.Errorf("unexpected error reading the secret from the environment: %s", err)
+	}
+}
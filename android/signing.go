@@ -0,0 +1,272 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// This file contains the signing backend abstraction used by the rules that sign
+// APKs, APEXes and AVB images. The backend is selected with the Signing_backend
+// product variable:
+//
+//   - "local" (the default) signs with the keys referenced by the modules, which must
+//     be readable on the builder.
+//   - "hsm" signs through a hardware security module: signapk loads the keys through
+//     a JCA provider and avbtool calls out to a signing helper.
+//   - "remote" does not sign at all. The unsigned artifact is copied to the signed
+//     output path and a signing request is exported to signing_requests.json, so
+//     that release tooling can sign the artifacts and stitch the signatures back in
+//     a later pass without the keys ever touching the builders.
+//
+// Soong doesn't sign the requests of the remote backend itself, the release tooling
+// does, with this contract:
+//
+//  1. `m signing_requests` builds the unsigned artifacts and out/soong/signing_requests.json,
+//     the list of SigningRequests, which is also copied to the dist directory.
+//  2. The tooling signs the Unsigned file of each request with signapk, or an equivalent
+//     signer, using its Certificates and Flags, and writes the result over its Signed file.
+//  3. Rerunning the build then rebuilds everything that packages the signed artifacts, as
+//     their Signed files are newer than the rules reading them. The copies that wrote the
+//     Signed files don't run again, as their outputs are newer than their inputs.
+//
+// The requests of kind "capex" compress an APEX, so they have to be signed in a second
+// round, once the requests of their "apex" have been stitched in and the build rerun.
+
+func init() {
+	RegisterSigningBackend("local", func(Config) SigningBackend { return localSigningBackend{} })
+	RegisterSigningBackend("hsm", newHsmSigningBackend)
+	RegisterSigningBackend("remote", newRemoteSigningBackend)
+
+	InitRegistrationContext.RegisterSingletonType("signing_requests", signingRequestsSingletonFactory)
+}
+
+// SigningBackend is implemented by the ways release artifacts can be signed.
+type SigningBackend interface {
+	// Name returns the name the backend was registered with.
+	Name() string
+
+	// SignapkFlags returns additional flags to pass to signapk.
+	SignapkFlags() []string
+
+	// AvbtoolFlags returns additional flags to pass to avbtool commands that sign.
+	AvbtoolFlags() []string
+
+	// Deferred returns true if signing happens after the build. Rules using a
+	// deferred backend should call BuildDeferredSigning instead of signing.
+	Deferred() bool
+}
+
+// SigningBackendFactory creates a SigningBackend for a Config.
+type SigningBackendFactory func(config Config) SigningBackend
+
+var signingBackends = map[string]SigningBackendFactory{}
+
+// RegisterSigningBackend registers a signing backend that can be selected with the
+// Signing_backend product variable.
+func RegisterSigningBackend(name string, factory SigningBackendFactory) {
+	if _, exists := signingBackends[name]; exists {
+		panic(fmt.Errorf("signing backend %q is already registered", name))
+	}
+	signingBackends[name] = factory
+}
+
+var signingBackendKey = NewOnceKey("signingBackend")
+
+// SigningBackend returns the signing backend selected by the Signing_backend
+// product variable.
+func (c Config) SigningBackend() SigningBackend {
+	return c.Once(signingBackendKey, func() interface{} {
+		// Validate rejects the names of the backends that aren't registered.
+		factory, ok := signingBackends[StringDefault(c.productVariables.Signing_backend, "local")]
+		if !ok {
+			factory = signingBackends["local"]
+		}
+		return factory(c)
+	}).(SigningBackend)
+}
+
+type localSigningBackend struct{}
+
+func (localSigningBackend) Name() string           { return "local" }
+func (localSigningBackend) SignapkFlags() []string { return nil }
+func (localSigningBackend) AvbtoolFlags() []string { return nil }
+func (localSigningBackend) Deferred() bool         { return false }
+
+type hsmSigningBackend struct {
+	providerClass string
+	providerArg   string
+	avbHelper     string
+}
+
+func newHsmSigningBackend(config Config) SigningBackend {
+	// Validate rejects the hsm backend without Signing_hsm_provider_class.
+	return hsmSigningBackend{
+		providerClass: String(config.productVariables.Signing_hsm_provider_class),
+		providerArg:   String(config.productVariables.Signing_hsm_provider_arg),
+		avbHelper:     String(config.productVariables.Signing_avb_helper),
+	}
+}
+
+func (hsmSigningBackend) Name() string { return "hsm" }
+
+func (b hsmSigningBackend) SignapkFlags() []string {
+	flags := []string{"-providerClass", b.providerClass}
+	if b.providerArg != "" {
+		flags = append(flags, "-providerArg", b.providerArg)
+	}
+	return flags
+}
+
+func (b hsmSigningBackend) AvbtoolFlags() []string {
+	if b.avbHelper == "" {
+		return nil
+	}
+	return []string{"--signing_helper_with_files", b.avbHelper}
+}
+
+func (hsmSigningBackend) Deferred() bool { return false }
+
+type remoteSigningBackend struct {
+	avbHelper string
+}
+
+func newRemoteSigningBackend(config Config) SigningBackend {
+	return remoteSigningBackend{
+		avbHelper: String(config.productVariables.Signing_avb_helper),
+	}
+}
+
+func (remoteSigningBackend) Name() string           { return "remote" }
+func (remoteSigningBackend) SignapkFlags() []string { return nil }
+
+// AVB images embed their signatures in the middle of the image, so they are signed
+// through a signing helper that forwards the requests to the remote service instead
+// of being deferred.
+func (b remoteSigningBackend) AvbtoolFlags() []string {
+	if b.avbHelper == "" {
+		return nil
+	}
+	return []string{"--signing_helper_with_files", b.avbHelper}
+}
+
+func (remoteSigningBackend) Deferred() bool { return true }
+
+// SigningRequest describes an artifact whose signing was deferred to a later pass.
+type SigningRequest struct {
+	// The kind of artifact, e.g. "apk" or "apex".
+	Kind string
+
+	// The name of the module that produced the artifact.
+	Module string
+
+	// The unsigned artifact.
+	Unsigned string
+
+	// The path that the signed artifact has to be written to. Until then it
+	// contains a copy of the unsigned artifact.
+	Signed string
+
+	// The certificates to sign with, as passed to signapk.
+	Certificates []string
+
+	// The signapk flags that would have been used to sign locally.
+	Flags []string `json:",omitempty"`
+}
+
+type signingRequests struct {
+	lock     sync.Mutex
+	requests []SigningRequest
+	signed   Paths
+}
+
+var signingRequestsKey = NewOnceKey("signingRequests")
+
+func (c Config) signingRequests() *signingRequests {
+	return c.Once(signingRequestsKey, func() interface{} {
+		return &signingRequests{}
+	}).(*signingRequests)
+}
+
+// BuildDeferredSigning copies unsigned to signed and exports a signing request for
+// it, for use when the selected SigningBackend is deferred.
+func BuildDeferredSigning(ctx ModuleContext, kind string, unsigned Path, signed WritablePath,
+	certificates []string, flags []string, validations Paths) {
+
+	ctx.Build(pctx, BuildParams{
+		Rule:        Cp,
+		Description: "unsigned " + kind,
+		Input:       unsigned,
+		Output:      signed,
+		Validations: validations,
+	})
+
+	requests := ctx.Config().signingRequests()
+	requests.lock.Lock()
+	defer requests.lock.Unlock()
+	requests.requests = append(requests.requests, SigningRequest{
+		Kind:         kind,
+		Module:       ctx.ModuleName(),
+		Unsigned:     unsigned.String(),
+		Signed:       signed.String(),
+		Certificates: certificates,
+		Flags:        flags,
+	})
+	requests.signed = append(requests.signed, signed)
+}
+
+func signingRequestsSingletonFactory() Singleton {
+	return &signingRequestsSingleton{}
+}
+
+type signingRequestsSingleton struct {
+	requestsFile WritablePath
+}
+
+func (s *signingRequestsSingleton) GenerateBuildActions(ctx SingletonContext) {
+	if !ctx.Config().SigningBackend().Deferred() {
+		return
+	}
+
+	requests := ctx.Config().signingRequests()
+	requests.lock.Lock()
+	defer requests.lock.Unlock()
+
+	sorted := append([]SigningRequest(nil), requests.requests...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Signed < sorted[j].Signed
+	})
+
+	data, err := json.MarshalIndent(sorted, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to marshal signing requests: %s", err)
+		return
+	}
+
+	s.requestsFile = PathForOutput(ctx, "signing_requests.json")
+	WriteFileRule(ctx, s.requestsFile, string(data))
+
+	// Building the phony produces everything that needs to be sent for signing.
+	ctx.Phony("signing_requests", append(Paths{s.requestsFile}, requests.signed...)...)
+}
+
+func (s *signingRequestsSingleton) MakeVars(ctx MakeVarsContext) {
+	if s.requestsFile != nil {
+		ctx.DistForGoal("signing_requests", s.requestsFile)
+	}
+}
@@ -160,6 +160,9 @@ func (s *singletonContextAdaptor) Rule(pctx PackageContext, name string, params
 			params.Pool = nil
 		}
 	}
+	if err := checkRuleParamsForSecrets(s.Config().config, params); err != nil {
+		s.Errorf("%s: rule %s %s", s.Name(), name, err)
+	}
 	rule := s.SingletonContext.Rule(pctx.PackageContext, name, params, argNames...)
 	if s.Config().captureBuild {
 		s.ruleParams[rule] = params
@@ -176,6 +179,9 @@ func (s *singletonContextAdaptor) Build(pctx PackageContext, params BuildParams)
 	if err != nil {
 		s.Errorf("%s: build parameter validation failed: %s", s.Name(), err.Error())
 	}
+	if err := checkBuildParamsForSecrets(s.Config().config, bparams); err != nil {
+		s.Errorf("%s: build statement for %s %s", s.Name(), bparams.Outputs, err)
+	}
 	s.SingletonContext.Build(pctx.PackageContext, bparams)
 
 }
@@ -276,6 +282,7 @@ func (s *singletonContextAdaptor) ModuleVariantsFromName(referer Module, name st
 						referer.Name(), depQualified, "//"+s.ModuleDir(referer))
 					continue
 				}
+				recordLegacyPublicDependency(s.Config(), depQualified, qualified)
 			}
 			result = append(result, module)
 		}
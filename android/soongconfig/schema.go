@@ -0,0 +1,298 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package soongconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// VariableType is the type of a variable in a soong config namespace schema.
+type VariableType string
+
+const (
+	// BoolType variables must be set to one of "true", "false", "1", "0", "y", "n", "yes",
+	// "no", "on" or "off", or be left unset.
+	BoolType VariableType = "bool"
+
+	// StringType variables must be set to one of the values listed in the schema.
+	StringType VariableType = "string"
+
+	// ValueType variables can be set to any value.
+	ValueType VariableType = "value"
+)
+
+var boolValues = []string{"1", "0", "y", "n", "yes", "no", "on", "off", "true", "false"}
+
+// Variable describes a variable in a soong config namespace.
+type Variable struct {
+	Name string
+	Type VariableType
+
+	// The value used when the variable is not set by the product.
+	Default string `json:",omitempty"`
+
+	// The values a StringType variable can be set to.
+	Values []string `json:",omitempty"`
+}
+
+// Schema describes the variables of a soong config namespace, i.e. the variables set with
+// SOONG_CONFIG_<namespace>_<name> or PRODUCT_SOONG_CONFIG in Make.
+type Schema struct {
+	Namespace string
+	Variables []Variable
+}
+
+// ParseSchema reads a Schema from its JSON representation.
+func ParseSchema(r io.Reader) (*Schema, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	schema := &Schema{}
+	if err := decoder.Decode(schema); err != nil {
+		return nil, err
+	}
+	if errs := schema.Check(); len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return schema, nil
+}
+
+// Check returns an error for each problem with the schema itself.
+func (s *Schema) Check() []error {
+	var errs []error
+	if s.Namespace == "" {
+		errs = append(errs, fmt.Errorf("namespace must not be blank"))
+	}
+	seen := make(map[string]bool)
+	for _, v := range s.Variables {
+		if err := checkVariableName(v.Name); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", s.Namespace, err))
+			continue
+		}
+		if seen[v.Name] {
+			errs = append(errs, fmt.Errorf("%s: variable %q is declared more than once", s.Namespace, v.Name))
+		}
+		seen[v.Name] = true
+
+		switch v.Type {
+		case BoolType:
+			if len(v.Values) > 0 {
+				errs = append(errs, fmt.Errorf("%s.%s: bool variables cannot list values", s.Namespace, v.Name))
+			}
+		case StringType:
+			if len(v.Values) == 0 {
+				errs = append(errs, fmt.Errorf("%s.%s: string variables must list their values", s.Namespace, v.Name))
+			}
+		case ValueType:
+			if len(v.Values) > 0 {
+				errs = append(errs, fmt.Errorf("%s.%s: value variables cannot list values", s.Namespace, v.Name))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("%s.%s: unknown type %q, expected one of %q, %q or %q",
+				s.Namespace, v.Name, v.Type, BoolType, StringType, ValueType))
+			continue
+		}
+
+		if v.Default != "" {
+			if err := v.checkValue(v.Default); err != nil {
+				errs = append(errs, fmt.Errorf("%s.%s: default %s", s.Namespace, v.Name, err))
+			}
+		}
+	}
+	return errs
+}
+
+func (v Variable) checkValue(value string) error {
+	switch v.Type {
+	case BoolType:
+		if !InList(strings.ToLower(value), boolValues) {
+			return fmt.Errorf("%q is not a bool, expected one of %q", value, boolValues)
+		}
+	case StringType:
+		if !InList(value, v.Values) {
+			return fmt.Errorf("%q is not one of %q", value, v.Values)
+		}
+	}
+	return nil
+}
+
+func (s *Schema) variable(name string) (Variable, bool) {
+	for _, v := range s.Variables {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variable{}, false
+}
+
+// Validate returns an error for each variable in vars that isn't declared in the schema or
+// whose value doesn't match the type of the variable.
+func (s *Schema) Validate(vars map[string]string) []error {
+	var names []string
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		v, ok := s.variable(name)
+		if !ok {
+			err := fmt.Sprintf("unknown variable %q in soong config namespace %q", name, s.Namespace)
+			if guess := s.guess(name); guess != "" {
+				err += fmt.Sprintf(", did you mean %q?", guess)
+			}
+			errs = append(errs, fmt.Errorf("%s", err))
+			continue
+		}
+		if err := v.checkValue(vars[name]); err != nil {
+			errs = append(errs, fmt.Errorf("%s.%s: %s", s.Namespace, name, err))
+		}
+	}
+	return errs
+}
+
+// guess returns the declared variable whose name is closest to name, if any is close enough to
+// be a likely typo.
+func (s *Schema) guess(name string) string {
+	best, bestDistance := "", len(name)/2+1
+	for _, v := range s.Variables {
+		if d := editDistance(name, v.Name); d < bestDistance {
+			best, bestDistance = v.Name, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cur[j] = prev[j-1]
+			if a[i-1] != b[j-1] {
+				cur[j]++
+			}
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// ApplyDefaults returns a copy of vars with the defaults of the variables that aren't set.
+func (s *Schema) ApplyDefaults(vars map[string]string) map[string]string {
+	ret := make(map[string]string, len(vars))
+	for k, v := range vars {
+		ret[k] = v
+	}
+	for _, v := range s.Variables {
+		if _, exists := ret[v.Name]; !exists && v.Default != "" {
+			ret[v.Name] = v.Default
+		}
+	}
+	return ret
+}
+
+// goName converts a soong config name, e.g. "acme_audio", to an exported Go identifier, e.g.
+// "AcmeAudio".
+func goName(name string) string {
+	var sb strings.Builder
+	for _, part := range strings.FieldsFunc(CanonicalizeToProperty(name), func(r rune) bool { return r == '_' }) {
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return sb.String()
+}
+
+var accessorsTemplate = template.Must(template.New("accessors").Funcs(template.FuncMap{
+	"goName": goName,
+}).Parse(`// Code generated by soong_config_accessors. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"android/soong/android"
+	"android/soong/android/soongconfig"
+)
+
+func init() {
+{{- range .Schemas}}
+	android.RegisterVendorConfigSchema({{goName .Namespace | printf "%sSchema"}})
+{{- end}}
+}
+{{range .Schemas}}{{$config := printf "%sConfig" (goName .Namespace)}}
+var {{goName .Namespace | printf "%sSchema"}} = &soongconfig.Schema{
+	Namespace: {{printf "%q" .Namespace}},
+	Variables: []soongconfig.Variable{
+	{{- range .Variables}}
+		{Name: {{printf "%q" .Name}}, Type: {{printf "%q" .Type}}
+		{{- if .Default}}, Default: {{printf "%q" .Default}}{{end}}
+		{{- if .Values}}, Values: []string{ {{- range $i, $v := .Values}}{{if $i}}, {{end}}{{printf "%q" $v}}{{end -}} }{{end}}},
+	{{- end}}
+	},
+}
+
+// {{$config}} provides typed access to the variables of the {{printf "%q" .Namespace}} soong
+// config namespace.
+type {{$config}} struct {
+	config android.VendorConfig
+}
+
+// {{goName .Namespace}} returns the variables of the {{printf "%q" .Namespace}} soong config namespace.
+func {{goName .Namespace}}(config android.Config) {{$config}} {
+	return {{$config}}{config.VendorConfig({{printf "%q" .Namespace}})}
+}
+{{range .Variables}}
+// {{goName .Name}} returns the value of the {{printf "%q" .Name}} variable.
+{{- if eq .Type "bool"}}
+func (c {{$config}}) {{goName .Name}}() bool {
+	return c.config.Bool({{printf "%q" .Name}})
+}
+{{- else}}
+func (c {{$config}}) {{goName .Name}}() string {
+	return c.config.String({{printf "%q" .Name}})
+}
+{{- end}}
+{{end}}{{end}}`))
+
+// GenerateAccessors returns the source of a Go file in package pkg that registers the schemas
+// and declares typed accessors for the variables of their namespaces.
+func GenerateAccessors(pkg string, schemas []*Schema) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	err := accessorsTemplate.Execute(buf, struct {
+		Package string
+		Schemas []*Schema
+	}{pkg, schemas})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
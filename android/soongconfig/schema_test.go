@@ -0,0 +1,149 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package soongconfig
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSchema = `{
+	"Namespace": "acme_audio",
+	"Variables": [
+		{"Name": "use_dsp", "Type": "bool", "Default": "false"},
+		{"Name": "dsp_model", "Type": "string", "Values": ["a", "b"], "Default": "a"},
+		{"Name": "dsp_firmware", "Type": "value"}
+	]
+}`
+
+func errorStrings(errs []error) []string {
+	var ret []string
+	for _, err := range errs {
+		ret = append(ret, err.Error())
+	}
+	return ret
+}
+
+func Test_ParseSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		err    string
+	}{
+		{
+			name:   "valid",
+			schema: testSchema,
+		},
+		{
+			name:   "unknown field",
+			schema: `{"Namespace": "acme", "Variable": []}`,
+			err:    `json: unknown field "Variable"`,
+		},
+		{
+			name:   "unknown type",
+			schema: `{"Namespace": "acme", "Variables": [{"Name": "foo", "Type": "int"}]}`,
+			err:    `acme.foo: unknown type "int", expected one of "bool", "string" or "value"`,
+		},
+		{
+			name:   "string without values",
+			schema: `{"Namespace": "acme", "Variables": [{"Name": "foo", "Type": "string"}]}`,
+			err:    `acme.foo: string variables must list their values`,
+		},
+		{
+			name:   "invalid default",
+			schema: `{"Namespace": "acme", "Variables": [{"Name": "foo", "Type": "string", "Values": ["a"], "Default": "b"}]}`,
+			err:    `acme.foo: default "b" is not one of ["a"]`,
+		},
+		{
+			name:   "duplicate variable",
+			schema: `{"Namespace": "acme", "Variables": [{"Name": "foo", "Type": "bool"}, {"Name": "foo", "Type": "bool"}]}`,
+			err:    `acme: variable "foo" is declared more than once`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSchema(strings.NewReader(tt.schema))
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+			} else if err == nil || err.Error() != tt.err {
+				t.Errorf("expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func Test_SchemaValidate(t *testing.T) {
+	schema, err := ParseSchema(strings.NewReader(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := schema.Validate(map[string]string{
+		"use_dsp":      "True",
+		"dsp_model":    "c",
+		"use_dps":      "true",
+		"dsp_firmware": "anything",
+		"unrelated":    "1",
+	})
+	want := []string{
+		`acme_audio.dsp_model: "c" is not one of ["a" "b"]`,
+		`unknown variable "unrelated" in soong config namespace "acme_audio"`,
+		`unknown variable "use_dps" in soong config namespace "acme_audio", did you mean "use_dsp"?`,
+	}
+	if got := errorStrings(errs); !reflect.DeepEqual(got, want) {
+		t.Errorf("wanted errors %q, got %q", want, got)
+	}
+}
+
+func Test_SchemaApplyDefaults(t *testing.T) {
+	schema, err := ParseSchema(strings.NewReader(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := schema.ApplyDefaults(map[string]string{"use_dsp": "true"})
+	want := map[string]string{"use_dsp": "true", "dsp_model": "a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wanted %q, got %q", want, got)
+	}
+}
+
+func Test_GenerateAccessors(t *testing.T) {
+	schema, err := ParseSchema(strings.NewReader(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := GenerateAccessors("acme", []*Schema{schema})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"package acme\n",
+		"android.RegisterVendorConfigSchema(AcmeAudioSchema)",
+		`{Name: "dsp_model", Type: "string", Default: "a", Values: []string{"a", "b"}},`,
+		"func AcmeAudio(config android.Config) AcmeAudioConfig {",
+		"func (c AcmeAudioConfig) UseDsp() bool {",
+		"func (c AcmeAudioConfig) DspFirmware() string {",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("generated accessors do not contain %q:\n%s", want, data)
+		}
+	}
+}
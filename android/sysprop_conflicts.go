@@ -0,0 +1,240 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/blueprint"
+)
+
+// The system properties of the device are declared by the .sysprop files of sysprop_library
+// modules, and set by the setprop commands of the init .rc files of the modules and by the
+// property files of the device tree listed in PropFiles. The sysprop_conflicts singleton collects
+// all of them during analysis and reports the properties that are declared with different types
+// or owners, set from a partition that doesn't own them, or set to different read-only values by
+// different partitions, which would otherwise only show up as property ownership violations at
+// runtime. The conflicts are written to sysprop_conflicts.json in the Soong output directory, and
+// fail the build unless BUILD_BROKEN_SYSPROP_CONFLICTS is set.
+
+func init() {
+	RegisterSingletonType("sysprop_conflicts", syspropConflictsSingletonFactory)
+}
+
+// SyspropConflictsReportFileName is the name of the report, in the Soong output directory.
+const SyspropConflictsReportFileName = "sysprop_conflicts.json"
+
+// The partitions that system properties can be set from.
+var syspropPartitions = []string{"system", "system_ext", "product", "vendor", "odm"}
+
+// The partitions that can set the properties of each owner of sysprop_library modules.
+var syspropOwnerPartitions = map[string][]string{
+	"Platform": {"system", "system_ext", "product"},
+	"Vendor":   {"vendor", "odm"},
+	"Odm":      {"odm"},
+}
+
+// SyspropDefinition is a declaration of a system property by a sysprop_library, or an assignment
+// of a value to it.
+type SyspropDefinition struct {
+	Name string
+
+	// The type and owner of a property declared by a sysprop_library, empty for an assignment.
+	Type  string `json:",omitempty"`
+	Owner string `json:",omitempty"`
+
+	// The value of an assignment.
+	Value string `json:",omitempty"`
+
+	// The partition declaring or setting the property.
+	Partition string
+
+	// The module declaring or setting the property, empty for the property files of the device.
+	Module string `json:",omitempty"`
+
+	// The file and line declaring or setting the property.
+	Source string
+}
+
+// SyspropDefinitionsInfo is provided by the modules declaring system properties.
+type SyspropDefinitionsInfo struct {
+	Definitions []SyspropDefinition
+}
+
+var SyspropDefinitionsProvider = blueprint.NewProvider(SyspropDefinitionsInfo{})
+
+// SyspropConflict is a system property whose definitions conflict.
+type SyspropConflict struct {
+	Name        string
+	Reason      string
+	Definitions []SyspropDefinition
+}
+
+func (c SyspropConflict) String() string {
+	var sources []string
+	for _, def := range c.Definitions {
+		sources = append(sources, def.Source)
+	}
+	return fmt.Sprintf("property %s is %s (%s)", c.Name, c.Reason, strings.Join(sources, ", "))
+}
+
+// parsePropFile returns the assignments of a property file in the build.prop format, where each
+// line is a name=value or name?=value assignment, an import or a comment.
+func parsePropFile(name, partition string, r io.Reader) ([]SyspropDefinition, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var defs []SyspropDefinition
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "import ") {
+			continue
+		}
+		prop, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("%s:%d: expected <name>=<value>", name, i+1)
+		}
+		defs = append(defs, SyspropDefinition{
+			Name:      strings.TrimSuffix(strings.TrimSpace(prop), "?"),
+			Value:     strings.TrimSpace(value),
+			Partition: partition,
+			Source:    fmt.Sprintf("%s:%d", name, i+1),
+		})
+	}
+	return defs, nil
+}
+
+// findSyspropConflicts returns the conflicts between the definitions of the system properties,
+// sorted by property name.
+func findSyspropConflicts(defs []SyspropDefinition) []SyspropConflict {
+	byName := make(map[string][]SyspropDefinition)
+	for _, def := range defs {
+		byName[def.Name] = append(byName[def.Name], def)
+	}
+
+	var conflicts []SyspropConflict
+	for _, name := range SortedKeys(byName) {
+		defs := byName[name]
+		var types, owners, assignmentPartitions, values []string
+		for _, def := range defs {
+			if def.Type != "" {
+				types = append(types, def.Type)
+				owners = append(owners, def.Owner)
+			} else {
+				assignmentPartitions = append(assignmentPartitions, def.Partition)
+				values = append(values, def.Value)
+			}
+		}
+		types = FirstUniqueStrings(types)
+		owners = FirstUniqueStrings(owners)
+		assignmentPartitions = FirstUniqueStrings(assignmentPartitions)
+		values = FirstUniqueStrings(values)
+
+		conflict := func(format string, args ...interface{}) {
+			conflicts = append(conflicts, SyspropConflict{
+				Name:        name,
+				Reason:      fmt.Sprintf(format, args...),
+				Definitions: defs,
+			})
+		}
+		if len(types) > 1 {
+			conflict("declared with different types %s", strings.Join(types, ", "))
+		}
+		if len(owners) > 1 {
+			conflict("declared by different owners %s", strings.Join(owners, ", "))
+		} else if len(owners) == 1 {
+			for _, partition := range assignmentPartitions {
+				if !InList(partition, syspropOwnerPartitions[owners[0]]) {
+					conflict("owned by %s but set from the %s partition", owners[0], partition)
+				}
+			}
+		}
+		if strings.HasPrefix(name, "ro.") && len(assignmentPartitions) > 1 && len(values) > 1 {
+			conflict("read-only but set to different values by the %s partitions",
+				strings.Join(assignmentPartitions, ", "))
+		}
+	}
+	return conflicts
+}
+
+func syspropConflictsSingletonFactory() Singleton {
+	return &syspropConflictsSingleton{}
+}
+
+type syspropConflictsSingleton struct{}
+
+func (s *syspropConflictsSingleton) GenerateBuildActions(ctx SingletonContext) {
+	var defs []SyspropDefinition
+	ctx.VisitAllModules(func(module Module) {
+		if !module.Enabled() {
+			return
+		}
+		if module.Os() == Android {
+			defs = append(defs, module.base().initRcSetprops...)
+		}
+		if ctx.ModuleHasProvider(module, SyspropDefinitionsProvider) {
+			info := ctx.ModuleProvider(module, SyspropDefinitionsProvider).(SyspropDefinitionsInfo)
+			defs = append(defs, info.Definitions...)
+		}
+	})
+
+	for _, entry := range ctx.DeviceConfig().PropFiles() {
+		partition, path, _ := strings.Cut(entry, ":")
+		f, err := ctx.Config().fs.Open(path)
+		if err != nil {
+			ctx.Errorf("cannot read the property file %s: %s", path, err)
+			continue
+		}
+		ctx.AddNinjaFileDeps(path)
+		propFileDefs, err := parsePropFile(path, partition, f)
+		f.Close()
+		if err != nil {
+			ctx.Errorf("%s", err)
+			continue
+		}
+		defs = append(defs, propFileDefs...)
+	}
+
+	// The variants of a module define the same properties.
+	var uniqueDefs []SyspropDefinition
+	seen := make(map[SyspropDefinition]bool)
+	for _, def := range defs {
+		if !seen[def] {
+			seen[def] = true
+			uniqueDefs = append(uniqueDefs, def)
+		}
+	}
+
+	conflicts := findSyspropConflicts(uniqueDefs)
+	if conflicts == nil {
+		conflicts = []SyspropConflict{}
+	}
+	data, err := json.MarshalIndent(conflicts, "", "  ")
+	if err != nil {
+		ctx.Errorf("cannot marshal the sysprop conflicts report: %s", err)
+		return
+	}
+	WriteFileRule(ctx, PathForOutput(ctx, SyspropConflictsReportFileName), string(data))
+
+	if !ctx.DeviceConfig().BuildBrokenSyspropConflicts() {
+		for _, conflict := range conflicts {
+			ctx.Errorf("%s", conflict)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"strings"
+	"testing"
+)
+
+const testVendorProp = `# Acme vendor properties
+import /vendor/etc/acme.prop
+
+ro.acme.feature=true
+persist.acme.mode?=fast
+ro.build.characteristics=tablet
+`
+
+func TestFindSyspropConflicts(t *testing.T) {
+	defs, err := parsePropFile("device/acme/foo/vendor.prop", "vendor", strings.NewReader(testVendorProp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertDeepEquals(t, "prop file", []SyspropDefinition{
+		{Name: "ro.acme.feature", Value: "true", Partition: "vendor", Source: "device/acme/foo/vendor.prop:4"},
+		{Name: "persist.acme.mode", Value: "fast", Partition: "vendor", Source: "device/acme/foo/vendor.prop:5"},
+		{Name: "ro.build.characteristics", Value: "tablet", Partition: "vendor", Source: "device/acme/foo/vendor.prop:6"},
+	}, defs)
+
+	defs = append(defs,
+		SyspropDefinition{Name: "persist.acme.mode", Type: "String", Owner: "Platform", Partition: "system",
+			Module: "AcmeProperties", Source: "AcmeProperties.sysprop"},
+		SyspropDefinition{Name: "ro.build.characteristics", Value: "default", Partition: "system",
+			Module: "init_system", Source: "system/core/rootdir/init.rc:12"},
+		SyspropDefinition{Name: "sys.acme.ready", Type: "Boolean", Owner: "Vendor", Partition: "vendor",
+			Module: "AcmeVendorProperties", Source: "AcmeVendorProperties.sysprop"},
+		SyspropDefinition{Name: "sys.acme.ready", Type: "Integer", Owner: "Vendor", Partition: "vendor",
+			Module: "OtherVendorProperties", Source: "OtherVendorProperties.sysprop"},
+	)
+
+	var conflicts []string
+	for _, conflict := range findSyspropConflicts(defs) {
+		conflicts = append(conflicts, conflict.String())
+	}
+	AssertDeepEquals(t, "conflicts", []string{
+		"property persist.acme.mode is owned by Platform but set from the vendor partition " +
+			"(device/acme/foo/vendor.prop:5, AcmeProperties.sysprop)",
+		"property ro.build.characteristics is read-only but set to different values by the vendor, system partitions " +
+			"(device/acme/foo/vendor.prop:6, system/core/rootdir/init.rc:12)",
+		"property sys.acme.ready is declared with different types Boolean, Integer " +
+			"(AcmeVendorProperties.sysprop, OtherVendorProperties.sysprop)",
+	}, conflicts)
+}
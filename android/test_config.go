@@ -66,6 +66,8 @@ func TestConfig(buildDir string, env map[string]string, bp string, fs map[string
 		mixedBuildDisabledModules: make(map[string]struct{}),
 		mixedBuildEnabledModules:  make(map[string]struct{}),
 		bazelForceEnabledModules:  make(map[string]struct{}),
+
+		dexpreoptCloudProfiledApps: make(map[string]struct{}),
 	}
 	config.deviceConfig = &deviceConfig{
 		config: config,
@@ -76,7 +78,7 @@ func TestConfig(buildDir string, env map[string]string, bp string, fs map[string
 
 	determineBuildOS(config)
 
-	return Config{config}
+	return Config{config: config}
 }
 
 func modifyTestConfigToSupportArchMutator(testConfig Config) {
@@ -84,12 +86,12 @@ func modifyTestConfigToSupportArchMutator(testConfig Config) {
 
 	config.Targets = map[OsType][]Target{
 		Android: []Target{
-			{Android, Arch{ArchType: Arm64, ArchVariant: "armv8-a", Abi: []string{"arm64-v8a"}}, NativeBridgeDisabled, "", "", false},
-			{Android, Arch{ArchType: Arm, ArchVariant: "armv7-a-neon", Abi: []string{"armeabi-v7a"}}, NativeBridgeDisabled, "", "", false},
+			{Android, Arch{ArchType: Arm64, ArchVariant: "armv8-a", Abi: []string{"arm64-v8a"}}, NativeBridgeDisabled, "", "", false, ""},
+			{Android, Arch{ArchType: Arm, ArchVariant: "armv7-a-neon", Abi: []string{"armeabi-v7a"}}, NativeBridgeDisabled, "", "", false, ""},
 		},
 		config.BuildOS: []Target{
-			{config.BuildOS, Arch{ArchType: X86_64}, NativeBridgeDisabled, "", "", false},
-			{config.BuildOS, Arch{ArchType: X86}, NativeBridgeDisabled, "", "", false},
+			{config.BuildOS, Arch{ArchType: X86_64}, NativeBridgeDisabled, "", "", false, ""},
+			{config.BuildOS, Arch{ArchType: X86}, NativeBridgeDisabled, "", "", false, ""},
 		},
 	}
 
@@ -116,8 +118,8 @@ func ModifyTestConfigForMusl(config Config) {
 	config.productVariables.HostMusl = boolPtr(true)
 	determineBuildOS(config.config)
 	config.Targets[config.BuildOS] = []Target{
-		{config.BuildOS, Arch{ArchType: X86_64}, NativeBridgeDisabled, "", "", false},
-		{config.BuildOS, Arch{ArchType: X86}, NativeBridgeDisabled, "", "", false},
+		{config.BuildOS, Arch{ArchType: X86_64}, NativeBridgeDisabled, "", "", false, ""},
+		{config.BuildOS, Arch{ArchType: X86}, NativeBridgeDisabled, "", "", false, ""},
 	}
 
 	config.BuildOSTarget = config.Targets[config.BuildOS][0]
@@ -126,7 +128,7 @@ func ModifyTestConfigForMusl(config Config) {
 
 func modifyTestConfigForMuslArm64HostCross(config Config) {
 	config.Targets[LinuxMusl] = append(config.Targets[LinuxMusl],
-		Target{config.BuildOS, Arch{ArchType: Arm64}, NativeBridgeDisabled, "", "", true})
+		Target{config.BuildOS, Arch{ArchType: Arm64}, NativeBridgeDisabled, "", "", true, ""})
 }
 
 // TestArchConfig returns a Config object suitable for using for tests that
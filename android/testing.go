@@ -449,6 +449,7 @@ func (ctx *TestContext) Register() {
 	mutators := collateRegisteredMutators(ctx.preArch, ctx.preDeps, ctx.postDeps, ctx.finalDeps)
 	// Ensure that the mutators used in the test are in the same order as they are used at runtime.
 	globalOrder.mutatorOrder.enforceOrdering(mutators)
+	mutators = applyMutatorDebugOptions(ctx.config, mutators)
 	mutators.registerAll(ctx.Context)
 
 	// Ensure that the singletons used in the test are in the same order as they are used at runtime.
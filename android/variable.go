@@ -15,6 +15,7 @@
 package android
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"runtime"
@@ -94,6 +95,16 @@ type variableProperties struct {
 			Cflags []string `android:"arch_variant"`
 		} `android:"arch_variant"`
 
+		// The default sample rate and maximum number of simultaneous allocations of GWP-ASan,
+		// for the malloc integration of libc, e.g. cflags: ["-DGWP_ASAN_SAMPLE_RATE=%d"].
+		Gwp_asan_sample_rate struct {
+			Cflags []string `android:"arch_variant"`
+		} `android:"arch_variant"`
+
+		Gwp_asan_max_allocs struct {
+			Cflags []string `android:"arch_variant"`
+		} `android:"arch_variant"`
+
 		Safestack struct {
 			Cflags []string `android:"arch_variant"`
 		} `android:"arch_variant"`
@@ -192,6 +203,106 @@ type variableProperties struct {
 
 var defaultProductVariables interface{} = variableProperties{}
 
+// SystemSharedLibsOverride replaces the default system shared libraries of the native modules in
+// some directories, e.g. to build them against an experimental fork of bionic.
+type SystemSharedLibsOverride struct {
+	// The directories whose modules use the replacements, e.g. "vendor/acme/experiments".
+	Paths []string
+
+	// The replacement of each default system shared library, e.g. {"libc": "libc_acme"}. Only
+	// libc, libm and libdl can be replaced.
+	Replacements map[string]string
+}
+
+// AAPTResourceFilter is the aapt2 resource filtering policy of the apps of some partitions or of
+// a group of apps, so that a size constrained device drops the locales and densities its apps
+// don't need consistently, instead of through the properties of each app.
+type AAPTResourceFilter struct {
+	// The partitions whose apps use the policy, e.g. "product" or "system_ext".
+	Partitions []string `json:",omitempty"`
+
+	// The apps that use the policy, by module name. The policy that lists an app has a priority
+	// over the policy of its partition.
+	Apps []string `json:",omitempty"`
+
+	// The locales kept in the resources of the apps, e.g. "en_US" or "fr". All the locales are
+	// kept if empty.
+	Locales []string `json:",omitempty"`
+
+	// The densities kept in the resources of the apps, e.g. "xhdpi", instead of the
+	// configurations of AAPTConfig.
+	Densities []string `json:",omitempty"`
+
+	// The preferred density of the apps, instead of AAPTPreferredConfig.
+	PreferredDensity *string `json:",omitempty"`
+}
+
+// AAPTConfig returns the configurations of the resources kept by the filter, for the -c flag of
+// aapt2 link, given the AAPTConfig of the product.
+func (f AAPTResourceFilter) AAPTConfig(productConfig []string) []string {
+	densities := f.Densities
+	if len(densities) == 0 {
+		densities = productConfig
+	}
+	return append(append([]string(nil), f.Locales...), densities...)
+}
+
+// AAPTPreferredConfig returns the preferred density of the apps using the filter, given the
+// AAPTPreferredConfig of the product.
+func (f AAPTResourceFilter) AAPTPreferredConfig(productPreferredConfig string) string {
+	if f.PreferredDensity != nil {
+		return *f.PreferredDensity
+	}
+	return productPreferredConfig
+}
+
+// DeviceTargetVariables are the architecture variables of an additional device of a
+// multi-device product, e.g. a wearable companion built alongside a phone.
+type DeviceTargetVariables struct {
+	DeviceArch        *string  `json:",omitempty"`
+	DeviceArchVariant *string  `json:",omitempty"`
+	DeviceCpuVariant  *string  `json:",omitempty"`
+	DeviceAbi         []string `json:",omitempty"`
+
+	DeviceSecondaryArch        *string  `json:",omitempty"`
+	DeviceSecondaryArchVariant *string  `json:",omitempty"`
+	DeviceSecondaryCpuVariant  *string  `json:",omitempty"`
+	DeviceSecondaryAbi         []string `json:",omitempty"`
+}
+
+// ClangPlugin is a prebuilt clang plugin, e.g. a vendor security checker, that a product loads
+// into the compiles of the C and C++ sources of the native modules in some directories.
+type ClangPlugin struct {
+	// The name that the plugin registers itself with.
+	Name string
+
+	// The path of the plugin shared library, relative to the top of the source tree.
+	Path string
+
+	// The versions of the prebuilt clang that the plugin is built against, like "clang-r487747c".
+	ClangVersions []string
+
+	// The arguments passed to the plugin.
+	Args []string `json:",omitempty"`
+
+	// The directories whose native modules load the plugin, and the ones among them that don't.
+	Paths        []string
+	ExcludePaths []string `json:",omitempty"`
+}
+
+// ActionPoolConfig sets the depth of an action pool, a ninja pool limiting how many memory-heavy
+// actions of a kind run in parallel, and the modules whose actions run in it.
+type ActionPoolConfig struct {
+	// The name of the pool: "metalava", "d8" or "lld".
+	Name string
+
+	// The number of actions of the pool that run in parallel.
+	Depth int
+
+	// The modules whose actions run in the pool, or all the modules if empty.
+	Modules []string `json:",omitempty"`
+}
+
 type productVariables struct {
 	// Suffix to add to generated Makefiles
 	Make_suffix *string `json:",omitempty"`
@@ -199,6 +310,10 @@ type productVariables struct {
 	BuildId         *string `json:",omitempty"`
 	BuildNumberFile *string `json:",omitempty"`
 
+	// Path of a file in the source tree containing the seconds since the unix epoch that are used
+	// as the timestamp of the files of the reproducible filesystem images.
+	BuildTimestampSource *string `json:",omitempty"`
+
 	Platform_version_name                     *string  `json:",omitempty"`
 	Platform_sdk_version                      *int     `json:",omitempty"`
 	Platform_sdk_codename                     *string  `json:",omitempty"`
@@ -235,6 +350,9 @@ type productVariables struct {
 	DeviceSecondaryCpuVariant  *string  `json:",omitempty"`
 	DeviceSecondaryAbi         []string `json:",omitempty"`
 
+	// The additional devices of a multi-device product, keyed by device name.
+	DeviceTargets map[string]DeviceTargetVariables `json:",omitempty"`
+
 	NativeBridgeArch         *string  `json:",omitempty"`
 	NativeBridgeArchVariant  *string  `json:",omitempty"`
 	NativeBridgeCpuVariant   *string  `json:",omitempty"`
@@ -251,6 +369,10 @@ type productVariables struct {
 	HostSecondaryArch *string `json:",omitempty"`
 	HostMusl          *bool   `json:",omitempty"`
 
+	// The architecture of an additional Linux host target, e.g. arm64 to cross-compile the host
+	// tools for arm64 build servers.
+	HostCrossArch *string `json:",omitempty"`
+
 	CrossHost              *string `json:",omitempty"`
 	CrossHostArch          *string `json:",omitempty"`
 	CrossHostSecondaryArch *string `json:",omitempty"`
@@ -265,9 +387,16 @@ type productVariables struct {
 	AAPTPreferredConfig *string  `json:",omitempty"`
 	AAPTPrebuiltDPI     []string `json:",omitempty"`
 
+	AAPTResourceFilters []AAPTResourceFilter `json:",omitempty"`
+
 	DefaultAppCertificate           *string `json:",omitempty"`
 	MainlineSepolicyDevCertificates *string `json:",omitempty"`
 
+	Signing_backend            *string `json:",omitempty"`
+	Signing_hsm_provider_class *string `json:",omitempty"`
+	Signing_hsm_provider_arg   *string `json:",omitempty"`
+	Signing_avb_helper         *string `json:",omitempty"`
+
 	AppsDefaultVersionName *string `json:",omitempty"`
 
 	Allow_missing_dependencies   *bool    `json:",omitempty"`
@@ -280,6 +409,8 @@ type productVariables struct {
 	Malloc_not_svelte_libc32     *bool    `json:",omitempty"`
 	Malloc_zero_contents         *bool    `json:",omitempty"`
 	Malloc_pattern_fill_contents *bool    `json:",omitempty"`
+	Gwp_asan_sample_rate         *int     `json:",omitempty"`
+	Gwp_asan_max_allocs          *int     `json:",omitempty"`
 	Safestack                    *bool    `json:",omitempty"`
 	HostStaticBinaries           *bool    `json:",omitempty"`
 	Binder32bit                  *bool    `json:",omitempty"`
@@ -288,6 +419,7 @@ type productVariables struct {
 	UseRBEJAVAC                  *bool    `json:",omitempty"`
 	UseRBER8                     *bool    `json:",omitempty"`
 	UseRBED8                     *bool    `json:",omitempty"`
+	UseRBECacheOnly              *bool    `json:",omitempty"`
 	Debuggable                   *bool    `json:",omitempty"`
 	Eng                          *bool    `json:",omitempty"`
 	Treble_linker_namespaces     *bool    `json:",omitempty"`
@@ -296,7 +428,8 @@ type productVariables struct {
 	Arc                          *bool    `json:",omitempty"`
 	MinimizeJavaDebugInfo        *bool    `json:",omitempty"`
 
-	Check_elf_files *bool `json:",omitempty"`
+	Check_elf_files           *bool    `json:",omitempty"`
+	PrebuiltElfCheckAllowlist []string `json:",omitempty"`
 
 	UncompressPrivAppDex             *bool    `json:",omitempty"`
 	ModulesLoadedByPrivilegedModules []string `json:",omitempty"`
@@ -323,8 +456,10 @@ type productVariables struct {
 	ProductPath   *string `json:",omitempty"`
 	SystemExtPath *string `json:",omitempty"`
 
-	ClangTidy  *bool   `json:",omitempty"`
-	TidyChecks *string `json:",omitempty"`
+	ClangTidy     *bool    `json:",omitempty"`
+	TidyChecks    *string  `json:",omitempty"`
+	TidyProfiles  []string `json:",omitempty"`
+	TidyBaselines []string `json:",omitempty"`
 
 	JavaCoveragePaths        []string `json:",omitempty"`
 	JavaCoverageExcludePaths []string `json:",omitempty"`
@@ -343,6 +478,9 @@ type productVariables struct {
 	SanitizeDeviceDiag []string `json:",omitempty"`
 	SanitizeDeviceArch []string `json:",omitempty"`
 
+	SanitizeHostTools           []string `json:",omitempty"`
+	SanitizeHostToolsSanitizers []string `json:",omitempty"`
+
 	ArtUseReadBarrier *bool `json:",omitempty"`
 
 	BtConfigIncludeDir *string `json:",omitempty"`
@@ -357,6 +495,8 @@ type productVariables struct {
 
 	NamespacesToExport []string `json:",omitempty"`
 
+	ModuleAliases map[string]string `json:",omitempty"`
+
 	PgoAdditionalProfileDirs []string `json:",omitempty"`
 
 	VndkUseCoreVariant         *bool `json:",omitempty"`
@@ -399,7 +539,9 @@ type productVariables struct {
 
 	VendorVars map[string]map[string]string `json:",omitempty"`
 
-	Ndk_abis *bool `json:",omitempty"`
+	Ndk_abis       *bool    `json:",omitempty"`
+	Ndk_abis_list  []string `json:",omitempty"`
+	Ndk_api_levels []string `json:",omitempty"`
 
 	TrimmedApex                  *bool `json:",omitempty"`
 	Flatten_apex                 *bool `json:",omitempty"`
@@ -438,13 +580,74 @@ type productVariables struct {
 	EnforceInterPartitionJavaSdkLibrary *bool    `json:",omitempty"`
 	InterPartitionJavaLibraryAllowList  []string `json:",omitempty"`
 
+	DefaultVisibility map[string][]string `json:",omitempty"`
+
 	InstallExtraFlattenedApexes *bool `json:",omitempty"`
 
+	EnforceApexSharedLibDedup   *bool    `json:",omitempty"`
+	ApexSharedLibDedupAllowlist []string `json:",omitempty"`
+
+	SystemSharedLibsOverrides []SystemSharedLibsOverride `json:",omitempty"`
+
+	WarningsAllowedPaths  []string `json:",omitempty"`
+	WarningsAsErrorsPaths []string `json:",omitempty"`
+	WarningBaselines      []string `json:",omitempty"`
+
+	RestrictedFlagsAllowedPaths map[string][]string `json:",omitempty"`
+	EnforceRestrictedFlags      *bool               `json:",omitempty"`
+
+	StgAbiMonitoring   *string `json:",omitempty"`
+	StgAbiReferenceDir *string `json:",omitempty"`
+
+	SdclangPath         *string  `json:",omitempty"`
+	SdclangPaths        []string `json:",omitempty"`
+	SdclangExcludePaths []string `json:",omitempty"`
+
+	CcCompilerCache    *string `json:",omitempty"`
+	CcCompilerCacheDir *string `json:",omitempty"`
+
+	TargetGlobalExtraCflags  []string `json:",omitempty"`
+	TargetGlobalExtraLdflags []string `json:",omitempty"`
+
+	Riscv64Isa *string `json:",omitempty"`
+
+	SplitDwarfPaths        []string `json:",omitempty"`
+	SplitDwarfExcludePaths []string `json:",omitempty"`
+
+	StaticAnalysisSarifPaths        []string `json:",omitempty"`
+	StaticAnalysisSarifExcludePaths []string `json:",omitempty"`
+
+	ClangPlugins []ClangPlugin `json:",omitempty"`
+
+	NetworkIsolatedActions *bool    `json:",omitempty"`
+	AllowNetworkActions    []string `json:",omitempty"`
+
+	SandboxedGenrules   *bool    `json:",omitempty"`
+	UnsandboxedGenrules []string `json:",omitempty"`
+
+	GenruleRemoteExecutionPaths []string `json:",omitempty"`
+
+	ActionPools []ActionPoolConfig `json:",omitempty"`
+
+	OutputAttestationLockfile *string  `json:",omitempty"`
+	OutputAttestationModules  []string `json:",omitempty"`
+
+	// The values of the product variables registered with RegisterProductVariable, which are
+	// decoded from the top level of soong.variables along with the built-in variables.
+	RegisteredVariables map[string]json.RawMessage `json:"-"`
+
 	BoardUsesRecoveryAsBoot *bool `json:",omitempty"`
 
 	BoardKernelBinaries                []string `json:",omitempty"`
 	BoardKernelModuleInterfaceVersions []string `json:",omitempty"`
 
+	KernelModuleSigningKey  *string `json:",omitempty"`
+	KernelModuleSigningCert *string `json:",omitempty"`
+
+	// The install tags, e.g. "debug", enabled in the images of the product. Modules with install
+	// tags are only packaged in the images if all of their tags are enabled.
+	ImageInstallTags []string `json:",omitempty"`
+
 	BoardMoveRecoveryResourcesToVendorBoot *bool `json:",omitempty"`
 
 	PrebuiltHiddenApiDir *string `json:",omitempty"`
@@ -456,6 +659,7 @@ type productVariables struct {
 	BuildBrokenClangProperty           bool     `json:",omitempty"`
 	BuildBrokenDepfile                 *bool    `json:",omitempty"`
 	BuildBrokenEnforceSyspropOwner     bool     `json:",omitempty"`
+	BuildBrokenSyspropConflicts        bool     `json:",omitempty"`
 	BuildBrokenTrebleSyspropNeverallow bool     `json:",omitempty"`
 	BuildBrokenUsesSoongPython2Modules bool     `json:",omitempty"`
 	BuildBrokenVendorPropertyNamespace bool     `json:",omitempty"`
@@ -479,7 +683,35 @@ type productVariables struct {
 	IncludeTags    []string `json:",omitempty"`
 	SourceRootDirs []string `json:",omitempty"`
 
-	AfdoProfiles []string `json:",omitempty"`
+	AfdoProfiles         []string `json:",omitempty"`
+	PropellerProfiles    []string `json:",omitempty"`
+	DexpreoptAppProfiles []string `json:",omitempty"`
+
+	DexpreoptCloudProfilesDir *string `json:",omitempty"`
+
+	// Globs of the packages not to dexpreopt. An app is matched by the package name it is renamed
+	// to by package_name or PRODUCT_MANIFEST_PACKAGE_NAME_OVERRIDES, and otherwise by its module
+	// name, as the package in its manifest is only known at build time. Other modules are matched
+	// by their module name.
+	DexpreoptDisabledPackages []string `json:",omitempty"`
+
+	BootImageProfile        *string  `json:",omitempty"`
+	BootImageCompilerFilter *string  `json:",omitempty"`
+	BootImageFormats        []string `json:",omitempty"`
+
+	GpuDriverVersions []string `json:",omitempty"`
+
+	PropFiles []string `json:",omitempty"`
+
+	ThinLTOCacheDir           *string `json:",omitempty"`
+	ThinLTOCacheSizePercent   *int    `json:",omitempty"`
+	ThinLTOCacheSizeBytes     *string `json:",omitempty"`
+	ThinLTOCachePruneInterval *string `json:",omitempty"`
+	ThinLTOCachePruneAfter    *string `json:",omitempty"`
+
+	SoongBuildMemoryLimit *string `json:",omitempty"`
+
+	HostLinker *string `json:",omitempty"`
 
 	ProductManufacturer string   `json:",omitempty"`
 	ProductBrand        string   `json:",omitempty"`
@@ -966,6 +1198,12 @@ func VariableMutator(mctx BottomUpMutatorContext) {
 		name := variableValues.Type().Field(i).Name
 		property := "product_variables." + proptools.PropertyNameForField(name)
 
+		// Check if any properties were set for the module
+		if variableValue.IsZero() {
+			continue
+		}
+		mctx.Config().recordProductVariableDep(name)
+
 		// Check that the variable was set for the product
 		val := productVariables.FieldByName(name)
 		if !val.IsValid() || val.Kind() != reflect.Ptr || val.IsNil() {
@@ -978,11 +1216,6 @@ func VariableMutator(mctx BottomUpMutatorContext) {
 		if val.Kind() == reflect.Bool && val.Bool() == false {
 			continue
 		}
-
-		// Check if any properties were set for the module
-		if variableValue.IsZero() {
-			continue
-		}
 		a.setVariableProperties(mctx, property, variableValue, val.Interface())
 	}
 }
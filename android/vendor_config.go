@@ -0,0 +1,47 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+
+	"android/soong/android/soongconfig"
+)
+
+// Soong config namespaces with a registered schema are type checked: every variable the product
+// sets in the namespace must be declared by the schema and have a value of the declared type,
+// and the variables that aren't set use the schema's defaults.
+//
+// Schemas are usually registered by the Go code generated by soong_config_accessors, which also
+// declares typed accessors for the variables of each namespace.
+
+var vendorConfigSchemas = map[string]*soongconfig.Schema{}
+
+// RegisterVendorConfigSchema registers the schema of a soong config namespace.
+func RegisterVendorConfigSchema(schema *soongconfig.Schema) {
+	if errs := schema.Check(); len(errs) > 0 {
+		panic(fmt.Errorf("invalid soong config schema: %s", errs[0]))
+	}
+	if _, exists := vendorConfigSchemas[schema.Namespace]; exists {
+		panic(fmt.Errorf("soong config namespace %q already has a schema", schema.Namespace))
+	}
+	vendorConfigSchemas[schema.Namespace] = schema
+}
+
+// VendorConfigSchema returns the schema registered for a soong config namespace, or nil if
+// the namespace is untyped.
+func VendorConfigSchema(namespace string) *soongconfig.Schema {
+	return vendorConfigSchemas[namespace]
+}
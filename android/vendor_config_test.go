@@ -0,0 +1,50 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+
+	"android/soong/android/soongconfig"
+)
+
+func TestVendorConfigSchema(t *testing.T) {
+	RegisterVendorConfigSchema(&soongconfig.Schema{
+		Namespace: "test_vendor_config_schema",
+		Variables: []soongconfig.Variable{
+			{Name: "use_dsp", Type: soongconfig.BoolType},
+			{Name: "dsp_model", Type: soongconfig.StringType, Values: []string{"a", "b"}, Default: "a"},
+		},
+	})
+	defer delete(vendorConfigSchemas, "test_vendor_config_schema")
+
+	config := TestConfig(t.TempDir(), nil, "", nil)
+	config.productVariables.VendorVars = map[string]map[string]string{
+		"test_vendor_config_schema": {"use_dsp": "true"},
+		"untyped":                   {"anything": "goes"},
+	}
+
+	AssertBoolEquals(t, "use_dsp", true, config.VendorConfig("test_vendor_config_schema").Bool("use_dsp"))
+	AssertStringEquals(t, "dsp_model default", "a", config.VendorConfig("test_vendor_config_schema").String("dsp_model"))
+	AssertStringEquals(t, "untyped", "goes", config.VendorConfig("untyped").String("anything"))
+	AssertDeepEquals(t, "valid vendor vars", nil, config.Validate())
+
+	config.productVariables.VendorVars["test_vendor_config_schema"] = map[string]string{"use_dps": "true"}
+	AssertErrorMessageEquals(t, "invalid vendor vars",
+		"invalid product variables in soong.variables:\n"+
+			`    VendorVars[test_vendor_config_schema]=map["use_dps":"true"]: `+
+			`unknown variable "use_dps" in soong config namespace "test_vendor_config_schema", did you mean "use_dsp"?`,
+		config.Validate())
+}
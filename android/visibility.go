@@ -38,7 +38,8 @@ import (
 //   qualifiedModuleName instance, i.e. //<pkg>:<name>. The map is stored in the context rather
 //   than a global variable for testing. Each test has its own Config so they do not share a map
 //   and so can be run in parallel. If a module has no visibility specified then it uses the
-//   default package visibility if specified.
+//   default package visibility if specified, and otherwise the visibility the DefaultVisibility
+//   product variable specifies for its directory. See visibility_defaults.go.
 //
 // * Fourth stage works top down and iterates over all the deps for each module. If the dep is in
 //   the same package then it is automatically visible. Otherwise, for each dep it first extracts
@@ -212,6 +213,7 @@ func registerVisibilityMutators(ctx RegistrationContext) {
 	ctx.PreArchMutators(RegisterVisibilityRuleChecker)
 	ctx.PreArchMutators(RegisterVisibilityRuleGatherer)
 	ctx.PostDepsMutators(RegisterVisibilityRuleEnforcer)
+	registerVisibilityDefaultsBuildComponents(ctx)
 }
 
 // The rule checker needs to be registered before defaults expansion to correctly check that
@@ -460,7 +462,9 @@ func visibilityRuleEnforcer(ctx TopDownMutatorContext) {
 		rule := effectiveVisibilityRules(ctx.Config(), depQualified)
 		if !rule.matches(qualified) {
 			ctx.ModuleErrorf("depends on %s which is not visible to this module\nYou may need to add %q to its visibility", depQualified, "//"+ctx.ModuleDir())
+			return
 		}
+		recordLegacyPublicDependency(ctx.Config(), depQualified, qualified)
 	})
 }
 
@@ -469,8 +473,9 @@ var defaultVisibility = compositeRule{publicRule{}}
 
 // Return the effective visibility rules.
 //
-// If no rules have been specified this will return the default visibility rule
-// which is currently //visibility:public.
+// If no rules have been specified by the module or its package then this will return the
+// rules the DefaultVisibility product variable specifies for the module's directory, and
+// otherwise the default visibility rule which is currently //visibility:public.
 func effectiveVisibilityRules(config Config, qualified qualifiedModuleName) compositeRule {
 	moduleToVisibilityRule := moduleToVisibilityRuleMap(config)
 	value, ok := moduleToVisibilityRule.Load(qualified)
@@ -481,6 +486,10 @@ func effectiveVisibilityRules(config Config, qualified qualifiedModuleName) comp
 		rule = packageDefaultVisibility(config, qualified)
 	}
 
+	if rule == nil {
+		rule = pathDefaultVisibility(config, qualified.pkg)
+	}
+
 	// If no rule is specified then return the default visibility rule to avoid
 	// every caller having to treat nil as public.
 	if rule == nil {
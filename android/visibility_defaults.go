@@ -0,0 +1,265 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Product configurable default visibility.
+//
+// The DefaultVisibility product variable maps a directory to the visibility rules used by the
+// modules under it that specify neither a visibility property nor have a package
+// default_visibility, e.g.
+//
+//	"DefaultVisibility": {
+//	    "device": ["//device:__subpackages__"],
+//	    "device/google/shared": ["//visibility:legacy_public"]
+//	}
+//
+// The rules of the deepest matching directory are used. Only modules that don't match any
+// directory fall back to the global default of //visibility:legacy_public.
+//
+// Every module that is depended upon from another package only because of the legacy public
+// default is listed in visibility_legacy_public.json, along with the packages that depend on
+// it, so that downstream trees can tighten their visibility one directory at a time.
+
+func init() {
+	registerVisibilityDefaultsBuildComponents(InitRegistrationContext)
+}
+
+// Registers the report of the modules visible only because of the legacy public default. The
+// visibility mutators themselves are hard coded in mutator.go.
+func registerVisibilityDefaultsBuildComponents(ctx RegistrationContext) {
+	ctx.RegisterSingletonType("visibility_legacy_public_report", visibilityLegacyPublicReportSingletonFactory)
+}
+
+// visibilityRule for //visibility:legacy_public
+//
+// It can only be used in the DefaultVisibility product variable, to restore the legacy public
+// default for a directory inside one that has a stricter default visibility.
+type legacyPublicRule struct{}
+
+func (r legacyPublicRule) matches(_ qualifiedModuleName) bool {
+	return true
+}
+
+func (r legacyPublicRule) String() string {
+	return "//visibility:legacy_public"
+}
+
+// parseDefaultVisibility parses the rules the DefaultVisibility product variable specifies for
+// dir. It supports the same rules as the visibility property, except that
+// //visibility:legacy_public is allowed and //visibility:override is not.
+func parseDefaultVisibility(dir string, visibility []string) (compositeRule, error) {
+	if len(visibility) == 0 {
+		return nil, fmt.Errorf("must contain at least one visibility rule")
+	}
+
+	rules := make(compositeRule, 0, len(visibility))
+	for _, v := range visibility {
+		matches := visibilityRuleRegexp.FindStringSubmatch(v)
+		if v == "" || matches == nil {
+			return nil, fmt.Errorf("invalid visibility pattern %q", v)
+		}
+		pkg, name := matches[1], matches[2]
+		if pkg == "" {
+			pkg = dir
+		}
+		if name == "" {
+			name = "__pkg__"
+		}
+
+		if pkg == "visibility" {
+			if len(visibility) != 1 {
+				return nil, fmt.Errorf("cannot mix %q with any other visibility rules", v)
+			}
+			switch name {
+			case "public":
+				return compositeRule{publicRule{}}, nil
+			case "private":
+				return compositeRule{privateRule{}}, nil
+			case "legacy_public":
+				return compositeRule{legacyPublicRule{}}, nil
+			default:
+				return nil, fmt.Errorf("unrecognized visibility rule %q", v)
+			}
+		}
+
+		switch name {
+		case "__pkg__":
+			rules = append(rules, packageRule{pkg})
+		case "__subpackages__":
+			rules = append(rules, subpackagesRule{pkg})
+		default:
+			return nil, fmt.Errorf("invalid visibility pattern %q", v)
+		}
+	}
+	return rules, nil
+}
+
+// normalizeDefaultVisibilityDir converts the directories used as keys of DefaultVisibility,
+// e.g. "//device/" or "device", to a package path, e.g. "device".
+func normalizeDefaultVisibilityDir(dir string) string {
+	return strings.Trim(dir, "/")
+}
+
+type pathDefaultVisibilityRule struct {
+	dir  string
+	rule compositeRule
+}
+
+var pathDefaultVisibilityKey = NewOnceKey("pathDefaultVisibility")
+
+// pathDefaultVisibilityRules returns the parsed DefaultVisibility product variable, deepest
+// directories first. Invalid rules are ignored here as they are reported by Config.Validate.
+func pathDefaultVisibilityRules(config Config) []pathDefaultVisibilityRule {
+	return config.Once(pathDefaultVisibilityKey, func() interface{} {
+		var rules []pathDefaultVisibilityRule
+		for dir, visibility := range config.productVariables.DefaultVisibility {
+			dir = normalizeDefaultVisibilityDir(dir)
+			if rule, err := parseDefaultVisibility(dir, visibility); err == nil {
+				rules = append(rules, pathDefaultVisibilityRule{dir, rule})
+			}
+		}
+		sort.Slice(rules, func(i, j int) bool {
+			if len(rules[i].dir) != len(rules[j].dir) {
+				return len(rules[i].dir) > len(rules[j].dir)
+			}
+			return rules[i].dir < rules[j].dir
+		})
+		return rules
+	}).([]pathDefaultVisibilityRule)
+}
+
+// pathDefaultVisibility returns the visibility rules the DefaultVisibility product variable
+// specifies for the modules in pkg, or nil if the modules use the legacy public default.
+func pathDefaultVisibility(config Config, pkg string) compositeRule {
+	for _, r := range pathDefaultVisibilityRules(config) {
+		if r.dir == "" || isAncestor(r.dir, pkg) {
+			if _, ok := r.rule[0].(legacyPublicRule); ok {
+				return nil
+			}
+			return r.rule
+		}
+	}
+	return nil
+}
+
+// isLegacyPublicVisibility returns true if the module has no visibility rules of its own, from
+// its package or from the DefaultVisibility product variable, and so is only visible to other
+// packages because of the legacy public default.
+func isLegacyPublicVisibility(config Config, qualified qualifiedModuleName) bool {
+	if _, ok := moduleToVisibilityRuleMap(config).Load(qualified); ok {
+		return false
+	}
+	if packageDefaultVisibility(config, qualified) != nil {
+		return false
+	}
+	return pathDefaultVisibility(config, qualified.pkg) == nil
+}
+
+type legacyPublicDependencies struct {
+	lock sync.Mutex
+
+	// The packages that depend on each module that is only visible to them because of the
+	// legacy public default.
+	dependents map[qualifiedModuleName]map[string]bool
+}
+
+var legacyPublicDependenciesKey = NewOnceKey("legacyPublicDependencies")
+
+func getLegacyPublicDependencies(config Config) *legacyPublicDependencies {
+	return config.Once(legacyPublicDependenciesKey, func() interface{} {
+		return &legacyPublicDependencies{
+			dependents: make(map[qualifiedModuleName]map[string]bool),
+		}
+	}).(*legacyPublicDependencies)
+}
+
+// recordLegacyPublicDependency records the dependency of the module qualified on the module dep
+// in the legacy public report if dep is only visible to it because of the legacy public default.
+func recordLegacyPublicDependency(config Config, dep, qualified qualifiedModuleName) {
+	if !isLegacyPublicVisibility(config, dep) {
+		return
+	}
+
+	deps := getLegacyPublicDependencies(config)
+	deps.lock.Lock()
+	defer deps.lock.Unlock()
+	if deps.dependents[dep] == nil {
+		deps.dependents[dep] = make(map[string]bool)
+	}
+	deps.dependents[dep]["//"+qualified.pkg] = true
+}
+
+// LegacyPublicVisibilityEntry is an entry in visibility_legacy_public.json.
+type LegacyPublicVisibilityEntry struct {
+	// The module that is only visible to other packages because of the legacy public default.
+	Module string
+
+	// The packages that depend on the module.
+	Dependents []string
+}
+
+// legacyPublicVisibilityReport returns the modules that are depended upon from other packages
+// only because of the legacy public default, sorted by module.
+func legacyPublicVisibilityReport(config Config) []LegacyPublicVisibilityEntry {
+	deps := getLegacyPublicDependencies(config)
+	deps.lock.Lock()
+	defer deps.lock.Unlock()
+
+	report := make([]LegacyPublicVisibilityEntry, 0, len(deps.dependents))
+	for dep, dependents := range deps.dependents {
+		report = append(report, LegacyPublicVisibilityEntry{
+			Module:     dep.String(),
+			Dependents: SortedStringKeys(dependents),
+		})
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].Module < report[j].Module
+	})
+	return report
+}
+
+func visibilityLegacyPublicReportSingletonFactory() Singleton {
+	return &visibilityLegacyPublicReportSingleton{}
+}
+
+type visibilityLegacyPublicReportSingleton struct {
+	reportFile WritablePath
+}
+
+func (s *visibilityLegacyPublicReportSingleton) GenerateBuildActions(ctx SingletonContext) {
+	data, err := json.MarshalIndent(legacyPublicVisibilityReport(ctx.Config()), "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to marshal legacy public visibility report: %s", err)
+		return
+	}
+
+	s.reportFile = PathForOutput(ctx, "visibility_legacy_public.json")
+	WriteFileRule(ctx, s.reportFile, string(data))
+	ctx.Phony("visibility_legacy_public_report", s.reportFile)
+}
+
+func (s *visibilityLegacyPublicReportSingleton) MakeVars(ctx MakeVarsContext) {
+	if s.reportFile != nil {
+		ctx.DistForGoal("visibility_legacy_public_report", s.reportFile)
+	}
+}
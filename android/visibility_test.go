@@ -1940,6 +1940,100 @@ func TestVisibility(t *testing.T) {
 	}
 }
 
+func TestDefaultVisibility(t *testing.T) {
+	result := GroupFixturePreparers(
+		PrepareForTestWithArchMutator,
+		PrepareForTestWithPackageModule,
+		PrepareForTestWithVisibility,
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("mock_library", newMockLibraryModule)
+		}),
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.DefaultVisibility = map[string][]string{
+				"device":               {"//device:__subpackages__"},
+				"device/google/shared": {"//visibility:legacy_public"},
+			}
+		}),
+		MockFS{
+			"device/google/a/Android.bp": []byte(`
+				mock_library {
+					name: "libdevice",
+				}`),
+			"device/google/shared/Android.bp": []byte(`
+				mock_library {
+					name: "libshared",
+				}`),
+			"device/google/b/Android.bp": []byte(`
+				mock_library {
+					name: "libdevice_user",
+					deps: ["libdevice", "libshared"],
+				}`),
+			"device/google/c/Android.bp": []byte(`
+				package {
+					default_visibility: ["//visibility:public"],
+				}
+
+				mock_library {
+					name: "libpackage_default",
+				}`),
+			"top/Android.bp": []byte(`
+				mock_library {
+					name: "libtop",
+					deps: ["libshared", "libpackage_default"],
+				}`),
+			"outsider/Android.bp": []byte(`
+				mock_library {
+					name: "liboutsider",
+					deps: ["libtop", "libshared"],
+				}`),
+		}.AddToFixture(),
+	).RunTest(t)
+
+	checkEffectiveVisibility(t, result, map[qualifiedModuleName][]string{
+		qualifiedModuleName{pkg: "device/google/a", name: "libdevice"}:          {"//device:__subpackages__"},
+		qualifiedModuleName{pkg: "device/google/shared", name: "libshared"}:     {"//visibility:public"},
+		qualifiedModuleName{pkg: "device/google/c", name: "libpackage_default"}: {"//visibility:public"},
+		qualifiedModuleName{pkg: "top", name: "libtop"}:                         {"//visibility:public"},
+	})
+
+	report := legacyPublicVisibilityReport(result.Config)
+	AssertDeepEquals(t, "legacy public report", []LegacyPublicVisibilityEntry{
+		{Module: "//device/google/shared:libshared", Dependents: []string{"//device/google/b", "//outsider", "//top"}},
+		{Module: "//top:libtop", Dependents: []string{"//outsider"}},
+	}, report)
+
+	reportFile := result.SingletonForTests("visibility_legacy_public_report").Output("visibility_legacy_public.json")
+	AssertStringDoesContain(t, "report file", ContentFromFileRuleForTests(t, reportFile), `"//device/google/shared:libshared"`)
+}
+
+func TestDefaultVisibilityEnforced(t *testing.T) {
+	GroupFixturePreparers(
+		PrepareForTestWithArchMutator,
+		PrepareForTestWithVisibility,
+		FixtureRegisterWithContext(func(ctx RegistrationContext) {
+			ctx.RegisterModuleType("mock_library", newMockLibraryModule)
+		}),
+		FixtureModifyProductVariables(func(variables FixtureProductVariables) {
+			variables.DefaultVisibility = map[string][]string{
+				"device": {"//device:__subpackages__"},
+			}
+		}),
+		MockFS{
+			"device/google/Android.bp": []byte(`
+				mock_library {
+					name: "libdevice",
+				}`),
+			"outsider/Android.bp": []byte(`
+				mock_library {
+					name: "liboutsider",
+					deps: ["libdevice"],
+				}`),
+		}.AddToFixture(),
+	).ExtendWithErrorHandler(FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`module "liboutsider" variant "android_common": depends on //device/google:libdevice which is not visible to this module`,
+	)).RunTest(t)
+}
+
 func checkEffectiveVisibility(t *testing.T, result *TestResult, effectiveVisibility map[qualifiedModuleName][]string) {
 	for moduleName, expectedRules := range effectiveVisibility {
 		rule := effectiveVisibilityRules(result.Config, moduleName)
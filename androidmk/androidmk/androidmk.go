@@ -41,6 +41,11 @@ type bpFile struct {
 	mkPos scanner.Position // Position of the last handled line in the makefile
 	bpPos scanner.Position // Position of the last emitted line to the blueprint file
 
+	nodePos scanner.Position // Position of the makefile line being converted
+
+	blockers []Problem
+	warnings []Problem
+
 	inModule bool
 }
 
@@ -85,6 +90,7 @@ func (f *bpFile) insertExtraComment(s string) {
 func (f *bpFile) errorf(failedNode mkparser.Node, message string, args ...interface{}) {
 	orig := failedNode.Dump()
 	message = fmt.Sprintf(message, args...)
+	f.blockers = append(f.blockers, Problem{Pos: f.nodePos, Message: message})
 	f.addErrorText(fmt.Sprintf("// ANDROIDMK TRANSLATION ERROR: %s", message))
 
 	lines := strings.Split(orig, "\n")
@@ -96,6 +102,7 @@ func (f *bpFile) errorf(failedNode mkparser.Node, message string, args ...interf
 // records that something unexpected occurred
 func (f *bpFile) warnf(message string, args ...interface{}) {
 	message = fmt.Sprintf(message, args...)
+	f.warnings = append(f.warnings, Problem{Pos: f.nodePos, Message: message})
 	f.addErrorText(fmt.Sprintf("// ANDROIDMK TRANSLATION WARNING: %s", message))
 }
 
@@ -129,12 +136,51 @@ type conditional struct {
 	eq   bool
 }
 
+// Problem is a part of a makefile that couldn't be converted, or that was converted but needs
+// to be checked.
+type Problem struct {
+	Pos     scanner.Position
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s", p.Pos, p.Message)
+}
+
+// Conversion is the result of converting a makefile.
+type Conversion struct {
+	// The converted Android.bp file, with the blockers and warnings as comments.
+	Output string
+
+	// The parts of the makefile that couldn't be converted, and that have to be converted by
+	// hand before the output can replace the makefile.
+	Blockers []Problem
+
+	// The parts of the makefile that were converted but need to be checked.
+	Warnings []Problem
+}
+
+// Convertible returns true if the whole makefile was converted.
+func (c *Conversion) Convertible() bool {
+	return len(c.Blockers) == 0
+}
+
 func ConvertFile(filename string, buffer *bytes.Buffer) (string, []error) {
+	conversion, errs := Convert(filename, buffer)
+	if conversion == nil {
+		return "", errs
+	}
+	return conversion.Output, errs
+}
+
+// Convert converts the makefile in buffer into an Android.bp file, recording the parts that
+// couldn't be converted. It returns a nil Conversion if the makefile cannot be parsed.
+func Convert(filename string, buffer *bytes.Buffer) (*Conversion, []error) {
 	p := mkparser.NewParser(filename, buffer)
 
 	nodes, errs := p.Parse()
 	if len(errs) > 0 {
-		return "", errs
+		return nil, errs
 	}
 
 	file := &bpFile{
@@ -149,7 +195,8 @@ func ConvertFile(filename string, buffer *bytes.Buffer) (string, []error) {
 	var tree *bpparser.File
 
 	for _, node := range nodes {
-		file.setMkPos(p.Unpack(node.Pos()), p.Unpack(node.End()))
+		file.nodePos = p.Unpack(node.Pos())
+		file.setMkPos(file.nodePos, p.Unpack(node.End()))
 
 		switch x := node.(type) {
 		case *mkparser.Comment:
@@ -247,10 +294,14 @@ func ConvertFile(filename string, buffer *bytes.Buffer) (string, []error) {
 	out, err := bpparser.Print(tree)
 	if err != nil {
 		errs = append(errs, err)
-		return "", errs
+		return nil, errs
 	}
 
-	return string(out), errs
+	return &Conversion{
+		Output:   string(out),
+		Blockers: file.blockers,
+		Warnings: file.warnings,
+	}, errs
 }
 
 func renameVariableWithInvalidCharacters(name string) string {
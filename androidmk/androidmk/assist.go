@@ -0,0 +1,133 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package androidmk
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The conversion assistant reports how far the Android.mk files remaining in a set of
+// directories, e.g. a device tree, are from being converted, and can write draft Android.bp
+// files next to them to start from.
+
+const (
+	androidMkFileName = "Android.mk"
+
+	// DraftFileName is the name of the draft Android.bp files written next to the makefiles.
+	// Drafts are never written over existing Android.bp files.
+	DraftFileName = "Android.bp.draft"
+)
+
+// FileReport is the result of converting one Android.mk file.
+type FileReport struct {
+	Path string
+
+	// The conversion, or nil if the makefile couldn't be parsed.
+	Conversion *Conversion
+
+	// Errors reading, parsing or converting the makefile.
+	Errors []error
+}
+
+// Convertible returns true if the makefile was entirely converted.
+func (r FileReport) Convertible() bool {
+	return len(r.Errors) == 0 && r.Conversion != nil && r.Conversion.Convertible()
+}
+
+// FindAndroidMkFiles returns the Android.mk files in the given directories and their
+// subdirectories, sorted. Hidden directories are skipped.
+func FindAndroidMkFiles(dirs []string) ([]string, error) {
+	var files []string
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !d.IsDir() && d.Name() == androidMkFileName {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// AssessFile converts the makefile at path.
+func AssessFile(path string) FileReport {
+	report := FileReport{Path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		report.Errors = append(report.Errors, err)
+		return report
+	}
+	report.Conversion, report.Errors = Convert(path, bytes.NewBuffer(data))
+	return report
+}
+
+// WriteDraft writes the converted makefile to DraftFileName next to it, unless the directory
+// already has an Android.bp file. It returns the path of the draft, or "" if it wasn't written.
+func (r FileReport) WriteDraft() (string, error) {
+	if r.Conversion == nil {
+		return "", nil
+	}
+	dir := filepath.Dir(r.Path)
+	if _, err := os.Stat(filepath.Join(dir, "Android.bp")); err == nil {
+		return "", nil
+	}
+	draft := filepath.Join(dir, DraftFileName)
+	return draft, os.WriteFile(draft, []byte(r.Conversion.Output), 0666)
+}
+
+// WriteReport writes a human readable report of the conversion of the makefiles, listing the
+// blockers of each makefile that couldn't be entirely converted.
+func WriteReport(w io.Writer, reports []FileReport) {
+	convertible := 0
+	for _, r := range reports {
+		if r.Convertible() {
+			convertible++
+			fmt.Fprintf(w, "%s: convertible\n", r.Path)
+			continue
+		}
+
+		var problems []string
+		for _, err := range r.Errors {
+			problems = append(problems, err.Error())
+		}
+		if r.Conversion != nil {
+			for _, b := range r.Conversion.Blockers {
+				problems = append(problems, b.String())
+			}
+		}
+		fmt.Fprintf(w, "%s: %d blockers\n", r.Path, len(problems))
+		for _, p := range problems {
+			fmt.Fprintf(w, "    %s\n", p)
+		}
+	}
+	fmt.Fprintf(w, "%d of %d makefiles are convertible\n", convertible, len(reports))
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package androidmk
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAssistant(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(path, contents string) {
+		t.Helper()
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile("good/Android.mk", `
+include $(CLEAR_VARS)
+LOCAL_MODULE := libgood
+include $(BUILD_SHARED_LIBRARY)
+`)
+	writeFile("bad/Android.mk", `
+include $(CLEAR_VARS)
+LOCAL_MODULE := libbad
+include $(BUILD_SHARED_LIBRARY)
+include $(LOCAL_PATH)/other.mk
+`)
+	writeFile("converted/Android.mk", `
+include $(CLEAR_VARS)
+LOCAL_MODULE := libconverted
+include $(BUILD_SHARED_LIBRARY)
+`)
+	writeFile("converted/Android.bp", "")
+	writeFile(".hidden/Android.mk", "")
+
+	files, err := FindAndroidMkFiles([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedFiles := []string{
+		filepath.Join(dir, "bad/Android.mk"),
+		filepath.Join(dir, "converted/Android.mk"),
+		filepath.Join(dir, "good/Android.mk"),
+	}
+	if !reflect.DeepEqual(files, expectedFiles) {
+		t.Errorf("expected files %q, got %q", expectedFiles, files)
+	}
+
+	var reports []FileReport
+	for _, file := range files {
+		report := AssessFile(file)
+		if _, err := report.WriteDraft(); err != nil {
+			t.Fatal(err)
+		}
+		reports = append(reports, report)
+	}
+
+	buf := &bytes.Buffer{}
+	WriteReport(buf, reports)
+	expectedReport := strings.ReplaceAll(`DIR/bad/Android.mk: 1 blockers
+    DIR/bad/Android.mk:5:1: unsupported include
+DIR/converted/Android.mk: convertible
+DIR/good/Android.mk: convertible
+2 of 3 makefiles are convertible
+`, "DIR", dir)
+	if buf.String() != expectedReport {
+		t.Errorf("expected report:\n%s\ngot:\n%s", expectedReport, buf.String())
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "good", DraftFileName)); err != nil {
+		t.Errorf("expected a draft for good/Android.mk: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "converted", DraftFileName)); err == nil {
+		t.Errorf("unexpected draft for converted/Android.mk, which already has an Android.bp file")
+	}
+}
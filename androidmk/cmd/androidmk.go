@@ -24,9 +24,17 @@ import (
 	"android/soong/androidmk/androidmk"
 )
 
+var (
+	assist      = flag.Bool("assist", false, "report how far the Android.mk files in the given directories are from being converted")
+	writeDrafts = flag.Bool("write_drafts", false, "with -assist, write the conversion of each Android.mk file to "+androidmk.DraftFileName+" next to it")
+)
+
 var usage = func() {
 	fmt.Fprintf(os.Stderr, "usage: androidmk [flags] <inputFile>\n"+
-		"\nandroidmk parses <inputFile> as an Android.mk file and attempts to output an analogous Android.bp file (to standard out)\n")
+		"       androidmk -assist [-write_drafts] <dir>...\n"+
+		"\nandroidmk parses <inputFile> as an Android.mk file and attempts to output an analogous Android.bp file (to standard out)\n"+
+		"\nWith -assist, androidmk converts every Android.mk file in the given directories and reports the parts of each\n"+
+		"that couldn't be converted\n")
 	flag.PrintDefaults()
 	os.Exit(1)
 }
@@ -34,6 +42,13 @@ var usage = func() {
 func main() {
 	flag.Usage = usage
 	flag.Parse()
+	if *assist {
+		if len(flag.Args()) == 0 {
+			usage()
+		}
+		runAssistant(flag.Args())
+		return
+	}
 	if len(flag.Args()) != 1 {
 		usage()
 	}
@@ -55,3 +70,34 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// runAssistant converts the Android.mk files in dirs and reports which of them can be
+// converted. It exits with an error if any of them can't.
+func runAssistant(dirs []string) {
+	files, err := androidmk.FindAndroidMkFiles(dirs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR: ", err)
+		os.Exit(1)
+	}
+
+	var reports []androidmk.FileReport
+	for _, file := range files {
+		report := androidmk.AssessFile(file)
+		if *writeDrafts {
+			if draft, err := report.WriteDraft(); err != nil {
+				fmt.Fprintln(os.Stderr, "ERROR: ", err)
+				os.Exit(1)
+			} else if draft != "" {
+				fmt.Fprintf(os.Stderr, "wrote %s\n", draft)
+			}
+		}
+		reports = append(reports, report)
+	}
+
+	androidmk.WriteReport(os.Stdout, reports)
+	for _, report := range reports {
+		if !report.Convertible() {
+			os.Exit(1)
+		}
+	}
+}
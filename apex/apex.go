@@ -128,6 +128,10 @@ type apexBundleProperties struct {
 	// List of java libraries that are embedded inside this APEX bundle.
 	Java_libs []string
 
+	// The name of a java_baseline_profile module whose rules are compiled into the profiles of the
+	// contents of the systemserverclasspath fragments of this APEX bundle that don't have their own.
+	Baseline_profile *string
+
 	// List of sh binaries that are embedded inside this APEX bundle.
 	Sh_binaries []string
 
@@ -408,6 +412,9 @@ type apexBundle struct {
 	publicKeyFile  android.Path
 	privateKeyFile android.Path
 
+	// The rules of the baseline profile of the apex, or nil if it doesn't have one.
+	baselineProfile android.Path
+
 	// Cert/priv-key for the zip container
 	containerCertificateFile android.Path
 	containerPrivateKeyFile  android.Path
@@ -706,6 +713,10 @@ var (
 	shBinaryTag     = &dependencyTag{name: "shBinary", payload: true}
 )
 
+// The java_baseline_profile dependency isn't part of the payload, only the profiles compiled from it
+// are.
+var baselineProfileTag = &dependencyTag{name: "baselineProfile"}
+
 // TODO(jiyong): shorten this function signature
 func addDependenciesForNativeModules(ctx android.BottomUpMutatorContext, nativeModules ApexNativeDependencies, target android.Target, imageVariation string) {
 	binVariations := target.Variations()
@@ -877,6 +888,9 @@ func (a *apexBundle) DepsMutator(ctx android.BottomUpMutatorContext) {
 	ctx.AddFarVariationDependencies(commonVariation, javaLibTag, a.properties.Java_libs...)
 	ctx.AddFarVariationDependencies(commonVariation, fsTag, a.properties.Filesystems...)
 	ctx.AddFarVariationDependencies(commonVariation, compatConfigTag, a.properties.Compat_configs...)
+	if profile := String(a.properties.Baseline_profile); profile != "" {
+		ctx.AddDependency(ctx.Module(), baselineProfileTag, profile)
+	}
 }
 
 // DepsMutator for the overridden properties.
@@ -1812,6 +1826,22 @@ func apexFileForJavaModuleProfile(ctx android.BaseModuleContext, module javaModu
 	return nil
 }
 
+// apexFileForJavaModuleBaselineProfile creates an apexFile for the profile of a java module's dex
+// implementation jar compiled from the baseline profile of the apex, or returns nil if the apex
+// doesn't have one.
+func (a *apexBundle) apexFileForJavaModuleBaselineProfile(ctx android.ModuleContext, module javaModule) *apexFile {
+	dexJar := module.DexJarBuildPath()
+	if a.baselineProfile == nil || !dexJar.Valid() {
+		return nil
+	}
+	dirInApex := "javalib"
+	dexLocation := filepath.Join("/apex", a.ApexVariationName(), dirInApex, module.Stem()+".jar")
+	profile := java.BuildBaselineProfile(ctx, a.baselineProfile, dexJar.Path(), dexLocation, module.Stem())
+	af := newApexFile(ctx, profile, module.BaseModuleName()+"-profile", dirInApex, etc, nil)
+	af.customStem = module.Stem() + ".jar.prof"
+	return &af
+}
+
 // androidApp is an interface to handle all app modules (android_app, android_app_import, etc.) in
 // the same way.
 type androidApp interface {
@@ -2534,6 +2564,8 @@ func (a *apexBundle) depVisitor(vctx *visitorContext, ctx android.ModuleContext,
 			vctx.filesInfo = append(vctx.filesInfo, af)
 			if profileAf := apexFileForJavaModuleProfile(ctx, child.(javaModule)); profileAf != nil {
 				vctx.filesInfo = append(vctx.filesInfo, *profileAf)
+			} else if profileAf := a.apexFileForJavaModuleBaselineProfile(ctx, child.(javaModule)); profileAf != nil {
+				vctx.filesInfo = append(vctx.filesInfo, *profileAf)
 			}
 			return true // track transitive dependencies
 		default:
@@ -2588,6 +2620,7 @@ func (a *apexBundle) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 
 	// TODO(jiyong): do this using WalkPayloadDeps
 	// TODO(jiyong): make this clean!!!
+	a.baselineProfile = java.BaselineProfileRules(ctx, baselineProfileTag)
 	vctx := visitorContext{
 		handleSpecialLibs: !android.Bool(a.properties.Ignore_system_library_special_case),
 		checkDuplicate:    a.shouldCheckDuplicate(ctx),
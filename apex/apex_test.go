@@ -1083,6 +1083,82 @@ func TestApexCanUsePrivateApis(t *testing.T) {
 	ensureContains(t, rustDeps, "libfoo.shared_from_rust/android_arm64_armv8-a_shared/libfoo.shared_from_rust.so")
 }
 
+func TestApexSharedLibDedup(t *testing.T) {
+	bp := `
+		apex {
+			name: "myapex",
+			key: "myapex.key",
+			native_shared_libs: ["libshared", "libdup"],
+			updatable: false,
+		}
+
+		apex {
+			name: "otherapex",
+			key: "myapex.key",
+			native_shared_libs: ["libshared", "libdup", "libsingle"],
+			updatable: false,
+		}
+
+		apex_key {
+			name: "myapex.key",
+			public_key: "testkey.avbpubkey",
+			private_key: "testkey.pem",
+		}
+
+		cc_library {
+			name: "libshared",
+			srcs: ["mylib.cpp"],
+			system_shared_libs: [],
+			stl: "none",
+			stubs: {
+				versions: ["1"],
+			},
+			apex_available: ["myapex", "otherapex"],
+		}
+
+		cc_library {
+			name: "libdup",
+			srcs: ["mylib.cpp"],
+			system_shared_libs: [],
+			stl: "none",
+			apex_available: ["myapex", "otherapex"],
+		}
+
+		cc_library {
+			name: "libsingle",
+			srcs: ["mylib.cpp"],
+			system_shared_libs: [],
+			stl: "none",
+			apex_available: ["otherapex"],
+		}
+	`
+
+	ctx := testApex(t, bp, PrepareForTestWithApexSharedLibDedup)
+
+	copies := android.ContentFromFileRuleForTests(t,
+		ctx.SingletonForTests("apex_shared_lib_dedup").Output("apex/shared_lib_dedup_copies.txt"))
+	ensureContains(t, copies, "libshared\tlib64\tmyapex\t")
+	ensureContains(t, copies, "libshared\tlib64\totherapex\t")
+	ensureContains(t, copies, "libdup\tlib64\tmyapex\t")
+	ensureContains(t, copies, "libdup\tlib64\totherapex\t")
+	ensureNotContains(t, copies, "libsingle")
+	ensureContains(t, copies, "/libshared.so\ttrue")
+	ensureContains(t, copies, "/libdup.so\tfalse")
+
+	testApexError(t, `libshared \(lib64\) has stubs but is bundled into myapex, otherapex`, bp,
+		PrepareForTestWithApexSharedLibDedup,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.EnforceApexSharedLibDedup = proptools.BoolPtr(true)
+		}))
+
+	testApex(t, bp,
+		PrepareForTestWithApexSharedLibDedup,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.EnforceApexSharedLibDedup = proptools.BoolPtr(true)
+			variables.ApexSharedLibDedupAllowlist = []string{"libshared"}
+		}))
+}
+
 func TestApexWithStubsWithMinSdkVersion(t *testing.T) {
 	t.Parallel()
 	ctx := testApex(t, `
@@ -9064,6 +9140,34 @@ func TestCompressedApex(t *testing.T) {
 	ensureContains(t, androidMk, "LOCAL_MODULE_STEM := myapex.capex\n")
 }
 
+func TestCompressedApexDeferredSigning(t *testing.T) {
+	ctx := testApex(t, `
+		apex {
+			name: "myapex",
+			key: "myapex.key",
+			compressible: true,
+			updatable: false,
+		}
+		apex_key {
+			name: "myapex.key",
+			public_key: "testkey.avbpubkey",
+			private_key: "testkey.pem",
+		}
+	`,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.CompressedApex = proptools.BoolPtr(true)
+			variables.Signing_backend = proptools.StringPtr("remote")
+		}),
+	)
+
+	module := ctx.ModuleForTests("myapex", "android_common_myapex_image")
+	apex := module.Output("myapex.apex")
+	ensureEquals(t, apex.Description, "unsigned apex")
+	capex := module.Output("myapex.capex")
+	ensureEquals(t, capex.Description, "unsigned capex")
+	ensureContains(t, capex.Input.String(), "myapex.capex.unsigned")
+}
+
 func TestPreferredPrebuiltSharedLibDep(t *testing.T) {
 	ctx := testApex(t, `
 		apex {
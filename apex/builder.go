@@ -863,31 +863,11 @@ func (a *apexBundle) buildUnflattenedApex(ctx android.ModuleContext) {
 	// Step 4: Sign the APEX using signapk
 	signedOutputFile := android.PathForModuleOut(ctx, a.Name()+suffix)
 
-	pem, key := a.getCertificateAndPrivateKey(ctx)
-	rule := java.Signapk
-	args := map[string]string{
-		"certificates": pem.String() + " " + key.String(),
-		"flags":        "-a 4096 --align-file-size", //alignment
-	}
-	implicits := android.Paths{pem, key}
-	if ctx.Config().UseRBE() && ctx.Config().IsEnvTrue("RBE_SIGNAPK") {
-		rule = java.SignapkRE
-		args["implicits"] = strings.Join(implicits.Strings(), ",")
-		args["outCommaList"] = signedOutputFile.String()
-	}
 	var validations android.Paths
 	if suffix == imageApexSuffix {
 		validations = append(validations, runApexSepolicyTests(ctx, unsignedOutputFile.OutputPath))
 	}
-	ctx.Build(pctx, android.BuildParams{
-		Rule:        rule,
-		Description: "signapk",
-		Output:      signedOutputFile,
-		Input:       unsignedOutputFile,
-		Implicits:   implicits,
-		Args:        args,
-		Validations: validations,
-	})
+	a.buildSignedApex(ctx, "apex", "signapk", unsignedOutputFile, signedOutputFile, validations)
 	if suffix == imageApexSuffix {
 		a.outputApexFile = signedOutputFile
 	}
@@ -916,17 +896,8 @@ func (a *apexBundle) buildUnflattenedApex(ctx android.ModuleContext) {
 		compressRule.Build("compressRule", "Generate unsigned compressed APEX file")
 
 		signedCompressedOutputFile := android.PathForModuleOut(ctx, a.Name()+imageCapexSuffix)
-		if ctx.Config().UseRBE() && ctx.Config().IsEnvTrue("RBE_SIGNAPK") {
-			args["outCommaList"] = signedCompressedOutputFile.String()
-		}
-		ctx.Build(pctx, android.BuildParams{
-			Rule:        rule,
-			Description: "sign compressedApex",
-			Output:      signedCompressedOutputFile,
-			Input:       unsignedCompressedOutputFile,
-			Implicits:   implicits,
-			Args:        args,
-		})
+		a.buildSignedApex(ctx, "capex", "sign compressedApex", unsignedCompressedOutputFile,
+			signedCompressedOutputFile, nil)
 		a.outputFile = signedCompressedOutputFile
 		installSuffix = imageCapexSuffix
 	}
@@ -943,6 +914,43 @@ func (a *apexBundle) buildUnflattenedApex(ctx android.ModuleContext) {
 	a.installedFilesFile = a.buildInstalledFilesFile(ctx, a.outputFile, imageDir)
 }
 
+// buildSignedApex signs an APEX or a compressed APEX with signapk, or exports a signing request
+// for it when the signing backend of the product is deferred.
+func (a *apexBundle) buildSignedApex(ctx android.ModuleContext, kind, desc string,
+	unsigned android.Path, signed android.WritablePath, validations android.Paths) {
+
+	pem, key := a.getCertificateAndPrivateKey(ctx)
+	signingFlags := []string{"-a", "4096", "--align-file-size"} //alignment
+	backend := ctx.Config().SigningBackend()
+	if backend.Deferred() {
+		android.BuildDeferredSigning(ctx, kind, unsigned, signed,
+			[]string{pem.String(), key.String()}, signingFlags, validations)
+		return
+	}
+
+	signingFlags = append(signingFlags, backend.SignapkFlags()...)
+	rule := java.Signapk
+	args := map[string]string{
+		"certificates": pem.String() + " " + key.String(),
+		"flags":        strings.Join(signingFlags, " "),
+	}
+	implicits := android.Paths{pem, key}
+	if ctx.Config().UseRBE() && ctx.Config().IsEnvTrue("RBE_SIGNAPK") {
+		rule = java.SignapkRE
+		args["implicits"] = strings.Join(implicits.Strings(), ",")
+		args["outCommaList"] = signed.String()
+	}
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        rule,
+		Description: desc,
+		Output:      signed,
+		Input:       unsigned,
+		Implicits:   implicits,
+		Args:        args,
+		Validations: validations,
+	})
+}
+
 // buildFlattenedApex creates rules for a flattened APEX. Flattened APEX actually doesn't have a
 // single output file. It is a phony target for all the files under /system/apex/<name> directory.
 // This function creates the installation rules for the files.
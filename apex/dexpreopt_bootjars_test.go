@@ -20,11 +20,33 @@ import (
 	"sort"
 	"testing"
 
+	"github.com/google/blueprint/proptools"
+
 	"android/soong/android"
+	"android/soong/dexpreopt"
 	"android/soong/java"
 )
 
 func testDexpreoptBoot(t *testing.T, ruleFile string, expectedInputs, expectedOutputs []string, preferPrebuilt bool) {
+	result := runDexpreoptBootTest(t, preferPrebuilt)
+
+	platformBootclasspath := result.ModuleForTests("platform-bootclasspath", "android_common")
+	rule := platformBootclasspath.Output(ruleFile)
+
+	inputs := rule.Implicits.Strings()
+	sort.Strings(inputs)
+	sort.Strings(expectedInputs)
+
+	outputs := append(android.WritablePaths{rule.Output}, rule.ImplicitOutputs...).Strings()
+	sort.Strings(outputs)
+	sort.Strings(expectedOutputs)
+
+	android.AssertStringPathsRelativeToTopEquals(t, "inputs", result.Config, expectedInputs, inputs)
+
+	android.AssertStringPathsRelativeToTopEquals(t, "outputs", result.Config, expectedOutputs, outputs)
+}
+
+func runDexpreoptBootTest(t *testing.T, preferPrebuilt bool, preparers ...android.FixturePreparer) *android.TestResult {
 	bp := `
 		// Platform.
 
@@ -129,29 +151,15 @@ func testDexpreoptBoot(t *testing.T, ruleFile string, expectedInputs, expectedOu
 		}
 	`
 
-	result := android.GroupFixturePreparers(
+	return android.GroupFixturePreparers(
 		java.PrepareForTestWithDexpreopt,
 		java.PrepareForTestWithJavaSdkLibraryFiles,
 		java.FixtureWithLastReleaseApis("foo"),
 		java.FixtureConfigureBootJars("com.android.art:core-oj", "platform:foo", "system_ext:bar", "platform:baz"),
 		PrepareForTestWithApexBuildComponents,
 		prepareForTestWithArtApex,
+		android.GroupFixturePreparers(preparers...),
 	).RunTestWithBp(t, fmt.Sprintf(bp, preferPrebuilt))
-
-	platformBootclasspath := result.ModuleForTests("platform-bootclasspath", "android_common")
-	rule := platformBootclasspath.Output(ruleFile)
-
-	inputs := rule.Implicits.Strings()
-	sort.Strings(inputs)
-	sort.Strings(expectedInputs)
-
-	outputs := append(android.WritablePaths{rule.Output}, rule.ImplicitOutputs...).Strings()
-	sort.Strings(outputs)
-	sort.Strings(expectedOutputs)
-
-	android.AssertStringPathsRelativeToTopEquals(t, "inputs", result.Config, expectedInputs, inputs)
-
-	android.AssertStringPathsRelativeToTopEquals(t, "outputs", result.Config, expectedOutputs, outputs)
 }
 
 func TestDexpreoptBootJarsWithSourceArtApex(t *testing.T) {
@@ -252,3 +260,32 @@ func TestDexpreoptBootZip(t *testing.T) {
 
 	testDexpreoptBoot(t, ruleFile, expectedInputs, expectedOutputs, false)
 }
+
+// The product may build space-optimized boot images, e.g. for low-RAM devices.
+func TestDexpreoptBootImageProductConfig(t *testing.T) {
+	result := runDexpreoptBootTest(t, false,
+		dexpreopt.FixtureSetBootImageProfiles(),
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.BootImageProfile = proptools.StringPtr("device/acme/boot-image-profile.txt")
+			variables.BootImageCompilerFilter = proptools.StringPtr("space-profile")
+			variables.BootImageFormats = []string{"arm64:lz4"}
+		}),
+		android.FixtureMergeMockFs(android.MockFS{
+			"device/acme/boot-image-profile.txt": nil,
+		}),
+	)
+
+	platformBootclasspath := result.ModuleForTests("platform-bootclasspath", "android_common")
+	profile := platformBootclasspath.Output("out/soong/dexpreopt_arm64/dex_bootjars/boot-image-profile.txt")
+	android.AssertStringDoesContain(t, "boot image profile", profile.RuleParams.Command,
+		"cat device/acme/boot-image-profile.txt >")
+
+	for arch, format := range map[string]string{"arm64": "lz4", "arm": "lz4hc"} {
+		rule := platformBootclasspath.Output(
+			"out/soong/dexpreopt_arm64/dex_bootjars/android/system/framework/" + arch + "/boot.art")
+		android.AssertStringDoesContain(t, arch+" compiler filter", rule.RuleParams.Command,
+			"--compiler-filter=space-profile")
+		android.AssertStringDoesContain(t, arch+" image format", rule.RuleParams.Command,
+			"--image-format="+format+" ")
+	}
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright (C) 2023 The Android Open Source Project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apex
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/blueprint"
+
+	"android/soong/android"
+	"android/soong/cc"
+)
+
+// The shared library dedup report lists every native shared library that is bundled into more
+// than one APEX, or into an APEX and the platform, together with the size of each copy.
+//
+// A library that has stubs could be shared instead: a single copy is installed and the other
+// APEXes and the platform link against its stubs. Such libraries are marked as shareable in the
+// report, and with the EnforceApexSharedLibDedup product variable set, bundling more than one
+// copy of them is an error unless they are listed in ApexSharedLibDedupAllowlist.
+
+func init() {
+	registerApexSharedLibDedupBuildComponents(android.InitRegistrationContext)
+}
+
+func registerApexSharedLibDedupBuildComponents(ctx android.RegistrationContext) {
+	ctx.RegisterSingletonType("apex_shared_lib_dedup", apexSharedLibDedupSingletonFactory)
+}
+
+var PrepareForTestWithApexSharedLibDedup = android.FixtureRegisterWithContext(registerApexSharedLibDedupBuildComponents)
+
+var (
+	// Add the size of each copy to the list of copies. The fields of the list never contain
+	// whitespace, so they are split with the default IFS. ninja runs the command with /bin/sh, and
+	// wc is used instead of stat, whose flags differ between Linux and Darwin.
+	apexSharedLibDedupReportRule = pctx.AndroidStaticRule("apexSharedLibDedupReportRule", blueprint.RuleParams{
+		Command: `(printf 'library\tmultilib\tlocation\tsize\tshareable\n' && ` +
+			`while read -r lib multilib location path shareable; do ` +
+			`[ -z "$$lib" ] && continue; ` +
+			`printf '%s\t%s\t%s\t%s\t%s\n' "$$lib" "$$multilib" "$$location" "$$(wc -c < "$$path" | tr -d ' ')" "$$shareable"; ` +
+			`done < $in) > $out`,
+		Description: "apex shared lib dedup report",
+	})
+)
+
+// The platform is reported as the location of copies installed outside of any APEX.
+const sharedLibDedupPlatformLocation = "platform"
+
+type sharedLibCopy struct {
+	location string
+	file     android.Path
+}
+
+type sharedLibKey struct {
+	name     string
+	multilib string
+}
+
+type sharedLibCopies struct {
+	copies    []sharedLibCopy
+	shareable bool
+}
+
+func (l *sharedLibCopies) locations() []string {
+	var locations []string
+	for _, c := range l.copies {
+		locations = append(locations, c.location)
+	}
+	return android.SortedUniqueStrings(locations)
+}
+
+func apexSharedLibDedupSingletonFactory() android.Singleton {
+	return &apexSharedLibDedupSingleton{}
+}
+
+type apexSharedLibDedupSingleton struct {
+	report android.WritablePath
+}
+
+func (s *apexSharedLibDedupSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	libs := make(map[sharedLibKey]*sharedLibCopies)
+	addCopy := func(module android.Module, multilib, location string, file android.Path) {
+		key := sharedLibKey{ctx.ModuleName(module), multilib}
+		if libs[key] == nil {
+			libs[key] = &sharedLibCopies{}
+		}
+		libs[key].copies = append(libs[key].copies, sharedLibCopy{location, file})
+		if ccMod, ok := module.(*cc.Module); ok && ccMod.HasStubsVariants() {
+			libs[key].shareable = true
+		}
+	}
+
+	ctx.VisitAllModules(func(module android.Module) {
+		if !module.Enabled() {
+			return
+		}
+		if a, ok := module.(*apexBundle); ok {
+			if a.testApex || !a.installable() {
+				return
+			}
+			for _, fi := range a.filesInfo {
+				if fi.class == nativeSharedLib && fi.module != nil && fi.ok() {
+					addCopy(fi.module, fi.multilib, a.BaseModuleName(), fi.builtFile)
+				}
+			}
+			return
+		}
+		if ccMod, ok := module.(*cc.Module); ok && isPlatformSharedLib(ctx, ccMod) {
+			addCopy(ccMod, ccMod.Target().Arch.ArchType.Multilib, sharedLibDedupPlatformLocation, ccMod.OutputFile().Path())
+		}
+	})
+
+	var keys []sharedLibKey
+	for key, lib := range libs {
+		if len(lib.locations()) > 1 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].multilib < keys[j].multilib
+	})
+
+	enforce := ctx.Config().EnforceApexSharedLibDedup()
+	allowlist := ctx.Config().ApexSharedLibDedupAllowlist()
+
+	var lines []string
+	var files android.Paths
+	for _, key := range keys {
+		lib := libs[key]
+		for _, c := range lib.copies {
+			lines = append(lines, strings.Join([]string{key.name, key.multilib, c.location, c.file.String(), fmt.Sprint(lib.shareable)}, "\t"))
+			files = append(files, c.file)
+		}
+		if enforce && lib.shareable && !android.InList(key.name, allowlist) {
+			ctx.Errorf("%s (%s) has stubs but is bundled into %s. Install it once and depend on its "+
+				"stubs elsewhere, or add it to ApexSharedLibDedupAllowlist.",
+				key.name, key.multilib, strings.Join(lib.locations(), ", "))
+		}
+	}
+
+	copiesList := android.PathForOutput(ctx, "apex", "shared_lib_dedup_copies.txt")
+	android.WriteFileRule(ctx, copiesList, strings.Join(lines, "\n"))
+
+	s.report = android.PathForOutput(ctx, "apex", "shared_lib_dedup_report.txt")
+	ctx.Build(pctx, android.BuildParams{
+		Rule:      apexSharedLibDedupReportRule,
+		Input:     copiesList,
+		Implicits: android.FirstUniquePaths(files),
+		Output:    s.report,
+	})
+	ctx.Phony("apex-shared-lib-dedup-report", s.report)
+}
+
+// isPlatformSharedLib returns true if the module is the implementation of a shared library that
+// is installed in the system partition outside of any APEX.
+func isPlatformSharedLib(ctx android.SingletonContext, m *cc.Module) bool {
+	if !m.Device() || !m.Shared() || m.IsStubs() || m.IsSdkVariant() || m.UseVndk() ||
+		m.InRamdisk() || m.InVendorRamdisk() || m.InRecovery() {
+		return false
+	}
+	if m.IsSkipInstall() || !m.OutputFile().Valid() {
+		return false
+	}
+	apexInfo := ctx.ModuleProvider(m, android.ApexInfoProvider).(android.ApexInfo)
+	return apexInfo.IsForPlatform()
+}
+
+func (s *apexSharedLibDedupSingleton) MakeVars(ctx android.MakeVarsContext) {
+	if s.report != nil {
+		ctx.DistForGoal("apex-shared-lib-dedup-report", s.report)
+	}
+}
@@ -87,6 +87,11 @@ func Codegen(ctx *CodegenContext) *CodegenMetrics {
 	// performance implications.
 	deleteFilesExcept(ctx, bp2buildDir, bp2buildFiles)
 
+	if err := writeConversionBlockers(ctx); err != nil {
+		fmt.Printf("ERROR: failed to write %s: %s\n", ConversionBlockersFileName, err)
+		os.Exit(1)
+	}
+
 	injectionFiles, err := CreateSoongInjectionDirFiles(ctx, res.metrics)
 	if err != nil {
 		fmt.Printf("%s\n", err.Error())
@@ -0,0 +1,92 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bp2build
+
+import (
+	"encoding/json"
+
+	"github.com/google/blueprint"
+
+	"android/soong/android"
+	"android/soong/android/allowlists"
+)
+
+// ConversionBlockersFileName is the name of the report of the reasons why bp2build or mixed builds
+// don't handle the modules yet, in the Soong output directory. The bp2build_blockers tool prints
+// the blockers of a module and of its dependencies as a tree.
+const ConversionBlockersFileName = "bp2build_blockers.json"
+
+// ConversionBlockers are the reasons why bp2build or mixed builds don't handle a module yet.
+type ConversionBlockers struct {
+	Type string
+	Dir  string
+
+	// Whether bp2build converts the module, or it has a handcrafted Bazel target.
+	Converted bool
+
+	// The reasons why bp2build doesn't convert the module, or mixed builds don't use its Bazel
+	// target, other than its dependencies.
+	Reasons []string `json:",omitempty"`
+
+	// The dependencies that bp2build doesn't convert, or can't find.
+	UnconvertedDeps []string `json:",omitempty"`
+	MissingDeps     []string `json:",omitempty"`
+}
+
+// collectConversionBlockers returns the conversion blockers of the modules, by module name.
+func collectConversionBlockers(ctx *CodegenContext) map[string]*ConversionBlockers {
+	mixedBuildsDisabled := make(map[string]bool)
+	for _, name := range allowlists.MixedBuildsDisabledList {
+		mixedBuildsDisabled[name] = true
+	}
+
+	blockers := make(map[string]*ConversionBlockers)
+	bpCtx := ctx.Context()
+	bpCtx.VisitAllModules(func(m blueprint.Module) {
+		aModule, ok := m.(android.Module)
+		if !ok || m.Name() == "" {
+			return
+		}
+		b, ok := blockers[m.Name()]
+		if !ok {
+			b = &ConversionBlockers{Type: bpCtx.ModuleType(m), Dir: bpCtx.ModuleDir(m)}
+			blockers[m.Name()] = b
+		}
+
+		if bazelable, ok := m.(android.Bazelable); ok && bazelable.HasHandcraftedLabel() {
+			b.Converted = true
+		} else if aModule.IsConvertedByBp2build() {
+			b.Converted = true
+			b.UnconvertedDeps = android.FirstUniqueStrings(append(b.UnconvertedDeps, aModule.GetUnconvertedBp2buildDeps()...))
+			b.MissingDeps = android.FirstUniqueStrings(append(b.MissingDeps, aModule.GetMissingBp2buildDeps()...))
+		}
+		b.Reasons = android.FirstUniqueStrings(append(b.Reasons, aModule.GetBp2buildBlockers()...))
+		if b.Converted && mixedBuildsDisabled[m.Name()] {
+			b.Reasons = android.FirstUniqueStrings(append(b.Reasons,
+				"mixed builds don't use the Bazel target, the module is in MixedBuildsDisabledList"))
+		}
+	})
+	return blockers
+}
+
+// writeConversionBlockers writes the conversion blockers of the modules to the Soong output
+// directory.
+func writeConversionBlockers(ctx *CodegenContext) error {
+	data, err := json.MarshalIndent(collectConversionBlockers(ctx), "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(android.PathForOutput(ctx, ConversionBlockersFileName), string(data))
+}
@@ -0,0 +1,60 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bp2build
+
+import (
+	"testing"
+
+	"android/soong/android"
+	"android/soong/android/allowlists"
+)
+
+func TestConversionBlockers(t *testing.T) {
+	fs := map[string][]byte{
+		"migrated/Android.bp": []byte(`filegroup { name: "a", srcs: [":b", ":c"] }`),
+		"migrated/opt_out/Android.bp": []byte(`
+filegroup { name: "b", bazel_module: { bp2build_available: false } }
+`),
+		"not_migrated/Android.bp": []byte(`filegroup { name: "c" }`),
+	}
+	config := android.TestConfig(buildDir, nil, "", fs)
+	ctx := android.NewTestContext(config)
+	ctx.RegisterModuleType("filegroup", android.FileGroupFactory)
+	ctx.RegisterBp2BuildConfig(android.NewBp2BuildAllowlist().SetDefaultConfig(allowlists.Bp2BuildConfig{
+		"migrated": allowlists.Bp2BuildDefaultTrueRecursively,
+	}))
+	ctx.RegisterForBazelConversion()
+
+	_, errs := ctx.ParseFileList(".", []string{"Android.bp", "migrated/Android.bp",
+		"migrated/opt_out/Android.bp", "not_migrated/Android.bp"})
+	android.FailIfErrored(t, errs)
+	_, errs = ctx.ResolveDependencies(config)
+	android.FailIfErrored(t, errs)
+
+	blockers := collectConversionBlockers(NewCodegenContext(config, ctx.Context, Bp2Build, ""))
+	android.AssertDeepEquals(t, "blockers of a", &ConversionBlockers{
+		Type:            "filegroup",
+		Dir:             "migrated",
+		Converted:       true,
+		UnconvertedDeps: []string{"b", "c"},
+	}, blockers["a"])
+	android.AssertDeepEquals(t, "blockers of b", []string{
+		"the module sets bazel_module: { bp2build_available: false }",
+	}, blockers["b"].Reasons)
+	android.AssertDeepEquals(t, "blockers of c", []string{
+		"directory not_migrated is not enabled in Bp2buildDefaultConfig, and the module is not in " +
+			"Bp2buildModuleAlwaysConvertList",
+	}, blockers["c"].Reasons)
+}
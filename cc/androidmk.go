@@ -34,6 +34,9 @@ var (
 	VendorRamdiskSuffix = ".vendor_ramdisk"
 	RecoverySuffix      = ".recovery"
 	sdkSuffix           = ".sdk"
+
+	// The suffix of the sanitized variant of the host tools selected with SanitizeHostTools.
+	sanitizedHostToolSuffix = ".sanitized"
 )
 
 type AndroidMkContext interface {
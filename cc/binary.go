@@ -94,6 +94,8 @@ type binaryDecorator struct {
 
 	Properties BinaryLinkerProperties
 
+	bolt bolt
+
 	toolPath android.OptionalPath
 
 	// Location of the linked, unstripped binary
@@ -124,7 +126,8 @@ var _ linker = (*binaryDecorator)(nil)
 func (binary *binaryDecorator) linkerProps() []interface{} {
 	return append(binary.baseLinker.linkerProps(),
 		&binary.Properties,
-		&binary.stripper.StripProperties)
+		&binary.stripper.StripProperties,
+		&binary.bolt.Properties)
 
 }
 
@@ -228,6 +231,12 @@ func (binary *binaryDecorator) linkerInit(ctx BaseModuleContext) {
 		if binary.Properties.Static_executable == nil && ctx.Config().HostStaticBinaries() {
 			binary.Properties.Static_executable = BoolPtr(true)
 		}
+		// The musl variants of host tools in builds against glibc are meant to run on other
+		// machines, so they are static unless explicitly specified otherwise.
+		if binary.Properties.Static_executable == nil && ctx.Os() == android.LinuxMusl &&
+			ctx.Target().HostCross && !ctx.Config().UseHostMusl() {
+			binary.Properties.Static_executable = BoolPtr(true)
+		}
 	}
 
 	if ctx.Darwin() || ctx.Windows() {
@@ -253,6 +262,7 @@ func (binary *binaryDecorator) binary() bool {
 // combined with the given flags.
 func (binary *binaryDecorator) linkerFlags(ctx ModuleContext, flags Flags) Flags {
 	flags = binary.baseLinker.linkerFlags(ctx, flags)
+	flags = binary.bolt.flags(ctx, flags)
 
 	// Passing -pie to clang for Windows binaries causes a warning that -pie is unused.
 	if ctx.Host() && !ctx.Windows() && !binary.static() {
@@ -381,6 +391,9 @@ func (binary *binaryDecorator) link(ctx ModuleContext,
 
 	outputFile = maybeInjectBoringSSLHash(ctx, outputFile, binary.Properties.Inject_bssl_hash, fileName)
 
+	// Optimize the layout before the BoringSSL hash is computed, which covers the code.
+	outputFile = binary.bolt.optimize(ctx, outputFile, fileName)
+
 	// If use_version_lib is true, make an android::build::GetBuildNumber() function available.
 	if Bool(binary.baseLinker.Properties.Use_version_lib) {
 		if ctx.Host() {
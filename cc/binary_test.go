@@ -120,3 +120,56 @@ func TestBinaryLinkerScripts(t *testing.T) {
 	android.AssertStringDoesContain(t, "missing flag for linker_scripts",
 		binFoo.Args["ldFlags"], "-Wl,--script,bar.ld")
 }
+
+func TestBinaryMoldLinker(t *testing.T) {
+	t.Parallel()
+	bp := `
+		cc_binary_host {
+			name: "foo",
+			srcs: ["foo.cc"],
+			linker: "mold",
+		}
+
+		cc_binary_host {
+			name: "bar",
+			srcs: ["bar.cc"],
+		}
+
+		cc_binary {
+			name: "baz",
+			srcs: ["baz.cc"],
+			host_supported: true,
+		}`
+
+	result := android.GroupFixturePreparers(
+		PrepareForIntegrationTestWithCc,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.HostLinker = StringPtr("mold")
+		}),
+		android.MockFS{
+			"prebuilts/mold/linux-x86/bin/mold": nil,
+		}.AddToFixture(),
+	).RunTestWithBp(t, bp)
+
+	for _, name := range []string{"foo", "bar", "baz"} {
+		ld := result.ModuleForTests(name, "linux_glibc_x86_64").Rule("ld")
+		android.AssertStringDoesContain(t, name+" should be linked with mold",
+			ld.Args["ldFlags"], "--ld-path=prebuilts/mold/linux-x86/bin/mold")
+		android.AssertStringListContains(t, name+" should depend on mold",
+			ld.Implicits.Strings(), "prebuilts/mold/linux-x86/bin/mold")
+	}
+
+	device := result.ModuleForTests("baz", "android_arm64_armv8-a").Rule("ld")
+	android.AssertStringDoesNotContain(t, "device modules should be linked with lld",
+		device.Args["ldFlags"], "--ld-path")
+
+	PrepareForIntegrationTestWithCc.
+		ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+			`"foo" .*mold is only supported for Linux host modules`)).
+		RunTestWithBp(t, `
+			cc_binary {
+				name: "foo",
+				srcs: ["foo.cc"],
+				linker: "mold",
+			}`)
+}
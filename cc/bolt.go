@@ -0,0 +1,84 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"android/soong/android"
+)
+
+// BOLT is a post-link optimizer that rewrites the layout of the code of a linked binary from a
+// profile of its execution, collected with perf and converted with perf2bolt. Binaries and shared
+// libraries with a bolt profile are linked with relocations and then optimized with llvm-bolt,
+// before being stripped.
+
+// The llvm-bolt optimizations applied to binaries with a BOLT profile.
+var boltOptimizationFlags = []string{
+	"-reorder-blocks=ext-tsp",
+	"-reorder-functions=hfsort",
+	"-split-functions",
+	"-split-all-cold",
+	"-icf=1",
+	"-use-gnu-stack",
+}
+
+type BoltProperties struct {
+	Bolt struct {
+		// The BOLT profile (.fdata) of the binary or shared library. If set, the linked output
+		// is optimized with llvm-bolt using this profile. Only supported for ELF outputs.
+		Profile *string `android:"path,arch_variant"`
+	} `android:"arch_variant"`
+}
+
+type bolt struct {
+	Properties BoltProperties
+}
+
+func (bolt *bolt) props() []interface{} {
+	return []interface{}{&bolt.Properties}
+}
+
+func (bolt *bolt) enabled() bool {
+	return String(bolt.Properties.Bolt.Profile) != ""
+}
+
+// flags adds the linker flags needed by llvm-bolt.
+func (bolt *bolt) flags(ctx ModuleContext, flags Flags) Flags {
+	if !bolt.enabled() {
+		return flags
+	}
+	if ctx.Darwin() || ctx.Windows() {
+		ctx.PropertyErrorf("bolt.profile", "BOLT is only supported for ELF binaries, not for %s", ctx.Os())
+		return flags
+	}
+	// llvm-bolt needs the static relocations to move code around.
+	flags.Local.LdFlags = append(flags.Local.LdFlags, "-Wl,--emit-relocs")
+	return flags
+}
+
+// optimize adds the rules to optimize the linked file with llvm-bolt if the module has a BOLT
+// profile. Like the other post-link steps, it takes the path that the optimized file must be
+// written to and returns the path that the linked file must be written to.
+func (bolt *bolt) optimize(ctx ModuleContext, outputFile android.ModuleOutPath,
+	fileName string) android.ModuleOutPath {
+
+	if !bolt.enabled() || ctx.Darwin() || ctx.Windows() {
+		return outputFile
+	}
+	optimizedOutputFile := outputFile
+	outputFile = android.PathForModuleOut(ctx, "unbolted", fileName)
+	profile := android.PathForModuleSrc(ctx, String(bolt.Properties.Bolt.Profile))
+	transformBinaryBolt(ctx, profile, outputFile, optimizedOutputFile)
+	return outputFile
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestBolt(t *testing.T) {
+	t.Parallel()
+	bp := `
+	cc_binary {
+		name: "hot_binary",
+		srcs: ["hot.c"],
+		bolt: {
+			profile: "hot_binary.fdata",
+		},
+	}
+
+	cc_library {
+		name: "libhot",
+		srcs: ["hot.c"],
+		bolt: {
+			profile: "libhot.fdata",
+		},
+	}
+
+	cc_binary {
+		name: "cold_binary",
+		srcs: ["cold.c"],
+	}
+	`
+
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.MockFS{
+			"hot_binary.fdata": nil,
+			"libhot.fdata":     nil,
+		}.AddToFixture(),
+	).RunTestWithBp(t, bp)
+
+	hotBinary := result.ModuleForTests("hot_binary", "android_arm64_armv8-a")
+	bolt := hotBinary.Rule("boltOptimize")
+	android.AssertStringEquals(t, "hot_binary profile", "hot_binary.fdata", bolt.Args["profile"])
+	android.AssertPathRelativeToTopEquals(t, "hot_binary bolt input",
+		"out/soong/.intermediates/hot_binary/android_arm64_armv8-a/unbolted/hot_binary", bolt.Input)
+	android.AssertStringDoesContain(t, "hot_binary ldflags", hotBinary.Rule("ld").Args["ldFlags"],
+		"-Wl,--emit-relocs")
+
+	libHot := result.ModuleForTests("libhot", "android_arm64_armv8-a_shared")
+	android.AssertStringEquals(t, "libhot profile", "libhot.fdata", libHot.Rule("boltOptimize").Args["profile"])
+
+	coldBinary := result.ModuleForTests("cold_binary", "android_arm64_armv8-a")
+	if coldBinary.MaybeRule("boltOptimize").Rule != nil {
+		t.Errorf("unexpected boltOptimize rule for cold_binary")
+	}
+	android.AssertStringDoesNotContain(t, "cold_binary ldflags", coldBinary.Rule("ld").Args["ldFlags"],
+		"-Wl,--emit-relocs")
+}
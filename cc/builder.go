@@ -53,23 +53,25 @@ var (
 	// Rule to invoke gcc with given command and flags, but no dependencies.
 	ccNoDeps = pctx.AndroidStaticRule("ccNoDeps",
 		blueprint.RuleParams{
-			Command:     "$relPwd $ccCmd -c $cFlags -o $out $in",
+			Command:     "$relPwd ${config.CcCacheWrapper}$ccCmd -c $cFlags -o $out $in",
 			CommandDeps: []string{"$ccCmd"},
 		},
 		"ccCmd", "cFlags")
 
 	// Rules to invoke ld to link binaries. Uses a .rsp file to list dependencies, as there may
 	// be many.
-	ld, ldRE = pctx.RemoteStaticRules("ld",
-		blueprint.RuleParams{
-			Command: "$reTemplate$ldCmd ${crtBegin} @${out}.rsp " +
-				"${crtEnd} -o ${out} ${ldFlags} ${extraLibFlags}",
-			CommandDeps:    []string{"$ldCmd"},
-			Rspfile:        "${out}.rsp",
-			RspfileContent: "${in} ${libFlags}",
-			// clang -Wl,--out-implib doesn't update its output file if it hasn't changed.
-			Restat: true,
-		},
+	ldParams = blueprint.RuleParams{
+		Command: "$reTemplate$ldCmd ${crtBegin} @${out}.rsp " +
+			"${crtEnd} -o ${out} ${ldFlags} ${extraLibFlags}",
+		CommandDeps:    []string{"$ldCmd"},
+		Rspfile:        "${out}.rsp",
+		RspfileContent: "${in} ${libFlags}",
+		// clang -Wl,--out-implib doesn't update its output file if it hasn't changed.
+		Restat: true,
+	}
+	ldArgs = []string{"ldCmd", "crtBegin", "libFlags", "crtEnd", "ldFlags", "extraLibFlags"}
+
+	ld, ldRE = pctx.RemoteStaticRules("ld", ldParams,
 		&remoteexec.REParams{
 			Labels:          map[string]string{"type": "link", "tool": "clang"},
 			ExecStrategy:    "${config.RECXXLinksExecStrategy}",
@@ -78,7 +80,12 @@ var (
 			OutputFiles:     []string{"${out}", "$implicitOutputs"},
 			ToolchainInputs: []string{"$ldCmd"},
 			Platform:        map[string]string{remoteexec.PoolKey: "${config.RECXXLinksPool}"},
-		}, []string{"ldCmd", "crtBegin", "libFlags", "crtEnd", "ldFlags", "extraLibFlags"}, []string{"implicitInputs", "implicitOutputs"})
+		}, ldArgs, []string{"implicitInputs", "implicitOutputs"})
+
+	// Linking the largest libraries with lld uses lots of memory. The product can restrict the
+	// number of them that run in parallel by putting their modules in the lld action pool.
+	lldPool  = pctx.ActionPool("lld")
+	ldPooled = lldPool.StaticRule("ldPooled", ldParams, []string{"$reTemplate"}, ldArgs...)
 
 	// Rules for .o files to combine to other .o files, using ld partial linking.
 	partialLd, partialLdRE = pctx.RemoteStaticRules("partialLd",
@@ -125,6 +132,14 @@ var (
 		},
 		"objcopyCmd", "prefix")
 
+	// Rule to optimize the layout of a linked binary or shared library with llvm-bolt.
+	boltOptimize = pctx.AndroidStaticRule("boltOptimize",
+		blueprint.RuleParams{
+			Command:     "${config.ClangBin}/llvm-bolt ${in} -o ${out} -data=${profile} ${boltFlags}",
+			CommandDeps: []string{"${config.ClangBin}/llvm-bolt"},
+		},
+		"profile", "boltFlags")
+
 	_ = pctx.SourcePathVariable("stripPath", "build/soong/scripts/strip.sh")
 	_ = pctx.SourcePathVariable("xzCmd", "prebuilts/build-tools/${config.HostPrebuiltTag}/bin/xz")
 	_ = pctx.SourcePathVariable("createMiniDebugInfo", "prebuilts/build-tools/${config.HostPrebuiltTag}/bin/create_minidebuginfo")
@@ -208,7 +223,7 @@ var (
 			Depfile: "${out}.d",
 			Deps:    blueprint.DepsGCC,
 			Command: "CLANG_CMD=$clangCmd TIDY_FILE=$out " +
-				"$tidyVars$reTemplate${config.ClangBin}/clang-tidy.sh $in $tidyFlags -- $cFlags",
+				"$tidyVars$reTemplate${config.ClangBin}/clang-tidy.sh $in $tidyFlags -- $cFlags$tidyLog",
 			CommandDeps: []string{"${config.ClangBin}/clang-tidy.sh", "$ccCmd", "$tidyCmd"},
 		},
 		&remoteexec.REParams{
@@ -225,7 +240,7 @@ var (
 			// (1) New timestamps trigger clang and clang-tidy compilations again.
 			// (2) Changing source files caused concurrent clang or clang-tidy jobs to crash.
 			Platform: map[string]string{remoteexec.PoolKey: "${config.REClangTidyPool}"},
-		}, []string{"cFlags", "ccCmd", "clangCmd", "tidyCmd", "tidyFlags", "tidyVars"}, []string{})
+		}, []string{"cFlags", "ccCmd", "clangCmd", "tidyCmd", "tidyFlags", "tidyLog", "tidyVars"}, []string{})
 
 	_ = pctx.SourcePathVariable("yasmCmd", "prebuilts/misc/${config.HostPrebuiltTag}/yasm/yasm")
 
@@ -378,9 +393,18 @@ type builderFlags struct {
 	// True if these extra features are enabled.
 	tidy          bool
 	needTidyFiles bool
+	tidyBaseline  map[string][]string
 	gcovCoverage  bool
 	sAbiDump      bool
 	emitXrefs     bool
+	sdclang       bool
+	splitDwarf    bool
+	sarif         bool
+	analyzer      bool
+	warningLog    bool
+	clangVersion  string
+
+	clangPluginFlags string
 
 	assemblerWithCpp bool // True if .s files should be processed with the c preprocessor.
 
@@ -414,6 +438,8 @@ type Objects struct {
 	coverageFiles android.Paths
 	sAbiDumpFiles android.Paths
 	kytheFiles    android.Paths
+	sarifFiles    android.Paths // clang-tidy outputs and static analyzer SARIF files
+	warningFiles  android.Paths // compiler warnings for the warning baselines
 }
 
 func (a Objects) Copy() Objects {
@@ -424,6 +450,8 @@ func (a Objects) Copy() Objects {
 		coverageFiles: append(android.Paths{}, a.coverageFiles...),
 		sAbiDumpFiles: append(android.Paths{}, a.sAbiDumpFiles...),
 		kytheFiles:    append(android.Paths{}, a.kytheFiles...),
+		sarifFiles:    append(android.Paths{}, a.sarifFiles...),
+		warningFiles:  append(android.Paths{}, a.warningFiles...),
 	}
 }
 
@@ -435,6 +463,8 @@ func (a Objects) Append(b Objects) Objects {
 		coverageFiles: append(a.coverageFiles, b.coverageFiles...),
 		sAbiDumpFiles: append(a.sAbiDumpFiles, b.sAbiDumpFiles...),
 		kytheFiles:    append(a.kytheFiles, b.kytheFiles...),
+		sarifFiles:    append(a.sarifFiles, b.sarifFiles...),
+		warningFiles:  append(a.warningFiles, b.warningFiles...),
 	}
 }
 
@@ -468,46 +498,103 @@ func transformSourceToObj(ctx ModuleContext, subdir string, srcFiles, noTidySrcs
 	if flags.emitXrefs {
 		kytheFiles = make(android.Paths, 0, len(srcFiles))
 	}
+	var sarifFiles android.Paths
+	var warningFiles android.Paths
+
+	// Multiple source files have build rules usually share the same cFlags or tidyFlags.
+	// Define only one version in this module and share it in multiple build rules.
+	// To simplify the code, the shared variables are all named as $flags<nnn>.
+	shared := ctx.getSharedFlags()
+
+	// Share flags only when there are multiple files or tidy rules.
+	var hasMultipleRules = len(srcFiles) > 1 || flags.tidy
+
+	var shareFlags = func(kind string, flags string) string {
+		if !hasMultipleRules || len(flags) < 60 {
+			// Modules have long names and so do the module variables.
+			// It does not save space by replacing a short name with a long one.
+			return flags
+		}
+		mapKey := kind + flags
+		n, ok := shared.flagsMap[mapKey]
+		if !ok {
+			shared.numSharedFlags += 1
+			n = strconv.Itoa(shared.numSharedFlags)
+			shared.flagsMap[mapKey] = n
+			ctx.Variable(pctx, kind+n, flags)
+		}
+		return "$" + kind + n
+	}
 
-	// Produce fully expanded flags for use by C tools, C compiles, C++ tools, C++ compiles, and asm compiles
+	// The flag groups that are part of the flags of every kind of source file, like the include
+	// flags of the module and of its dependencies, are shared by all of them too, so that they
+	// aren't repeated in the variables of the C, C++, tooling and asm flags of the module.
+	groups := make(map[string]string)
+	var shareGroup = func(kind string, flags string) string {
+		ref := shareFlags(kind, flags)
+		groups[ref] = flags
+		return ref
+	}
+	globalCommonFlags := shareGroup("commonFlags", flags.globalCommonFlags)
+	localCommonFlags := shareGroup("commonFlags", flags.localCommonFlags)
+	systemIncludeFlags := shareGroup("includeFlags", flags.systemIncludeFlags)
+
+	// expandGroups replaces the references to the shared flag groups in flags with their values.
+	var expandGroups = func(flags string) string {
+		fields := strings.Split(flags, " ")
+		for i, field := range fields {
+			if value, ok := groups[field]; ok {
+				fields[i] = value
+			}
+		}
+		return strings.Join(fields, " ")
+	}
+
+	// Produce flags for use by C tools, C compiles, C++ tools, C++ compiles, and asm compiles
 	// respectively.
-	toolingCflags := flags.globalCommonFlags + " " +
+	toolingCflags := globalCommonFlags + " " +
 		flags.globalToolingCFlags + " " +
 		flags.globalConlyFlags + " " +
-		flags.localCommonFlags + " " +
+		localCommonFlags + " " +
 		flags.localToolingCFlags + " " +
 		flags.localConlyFlags + " " +
-		flags.systemIncludeFlags
+		systemIncludeFlags
 
-	cflags := flags.globalCommonFlags + " " +
+	cflags := globalCommonFlags + " " +
 		flags.globalCFlags + " " +
 		flags.globalConlyFlags + " " +
-		flags.localCommonFlags + " " +
+		localCommonFlags + " " +
 		flags.localCFlags + " " +
 		flags.localConlyFlags + " " +
-		flags.systemIncludeFlags
+		systemIncludeFlags
 
-	toolingCppflags := flags.globalCommonFlags + " " +
+	toolingCppflags := globalCommonFlags + " " +
 		flags.globalToolingCFlags + " " +
 		flags.globalToolingCppFlags + " " +
-		flags.localCommonFlags + " " +
+		localCommonFlags + " " +
 		flags.localToolingCFlags + " " +
 		flags.localToolingCppFlags + " " +
-		flags.systemIncludeFlags
+		systemIncludeFlags
 
-	cppflags := flags.globalCommonFlags + " " +
+	cppflags := globalCommonFlags + " " +
 		flags.globalCFlags + " " +
 		flags.globalCppFlags + " " +
-		flags.localCommonFlags + " " +
+		localCommonFlags + " " +
 		flags.localCFlags + " " +
 		flags.localCppFlags + " " +
-		flags.systemIncludeFlags
+		systemIncludeFlags
 
-	asflags := flags.globalCommonFlags + " " +
+	asflags := globalCommonFlags + " " +
 		flags.globalAsFlags + " " +
-		flags.localCommonFlags + " " +
+		localCommonFlags + " " +
 		flags.localAsFlags + " " +
-		flags.systemIncludeFlags
+		systemIncludeFlags
+
+	if flags.clangPluginFlags != "" {
+		// The clang tools and the assembler don't run the clang plugins.
+		cflags += " " + flags.clangPluginFlags
+		cppflags += " " + flags.clangPluginFlags
+	}
 
 	var sAbiDumpFiles android.Paths
 	if flags.sAbiDump {
@@ -519,6 +606,13 @@ func transformSourceToObj(ctx ModuleContext, subdir string, srcFiles, noTidySrcs
 	cppflags += " ${config.NoOverrideGlobalCflags}"
 	toolingCppflags += " ${config.NoOverrideGlobalCflags}"
 
+	if flags.clangVersion != "" {
+		cflags += " ${config.ClangVersionExtraCflags}"
+		toolingCflags += " ${config.ClangVersionExtraCflags}"
+		cppflags += " ${config.ClangVersionExtraCflags}"
+		toolingCppflags += " ${config.ClangVersionExtraCflags}"
+	}
+
 	if flags.toolchain.Is64Bit() {
 		cflags += " ${config.NoOverride64GlobalCflags}"
 		toolingCflags += " ${config.NoOverride64GlobalCflags}"
@@ -534,31 +628,6 @@ func transformSourceToObj(ctx ModuleContext, subdir string, srcFiles, noTidySrcs
 		toolingCppflags += " ${config.NoOverrideExternalGlobalCflags}"
 	}
 
-	// Multiple source files have build rules usually share the same cFlags or tidyFlags.
-	// Define only one version in this module and share it in multiple build rules.
-	// To simplify the code, the shared variables are all named as $flags<nnn>.
-	shared := ctx.getSharedFlags()
-
-	// Share flags only when there are multiple files or tidy rules.
-	var hasMultipleRules = len(srcFiles) > 1 || flags.tidy
-
-	var shareFlags = func(kind string, flags string) string {
-		if !hasMultipleRules || len(flags) < 60 {
-			// Modules have long names and so do the module variables.
-			// It does not save space by replacing a short name with a long one.
-			return flags
-		}
-		mapKey := kind + flags
-		n, ok := shared.flagsMap[mapKey]
-		if !ok {
-			shared.numSharedFlags += 1
-			n = strconv.Itoa(shared.numSharedFlags)
-			shared.flagsMap[mapKey] = n
-			ctx.Variable(pctx, kind+n, flags)
-		}
-		return "$" + kind + n
-	}
-
 	for i, srcFile := range srcFiles {
 		objFile := android.ObjPathWithExt(ctx, subdir, srcFile, "o")
 
@@ -591,6 +660,8 @@ func transformSourceToObj(ctx ModuleContext, subdir string, srcFiles, noTidySrcs
 		tidy := flags.tidy
 		coverage := flags.gcovCoverage
 		dump := flags.sAbiDump
+		splitDwarf := flags.splitDwarf
+		analyze := flags.analyzer
 		rule := cc
 		emitXref := flags.emitXrefs
 
@@ -607,6 +678,8 @@ func transformSourceToObj(ctx ModuleContext, subdir string, srcFiles, noTidySrcs
 			coverage = false
 			dump = false
 			emitXref = false
+			splitDwarf = false
+			analyze = false
 		case ".c":
 			ccCmd = "clang"
 			moduleFlags = cflags
@@ -626,7 +699,7 @@ func transformSourceToObj(ctx ModuleContext, subdir string, srcFiles, noTidySrcs
 		// ccCmd is "clang" or "clang++"
 		ccDesc := ccCmd
 
-		ccCmd = "${config.ClangBin}/" + ccCmd
+		ccCmd = clangBin(flags) + "/" + ccCmd
 
 		var implicitOutputs android.WritablePaths
 		if coverage {
@@ -634,6 +707,17 @@ func transformSourceToObj(ctx ModuleContext, subdir string, srcFiles, noTidySrcs
 			implicitOutputs = append(implicitOutputs, gcnoFile)
 			coverageFiles = append(coverageFiles, gcnoFile)
 		}
+		if splitDwarf {
+			// -gsplit-dwarf writes the debug info to a .dwo file next to the object file.
+			implicitOutputs = append(implicitOutputs, android.ObjPathWithExt(ctx, subdir, srcFile, "dwo"))
+		}
+		if flags.warningLog && rule == cc {
+			// The warnings of the compilation are also kept for the warning baseline.
+			rule = ccWarningLog
+			warningFile := android.ObjPathWithExt(ctx, subdir, srcFile, "o.warnings")
+			implicitOutputs = append(implicitOutputs, warningFile)
+			warningFiles = append(warningFiles, warningFile)
+		}
 
 		ctx.Build(pctx, android.BuildParams{
 			Rule:            rule,
@@ -670,7 +754,7 @@ func transformSourceToObj(ctx ModuleContext, subdir string, srcFiles, noTidySrcs
 		if tidy && !noTidySrcsMap[srcFile.String()] {
 			tidyFile := android.ObjPathWithExt(ctx, subdir, srcFile, "tidy")
 			tidyFiles = append(tidyFiles, tidyFile)
-			tidyCmd := "${config.ClangBin}/clang-tidy"
+			tidyCmd := clangToolsBin(flags) + "/clang-tidy"
 
 			rule := clangTidy
 			reducedCFlags := moduleFlags
@@ -679,31 +763,62 @@ func transformSourceToObj(ctx ModuleContext, subdir string, srcFiles, noTidySrcs
 				// b/248371171, work around RBE input processor problem
 				// some cflags rejected by input processor, but usually
 				// do not affect included files or clang-tidy
-				reducedCFlags = config.TidyReduceCFlags(reducedCFlags)
+				reducedCFlags = config.TidyReduceCFlags(expandGroups(reducedCFlags))
 			}
 
 			sharedCFlags := shareFlags("cFlags", reducedCFlags)
 			srcRelPath := srcFile.Rel()
+			tidyFlags := config.TidyFlagsForSrcFile(srcFile, flags.tidyFlags)
+			tidyFlags = config.TidyFlagsForBaseline(tidyFlags, flags.tidyBaseline[srcFile.String()])
+
+			// The output of clang-tidy is also kept for the SARIF files of the module.
+			var tidyLog string
+			var tidyImplicitOutputs android.WritablePaths
+			if flags.sarif {
+				tidyLogFile := android.ObjPathWithExt(ctx, subdir, srcFile, "tidy.log")
+				tidyLog = tidyLogRedirect(tidyLogFile)
+				tidyImplicitOutputs = append(tidyImplicitOutputs, tidyLogFile)
+				sarifFiles = append(sarifFiles, tidyLogFile)
+			}
 
 			// Add the .tidy rule
 			ctx.Build(pctx, android.BuildParams{
-				Rule:        rule,
-				Description: "clang-tidy " + srcRelPath,
-				Output:      tidyFile,
-				Input:       srcFile,
-				Implicits:   cFlagsDeps,
-				OrderOnly:   pathDeps,
+				Rule:            rule,
+				Description:     "clang-tidy " + srcRelPath,
+				Output:          tidyFile,
+				ImplicitOutputs: tidyImplicitOutputs,
+				Input:           srcFile,
+				Implicits:       cFlagsDeps,
+				OrderOnly:       pathDeps,
 				Args: map[string]string{
 					"cFlags":    sharedCFlags,
 					"ccCmd":     ccCmd,
 					"clangCmd":  ccDesc,
 					"tidyCmd":   tidyCmd,
-					"tidyFlags": shareFlags("tidyFlags", config.TidyFlagsForSrcFile(srcFile, flags.tidyFlags)),
+					"tidyFlags": shareFlags("tidyFlags", tidyFlags),
+					"tidyLog":   tidyLog,  // short and not shared
 					"tidyVars":  tidyVars, // short and not shared
 				},
 			})
 		}
 
+		if analyze {
+			sarifFile := android.ObjPathWithExt(ctx, subdir, srcFile, "sarif")
+			sarifFiles = append(sarifFiles, sarifFile)
+			ctx.Build(pctx, android.BuildParams{
+				Rule:        clangAnalyzer,
+				Description: "clang static analyzer " + srcFile.Rel(),
+				Output:      sarifFile,
+				Input:       srcFile,
+				Implicits:   cFlagsDeps,
+				OrderOnly:   pathDeps,
+				Args: map[string]string{
+					"cFlags": shareFlags("cFlags", moduleFlags),
+					"ccCmd":  ccCmd, // short and not shared
+				},
+			})
+		}
+
 		if dump {
 			sAbiDumpFile := android.ObjPathWithExt(ctx, subdir, srcFile, "sdump")
 			sAbiDumpFiles = append(sAbiDumpFiles, sAbiDumpFile)
@@ -740,7 +855,46 @@ func transformSourceToObj(ctx ModuleContext, subdir string, srcFiles, noTidySrcs
 		coverageFiles: coverageFiles,
 		sAbiDumpFiles: sAbiDumpFiles,
 		kytheFiles:    kytheFiles,
+		sarifFiles:    sarifFiles,
+		warningFiles:  warningFiles,
+	}
+}
+
+// Generate a rule for precompiling a C++ header with the flags of the C++ sources of a module.
+func transformHeaderToPch(ctx ModuleContext, header android.Path, outputFile android.WritablePath,
+	flags builderFlags, pathDeps android.Paths, cFlagsDeps android.Paths) {
+
+	cppflags := flags.globalCommonFlags + " " +
+		flags.globalCFlags + " " +
+		flags.globalCppFlags + " " +
+		flags.localCommonFlags + " " +
+		flags.localCFlags + " " +
+		flags.localCppFlags + " " +
+		flags.systemIncludeFlags
+
+	cppflags += " ${config.NoOverrideGlobalCflags}"
+	if flags.clangVersion != "" {
+		cppflags += " ${config.ClangVersionExtraCflags}"
 	}
+	if flags.toolchain.Is64Bit() {
+		cppflags += " ${config.NoOverride64GlobalCflags}"
+	}
+	if android.IsThirdPartyPath(android.PathForModuleSrc(ctx).String()) {
+		cppflags += " ${config.NoOverrideExternalGlobalCflags}"
+	}
+
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        cc,
+		Description: "clang++ pch " + header.Rel(),
+		Output:      outputFile,
+		Input:       header,
+		Implicits:   cFlagsDeps,
+		OrderOnly:   pathDeps,
+		Args: map[string]string{
+			"cFlags": "-x c++-header " + cppflags,
+			"ccCmd":  clangBin(flags) + "/clang++",
+		},
+	})
 }
 
 // Generate a rule for compiling multiple .o files to a static library (.a)
@@ -748,7 +902,7 @@ func transformObjToStaticLib(ctx android.ModuleContext,
 	objFiles android.Paths, wholeStaticLibs android.Paths,
 	flags builderFlags, outputFile android.ModuleOutPath, deps android.Paths, validations android.Paths) {
 
-	arCmd := "${config.ClangBin}/llvm-ar"
+	arCmd := clangToolsBin(flags) + "/llvm-ar"
 	arFlags := ""
 	if !ctx.Darwin() {
 		arFlags += " --format=gnu"
@@ -786,6 +940,24 @@ func transformObjToStaticLib(ctx android.ModuleContext,
 	}
 }
 
+// clangBin returns the bin directory of the clang that compiles the sources of the module.
+func clangBin(flags builderFlags) string {
+	if flags.sdclang {
+		return "${config.SdclangBin}"
+	}
+	return clangToolsBin(flags)
+}
+
+// clangToolsBin returns the bin directory of the clang whose tools link, archive and lint the
+// module. Modules compiled with the Snapdragon LLVM toolchain still use the tools of the platform
+// clang.
+func clangToolsBin(flags builderFlags) string {
+	if flags.clangVersion != "" {
+		return config.ClangBinForVersion(flags.clangVersion)
+	}
+	return "${config.ClangBin}"
+}
+
 // Generate a rule for compiling multiple .o files, plus static libraries, whole static libraries,
 // and shared libraries, to a shared library (.so) or dynamic executable
 func transformObjToDynamicBinary(ctx android.ModuleContext,
@@ -793,7 +965,7 @@ func transformObjToDynamicBinary(ctx android.ModuleContext,
 	groupLate bool, flags builderFlags, outputFile android.WritablePath,
 	implicitOutputs android.WritablePaths, validations android.Paths) {
 
-	ldCmd := "${config.ClangBin}/clang++"
+	ldCmd := clangToolsBin(flags) + "/clang++"
 
 	var libFlagsList []string
 
@@ -848,6 +1020,8 @@ func transformObjToDynamicBinary(ctx android.ModuleContext,
 		rule = ldRE
 		args["implicitOutputs"] = strings.Join(implicitOutputs.Strings(), ",")
 		args["implicitInputs"] = strings.Join(deps.Strings(), ",")
+	} else if lldPool.Contains(ctx) {
+		rule = ldPooled
 	}
 
 	ctx.Build(pctx, android.BuildParams{
@@ -968,7 +1142,7 @@ func TransformSharedObjectToToc(ctx android.ModuleContext, inputFile android.Pat
 func transformObjsToObj(ctx android.ModuleContext, objFiles android.Paths,
 	flags builderFlags, outputFile android.WritablePath, deps android.Paths) {
 
-	ldCmd := "${config.ClangBin}/clang++"
+	ldCmd := clangToolsBin(flags) + "/clang++"
 
 	rule := partialLd
 	args := map[string]string{
@@ -994,7 +1168,7 @@ func transformObjsToObj(ctx android.ModuleContext, objFiles android.Paths,
 func transformBinaryPrefixSymbols(ctx android.ModuleContext, prefix string, inputFile android.Path,
 	flags builderFlags, outputFile android.WritablePath) {
 
-	objcopyCmd := "${config.ClangBin}/llvm-objcopy"
+	objcopyCmd := clangToolsBin(flags) + "/llvm-objcopy"
 
 	ctx.Build(pctx, android.BuildParams{
 		Rule:        prefixSymbols,
@@ -1008,6 +1182,24 @@ func transformBinaryPrefixSymbols(ctx android.ModuleContext, prefix string, inpu
 	})
 }
 
+// Registers a build statement to optimize a linked binary or shared library with llvm-bolt, using
+// the given BOLT profile.
+func transformBinaryBolt(ctx android.ModuleContext, profile android.Path, inputFile android.Path,
+	outputFile android.WritablePath) {
+
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        boltOptimize,
+		Description: "bolt " + outputFile.Base(),
+		Output:      outputFile,
+		Input:       inputFile,
+		Implicit:    profile,
+		Args: map[string]string{
+			"profile":   profile.String(),
+			"boltFlags": strings.Join(boltOptimizationFlags, " "),
+		},
+	})
+}
+
 // Registers a build statement to invoke `strip` (to discard symbols and data from object files).
 func transformStrip(ctx android.ModuleContext, inputFile android.Path,
 	outputFile android.WritablePath, flags StripFlags) {
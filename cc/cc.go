@@ -51,6 +51,7 @@ func RegisterCCBuildComponents(ctx android.RegistrationContext) {
 		ctx.BottomUp("link", LinkageMutator).Parallel()
 		ctx.BottomUp("test_per_src", TestPerSrcMutator).Parallel()
 		ctx.BottomUp("version", versionMutator).Parallel()
+		ctx.Transition("sanitize_host_tool", &sanitizedHostToolTransitionMutator{})
 		ctx.BottomUp("begin", BeginMutator).Parallel()
 		ctx.BottomUp("fdo_profile", fdoProfileMutator)
 	})
@@ -63,6 +64,8 @@ func RegisterCCBuildComponents(ctx android.RegistrationContext) {
 		ctx.TopDown("sanitize_runtime_deps", sanitizerRuntimeDepsMutator).Parallel()
 		ctx.BottomUp("sanitize_runtime", sanitizerRuntimeMutator).Parallel()
 
+		ctx.Transition("clang_version", &clangVersionTransitionMutator{})
+
 		ctx.TopDown("fuzz_deps", fuzzMutatorDeps)
 
 		ctx.BottomUp("coverage", coverageMutator).Parallel()
@@ -117,6 +120,9 @@ type Deps struct {
 
 	ObjFiles []string
 
+	// Name of the cc_pch module that provides the precompiled header.
+	PrecompiledHeader string
+
 	GeneratedSources []string
 	GeneratedHeaders []string
 	GeneratedDeps    []string
@@ -163,6 +169,9 @@ type PathDeps struct {
 	GeneratedSources android.Paths
 	GeneratedDeps    android.Paths
 
+	// Precompiled header included in the C++ compiles
+	PrecompiledHeader *PrecompiledHeaderInfo
+
 	Flags                      []string
 	LdFlags                    []string
 	IncludeDirs                android.Paths
@@ -224,6 +233,21 @@ type Flags struct {
 	GcovCoverage  bool // True if coverage files should be generated.
 	SAbiDump      bool // True if header abi dumps should be generated.
 	EmitXrefs     bool // If true, generate Ninja rules to generate emitXrefs input files for Kythe
+	Sdclang       bool // True if sources should be compiled with the Snapdragon LLVM toolchain.
+	SplitDwarf    bool // True if the debug info of C and C++ sources should be split into .dwo files.
+	Sarif         bool // True if the static analysis diagnostics should be collected into SARIF files.
+	Analyzer      bool // True if the sources should be analyzed with the static analyzer of SDClang.
+	WarningLog    bool // True if the compiler warnings should be kept for the warning baseline.
+
+	// The flags loading the clang plugins of the product, which only the compiles of the C and C++
+	// sources use.
+	ClangPluginFlags []string
+
+	// The checks of the pre-existing clang-tidy findings of each source file that are not errors.
+	TidyBaseline map[string][]string
+
+	// The version of the prebuilt clang to use instead of the default one, or "".
+	ClangVersion string
 
 	// The instruction set required for clang ("arm" or "thumb").
 	RequiredInstructionSet string
@@ -297,6 +321,10 @@ type BaseProperties struct {
 	// Suffix for the name of Android.mk entries generated by this module
 	SubName string `blueprint:"mutated"`
 
+	// The version of the prebuilt clang that builds this module, or "" for the default one. Set
+	// by the clang_version mutator.
+	ClangVersion string `blueprint:"mutated"`
+
 	// *.logtags files, to combine together in order to generate the /system/etc/event-log-tags
 	// file
 	Logtags []string
@@ -754,6 +782,7 @@ var (
 	genHeaderDepTag       = dependencyTag{name: "gen header"}
 	genHeaderExportDepTag = dependencyTag{name: "gen header export"}
 	objDepTag             = dependencyTag{name: "obj"}
+	pchDepTag             = dependencyTag{name: "pch"}
 	dynamicLinkerDepTag   = installDependencyTag{name: "dynamic linker"}
 	reuseObjTag           = dependencyTag{name: "reuse objects"}
 	staticVariantTag      = dependencyTag{name: "static variant"}
@@ -844,16 +873,18 @@ type Module struct {
 	installer    installer
 	bazelHandler BazelHandler
 
-	features []feature
-	stl      *stl
-	sanitize *sanitize
-	coverage *coverage
-	fuzzer   *fuzzer
-	sabi     *sabi
-	vndkdep  *vndkdep
-	lto      *lto
-	afdo     *afdo
-	pgo      *pgo
+	features  []feature
+	stl       *stl
+	sanitize  *sanitize
+	coverage  *coverage
+	fuzzer    *fuzzer
+	sabi      *sabi
+	vndkdep   *vndkdep
+	lto       *lto
+	afdo      *afdo
+	propeller *propeller
+	pgo       *pgo
+	gwpAsan   *gwpAsan
 
 	library libraryInterface
 
@@ -879,6 +910,14 @@ type Module struct {
 	objFiles android.Paths
 	// Tidy .tidy file output paths for this compilation module
 	tidyFiles android.Paths
+	// Compiler warnings of the objects for the warning baselines, see warning_baseline.go
+	warningFiles android.Paths
+	// The entry of the module in cc_exports.json, see exports_report.go
+	exportsReport *ccExportsModule
+	// Split debug info .dwp file output path for this compilation module
+	dwpFile android.OptionalPath
+	// SARIF file of the static analysis diagnostics of this compilation module
+	sarifFile android.OptionalPath
 
 	// For apex variants, this is set as apex.min_sdk_version
 	apexSdkVersion android.ApiLevel
@@ -1214,9 +1253,15 @@ func (c *Module) Init() android.Module {
 	if c.afdo != nil {
 		c.AddProperties(c.afdo.props()...)
 	}
+	if c.propeller != nil {
+		c.AddProperties(c.propeller.props()...)
+	}
 	if c.pgo != nil {
 		c.AddProperties(c.pgo.props()...)
 	}
+	if c.gwpAsan != nil {
+		c.AddProperties(c.gwpAsan.props()...)
+	}
 	for _, feature := range c.features {
 		c.AddProperties(feature.props()...)
 	}
@@ -1752,7 +1797,9 @@ func newModule(hod android.HostOrDeviceSupported, multilib android.Multilib) *Mo
 	module.vndkdep = &vndkdep{}
 	module.lto = &lto{}
 	module.afdo = &afdo{}
+	module.propeller = &propeller{}
 	module.pgo = &pgo{}
+	module.gwpAsan = &gwpAsan{}
 	return module
 }
 
@@ -1864,6 +1911,10 @@ func GetSubnameProperty(actx android.ModuleContext, c LinkableInterface) string
 		subName = sdkSuffix
 	}
 
+	if m, ok := c.(*Module); ok && m.sanitize != nil && m.sanitize.Properties.SanitizedHostTool {
+		subName += sanitizedHostToolSuffix
+	}
+
 	return subName
 }
 
@@ -2008,8 +2059,9 @@ func (c *Module) GenerateAndroidBuildActions(actx android.ModuleContext) {
 	}
 
 	flags := Flags{
-		Toolchain: c.toolchain(ctx),
-		EmitXrefs: ctx.Config().EmitXrefRules(),
+		Toolchain:    c.toolchain(ctx),
+		EmitXrefs:    ctx.Config().EmitXrefRules(),
+		ClangVersion: c.Properties.ClangVersion,
 	}
 	if c.compiler != nil {
 		flags = c.compiler.compilerFlags(ctx, flags, deps)
@@ -2035,12 +2087,28 @@ func (c *Module) GenerateAndroidBuildActions(actx android.ModuleContext) {
 	if c.afdo != nil {
 		flags = c.afdo.flags(ctx, flags)
 	}
+	if c.propeller != nil {
+		flags = c.propeller.flags(ctx, flags)
+	}
 	if c.pgo != nil {
 		flags = c.pgo.flags(ctx, flags)
 	}
+	if c.gwpAsan != nil {
+		flags = c.gwpAsan.flags(ctx, flags)
+	}
 	for _, feature := range c.features {
 		flags = feature.flags(ctx, flags)
 	}
+	if useSplitDwarf(ctx, c) {
+		flags.SplitDwarf = true
+		flags.Local.CFlags = append(flags.Local.CFlags, "-gsplit-dwarf")
+	}
+	if useSarif(ctx) {
+		flags.Analyzer = flags.Sdclang && ctx.Config().IsEnvTrue("SDCLANG_SA_ENABLED")
+		flags.Sarif = flags.Tidy || flags.Analyzer
+	}
+	flags.WarningLog = useWarningBaseline(ctx)
+	flags = clangPluginFlags(ctx, flags)
 	if ctx.Failed() {
 		return
 	}
@@ -2071,12 +2139,19 @@ func (c *Module) GenerateAndroidBuildActions(actx android.ModuleContext) {
 	var objs Objects
 	if c.compiler != nil {
 		objs = c.compiler.compile(ctx, flags, deps)
+		if c.gwpAsan != nil {
+			objs = objs.Append(c.gwpAsan.compile(ctx, flags, deps))
+		}
 		if ctx.Failed() {
 			return
 		}
 		c.kytheFiles = objs.kytheFiles
 		c.objFiles = objs.objFiles
 		c.tidyFiles = objs.tidyFiles
+		c.warningFiles = objs.warningFiles
+		if len(objs.sarifFiles) > 0 {
+			c.sarifFile = android.OptionalPathForPath(mergeModuleSarif(ctx, objs.sarifFiles))
+		}
 	}
 
 	if c.linker != nil {
@@ -2086,8 +2161,18 @@ func (c *Module) GenerateAndroidBuildActions(actx android.ModuleContext) {
 		}
 		c.outputFile = android.OptionalPathForPath(outputFile)
 
+		// Static libraries keep referring to the .dwo files of their objects, which are packaged
+		// with the binaries and shared libraries that link them.
+		if unstripped := c.linker.unstrippedOutputFilePath(); flags.SplitDwarf && !ctx.static() && unstripped != nil {
+			c.dwpFile = android.OptionalPathForPath(transformDwoToDwp(ctx, unstripped))
+		}
+
 		c.maybeUnhideFromMake()
 
+		if ccExportsReportEnabled(ctx.Config()) {
+			c.collectExportsReport(ctx)
+		}
+
 		// glob exported headers for snapshot, if BOARD_VNDK_VERSION is current or
 		// RECOVERY_SNAPSHOT_VERSION is current.
 		if i, ok := c.linker.(snapshotLibraryInterface); ok {
@@ -2657,6 +2742,9 @@ func (c *Module) DepsMutator(actx android.BottomUpMutatorContext) {
 
 	crtVariations := GetCrtVariations(ctx, c)
 	actx.AddVariationDependencies(crtVariations, objDepTag, deps.ObjFiles...)
+	if deps.PrecompiledHeader != "" {
+		actx.AddVariationDependencies(nil, pchDepTag, deps.PrecompiledHeader)
+	}
 	for _, crt := range deps.CrtBegin {
 		actx.AddVariationDependencies(crtVariations, CrtBeginDepTag,
 			GetReplaceModuleName(crt, GetSnapshot(c, &snapshotInfo, actx).Objects))
@@ -3301,6 +3389,13 @@ func (c *Module) depsToPaths(ctx android.ModuleContext) PathDeps {
 				c.Properties.SnapshotRuntimeLibs = append(c.Properties.SnapshotRuntimeLibs, BaseLibName(depName))
 			case objDepTag:
 				depPaths.Objs.objFiles = append(depPaths.Objs.objFiles, linkFile.Path())
+			case pchDepTag:
+				if !ctx.OtherModuleHasProvider(dep, PrecompiledHeaderInfoProvider) {
+					ctx.PropertyErrorf("precompiled_header", "module %q is not a cc_pch module", depName)
+					return
+				}
+				pchInfo := ctx.OtherModuleProvider(dep, PrecompiledHeaderInfoProvider).(PrecompiledHeaderInfo)
+				depPaths.PrecompiledHeader = &pchInfo
 			case CrtBeginDepTag:
 				depPaths.CrtBegin = append(depPaths.CrtBegin, linkFile.Path())
 			case CrtEndDepTag:
@@ -4141,6 +4141,56 @@ func TestFuzzTarget(t *testing.T) {
 	ctx.ModuleForTests("fuzz_smoke_test", variant).Rule("cc")
 }
 
+func TestFuzzDeviceArtifact(t *testing.T) {
+	t.Parallel()
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureRegisterWithContext(func(ctx android.RegistrationContext) {
+			ctx.RegisterSingletonType("cc_fuzz_packaging", fuzzPackagingFactory)
+		}),
+		android.MockFS{
+			"corpus/seed": nil,
+			"fuzz.dict":   nil,
+		}.AddToFixture(),
+	).RunTestWithBp(t, `
+		cc_fuzz {
+			name: "fuzz_foo",
+			srcs: ["foo.c"],
+			corpus: ["corpus/seed"],
+			dictionary: "fuzz.dict",
+			shared_libs: ["libfoo"],
+		}
+		cc_library {
+			name: "libfoo",
+			srcs: ["foo.c"],
+		}`)
+
+	singleton := result.SingletonForTests("cc_fuzz_packaging")
+	metadata := android.ContentFromFileRuleForTests(t, singleton.Output("fuzz/fuzz_targets.json"))
+	for _, expected := range []string{
+		`"name": "fuzz_foo"`,
+		`"arch": "arm64"`,
+		`"framework": "libfuzzer"`,
+		`"binary": "arm64/fuzz_foo/fuzz_foo"`,
+		`"lib_dir": "arm64/lib"`,
+		`"corpus": "arm64/fuzz_foo/fuzz_foo_seed_corpus.zip"`,
+		`"dictionary": "arm64/fuzz_foo/fuzz.dict"`,
+	} {
+		android.AssertStringDoesContain(t, "fuzz target metadata", metadata, expected)
+	}
+
+	artifact := singleton.Output("fuzz-artifacts-test_device.zip")
+	command := android.StringRelativeToTop(result.Config, artifact.RuleParams.Command)
+	for _, expected := range []string{
+		"-P arm64/fuzz_foo -f out/soong/.intermediates/fuzz_foo/android_arm64_armv8-a_fuzzer/unstripped/fuzz_foo",
+		"-P arm64/fuzz_foo -f out/soong/.intermediates/fuzz/target/arm64/fuzz_foo_seed_corpus.zip",
+		"-P arm64/fuzz_foo -f fuzz.dict",
+		"-P arm64/lib -f out/soong/.intermediates/libfoo/android_arm64_armv8-a_shared_fuzzer/unstripped/libfoo.so",
+	} {
+		android.AssertStringDoesContain(t, "fuzz artifact command", command, expected)
+	}
+}
+
 func assertString(t *testing.T, got, expected string) {
 	t.Helper()
 	if got != expected {
@@ -5033,6 +5083,35 @@ func TestAddnoOverride64GlobalCflags(t *testing.T) {
 	}
 }
 
+func TestSharedFlagGroups(t *testing.T) {
+	t.Parallel()
+	ctx := testCc(t, `
+		cc_library_static {
+			name: "libfoo",
+			srcs: ["foo.c", "bar.cpp"],
+			local_include_dirs: ["include/with/a/path/long/enough/to/be/shared"],
+		}`)
+
+	libfoo := ctx.ModuleForTests("libfoo", "android_arm64_armv8-a_static")
+	includeFlag := "-Iinclude/with/a/path/long/enough/to/be/shared"
+
+	// The C and C++ flags of libfoo both reference the module variable of its local common flags,
+	// which is the only one with its include flag.
+	var commonFlags []string
+	for name, value := range libfoo.Module().VariablesForTests() {
+		if strings.Contains(value, includeFlag) {
+			commonFlags = append(commonFlags, name)
+		}
+	}
+	if len(commonFlags) != 1 || !strings.HasPrefix(commonFlags[0], "commonFlags") {
+		t.Fatalf("expected %q in a single commonFlags variable, got it in %q", includeFlag, commonFlags)
+	}
+	for _, desc := range []string{"foo.c", "bar.cpp"} {
+		cFlags := libfoo.Description(desc).Args["cFlags"]
+		android.AssertStringDoesContain(t, "cFlags of "+desc, cFlags, includeFlag)
+	}
+}
+
 func TestCcBuildBrokenClangProperty(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -108,6 +108,31 @@ func CheckBadLinkerFlags(ctx BaseModuleContext, prop string, flags []string) {
 	}
 }
 
+// Check for restricted c/cpp/conly/ldflags that the module's directory isn't allowed to use, see
+// config.RestrictedFlag. Only use this for flags explicitly passed by the user, since these flags
+// may be used internally.
+func CheckRestrictedFlags(ctx ModuleContext, prop string, flags []string, restricted []config.RestrictedFlag) {
+	for _, flag := range flags {
+		flag = strings.TrimSpace(flag)
+		for _, r := range restricted {
+			if !r.Matches(flag) || r.AllowedIn(ctx.ModuleDir(), ctx.Config().RestrictedFlagsAllowedPaths(r.Flag)) {
+				continue
+			}
+			addToModuleList(ctx, modulesUsingRestrictedFlagsKey,
+				ctx.ModuleDir()+"/Android.bp:"+ctx.ModuleName()+":"+prop+":"+flag)
+			if ctx.Config().EnforceRestrictedFlags() {
+				allowed := "no directory"
+				if len(r.AllowedPaths) > 0 {
+					allowed = strings.Join(r.AllowedPaths, ", ")
+				}
+				ctx.PropertyErrorf(prop, "Restricted flag `%s` %s and is only allowed in %s. "+
+					"Add the directory to RestrictedFlagsAllowedPaths in the product config to allow it",
+					flag, r.Reason, allowed)
+			}
+		}
+	}
+}
+
 // Check for bad host_ldlibs
 func CheckBadHostLdlibs(ctx ModuleContext, prop string, flags []string) {
 	allowedLdlibs := ctx.toolchain().AvailableLibraries()
@@ -0,0 +1,69 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"path/filepath"
+
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+)
+
+// This file implements the loading of the prebuilt clang plugins of the product, e.g. vendor
+// security checkers adding custom diagnostics, into the compiles of the C and C++ sources of the
+// native modules in their Paths. The plugins are checked into the source tree, so the compiles
+// depend on them, and each of them lists the versions of the prebuilt clang it is built against,
+// as clang plugins only load into the clang they are built against. This replaces passing
+// "-Xclang -load" flags through the environment, which Soong doesn't track.
+
+// clangPluginFlags adds the flags loading the clang plugins of the product that apply to the
+// module to the flags of its compiles. Modules compiled with SDClang don't load them.
+func clangPluginFlags(ctx ModuleContext, flags Flags) Flags {
+	plugins := ctx.Config().ClangPlugins()
+	if len(plugins) == 0 || flags.Sdclang {
+		return flags
+	}
+
+	subdir := ctx.ModuleDir() + "/"
+	clangVersion := pchToolchain(ctx, flags)
+	for _, plugin := range plugins {
+		included := longestMatchingDir(subdir, plugin.Paths)
+		if included < 0 || included <= longestMatchingDir(subdir, plugin.ExcludePaths) {
+			continue
+		}
+		if plugin.Name == "" || plugin.Path == "" || filepath.IsAbs(plugin.Path) {
+			ctx.ModuleErrorf("clang plugin %q must have a name and a path relative to the top of the source tree, got %q",
+				plugin.Name, plugin.Path)
+			continue
+		}
+		if !inList(clangVersion, plugin.ClangVersions) {
+			ctx.ModuleErrorf("clang plugin %q is built against clang %q, but this module is compiled with %s",
+				plugin.Name, plugin.ClangVersions, clangVersion)
+			continue
+		}
+
+		path := android.PathForSource(ctx, plugin.Path)
+		flags.CFlagsDeps = append(flags.CFlagsDeps, path)
+		flags.ClangPluginFlags = append(flags.ClangPluginFlags,
+			"-Xclang -load -Xclang "+path.String(),
+			"-Xclang -add-plugin -Xclang "+plugin.Name)
+		for _, arg := range plugin.Args {
+			flags.ClangPluginFlags = append(flags.ClangPluginFlags,
+				"-Xclang -plugin-arg-"+plugin.Name+" -Xclang "+proptools.ShellEscape(arg))
+		}
+	}
+	return flags
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"testing"
+
+	"android/soong/android"
+	"android/soong/cc/config"
+)
+
+var prepareForTestWithClangPlugin = android.GroupFixturePreparers(
+	prepareForCcTest,
+	android.FixtureAddTextFile("vendor/foo/Android.bp", `
+		cc_library {
+			name: "libfoo",
+			srcs: ["foo.c"],
+		}
+
+		cc_library {
+			name: "libasm",
+			srcs: ["asm.S"],
+		}
+	`),
+	android.FixtureAddTextFile("vendor/foo/legacy/Android.bp", `
+		cc_library {
+			name: "liblegacy",
+			srcs: ["foo.c"],
+		}
+	`),
+	android.MockFS{
+		"prebuilts/checker/checker.so": nil,
+		"vendor/foo/foo.c":             nil,
+		"vendor/foo/asm.S":             nil,
+		"vendor/foo/legacy/foo.c":      nil,
+	}.AddToFixture(),
+)
+
+func clangPluginProductVariables(clangVersions ...string) android.FixturePreparer {
+	return android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+		variables.ClangPlugins = []android.ClangPlugin{{
+			Name:          "checker",
+			Path:          "prebuilts/checker/checker.so",
+			ClangVersions: clangVersions,
+			Args:          []string{"strict"},
+			Paths:         []string{"vendor/foo"},
+			ExcludePaths:  []string{"vendor/foo/legacy"},
+		}}
+	})
+}
+
+func TestClangPlugin(t *testing.T) {
+	t.Parallel()
+	result := android.GroupFixturePreparers(
+		prepareForTestWithClangPlugin,
+		clangPluginProductVariables(config.ClangDefaultVersion),
+	).RunTest(t)
+
+	libfoo := result.ModuleForTests("libfoo", "android_arm64_armv8-a_shared")
+	obj := libfoo.Output("obj/foo.o")
+	android.AssertStringDoesContain(t, "libfoo cflags", obj.Args["cFlags"],
+		"-Xclang -load -Xclang prebuilts/checker/checker.so -Xclang -add-plugin -Xclang checker -Xclang -plugin-arg-checker -Xclang strict")
+	android.AssertPathsRelativeToTopEquals(t, "libfoo implicits",
+		[]string{"prebuilts/checker/checker.so"}, obj.Implicits)
+
+	asm := result.ModuleForTests("libasm", "android_arm64_armv8-a_shared").Output("obj/asm.o")
+	android.AssertStringDoesNotContain(t, "libasm asflags", asm.Args["cFlags"], "-add-plugin")
+
+	legacyObj := result.ModuleForTests("liblegacy", "android_arm64_armv8-a_shared").Output("obj/foo.o")
+	android.AssertStringDoesNotContain(t, "liblegacy cflags", legacyObj.Args["cFlags"], "-add-plugin")
+}
+
+func TestClangPluginIncompatibleClangVersion(t *testing.T) {
+	t.Parallel()
+	android.GroupFixturePreparers(
+		prepareForTestWithClangPlugin,
+		clangPluginProductVariables(config.ClangDefaultVersion),
+		android.FixtureMergeEnv(map[string]string{
+			"LLVM_PREBUILTS_VERSION": "clang-r498229",
+		}),
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`clang plugin "checker" is built against clang \["` + config.ClangDefaultVersion + `"\], but this module is compiled with clang-r498229`)).
+		RunTest(t)
+}
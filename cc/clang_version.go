@@ -0,0 +1,150 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"path"
+	"strings"
+
+	"android/soong/android"
+	"android/soong/cc/config"
+)
+
+// A module setting clang_version is built by another prebuilt clang, and its static libraries and
+// objects have to be built by the same clang, and linked with the compiler-rt runtime libraries of
+// that clang. The clang_version mutator creates a variant of these dependencies for each clang
+// version requested by their dependents, and a variant of the prebuilt compiler-rt libraries that
+// uses the libraries of that clang. The variants built for a dependent aren't installed, the
+// dependents link them statically. Shared libraries keep being built by the default clang, so the
+// modules setting clang_version can't link the shared compiler-rt runtimes.
+
+type clangVersionTransitionMutator struct{}
+
+var _ android.TransitionMutator = (*clangVersionTransitionMutator)(nil)
+
+func (t *clangVersionTransitionMutator) Split(ctx android.BaseModuleContext) []string {
+	if c, ok := ctx.Module().(*Module); ok {
+		return []string{c.requestedClangVersion()}
+	}
+	return []string{""}
+}
+
+func (t *clangVersionTransitionMutator) OutgoingTransition(ctx android.OutgoingTransitionContext, sourceVariation string) string {
+	return sourceVariation
+}
+
+func (t *clangVersionTransitionMutator) IncomingTransition(ctx android.IncomingTransitionContext, incomingVariation string) string {
+	c, ok := ctx.Module().(*Module)
+	if !ok {
+		return ""
+	}
+	if version := c.requestedClangVersion(); version != "" {
+		return version
+	}
+	if c.IsPrebuilt() {
+		// The prebuilts are only available for the clang that built them, except for the
+		// compiler-rt libraries that are checked in with each clang.
+		if isClangRuntimeLibrary(c) && c.static() {
+			return incomingVariation
+		}
+		return ""
+	}
+	if (c.CcLibraryInterface() && c.static()) || c.Object() {
+		return incomingVariation
+	}
+	return ""
+}
+
+func (t *clangVersionTransitionMutator) Mutate(ctx android.BottomUpMutatorContext, variation string) {
+	c, ok := ctx.Module().(*Module)
+	if !ok {
+		return
+	}
+	c.Properties.ClangVersion = variation
+	if variation == "" {
+		return
+	}
+	if variation != c.requestedClangVersion() {
+		c.HideFromMake()
+		c.SkipInstall()
+	}
+	if p, ok := c.linker.(*prebuiltLibraryLinker); ok && isClangRuntimeLibrary(c) {
+		p.properties.Srcs = clangRuntimeSrcsForVersion(ctx, p.properties.Srcs, variation)
+		p.libraryDecorator.StaticProperties.Static.Srcs = clangRuntimeSrcsForVersion(ctx,
+			p.libraryDecorator.StaticProperties.Static.Srcs, variation)
+	}
+	ctx.VisitDirectDeps(func(dep android.Module) {
+		if d, ok := dep.(*Module); ok && isClangRuntimeLibrary(d) && d.Properties.ClangVersion != variation {
+			ctx.ModuleErrorf("is built with %s but links %s, the compiler-rt runtime of the default clang",
+				variation, ctx.OtherModuleName(dep))
+		}
+	})
+}
+
+// requestedClangVersion returns the version of the prebuilt clang set in the clang_version
+// property of the module, or "" for the default clang.
+func (c *Module) requestedClangVersion() string {
+	if compiler, ok := c.compiler.(interface {
+		clangVersion() string
+	}); ok {
+		return compiler.clangVersion()
+	}
+	return ""
+}
+
+// isClangRuntimeLibrary returns true if the module is one of the compiler-rt libraries checked in
+// with the prebuilt clang, e.g. libclang_rt.builtins.
+func isClangRuntimeLibrary(c *Module) bool {
+	return c.IsPrebuilt() && strings.HasPrefix(android.RemoveOptionalPrebuiltPrefix(c.Name()), "libclang_rt.")
+}
+
+// clangRuntimeSrcsForVersion returns the paths of the compiler-rt libraries of the prebuilt clang
+// version that replace the ones of the default clang in srcs, e.g.
+// clang-r487747c/lib/clang/17/lib/linux/libclang_rt.builtins-aarch64-android.a.
+func clangRuntimeSrcsForVersion(ctx android.BottomUpMutatorContext, srcs []string, version string) []string {
+	defaultVersion := ctx.Config().GetenvWithDefault("LLVM_PREBUILTS_VERSION", config.ClangDefaultVersion)
+	defaultShortVersion := ctx.Config().GetenvWithDefault("LLVM_RELEASE_VERSION", config.ClangDefaultShortVersion)
+
+	var ret []string
+	for _, src := range srcs {
+		if src == "" {
+			ret = append(ret, src)
+			continue
+		}
+		versionSrc, ok := clangRuntimeSrcForVersion(src, defaultVersion, defaultShortVersion,
+			version, config.ClangAllowedVersions[version])
+		if !ok {
+			ctx.PropertyErrorf("srcs", "%q is not in the prebuilt of the default clang %s, can't find it in %s",
+				src, defaultVersion, version)
+			continue
+		}
+		ret = append(ret, versionSrc)
+	}
+	return ret
+}
+
+// clangRuntimeSrcForVersion rewrites a path in the prebuilt of the default clang to the same path
+// in the prebuilt of another version, including the resource directory lib/clang/<short version>.
+func clangRuntimeSrcForVersion(src, defaultVersion, defaultShortVersion, version, shortVersion string) (string, bool) {
+	rel := strings.TrimPrefix(path.Clean(src), defaultVersion+"/")
+	if rel == path.Clean(src) {
+		return "", false
+	}
+	defaultResourceDir := path.Join("lib", "clang", defaultShortVersion) + "/"
+	if strings.HasPrefix(rel, defaultResourceDir) {
+		rel = path.Join("lib", "clang", shortVersion, strings.TrimPrefix(rel, defaultResourceDir))
+	}
+	return path.Join(version, rel), true
+}
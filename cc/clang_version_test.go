@@ -0,0 +1,163 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+var prepareForClangVersionTest = android.FixtureAddFile("prebuilts/clang/host/linux-x86/clang-r498229/bin/clang", nil)
+
+func TestClangVersion(t *testing.T) {
+	t.Parallel()
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		prepareForClangVersionTest,
+	).RunTestWithBp(t, `
+		cc_library {
+			name: "libnew",
+			srcs: ["foo.c"],
+			static_libs: ["libstatic"],
+			shared_libs: ["libshared"],
+			clang_version: "clang-r498229",
+		}
+		cc_library {
+			name: "libdefault",
+			srcs: ["foo.c"],
+			static_libs: ["libstatic"],
+		}
+		cc_library_static {
+			name: "libstatic",
+			srcs: ["foo.c"],
+		}
+		cc_library_shared {
+			name: "libshared",
+			srcs: ["foo.c"],
+		}
+	`)
+	ctx := result.TestContext
+
+	newBin := "${config.ClangBase}/${config.HostPrebuiltTag}/clang-r498229/bin"
+	libnew := result.ModuleForTests("libnew", "android_arm64_armv8-a_shared_clang-r498229")
+	android.AssertStringEquals(t, "libnew ccCmd", newBin+"/clang", libnew.Rule("cc").Args["ccCmd"])
+	android.AssertStringEquals(t, "libnew ldCmd", newBin+"/clang++", libnew.Rule("ld").Args["ldCmd"])
+	android.AssertStringDoesContain(t, "libnew cFlags", libnew.Rule("cc").Args["cFlags"],
+		"${config.ClangVersionExtraCflags}")
+
+	// The static libraries are built with the same clang, the shared libraries with the default one.
+	libstaticNew := result.ModuleForTests("libstatic", "android_arm64_armv8-a_static_clang-r498229")
+	android.AssertStringEquals(t, "libstatic ccCmd for libnew", newBin+"/clang", libstaticNew.Rule("cc").Args["ccCmd"])
+	android.AssertStringEquals(t, "libstatic arCmd for libnew", newBin+"/llvm-ar", libstaticNew.Rule("ar").Args["arCmd"])
+	expectStaticLinkDep(t, ctx, libnew, libstaticNew)
+
+	libshared := result.ModuleForTests("libshared", "android_arm64_armv8-a_shared")
+	android.AssertStringEquals(t, "libshared ccCmd", "${config.ClangBin}/clang", libshared.Rule("cc").Args["ccCmd"])
+	expectSharedLinkDep(t, ctx, libnew, libshared)
+
+	// libnew links the compiler-rt libraries of its clang.
+	result.ModuleForTests("libclang_rt.builtins", "android_arm64_armv8-a_static_clang-r498229")
+
+	libdefault := result.ModuleForTests("libdefault", "android_arm64_armv8-a_shared")
+	android.AssertStringEquals(t, "libdefault ccCmd", "${config.ClangBin}/clang", libdefault.Rule("cc").Args["ccCmd"])
+	android.AssertStringEquals(t, "libdefault ldCmd", "${config.ClangBin}/clang++", libdefault.Rule("ld").Args["ldCmd"])
+	android.AssertStringDoesNotContain(t, "libdefault cFlags", libdefault.Rule("cc").Args["cFlags"],
+		"${config.ClangVersionExtraCflags}")
+
+	libstatic := result.ModuleForTests("libstatic", "android_arm64_armv8-a_static")
+	android.AssertStringEquals(t, "libstatic ccCmd", "${config.ClangBin}/clang", libstatic.Rule("cc").Args["ccCmd"])
+	android.AssertStringEquals(t, "libstatic arCmd", "${config.ClangBin}/llvm-ar", libstatic.Rule("ar").Args["arCmd"])
+	expectStaticLinkDep(t, ctx, libdefault, libstatic)
+}
+
+func TestClangVersionNotAllowed(t *testing.T) {
+	t.Parallel()
+	android.GroupFixturePreparers(
+		prepareForCcTest,
+		prepareForClangVersionTest,
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`clang_version: "clang-r1" is not one of the allowed clang versions`)).RunTestWithBp(t, `
+		cc_library {
+			name: "libnew",
+			srcs: ["foo.c"],
+			clang_version: "clang-r1",
+		}
+	`)
+}
+
+func TestClangVersionNotCheckedIn(t *testing.T) {
+	t.Parallel()
+	prepareForCcTest.ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`clang_version: the prebuilt of clang-r498229 is not checked in`)).RunTestWithBp(t, `
+		cc_library {
+			name: "libnew",
+			srcs: ["foo.c"],
+			clang_version: "clang-r498229",
+		}
+	`)
+}
+
+func TestClangVersionSharedRuntime(t *testing.T) {
+	t.Parallel()
+	android.GroupFixturePreparers(
+		prepareForCcTest,
+		prepareForClangVersionTest,
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`is built with clang-r498229 but links libclang_rt.hwasan, the compiler-rt runtime of the default clang`)).
+		RunTestWithBp(t, `
+		cc_library {
+			name: "libnew",
+			srcs: ["foo.c"],
+			shared_libs: ["libclang_rt.hwasan"],
+			clang_version: "clang-r498229",
+		}
+	`)
+}
+
+func TestClangRuntimeSrcForVersion(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name string
+		src  string
+		want string
+		ok   bool
+	}{
+		{
+			name: "resource directory",
+			src:  "clang-r487747c/lib/clang/17/lib/linux/libclang_rt.builtins-aarch64-android.a",
+			want: "clang-r498229/lib/clang/18/lib/linux/libclang_rt.builtins-aarch64-android.a",
+			ok:   true,
+		},
+		{
+			name: "other directory",
+			src:  "clang-r487747c/musl/lib/x86_64-unknown-linux-musl/libc++.a",
+			want: "clang-r498229/musl/lib/x86_64-unknown-linux-musl/libc++.a",
+			ok:   true,
+		},
+		{
+			name: "not in the default clang",
+			src:  "libclang_rt.ubsan_minimal.android_arm64.a",
+			ok:   false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := clangRuntimeSrcForVersion(tc.src, "clang-r487747c", "17", "clang-r498229", "18")
+			android.AssertBoolEquals(t, "ok", tc.ok, ok)
+			android.AssertStringEquals(t, "src", tc.want, got)
+		})
+	}
+}
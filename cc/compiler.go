@@ -212,6 +212,23 @@ type BaseCompilerProperties struct {
 
 	// Build and link with OpenMP
 	Openmp *bool `android:"arch_variant"`
+
+	// Compile with the Snapdragon LLVM toolchain set by the SdclangPath product variable instead
+	// of the platform clang. Defaults to true for the modules in the SdclangPaths of the product
+	// that aren't in its SdclangExcludePaths. Has no effect on host modules or when the product
+	// doesn't set SdclangPath.
+	Sdclang *bool
+
+	// Compile and link with this version of the prebuilt clang instead of the default one, e.g.
+	// "clang-r498229". It must be one of the versions listed in ClangAllowedVersions whose prebuilt
+	// is checked into prebuilts/clang/host, and can't be combined with sdclang. The static
+	// libraries of the module are built with the same clang, and it is linked with the compiler-rt
+	// libraries of that clang.
+	Clang_version *string
+
+	// Name of a cc_pch module whose precompiled header the C++ sources of this module include
+	// implicitly. The module must be compiled with the same flags as the cc_pch module.
+	Precompiled_header *string
 }
 
 func NewBaseCompiler() *baseCompiler {
@@ -258,6 +275,10 @@ func (compiler *baseCompiler) compilerProps() []interface{} {
 	return []interface{}{&compiler.Properties, &compiler.Proto}
 }
 
+func (compiler *baseCompiler) clangVersion() string {
+	return String(compiler.Properties.Clang_version)
+}
+
 func includeBuildDirectory(prop *bool) bool {
 	return proptools.BoolDefault(prop, true)
 }
@@ -282,13 +303,47 @@ func (compiler *baseCompiler) compilerDeps(ctx DepsContext, deps Deps) Deps {
 		deps.StaticLibs = append(deps.StaticLibs, "libomp")
 	}
 
+	deps.PrecompiledHeader = String(compiler.Properties.Precompiled_header)
+
 	return deps
 }
 
-// Return true if the module is in the WarningAllowedProjects.
-func warningsAreAllowed(subdir string) bool {
+// Return true if the module is in the WarningAllowedProjects or the WarningsAllowedPaths of the
+// product, and not in a deeper directory of its WarningsAsErrorsPaths.
+func warningsAreAllowed(cfg android.Config, subdir string) bool {
 	subdir += "/"
-	return android.HasAnyPrefix(subdir, config.WarningAllowedProjects)
+	allowed := longestMatchingDir(subdir, config.WarningAllowedProjects)
+	if l := longestMatchingDir(subdir, cfg.WarningsAllowedPaths()); l > allowed {
+		allowed = l
+	}
+	return allowed >= 0 && allowed > longestMatchingDir(subdir, cfg.WarningsAsErrorsPaths())
+}
+
+// useSdclang returns true if the module is compiled with the Snapdragon LLVM toolchain, from its
+// sdclang property or else from the SdclangPaths and SdclangExcludePaths of the product.
+func useSdclang(ctx ModuleContext, sdclang *bool) bool {
+	if ctx.Config().SdclangPath() == "" || !ctx.Device() {
+		return false
+	}
+	if sdclang != nil {
+		return *sdclang
+	}
+	subdir := ctx.ModuleDir() + "/"
+	included := longestMatchingDir(subdir, ctx.Config().SdclangPaths())
+	return included >= 0 && included > longestMatchingDir(subdir, ctx.Config().SdclangExcludePaths())
+}
+
+// longestMatchingDir returns the length of the longest of dirs that subdir, which ends in a "/",
+// is in, or -1 if there are none.
+func longestMatchingDir(subdir string, dirs []string) int {
+	longest := -1
+	for _, dir := range dirs {
+		dir = strings.TrimSuffix(dir, "/") + "/"
+		if strings.HasPrefix(subdir, dir) && len(dir) > longest {
+			longest = len(dir)
+		}
+	}
+	return longest
 }
 
 func addToModuleList(ctx ModuleContext, key android.OnceKey, module string) {
@@ -347,6 +402,26 @@ func (compiler *baseCompiler) compilerFlags(ctx ModuleContext, flags Flags, deps
 		flags.Local.CommonFlags = append(flags.Local.CommonFlags, "-I" + additionalIncludeDirs)
 	}
 
+	flags.Sdclang = useSdclang(ctx, compiler.Properties.Sdclang)
+	if version := compiler.clangVersion(); version != "" {
+		if _, ok := config.ClangAllowedVersions[version]; !ok {
+			ctx.PropertyErrorf("clang_version", "%q is not one of the allowed clang versions %q",
+				version, android.SortedKeys(config.ClangAllowedVersions))
+		} else if !config.ClangForVersion(ctx, version).Valid() {
+			ctx.PropertyErrorf("clang_version", "the prebuilt of %s is not checked in", version)
+		} else if Bool(compiler.Properties.Sdclang) {
+			ctx.PropertyErrorf("clang_version", "cannot be set together with sdclang")
+		}
+	}
+	if flags.ClangVersion != "" {
+		// Set by the clang_version mutator, for this module or a module that links it.
+		flags.Sdclang = false
+	}
+
+	if deps.PrecompiledHeader != nil {
+		flags = addPrecompiledHeaderFlags(ctx, flags, deps.PrecompiledHeader)
+	}
+
 	compiler.srcsBeforeGen = android.PathsForModuleSrcExcludes(ctx, compiler.Properties.Srcs, compiler.Properties.Exclude_srcs)
 	compiler.srcsBeforeGen = append(compiler.srcsBeforeGen, deps.GeneratedSources...)
 
@@ -359,6 +434,11 @@ func (compiler *baseCompiler) compilerFlags(ctx ModuleContext, flags Flags, deps
 	CheckBadCompilerFlags(ctx, "recovery.cflags", compiler.Properties.Target.Recovery.Cflags)
 	CheckBadCompilerFlags(ctx, "vendor_ramdisk.cflags", compiler.Properties.Target.Vendor_ramdisk.Cflags)
 	CheckBadCompilerFlags(ctx, "platform.cflags", compiler.Properties.Target.Platform.Cflags)
+	CheckRestrictedFlags(ctx, "cflags", compiler.Properties.Cflags, config.RestrictedCflags)
+	CheckRestrictedFlags(ctx, "cppflags", compiler.Properties.Cppflags, config.RestrictedCflags)
+	CheckRestrictedFlags(ctx, "conlyflags", compiler.Properties.Conlyflags, config.RestrictedCflags)
+	CheckRestrictedFlags(ctx, "vendor.cflags", compiler.Properties.Target.Vendor.Cflags, config.RestrictedCflags)
+	CheckRestrictedFlags(ctx, "product.cflags", compiler.Properties.Target.Product.Cflags, config.RestrictedCflags)
 
 	esc := proptools.NinjaAndShellEscapeList
 
@@ -610,7 +690,7 @@ func (compiler *baseCompiler) compilerFlags(ctx ModuleContext, flags Flags, deps
 		if inList("-Wno-error", flags.Local.CFlags) || inList("-Wno-error", flags.Local.CppFlags) {
 			addToModuleList(ctx, modulesUsingWnoErrorKey, module)
 		} else if !inList("-Werror", flags.Local.CFlags) && !inList("-Werror", flags.Local.CppFlags) {
-			if warningsAreAllowed(ctx.ModuleDir()) {
+			if warningsAreAllowed(ctx.Config(), ctx.ModuleDir()) {
 				addToModuleList(ctx, modulesWarningsAllowedKey, module)
 			} else {
 				flags.Local.CFlags = append([]string{"-Werror"}, flags.Local.CFlags...)
@@ -17,6 +17,8 @@ package cc
 import (
 	"testing"
 
+	"github.com/google/blueprint/proptools"
+
 	"android/soong/android"
 )
 
@@ -44,3 +46,78 @@ func TestIsThirdParty(t *testing.T) {
 		}
 	}
 }
+
+func TestWarningsAreAllowed(t *testing.T) {
+	config := android.TestConfig(t.TempDir(), nil, "", nil)
+	config.TestProductVariables.WarningsAllowedPaths = []string{"hardware/acme"}
+	config.TestProductVariables.WarningsAsErrorsPaths = []string{"vendor/acme/", "hardware/acme/clean"}
+
+	testCases := map[string]bool{
+		"vendor/other":             true,
+		"vendor/acme":              false,
+		"vendor/acme/lib":          false,
+		"vendor/acme_other":        true,
+		"hardware/acme":            true,
+		"hardware/acme/clean":      false,
+		"hardware/acme/clean/test": false,
+		"hardware/other":           false,
+		"external/foo":             false,
+	}
+	for dir, expected := range testCases {
+		android.AssertBoolEquals(t, dir, expected, warningsAreAllowed(config, dir))
+	}
+}
+
+func TestSdclang(t *testing.T) {
+	t.Parallel()
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.SdclangPath = proptools.StringPtr("vendor/qcom/sdclang/bin")
+			variables.SdclangPaths = []string{"vendor/qcom"}
+			variables.SdclangExcludePaths = []string{"vendor/qcom/legacy"}
+		}),
+		android.FixtureAddTextFile("vendor/qcom/Android.bp", `
+			cc_library {
+				name: "libqcom",
+				srcs: ["foo.c"],
+			}
+			cc_library {
+				name: "libqcom_optout",
+				srcs: ["foo.c"],
+				sdclang: false,
+			}
+		`),
+		android.FixtureAddTextFile("vendor/qcom/legacy/Android.bp", `
+			cc_library {
+				name: "liblegacy",
+				srcs: ["foo.c"],
+			}
+		`),
+		android.FixtureAddTextFile("external/foo/Android.bp", `
+			cc_library {
+				name: "libfoo",
+				srcs: ["foo.c"],
+				sdclang: true,
+				host_supported: true,
+			}
+		`),
+		android.MockFS{
+			"vendor/qcom/foo.c":        nil,
+			"vendor/qcom/legacy/foo.c": nil,
+			"external/foo/foo.c":       nil,
+		}.AddToFixture(),
+	).RunTest(t)
+
+	ccCmd := func(name, variant string) string {
+		return result.ModuleForTests(name, variant).Rule("cc").Args["ccCmd"]
+	}
+	device := "android_arm64_armv8-a_static"
+	host := result.Config.BuildOSTarget.String() + "_static"
+
+	android.AssertStringEquals(t, "libqcom", "${config.SdclangBin}/clang", ccCmd("libqcom", device))
+	android.AssertStringEquals(t, "libqcom_optout", "${config.ClangBin}/clang", ccCmd("libqcom_optout", device))
+	android.AssertStringEquals(t, "liblegacy", "${config.ClangBin}/clang", ccCmd("liblegacy", device))
+	android.AssertStringEquals(t, "libfoo", "${config.SdclangBin}/clang", ccCmd("libfoo", device))
+	android.AssertStringEquals(t, "libfoo host", "${config.ClangBin}/clang", ccCmd("libfoo", host))
+}
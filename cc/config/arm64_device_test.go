@@ -0,0 +1,83 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestArm64ArchVariantCflags(t *testing.T) {
+	testCases := []struct {
+		archVariant      string
+		march            string
+		branchProtection bool
+	}{
+		{"armv8-a", "-march=armv8-a", false},
+		{"armv8-a-branchprot", "-march=armv8-a", true},
+		{"armv8-2a", "-march=armv8.2-a", false},
+		{"armv8-2a-dotprod", "-march=armv8.2-a+lse+fp16+dotprod", false},
+		{"armv9-a", "-march=armv8.2-a+dotprod", true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.archVariant, func(t *testing.T) {
+			cflags := arm64ArchVariantCflags[testCase.archVariant]
+			if !android.InList(testCase.march, cflags) {
+				t.Errorf("expected %q in %q", testCase.march, cflags)
+			}
+			if got := android.InList("-mbranch-protection=standard", cflags); got != testCase.branchProtection {
+				t.Errorf("expected branch protection %t, got %t in %q", testCase.branchProtection, got, cflags)
+			}
+
+			toolchain := arm64ToolchainFactory(android.Arch{
+				ArchType:    android.Arm64,
+				ArchVariant: testCase.archVariant,
+			})
+			expected := arm64ArchVariantCflagsVar[testCase.archVariant]
+			if !strings.Contains(toolchain.ToolchainCflags(), expected) {
+				t.Errorf("expected %q in toolchain cflags %q", expected, toolchain.ToolchainCflags())
+			}
+		})
+	}
+}
+
+func TestRegisterCpuVariant(t *testing.T) {
+	RegisterCpuVariant(android.Arm64, "test-kryo", CpuVariantFlags{
+		Cflags:  []string{"-mcpu=cortex-a76", "-mtune=cortex-a76"},
+		Ldflags: []string{"-Wl,--fix-cortex-a53-843419"},
+	})
+
+	toolchain := arm64ToolchainFactory(android.Arch{
+		ArchType:    android.Arm64,
+		ArchVariant: "armv8-2a",
+		CpuVariant:  "test-kryo",
+	})
+	if !strings.Contains(toolchain.ToolchainCflags(), "-mcpu=cortex-a76 -mtune=cortex-a76") {
+		t.Errorf("expected the cflags of test-kryo in toolchain cflags %q", toolchain.ToolchainCflags())
+	}
+	if !strings.Contains(toolchain.Lldflags(), "-Wl,--fix-cortex-a53-843419") {
+		t.Errorf("expected the ldflags of test-kryo in toolchain lldflags %q", toolchain.Lldflags())
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected registering test-kryo twice to panic")
+		}
+	}()
+	RegisterCpuVariant(android.Arm64, "test-kryo", CpuVariantFlags{})
+}
@@ -253,6 +253,8 @@ var (
 		"exynos-m1":      "${config.ArmCortexA53Cflags}",
 		"exynos-m2":      "${config.ArmCortexA53Cflags}",
 	}
+
+	armCpuVariantLdflags = map[string]string{}
 )
 
 type toolchainArm struct {
@@ -343,7 +345,7 @@ func armToolchainFactory(arch android.Arch) Toolchain {
 		panic(fmt.Sprintf("Unknown ARM architecture version: %q", arch.ArchVariant))
 	}
 
-	return &toolchainArm{
+	toolchain := &toolchainArm{
 		ldflags: strings.Join([]string{
 			"${config.ArmLdflags}",
 			fixCortexA8,
@@ -351,6 +353,11 @@ func armToolchainFactory(arch android.Arch) Toolchain {
 		lldflags:        "${config.ArmLldflags}",
 		toolchainCflags: strings.Join(toolchainCflags, " "),
 	}
+	if extraLdflags, ok := armCpuVariantLdflags[arch.CpuVariant]; ok {
+		toolchain.ldflags += " " + extraLdflags
+		toolchain.lldflags += " " + extraLdflags
+	}
+	return toolchain
 }
 
 func init() {
@@ -21,7 +21,11 @@ var (
 		"-march=armv7a",
 	}
 
-	linuxArm64Cflags = []string{}
+	linuxArm64Cflags = []string{
+		// Target the baseline ISA so that the host tools cross-compiled for arm64 run on any
+		// arm64 build server.
+		"-march=armv8-a",
+	}
 
 	linuxArmLdflags = []string{
 		"-march=armv7a",
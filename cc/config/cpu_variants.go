@@ -0,0 +1,53 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"android/soong/android"
+)
+
+// CpuVariantFlags are the flags of the toolchain of a cpu variant.
+type CpuVariantFlags struct {
+	// Compiler flags, typically the -mcpu or -mtune flags of the core.
+	Cflags []string
+
+	// Linker flags, e.g. the workarounds for errata of the core.
+	Ldflags []string
+}
+
+// RegisterCpuVariant adds a cpu variant of arm or arm64 with the flags of its toolchain, so that
+// SoC vendors can support their cores, like Kryo or Exynos ones, from a Go package of their own
+// tree instead of the tables of this package. Like android.RegisterCpuVariant, which it calls, it
+// must be called from the init function of a Go package.
+func RegisterCpuVariant(archType android.ArchType, variant string, flags CpuVariantFlags) {
+	var cflagsVars, ldflagsVars map[string]string
+	switch archType {
+	case android.Arm:
+		cflagsVars, ldflagsVars = armCpuVariantCflagsVar, armCpuVariantLdflags
+	case android.Arm64:
+		cflagsVars, ldflagsVars = arm64CpuVariantCflagsVar, arm64CpuVariantLdflags
+	default:
+		panic(fmt.Errorf("cpu variants of %q are not supported", archType))
+	}
+
+	android.RegisterCpuVariant(archType, variant)
+	cflagsVars[variant] = strings.Join(flags.Cflags, " ")
+	if len(flags.Ldflags) > 0 {
+		ldflagsVars[variant] = strings.Join(flags.Ldflags, " ")
+	}
+}
@@ -15,9 +15,12 @@
 package config
 
 import (
+	"path/filepath"
 	"runtime"
 	"strings"
 
+	"github.com/google/blueprint/proptools"
+
 	"android/soong/android"
 	"android/soong/remoteexec"
 )
@@ -299,6 +302,34 @@ var (
 		"-w",
 	}
 
+	// Compiler flags that change the ABI, which products can't add to TargetGlobalExtraCflags as
+	// the prebuilts and the libraries of the other partitions are built without them. The flags
+	// that end with "=" match all their values.
+	abiChangingCflags = []string{
+		"-fshort-enums",
+		"-fshort-wchar",
+		"-fpack-struct",
+		"-fpack-struct=",
+		"-fsigned-char",
+		"-funsigned-char",
+		"-fpcc-struct-return",
+		"-freg-struct-return",
+		"-malign-double",
+		"-mabi=",
+		"-mfloat-abi=",
+		"-m32",
+		"-m64",
+		"-mx32",
+		"-D_FILE_OFFSET_BITS=",
+	}
+
+	// Linker flags that change the ABI, which products can't add to TargetGlobalExtraLdflags.
+	abiChangingLdflags = []string{
+		"-Wl,--hash-style=",
+		"-Wl,-z,max-page-size=",
+		"-Wl,--dynamic-linker=",
+	}
+
 	CStdVersion               = "gnu11"
 	CppStdVersion             = "gnu++17"
 	ExperimentalCStdVersion   = "gnu17"
@@ -309,6 +340,19 @@ var (
 	ClangDefaultVersion      = "clang-r487747c"
 	ClangDefaultShortVersion = "17"
 
+	// The prebuilt mold linker, in <MoldDefaultBase>/<host prebuilt tag>/bin/mold, that Linux
+	// host modules can be linked with instead of lld.
+	MoldDefaultBase = "prebuilts/mold"
+
+	// The versions of the prebuilt clang in ClangDefaultBase that modules can select with the
+	// clang_version property, to bring up a new toolchain on a subset of the tree, mapped to the
+	// short version of their resource directory, lib/clang/<short version>. The modules can only
+	// use the versions whose prebuilt is checked in, see ClangForVersion.
+	ClangAllowedVersions = map[string]string{
+		ClangDefaultVersion: ClangDefaultShortVersion,
+		"clang-r498229":     "17",
+	}
+
 	// Directories with warnings from Android.bp files.
 	WarningAllowedProjects = []string{
 		"device/",
@@ -336,11 +380,17 @@ func init() {
 		commonGlobalCflags = append(commonGlobalCflags, "-fdebug-prefix-map=/proc/self/cwd=")
 	}
 
+	// The products can't add these flags to the global flags of the device, Config.Validate
+	// rejects them.
+	android.BanGlobalExtraCflags(IllegalFlags...)
+	android.BanGlobalExtraCflags(abiChangingCflags...)
+	android.BanGlobalExtraCflags(restrictedFlagNames(RestrictedCflags)...)
+	android.BanGlobalExtraLdflags(abiChangingLdflags...)
+	android.BanGlobalExtraLdflags(restrictedFlagNames(RestrictedLdflags)...)
+
 	exportedVars.ExportStringListStaticVariable("CommonGlobalConlyflags", commonGlobalConlyflags)
 	exportedVars.ExportStringListStaticVariable("CommonGlobalAsflags", commonGlobalAsflags)
 	exportedVars.ExportStringListStaticVariable("DeviceGlobalCppflags", deviceGlobalCppflags)
-	exportedVars.ExportStringListStaticVariable("DeviceGlobalLdflags", deviceGlobalLdflags)
-	exportedVars.ExportStringListStaticVariable("DeviceGlobalLldflags", deviceGlobalLldflags)
 	exportedVars.ExportStringListStaticVariable("HostGlobalCppflags", hostGlobalCppflags)
 	exportedVars.ExportStringListStaticVariable("HostGlobalLdflags", hostGlobalLdflags)
 	exportedVars.ExportStringListStaticVariable("HostGlobalLldflags", hostGlobalLldflags)
@@ -383,7 +433,23 @@ func init() {
 	exportedVars.ExportStringList("DeviceGlobalCflags", deviceGlobalCflags)
 
 	pctx.VariableFunc("DeviceGlobalCflags", func(ctx android.PackageVarContext) string {
-		return strings.Join(deviceGlobalCflags, " ")
+		extraFlags := proptools.NinjaAndShellEscapeList(ctx.Config().TargetGlobalExtraCflags())
+		flags := append(append([]string(nil), deviceGlobalCflags...), ctx.Config().BuildTypeSettings().Cflags...)
+		return strings.Join(append(flags, extraFlags...), " ")
+	})
+
+	// Export the static default DeviceGlobalLdflags and DeviceGlobalLldflags to Bazel.
+	exportedVars.ExportStringList("DeviceGlobalLdflags", deviceGlobalLdflags)
+	exportedVars.ExportStringList("DeviceGlobalLldflags", deviceGlobalLldflags)
+
+	deviceGlobalExtraLdflags := func(ctx android.PackageVarContext) []string {
+		return proptools.NinjaAndShellEscapeList(ctx.Config().TargetGlobalExtraLdflags())
+	}
+	pctx.VariableFunc("DeviceGlobalLdflags", func(ctx android.PackageVarContext) string {
+		return strings.Join(append(append([]string(nil), deviceGlobalLdflags...), deviceGlobalExtraLdflags(ctx)...), " ")
+	})
+	pctx.VariableFunc("DeviceGlobalLldflags", func(ctx android.PackageVarContext) string {
+		return strings.Join(append(append([]string(nil), deviceGlobalLldflags...), deviceGlobalExtraLdflags(ctx)...), " ")
 	})
 
 	// Export the static default NoOverrideGlobalCflags to Bazel.
@@ -396,6 +462,10 @@ func init() {
 		return strings.Join(flags, " ")
 	})
 
+	// The modules built with a clang_version other than the default one can hit the new warnings
+	// of that clang, which the tree isn't clean of yet, so they don't fail the build.
+	pctx.StaticVariable("ClangVersionExtraCflags", strings.Join(llvmNextExtraCommonGlobalCflags, " "))
+
 	exportedVars.ExportStringListStaticVariable("NoOverride64GlobalCflags", noOverride64GlobalCflags)
 	exportedVars.ExportStringListStaticVariable("HostGlobalCflags", hostGlobalCflags)
 	exportedVars.ExportStringListStaticVariable("NoOverrideExternalGlobalCflags", noOverrideExternalGlobalCflags)
@@ -433,6 +503,12 @@ func init() {
 	pctx.StaticVariable("ClangPath", "${ClangBase}/${HostPrebuiltTag}/${ClangVersion}")
 	pctx.StaticVariable("ClangBin", "${ClangPath}/bin")
 
+	// The bin directory of the Snapdragon LLVM toolchain, used to compile the modules that opt
+	// into it with the sdclang property or the SdclangPaths product variable.
+	pctx.VariableFunc("SdclangBin", func(ctx android.PackageVarContext) string {
+		return ctx.Config().SdclangPath()
+	})
+
 	exportedVars.ExportStringStaticVariableWithEnvOverride("ClangShortVersion", "LLVM_RELEASE_VERSION", ClangDefaultShortVersion)
 	pctx.StaticVariable("ClangAsanLibDir", "${ClangBase}/linux-x86/${ClangVersion}/lib/clang/${ClangShortVersion}/lib/linux")
 
@@ -454,16 +530,75 @@ func init() {
 		if override := ctx.Config().Getenv("CC_WRAPPER"); override != "" {
 			return override + " "
 		}
-		return ""
+		return CompilerCacheWrapper(ctx.Config())
+	})
+	// The compiler cache wrapper alone, for the compilations that don't support CC_WRAPPER.
+	pctx.VariableFunc("CcCacheWrapper", func(ctx android.PackageVarContext) string {
+		if ctx.Config().Getenv("CC_WRAPPER") != "" {
+			return ""
+		}
+		return CompilerCacheWrapper(ctx.Config())
 	})
 
 	pctx.StaticVariableWithEnvOverride("RECXXPool", "RBE_CXX_POOL", remoteexec.DefaultPool)
 	pctx.StaticVariableWithEnvOverride("RECXXLinksPool", "RBE_CXX_LINKS_POOL", remoteexec.DefaultPool)
 	pctx.StaticVariableWithEnvOverride("REClangTidyPool", "RBE_CLANG_TIDY_POOL", remoteexec.DefaultPool)
-	pctx.StaticVariableWithEnvOverride("RECXXLinksExecStrategy", "RBE_CXX_LINKS_EXEC_STRATEGY", remoteexec.LocalExecStrategy)
-	pctx.StaticVariableWithEnvOverride("REClangTidyExecStrategy", "RBE_CLANG_TIDY_EXEC_STRATEGY", remoteexec.LocalExecStrategy)
-	pctx.StaticVariableWithEnvOverride("REAbiDumperExecStrategy", "RBE_ABI_DUMPER_EXEC_STRATEGY", remoteexec.LocalExecStrategy)
-	pctx.StaticVariableWithEnvOverride("REAbiLinkerExecStrategy", "RBE_ABI_LINKER_EXEC_STRATEGY", remoteexec.LocalExecStrategy)
+	pctx.StaticVariableWithEnvOverride("RECXXLinksExecStrategy", "RBE_CXX_LINKS_EXEC_STRATEGY", remoteexec.DefaultExecStrategy("RBE_CXX_LINKS_EXEC_STRATEGY"))
+	pctx.StaticVariableWithEnvOverride("REClangTidyExecStrategy", "RBE_CLANG_TIDY_EXEC_STRATEGY", remoteexec.DefaultExecStrategy("RBE_CLANG_TIDY_EXEC_STRATEGY"))
+	pctx.StaticVariableWithEnvOverride("REAbiDumperExecStrategy", "RBE_ABI_DUMPER_EXEC_STRATEGY", remoteexec.DefaultExecStrategy("RBE_ABI_DUMPER_EXEC_STRATEGY"))
+	pctx.StaticVariableWithEnvOverride("REAbiLinkerExecStrategy", "RBE_ABI_LINKER_EXEC_STRATEGY", remoteexec.DefaultExecStrategy("RBE_ABI_LINKER_EXEC_STRATEGY"))
+}
+
+func restrictedFlagNames(restricted []RestrictedFlag) []string {
+	var names []string
+	for _, r := range restricted {
+		names = append(names, r.Flag)
+	}
+	return names
+}
+
+// CompilerCacheWrapper returns the command prefix that runs a compiler through the compiler cache
+// selected by the product, or "" if there is none. The environment of the compiler cache is set
+// in the command itself so that it doesn't depend on the environment ninja runs in, from the
+// physical working directory as the compilers run with PWD=/proc/self/cwd. The compiler
+// is checked by content as the prebuilt compilers are in the source tree, where their timestamps
+// change with every checkout.
+func CompilerCacheWrapper(config android.Config) string {
+	cacheDir := config.CcCompilerCacheDir()
+	if cacheDir != "" && !filepath.IsAbs(cacheDir) {
+		cacheDir = "$$(pwd -P)/" + cacheDir
+	}
+	switch config.CcCompilerCache() {
+	case "ccache":
+		env := "CCACHE_BASEDIR=$$(pwd -P) CCACHE_COMPILERCHECK=content "
+		if cacheDir != "" {
+			env += "CCACHE_DIR=" + cacheDir + " "
+		}
+		return env + "ccache "
+	case "sccache":
+		env := ""
+		if cacheDir != "" {
+			env += "SCCACHE_DIR=" + cacheDir + " "
+		}
+		return env + "sccache "
+	}
+	return ""
+}
+
+// ClangBinForVersion returns the bin directory of the prebuilt clang version, one of
+// ClangAllowedVersions, as a ninja string.
+func ClangBinForVersion(version string) string {
+	return "${config.ClangBase}/${config.HostPrebuiltTag}/" + version + "/bin"
+}
+
+// ClangForVersion returns the path of the clang of the prebuilt clang version, or an invalid path
+// if the prebuilt isn't checked in for the host.
+func ClangForVersion(ctx android.PathGlobContext, version string) android.OptionalPath {
+	clangBase := ClangDefaultBase
+	if override := ctx.Config().Getenv("LLVM_PREBUILTS_BASE"); override != "" {
+		clangBase = override
+	}
+	return android.ExistentPathForSource(ctx, clangBase, ctx.Config().PrebuiltOS(), version, "bin", "clang")
 }
 
 var HostPrebuiltTag = exportedVars.ExportVariableConfigMethod("HostPrebuiltTag", android.Config.PrebuiltOS)
@@ -480,6 +615,12 @@ func ClangPath(ctx android.PathContext, file string) android.SourcePath {
 
 var clangPathKey = android.NewOnceKey("clangPath")
 
+// MoldPath returns the path of the prebuilt mold linker, or an invalid path if it isn't
+// available in the prebuilts of the host.
+func MoldPath(ctx android.PathGlobContext) android.OptionalPath {
+	return android.ExistentPathForSource(ctx, MoldDefaultBase, ctx.Config().PrebuiltOS(), "bin", "mold")
+}
+
 func clangPath(ctx android.PathContext) android.SourcePath {
 	return ctx.Config().OnceSourcePath(clangPathKey, func() android.SourcePath {
 		clangBase := ClangDefaultBase
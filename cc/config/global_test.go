@@ -0,0 +1,90 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+)
+
+func TestCompilerCacheWrapper(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cache    *string
+		cacheDir *string
+		useRBE   bool
+		expected string
+	}{
+		{
+			name:     "no compiler cache",
+			expected: "",
+		},
+		{
+			name:     "ccache",
+			cache:    proptools.StringPtr("ccache"),
+			expected: "CCACHE_BASEDIR=$$(pwd -P) CCACHE_COMPILERCHECK=content ccache ",
+		},
+		{
+			name:     "ccache with relative dir",
+			cache:    proptools.StringPtr("ccache"),
+			cacheDir: proptools.StringPtr("out/ccache"),
+			expected: "CCACHE_BASEDIR=$$(pwd -P) CCACHE_COMPILERCHECK=content CCACHE_DIR=$$(pwd -P)/out/ccache ccache ",
+		},
+		{
+			name:     "sccache with absolute dir",
+			cache:    proptools.StringPtr("sccache"),
+			cacheDir: proptools.StringPtr("/ssd/sccache"),
+			expected: "SCCACHE_DIR=/ssd/sccache sccache ",
+		},
+		{
+			name:     "remote execution",
+			cache:    proptools.StringPtr("ccache"),
+			useRBE:   true,
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := android.TestConfig(t.TempDir(), nil, "", nil)
+			config.TestProductVariables.CcCompilerCache = tc.cache
+			config.TestProductVariables.CcCompilerCacheDir = tc.cacheDir
+			config.TestProductVariables.UseRBE = proptools.BoolPtr(tc.useRBE)
+			android.AssertStringEquals(t, "wrapper", tc.expected, CompilerCacheWrapper(config))
+		})
+	}
+}
+
+func TestCheckGlobalExtraFlags(t *testing.T) {
+	config := android.TestConfig(t.TempDir(), nil, "", nil)
+	config.TestProductVariables.TargetGlobalExtraCflags = []string{
+		"-fno-omit-frame-pointer", "-fshort-enums", "-mfloat-abi=soft", "-w", "-fno-stack-protector", "O2"}
+	config.TestProductVariables.TargetGlobalExtraLdflags = []string{"-Wl,--gc-sections", "-Wl,-z,lazy"}
+	err := config.Validate()
+	if err == nil {
+		t.Fatalf("expected an error for the banned flags")
+	}
+	android.AssertStringEquals(t, "errors", "invalid product variables in soong.variables:\n"+
+		`    TargetGlobalExtraCflags="-fshort-enums": the flag is not allowed in the global flags of a device`+"\n"+
+		`    TargetGlobalExtraCflags="-mfloat-abi=soft": the flag is not allowed in the global flags of a device`+"\n"+
+		`    TargetGlobalExtraCflags="-w": the flag is not allowed in the global flags of a device`+"\n"+
+		`    TargetGlobalExtraCflags="-fno-stack-protector": the flag is not allowed in the global flags of a device`+"\n"+
+		"    TargetGlobalExtraCflags=\"O2\": flags must start with `-`\n"+
+		`    TargetGlobalExtraLdflags="-Wl,-z,lazy": the flag is not allowed in the global flags of a device`,
+		err.Error())
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+)
+
+// RestrictedFlag is a raw flag that weakens the hardening of the build, and that modules may only
+// add to their cflags or ldflags in some directories. The modules using it elsewhere are reported
+// in SOONG_MODULES_USING_RESTRICTED_FLAGS, and fail the build if the product sets
+// EnforceRestrictedFlags.
+type RestrictedFlag struct {
+	// The restricted flag, or a prefix of the restricted flags if it ends with "=".
+	Flag string
+
+	// Why the flag is restricted, reported with the violations.
+	Reason string
+
+	// The directories whose modules may use the flag.
+	AllowedPaths []string
+}
+
+// Matches returns true if flag is restricted by f.
+func (f RestrictedFlag) Matches(flag string) bool {
+	if strings.HasSuffix(f.Flag, "=") {
+		return strings.HasPrefix(flag, f.Flag)
+	}
+	return flag == f.Flag
+}
+
+// AllowedIn returns true if the modules in dir may use the flag, either because dir is in
+// AllowedPaths or in one of extraAllowedPaths.
+func (f RestrictedFlag) AllowedIn(dir string, extraAllowedPaths []string) bool {
+	dir = dir + "/"
+	for _, paths := range [][]string{f.AllowedPaths, extraAllowedPaths} {
+		for _, path := range paths {
+			if strings.HasPrefix(dir, strings.TrimSuffix(path, "/")+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var (
+	// Compiler flags that modules may only add to their cflags, cppflags and conlyflags in the
+	// allowed directories.
+	RestrictedCflags = []RestrictedFlag{
+		{
+			Flag:   "-fno-stack-protector",
+			Reason: "disables the stack protector",
+			// The libc startup code runs before the stack guard is set up.
+			AllowedPaths: []string{"bionic/", "external/compiler-rt/"},
+		},
+		{
+			Flag:         "-U_FORTIFY_SOURCE",
+			Reason:       "disables FORTIFY",
+			AllowedPaths: []string{"bionic/"},
+		},
+		{
+			Flag:         "-D_FORTIFY_SOURCE=",
+			Reason:       "overrides the FORTIFY level",
+			AllowedPaths: []string{"bionic/"},
+		},
+		{
+			Flag:   "-ftrivial-auto-var-init=uninitialized",
+			Reason: "disables the automatic initialization of stack variables",
+		},
+	}
+
+	// Linker flags that modules may only add to their ldflags in the allowed directories.
+	RestrictedLdflags = []RestrictedFlag{
+		{
+			Flag:         "-Wl,--allow-shlib-undefined",
+			Reason:       "hides undefined symbols until they fail to resolve at runtime",
+			AllowedPaths: []string{"bionic/"},
+		},
+		{
+			Flag:   "-Wl,-z,execstack",
+			Reason: "makes the stack executable",
+		},
+		{
+			Flag:   "-Wl,-z,norelro",
+			Reason: "disables RELRO",
+		},
+		{
+			Flag:   "-Wl,-z,lazy",
+			Reason: "disables immediate binding",
+		},
+	}
+)
@@ -31,7 +31,17 @@ var (
 		"-riscv-disable-sextw-removal=true",
 	}
 
-	riscv64ArchVariantCflags = map[string][]string{}
+	riscv64ArchVariantCflags = map[string][]string{
+		"rv64gc-zb": []string{
+			"-march=rv64gc_zba_zbb_zbs",
+		},
+		"rv64gcv": []string{
+			"-march=rv64gcv",
+		},
+		"rv64gcv-zb": []string{
+			"-march=rv64gcv_zba_zbb_zbs",
+		},
+	}
 
 	riscv64Ldflags = []string{
 		"-Wl,--hash-style=gnu",
@@ -60,10 +70,26 @@ func init() {
 	exportedVars.ExportVariableReferenceDict("Riscv64ArchVariantCflags", riscv64ArchVariantCflagsVar)
 	exportedVars.ExportVariableReferenceDict("Riscv64CpuVariantCflags", riscv64CpuVariantCflagsVar)
 	exportedVars.ExportVariableReferenceDict("Riscv64CpuVariantLdflags", riscv64CpuVariantLdflags)
+
+	exportedVars.ExportStringListStaticVariable("Riscv64Rv64gcZbCflags", riscv64ArchVariantCflags["rv64gc-zb"])
+	exportedVars.ExportStringListStaticVariable("Riscv64Rv64gcvCflags", riscv64ArchVariantCflags["rv64gcv"])
+	exportedVars.ExportStringListStaticVariable("Riscv64Rv64gcvZbCflags", riscv64ArchVariantCflags["rv64gcv-zb"])
+
+	// The ISA string selected by the product overrides the -march flag of the arch variant.
+	pctx.VariableFunc("Riscv64IsaCflags", func(ctx android.PackageVarContext) string {
+		if isa := ctx.Config().Riscv64Isa(); isa != "" {
+			return "-march=" + isa
+		}
+		return ""
+	})
 }
 
 var (
-	riscv64ArchVariantCflagsVar = map[string]string{}
+	riscv64ArchVariantCflagsVar = map[string]string{
+		"rv64gc-zb":  "${config.Riscv64Rv64gcZbCflags}",
+		"rv64gcv":    "${config.Riscv64Rv64gcvCflags}",
+		"rv64gcv-zb": "${config.Riscv64Rv64gcvZbCflags}",
+	}
 
 	riscv64CpuVariantCflagsVar = map[string]string{}
 
@@ -118,13 +144,18 @@ func (toolchainRiscv64) LibclangRuntimeLibraryArch() string {
 func riscv64ToolchainFactory(arch android.Arch) Toolchain {
 	switch arch.ArchVariant {
 	case "":
+	case "rv64gc-zb":
+	case "rv64gcv":
+	case "rv64gcv-zb":
+		// Nothing extra for the rv64gc extensions
 	default:
 		panic(fmt.Sprintf("Unknown Riscv64 architecture version: %q", arch.ArchVariant))
 	}
 
 	toolchainCflags := []string{riscv64ArchVariantCflagsVar[arch.ArchVariant]}
 	toolchainCflags = append(toolchainCflags,
-		variantOrDefault(riscv64CpuVariantCflagsVar, arch.CpuVariant))
+		variantOrDefault(riscv64CpuVariantCflagsVar, arch.CpuVariant),
+		"${config.Riscv64IsaCflags}")
 
 	extraLdflags := variantOrDefault(riscv64CpuVariantLdflags, arch.CpuVariant)
 	return &toolchainRiscv64{
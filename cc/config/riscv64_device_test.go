@@ -0,0 +1,55 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestRiscv64ArchVariantCflags(t *testing.T) {
+	testCases := []struct {
+		archVariant string
+		march       string
+	}{
+		{"", ""},
+		{"rv64gc-zb", "-march=rv64gc_zba_zbb_zbs"},
+		{"rv64gcv", "-march=rv64gcv"},
+		{"rv64gcv-zb", "-march=rv64gcv_zba_zbb_zbs"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.archVariant, func(t *testing.T) {
+			if testCase.march != "" && !android.InList(testCase.march, riscv64ArchVariantCflags[testCase.archVariant]) {
+				t.Errorf("expected %q in %q", testCase.march, riscv64ArchVariantCflags[testCase.archVariant])
+			}
+
+			toolchain := riscv64ToolchainFactory(android.Arch{
+				ArchType:    android.Riscv64,
+				ArchVariant: testCase.archVariant,
+			})
+			cflags := toolchain.ToolchainCflags()
+			if expected := riscv64ArchVariantCflagsVar[testCase.archVariant]; !strings.Contains(cflags, expected) {
+				t.Errorf("expected %q in toolchain cflags %q", expected, cflags)
+			}
+			// The ISA string of the product must come last to override the arch variant.
+			if !strings.HasSuffix(cflags, "${config.Riscv64IsaCflags}") {
+				t.Errorf("expected toolchain cflags %q to end with the ISA string of the product", cflags)
+			}
+		})
+	}
+}
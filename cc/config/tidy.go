@@ -274,8 +274,19 @@ func TidyFlagsForSrcFile(srcFile android.Path, flags string) string {
 
 var (
 	removedCFlags = regexp.MustCompile(" -fsanitize=[^ ]*memtag-[^ ]* ")
+
+	warningsAsErrorsFlag = regexp.MustCompile(`(^| )-warnings-as-errors=[^ ]+`)
 )
 
+// TidyFlagsForBaseline removes the checks of the pre-existing findings of a source file in the
+// tidy baseline from the checks that are errors, so that they are only reported as warnings.
+func TidyFlagsForBaseline(flags string, baselineChecks []string) string {
+	if len(baselineChecks) == 0 {
+		return flags
+	}
+	return warningsAsErrorsFlag.ReplaceAllString(flags, "${0},-"+strings.Join(baselineChecks, ",-"))
+}
+
 func TidyReduceCFlags(flags string) string {
 	return removedCFlags.ReplaceAllString(flags, " ")
 }
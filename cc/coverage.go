@@ -22,7 +22,10 @@ import (
 	"android/soong/android"
 )
 
-const profileInstrFlag = "-fprofile-instr-generate=/data/misc/trace/clang-%p-%m.profraw"
+// ProfileInstrFlag is the profile path of the C/C++ and Rust binaries built with clang coverage, so
+// that their profiles are pulled and merged together.
+// Add '%c' to default specifier after we resolve http://b/210012154
+const ProfileInstrFlag = "-fprofile-instr-generate=/data/misc/trace/clang-%p-%m.profraw"
 
 type CoverageProperties struct {
 	Native_coverage *bool
@@ -100,7 +103,7 @@ func (cov *coverage) flags(ctx ModuleContext, flags Flags, deps PathDeps) (Flags
 			// flags that the module may use.
 			flags.Local.CFlags = append(flags.Local.CFlags, "-Wno-frame-larger-than=", "-O0")
 		} else if clangCoverage {
-			flags.Local.CommonFlags = append(flags.Local.CommonFlags, profileInstrFlag,
+			flags.Local.CommonFlags = append(flags.Local.CommonFlags, ProfileInstrFlag,
 				"-fcoverage-mapping", "-Wno-pass-failed", "-D__ANDROID_CLANG_COVERAGE__")
 			// Override -Wframe-larger-than.  We can expect frame size increase after
 			// coverage instrumentation.
@@ -164,7 +167,7 @@ func (cov *coverage) flags(ctx ModuleContext, flags Flags, deps PathDeps) (Flags
 
 			flags.Local.LdFlags = append(flags.Local.LdFlags, "-Wl,--wrap,getenv")
 		} else if clangCoverage {
-			flags.Local.LdFlags = append(flags.Local.LdFlags, profileInstrFlag)
+			flags.Local.LdFlags = append(flags.Local.LdFlags, ProfileInstrFlag)
 			if EnableContinuousCoverage(ctx) {
 				flags.Local.LdFlags = append(flags.Local.LdFlags, "-Wl,-mllvm=-runtime-counter-relocation")
 			}
@@ -0,0 +1,154 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+	"android/soong/cc/config"
+)
+
+// This file implements the HTML report of the source-based coverage of ClangCoverage builds. The
+// .profraw and .profdata files pulled from the devices into out/coverage/profiles are merged into
+// out/coverage/clang_coverage.profdata, and llvm-cov writes the report of the coverage of the
+// sources in the NativeCoveragePaths of the product by the coverage variants of the binaries and
+// shared libraries into out/coverage/html, which `m clang_coverage_report` builds.
+
+func init() {
+	android.RegisterSingletonType("clang_coverage_report", clangCoverageReportSingletonFactory)
+}
+
+func clangCoverageReportSingletonFactory() android.Singleton {
+	return &clangCoverageReportSingleton{}
+}
+
+type clangCoverageReportSingleton struct{}
+
+// CoverageReportModule is implemented by the modules of other languages built with clang
+// coverage, like Rust, whose binaries and shared libraries are included in the coverage report.
+type CoverageReportModule interface {
+	// CoverageReportObject returns the unstripped binary or shared library of a device module
+	// built with coverage, or nil.
+	CoverageReportObject() android.Path
+}
+
+// coverageReportObjects returns the unstripped binaries and shared libraries of the device modules
+// built with coverage, which map the counters of the profiles to the sources.
+func coverageReportObjects(ctx android.SingletonContext) android.Paths {
+	var objects android.Paths
+	ctx.VisitAllModules(func(module android.Module) {
+		if m, ok := module.(CoverageReportModule); ok {
+			if object := m.CoverageReportObject(); object != nil && module.Enabled() {
+				objects = append(objects, object)
+			}
+			return
+		}
+		m, ok := module.(*Module)
+		if !ok || !m.Enabled() || !m.Device() || m.coverage == nil || !m.coverage.Properties.CoverageEnabled {
+			return
+		}
+		if !m.Binary() && (m.library == nil || !m.library.shared()) {
+			return
+		}
+		if unstripped := m.UnstrippedOutputFile(); unstripped != nil {
+			objects = append(objects, unstripped)
+		}
+	})
+	return android.SortedUniquePaths(objects)
+}
+
+// coverageProfiles returns the profiles in out/coverage/profiles, and reruns soong_build when
+// profiles are added or removed.
+func coverageProfiles(ctx android.SingletonContext) android.Paths {
+	profilesDir := android.PathForCoverageInstall(ctx, "profiles")
+	var profiles android.Paths
+	for _, ext := range []string{".profraw", ".profdata"} {
+		matches, err := ctx.GlobWithDeps(filepath.Join(profilesDir.String(), "**", "*"+ext), nil)
+		if err != nil {
+			ctx.Errorf("failed to glob coverage profiles: %s", err)
+			return nil
+		}
+		for _, match := range matches {
+			rel, err := filepath.Rel(profilesDir.String(), match)
+			if err != nil {
+				ctx.Errorf("%s", err)
+				continue
+			}
+			profiles = append(profiles, profilesDir.Join(ctx, rel))
+		}
+	}
+	return profiles
+}
+
+func (s *clangCoverageReportSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	if !ctx.DeviceConfig().ClangCoverageEnabled() {
+		return
+	}
+	objects := coverageReportObjects(ctx)
+	if len(objects) == 0 {
+		return
+	}
+
+	index := android.PathForCoverageInstall(ctx, "html", "index.html")
+	profiles := coverageProfiles(ctx)
+	if len(profiles) == 0 {
+		ctx.Build(pctx, android.BuildParams{
+			Rule:   android.ErrorRule,
+			Output: index,
+			Args: map[string]string{
+				"error": "no coverage profiles in " + android.PathForCoverageInstall(ctx, "profiles").String() +
+					", pull the .profraw files of the device into it",
+			},
+		})
+		ctx.Phony("clang_coverage_report", index)
+		return
+	}
+
+	profdata := android.PathForCoverageInstall(ctx, "clang_coverage.profdata")
+	rule := android.NewRuleBuilder(pctx, ctx)
+	rule.Command().Text("rm -rf").Text(filepath.Dir(index.String()))
+	rule.Command().
+		Tool(config.ClangPath(ctx, "bin/llvm-profdata")).
+		Text("merge -sparse").
+		FlagWithOutput("-o ", profdata).
+		FlagWithRspFileInputList("@", android.PathForOutput(ctx, "coverage", "clang_coverage_profiles.rsp"), profiles)
+
+	cmd := rule.Command().
+		Tool(config.ClangPath(ctx, "bin/llvm-cov")).
+		Text("show -format=html").
+		FlagWithArg("-output-dir=", filepath.Dir(index.String())).
+		FlagWithInput("-instr-profile=", profdata).
+		ImplicitOutput(index)
+	if excludes := ctx.DeviceConfig().NativeCoverageExcludePaths(); len(excludes) > 0 {
+		var patterns []string
+		for _, exclude := range excludes {
+			patterns = append(patterns, regexp.QuoteMeta(strings.TrimSuffix(exclude, "/"))+"/")
+		}
+		cmd.FlagWithArg("-ignore-filename-regex=", proptools.ShellEscape("^("+strings.Join(patterns, "|")+")"))
+	}
+	cmd.Input(objects[0]).FlagForEachInput("-object ", objects[1:])
+	// Only report the sources that are built with coverage.
+	if paths := ctx.DeviceConfig().NativeCoveragePaths(); !android.InList("*", paths) {
+		cmd.Flags(paths)
+	}
+	rule.Build("clang_coverage_report", "clang coverage report")
+
+	ctx.Phony("clang_coverage_report", index)
+}
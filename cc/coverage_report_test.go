@@ -0,0 +1,52 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"testing"
+
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+)
+
+func TestClangCoverageReport(t *testing.T) {
+	t.Parallel()
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureRegisterWithContext(func(ctx android.RegistrationContext) {
+			ctx.RegisterSingletonType("clang_coverage_report", clangCoverageReportSingletonFactory)
+		}),
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.ClangCoverage = proptools.BoolPtr(true)
+			variables.Native_coverage = proptools.BoolPtr(true)
+			variables.NativeCoveragePaths = []string{"foo"}
+		}),
+		android.FixtureAddTextFile("foo/Android.bp", `
+			cc_binary {
+				name: "foo",
+				srcs: ["foo.c"],
+			}
+		`),
+		android.MockFS{
+			"foo/foo.c": nil,
+		}.AddToFixture(),
+	).RunTest(t)
+
+	// Without profiles in out/coverage/profiles the report fails with an explanation.
+	report := result.SingletonForTests("clang_coverage_report").Output("out/coverage/html/index.html")
+	android.AssertSame(t, "report rule without profiles", android.ErrorRule, report.Rule)
+	android.AssertStringDoesContain(t, "report error", report.Args["error"], "no coverage profiles in")
+}
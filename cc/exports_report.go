@@ -0,0 +1,133 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"encoding/json"
+
+	"android/soong/android"
+)
+
+// With SOONG_CC_EXPORTS_REPORT=true, `m cc_exports` writes out/soong/cc_exports.json, which lists
+// the objects of each native module and the libraries it depends on, with the include directories
+// they export to it and the libraries it links. The unused_exports tool cross-references it with the compile inputs of the
+// objects and their symbols to report the exported include directories and the libraries that
+// are not used by the modules that depend on them.
+
+func init() {
+	android.RegisterSingletonType("cc_exports_report", ccExportsReportSingletonFactory)
+}
+
+// ccExportsModule is the entry of a module variant in cc_exports.json.
+type ccExportsModule struct {
+	Name    string
+	Variant string
+
+	// The objects compiled for the module.
+	Objects []string `json:",omitempty"`
+
+	Deps []ccExportsDep `json:",omitempty"`
+}
+
+// ccExportsDep is a library dependency of a module in cc_exports.json.
+type ccExportsDep struct {
+	Name string
+
+	// "header", "static", "whole_static" or "shared".
+	Kind string
+
+	// The include directories exported to the module by the library, including the ones it
+	// reexports.
+	IncludeDirs []string `json:",omitempty"`
+
+	// The static or shared library linked by the module, empty for header libraries.
+	Library string `json:",omitempty"`
+}
+
+func ccExportsReportEnabled(config android.Config) bool {
+	return config.IsEnvTrue("SOONG_CC_EXPORTS_REPORT")
+}
+
+// collectExportsReport records the objects of the module and its library dependencies for
+// cc_exports.json.
+func (c *Module) collectExportsReport(ctx ModuleContext) {
+	entry := &ccExportsModule{
+		Name:    ctx.ModuleName(),
+		Variant: ctx.ModuleSubDir(),
+		Objects: c.objFiles.Strings(),
+	}
+	ctx.VisitDirectDeps(func(dep android.Module) {
+		tag, ok := ctx.OtherModuleDependencyTag(dep).(libraryDependencyTag)
+		if !ok {
+			return
+		}
+		exportsDep := ccExportsDep{Name: ctx.OtherModuleName(dep)}
+		switch {
+		case tag.header():
+			exportsDep.Kind = "header"
+		case tag.shared():
+			exportsDep.Kind = "shared"
+			if ctx.OtherModuleHasProvider(dep, SharedLibraryInfoProvider) {
+				info := ctx.OtherModuleProvider(dep, SharedLibraryInfoProvider).(SharedLibraryInfo)
+				if info.SharedLibrary != nil {
+					exportsDep.Library = info.SharedLibrary.String()
+				}
+			}
+		case tag.static():
+			exportsDep.Kind = "static"
+			if tag.wholeStatic {
+				exportsDep.Kind = "whole_static"
+			}
+			if ctx.OtherModuleHasProvider(dep, StaticLibraryInfoProvider) {
+				info := ctx.OtherModuleProvider(dep, StaticLibraryInfoProvider).(StaticLibraryInfo)
+				if info.StaticLibrary != nil {
+					exportsDep.Library = info.StaticLibrary.String()
+				}
+			}
+		default:
+			return
+		}
+		exporter := ctx.OtherModuleProvider(dep, FlagExporterInfoProvider).(FlagExporterInfo)
+		exportsDep.IncludeDirs = append(exporter.IncludeDirs.Strings(), exporter.SystemIncludeDirs.Strings()...)
+		entry.Deps = append(entry.Deps, exportsDep)
+	})
+	c.exportsReport = entry
+}
+
+func ccExportsReportSingletonFactory() android.Singleton {
+	return &ccExportsReportSingleton{}
+}
+
+type ccExportsReportSingleton struct{}
+
+func (s *ccExportsReportSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	if !ccExportsReportEnabled(ctx.Config()) {
+		return
+	}
+	var modules []*ccExportsModule
+	ctx.VisitAllModules(func(module android.Module) {
+		if m, ok := module.(*Module); ok && m.Enabled() && m.exportsReport != nil {
+			modules = append(modules, m.exportsReport)
+		}
+	})
+	data, err := json.MarshalIndent(modules, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to marshal the cc exports: %s", err)
+		return
+	}
+	exportsFile := android.PathForOutput(ctx, "cc_exports.json")
+	android.WriteFileRule(ctx, exportsFile, string(data))
+	ctx.Phony("cc_exports", exportsFile)
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestCcExportsReport(t *testing.T) {
+	t.Parallel()
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureRegisterWithContext(func(ctx android.RegistrationContext) {
+			ctx.RegisterSingletonType("cc_exports_report", ccExportsReportSingletonFactory)
+		}),
+		android.FixtureMergeEnv(map[string]string{"SOONG_CC_EXPORTS_REPORT": "true"}),
+	).RunTestWithBp(t, `
+		cc_binary {
+			name: "foo",
+			srcs: ["foo.c"],
+			shared_libs: ["libbar"],
+			whole_static_libs: ["libbaz"],
+		}
+
+		cc_library {
+			name: "libbar",
+			srcs: ["bar.c"],
+			export_include_dirs: ["bar/include"],
+		}
+
+		cc_library_static {
+			name: "libbaz",
+			srcs: ["baz.c"],
+		}
+	`)
+
+	var modules []ccExportsModule
+	content := android.ContentFromFileRuleForTests(t, result.SingletonForTests("cc_exports_report").Output("cc_exports.json"))
+	if err := json.Unmarshal([]byte(content), &modules); err != nil {
+		t.Fatalf("failed to parse cc_exports.json: %s", err)
+	}
+
+	var foo *ccExportsModule
+	for i := range modules {
+		if modules[i].Name == "foo" && modules[i].Variant == "android_arm64_armv8-a" {
+			foo = &modules[i]
+		}
+	}
+	if foo == nil {
+		t.Fatalf("foo is missing from cc_exports.json")
+	}
+	android.AssertDeepEquals(t, "objects of foo",
+		[]string{"out/soong/.intermediates/foo/android_arm64_armv8-a/obj/foo.o"}, foo.Objects)
+
+	deps := make(map[string]ccExportsDep)
+	for _, dep := range foo.Deps {
+		deps[dep.Name] = dep
+	}
+	android.AssertStringEquals(t, "kind of libbar", "shared", deps["libbar"].Kind)
+	android.AssertStringListContains(t, "include dirs of libbar", deps["libbar"].IncludeDirs, "bar/include")
+	android.AssertStringEquals(t, "library of libbar",
+		"out/soong/.intermediates/libbar/android_arm64_armv8-a_shared/libbar.so", deps["libbar"].Library)
+	android.AssertStringEquals(t, "kind of libbaz", "whole_static", deps["libbaz"].Kind)
+}
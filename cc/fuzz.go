@@ -354,6 +354,9 @@ type ccRustFuzzPackager struct {
 	fuzzPackagingArchModules         string
 	fuzzTargetSharedDepsInstallPairs string
 	allFuzzTargetsName               string
+
+	// The zip of the device fuzz targets, built by 'make fuzz-artifacts'.
+	deviceArtifact android.OptionalPath
 }
 
 func fuzzPackagingFactory() android.Singleton {
@@ -380,6 +383,8 @@ func (s *ccRustFuzzPackager) GenerateBuildActions(ctx android.SingletonContext)
 	// multiple fuzzers that depend on the same shared library.
 	sharedLibraryInstalled := make(map[string]bool)
 
+	var deviceArtifact fuzz.DeviceFuzzArtifact
+
 	ctx.VisitAllModules(func(module android.Module) {
 		ccModule, ok := module.(LinkableInterface)
 		if !ok || ccModule.PreventInstall() {
@@ -422,15 +427,32 @@ func (s *ccRustFuzzPackager) GenerateBuildActions(ctx android.SingletonContext)
 		files = append(files, GetSharedLibsToZip(ccModule.FuzzSharedLibraries(), ccModule, &s.FuzzPackager, archString, sharedLibsInstallDirPrefix, &sharedLibraryInstalled)...)
 
 		// The executable.
-		files = append(files, fuzz.FileToZip{android.OutputFileForModule(ctx, ccModule, "unstripped"), ""})
+		binary := android.OutputFileForModule(ctx, ccModule, "unstripped")
+		files = append(files, fuzz.FileToZip{binary, ""})
 
 		archDirs[archOs], ok = s.BuildZipFile(ctx, module, fpm, files, builder, archDir, archString, hostOrTargetString, archOs, archDirs)
 		if !ok {
 			return
 		}
+
+		if !ccModule.Host() {
+			lang := fuzz.Rust
+			if _, isCc := module.(*Module); isCc {
+				lang = fuzz.Cc
+			}
+			deviceArtifact.AddTarget(ctx, module, fpm, fuzz.ConfiguredFramework(ctx.Config(), lang), archString,
+				archDir, binary, ccModule.FuzzSharedLibraries())
+		}
 	})
 
 	s.CreateFuzzPackage(ctx, archDirs, fuzz.Cc, pctx)
+
+	if len(deviceArtifact.Targets) > 0 {
+		artifact := android.PathForOutput(ctx, "fuzz-artifacts-"+ctx.Config().DeviceName()+".zip")
+		deviceArtifact.Build(ctx, pctx, artifact)
+		ctx.Phony("fuzz-artifacts", artifact)
+		s.deviceArtifact = android.OptionalPathForPath(artifact)
+	}
 }
 
 func (s *ccRustFuzzPackager) MakeVars(ctx android.MakeVarsContext) {
@@ -449,6 +471,10 @@ func (s *ccRustFuzzPackager) MakeVars(ctx android.MakeVarsContext) {
 
 	// Preallocate the slice of fuzz targets to minimise memory allocations.
 	s.PreallocateSlice(ctx, s.allFuzzTargetsName)
+
+	if s.deviceArtifact.Valid() {
+		ctx.DistForGoal("fuzz-artifacts", s.deviceArtifact.Path())
+	}
 }
 
 // GetSharedLibsToZip finds and marks all the transiently-dependent shared libraries for
@@ -0,0 +1,105 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"fmt"
+	"strings"
+
+	"android/soong/android"
+)
+
+// GWP-ASan is the sampling allocator of the malloc of bionic, which catches heap memory errors in
+// production. The product sets its default sample rate and maximum number of simultaneous
+// allocations with the Gwp_asan_sample_rate and Gwp_asan_max_allocs product variables, which the
+// malloc integration of libc is compiled with through its product_variables. The executables can
+// tune them with their gwp_asan properties, which are compiled into __gwp_asan_default_options,
+// the options the malloc integration looks up in the executable when a process starts.
+
+const gwpAsanDefaultOptionsSymbol = "__gwp_asan_default_options"
+
+type GwpAsanProperties struct {
+	Gwp_asan struct {
+		// Sample one allocation out of this many with GWP-ASan in the processes of this executable,
+		// instead of the default sample rate of the product. Lower values catch more errors at a
+		// higher cost.
+		Sample_rate *int64
+
+		// The maximum number of allocations sampled by GWP-ASan at the same time in the processes
+		// of this executable, instead of the default of the product. Each of them uses a page of
+		// memory and a guard page.
+		Max_allocs *int64
+	}
+}
+
+type gwpAsan struct {
+	Properties GwpAsanProperties
+}
+
+func (gwpAsan *gwpAsan) props() []interface{} {
+	return []interface{}{&gwpAsan.Properties}
+}
+
+// options returns the GWP-ASan options of the executable, or "" if it uses the defaults of the
+// product.
+func (gwpAsan *gwpAsan) options(ctx ModuleContext) string {
+	if !ctx.binary() || !ctx.Device() {
+		return ""
+	}
+	props := gwpAsan.Properties.Gwp_asan
+	var options []string
+	if rate := props.Sample_rate; rate != nil {
+		options = append(options, fmt.Sprintf("SampleRate=%d", *rate))
+	}
+	if maxAllocs := props.Max_allocs; maxAllocs != nil {
+		options = append(options, fmt.Sprintf("MaxSimultaneousAllocations=%d", *maxAllocs))
+	}
+	return strings.Join(options, ":")
+}
+
+func (gwpAsan *gwpAsan) flags(ctx ModuleContext, flags Flags) Flags {
+	props := gwpAsan.Properties.Gwp_asan
+	if rate := props.Sample_rate; rate != nil && *rate < 1 {
+		ctx.PropertyErrorf("gwp_asan.sample_rate", "must be at least 1, got %d", *rate)
+	}
+	if maxAllocs := props.Max_allocs; maxAllocs != nil && *maxAllocs < 1 {
+		ctx.PropertyErrorf("gwp_asan.max_allocs", "must be at least 1, got %d", *maxAllocs)
+	}
+
+	// The options of dynamic executables are looked up by the malloc integration of libc.so.
+	if gwpAsan.options(ctx) != "" && !ctx.static() {
+		flags.Local.LdFlags = append(flags.Local.LdFlags,
+			"-Wl,--export-dynamic-symbol="+gwpAsanDefaultOptionsSymbol)
+	}
+	return flags
+}
+
+// compile compiles the GWP-ASan options of the executable into its __gwp_asan_default_options.
+func (gwpAsan *gwpAsan) compile(ctx ModuleContext, flags Flags, deps PathDeps) Objects {
+	options := gwpAsan.options(ctx)
+	if options == "" {
+		return Objects{}
+	}
+	src := android.PathForModuleGen(ctx, "gwp_asan", "gwp_asan_options.c")
+	android.WriteFileRule(ctx, src, fmt.Sprintf(
+		"// Generated from the gwp_asan properties of %s.\n"+
+			"__attribute__((visibility(\"default\"), used))\n"+
+			"const char* %s(void) {\n"+
+			"  return %q;\n"+
+			"}", ctx.ModuleName(), gwpAsanDefaultOptionsSymbol, options))
+
+	return compileObjs(ctx, flagsToBuilderFlags(flags), "", android.Paths{src},
+		android.Paths{src}, nil, deps.GeneratedDeps, flags.CFlagsDeps)
+}
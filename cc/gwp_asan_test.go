@@ -0,0 +1,73 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestGwpAsanOptions(t *testing.T) {
+	t.Parallel()
+	result := prepareForCcTest.RunTestWithBp(t, `
+		cc_binary {
+			name: "lowram_daemon",
+			srcs: ["foo.c"],
+			gwp_asan: {
+				sample_rate: 5000,
+				max_allocs: 8,
+			},
+		}
+
+		cc_binary {
+			name: "default_daemon",
+			srcs: ["foo.c"],
+		}
+	`)
+
+	daemon := result.ModuleForTests("lowram_daemon", "android_arm64_armv8-a")
+	src := daemon.Output("gen/gwp_asan/gwp_asan_options.c")
+	android.AssertStringDoesContain(t, "options source",
+		android.ContentFromFileRuleForTests(t, src), `return "SampleRate=5000:MaxSimultaneousAllocations=8";`)
+	obj := daemon.Output("obj/gwp_asan/gwp_asan_options.o")
+	android.AssertPathRelativeToTopEquals(t, "options object input",
+		"out/soong/.intermediates/lowram_daemon/android_arm64_armv8-a/gen/gwp_asan/gwp_asan_options.c", obj.Input)
+	link := daemon.Rule("ld")
+	android.AssertStringListContains(t, "linked objects", android.PathsRelativeToTop(link.Inputs),
+		"out/soong/.intermediates/lowram_daemon/android_arm64_armv8-a/obj/gwp_asan/gwp_asan_options.o")
+	android.AssertStringDoesContain(t, "ldflags", link.Args["ldFlags"],
+		"-Wl,--export-dynamic-symbol=__gwp_asan_default_options")
+
+	defaultDaemon := result.ModuleForTests("default_daemon", "android_arm64_armv8-a")
+	if defaultDaemon.MaybeOutput("gen/gwp_asan/gwp_asan_options.c").Rule != nil {
+		t.Errorf("expected no GWP-ASan options without gwp_asan properties")
+	}
+}
+
+func TestGwpAsanInvalidSampleRate(t *testing.T) {
+	t.Parallel()
+	prepareForCcTest.ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`gwp_asan.sample_rate: must be at least 1, got 0`,
+	)).RunTestWithBp(t, `
+		cc_binary {
+			name: "daemon",
+			srcs: ["foo.c"],
+			gwp_asan: {
+				sample_rate: 0,
+			},
+		}
+	`)
+}
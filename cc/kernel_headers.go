@@ -25,17 +25,51 @@ type kernelHeadersDecorator struct {
 func (stub *kernelHeadersDecorator) link(ctx ModuleContext, flags Flags, deps PathDeps, objs Objects) android.Path {
 	if ctx.Device() {
 		f := &stub.libraryDecorator.flagExporter
-		f.reexportSystemDirs(android.PathsForSource(ctx, ctx.DeviceConfig().DeviceKernelHeaderDirs())...)
+		f.reexportSystemDirs(kernelHeaderDirs(ctx)...)
 		f.setProvider(ctx)
 	}
 	return stub.libraryDecorator.linkStatic(ctx, flags, deps, objs)
 }
 
+// kernelArchs maps the arch types to the names of the archs in the kernel headers.
+var kernelArchs = map[android.ArchType]string{
+	android.Arm:     "arm",
+	android.Arm64:   "arm64",
+	android.Riscv64: "riscv",
+	android.X86:     "x86",
+	android.X86_64:  "x86",
+}
+
+// kernelHeaderDirs returns the DeviceKernelHeaderDirs along with the uapi directories they
+// contain: the asm-<arch> directory for the arch of the module of headers processed like the
+// ones of bionic, and the uapi directories of a kernel source tree. The directories that don't
+// exist are skipped, and soong_build reruns when they are created.
+func kernelHeaderDirs(ctx ModuleContext) android.Paths {
+	var subdirs []string
+	if arch, ok := kernelArchs[ctx.Arch().ArchType]; ok {
+		subdirs = append(subdirs, "asm-"+arch,
+			"arch/"+arch+"/include/uapi", "arch/"+arch+"/include/generated/uapi")
+	}
+	subdirs = append(subdirs, "include/uapi", "include/generated/uapi")
+
+	var dirs android.Paths
+	for _, dir := range ctx.DeviceConfig().DeviceKernelHeaderDirs() {
+		dirs = append(dirs, android.PathForSource(ctx, dir))
+		for _, subdir := range subdirs {
+			if path := android.ExistentPathForSource(ctx, dir, subdir); path.Valid() {
+				dirs = append(dirs, path.Path())
+			}
+		}
+	}
+	return dirs
+}
+
 // kernel_headers retrieves the list of kernel headers directories from
 // TARGET_BOARD_KERNEL_HEADERS and TARGET_PRODUCT_KERNEL_HEADERS variables in
 // a makefile for compilation. See
 // https://android.googlesource.com/platform/build/+/master/core/config.mk
-// for more details on them.
+// for more details on them. The uapi headers of the arch of each variant are exported along with
+// them, so that modules can depend on the kernel headers of the device by name.
 func kernelHeadersFactory() android.Module {
 	module, library := NewLibrary(android.HostAndDeviceSupported)
 	library.HeaderOnly()
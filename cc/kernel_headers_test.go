@@ -0,0 +1,66 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestKernelHeaders(t *testing.T) {
+	t.Parallel()
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureRegisterWithContext(func(ctx android.RegistrationContext) {
+			ctx.RegisterModuleType("kernel_headers", kernelHeadersFactory)
+		}),
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.DeviceKernelHeaders = []string{"kernel/headers", "kernel/source"}
+		}),
+		android.MockFS{
+			"kernel/headers/linux/types.h":                       nil,
+			"kernel/headers/asm-arm64/asm/types.h":               nil,
+			"kernel/headers/asm-arm/asm/types.h":                 nil,
+			"kernel/source/include/uapi/linux/types.h":           nil,
+			"kernel/source/arch/arm64/include/uapi/asm/unistd.h": nil,
+		}.AddToFixture(),
+	).RunTestWithBp(t, `
+		kernel_headers {
+			name: "kernel_hdrs",
+		}
+		cc_library_shared {
+			name: "libhal",
+			srcs: ["foo.c"],
+			header_libs: ["kernel_hdrs"],
+		}
+	`)
+
+	arm64Flags := result.ModuleForTests("libhal", "android_arm64_armv8-a_shared").Rule("cc").Args["cFlags"]
+	for _, dir := range []string{
+		"kernel/headers",
+		"kernel/headers/asm-arm64",
+		"kernel/source",
+		"kernel/source/arch/arm64/include/uapi",
+		"kernel/source/include/uapi",
+	} {
+		android.AssertStringDoesContain(t, "arm64 kernel headers", arm64Flags, "-isystem "+dir+" ")
+	}
+	android.AssertStringDoesNotContain(t, "arm64 kernel headers", arm64Flags, "asm-arm ")
+
+	armFlags := result.ModuleForTests("libhal", "android_arm_armv7-a-neon_shared").Rule("cc").Args["cFlags"]
+	android.AssertStringDoesContain(t, "arm kernel headers", armFlags, "-isystem kernel/headers/asm-arm ")
+	android.AssertStringDoesNotContain(t, "arm kernel headers", armFlags, "asm-arm64")
+}
@@ -87,6 +87,10 @@ type LibraryProperties struct {
 	// Properties for ABI compatibility checker.
 	Header_abi_checker headerAbiCheckerProperties
 
+	// Check that the symbols exported by the shared library are exactly the global symbols of
+	// its version_script, or of its stubs or llndk symbol_file if it has no version_script.
+	Strict_symbol_check *bool
+
 	Target struct {
 		Vendor, Product struct {
 			// set suffix of the name of the output
@@ -793,6 +797,8 @@ type libraryDecorator struct {
 	SharedProperties  SharedProperties
 	MutatedProperties LibraryMutatedProperties
 
+	bolt bolt
+
 	// For reusing static library objects for shared library
 	reuseObjects Objects
 
@@ -819,7 +825,7 @@ type libraryDecorator struct {
 	// linked Source Abi Dump
 	sAbiOutputFile android.OptionalPath
 
-	// Source Abi Diff
+	// Source Abi Diff, and STG Abi Diff of the NDK and LLNDK libraries
 	sAbiDiff android.Paths
 
 	// Location of the static library in the sysroot. Empty if the library is
@@ -1124,7 +1130,7 @@ func (library *libraryDecorator) linkerProps() []interface{} {
 		&library.stripper.StripProperties)
 
 	if library.MutatedProperties.BuildShared {
-		props = append(props, &library.SharedProperties)
+		props = append(props, &library.SharedProperties, &library.bolt.Properties)
 	}
 	if library.MutatedProperties.BuildStatic {
 		props = append(props, &library.StaticProperties)
@@ -1150,6 +1156,7 @@ func (library *libraryDecorator) linkerFlags(ctx ModuleContext, flags Flags) Fla
 		flags.Local.CFlags = append(flags.Local.CFlags, library.StaticProperties.Static.Cflags...)
 	} else if library.shared() {
 		flags.Local.CFlags = append(flags.Local.CFlags, library.SharedProperties.Shared.Cflags...)
+		flags = library.bolt.flags(ctx, flags)
 	}
 
 	if library.shared() {
@@ -1754,6 +1761,9 @@ func (library *libraryDecorator) linkShared(ctx ModuleContext,
 
 	outputFile = maybeInjectBoringSSLHash(ctx, outputFile, library.Properties.Inject_bssl_hash, fileName)
 
+	// Optimize the layout before the BoringSSL hash is computed, which covers the code.
+	outputFile = library.bolt.optimize(ctx, outputFile, fileName)
+
 	if Bool(library.baseLinker.Properties.Use_version_lib) {
 		if ctx.Host() {
 			versionedOutputFile := outputFile
@@ -1791,6 +1801,12 @@ func (library *libraryDecorator) linkShared(ctx ModuleContext,
 
 	library.coverageOutputFile = transformCoverageFilesToZip(ctx, objs, library.getLibName(ctx))
 	library.linkSAbiDumpFiles(ctx, objs, fileName, unstrippedOutputFile)
+	if diff := library.stgAbiDumpAndDiff(ctx, fileName); diff.Valid() {
+		library.sAbiDiff = append(library.sAbiDiff, diff.Path())
+	}
+	if check := library.checkExportedSymbols(ctx, fileName); check.Valid() {
+		library.sAbiDiff = append(library.sAbiDiff, check.Path())
+	}
 
 	var transitiveStaticLibrariesForOrdering *android.DepSet
 	if static := ctx.GetDirectDepsWithTag(staticVariantTag); len(static) > 0 {
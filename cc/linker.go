@@ -68,6 +68,11 @@ type BaseLinkerProperties struct {
 	// Use clang lld instead of gnu ld.
 	Use_clang_lld *bool `android:"arch_variant"`
 
+	// The linker to link the module with, "lld" or "mold". mold, which is faster for huge links,
+	// is only supported for Linux host modules and must be available in the prebuilts. Defaults
+	// to the HostLinker of the product for Linux host modules, and to lld otherwise.
+	Linker *string `android:"arch_variant"`
+
 	// -l arguments to pass to linker for host-provided shared libraries
 	Host_ldlibs []string `android:"arch_variant"`
 
@@ -260,6 +265,9 @@ type baseLinker struct {
 	}
 
 	sanitize *sanitize
+
+	// The version script of the variant, from version_script or its target overrides.
+	versionScriptFile android.OptionalPath
 }
 
 func (linker *baseLinker) appendLdflags(flags []string) {
@@ -406,6 +414,10 @@ func (linker *baseLinker) linkerDeps(ctx DepsContext, deps Deps) Deps {
 		}
 	}
 
+	if linker.Properties.System_shared_libs == nil {
+		deps.SystemSharedLibs = replaceDefaultSystemSharedLibs(ctx, deps.SystemSharedLibs)
+	}
+
 	deps.LateSharedLibs = append(deps.LateSharedLibs, deps.SystemSharedLibs...)
 
 	if ctx.Windows() && ctx.ModuleName() != "libwinpthread" {
@@ -422,6 +434,38 @@ func (linker *baseLinker) useClangLld(ctx ModuleContext) bool {
 	return true
 }
 
+// linkerName returns the linker the module is linked with when it uses clang lld, "lld" or
+// "mold".
+func (linker *baseLinker) linkerName(ctx ModuleContext) string {
+	if linker.Properties.Linker != nil {
+		return *linker.Properties.Linker
+	}
+	if ctx.Host() && ctx.Os().Linux() {
+		if hostLinker := ctx.Config().HostLinker(); hostLinker != "" {
+			return hostLinker
+		}
+	}
+	return "lld"
+}
+
+// moldFlags returns the flags that make clang link the module with mold instead of lld, which
+// accepts the same flags, and adds mold to the dependencies of the link.
+func (linker *baseLinker) moldFlags(ctx ModuleContext, flags Flags) Flags {
+	if !ctx.Host() || !ctx.Os().Linux() {
+		ctx.PropertyErrorf("linker", "mold is only supported for Linux host modules")
+		return flags
+	}
+	mold := config.MoldPath(ctx)
+	if !mold.Valid() {
+		ctx.PropertyErrorf("linker", "mold is not available in %s", config.MoldDefaultBase)
+		return flags
+	}
+	// --ld-path takes precedence over the -fuse-ld=lld of the global flags.
+	flags.Global.LdFlags = append(flags.Global.LdFlags, "--ld-path="+mold.String())
+	flags.LdFlagsDeps = append(flags.LdFlagsDeps, mold.Path())
+	return flags
+}
+
 // Check whether the SDK version is not older than the specific one
 func CheckSdkVersionAtLeast(ctx ModuleContext, SdkVersion android.ApiLevel) bool {
 	if ctx.minSdkVersion() == "current" {
@@ -482,7 +526,17 @@ func (linker *baseLinker) linkerFlags(ctx ModuleContext, flags Flags) Flags {
 
 	if linker.useClangLld(ctx) {
 		flags.Global.LdFlags = append(flags.Global.LdFlags, toolchain.Lldflags())
+		switch linkerName := linker.linkerName(ctx); linkerName {
+		case "lld":
+		case "mold":
+			flags = linker.moldFlags(ctx, flags)
+		default:
+			ctx.PropertyErrorf("linker", "expected lld or mold, got %q", linkerName)
+		}
 	} else {
+		if linker.Properties.Linker != nil {
+			ctx.PropertyErrorf("linker", "can't be used with use_clang_lld: false")
+		}
 		flags.Global.LdFlags = append(flags.Global.LdFlags, toolchain.Ldflags())
 	}
 
@@ -506,6 +560,7 @@ func (linker *baseLinker) linkerFlags(ctx ModuleContext, flags Flags) Flags {
 	}
 
 	CheckBadLinkerFlags(ctx, "ldflags", linker.Properties.Ldflags)
+	CheckRestrictedFlags(ctx, "ldflags", linker.Properties.Ldflags, config.RestrictedLdflags)
 
 	flags.Local.LdFlags = append(flags.Local.LdFlags, proptools.NinjaAndShellEscapeList(linker.Properties.Ldflags)...)
 
@@ -537,6 +592,7 @@ func (linker *baseLinker) linkerFlags(ctx ModuleContext, flags Flags) Flags {
 				linker.Properties.Target.Product.Version_script,
 				"target.product.version_script")
 		}
+		linker.versionScriptFile = versionScript
 
 		if versionScript.Valid() {
 			if ctx.Darwin() {
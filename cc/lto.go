@@ -117,17 +117,16 @@ func (lto *lto) flags(ctx BaseModuleContext, flags Flags) Flags {
 			flags.Local.CFlags = append(flags.Local.CFlags, "-fwhole-program-vtables")
 		}
 
-		if (lto.DefaultThinLTO(ctx) || lto.ThinLTO()) && ctx.Config().IsEnvTrue("USE_THINLTO_CACHE") && lto.useClangLld(ctx) {
-			// Set appropriate ThinLTO cache policy
-			cacheDirFormat := "-Wl,--thinlto-cache-dir="
-			cacheDir := android.PathForOutput(ctx, "thinlto-cache").String()
-			flags.Local.LdFlags = append(flags.Local.LdFlags, cacheDirFormat+cacheDir)
-
-			// Limit the size of the ThinLTO cache to the lesser of 10% of available
-			// disk space and 10GB.
-			cachePolicyFormat := "-Wl,--thinlto-cache-policy="
-			policy := "cache_size=10%:cache_size_bytes=10g"
-			flags.Local.LdFlags = append(flags.Local.LdFlags, cachePolicyFormat+policy)
+		if (lto.DefaultThinLTO(ctx) || lto.ThinLTO()) && lto.useClangLld(ctx) {
+			if cacheDir := thinLTOCacheDir(ctx); cacheDir != "" {
+				// Set appropriate ThinLTO cache policy
+				cacheDirFormat := "-Wl,--thinlto-cache-dir="
+				flags.Local.LdFlags = append(flags.Local.LdFlags, cacheDirFormat+cacheDir)
+
+				cachePolicyFormat := "-Wl,--thinlto-cache-policy="
+				policy := ctx.Config().ThinLTOCachePolicy()
+				flags.Local.LdFlags = append(flags.Local.LdFlags, cachePolicyFormat+policy)
+			}
 		}
 
 		// If the module does not have a profile, be conservative and limit cross TU inline
@@ -140,6 +139,19 @@ func (lto *lto) flags(ctx BaseModuleContext, flags Flags) Flags {
 	return flags
 }
 
+// thinLTOCacheDir returns the directory of the ThinLTO cache, or "" if ThinLTO links don't use a
+// cache. The directory configured with THINLTO_CACHE_DIR or the ThinLTOCacheDir product variable
+// persists across builds, while USE_THINLTO_CACHE uses a cache in the output directory.
+func thinLTOCacheDir(ctx BaseModuleContext) string {
+	if cacheDir := ctx.Config().ThinLTOCacheDir(); cacheDir != "" {
+		return cacheDir
+	}
+	if ctx.Config().IsEnvTrue("USE_THINLTO_CACHE") {
+		return android.PathForOutput(ctx, "thinlto-cache").String()
+	}
+	return ""
+}
+
 func (lto *lto) LTO(ctx BaseModuleContext) bool {
 	return lto.ThinLTO() || lto.FullLTO() || lto.DefaultThinLTO(ctx)
 }
@@ -21,6 +21,7 @@ import (
 	"android/soong/android"
 
 	"github.com/google/blueprint"
+	"github.com/google/blueprint/proptools"
 )
 
 func TestThinLtoDeps(t *testing.T) {
@@ -239,3 +240,37 @@ func TestLtoDoesNotPropagateToRuntimeLibs(t *testing.T) {
 	android.AssertStringDoesNotContain(t, "got flag for LTO in runtime_lib",
 		libBar.Args["ldFlags"], "-flto=thin")
 }
+
+func TestThinLtoCacheDir(t *testing.T) {
+	t.Parallel()
+	bp := `
+	cc_library_shared {
+		name: "libfoo",
+		srcs: ["foo.c"],
+		lto: {
+			thin: true,
+		},
+	}
+	cc_library_shared {
+		name: "libbar",
+		srcs: ["bar.c"],
+	}`
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.ThinLTOCacheDir = proptools.StringPtr("/tmp/thinlto-cache")
+			variables.ThinLTOCacheSizeBytes = proptools.StringPtr("50g")
+			variables.ThinLTOCachePruneAfter = proptools.StringPtr("72h")
+		}),
+	).RunTestWithBp(t, bp)
+
+	libFoo := result.ModuleForTests("libfoo", "android_arm64_armv8-a_shared").Rule("ld")
+	android.AssertStringDoesContain(t, "cache dir", libFoo.Args["ldFlags"],
+		"-Wl,--thinlto-cache-dir=/tmp/thinlto-cache")
+	android.AssertStringDoesContain(t, "cache policy", libFoo.Args["ldFlags"],
+		"-Wl,--thinlto-cache-policy=cache_size=10%:cache_size_bytes=50g:prune_after=72h")
+
+	libBar := result.ModuleForTests("libbar", "android_arm64_armv8-a_shared").Rule("ld")
+	android.AssertStringDoesNotContain(t, "cache dir without LTO", libBar.Args["ldFlags"],
+		"-Wl,--thinlto-cache-dir")
+}
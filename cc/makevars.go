@@ -25,9 +25,10 @@ import (
 )
 
 var (
-	modulesWarningsAllowedKey    = android.NewOnceKey("ModulesWarningsAllowed")
-	modulesUsingWnoErrorKey      = android.NewOnceKey("ModulesUsingWnoError")
-	modulesMissingProfileFileKey = android.NewOnceKey("ModulesMissingProfileFile")
+	modulesWarningsAllowedKey      = android.NewOnceKey("ModulesWarningsAllowed")
+	modulesUsingWnoErrorKey        = android.NewOnceKey("ModulesUsingWnoError")
+	modulesMissingProfileFileKey   = android.NewOnceKey("ModulesMissingProfileFile")
+	modulesUsingRestrictedFlagsKey = android.NewOnceKey("ModulesUsingRestrictedFlags")
 )
 
 func init() {
@@ -51,8 +52,11 @@ func makeStringOfKeys(ctx android.MakeVarsContext, key android.OnceKey) string {
 	return strings.Join(keys, " ")
 }
 
-func makeStringOfWarningAllowedProjects() string {
+func makeStringOfWarningAllowedProjects(cfg android.Config) string {
 	allProjects := append([]string{}, config.WarningAllowedProjects...)
+	for _, path := range cfg.WarningsAllowedPaths() {
+		allProjects = append(allProjects, strings.TrimSuffix(path, "/")+"/")
+	}
 	sort.Strings(allProjects)
 	// Makefile rules use pattern "path/%" to match module paths.
 	if len(allProjects) > 0 {
@@ -118,9 +122,10 @@ func makeVarsProvider(ctx android.MakeVarsContext) {
 	sort.Strings(lsdumpPaths)
 	ctx.Strict("LSDUMP_PATHS", strings.Join(lsdumpPaths, " "))
 
-	ctx.Strict("ANDROID_WARNING_ALLOWED_PROJECTS", makeStringOfWarningAllowedProjects())
+	ctx.Strict("ANDROID_WARNING_ALLOWED_PROJECTS", makeStringOfWarningAllowedProjects(ctx.Config()))
 	ctx.Strict("SOONG_MODULES_WARNINGS_ALLOWED", makeStringOfKeys(ctx, modulesWarningsAllowedKey))
 	ctx.Strict("SOONG_MODULES_USING_WNO_ERROR", makeStringOfKeys(ctx, modulesUsingWnoErrorKey))
+	ctx.Strict("SOONG_MODULES_USING_RESTRICTED_FLAGS", makeStringOfKeys(ctx, modulesUsingRestrictedFlagsKey))
 	ctx.Strict("SOONG_MODULES_MISSING_PGO_PROFILE_FILE", makeStringOfKeys(ctx, modulesMissingProfileFileKey))
 
 	ctx.Strict("ADDRESS_SANITIZER_CONFIG_EXTRA_CFLAGS", strings.Join(asanCflags, " "))
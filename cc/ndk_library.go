@@ -146,7 +146,11 @@ func (stub *stubDecorator) implementationModuleName(name string) string {
 func ndkLibraryVersions(ctx android.BaseMutatorContext, from android.ApiLevel) []string {
 	var versions []android.ApiLevel
 	versionStrs := []string{}
+	ndkApiLevels := ctx.Config().NdkApiLevels()
 	for _, version := range ctx.Config().AllSupportedApiLevels() {
+		if len(ndkApiLevels) > 0 && !android.InList(version.String(), ndkApiLevels) {
+			continue
+		}
 		if version.GreaterThanOrEqualTo(from) {
 			versions = append(versions, version)
 			versionStrs = append(versionStrs, version.String())
@@ -0,0 +1,193 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"fmt"
+
+	"github.com/google/blueprint"
+
+	"android/soong/android"
+	"android/soong/cc/config"
+)
+
+//
+// Precompiled headers shared by a group of modules
+//
+
+func init() {
+	android.RegisterModuleType("cc_pch", PchFactory)
+}
+
+// PrecompiledHeaderInfo is provided by cc_pch modules to the modules that use their precompiled
+// header.
+type PrecompiledHeaderInfo struct {
+	// The precompiled header.
+	Pch android.Path
+
+	// The version of the clang that built the precompiled header. Clang rejects precompiled headers
+	// built by any other version.
+	Toolchain string
+}
+
+var PrecompiledHeaderInfoProvider = blueprint.NewProvider(PrecompiledHeaderInfo{})
+
+type PchProperties struct {
+	// the C++ header to precompile. Every C++ source file of the modules that use the precompiled
+	// header includes it implicitly before its first line.
+	Header *string `android:"path,arch_variant"`
+
+	// list of static library modules that should only provide headers for this module.
+	Static_libs []string `android:"arch_variant,variant_prepend"`
+
+	// list of shared library modules should only provide headers for this module.
+	Shared_libs []string `android:"arch_variant,variant_prepend"`
+
+	// list of modules that should only provide headers for this module.
+	Header_libs []string `android:"arch_variant,variant_prepend"`
+
+	// list of default libraries that will provide headers for this module.  If unset, generally
+	// defaults to libc, libm, and libdl.  Set to [] to prevent using headers from the defaults.
+	System_shared_libs []string `android:"arch_variant"`
+}
+
+type pchLinker struct {
+	*baseLinker
+	Properties PchProperties
+}
+
+// cc_pch precompiles a C++ header for a group of modules, which use it with their
+// precompiled_header property. Clang only accepts a precompiled header in compiles with the same
+// language options, target and macro definitions as the header, so the members of the group
+// should share their flags with the cc_pch module through a cc_defaults module.
+func PchFactory() android.Module {
+	module := newObject(android.HostAndDeviceSupported)
+	module.linker = &pchLinker{
+		baseLinker: NewBaseLinker(module.sanitize),
+	}
+	module.compiler = NewBaseCompiler()
+
+	// The precompiled header is only an input of other compiles.
+	module.Properties.HideFromMake = true
+	return module.Init()
+}
+
+func (pch *pchLinker) appendLdflags(flags []string) {
+	panic(fmt.Errorf("appendLdflags on pchLinker not supported"))
+}
+
+func (pch *pchLinker) linkerProps() []interface{} {
+	return []interface{}{&pch.Properties}
+}
+
+func (*pchLinker) linkerInit(ctx BaseModuleContext) {}
+
+func (pch *pchLinker) linkerDeps(ctx DepsContext, deps Deps) Deps {
+	deps.HeaderLibs = append(deps.HeaderLibs, pch.Properties.Header_libs...)
+	deps.SharedLibs = append(deps.SharedLibs, pch.Properties.Shared_libs...)
+	deps.StaticLibs = append(deps.StaticLibs, pch.Properties.Static_libs...)
+
+	deps.SystemSharedLibs = pch.Properties.System_shared_libs
+	if deps.SystemSharedLibs == nil {
+		deps.SystemSharedLibs = append(deps.SystemSharedLibs, ctx.toolchain().DefaultSharedLibraries()...)
+	}
+	deps.LateSharedLibs = append(deps.LateSharedLibs, deps.SystemSharedLibs...)
+	return deps
+}
+
+func (pch *pchLinker) linkerFlags(ctx ModuleContext, flags Flags) Flags {
+	return flags
+}
+
+func (pch *pchLinker) link(ctx ModuleContext,
+	flags Flags, deps PathDeps, objs Objects) android.Path {
+
+	if String(pch.Properties.Header) == "" {
+		ctx.PropertyErrorf("header", "missing header to precompile")
+		return nil
+	}
+	if len(objs.objFiles) > 0 {
+		ctx.PropertyErrorf("srcs", "cc_pch modules do not compile sources")
+		return nil
+	}
+	header := android.PathForModuleSrc(ctx, String(pch.Properties.Header))
+
+	// Key the precompiled header by the clang version, so that switching versions back and forth
+	// doesn't rebuild it every time.
+	toolchain := pchToolchain(ctx, flags)
+	outputFile := android.PathForModuleOut(ctx, "pch", toolchain, header.Base()+".pch")
+
+	pathDeps := append(deps.GeneratedDeps, ndkPathDeps(ctx)...)
+	transformHeaderToPch(ctx, header, outputFile, flagsToBuilderFlags(flags), pathDeps, flags.CFlagsDeps)
+
+	ctx.SetProvider(PrecompiledHeaderInfoProvider, PrecompiledHeaderInfo{
+		Pch:       outputFile,
+		Toolchain: toolchain,
+	})
+
+	ctx.CheckbuildFile(outputFile)
+	return outputFile
+}
+
+func (pch *pchLinker) linkerSpecifiedDeps(specifiedDeps specifiedDeps) specifiedDeps {
+	specifiedDeps.sharedLibs = append(specifiedDeps.sharedLibs, pch.Properties.Shared_libs...)
+
+	if specifiedDeps.systemSharedLibs == nil {
+		specifiedDeps.systemSharedLibs = pch.Properties.System_shared_libs
+	} else {
+		specifiedDeps.systemSharedLibs = append(specifiedDeps.systemSharedLibs, pch.Properties.System_shared_libs...)
+	}
+
+	return specifiedDeps
+}
+
+func (pch *pchLinker) unstrippedOutputFilePath() android.Path {
+	return nil
+}
+
+func (pch *pchLinker) nativeCoverage() bool {
+	return false
+}
+
+func (pch *pchLinker) coverageOutputFilePath() android.OptionalPath {
+	return android.OptionalPath{}
+}
+
+// pchToolchain returns the version of the clang that compiles the sources of the module.
+func pchToolchain(ctx ModuleContext, flags Flags) string {
+	if flags.Sdclang {
+		return "sdclang"
+	}
+	if flags.ClangVersion != "" {
+		return flags.ClangVersion
+	}
+	if version := ctx.Config().Getenv("LLVM_PREBUILTS_VERSION"); version != "" {
+		return version
+	}
+	return config.ClangDefaultVersion
+}
+
+// addPrecompiledHeaderFlags makes the C++ compiles of a module include the precompiled header of
+// the cc_pch module in its precompiled_header property.
+func addPrecompiledHeaderFlags(ctx ModuleContext, flags Flags, pch *PrecompiledHeaderInfo) Flags {
+	if toolchain := pchToolchain(ctx, flags); toolchain != pch.Toolchain {
+		ctx.PropertyErrorf("precompiled_header", "the precompiled header is built with %s, but this module is compiled with %s",
+			pch.Toolchain, toolchain)
+		return flags
+	}
+	flags.Local.CppFlags = append(flags.Local.CppFlags, "-include-pch "+pch.Pch.String())
+	flags.CFlagsDeps = append(flags.CFlagsDeps, pch.Pch)
+	return flags
+}
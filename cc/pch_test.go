@@ -0,0 +1,106 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"testing"
+
+	"android/soong/android"
+	"android/soong/cc/config"
+)
+
+var prepareForPchTest = android.GroupFixturePreparers(
+	prepareForCcTest,
+	android.MockFS{
+		"common.h": nil,
+		"foo.cpp":  nil,
+		"bar.c":    nil,
+	}.AddToFixture(),
+)
+
+func TestPrecompiledHeader(t *testing.T) {
+	t.Parallel()
+	result := prepareForPchTest.RunTestWithBp(t, `
+		cc_defaults {
+			name: "group_defaults",
+			cflags: ["-DGROUP"],
+		}
+
+		cc_pch {
+			name: "group_pch",
+			defaults: ["group_defaults"],
+			header: "common.h",
+		}
+
+		cc_library {
+			name: "libfoo",
+			defaults: ["group_defaults"],
+			srcs: ["foo.cpp", "bar.c"],
+			precompiled_header: "group_pch",
+		}
+	`)
+
+	pch := result.ModuleForTests("group_pch", "android_arm64_armv8-a").Output("pch/" + config.ClangDefaultVersion + "/common.h.pch")
+	android.AssertPathRelativeToTopEquals(t, "pch output",
+		"out/soong/.intermediates/group_pch/android_arm64_armv8-a/pch/"+config.ClangDefaultVersion+"/common.h.pch",
+		pch.Output)
+	android.AssertStringDoesContain(t, "pch cflags", pch.Args["cFlags"], "-x c++-header ")
+	android.AssertStringDoesContain(t, "pch cflags", pch.Args["cFlags"], "-DGROUP")
+
+	libfoo := result.ModuleForTests("libfoo", "android_arm64_armv8-a_shared")
+	cpp := libfoo.Output("obj/foo.o")
+	android.AssertStringDoesContain(t, "C++ cflags", cpp.Args["cFlags"], "-include-pch "+pch.Output.String())
+	android.AssertStringListContains(t, "C++ implicits", cpp.Implicits.Strings(), pch.Output.String())
+
+	c := libfoo.Output("obj/bar.o")
+	android.AssertStringDoesNotContain(t, "C cflags", c.Args["cFlags"], "-include-pch")
+}
+
+func TestPrecompiledHeaderErrors(t *testing.T) {
+	t.Parallel()
+	prepareForPchTest.
+		ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+			`precompiled_header: module "bar" is not a cc_pch module`)).
+		RunTestWithBp(t, `
+			cc_object {
+				name: "bar",
+				srcs: ["bar.c"],
+			}
+
+			cc_library {
+				name: "libfoo",
+				srcs: ["foo.cpp"],
+				precompiled_header: "bar",
+			}
+		`)
+
+	prepareForPchTest.
+		ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+			`precompiled_header: the precompiled header is built with `+config.ClangDefaultVersion+
+				`, but this module is compiled with clang-r498229`)).
+		RunTestWithBp(t, `
+			cc_pch {
+				name: "group_pch",
+				header: "common.h",
+			}
+
+			cc_library {
+				name: "libfoo",
+				srcs: ["foo.cpp"],
+				clang_version: "clang-r498229",
+				precompiled_header: "group_pch",
+			}
+		`)
+}
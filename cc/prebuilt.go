@@ -194,6 +194,7 @@ func (p *prebuiltLibraryLinker) link(ctx ModuleContext,
 				Implicits:   implicits,
 				Input:       in,
 				Output:      outputFile,
+				Validation:  p.checkPrebuiltElfFile(ctx, p.unstrippedOutputFile, deps),
 				Args: map[string]string{
 					"cpFlags": "-L",
 				},
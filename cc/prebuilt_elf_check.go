@@ -0,0 +1,75 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"strings"
+
+	"github.com/google/blueprint"
+
+	"android/soong/android"
+)
+
+// The prebuilt shared libraries imported for the device are checked when they are built, so that
+// a library of the wrong architecture, with segments aligned below the max page size supported by
+// the device or needing libraries that aren't dependencies of the module fails the build instead
+// of failing to load at runtime. The check is disabled by check_elf_files: false, or by listing
+// the module in the PrebuiltElfCheckAllowlist of the product.
+
+func init() {
+	pctx.HostBinToolVariable("checkPrebuiltElfCmd", "check_prebuilt_elf")
+}
+
+var (
+	checkPrebuiltElf = pctx.AndroidStaticRule("checkPrebuiltElf",
+		blueprint.RuleParams{
+			Command: "rm -f $out && " +
+				"${config.ClangBin}/llvm-readelf --file-headers --program-headers --dynamic-table $in > ${out}.readelf && " +
+				"$checkPrebuiltElfCmd -readelf ${out}.readelf -arch $arch -max-page-size '$maxPageSize' $sharedLibs && " +
+				"touch $out",
+			CommandDeps: []string{"${config.ClangBin}/llvm-readelf", "$checkPrebuiltElfCmd"},
+		},
+		"arch", "maxPageSize", "sharedLibs")
+)
+
+// checkPrebuiltElfFile returns the timestamp of the check of the ELF file of a prebuilt shared
+// library, or nil if it isn't checked. The NEEDED entries of the library must be provided by its
+// shared library dependencies, which are the libraries available to its partition.
+func (p *prebuiltLibraryLinker) checkPrebuiltElfFile(ctx ModuleContext, in android.Path, deps PathDeps) android.Path {
+	if !ctx.Device() || !BoolDefault(p.properties.Check_elf_files, true) ||
+		android.InList(ctx.ModuleName(), ctx.Config().PrebuiltElfCheckAllowlist()) {
+		return nil
+	}
+
+	var sharedLibs []string
+	for _, lib := range append(append(append(android.Paths(nil), deps.EarlySharedLibs...),
+		deps.SharedLibs...), deps.LateSharedLibs...) {
+		sharedLibs = append(sharedLibs, "-shared-lib "+lib.Base())
+	}
+
+	timestamp := android.PathForModuleOut(ctx, "check_elf", "check.timestamp")
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        checkPrebuiltElf,
+		Description: "check prebuilt ELF " + in.Base(),
+		Input:       in,
+		Output:      timestamp,
+		Args: map[string]string{
+			"arch":        ctx.Arch().ArchType.String(),
+			"maxPageSize": ctx.Config().MaxPageSizeSupported(),
+			"sharedLibs":  strings.Join(android.FirstUniqueStrings(sharedLibs), " "),
+		},
+	})
+	return timestamp
+}
@@ -22,6 +22,7 @@ import (
 	"android/soong/bazel/cquery"
 
 	"github.com/google/blueprint"
+	"github.com/google/blueprint/proptools"
 )
 
 var prepareForPrebuiltTest = android.GroupFixturePreparers(
@@ -260,6 +261,55 @@ func TestPrebuiltLibraryStem(t *testing.T) {
 	assertString(t, shared.OutputFile().Path().Base(), "libbar.so")
 }
 
+func TestPrebuiltLibrarySharedCheckElf(t *testing.T) {
+	ctx := testPrebuilt(t, `
+	cc_library_shared {
+		name: "libbar",
+	}
+
+	cc_prebuilt_library_shared {
+		name: "libfoo",
+		srcs: ["libfoo.so"],
+		shared_libs: ["libbar"],
+	}
+
+	cc_prebuilt_library_shared {
+		name: "libunchecked",
+		srcs: ["libunchecked.so"],
+		check_elf_files: false,
+	}
+
+	cc_prebuilt_library_shared {
+		name: "liballowed",
+		srcs: ["liballowed.so"],
+	}
+	`, map[string][]byte{
+		"libfoo.so":       nil,
+		"libunchecked.so": nil,
+		"liballowed.so":   nil,
+	}, android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+		variables.DeviceMaxPageSizeSupported = proptools.StringPtr("16384")
+		variables.PrebuiltElfCheckAllowlist = []string{"liballowed"}
+	}))
+
+	libfoo := ctx.ModuleForTests("libfoo", "android_arm64_armv8-a_shared")
+	check := libfoo.Rule("checkPrebuiltElf")
+	android.AssertStringEquals(t, "check input", "libfoo.so", check.Input.String())
+	android.AssertStringEquals(t, "arch", "arm64", check.Args["arch"])
+	android.AssertStringEquals(t, "max page size", "16384", check.Args["maxPageSize"])
+	android.AssertStringDoesContain(t, "shared libs", check.Args["sharedLibs"], "-shared-lib libbar.so")
+	android.AssertStringDoesContain(t, "shared libs", check.Args["sharedLibs"], "-shared-lib libc.so")
+	cp := libfoo.Output("libfoo.so")
+	android.AssertStringEquals(t, "validation", check.Output.String(), cp.Validation.String())
+
+	for _, name := range []string{"libunchecked", "liballowed"} {
+		module := ctx.ModuleForTests(name, "android_arm64_armv8-a_shared")
+		if check := module.MaybeRule("checkPrebuiltElf"); check.Rule != nil {
+			t.Errorf("expected no ELF check for %s", name)
+		}
+	}
+}
+
 func TestPrebuiltLibrarySharedStem(t *testing.T) {
 	ctx := testPrebuilt(t, `
 	cc_prebuilt_library_shared {
@@ -0,0 +1,86 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"android/soong/android"
+)
+
+// Propeller is a post-link optimization that lays out the basic blocks and functions of a binary
+// from a profile of its hot paths. It takes two steps:
+//  1. Add `propeller: true` to the binary or shared library so that it is built with basic block
+//     address maps (-fbasic-block-sections=labels), then collect a Propeller profile from it.
+//  2. Convert the profile to a compiler profile and a linker profile, and list them for the module
+//     in the PropellerProfiles product variable (PROPELLER_PROFILES), in the
+//     <module>:<cc-profile>:<ld-profile> format. The module is then compiled with the basic block
+//     clusters of the compiler profile and linked with the symbol ordering of the linker profile.
+//
+// Only the sources of the module itself are compiled with the compiler profile; its static
+// dependencies still benefit from the symbol ordering at link time.
+
+const (
+	propellerLabelsCFlag          = "-fbasic-block-sections=labels"
+	propellerCCProfileCFlagPrefix = "-fbasic-block-sections=list="
+	propellerLdProfileFlagPrefix  = "-Wl,--symbol-ordering-file="
+	propellerNoWarnLdFlag         = "-Wl,--no-warn-symbol-ordering"
+)
+
+type PropellerProperties struct {
+	// Build with basic block address maps so that Propeller profiles can be collected for this
+	// binary or shared library. It is optimized with the Propeller profiles listed for it in the
+	// PropellerProfiles product variable, whether or not this is set.
+	Propeller bool
+}
+
+type propeller struct {
+	Properties PropellerProperties
+}
+
+func (propeller *propeller) props() []interface{} {
+	return []interface{}{&propeller.Properties}
+}
+
+func (propeller *propeller) flags(ctx ModuleContext, flags Flags) Flags {
+	// Like AutoFDO, Propeller only applies to the modules that are linked: binaries and shared
+	// libraries built for the device.
+	if ctx.Host() || (ctx.static() && !ctx.staticBinary()) {
+		return flags
+	}
+
+	ccProfile, ldProfile, err := ctx.DeviceConfig().PropellerProfile(ctx.ModuleName())
+	if err != nil {
+		ctx.ModuleErrorf("%s", err.Error())
+		return flags
+	}
+
+	if ccProfile != "" {
+		ccProfilePath := android.PathForSource(ctx, ccProfile)
+		ldProfilePath := android.PathForSource(ctx, ldProfile)
+
+		// The flags are prepended to allow overriding.
+		flags.Local.CFlags = append([]string{propellerCCProfileCFlagPrefix + ccProfilePath.String()},
+			flags.Local.CFlags...)
+		flags.Local.LdFlags = append([]string{propellerLdProfileFlagPrefix + ldProfilePath.String(),
+			propellerNoWarnLdFlag}, flags.Local.LdFlags...)
+
+		// Rebuild the module when the profiles are updated.
+		flags.CFlagsDeps = append(flags.CFlagsDeps, ccProfilePath)
+		flags.LdFlagsDeps = append(flags.LdFlagsDeps, ldProfilePath)
+	} else if propeller.Properties.Propeller {
+		flags.Local.CFlags = append([]string{propellerLabelsCFlag}, flags.Local.CFlags...)
+	}
+
+	return flags
+}
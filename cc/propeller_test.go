@@ -0,0 +1,72 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestPropeller(t *testing.T) {
+	t.Parallel()
+	bp := `
+	cc_binary {
+		name: "hot_binary",
+		srcs: ["hot.c"],
+	}
+
+	cc_library_shared {
+		name: "libprofiling",
+		srcs: ["profiling.c"],
+		propeller: true,
+	}
+
+	cc_library_static {
+		name: "libstatic",
+		srcs: ["static.c"],
+		propeller: true,
+	}
+	`
+
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.PropellerProfiles = []string{
+				"hot_binary:propeller/hot_binary_cc.txt:propeller/hot_binary_ld.txt",
+			}
+		}),
+		android.MockFS{
+			"propeller/hot_binary_cc.txt": nil,
+			"propeller/hot_binary_ld.txt": nil,
+		}.AddToFixture(),
+	).RunTestWithBp(t, bp)
+
+	hotBinary := result.ModuleForTests("hot_binary", "android_arm64_armv8-a")
+	android.AssertStringDoesContain(t, "hot_binary cflags", hotBinary.Rule("cc").Args["cFlags"],
+		"-fbasic-block-sections=list=propeller/hot_binary_cc.txt")
+	android.AssertStringDoesContain(t, "hot_binary ldflags", hotBinary.Rule("ld").Args["ldFlags"],
+		"-Wl,--symbol-ordering-file=propeller/hot_binary_ld.txt")
+
+	libProfiling := result.ModuleForTests("libprofiling", "android_arm64_armv8-a_shared")
+	android.AssertStringDoesContain(t, "libprofiling cflags", libProfiling.Rule("cc").Args["cFlags"],
+		"-fbasic-block-sections=labels")
+	android.AssertStringDoesNotContain(t, "libprofiling ldflags", libProfiling.Rule("ld").Args["ldFlags"],
+		"--symbol-ordering-file")
+
+	libStatic := result.ModuleForTests("libstatic", "android_arm64_armv8-a_static")
+	android.AssertStringDoesNotContain(t, "libstatic cflags", libStatic.Rule("cc").Args["cFlags"],
+		"-fbasic-block-sections")
+}
@@ -95,7 +95,7 @@ const (
 	Hwasan
 	tsan
 	intOverflow
-	scs
+	Scs
 	Fuzzer
 	Memtag_heap
 	Memtag_stack
@@ -107,7 +107,7 @@ var Sanitizers = []SanitizerType{
 	Hwasan,
 	tsan,
 	intOverflow,
-	scs,
+	Scs,
 	Fuzzer,
 	Memtag_heap,
 	Memtag_stack,
@@ -127,7 +127,7 @@ func (t SanitizerType) variationName() string {
 		return "intOverflow"
 	case cfi:
 		return "cfi"
-	case scs:
+	case Scs:
 		return "scs"
 	case Memtag_heap:
 		return "memtag_heap"
@@ -157,7 +157,7 @@ func (t SanitizerType) name() string {
 		return "integer_overflow"
 	case cfi:
 		return "cfi"
-	case scs:
+	case Scs:
 		return "shadow-call-stack"
 	case Fuzzer:
 		return "fuzzer"
@@ -168,7 +168,7 @@ func (t SanitizerType) name() string {
 
 func (t SanitizerType) registerMutators(ctx android.RegisterMutatorsContext) {
 	switch t {
-	case cfi, Hwasan, Asan, tsan, Fuzzer, scs:
+	case cfi, Hwasan, Asan, tsan, Fuzzer, Scs:
 		sanitizer := &sanitizerSplitMutator{t}
 		ctx.TopDown(t.variationName()+"_markapexes", sanitizer.markSanitizableApexesMutator)
 		ctx.Transition(t.variationName(), sanitizer)
@@ -205,7 +205,7 @@ func (*Module) SanitizerSupported(t SanitizerType) bool {
 		return true
 	case cfi:
 		return true
-	case scs:
+	case Scs:
 		return true
 	case Fuzzer:
 		return true
@@ -372,6 +372,7 @@ type SanitizeProperties struct {
 	BuiltinsDep       bool     `blueprint:"mutated"`
 	UbsanRuntimeDep   bool     `blueprint:"mutated"`
 	InSanitizerDir    bool     `blueprint:"mutated"`
+	SanitizedHostTool bool     `blueprint:"mutated"`
 	Sanitizers        []string `blueprint:"mutated"`
 	DiagSanitizers    []string `blueprint:"mutated"`
 }
@@ -478,6 +479,13 @@ func (sanitize *sanitize) begin(ctx BaseModuleContext) {
 	if ctx.Host() {
 		if !ctx.Windows() {
 			globalSanitizers = ctx.Config().SanitizeHost()
+			if sanitize.Properties.SanitizedHostTool {
+				globalSanitizers = android.FirstUniqueStrings(append(globalSanitizers,
+					ctx.Config().SanitizeHostToolsSanitizers()...))
+				// Install into a separate directory so that the sanitized tool doesn't
+				// replace the regular one.
+				sanitize.Properties.InSanitizerDir = true
+			}
 		}
 	} else {
 		arches := ctx.Config().SanitizeDeviceArch()
@@ -989,7 +997,7 @@ func (s *sanitize) getSanitizerBoolPtr(t SanitizerType) *bool {
 		return s.Properties.SanitizeMutated.Integer_overflow
 	case cfi:
 		return s.Properties.SanitizeMutated.Cfi
-	case scs:
+	case Scs:
 		return s.Properties.SanitizeMutated.Scs
 	case Memtag_heap:
 		return s.Properties.SanitizeMutated.Memtag_heap
@@ -1008,7 +1016,7 @@ func (sanitize *sanitize) isUnsanitizedVariant() bool {
 		!sanitize.isSanitizerEnabled(Hwasan) &&
 		!sanitize.isSanitizerEnabled(tsan) &&
 		!sanitize.isSanitizerEnabled(cfi) &&
-		!sanitize.isSanitizerEnabled(scs) &&
+		!sanitize.isSanitizerEnabled(Scs) &&
 		!sanitize.isSanitizerEnabled(Memtag_heap) &&
 		!sanitize.isSanitizerEnabled(Memtag_stack) &&
 		!sanitize.isSanitizerEnabled(Fuzzer)
@@ -1042,7 +1050,7 @@ func (sanitize *sanitize) SetSanitizer(t SanitizerType, b bool) {
 		sanitize.Properties.SanitizeMutated.Integer_overflow = bPtr
 	case cfi:
 		sanitize.Properties.SanitizeMutated.Cfi = bPtr
-	case scs:
+	case Scs:
 		sanitize.Properties.SanitizeMutated.Scs = bPtr
 	case Memtag_heap:
 		sanitize.Properties.SanitizeMutated.Memtag_heap = bPtr
@@ -1158,6 +1166,42 @@ func (s *sanitizerSplitMutator) markSanitizableApexesMutator(ctx android.TopDown
 	}
 }
 
+// The host tools selected with SanitizeHostTools are built twice: the regular variant is used by the
+// other modules and installed as usual, and the sanitized variant is built with the sanitizers of
+// SanitizeHostToolsSanitizers and installed in the sanitized directory, e.g.
+// out/host/linux-x86/sanitized/bin. The sanitized variant is exported to Make with the
+// ".sanitized" suffix.
+type sanitizedHostToolTransitionMutator struct{}
+
+const sanitizedHostToolVariation = "sanitized"
+
+var _ android.TransitionMutator = (*sanitizedHostToolTransitionMutator)(nil)
+
+func (s *sanitizedHostToolTransitionMutator) Split(ctx android.BaseModuleContext) []string {
+	if c, ok := ctx.Module().(*Module); ok && c.sanitize != nil && c.Binary() &&
+		ctx.Host() && !ctx.Windows() && ctx.Config().SanitizeHostTool(ctx.ModuleName()) {
+		return []string{"", sanitizedHostToolVariation}
+	}
+	return []string{""}
+}
+
+func (s *sanitizedHostToolTransitionMutator) OutgoingTransition(ctx android.OutgoingTransitionContext, sourceVariation string) string {
+	// The dependencies of the sanitized variant get their sanitized variants from the
+	// sanitizer mutators.
+	return ""
+}
+
+func (s *sanitizedHostToolTransitionMutator) IncomingTransition(ctx android.IncomingTransitionContext, incomingVariation string) string {
+	// The other modules always use the regular variant of the tool.
+	return ""
+}
+
+func (s *sanitizedHostToolTransitionMutator) Mutate(ctx android.BottomUpMutatorContext, variation string) {
+	if variation == sanitizedHostToolVariation {
+		ctx.Module().(*Module).sanitize.Properties.SanitizedHostTool = true
+	}
+}
+
 func (s *sanitizerSplitMutator) Split(ctx android.BaseModuleContext) []string {
 	if c, ok := ctx.Module().(PlatformSanitizeable); ok && c.SanitizePropDefined() {
 		if s.sanitizer == cfi && needsCfiForVendorSnapshot(ctx) {
@@ -1300,7 +1344,7 @@ func (s *sanitizerSplitMutator) Mutate(mctx android.BottomUpMutatorContext, vari
 
 		oneMakeVariation := false
 		if c.StaticallyLinked() || c.Header() {
-			if s.sanitizer != cfi && s.sanitizer != scs && s.sanitizer != Hwasan {
+			if s.sanitizer != cfi && s.sanitizer != Scs && s.sanitizer != Hwasan {
 				// These sanitizers export only one variation to Make. For the rest,
 				// Make targets can depend on both the sanitized and non-sanitized
 				// versions.
@@ -1310,7 +1354,7 @@ func (s *sanitizerSplitMutator) Mutate(mctx android.BottomUpMutatorContext, vari
 			// Shared library. These are the sanitizers that do propagate through shared
 			// library dependencies and therefore can cause multiple variations of a
 			// shared library to be built.
-			if s.sanitizer != cfi && s.sanitizer != Hwasan && s.sanitizer != scs && s.sanitizer != Asan {
+			if s.sanitizer != cfi && s.sanitizer != Hwasan && s.sanitizer != Scs && s.sanitizer != Asan {
 				oneMakeVariation = true
 			}
 		}
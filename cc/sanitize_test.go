@@ -328,6 +328,42 @@ func TestAsan(t *testing.T) {
 	})
 }
 
+func TestSanitizeHostTools(t *testing.T) {
+	t.Parallel()
+	bp := `
+		cc_binary_host {
+			name: "tool",
+		}
+	`
+
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		prepareForAsanTest,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.SanitizeHostTools = []string{"tool"}
+		}),
+	).RunTestWithBp(t, bp)
+
+	buildOS := result.Config.BuildOSTarget.String()
+	regular := result.ModuleForTests("tool", buildOS)
+	sanitized := result.ModuleForTests("tool", buildOS+"_sanitized_asan")
+
+	android.AssertPathRelativeToTopEquals(t, "regular tool install path",
+		"out/soong/host/linux-x86/bin/tool", regular.Description("install").Output)
+	android.AssertPathRelativeToTopEquals(t, "sanitized tool install path",
+		"out/soong/host/linux-x86/sanitized/bin/tool", sanitized.Description("install").Output)
+
+	android.AssertStringDoesNotContain(t, "regular tool ldflags",
+		regular.Description("link").Args["ldFlags"], "-fsanitize=address")
+	android.AssertStringDoesContain(t, "sanitized tool ldflags",
+		sanitized.Description("link").Args["ldFlags"], "-fsanitize=address")
+
+	android.AssertStringEquals(t, "regular tool Make name suffix",
+		"", regular.Module().(*Module).Properties.SubName)
+	android.AssertStringEquals(t, "sanitized tool Make name suffix",
+		".sanitized", sanitized.Module().(*Module).Properties.SubName)
+}
+
 func TestTsan(t *testing.T) {
 	t.Parallel()
 	bp := `
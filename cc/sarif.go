@@ -0,0 +1,123 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"github.com/google/blueprint"
+
+	"android/soong/android"
+)
+
+// This file implements the export of the static analysis diagnostics of C and C++ sources as
+// SARIF, which code scanning UIs import. When clang-tidy runs, or when SDCLANG_SA_ENABLED is set
+// and the sources of SDClang modules are analyzed by its static analyzer, the diagnostics of each
+// module variant are merged into out/soong/analysis/<dir>/<module>/<variant>.sarif, and those of
+// all the modules into out/soong/analysis/static_analysis.sarif, which `m sarif` builds. The
+// StaticAnalysisSarifPaths and StaticAnalysisSarifExcludePaths of the product select the modules
+// and the results.
+
+func init() {
+	pctx.HostBinToolVariable("mergeSarifCmd", "merge_sarif")
+
+	android.RegisterSingletonType("static_analysis_sarif", sarifSingletonFactory)
+}
+
+var (
+	// Rule to run the static analyzer of the clang of the module on a source file. Its findings
+	// are warnings, which don't fail the build.
+	clangAnalyzer = pctx.AndroidStaticRule("clangAnalyzer",
+		blueprint.RuleParams{
+			Command:     "$ccCmd --analyze --analyzer-output sarif $cFlags -Wno-error -o $out $in",
+			CommandDeps: []string{"$ccCmd"},
+		},
+		"ccCmd", "cFlags")
+
+	mergeSarif = pctx.AndroidStaticRule("mergeSarif",
+		blueprint.RuleParams{
+			Command:     "$mergeSarifCmd -o $out $in",
+			CommandDeps: []string{"$mergeSarifCmd"},
+		})
+)
+
+// useSarif returns true if the module is in the StaticAnalysisSarifPaths of the product, or if
+// there are none, and not in its StaticAnalysisSarifExcludePaths.
+func useSarif(ctx ModuleContext) bool {
+	subdir := ctx.ModuleDir() + "/"
+	included := 0
+	if paths := ctx.Config().StaticAnalysisSarifPaths(); len(paths) > 0 {
+		included = longestMatchingDir(subdir, paths)
+	}
+	return included >= 0 && included > longestMatchingDir(subdir, ctx.Config().StaticAnalysisSarifExcludePaths())
+}
+
+// tidyLogRedirect returns the end of a clang-tidy command that writes its output to the log file
+// as well as printing it.
+func tidyLogRedirect(log android.WritablePath) string {
+	return " > " + log.String() + " 2>&1 && cat " + log.String() + " || (cat " + log.String() + " && false)"
+}
+
+// mergeModuleSarif merges the clang-tidy outputs and the static analyzer SARIF files of the
+// sources of a module variant into its SARIF file.
+func mergeModuleSarif(ctx ModuleContext, sarifFiles android.Paths) android.Path {
+	outputFile := android.PathForOutput(ctx, "analysis", ctx.ModuleDir(), ctx.ModuleName(), ctx.ModuleSubDir()+".sarif")
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        mergeSarif,
+		Description: "merge SARIF " + ctx.ModuleName(),
+		Output:      outputFile,
+		Inputs:      sarifFiles,
+	})
+	return outputFile
+}
+
+func sarifSingletonFactory() android.Singleton {
+	return &sarifSingleton{}
+}
+
+type sarifSingleton struct {
+	sarifFile android.OptionalPath
+}
+
+// GenerateBuildActions merges the SARIF files of all the module variants, keeping the results in
+// the StaticAnalysisSarifPaths of the product that aren't in its StaticAnalysisSarifExcludePaths.
+func (s *sarifSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	var sarifFiles android.Paths
+	ctx.VisitAllModules(func(module android.Module) {
+		if m, ok := module.(*Module); ok && m.sarifFile.Valid() && m.Enabled() {
+			sarifFiles = append(sarifFiles, m.sarifFile.Path())
+		}
+	})
+	if len(sarifFiles) == 0 {
+		return
+	}
+
+	sarifFile := android.PathForOutput(ctx, "analysis", "static_analysis.sarif")
+	rule := android.NewRuleBuilder(pctx, ctx)
+	rule.Command().
+		BuiltTool("merge_sarif").
+		FlagWithOutput("-o ", sarifFile).
+		FlagForEachArg("-include ", ctx.Config().StaticAnalysisSarifPaths()).
+		FlagForEachArg("-exclude ", ctx.Config().StaticAnalysisSarifExcludePaths()).
+		FlagWithRspFileInputList("@", android.PathForOutput(ctx, "analysis", "static_analysis.sarif.rsp"), sarifFiles)
+	rule.Build("static_analysis_sarif", "merge static analysis SARIF files")
+
+	ctx.Phony("sarif", sarifFile)
+	s.sarifFile = android.OptionalPathForPath(sarifFile)
+}
+
+func (s *sarifSingleton) MakeVars(ctx android.MakeVarsContext) {
+	if s.sarifFile.Valid() {
+		ctx.DistForGoal("sarif", s.sarifFile.Path())
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"testing"
+
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+)
+
+func TestStaticAnalysisSarif(t *testing.T) {
+	t.Parallel()
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureMergeEnv(map[string]string{"SDCLANG_SA_ENABLED": "true"}),
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.SdclangPath = proptools.StringPtr("vendor/qcom/sdclang/bin")
+			variables.SdclangPaths = []string{"vendor/qcom"}
+			variables.StaticAnalysisSarifPaths = []string{"vendor/qcom"}
+			variables.StaticAnalysisSarifExcludePaths = []string{"vendor/qcom/legacy"}
+		}),
+		android.FixtureAddTextFile("vendor/qcom/Android.bp", `
+			cc_library_shared {
+				name: "libqcom",
+				srcs: ["foo.c", "asm.S"],
+				tidy: true,
+			}
+		`),
+		android.FixtureAddTextFile("vendor/qcom/legacy/Android.bp", `
+			cc_library_shared {
+				name: "liblegacy",
+				srcs: ["foo.c"],
+				tidy: true,
+			}
+		`),
+		android.MockFS{
+			"vendor/qcom/foo.c":        nil,
+			"vendor/qcom/asm.S":        nil,
+			"vendor/qcom/legacy/foo.c": nil,
+		}.AddToFixture(),
+	).RunTest(t)
+
+	libqcom := result.ModuleForTests("libqcom", "android_arm64_armv8-a_shared")
+	intermediates := "out/soong/.intermediates/vendor/qcom/libqcom/android_arm64_armv8-a_shared/"
+
+	tidy := libqcom.Output("obj/foo.tidy")
+	android.AssertPathsRelativeToTopEquals(t, "libqcom tidy implicit outputs",
+		[]string{intermediates + "obj/foo.tidy.log"}, tidy.ImplicitOutputs.Paths())
+	android.AssertStringDoesContain(t, "libqcom tidy log", tidy.Args["tidyLog"], "obj/foo.tidy.log")
+
+	analyzer := libqcom.Rule("clangAnalyzer")
+	android.AssertStringEquals(t, "libqcom analyzer clang", "${config.SdclangBin}/clang", analyzer.Args["ccCmd"])
+	android.AssertPathRelativeToTopEquals(t, "libqcom analyzer output", intermediates+"obj/foo.sarif", analyzer.Output)
+	if libqcom.MaybeOutput("obj/asm.sarif").Rule != nil {
+		t.Errorf("expected no static analysis of assembly sources")
+	}
+
+	merge := libqcom.Rule("mergeSarif")
+	android.AssertPathRelativeToTopEquals(t, "libqcom SARIF file",
+		"out/soong/analysis/vendor/qcom/libqcom/android_arm64_armv8-a_shared.sarif", merge.Output)
+	android.AssertPathsRelativeToTopEquals(t, "libqcom SARIF inputs",
+		[]string{intermediates + "obj/foo.tidy.log", intermediates + "obj/foo.sarif"}, merge.Inputs)
+
+	liblegacy := result.ModuleForTests("liblegacy", "android_arm64_armv8-a_shared")
+	if liblegacy.MaybeRule("mergeSarif").Rule != nil {
+		t.Errorf("expected no SARIF file for a module in StaticAnalysisSarifExcludePaths")
+	}
+	android.AssertStringEquals(t, "liblegacy tidy log", "", liblegacy.Output("obj/foo.tidy").Args["tidyLog"])
+}
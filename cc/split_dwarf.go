@@ -0,0 +1,115 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"path/filepath"
+
+	"github.com/google/blueprint"
+
+	"android/soong/android"
+)
+
+// This file implements split debug info (DWARF fission). The C and C++ sources of the modules in
+// the SplitDwarfPaths of the product are compiled with -gsplit-dwarf, which moves most of their
+// debug info out of the object files into .dwo files, so that the linker has less to process.
+// The .dwo files of each binary and shared library are packaged into a .dwp file for the symbols.
+
+func init() {
+	android.RegisterSingletonType("split_dwarf", splitDwarfSingletonFactory)
+}
+
+var (
+	// Rule to package the .dwo files referenced by the skeleton debug info of a linked binary or
+	// shared library into a .dwp file. The .dwo files are outputs of the compiles that produced
+	// its objects, so they are up to date once it is linked.
+	dwp = pctx.AndroidStaticRule("dwp",
+		blueprint.RuleParams{
+			Command:     "${config.ClangBin}/llvm-dwp -e $in -o $out",
+			CommandDeps: []string{"${config.ClangBin}/llvm-dwp"},
+		})
+)
+
+// useSplitDwarf returns true if the module is a device module in the SplitDwarfPaths of the
+// product that isn't in its SplitDwarfExcludePaths. Modules built with LTO keep their debug info
+// in their outputs, as it is only generated by the linker.
+func useSplitDwarf(ctx ModuleContext, c *Module) bool {
+	if !ctx.Device() || (c.lto != nil && c.lto.LTO(ctx)) {
+		return false
+	}
+	subdir := ctx.ModuleDir() + "/"
+	included := longestMatchingDir(subdir, ctx.Config().SplitDwarfPaths())
+	return included >= 0 && included > longestMatchingDir(subdir, ctx.Config().SplitDwarfExcludePaths())
+}
+
+// transformDwoToDwp packages the .dwo files of the unstripped output of a module into a .dwp file.
+func transformDwoToDwp(ctx ModuleContext, unstripped android.Path) android.Path {
+	outputFile := android.PathForModuleOut(ctx, "dwp", unstripped.Base()+".dwp")
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        dwp,
+		Description: "llvm-dwp " + outputFile.Base(),
+		Output:      outputFile,
+		Input:       unstripped,
+	})
+	return outputFile
+}
+
+func splitDwarfSingletonFactory() android.Singleton {
+	return &splitDwarfSingleton{}
+}
+
+type splitDwarfSingleton struct {
+	dwpZip android.OptionalPath
+}
+
+// GenerateBuildActions packages the .dwp files of the installed modules into a zip file, where
+// each of them is next to the path of its module in the symbols directory.
+func (s *splitDwarfSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	dwpFiles := make(map[string]android.Path)
+	ctx.VisitAllModules(func(module android.Module) {
+		m, ok := module.(*Module)
+		if !ok || !m.dwpFile.Valid() || !m.Enabled() {
+			return
+		}
+		for _, ps := range m.PackagingSpecs() {
+			if ps.FileName() == m.outputFile.Path().Base() {
+				dwpFiles[filepath.Join(ps.Partition(), ps.RelPathInPackage())+".dwp"] = m.dwpFile.Path()
+				break
+			}
+		}
+	})
+	if len(dwpFiles) == 0 {
+		return
+	}
+
+	dwpZip := android.PathForOutput(ctx, "split-dwarf", "dwp.zip")
+	rule := android.NewRuleBuilder(pctx, ctx)
+	cmd := rule.Command().
+		BuiltTool("soong_zip").
+		FlagWithOutput("-o ", dwpZip)
+	for _, entry := range android.SortedKeys(dwpFiles) {
+		cmd.FlagWithArg("-e ", entry).FlagWithInput("-f ", dwpFiles[entry])
+	}
+	rule.Build("split_dwarf_dwp_zip", "split debug info dwp.zip")
+
+	ctx.Phony("dwp", dwpZip)
+	s.dwpZip = android.OptionalPathForPath(dwpZip)
+}
+
+func (s *splitDwarfSingleton) MakeVars(ctx android.MakeVarsContext) {
+	if s.dwpZip.Valid() {
+		ctx.DistForGoals([]string{"droidcore", "dwp"}, s.dwpZip.Path())
+	}
+}
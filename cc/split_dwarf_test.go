@@ -0,0 +1,80 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestSplitDwarf(t *testing.T) {
+	t.Parallel()
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.SplitDwarfPaths = []string{"vendor/foo"}
+			variables.SplitDwarfExcludePaths = []string{"vendor/foo/legacy"}
+		}),
+		android.FixtureAddTextFile("vendor/foo/Android.bp", `
+			cc_library {
+				name: "libfoo",
+				srcs: ["foo.c", "asm.S"],
+				host_supported: true,
+			}
+		`),
+		android.FixtureAddTextFile("vendor/foo/legacy/Android.bp", `
+			cc_library {
+				name: "liblegacy",
+				srcs: ["foo.c"],
+			}
+		`),
+		android.MockFS{
+			"vendor/foo/foo.c":        nil,
+			"vendor/foo/asm.S":        nil,
+			"vendor/foo/legacy/foo.c": nil,
+		}.AddToFixture(),
+	).RunTest(t)
+
+	libfoo := result.ModuleForTests("libfoo", "android_arm64_armv8-a_shared")
+	obj := libfoo.Output("obj/foo.o")
+	android.AssertStringDoesContain(t, "libfoo cflags", obj.Args["cFlags"], "-gsplit-dwarf")
+	android.AssertPathsRelativeToTopEquals(t, "libfoo implicit outputs",
+		[]string{"out/soong/.intermediates/vendor/foo/libfoo/android_arm64_armv8-a_shared/obj/foo.dwo"},
+		obj.ImplicitOutputs.Paths())
+	asm := libfoo.Output("obj/asm.o")
+	android.AssertIntEquals(t, "libfoo assembly implicit outputs", 0, len(asm.ImplicitOutputs))
+
+	dwp := libfoo.Rule("dwp")
+	android.AssertPathRelativeToTopEquals(t, "libfoo dwp output",
+		"out/soong/.intermediates/vendor/foo/libfoo/android_arm64_armv8-a_shared/dwp/libfoo.so.dwp",
+		dwp.Output)
+	android.AssertPathRelativeToTopEquals(t, "libfoo dwp input",
+		"out/soong/.intermediates/vendor/foo/libfoo/android_arm64_armv8-a_shared/unstripped/libfoo.so",
+		dwp.Input)
+
+	libfooStatic := result.ModuleForTests("libfoo", "android_arm64_armv8-a_static")
+	staticObj := libfooStatic.Output("obj/foo.o")
+	android.AssertStringDoesContain(t, "libfoo static cflags", staticObj.Args["cFlags"], "-gsplit-dwarf")
+	if libfooStatic.MaybeRule("dwp").Rule != nil {
+		t.Errorf("expected no dwp rule for the static library")
+	}
+
+	hostObj := result.ModuleForTests("libfoo", result.Config.BuildOSTarget.String()+"_shared").Output("obj/foo.o")
+	android.AssertStringDoesNotContain(t, "libfoo host cflags", hostObj.Args["cFlags"], "-gsplit-dwarf")
+
+	legacyObj := result.ModuleForTests("liblegacy", "android_arm64_armv8-a_shared").Output("obj/foo.o")
+	android.AssertStringDoesNotContain(t, "liblegacy cflags", legacyObj.Args["cFlags"], "-gsplit-dwarf")
+}
@@ -0,0 +1,115 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"github.com/google/blueprint"
+
+	"android/soong/android"
+)
+
+// This file implements the ABI monitoring of the NDK and LLNDK libraries with STG. When the
+// StgAbiMonitoring product variable is set, the ABI of each of these libraries is extracted from
+// the debug info of its unstripped output into out/soong/abi/<arch>/<lib>.stg, and compared with
+// the reference <StgAbiReferenceDir>/<arch>/<lib>.stg checked in the tree, if there is one.
+// Incompatible changes fail the build when StgAbiMonitoring is "enforce", and are only reported
+// when it is "warn". `m stg-abi` builds the ABI dumps and checks of all the libraries.
+
+func init() {
+	pctx.HostBinToolVariable("stg", "stg")
+	pctx.HostBinToolVariable("stgdiff", "stgdiff")
+}
+
+var (
+	stgAbiDump = pctx.AndroidStaticRule("stgAbiDump",
+		blueprint.RuleParams{
+			Command:     "$stg --elf $in --output $out",
+			CommandDeps: []string{"$stg"},
+		})
+
+	// The report of stgdiff is only printed if the ABI changed, followed by the way to accept the
+	// change, and $onDiff decides whether the change fails the build.
+	stgAbiDiff = pctx.AndroidStaticRule("stgAbiDiff",
+		blueprint.RuleParams{
+			Command: "rm -f $out && " +
+				"if ! $stgdiff --stg $reference $in --format small --output $out.report; then " +
+				"echo \"ABI of $libName differs from $reference:\" && cat $out.report && " +
+				"echo \"To accept the change, run: cp $in $reference\" && $onDiff; " +
+				"fi && touch $out",
+			CommandDeps: []string{"$stgdiff"},
+		}, "reference", "libName", "onDiff")
+)
+
+// shouldCreateStgAbiDump returns true if the ABI of the library is monitored with STG, which is
+// the case of the platform variants of the implementation libraries of the NDK and LLNDK when
+// StgAbiMonitoring is set. The other variants are built from the same sources.
+func shouldCreateStgAbiDump(ctx ModuleContext, library *libraryDecorator) bool {
+	if ctx.DeviceConfig().StgAbiMonitoring() == "" || !ctx.Device() || !ctx.isForPlatform() {
+		return false
+	}
+	m := ctx.Module().(*Module)
+	if library.buildStubs() || m.IsSdkVariant() || ctx.useVndk() || m.Target().NativeBridge == android.NativeBridgeEnabled {
+		return false
+	}
+	if ctx.inRamdisk() || ctx.inVendorRamdisk() || ctx.inRecovery() {
+		return false
+	}
+	// Coverage and sanitizer variants have extra symbols.
+	if m.isCoverageVariant() || (m.sanitize != nil && !m.sanitize.isVariantOnProductionDevice()) {
+		return false
+	}
+	return ctx.isNdk(ctx.Config()) || ctx.isImplementationForLLNDKPublic()
+}
+
+// stgAbiDumpAndDiff dumps the ABI of the shared library with STG and compares it with its
+// reference, returning the timestamp of the comparison if there is a reference.
+func (library *libraryDecorator) stgAbiDumpAndDiff(ctx ModuleContext, fileName string) android.OptionalPath {
+	if !shouldCreateStgAbiDump(ctx, library) {
+		return android.OptionalPath{}
+	}
+	arch := ctx.Arch().ArchType.String()
+	stgFile := android.PathForOutput(ctx, "abi", arch, fileName+".stg")
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        stgAbiDump,
+		Description: "stg " + fileName,
+		Output:      stgFile,
+		Input:       library.unstrippedOutputFile,
+	})
+	ctx.Phony("stg-abi", stgFile)
+
+	reference := android.ExistentPathForSource(ctx, ctx.DeviceConfig().StgAbiReferenceDir(), arch, fileName+".stg")
+	if !reference.Valid() {
+		return android.OptionalPath{}
+	}
+	onDiff := "exit 1"
+	if ctx.DeviceConfig().StgAbiMonitoring() == "warn" {
+		onDiff = "true"
+	}
+	timestamp := android.PathForModuleOut(ctx, "stg", fileName+".stgdiff.timestamp")
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        stgAbiDiff,
+		Description: "stgdiff " + fileName,
+		Output:      timestamp,
+		Input:       stgFile,
+		Implicit:    reference.Path(),
+		Args: map[string]string{
+			"reference": reference.String(),
+			"libName":   fileName,
+			"onDiff":    onDiff,
+		},
+	})
+	ctx.Phony("stg-abi", timestamp)
+	return android.OptionalPathForPath(timestamp)
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestStgAbiMonitoring(t *testing.T) {
+	t.Parallel()
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.StgAbiMonitoring = StringPtr("warn")
+		}),
+		android.MockFS{
+			"prebuilts/abi-dumps/stg/arm64/libllndk.so.stg": nil,
+		}.AddToFixture(),
+	).RunTestWithBp(t, `
+		cc_library {
+			name: "libllndk",
+			srcs: ["foo.c"],
+			llndk: {
+				symbol_file: "libllndk.map.txt",
+			},
+		}
+
+		cc_library {
+			name: "libnoref",
+			srcs: ["foo.c"],
+			llndk: {
+				symbol_file: "libllndk.map.txt",
+			},
+		}
+
+		cc_library {
+			name: "libplatform",
+			srcs: ["foo.c"],
+		}
+	`)
+
+	libllndk := result.ModuleForTests("libllndk", "android_arm64_armv8-a_shared")
+	dump := libllndk.Output("out/soong/abi/arm64/libllndk.so.stg")
+	android.AssertPathRelativeToTopEquals(t, "libllndk stg input",
+		"out/soong/.intermediates/libllndk/android_arm64_armv8-a_shared/unstripped/libllndk.so", dump.Input)
+	diff := libllndk.Rule("stgAbiDiff")
+	android.AssertStringEquals(t, "libllndk stgdiff reference",
+		"prebuilts/abi-dumps/stg/arm64/libllndk.so.stg", diff.Args["reference"])
+	android.AssertStringEquals(t, "libllndk stgdiff in warn mode", "true", diff.Args["onDiff"])
+
+	libnoref := result.ModuleForTests("libnoref", "android_arm64_armv8-a_shared")
+	libnoref.Output("out/soong/abi/arm64/libnoref.so.stg")
+	if libnoref.MaybeRule("stgAbiDiff").Rule != nil {
+		t.Errorf("expected no stgdiff rule without a reference dump")
+	}
+
+	libplatform := result.ModuleForTests("libplatform", "android_arm64_armv8-a_shared")
+	if libplatform.MaybeRule("stgAbiDump").Rule != nil {
+		t.Errorf("expected no stg rule for a library that is neither NDK nor LLNDK")
+	}
+	libllndkVendor := result.ModuleForTests("libllndk", "android_vendor.29_arm64_armv8-a_shared")
+	if libllndkVendor.MaybeRule("stgAbiDump").Rule != nil {
+		t.Errorf("expected no stg rule for the vendor variant of an LLNDK library")
+	}
+}
@@ -74,7 +74,7 @@ func (stripper *Stripper) strip(actx android.ModuleContext, in android.Path, out
 		} else if !Bool(stripper.StripProperties.Strip.All) {
 			flags.StripKeepMiniDebugInfo = true
 		}
-		if actx.Config().Debuggable() && !flags.StripKeepMiniDebugInfo && !isStaticLib {
+		if actx.Config().BuildTypeSettings().StripAddGnuDebuglink && !flags.StripKeepMiniDebugInfo && !isStaticLib {
 			flags.StripAddGnuDebuglink = true
 		}
 		transformStrip(actx, in, out, flags)
@@ -0,0 +1,96 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"github.com/google/blueprint"
+
+	"android/soong/android"
+)
+
+// This file implements strict_symbol_check, which compares the symbols exported by a shared
+// library with the global symbols of its version script or .map.txt symbol file, and fails the
+// build with the symbols that are in one but not in the other. It catches the symbols that were
+// removed from the library but not from its symbol file, which break the stubs built from it, and
+// the symbols that leak from the library because of a wildcard or a missing version script.
+
+func init() {
+	pctx.HostBinToolVariable("symbolCheck", "symbolcheck")
+}
+
+var checkSymbols = pctx.AndroidStaticRule("checkSymbols",
+	blueprint.RuleParams{
+		Command: "rm -f $out && " +
+			"${config.ClangBin}/llvm-nm -D --defined-only --extern-only --format=just-symbols $in > $out.symbols && " +
+			"$symbolCheck --arch $arch --api-map $apiMap --library $libName $symbolFile $out.symbols && " +
+			"touch $out",
+		CommandDeps: []string{"$symbolCheck", "${config.ClangBin}/llvm-nm"},
+	}, "arch", "apiMap", "libName", "symbolFile")
+
+// exportedSymbolsFile returns the version script or symbol file the exported symbols of the
+// library are checked against.
+func (library *libraryDecorator) exportedSymbolsFile(ctx ModuleContext) android.OptionalPath {
+	if library.versionScriptFile.Valid() {
+		return library.versionScriptFile
+	}
+	if symbolFile := library.Properties.Stubs.Symbol_file; symbolFile != nil {
+		return android.OptionalPathForModuleSrc(ctx, symbolFile)
+	}
+	return android.OptionalPathForModuleSrc(ctx, library.Properties.Llndk.Symbol_file)
+}
+
+// checkExportedSymbols compares the symbols exported by the shared library with its symbol file
+// when strict_symbol_check is set, returning the timestamp of the check.
+func (library *libraryDecorator) checkExportedSymbols(ctx ModuleContext, fileName string) android.OptionalPath {
+	if !Bool(library.Properties.Strict_symbol_check) {
+		return android.OptionalPath{}
+	}
+	// The stubs are generated from the symbol file.
+	if library.buildStubs() || ctx.IsLlndk() || ctx.IsVendorPublicLibrary() {
+		return android.OptionalPath{}
+	}
+	// Coverage and sanitizer variants export extra symbols.
+	m := ctx.Module().(*Module)
+	if m.isCoverageVariant() || (m.sanitize != nil && !m.sanitize.isVariantOnProductionDevice()) {
+		return android.OptionalPath{}
+	}
+	if ctx.Darwin() || ctx.Windows() {
+		ctx.PropertyErrorf("strict_symbol_check", "Only supported for ELF files")
+		return android.OptionalPath{}
+	}
+	symbolFile := library.exportedSymbolsFile(ctx)
+	if !symbolFile.Valid() {
+		ctx.PropertyErrorf("strict_symbol_check",
+			"requires a version_script, stubs.symbol_file or llndk.symbol_file")
+		return android.OptionalPath{}
+	}
+
+	apiLevelsJson := android.GetApiLevelsJson(ctx)
+	timestamp := android.PathForModuleOut(ctx, "symbol_check", fileName+".timestamp")
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        checkSymbols,
+		Description: "check symbols " + fileName,
+		Output:      timestamp,
+		Input:       library.unstrippedOutputFile,
+		Implicits:   android.Paths{symbolFile.Path(), apiLevelsJson},
+		Args: map[string]string{
+			"arch":       ctx.Arch().ArchType.String(),
+			"apiMap":     apiLevelsJson.String(),
+			"libName":    fileName,
+			"symbolFile": symbolFile.String(),
+		},
+	})
+	return android.OptionalPathForPath(timestamp)
+}
@@ -0,0 +1,88 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestStrictSymbolCheck(t *testing.T) {
+	t.Parallel()
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureAddTextFile("libfoo.map.txt", ""),
+		android.FixtureAddTextFile("libbar.map.txt", ""),
+	).RunTestWithBp(t, `
+		cc_library_shared {
+			name: "libfoo",
+			srcs: ["foo.c"],
+			version_script: "libfoo.map.txt",
+			strict_symbol_check: true,
+		}
+
+		cc_library {
+			name: "libbar",
+			srcs: ["foo.c"],
+			stubs: {
+				symbol_file: "libbar.map.txt",
+				versions: ["29"],
+			},
+			strict_symbol_check: true,
+		}
+
+		cc_library_shared {
+			name: "libunchecked",
+			srcs: ["foo.c"],
+			version_script: "libfoo.map.txt",
+		}
+	`)
+
+	libfoo := result.ModuleForTests("libfoo", "android_arm64_armv8-a_shared")
+	check := libfoo.Rule("checkSymbols")
+	android.AssertPathRelativeToTopEquals(t, "libfoo symbol check input",
+		"out/soong/.intermediates/libfoo/android_arm64_armv8-a_shared/unstripped/libfoo.so", check.Input)
+	android.AssertStringEquals(t, "libfoo symbol file", "libfoo.map.txt", check.Args["symbolFile"])
+	android.AssertStringEquals(t, "libfoo arch", "arm64", check.Args["arch"])
+
+	libbar := result.ModuleForTests("libbar", "android_arm64_armv8-a_shared")
+	android.AssertStringEquals(t, "libbar symbol file", "libbar.map.txt",
+		libbar.Rule("checkSymbols").Args["symbolFile"])
+	libbarStubs := result.ModuleForTests("libbar", "android_arm64_armv8-a_shared_29")
+	if libbarStubs.MaybeRule("checkSymbols").Rule != nil {
+		t.Errorf("expected no symbol check for the stubs of libbar")
+	}
+
+	libunchecked := result.ModuleForTests("libunchecked", "android_arm64_armv8-a_shared")
+	if libunchecked.MaybeRule("checkSymbols").Rule != nil {
+		t.Errorf("expected no symbol check without strict_symbol_check")
+	}
+}
+
+func TestStrictSymbolCheckWithoutSymbolFile(t *testing.T) {
+	t.Parallel()
+	android.GroupFixturePreparers(
+		prepareForCcTest,
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`strict_symbol_check: requires a version_script, stubs.symbol_file or llndk.symbol_file`,
+	)).RunTestWithBp(t, `
+		cc_library_shared {
+			name: "libfoo",
+			srcs: ["foo.c"],
+			strict_symbol_check: true,
+		}
+	`)
+}
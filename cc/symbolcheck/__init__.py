@@ -0,0 +1,146 @@
+#!/usr/bin/env python
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+"""Checks the symbols exported by a shared library against its symbol file."""
+import argparse
+import json
+from pathlib import Path
+import sys
+from typing import Iterable, List, Set, TextIO
+
+import symbolfile
+from symbolfile import Arch
+
+
+class SymbolFile:
+    """The symbols a version script or a symbol map declares global."""
+    def __init__(self, symbols: Set[str], versions: Set[str],
+                 has_cpp_symbols: bool) -> None:
+        self.symbols = symbols
+        self.versions = versions
+        # The symbols of 'extern "C++"' blocks are demangled patterns that
+        # aren't parsed, so the exported C++ symbols can't be checked.
+        self.has_cpp_symbols = has_cpp_symbols
+
+
+def parse_symbol_file(input_file: TextIO, api_map: symbolfile.ApiMap,
+                      arch: Arch) -> SymbolFile:
+    """Parses the global symbols of the symbol file for the architecture.
+
+    All the symbols of the architecture are expected in the implementation
+    library, whatever the API level, mode or privacy of their version.
+    """
+    has_cpp_symbols = 'extern "C++"' in input_file.read()
+    input_file.seek(0)
+    filt = symbolfile.Filter(arch, symbolfile.FUTURE_API_LEVEL, llndk=True,
+                             apex=True, systemapi=True, ndk=True)
+    versions = symbolfile.SymbolFileParser(input_file, api_map, filt).parse()
+    symbols = set()
+    for version in versions:
+        if not symbolfile.symbol_in_arch(version.tags, arch):
+            continue
+        for symbol in version.symbols:
+            if symbolfile.symbol_in_arch(symbol.tags, arch):
+                symbols.add(symbol.name)
+    return SymbolFile(symbols, {version.name for version in versions},
+                      has_cpp_symbols)
+
+
+def parse_exported_symbols(lines: Iterable[str]) -> Set[str]:
+    """Parses the output of llvm-nm -D --format=just-symbols.
+
+    The version of versioned symbols, e.g. 'foo@@LIBFOO', is dropped.
+    """
+    symbols = set()
+    for line in lines:
+        name = line.strip().partition('@')[0]
+        if name:
+            symbols.add(name)
+    return symbols
+
+
+def check_symbols(symbol_file: SymbolFile,
+                  exported: Set[str]) -> List[str]:
+    """Returns the errors of the comparison of the symbols."""
+    errors = []
+    for name in sorted(symbol_file.symbols - exported):
+        errors.append(f'{name} is in the symbol file but is not exported')
+    for name in sorted(exported - symbol_file.symbols):
+        # Linkers may define a symbol for each version definition.
+        if name in symbol_file.versions:
+            continue
+        if symbol_file.has_cpp_symbols and name.startswith('_Z'):
+            continue
+        errors.append(f'{name} is exported but is not in the symbol file')
+    return errors
+
+
+def parse_args() -> argparse.Namespace:
+    """Parses and returns command line arguments."""
+    parser = argparse.ArgumentParser()
+
+    def resolved_path(raw: str) -> Path:
+        """Returns a resolved Path for the given string."""
+        return Path(raw).resolve()
+
+    parser.add_argument(
+        '--arch', choices=symbolfile.ALL_ARCHITECTURES, required=True,
+        help='Architecture of the library.')
+    parser.add_argument('--api-map',
+                        type=resolved_path,
+                        required=True,
+                        help='Path to the API level map JSON file.')
+    parser.add_argument('--library', required=True,
+                        help='Name of the library, for the error messages.')
+
+    parser.add_argument('symbol_file',
+                        type=resolved_path,
+                        help='Path to the version script or symbol map.')
+    parser.add_argument('exported_symbols',
+                        type=resolved_path,
+                        help='Path to the symbols exported by the library, '
+                        'from llvm-nm -D --format=just-symbols.')
+
+    return parser.parse_args()
+
+
+def main() -> None:
+    """Program entry point."""
+    args = parse_args()
+
+    with args.api_map.open() as map_file:
+        api_map = json.load(map_file)
+
+    with args.symbol_file.open() as input_file:
+        try:
+            symbol_file = parse_symbol_file(input_file, api_map, args.arch)
+        except (symbolfile.ParseError,
+                symbolfile.MultiplyDefinedSymbolError) as ex:
+            sys.exit(f'{args.symbol_file}: error: {ex}')
+
+    with args.exported_symbols.open() as exported_file:
+        exported = parse_exported_symbols(exported_file)
+
+    errors = check_symbols(symbol_file, exported)
+    if errors:
+        for error in errors:
+            print(f'{args.library}: error: {error}', file=sys.stderr)
+        sys.exit(f'{args.library}: the exported symbols do not match '
+                 f'{args.symbol_file}')
+
+
+if __name__ == '__main__':
+    main()
@@ -0,0 +1,91 @@
+#!/usr/bin/env python
+#
+# Copyright (C) 2023 The Android Open Source Project
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+"""Tests for symbolcheck.py."""
+import io
+import textwrap
+import unittest
+
+from symbolfile import Arch
+
+import symbolcheck
+
+
+# pylint: disable=missing-docstring
+
+
+class SymbolCheckTest(unittest.TestCase):
+    def parse(self, text: str) -> symbolcheck.SymbolFile:
+        return symbolcheck.parse_symbol_file(
+            io.StringIO(textwrap.dedent(text)), {}, Arch('arm64'))
+
+    def test_parse_symbol_file(self) -> None:
+        symbol_file = self.parse("""\
+            LIBFOO {
+              global:
+                foo;
+                bar; # arm
+                baz; # introduced=30 llndk
+              local:
+                *;
+            };
+            LIBFOO_PRIVATE {
+                qux; # platform-only
+            } LIBFOO;
+        """)
+        self.assertEqual({'foo', 'baz', 'qux'}, symbol_file.symbols)
+        self.assertEqual({'LIBFOO', 'LIBFOO_PRIVATE'}, symbol_file.versions)
+        self.assertFalse(symbol_file.has_cpp_symbols)
+
+    def test_parse_exported_symbols(self) -> None:
+        self.assertEqual({'foo', 'bar'},
+                         symbolcheck.parse_exported_symbols(
+                             ['foo@@LIBFOO\n', 'bar\n', '\n']))
+
+    def test_check_symbols(self) -> None:
+        symbol_file = self.parse("""\
+            LIBFOO {
+                foo;
+                bar;
+            };
+        """)
+        self.assertEqual([
+            'bar is in the symbol file but is not exported',
+            'baz is exported but is not in the symbol file',
+        ], symbolcheck.check_symbols(symbol_file, {'foo', 'baz', 'LIBFOO'}))
+
+    def test_check_cpp_symbols(self) -> None:
+        symbol_file = self.parse("""\
+            LIBFOO {
+              global:
+                foo;
+                extern "C++" {
+                    android::*;
+                };
+            };
+        """)
+        self.assertEqual([],
+                         symbolcheck.check_symbols(
+                             symbol_file, {'foo', '_ZN7android3barEv'}))
+
+
+def main() -> None:
+    suite = unittest.TestLoader().loadTestsFromName(__name__)
+    unittest.TextTestRunner(verbosity=3).run(suite)
+
+
+if __name__ == '__main__':
+    main()
@@ -0,0 +1,165 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"github.com/google/blueprint"
+
+	"android/soong/android"
+)
+
+// Overrides of the default system shared libraries.
+//
+// The SystemSharedLibsOverrides product variable replaces libc, libm and libdl in the default
+// system_shared_libs of the platform native modules in a set of directories, e.g. to build them
+// against an experimental bionic fork in vendor/:
+//
+//	"SystemSharedLibsOverrides": [
+//	    {
+//	        "Paths": ["vendor/foo/experiments"],
+//	        "Replacements": {"libc": "libc_experimental"}
+//	    }
+//	]
+//
+// Modules that set system_shared_libs explicitly are not affected. As the replaced libraries
+// are still used by the rest of the platform, `m check-system-shared-libs-overrides` checks
+// that each replacement defines every dynamic symbol of the library it replaces.
+
+func init() {
+	android.RegisterSingletonType("system_shared_libs_overrides", systemSharedLibsOverridesSingletonFactory)
+}
+
+var checkSystemSharedLibAbi = pctx.AndroidStaticRule("checkSystemSharedLibAbi",
+	blueprint.RuleParams{
+		Command: `${config.ClangBin}/llvm-nm -D --defined-only --format=just-symbols $original | sort -u > $out.original && ` +
+			`${config.ClangBin}/llvm-nm -D --defined-only --format=just-symbols $replacement | sort -u > $out.replacement && ` +
+			`comm -23 $out.original $out.replacement > $out.missing && ` +
+			`if [ -s $out.missing ]; then ` +
+			`echo "$replacementName is not ABI compatible with $originalName, it does not define:" >&2; ` +
+			`cat $out.missing >&2; exit 1; fi && ` +
+			`rm -f $out.original $out.replacement $out.missing && touch $out`,
+		CommandDeps: []string{"${config.ClangBin}/llvm-nm"},
+	}, "original", "replacement", "originalName", "replacementName")
+
+// replaceDefaultSystemSharedLibs returns the default system shared libraries of a module with
+// the replacements of SystemSharedLibsOverrides for its directory applied.
+func replaceDefaultSystemSharedLibs(ctx BaseModuleContext, libs []string) []string {
+	if !ctx.toolchain().Bionic() || !ctx.Device() || ctx.useSdk() {
+		return libs
+	}
+	replacements := ctx.DeviceConfig().SystemSharedLibsReplacements(ctx.ModuleDir())
+	if len(replacements) == 0 {
+		return libs
+	}
+	// A replacement cannot depend on itself.
+	for _, replacement := range replacements {
+		if ctx.ModuleName() == replacement {
+			return libs
+		}
+	}
+
+	ret := make([]string, len(libs))
+	for i, lib := range libs {
+		if replacement, ok := replacements[lib]; ok {
+			ret[i] = replacement
+		} else {
+			ret[i] = lib
+		}
+	}
+	return ret
+}
+
+func systemSharedLibsOverridesSingletonFactory() android.Singleton {
+	return &systemSharedLibsOverridesSingleton{}
+}
+
+type systemSharedLibsOverridesSingleton struct{}
+
+func (s *systemSharedLibsOverridesSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	overrides := ctx.DeviceConfig().SystemSharedLibsOverrides()
+	if len(overrides) == 0 {
+		return
+	}
+
+	// The platform variant of each library that is replaced or used as a replacement, keyed by
+	// name and arch.
+	wanted := make(map[string]bool)
+	for _, override := range overrides {
+		for original, replacement := range override.Replacements {
+			wanted[original] = true
+			wanted[replacement] = true
+		}
+	}
+	type libKey struct {
+		name string
+		arch string
+	}
+	libs := make(map[libKey]android.Path)
+	var arches []string
+	ctx.VisitAllModules(func(module android.Module) {
+		m, ok := module.(*Module)
+		if !ok || !m.Enabled() || !wanted[m.BaseModuleName()] {
+			return
+		}
+		if !m.Device() || !m.Shared() || m.IsStubs() || m.UseVndk() || m.UseSdk() ||
+			m.InRamdisk() || m.InVendorRamdisk() || m.InRecovery() {
+			return
+		}
+		apexInfo := ctx.ModuleProvider(module, android.ApexInfoProvider).(android.ApexInfo)
+		if !apexInfo.IsForPlatform() || m.UnstrippedOutputFile() == nil {
+			return
+		}
+		arch := m.Target().Arch.ArchType.String()
+		libs[libKey{m.BaseModuleName(), arch}] = m.UnstrippedOutputFile()
+		if !android.InList(arch, arches) {
+			arches = append(arches, arch)
+		}
+	})
+
+	var checks android.Paths
+	checked := make(map[string]bool)
+	for _, override := range overrides {
+		for _, original := range android.SortedStringKeys(override.Replacements) {
+			replacement := override.Replacements[original]
+			if checked[original+":"+replacement] {
+				continue
+			}
+			checked[original+":"+replacement] = true
+			for _, arch := range arches {
+				originalPath := libs[libKey{original, arch}]
+				replacementPath := libs[libKey{replacement, arch}]
+				if originalPath == nil || replacementPath == nil {
+					continue
+				}
+				check := android.PathForOutput(ctx, "system_shared_libs_overrides", arch, original+"-"+replacement+".abi_check")
+				ctx.Build(pctx, android.BuildParams{
+					Rule:        checkSystemSharedLibAbi,
+					Description: "check ABI of " + replacement + " against " + original,
+					Output:      check,
+					Implicits:   android.Paths{originalPath, replacementPath},
+					Args: map[string]string{
+						"original":        originalPath.String(),
+						"replacement":     replacementPath.String(),
+						"originalName":    original,
+						"replacementName": replacement,
+					},
+				})
+				checks = append(checks, check)
+			}
+		}
+	}
+
+	ctx.Phony("check-system-shared-libs-overrides", checks...)
+}
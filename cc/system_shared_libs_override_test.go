@@ -0,0 +1,73 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestSystemSharedLibsOverrides(t *testing.T) {
+	t.Parallel()
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.SystemSharedLibsOverrides = []android.SystemSharedLibsOverride{
+				{
+					Paths:        []string{"vendor/experiments"},
+					Replacements: map[string]string{"libc": "libc_experimental"},
+				},
+			}
+		}),
+		android.FixtureAddTextFile("vendor/experiments/Android.bp", `
+			cc_library {
+				name: "libc_experimental",
+				system_shared_libs: [],
+				stl: "none",
+			}
+
+			cc_library {
+				name: "libfoo",
+			}
+
+			cc_library {
+				name: "libfoo_explicit",
+				system_shared_libs: ["libc"],
+			}
+		`),
+		android.FixtureAddTextFile("external/bar/Android.bp", `
+			cc_library {
+				name: "libbar",
+			}
+		`),
+	).RunTest(t)
+
+	libFlags := func(name string) string {
+		return result.ModuleForTests(name, "android_arm64_armv8-a_shared").Rule("ld").Args["libFlags"]
+	}
+
+	android.AssertStringDoesContain(t, "libfoo links against the replacement",
+		libFlags("libfoo"), "libc_experimental/android_arm64_armv8-a_shared/libc_experimental.so")
+	android.AssertStringDoesNotContain(t, "libfoo links against libc",
+		libFlags("libfoo"), "libc/android_arm64_armv8-a_shared")
+	android.AssertStringDoesContain(t, "libfoo links against the default libm",
+		libFlags("libfoo"), "libm/android_arm64_armv8-a_shared")
+
+	android.AssertStringDoesContain(t, "explicit system_shared_libs are not replaced",
+		libFlags("libfoo_explicit"), "libc/android_arm64_armv8-a_shared")
+	android.AssertStringDoesContain(t, "modules outside the paths are not affected",
+		libFlags("libbar"), "libc/android_arm64_armv8-a_shared")
+}
@@ -77,10 +77,13 @@ func (tidy *tidyFeature) flags(ctx ModuleContext, flags Flags) Flags {
 	if tidy.Properties.Tidy != nil && !*tidy.Properties.Tidy {
 		return flags
 	}
+	// The TidyProfiles of the product select the checks of the modules in their directories, and
+	// the checks that are errors for them.
+	profileChecks, profileChecksAsErrors, hasProfile := ctx.Config().TidyProfile(ctx.ModuleDir())
 	// Some projects like external/* and vendor/* have clang-tidy disabled by default,
-	// unless they are enabled explicitly with the "tidy:true" property or
-	// when TIDY_EXTERNAL_VENDOR is set to true.
-	if !proptools.Bool(tidy.Properties.Tidy) &&
+	// unless they are enabled explicitly with the "tidy:true" property, with a tidy profile,
+	// or when TIDY_EXTERNAL_VENDOR is set to true.
+	if !proptools.Bool(tidy.Properties.Tidy) && !hasProfile &&
 		config.NoClangTidyForDir(
 			ctx.Config().IsEnvTrue("TIDY_EXTERNAL_VENDOR"),
 			ctx.ModuleDir()) {
@@ -143,6 +146,8 @@ func (tidy *tidyFeature) flags(ctx ModuleContext, flags Flags) Flags {
 	tidyChecks := "-checks="
 	if checks := ctx.Config().TidyChecks(); len(checks) > 0 {
 		tidyChecks += checks
+	} else if hasProfile {
+		tidyChecks += proptools.NinjaAndShellEscape(profileChecks)
 	} else {
 		tidyChecks += config.TidyChecksForDir(ctx.ModuleDir())
 	}
@@ -189,13 +194,24 @@ func (tidy *tidyFeature) flags(ctx ModuleContext, flags Flags) Flags {
 		}
 	}
 	// Default clang-tidy flags does not contain -warning-as-errors.
-	// If a module has tidy_checks_as_errors, add the list to -warnings-as-errors
-	// and then append the TidyGlobalNoErrorChecks.
-	if len(tidy.Properties.Tidy_checks_as_errors) > 0 {
-		tidyChecksAsErrors := "-warnings-as-errors=" +
-			strings.Join(esc(ctx, "tidy_checks_as_errors", tidy.Properties.Tidy_checks_as_errors), ",") +
+	// If a module has tidy_checks_as_errors or a tidy profile with checks as errors,
+	// add the list to -warnings-as-errors and then append the TidyGlobalNoErrorChecks.
+	checksAsErrors := esc(ctx, "tidy_checks_as_errors", tidy.Properties.Tidy_checks_as_errors)
+	if profileChecksAsErrors != "" {
+		checksAsErrors = append([]string{proptools.NinjaAndShellEscape(profileChecksAsErrors)}, checksAsErrors...)
+	}
+	if len(checksAsErrors) > 0 {
+		tidyChecksAsErrors := "-warnings-as-errors=" + strings.Join(checksAsErrors, ",") +
 			config.TidyGlobalNoErrorChecks()
 		flags.TidyFlags = append(flags.TidyFlags, tidyChecksAsErrors)
+
+		// The pre-existing findings in the tidy baseline of the product are not errors, so that
+		// checks can be errors for new code without fixing the legacy code first.
+		baseline, err := ctx.Config().TidyBaseline(ctx, ctx.ModuleDir())
+		if err != nil {
+			ctx.ModuleErrorf("%s", err)
+		}
+		flags.TidyBaseline = baseline
 	}
 	return flags
 }
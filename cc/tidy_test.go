@@ -244,3 +244,51 @@ func TestWithTidy(t *testing.T) {
 		})
 	}
 }
+
+func TestTidyProfiles(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.TidyProfiles = []string{"vendor/acme:-*,bugprone-*:bugprone-*"}
+			variables.TidyBaselines = []string{"vendor/acme:vendor/acme/tidy_baseline.txt"}
+		}),
+		android.FixtureMergeMockFs(android.MockFS{
+			"vendor/acme/tidy_baseline.txt": []byte("# Pre-existing findings\n" +
+				"vendor/acme/old.cpp:bugprone-branch-clone\n" +
+				"vendor/acme/old.cpp:bugprone-macro-parentheses\n"),
+			"vendor/acme/Android.bp": []byte(`
+				cc_library_shared {
+					name: "libacme",
+					srcs: ["new.cpp", "old.cpp"],
+				}`),
+			"vendor/acme/new.cpp": nil,
+			"vendor/acme/old.cpp": nil,
+			"vendor/other/Android.bp": []byte(`
+				cc_library_shared {
+					name: "libother",
+					srcs: ["other.cpp"],
+				}`),
+			"vendor/other/other.cpp": nil,
+		}),
+	).RunTest(t)
+
+	tidyFlags := func(src string) string {
+		return result.ModuleForTests("libacme", "android_arm64_armv8-a_shared").Output(
+			"out/soong/.intermediates/vendor/acme/libacme/android_arm64_armv8-a_shared/obj/" + src + ".tidy").Args["tidyFlags"]
+	}
+
+	// The modules of the profile are checked even in vendor/, with the checks of the profile.
+	newFlags := tidyFlags("new")
+	android.AssertStringDoesContain(t, "new.cpp checks", newFlags, "-checks='-*,bugprone-*'")
+	android.AssertStringDoesContain(t, "new.cpp checks as errors", newFlags, "-warnings-as-errors='bugprone-*'")
+	android.AssertStringDoesNotContain(t, "new.cpp baseline", newFlags, "bugprone-branch-clone")
+
+	// The findings of the baseline are not errors.
+	oldFlags := tidyFlags("old")
+	android.AssertStringDoesContain(t, "old.cpp checks as errors", oldFlags, "-warnings-as-errors='bugprone-*'")
+	android.AssertStringDoesContain(t, "old.cpp baseline", oldFlags, ",-bugprone-branch-clone,-bugprone-macro-parentheses")
+
+	// Modules without a profile in vendor/ are not checked.
+	android.AssertIntEquals(t, "libother tidy rules", 0,
+		len(result.ModuleForTests("libother", "android_arm64_armv8-a_shared").MaybeRule("clangTidy").AllOutputs()))
+}
@@ -66,8 +66,17 @@ func flagsToBuilderFlags(in Flags) builderFlags {
 		gcovCoverage:  in.GcovCoverage,
 		tidy:          in.Tidy,
 		needTidyFiles: in.NeedTidyFiles,
+		tidyBaseline:  in.TidyBaseline,
 		sAbiDump:      in.SAbiDump,
 		emitXrefs:     in.EmitXrefs,
+		sdclang:       in.Sdclang,
+		splitDwarf:    in.SplitDwarf,
+		sarif:         in.Sarif,
+		analyzer:      in.Analyzer,
+		warningLog:    in.WarningLog,
+		clangVersion:  in.ClangVersion,
+
+		clangPluginFlags: strings.Join(in.ClangPluginFlags, " "),
 
 		systemIncludeFlags: strings.Join(in.SystemIncludeFlags, " "),
 
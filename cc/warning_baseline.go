@@ -0,0 +1,118 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"strings"
+
+	"github.com/google/blueprint"
+
+	"android/soong/android"
+)
+
+// This file implements the warning baselines, which ratchet the compiler warnings of the projects
+// that are allowed to have warnings towards -Werror. The WarningBaselines of the product map
+// directories to checked-in baseline files with the number of warnings of each directory below
+// them. The warnings of the native modules in the directories are kept next to their objects, and
+// the build fails if the number of warnings of a directory grows above its baseline, with a report
+// of the warnings of the directory in out/soong/warning_baselines/<dir>/new_warnings.txt. With
+// UPDATE_WARNING_BASELINES=true the baseline files are rewritten with the counts of the build
+// instead, which lowers them as the warnings are fixed.
+
+func init() {
+	android.RegisterSingletonType("warning_baselines", warningBaselinesSingletonFactory)
+}
+
+var (
+	// Like cc, but also writes the warnings of the compilation to $out.warnings.
+	ccWarningLog = pctx.AndroidStaticRule("ccWarningLog",
+		blueprint.RuleParams{
+			Depfile: "${out}.d",
+			Deps:    blueprint.DepsGCC,
+			Command: "$relPwd ${config.CcWrapper}$ccCmd -c $cFlags -MD -MF ${out}.d -o $out $in 2> ${out}.warnings && " +
+				"cat ${out}.warnings >&2 || (cat ${out}.warnings >&2 && false)",
+			CommandDeps: []string{"$ccCmd"},
+		},
+		"ccCmd", "cFlags")
+)
+
+// useWarningBaseline returns true if the module is in a directory of the WarningBaselines of the
+// product.
+func useWarningBaseline(ctx ModuleContext) bool {
+	_, _, ok := ctx.Config().WarningBaseline(ctx.ModuleDir())
+	return ok
+}
+
+var _ android.CompilerWarningsModule = (*Module)(nil)
+
+// CompilerWarningFiles returns the files with the compiler warnings of the sources of the module,
+// which are only kept for the modules in the directories of WarningBaselines.
+func (c *Module) CompilerWarningFiles() android.Paths {
+	return c.warningFiles
+}
+
+func warningBaselinesSingletonFactory() android.Singleton {
+	return &warningBaselinesSingleton{}
+}
+
+type warningBaselinesSingleton struct{}
+
+// GenerateBuildActions checks the warnings of the modules in the directories of each entry of
+// WarningBaselines against its baseline file.
+func (s *warningBaselinesSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	warningFiles := make(map[string]android.Paths)
+	baselineDirs := make(map[string]string)
+	ctx.VisitAllModules(func(module android.Module) {
+		m, ok := module.(*Module)
+		if !ok || !m.Enabled() || len(m.warningFiles) == 0 {
+			return
+		}
+		dir, file, ok := ctx.Config().WarningBaseline(ctx.ModuleDir(module))
+		if !ok {
+			return
+		}
+		warningFiles[file] = append(warningFiles[file], m.warningFiles...)
+		baselineDirs[file] = dir
+	})
+	if len(warningFiles) == 0 {
+		return
+	}
+
+	update := ctx.Config().IsEnvTrue("UPDATE_WARNING_BASELINES")
+	var timestamps android.Paths
+	for _, file := range android.SortedKeys(warningFiles) {
+		dir := baselineDirs[file]
+		outDir := android.PathForOutput(ctx, "warning_baselines", dir)
+		timestamp := outDir.Join(ctx, "check.timestamp")
+		rule := android.NewRuleBuilder(pctx, ctx)
+		cmd := rule.Command().
+			BuiltTool("warning_ratchet").
+			FlagWithArg("-dir ", dir).
+			FlagWithOutput("-report ", outDir.Join(ctx, "new_warnings.txt"))
+		if update {
+			// The baseline is written by the rule, and may not exist yet.
+			cmd.Flag("-update").FlagWithArg("-baseline ", android.MaybeExistentPathForSource(ctx, file).String())
+		} else {
+			cmd.FlagWithInput("-baseline ", android.PathForSource(ctx, file))
+		}
+		cmd.FlagWithRspFileInputList("@", outDir.Join(ctx, "warnings.rsp"), warningFiles[file])
+		rule.Command().Text("touch").Output(timestamp)
+		rule.Build("warning_baseline_"+strings.ReplaceAll(dir, "/", "_"), "check warning baseline of "+dir)
+		timestamps = append(timestamps, timestamp)
+	}
+
+	ctx.Phony("check_warning_baselines", timestamps...)
+	ctx.Phony("droidcore", android.PathForPhony(ctx, "check_warning_baselines"))
+}
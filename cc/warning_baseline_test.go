@@ -0,0 +1,73 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cc
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestWarningBaselines(t *testing.T) {
+	t.Parallel()
+	result := android.GroupFixturePreparers(
+		prepareForCcTest,
+		android.FixtureRegisterWithContext(func(ctx android.RegistrationContext) {
+			ctx.RegisterSingletonType("warning_baselines", warningBaselinesSingletonFactory)
+		}),
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.WarningBaselines = []string{"vendor/acme:vendor/acme/warning_baseline.txt"}
+		}),
+		android.FixtureAddTextFile("vendor/acme/Android.bp", `
+			cc_library_shared {
+				name: "libacme",
+				srcs: ["foo.c", "asm.s"],
+			}
+		`),
+		android.FixtureAddTextFile("device/acme/Android.bp", `
+			cc_library_shared {
+				name: "libdevice",
+				srcs: ["foo.c"],
+			}
+		`),
+		android.MockFS{
+			"vendor/acme/foo.c":                nil,
+			"vendor/acme/asm.s":                nil,
+			"vendor/acme/warning_baseline.txt": nil,
+			"device/acme/foo.c":                nil,
+		}.AddToFixture(),
+	).RunTest(t)
+
+	libacme := result.ModuleForTests("libacme", "android_arm64_armv8-a_shared")
+	intermediates := "out/soong/.intermediates/vendor/acme/libacme/android_arm64_armv8-a_shared/"
+	compile := libacme.Rule("ccWarningLog")
+	android.AssertPathRelativeToTopEquals(t, "libacme compile output", intermediates+"obj/foo.o", compile.Output)
+	android.AssertPathsRelativeToTopEquals(t, "libacme compile implicit outputs",
+		[]string{intermediates + "obj/foo.o.warnings"}, compile.ImplicitOutputs.Paths())
+	if libacme.Output("obj/asm.o").Rule == ccWarningLog {
+		t.Errorf("expected no warning log for the assembly sources without preprocessing")
+	}
+
+	libdevice := result.ModuleForTests("libdevice", "android_arm64_armv8-a_shared")
+	if libdevice.MaybeRule("ccWarningLog").Rule != nil {
+		t.Errorf("expected no warning log for a module outside of WarningBaselines")
+	}
+
+	check := result.SingletonForTests("warning_baselines").Output("warning_baselines/vendor/acme/check.timestamp")
+	android.AssertStringDoesContain(t, "check command", check.RuleParams.Command,
+		"-dir vendor/acme -report out/soong/warning_baselines/vendor/acme/new_warnings.txt -baseline vendor/acme/warning_baseline.txt")
+	android.AssertStringListContains(t, "check inputs",
+		android.PathsRelativeToTop(check.Implicits), intermediates+"obj/foo.o.warnings")
+}
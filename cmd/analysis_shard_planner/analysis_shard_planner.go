@@ -0,0 +1,77 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"android/soong/shared"
+)
+
+// This tool is the first step of the experimental sharded analysis described in
+// docs/analysis_sharding.md. It partitions the source tree into non-overlapping subtrees of
+// balanced size from the JSON module graph of a previous build, and reports the dependencies
+// between the shards that the workers analyzing them would have to stitch together.
+
+var (
+	moduleGraph = flag.String("module_graph", "", "the JSON module graph written by `m json-module-graph`")
+	numShards   = flag.Int("shards", 2, "the number of shards")
+	output      = flag.String("o", "", "the file to write the JSON shard plan to")
+	zstd        = flag.String("zstd", "", "the zstd tool, to read a module graph compressed with zstd")
+)
+
+func main() {
+	flag.Parse()
+	if *moduleGraph == "" || *output == "" || *numShards < 1 {
+		fmt.Fprintln(os.Stderr, "usage: analysis_shard_planner -module_graph <file> -shards <n> -o <plan.json>")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	r, err := shared.OpenDecompressed(*moduleGraph, *zstd)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	modules, err := loadModules(r)
+	r.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	plan := planShards(modules, *numShards)
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*output, data, 0666); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	for _, shard := range plan.Shards {
+		fmt.Printf("shard %d: %d variants in %d subtrees, %d imported modules from shards %v\n",
+			shard.Index, shard.Variants, len(shard.Dirs), len(shard.Imports), shard.DependsOn)
+	}
+	fmt.Printf("%d dependencies between shards\n", plan.CrossShardDeps)
+	for _, cycle := range plan.Cycles {
+		fmt.Printf("warning: shards %v depend on each other and can't be analyzed in dependency order\n", cycle)
+	}
+}
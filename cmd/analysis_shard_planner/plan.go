@@ -0,0 +1,284 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The subset of the JSON module graph written by soong_build --module_graph_file that is used to
+// plan the shards. All the variants of a module are defined by the same Android.bp file, so the
+// planner works on module names, weighted by their number of variants.
+
+type jsonDep struct {
+	Name string
+}
+
+type jsonModule struct {
+	Name      string
+	Blueprint string
+	Deps      []jsonDep
+}
+
+// unit is a directory of the source tree assigned to a single shard, with its subdirectories if
+// recursive.
+type unit struct {
+	dir       string
+	recursive bool
+	weight    int
+}
+
+func (u unit) String() string {
+	if u.recursive {
+		if u.dir == "." {
+			return "..."
+		}
+		return u.dir + "/..."
+	}
+	return u.dir
+}
+
+// dirNode is a directory of the source tree with the weight of the modules it defines.
+type dirNode struct {
+	dir      string
+	weight   int
+	total    int
+	children map[string]*dirNode
+}
+
+// Shard is a set of non-overlapping subtrees analyzed by one soong_build worker.
+type Shard struct {
+	Index int
+
+	// The subtrees of the shard, as "<dir>/..." for a directory and its subdirectories, or "<dir>"
+	// for the Android.bp file of the directory only.
+	Dirs []string
+
+	// The number of module variants defined by the shard.
+	Variants int
+
+	// The modules of other shards that modules of the shard depend on, which the shard imports from
+	// the graphs exported by these shards.
+	Imports []string `json:",omitempty"`
+
+	// The shards whose exported graphs the shard imports.
+	DependsOn []int `json:",omitempty"`
+}
+
+// Plan is the partition of the analysis into shards.
+type Plan struct {
+	Shards []Shard
+
+	// The number of dependencies between modules of different shards.
+	CrossShardDeps int
+
+	// The groups of shards that depend on each other, which can't be analyzed in dependency order
+	// and have to be merged or analyzed iteratively.
+	Cycles [][]int `json:",omitempty"`
+}
+
+// loadModules reads the JSON module graph.
+func loadModules(r io.Reader) ([]*jsonModule, error) {
+	var modules []*jsonModule
+	if err := json.NewDecoder(r).Decode(&modules); err != nil {
+		return nil, fmt.Errorf("failed to parse the module graph: %s", err)
+	}
+	return modules, nil
+}
+
+// buildDirTree returns the directory tree of the Android.bp files defining the modules, and the
+// directory of each module.
+func buildDirTree(modules []*jsonModule) (*dirNode, map[string]string) {
+	root := &dirNode{dir: ".", children: make(map[string]*dirNode)}
+	moduleDirs := make(map[string]string)
+	for _, m := range modules {
+		dir := filepath.Dir(m.Blueprint)
+		moduleDirs[m.Name] = dir
+		node := root
+		if dir != "." {
+			for i, elem := range strings.Split(dir, "/") {
+				child, ok := node.children[elem]
+				if !ok {
+					child = &dirNode{
+						dir:      strings.Join(strings.Split(dir, "/")[:i+1], "/"),
+						children: make(map[string]*dirNode),
+					}
+					node.children[elem] = child
+				}
+				node = child
+			}
+		}
+		node.weight++
+	}
+	var sumTotals func(n *dirNode) int
+	sumTotals = func(n *dirNode) int {
+		n.total = n.weight
+		for _, child := range n.children {
+			n.total += sumTotals(child)
+		}
+		return n.total
+	}
+	sumTotals(root)
+	return root, moduleDirs
+}
+
+// splitUnits splits the directory tree into units of at most maxWeight variants where possible,
+// by replacing the heaviest subtrees with their own directory and the subtrees of its children.
+func splitUnits(root *dirNode, maxWeight int) []unit {
+	var units []unit
+	var split func(n *dirNode)
+	split = func(n *dirNode) {
+		if n.total <= maxWeight || len(n.children) == 0 {
+			units = append(units, unit{dir: n.dir, recursive: true, weight: n.total})
+			return
+		}
+		if n.weight > 0 {
+			units = append(units, unit{dir: n.dir, weight: n.weight})
+		}
+		for _, child := range n.children {
+			split(child)
+		}
+	}
+	split(root)
+	return units
+}
+
+// planShards partitions the modules into the given number of shards of balanced weight, and
+// computes the dependencies between the shards.
+func planShards(modules []*jsonModule, numShards int) *Plan {
+	root, moduleDirs := buildDirTree(modules)
+	maxWeight := (root.total + numShards - 1) / numShards
+	units := splitUnits(root, maxWeight)
+
+	// Assign the heaviest units first to the lightest shard.
+	sort.Slice(units, func(i, j int) bool {
+		if units[i].weight != units[j].weight {
+			return units[i].weight > units[j].weight
+		}
+		return units[i].String() < units[j].String()
+	})
+	plan := &Plan{Shards: make([]Shard, numShards)}
+	recursiveUnits := make(map[string]int)
+	exactUnits := make(map[string]int)
+	for i := range plan.Shards {
+		plan.Shards[i].Index = i
+	}
+	for _, u := range units {
+		lightest := 0
+		for i, shard := range plan.Shards {
+			if shard.Variants < plan.Shards[lightest].Variants {
+				lightest = i
+			}
+		}
+		shard := &plan.Shards[lightest]
+		shard.Dirs = append(shard.Dirs, u.String())
+		shard.Variants += u.weight
+		if u.recursive {
+			recursiveUnits[u.dir] = lightest
+		} else {
+			exactUnits[u.dir] = lightest
+		}
+	}
+
+	shardOf := func(module string) (int, bool) {
+		dir, ok := moduleDirs[module]
+		if !ok {
+			return 0, false
+		}
+		if shard, ok := exactUnits[dir]; ok {
+			return shard, true
+		}
+		for d := dir; ; d = filepath.Dir(d) {
+			if shard, ok := recursiveUnits[d]; ok {
+				return shard, true
+			}
+			if d == "." {
+				return 0, false
+			}
+		}
+	}
+
+	imports := make([]map[string]bool, numShards)
+	dependsOn := make([][]bool, numShards)
+	for i := range imports {
+		imports[i] = make(map[string]bool)
+		dependsOn[i] = make([]bool, numShards)
+	}
+	for _, m := range modules {
+		shard, _ := shardOf(m.Name)
+		for _, dep := range m.Deps {
+			depShard, ok := shardOf(dep.Name)
+			if !ok || depShard == shard {
+				continue
+			}
+			plan.CrossShardDeps++
+			imports[shard][dep.Name] = true
+			dependsOn[shard][depShard] = true
+		}
+	}
+	for i := range plan.Shards {
+		shard := &plan.Shards[i]
+		sort.Strings(shard.Dirs)
+		for name := range imports[i] {
+			shard.Imports = append(shard.Imports, name)
+		}
+		sort.Strings(shard.Imports)
+		for j, ok := range dependsOn[i] {
+			if ok {
+				shard.DependsOn = append(shard.DependsOn, j)
+			}
+		}
+	}
+	plan.Cycles = shardCycles(dependsOn)
+	return plan
+}
+
+// shardCycles returns the groups of shards that depend on each other, directly or transitively.
+func shardCycles(dependsOn [][]bool) [][]int {
+	n := len(dependsOn)
+	reaches := make([][]bool, n)
+	for i := range reaches {
+		reaches[i] = append([]bool(nil), dependsOn[i]...)
+	}
+	for k := 0; k < n; k++ {
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				reaches[i][j] = reaches[i][j] || (reaches[i][k] && reaches[k][j])
+			}
+		}
+	}
+
+	var cycles [][]int
+	inCycle := make([]bool, n)
+	for i := 0; i < n; i++ {
+		if inCycle[i] || !reaches[i][i] {
+			continue
+		}
+		cycle := []int{i}
+		for j := i + 1; j < n; j++ {
+			if reaches[i][j] && reaches[j][i] {
+				cycle = append(cycle, j)
+				inCycle[j] = true
+			}
+		}
+		cycles = append(cycles, cycle)
+	}
+	return cycles
+}
@@ -0,0 +1,66 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testGraph = `[
+	{"Name": "libc", "Variant": "android_arm64_shared", "Blueprint": "bionic/libc/Android.bp"},
+	{"Name": "libc", "Variant": "android_arm64_static", "Blueprint": "bionic/libc/Android.bp"},
+	{"Name": "libm", "Variant": "android_arm64_shared", "Blueprint": "bionic/libm/Android.bp",
+		"Deps": [{"Name": "libc"}]},
+	{"Name": "libbase", "Variant": "android_arm64_shared", "Blueprint": "system/libbase/Android.bp",
+		"Deps": [{"Name": "libc"}]},
+	{"Name": "liblog", "Variant": "android_arm64_shared", "Blueprint": "system/logging/Android.bp",
+		"Deps": [{"Name": "libc"}, {"Name": "libbase"}]},
+	{"Name": "logcat", "Variant": "android_arm64", "Blueprint": "system/Android.bp",
+		"Deps": [{"Name": "liblog"}]},
+	{"Name": "bionic_tests", "Variant": "android_arm64", "Blueprint": "bionic/Android.bp",
+		"Deps": [{"Name": "logcat"}]}
+]`
+
+func TestPlanShards(t *testing.T) {
+	modules, err := loadModules(strings.NewReader(testGraph))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan := planShards(modules, 2)
+	expected := &Plan{
+		Shards: []Shard{
+			{Index: 0, Dirs: []string{"bionic/..."}, Variants: 4, Imports: []string{"logcat"}, DependsOn: []int{1}},
+			{Index: 1, Dirs: []string{"system/..."}, Variants: 3, Imports: []string{"libc"}, DependsOn: []int{0}},
+		},
+		CrossShardDeps: 3,
+		Cycles:         [][]int{{0, 1}},
+	}
+	if !reflect.DeepEqual(plan, expected) {
+		t.Errorf("expected %+v, got %+v", expected, plan)
+	}
+
+	plan = planShards(modules, 3)
+	var dirs [][]string
+	for _, shard := range plan.Shards {
+		dirs = append(dirs, shard.Dirs)
+	}
+	expectedDirs := [][]string{{"system/..."}, {"bionic/libc/..."}, {"bionic", "bionic/libm/..."}}
+	if !reflect.DeepEqual(dirs, expectedDirs) {
+		t.Errorf("expected shards %q, got %q", expectedDirs, dirs)
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+)
+
+// This tool attests the critical outputs of the build against a checked-in lockfile. The
+// manifest lists the path on the device and the path in the build of each file, one per line,
+// and the lockfile the SHA-256 hash and the path on the device of each of them, like the output
+// of sha256sum. The hashes of the build are written to the output file, and either compared with
+// the lockfile, failing with every difference, or written to the lockfile with -update.
+
+const lockfileHeader = "# Expected SHA-256 hashes of the critical outputs of the build.\n" +
+	"# Regenerate with UPDATE_OUTPUT_ATTESTATION=true m attest_outputs and review the changes.\n"
+
+func main() {
+	output := flag.String("o", "", "file to write the hashes of the outputs to")
+	manifest := flag.String("manifest", "", "file listing the path on the device and the path of each output")
+	lockfile := flag.String("lockfile", "", "file with the expected hashes of the outputs")
+	update := flag.Bool("update", false, "write the hashes of the outputs to the lockfile instead of checking them")
+	flag.Parse()
+
+	if *output == "" || *manifest == "" || *lockfile == "" || flag.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "usage: %s -o <output> -manifest <manifest> -lockfile <lockfile> [-update]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	if err := attestOutputs(*output, *manifest, *lockfile, *update); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
+
+func attestOutputs(output, manifest, lockfile string, update bool) error {
+	files, err := readManifest(manifest)
+	if err != nil {
+		return err
+	}
+	hashes := make(map[string]string)
+	for onDevicePath, path := range files {
+		hash, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		hashes[onDevicePath] = hash
+	}
+	contents := formatHashes(hashes)
+
+	if update {
+		if err := os.WriteFile(lockfile, []byte(lockfileHeader+contents), 0666); err != nil {
+			return err
+		}
+	} else {
+		f, err := os.Open(lockfile)
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%s doesn't exist, create it with UPDATE_OUTPUT_ATTESTATION=true m attest_outputs", lockfile)
+		} else if err != nil {
+			return err
+		}
+		expected, err := parseHashes(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", lockfile, err)
+		}
+		if diffs := diffHashes(expected, hashes); len(diffs) > 0 {
+			return fmt.Errorf("the critical outputs don't match %s:\n    %s\n"+
+				"If the changes are expected, update it with UPDATE_OUTPUT_ATTESTATION=true m attest_outputs",
+				lockfile, strings.Join(diffs, "\n    "))
+		}
+	}
+	return os.WriteFile(output, []byte(contents), 0666)
+}
+
+// readManifest returns the paths of the outputs by their path on the device.
+func readManifest(manifest string) (map[string]string, error) {
+	data, err := os.ReadFile(manifest)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s: invalid line %q", manifest, line)
+		}
+		files[fields[0]] = fields[1]
+	}
+	return files, nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// formatHashes returns the hashes in the format of sha256sum, sorted by path.
+func formatHashes(hashes map[string]string) string {
+	var paths []string
+	for path := range hashes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var sb strings.Builder
+	for _, path := range paths {
+		fmt.Fprintf(&sb, "%s  %s\n", hashes[path], path)
+	}
+	return sb.String()
+}
+
+// parseHashes parses hashes in the format of sha256sum, ignoring empty lines and comments.
+func parseHashes(r io.Reader) (map[string]string, error) {
+	hashes := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 || len(fields[0]) != sha256.Size*2 {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+		hashes[fields[1]] = fields[0]
+	}
+	return hashes, scanner.Err()
+}
+
+// diffHashes returns a description of each difference between the expected and actual hashes.
+func diffHashes(expected, actual map[string]string) []string {
+	var diffs []string
+	for path, hash := range actual {
+		if want, ok := expected[path]; !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: not in the lockfile, hash %s", path, hash))
+		} else if want != hash {
+			diffs = append(diffs, fmt.Sprintf("%s: hash %s, expected %s", path, hash, want))
+		}
+	}
+	for path := range expected {
+		if _, ok := actual[path]; !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: in the lockfile, but not built", path))
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
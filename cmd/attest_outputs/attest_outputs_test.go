@@ -0,0 +1,73 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAttestOutputs(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0666); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	libc := write("libc.so", "libc")
+	framework := write("framework.jar", "framework")
+	manifest := write("manifest.txt", "/system/lib64/libc.so "+libc+"\n/system/framework/framework.jar "+framework)
+	lockfile := filepath.Join(dir, "lockfile.txt")
+	output := filepath.Join(dir, "hashes.txt")
+
+	if err := attestOutputs(output, manifest, lockfile, false); err == nil ||
+		!strings.Contains(err.Error(), "doesn't exist") {
+		t.Errorf("expected an error for a missing lockfile, got %v", err)
+	}
+
+	if err := attestOutputs(output, manifest, lockfile, true); err != nil {
+		t.Fatalf("unexpected error updating the lockfile: %s", err)
+	}
+	if err := attestOutputs(output, manifest, lockfile, false); err != nil {
+		t.Errorf("unexpected error checking the updated lockfile: %s", err)
+	}
+
+	write("libc.so", "tampered libc")
+	err := attestOutputs(output, manifest, lockfile, false)
+	if err == nil {
+		t.Fatal("expected an error for a tampered output")
+	}
+	if !strings.Contains(err.Error(), "/system/lib64/libc.so: hash ") ||
+		strings.Contains(err.Error(), "framework.jar") {
+		t.Errorf("expected only libc.so to differ, got %s", err)
+	}
+}
+
+func TestDiffHashes(t *testing.T) {
+	expected := map[string]string{"/a": "1", "/b": "2", "/c": "3"}
+	actual := map[string]string{"/a": "1", "/b": "4", "/d": "5"}
+	want := []string{
+		"/b: hash 4, expected 2",
+		"/c: in the lockfile, but not built",
+		"/d: not in the lockfile, hash 5",
+	}
+	if got := diffHashes(expected, actual); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// This tool prints why bp2build or mixed builds don't handle a module yet, from the
+// bp2build_blockers.json report written by `m bp2build`: the allowlists and properties blocking the
+// conversion of the module, its missing dependencies, and the blockers of its unconverted
+// dependencies, recursively. It exits with status 3 if the module is blocked.
+
+var blockersFile = flag.String("blockers", "out/soong/bp2build_blockers.json",
+	"the bp2build_blockers.json report written by `m bp2build`")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: bp2build_blockers [-blockers <file>] <module>...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	f, err := os.Open(*blockersFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s, run `m bp2build` first\n", err)
+		os.Exit(1)
+	}
+	blockers, err := loadBlockers(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	blocked := false
+	for _, name := range flag.Args() {
+		if writeBlockerTree(os.Stdout, blockers, name) {
+			blocked = true
+		}
+	}
+	if blocked {
+		os.Exit(3)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// moduleBlockers is an entry of the bp2build_blockers.json report written by bp2build.
+type moduleBlockers struct {
+	Type            string
+	Dir             string
+	Converted       bool
+	Reasons         []string
+	UnconvertedDeps []string
+	MissingDeps     []string
+}
+
+func loadBlockers(r io.Reader) (map[string]*moduleBlockers, error) {
+	var blockers map[string]*moduleBlockers
+	if err := json.NewDecoder(r).Decode(&blockers); err != nil {
+		return nil, fmt.Errorf("failed to parse the bp2build blockers: %s", err)
+	}
+	return blockers, nil
+}
+
+// blockerTree writes the blockers of the module and of its unconverted dependencies as a tree.
+type blockerTree struct {
+	w        io.Writer
+	blockers map[string]*moduleBlockers
+	printed  map[string]bool
+
+	// Whether the tree has unconverted modules, whose dependencies aren't known.
+	hasUnconverted bool
+}
+
+func (t *blockerTree) write(name, prefix string, depth int) {
+	indent := strings.Repeat("    ", depth)
+	b, ok := t.blockers[name]
+	if !ok {
+		fmt.Fprintf(t.w, "%s%s%s: not defined in any Android.bp file\n", indent, prefix, name)
+		return
+	}
+	if t.printed[name] {
+		fmt.Fprintf(t.w, "%s%s%s (see above)\n", indent, prefix, name)
+		return
+	}
+	t.printed[name] = true
+
+	status := "converted"
+	if !b.Converted {
+		status = "not converted"
+		t.hasUnconverted = true
+	}
+	fmt.Fprintf(t.w, "%s%s%s (%s in %s): %s\n", indent, prefix, name, b.Type, b.Dir, status)
+	for _, reason := range b.Reasons {
+		fmt.Fprintf(t.w, "%s    %s\n", indent, reason)
+	}
+	for _, dep := range b.MissingDeps {
+		fmt.Fprintf(t.w, "%s    missing dependency %s\n", indent, dep)
+	}
+	for _, dep := range b.UnconvertedDeps {
+		t.write(dep, "dependency ", depth+1)
+	}
+}
+
+// writeBlockerTree writes the blockers of the module and of its unconverted dependencies as a
+// tree, and returns whether the module is blocked.
+func writeBlockerTree(w io.Writer, blockers map[string]*moduleBlockers, name string) bool {
+	t := &blockerTree{w: w, blockers: blockers, printed: make(map[string]bool)}
+	t.write(name, "", 0)
+	if t.hasUnconverted {
+		fmt.Fprintln(w, "The dependencies of the modules that are not converted are only known once their "+
+			"blockers are fixed.")
+	}
+	b, ok := blockers[name]
+	return !ok || len(b.Reasons) > 0 || len(b.MissingDeps) > 0 || len(b.UnconvertedDeps) > 0
+}
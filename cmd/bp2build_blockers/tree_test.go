@@ -0,0 +1,58 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testBlockers = `{
+	"libfoo": {"Type": "cc_library", "Dir": "foo", "Converted": true,
+		"UnconvertedDeps": ["libbar", "libbaz"], "MissingDeps": ["libgone"]},
+	"libbar": {"Type": "cc_library_static", "Dir": "bar",
+		"Reasons": ["the module is in Bp2buildModuleDoNotConvertList"]},
+	"libbaz": {"Type": "cc_library", "Dir": "baz", "Converted": true, "UnconvertedDeps": ["libbar"]},
+	"libok": {"Type": "cc_library", "Dir": "ok", "Converted": true}
+}`
+
+func TestWriteBlockerTree(t *testing.T) {
+	blockers, err := loadBlockers(strings.NewReader(testBlockers))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	if !writeBlockerTree(buf, blockers, "libfoo") {
+		t.Errorf("expected libfoo to be blocked")
+	}
+	expected := `libfoo (cc_library in foo): converted
+    missing dependency libgone
+    dependency libbar (cc_library_static in bar): not converted
+        the module is in Bp2buildModuleDoNotConvertList
+    dependency libbaz (cc_library in baz): converted
+        dependency libbar (see above)
+The dependencies of the modules that are not converted are only known once their blockers are fixed.
+`
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	buf.Reset()
+	if writeBlockerTree(buf, blockers, "libok") {
+		t.Errorf("expected libok not to be blocked, got:\n%s", buf.String())
+	}
+}
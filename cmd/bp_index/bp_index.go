@@ -0,0 +1,125 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/blueprint/parser"
+)
+
+// This tool writes the semantic index of the Android.bp files of the tree consumed by the
+// language servers of Android.bp files for "go to definition" and "find references" of module
+// names and for the completion of properties, e.g.:
+//
+//	bp_index -l out/.module_paths/Android.bp.list \
+//	    -module_types out/soong/docs/module_types.json -o out/soong/bp_index.json
+//
+// module_types.json is written by `m soong_docs`.
+
+func main() {
+	list := flag.String("l", "", "file with the list of the Android.bp files to index, one per line")
+	moduleTypesFile := flag.String("module_types", "", "module_types.json written by soong_docs")
+	output := flag.String("o", "", "output index file")
+	flag.Parse()
+
+	if *output == "" {
+		fmt.Fprintln(os.Stderr, "-o is required")
+		os.Exit(1)
+	}
+
+	bpFiles := flag.Args()
+	if *list != "" {
+		listed, err := readList(*list)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		bpFiles = append(bpFiles, listed...)
+	}
+
+	var files []*parser.File
+	for _, bpFile := range bpFiles {
+		file, err := parseFile(bpFile)
+		if err != nil {
+			// Keep indexing the other files, the index of a tree with a broken Android.bp file
+			// is still useful.
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		files = append(files, file)
+	}
+
+	var moduleTypes map[string][]PropertySchema
+	if *moduleTypesFile != "" {
+		data, err := os.ReadFile(*moduleTypesFile)
+		if err == nil {
+			err = json.Unmarshal(data, &moduleTypes)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read %s: %s\n", *moduleTypesFile, err)
+			os.Exit(1)
+		}
+	}
+
+	data, err := newIndex(files, moduleTypes).MarshalCompact()
+	if err == nil {
+		err = os.WriteFile(*output, data, 0666)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func readList(list string) ([]string, error) {
+	f, err := os.Open(list)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var files []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, scanner.Err()
+}
+
+func parseFile(bpFile string) (*parser.File, error) {
+	f, err := os.Open(bpFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	file, errs := parser.Parse(bpFile, f, parser.NewScope(nil))
+	if len(errs) > 0 {
+		var msgs []string
+		for _, err := range errs {
+			msgs = append(msgs, err.Error())
+		}
+		return nil, fmt.Errorf("%s", strings.Join(msgs, "\n"))
+	}
+	return file, nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/google/blueprint/parser"
+)
+
+// PropertySchema is the schema of a property of a module type, as written to module_types.json
+// by the documentation mode of soong_build.
+type PropertySchema struct {
+	Name string
+	Type string
+	Text string `json:",omitempty"`
+}
+
+// Definition is the location of the definition of a module.
+type Definition struct {
+	Type string
+	// The location of the module, as file:line:column.
+	Loc string
+}
+
+// Index is the semantic index of the Android.bp files.
+type Index struct {
+	// The definitions of the modules, by module name. Modules in different namespaces may have
+	// the same name.
+	Modules map[string][]Definition
+
+	// The locations of the references to the modules, as file:line:column, by module name.
+	References map[string][]string
+
+	// The properties of the module types, by module type.
+	ModuleTypes map[string][]PropertySchema `json:",omitempty"`
+}
+
+// newIndex indexes the definitions of the modules of the parsed Android.bp files, and the
+// references to them in the properties of other modules.
+func newIndex(files []*parser.File, moduleTypes map[string][]PropertySchema) *Index {
+	index := &Index{
+		Modules:     make(map[string][]Definition),
+		References:  make(map[string][]string),
+		ModuleTypes: moduleTypes,
+	}
+
+	for _, file := range files {
+		for _, def := range file.Defs {
+			if mod, ok := def.(*parser.Module); ok {
+				if name := moduleName(mod); name != "" {
+					index.Modules[name] = append(index.Modules[name], Definition{
+						Type: mod.Type,
+						Loc:  mod.TypePos.String(),
+					})
+				}
+			}
+		}
+	}
+
+	for _, file := range files {
+		for _, def := range file.Defs {
+			if mod, ok := def.(*parser.Module); ok {
+				for _, prop := range mod.Properties {
+					if prop.Name != "name" {
+						index.addReferences(prop.Value)
+					}
+				}
+			}
+		}
+	}
+
+	for name := range index.References {
+		sort.Strings(index.References[name])
+	}
+	return index
+}
+
+func moduleName(mod *parser.Module) string {
+	if prop, ok := mod.GetProperty("name"); ok {
+		if name, ok := prop.Value.(*parser.String); ok {
+			return name.Value
+		}
+	}
+	return ""
+}
+
+// referencedModule returns the name of the module referenced by a string in a property, which is
+// either the name of a module, ":name" or ":name{tag}" in path properties, or any of them
+// prefixed with the "//namespace" of the module, or "" if it isn't a module reference.
+func (index *Index) referencedModule(s string) string {
+	if strings.HasPrefix(s, "//") {
+		if i := strings.LastIndex(s, ":"); i >= 0 {
+			s = s[i:]
+		}
+	}
+	if strings.HasPrefix(s, ":") {
+		s = strings.TrimPrefix(s, ":")
+		if i := strings.Index(s, "{"); i >= 0 {
+			s = s[:i]
+		}
+	}
+	if _, ok := index.Modules[s]; ok {
+		return s
+	}
+	return ""
+}
+
+func (index *Index) addReferences(value parser.Expression) {
+	switch v := value.(type) {
+	case *parser.String:
+		if name := index.referencedModule(v.Value); name != "" {
+			index.References[name] = append(index.References[name], v.LiteralPos.String())
+		}
+	case *parser.List:
+		for _, e := range v.Values {
+			index.addReferences(e)
+		}
+	case *parser.Map:
+		for _, prop := range v.Properties {
+			index.addReferences(prop.Value)
+		}
+	case *parser.Operator:
+		index.addReferences(v.Args[0])
+		index.addReferences(v.Args[1])
+	}
+}
+
+// MarshalCompact returns the compact JSON of the index.
+func (index *Index) MarshalCompact() ([]byte, error) {
+	return json.Marshal(index)
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/blueprint/parser"
+)
+
+func TestIndex(t *testing.T) {
+	bpFiles := map[string]string{
+		"foo/Android.bp": `
+cc_library {
+    name: "libfoo",
+    shared_libs: ["libbar"],
+    srcs: [":foo_srcs{.cpp}"],
+}
+
+filegroup {
+    name: "foo_srcs",
+    srcs: ["foo.cpp"],
+}
+`,
+		"bar/Android.bp": `
+cc_library {
+    name: "libbar",
+    static_libs: ["libbaz"] + ["//vendor/foo:libfoo"],
+}
+`,
+	}
+
+	var files []*parser.File
+	for _, name := range []string{"bar/Android.bp", "foo/Android.bp"} {
+		file, errs := parser.Parse(name, strings.NewReader(bpFiles[name]), parser.NewScope(nil))
+		if len(errs) > 0 {
+			t.Fatal(errs)
+		}
+		files = append(files, file)
+	}
+
+	index := newIndex(files, nil)
+
+	expectedModules := map[string][]Definition{
+		"libfoo":   {{Type: "cc_library", Loc: "foo/Android.bp:2:1"}},
+		"foo_srcs": {{Type: "filegroup", Loc: "foo/Android.bp:8:1"}},
+		"libbar":   {{Type: "cc_library", Loc: "bar/Android.bp:2:1"}},
+	}
+	if !reflect.DeepEqual(index.Modules, expectedModules) {
+		t.Errorf("expected modules %v, got %v", expectedModules, index.Modules)
+	}
+
+	expectedReferences := map[string][]string{
+		"libbar":   {"foo/Android.bp:4:19"},
+		"foo_srcs": {"foo/Android.bp:5:12"},
+		"libfoo":   {"bar/Android.bp:4:32"},
+	}
+	if !reflect.DeepEqual(index.References, expectedReferences) {
+		t.Errorf("expected references %v, got %v", expectedReferences, index.References)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"android/soong/response"
+)
+
+// This tool writes the build health summary of a build, out/soong/build_health.json. It adds the
+// compiler warnings by category and the sizes of the outputs with a size budget to the part of the
+// summary that soong_build writes at analysis time.
+
+// sizeBudgetFlags is the list of -size_budget <module>=<output>:<budget in bytes> flags.
+type sizeBudgetFlags []string
+
+func (f *sizeBudgetFlags) String() string {
+	return strings.Join(*f, " ")
+}
+
+func (f *sizeBudgetFlags) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func main() {
+	var expandedArgs []string
+	for _, arg := range os.Args[1:] {
+		if strings.HasPrefix(arg, "@") {
+			f, err := os.Open(strings.TrimPrefix(arg, "@"))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+
+			respArgs, err := response.ReadRspFile(f)
+			f.Close()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			expandedArgs = append(expandedArgs, respArgs...)
+		} else {
+			expandedArgs = append(expandedArgs, arg)
+		}
+	}
+
+	flags := flag.NewFlagSet("flags", flag.ExitOnError)
+	output := flags.String("o", "", "file to write the build health summary to")
+	analysisFile := flags.String("analysis", "", "file with the part of the summary known at analysis time")
+	var sizeBudgets sizeBudgetFlags
+	flags.Var(&sizeBudgets, "size_budget", "<module>=<output>:<budget in bytes> of an output with a size budget")
+	flags.Parse(expandedArgs)
+
+	if *output == "" || *analysisFile == "" {
+		fmt.Fprintf(os.Stderr, "usage: %s -o <output> -analysis <analysis.json> [-size_budget <module>=<output>:<budget>]... [<warnings file>...]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	if err := writeBuildHealth(*output, *analysisFile, sizeBudgets, flags.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
+
+// writeBuildHealth writes the build health summary of the build to output.
+func writeBuildHealth(output, analysisFile string, sizeBudgets, warningFiles []string) error {
+	data, err := os.ReadFile(analysisFile)
+	if err != nil {
+		return err
+	}
+	var analysis Analysis
+	if err := json.Unmarshal(data, &analysis); err != nil {
+		return fmt.Errorf("failed to parse %s: %s", analysisFile, err)
+	}
+
+	warnings, err := countWarnings(warningFiles)
+	if err != nil {
+		return err
+	}
+
+	var budgets []SizeBudget
+	for _, flag := range sizeBudgets {
+		budget, err := checkSizeBudget(flag)
+		if err != nil {
+			return err
+		}
+		budgets = append(budgets, budget)
+	}
+
+	data, err = json.MarshalIndent(newBuildHealth(analysis, warnings, budgets), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(output, append(data, '\n'), 0666)
+}
@@ -0,0 +1,163 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// schemaVersion is the version of the format of the build health summary. Fields are only added
+// to a version, so that the summaries of older releases can be compared with newer ones.
+const schemaVersion = 1
+
+// Analysis is the part of the build health summary that soong_build writes at analysis time.
+type Analysis struct {
+	BuildBrokenFlags    []string
+	NeverallowWaivers   []string
+	MissingDependencies map[string][]string
+	MixedBuildFallbacks []string
+}
+
+// SizeBudget is the size of an output with a size budget against its budget.
+type SizeBudget struct {
+	Module   string
+	Output   string
+	Size     int64
+	Budget   int64
+	Exceeded bool
+}
+
+// BuildHealth is the build health summary of a build.
+type BuildHealth struct {
+	SchemaVersion int
+
+	// The number of each indicator, to be tracked release over release:
+	//   warnings: the compiler warnings
+	//   build_broken_flags: the BuildBroken product variables that are set
+	//   neverallow_waivers: the product variables waiving neverallow checks that are set
+	//   modules_with_missing_dependencies: the modules built with missing dependencies
+	//   mixed_build_fallbacks: the modules that mixed builds build with Soong
+	//   size_budgets_exceeded: the outputs larger than their size budget
+	Counts map[string]int
+
+	// The number of compiler warnings of each category, like "-Wunused-variable".
+	Warnings map[string]int
+
+	BuildBrokenFlags    []string
+	NeverallowWaivers   []string
+	MissingDependencies map[string][]string
+	MixedBuildFallbacks []string
+	SizeBudgets         []SizeBudget
+}
+
+// A warning printed by clang, e.g. "foo/foo.c:3:10: warning: unused variable 'x' [-Wunused-variable]".
+var warningRegexp = regexp.MustCompile(`^.+?:\d+:\d+: warning: `)
+
+// The category of a warning, e.g. "-Wunused-variable".
+var warningCategoryRegexp = regexp.MustCompile(`\[(-W[^,\]]+)[^\]]*\]$`)
+
+// countWarnings returns the number of compiler warnings of each category in the warnings files.
+// A warning in a header is printed by every compile that includes it, so it is only counted once.
+func countWarnings(files []string) (map[string]int, error) {
+	warnings := make(map[string]int)
+	seen := make(map[string]bool)
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !warningRegexp.MatchString(line) || seen[line] {
+				continue
+			}
+			seen[line] = true
+			category := "other"
+			if match := warningCategoryRegexp.FindStringSubmatch(line); match != nil {
+				category = match[1]
+			}
+			warnings[category]++
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %s", file, err)
+		}
+	}
+	return warnings, nil
+}
+
+// checkSizeBudget returns the size of the output of a -size_budget <module>=<output>:<budget> flag
+// against its budget.
+func checkSizeBudget(flag string) (SizeBudget, error) {
+	module, rest, ok := strings.Cut(flag, "=")
+	i := strings.LastIndex(rest, ":")
+	if !ok || i < 0 {
+		return SizeBudget{}, fmt.Errorf("invalid -size_budget %q, expected <module>=<output>:<budget>", flag)
+	}
+	budget, err := strconv.ParseInt(rest[i+1:], 10, 64)
+	if err != nil {
+		return SizeBudget{}, fmt.Errorf("invalid budget in -size_budget %q: %s", flag, err)
+	}
+	output := rest[:i]
+	info, err := os.Stat(output)
+	if err != nil {
+		return SizeBudget{}, err
+	}
+	return SizeBudget{
+		Module:   module,
+		Output:   output,
+		Size:     info.Size(),
+		Budget:   budget,
+		Exceeded: info.Size() > budget,
+	}, nil
+}
+
+// newBuildHealth returns the build health summary of a build.
+func newBuildHealth(analysis Analysis, warnings map[string]int, budgets []SizeBudget) *BuildHealth {
+	sort.Slice(budgets, func(i, j int) bool { return budgets[i].Module < budgets[j].Module })
+	health := &BuildHealth{
+		SchemaVersion:       schemaVersion,
+		Counts:              make(map[string]int),
+		Warnings:            warnings,
+		BuildBrokenFlags:    analysis.BuildBrokenFlags,
+		NeverallowWaivers:   analysis.NeverallowWaivers,
+		MissingDependencies: analysis.MissingDependencies,
+		MixedBuildFallbacks: analysis.MixedBuildFallbacks,
+		SizeBudgets:         budgets,
+	}
+
+	for _, n := range warnings {
+		health.Counts["warnings"] += n
+	}
+	health.Counts["build_broken_flags"] = len(analysis.BuildBrokenFlags)
+	health.Counts["neverallow_waivers"] = len(analysis.NeverallowWaivers)
+	health.Counts["modules_with_missing_dependencies"] = len(analysis.MissingDependencies)
+	health.Counts["mixed_build_fallbacks"] = len(analysis.MixedBuildFallbacks)
+	health.Counts["size_budgets_exceeded"] = 0
+	for _, budget := range budgets {
+		if budget.Exceeded {
+			health.Counts["size_budgets_exceeded"]++
+		}
+	}
+	return health
+}
@@ -0,0 +1,107 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testWarnings = `vendor/acme/foo/foo.c:3:10: warning: unused variable 'x' [-Wunused-variable]
+    int x;
+        ^
+vendor/acme/include/acme.h:5:1: warning: 'foo' is deprecated [-Wdeprecated-declarations,-Wfoo]
+vendor/acme/include/acme.h:5:1: warning: 'foo' is deprecated [-Wdeprecated-declarations,-Wfoo]
+vendor/acme/foo/foo.c:7:1: warning: something without a flag
+2 warnings generated.
+`
+
+func TestCountWarnings(t *testing.T) {
+	dir := t.TempDir()
+	foo := filepath.Join(dir, "foo.o.warnings")
+	bar := filepath.Join(dir, "bar.o.warnings")
+	if err := os.WriteFile(foo, []byte(testWarnings), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bar, []byte("vendor/acme/include/acme.h:5:1: warning: 'foo' is deprecated [-Wdeprecated-declarations,-Wfoo]\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	warnings, err := countWarnings([]string{foo, bar})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]int{
+		"-Wunused-variable":         1,
+		"-Wdeprecated-declarations": 1,
+		"other":                     1,
+	}
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("expected %v, got %v", expected, warnings)
+	}
+}
+
+func TestCheckSizeBudget(t *testing.T) {
+	image := filepath.Join(t.TempDir(), "system.img")
+	if err := os.WriteFile(image, make([]byte, 100), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	budget, err := checkSizeBudget("system_image=" + image + ":64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := SizeBudget{Module: "system_image", Output: image, Size: 100, Budget: 64, Exceeded: true}
+	if budget != expected {
+		t.Errorf("expected %+v, got %+v", expected, budget)
+	}
+
+	if _, err := checkSizeBudget("system_image=" + image); err == nil {
+		t.Errorf("expected an error for a -size_budget without a budget")
+	}
+}
+
+func TestNewBuildHealth(t *testing.T) {
+	analysis := Analysis{
+		BuildBrokenFlags:    []string{"BuildBrokenClangProperty", "BuildBrokenDepfile"},
+		NeverallowWaivers:   []string{"SelinuxIgnoreNeverallows"},
+		MissingDependencies: map[string][]string{"libfoo": {"libmissing"}},
+	}
+	budgets := []SizeBudget{
+		{Module: "vendor_image", Size: 10, Budget: 20},
+		{Module: "system_image", Size: 30, Budget: 20, Exceeded: true},
+	}
+	health := newBuildHealth(analysis, map[string]int{"-Wunused-variable": 2, "other": 1}, budgets)
+
+	expectedCounts := map[string]int{
+		"warnings":                          3,
+		"build_broken_flags":                2,
+		"neverallow_waivers":                1,
+		"modules_with_missing_dependencies": 1,
+		"mixed_build_fallbacks":             0,
+		"size_budgets_exceeded":             1,
+	}
+	if !reflect.DeepEqual(health.Counts, expectedCounts) {
+		t.Errorf("expected counts %v, got %v", expectedCounts, health.Counts)
+	}
+	if health.SchemaVersion != schemaVersion {
+		t.Errorf("expected schema version %d, got %d", schemaVersion, health.SchemaVersion)
+	}
+	if health.SizeBudgets[0].Module != "system_image" {
+		t.Errorf("expected the size budgets sorted by module, got %+v", health.SizeBudgets)
+	}
+}
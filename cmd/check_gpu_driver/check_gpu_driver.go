@@ -0,0 +1,165 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// This tool checks a prebuilt updatable GPU driver, an APK or an APEX, against the board it is
+// installed on: its uncompressed native libraries and APEX payload must be aligned to the max page
+// size supported by the device so that they can be mapped in place, and for an APK the output of
+// `aapt2 dump badging` must show the expected target SDK version and native code for every ABI of
+// the device.
+
+var (
+	targetSdkVersionRegexp = regexp.MustCompile(`^targetSdkVersion:'([^']*)'`)
+	nativeCodeRegexp       = regexp.MustCompile(`'([^']*)'`)
+)
+
+type multiString []string
+
+func (ms *multiString) String() string     { return strings.Join(*ms, ", ") }
+func (ms *multiString) Set(s string) error { *ms = append(*ms, s); return nil }
+
+// badging is the part of the output of aapt2 dump badging that is checked.
+type badging struct {
+	targetSdkVersion string
+	nativeCode       []string
+}
+
+func main() {
+	driver := flag.String("driver", "", "the driver APK or APEX")
+	badgingFile := flag.String("badging", "", "output of aapt2 dump badging for the driver APK")
+	targetSdkVersion := flag.String("target-sdk-version", "", "expected target SDK version of the driver APK")
+	pageSize := flag.Uint64("page-size", 4096, "max page size supported by the device")
+	var abis multiString
+	flag.Var(&abis, "abi", "ABI of the device that the driver APK must have native code for")
+	flag.Parse()
+
+	if *driver == "" {
+		fmt.Fprintf(os.Stderr, "usage: %s -driver <file> [-badging <file> -target-sdk-version <version> -abi <abi>...] [-page-size <size>]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	r, err := zip.OpenReader(*driver)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	errs, err := checkAlignment(r.File, *pageSize)
+	r.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", *driver, err)
+		os.Exit(1)
+	}
+
+	if *badgingFile != "" {
+		f, err := os.Open(*badgingFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		b, err := parseBadging(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", *badgingFile, err)
+			os.Exit(1)
+		}
+		errs = append(errs, checkBadging(b, *targetSdkVersion, abis)...)
+	}
+
+	if len(errs) > 0 {
+		for _, e := range errs {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", *driver, e)
+		}
+		os.Exit(1)
+	}
+}
+
+// checkAlignment returns the uncompressed native libraries and APEX payloads among files whose data
+// isn't aligned to pageSize.
+func checkAlignment(files []*zip.File, pageSize uint64) ([]string, error) {
+	var errs []string
+	for _, f := range files {
+		if f.Method != zip.Store || !(strings.HasSuffix(f.Name, ".so") || f.Name == "apex_payload.img") {
+			continue
+		}
+		offset, err := f.DataOffset()
+		if err != nil {
+			return nil, err
+		}
+		if uint64(offset)%pageSize != 0 {
+			errs = append(errs, fmt.Sprintf("%s is at offset %d, which isn't aligned to the max page size %d",
+				f.Name, offset, pageSize))
+		}
+	}
+	return errs, nil
+}
+
+// parseBadging parses the target SDK version and the native code in the output of aapt2 dump
+// badging.
+func parseBadging(r io.Reader) (*badging, error) {
+	b := &badging{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := targetSdkVersionRegexp.FindStringSubmatch(line); match != nil {
+			b.targetSdkVersion = match[1]
+		} else if nativeCode, ok := strings.CutPrefix(line, "native-code:"); ok {
+			for _, match := range nativeCodeRegexp.FindAllStringSubmatch(nativeCode, -1) {
+				b.nativeCode = append(b.nativeCode, match[1])
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if b.targetSdkVersion == "" {
+		return nil, fmt.Errorf("missing targetSdkVersion")
+	}
+	return b, nil
+}
+
+// checkBadging returns the differences between the badging of a driver APK and the target SDK
+// version and ABIs expected by the device.
+func checkBadging(b *badging, targetSdkVersion string, abis []string) []string {
+	var errs []string
+	if targetSdkVersion != "" && b.targetSdkVersion != targetSdkVersion {
+		errs = append(errs, fmt.Sprintf("targetSdkVersion is %s, expected %s", b.targetSdkVersion, targetSdkVersion))
+	}
+	for _, abi := range abis {
+		found := false
+		for _, nativeCode := range b.nativeCode {
+			if nativeCode == abi {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, fmt.Sprintf("missing native code for the %s ABI of the device, it has %s",
+				abi, strconv.Quote(strings.Join(b.nativeCode, " "))))
+		}
+	}
+	return errs
+}
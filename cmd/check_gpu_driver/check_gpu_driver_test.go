@@ -0,0 +1,96 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testBadging = `package: name='com.acme.gpu.driver' versionCode='2023' versionName='2023.2' platformBuildVersionName='14'
+sdkVersion:'33'
+targetSdkVersion:'34'
+application: label='' icon=''
+native-code: 'arm64-v8a' 'armeabi-v7a'
+`
+
+func TestCheckAlignment(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for _, entry := range []struct {
+		name   string
+		method uint16
+	}{
+		{"AndroidManifest.xml", zip.Deflate},
+		{"lib/arm64-v8a/libacme_gpu.so", zip.Store},
+		{"lib/armeabi-v7a/libacme_gpu.so", zip.Deflate},
+	} {
+		f, err := w.CreateHeader(&zip.FileHeader{Name: entry.name, Method: entry.method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte("contents"))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs, err := checkAlignment(r.File, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 1 || !strings.HasPrefix(errs[0], "lib/arm64-v8a/libacme_gpu.so is at offset") {
+		t.Errorf("expected only the uncompressed library to be misaligned, got %q", errs)
+	}
+
+	errs, err = checkAlignment(r.File, 1)
+	if err != nil || len(errs) != 0 {
+		t.Errorf("unexpected errors %q, %v", errs, err)
+	}
+}
+
+func TestCheckBadging(t *testing.T) {
+	b, err := parseBadging(strings.NewReader(testBadging))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedBadging := &badging{
+		targetSdkVersion: "34",
+		nativeCode:       []string{"arm64-v8a", "armeabi-v7a"},
+	}
+	if !reflect.DeepEqual(b, expectedBadging) {
+		t.Fatalf("expected %#v, got %#v", expectedBadging, b)
+	}
+
+	if errs := checkBadging(b, "34", []string{"arm64-v8a", "armeabi-v7a"}); len(errs) != 0 {
+		t.Errorf("unexpected errors %q", errs)
+	}
+
+	errs := checkBadging(b, "33", []string{"arm64-v8a", "x86_64"})
+	expected := []string{
+		"targetSdkVersion is 34, expected 33",
+		`missing native code for the x86_64 ABI of the device, it has "arm64-v8a armeabi-v7a"`,
+	}
+	if !reflect.DeepEqual(errs, expected) {
+		t.Errorf("expected %q, got %q", expected, errs)
+	}
+}
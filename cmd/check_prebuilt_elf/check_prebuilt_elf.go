@@ -0,0 +1,160 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// This tool checks the ELF file of a prebuilt shared library from the output of
+// `llvm-readelf --file-headers --program-headers --dynamic-table`: its machine must match the
+// architecture of the module, its LOAD segments must be aligned to at least the max page size
+// supported by the device, and its NEEDED libraries must be shared library dependencies of the
+// module.
+
+// The machines printed by llvm-readelf for the architectures of the device.
+var archMachines = map[string]string{
+	"arm":     "ARM",
+	"arm64":   "AArch64",
+	"riscv64": "RISC-V",
+	"x86":     "Intel 80386",
+	"x86_64":  "Advanced Micro Devices X86-64",
+}
+
+// A NEEDED entry of the dynamic table, e.g.
+// "0x0000000000000001 (NEEDED)             Shared library: [libc.so]".
+var neededRegexp = regexp.MustCompile(`\(NEEDED\)\s+Shared library: \[(.+)\]`)
+
+type multiString []string
+
+func (ms *multiString) String() string     { return strings.Join(*ms, ", ") }
+func (ms *multiString) Set(s string) error { *ms = append(*ms, s); return nil }
+
+// elfInfo is the part of the output of llvm-readelf that is checked.
+type elfInfo struct {
+	machine    string
+	loadAligns []uint64
+	neededLibs []string
+}
+
+func main() {
+	readelf := flag.String("readelf", "", "output of llvm-readelf for the prebuilt library")
+	arch := flag.String("arch", "", "architecture of the module")
+	maxPageSize := flag.String("max-page-size", "", "max page size supported by the device")
+	var sharedLibs multiString
+	flag.Var(&sharedLibs, "shared-lib", "file name of a shared library dependency of the module")
+	flag.Parse()
+
+	if *readelf == "" || *arch == "" {
+		fmt.Fprintf(os.Stderr, "usage: %s -readelf <file> -arch <arch> [-max-page-size <size>] [-shared-lib <lib>...]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	f, err := os.Open(*readelf)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	info, err := parseReadelf(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", *readelf, err)
+		os.Exit(1)
+	}
+
+	errs, err := checkElf(info, *arch, *maxPageSize, sharedLibs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	if len(errs) > 0 {
+		for _, e := range errs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		fmt.Fprintln(os.Stderr, "Fix the prebuilt, or set check_elf_files: false if this is intended")
+		os.Exit(1)
+	}
+}
+
+// parseReadelf parses the machine, the alignment of the LOAD segments and the NEEDED libraries in
+// the output of llvm-readelf.
+func parseReadelf(r io.Reader) (*elfInfo, error) {
+	info := &elfInfo{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if machine, ok := strings.CutPrefix(line, "Machine:"); ok {
+			info.machine = strings.TrimSpace(machine)
+		} else if fields := strings.Fields(line); len(fields) > 0 && fields[0] == "LOAD" {
+			// The flags may contain spaces, the alignment is the last field.
+			align, err := strconv.ParseUint(fields[len(fields)-1], 0, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid alignment in %q", line)
+			}
+			info.loadAligns = append(info.loadAligns, align)
+		} else if match := neededRegexp.FindStringSubmatch(line); match != nil {
+			info.neededLibs = append(info.neededLibs, match[1])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if info.machine == "" {
+		return nil, fmt.Errorf("missing machine in the ELF header")
+	}
+	return info, nil
+}
+
+// checkElf returns the problems of the ELF file of a prebuilt library of the architecture arch
+// linked against sharedLibs, for a device whose max page size is maxPageSize if not empty.
+func checkElf(info *elfInfo, arch, maxPageSize string, sharedLibs []string) ([]string, error) {
+	var errs []string
+	if expected, ok := archMachines[arch]; !ok {
+		return nil, fmt.Errorf("unknown architecture %q", arch)
+	} else if info.machine != expected {
+		errs = append(errs, fmt.Sprintf("machine is %q, expected %q for %s", info.machine, expected, arch))
+	}
+
+	if maxPageSize != "" {
+		pageSize, err := strconv.ParseUint(maxPageSize, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max page size %q", maxPageSize)
+		}
+		for i, align := range info.loadAligns {
+			if align < pageSize {
+				errs = append(errs, fmt.Sprintf("LOAD segment %d is aligned to %d, below the max page size %d",
+					i, align, pageSize))
+			}
+		}
+	}
+
+	available := make(map[string]bool)
+	for _, lib := range sharedLibs {
+		available[lib] = true
+	}
+	for _, lib := range info.neededLibs {
+		if !available[lib] {
+			errs = append(errs, fmt.Sprintf("NEEDED library %s is not a shared library dependency of the module", lib))
+		}
+	}
+	return errs, nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testReadelf = `ELF Header:
+  Magic:   7f 45 4c 46 02 01 01 00 00 00 00 00 00 00 00 00
+  Class:                             ELF64
+  Type:                              DYN (Shared object file)
+  Machine:                           AArch64
+
+Program Headers:
+  Type           Offset   VirtAddr           PhysAddr           FileSiz  MemSiz   Flg Align
+  PHDR           0x000040 0x0000000000000040 0x0000000000000040 0x000230 0x000230 R   0x8
+  LOAD           0x000000 0x0000000000000000 0x0000000000000000 0x0008fc 0x0008fc R   0x4000
+  LOAD           0x000900 0x0000000000004900 0x0000000000004900 0x000190 0x000190 R E 0x1000
+
+Dynamic section at offset 0x2a8 contains 3 entries:
+  Tag                Type                 Name/Value
+  0x0000000000000001 (NEEDED)             Shared library: [libc.so]
+  0x0000000000000001 (NEEDED)             Shared library: [libbar.so]
+  0x000000000000000e (SONAME)             Library soname: [libfoo.so]
+`
+
+func TestCheckElf(t *testing.T) {
+	info, err := parseReadelf(strings.NewReader(testReadelf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedInfo := &elfInfo{
+		machine:    "AArch64",
+		loadAligns: []uint64{0x4000, 0x1000},
+		neededLibs: []string{"libc.so", "libbar.so"},
+	}
+	if !reflect.DeepEqual(info, expectedInfo) {
+		t.Fatalf("expected %#v, got %#v", expectedInfo, info)
+	}
+
+	errs, err := checkElf(info, "arm64", "4096", []string{"libc.so", "libbar.so"})
+	if err != nil || len(errs) != 0 {
+		t.Errorf("unexpected errors %q, %v", errs, err)
+	}
+
+	errs, err = checkElf(info, "x86_64", "16384", []string{"libc.so"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`machine is "AArch64", expected "Advanced Micro Devices X86-64" for x86_64`,
+		"LOAD segment 1 is aligned to 4096, below the max page size 16384",
+		"NEEDED library libbar.so is not a shared library dependency of the module",
+	}
+	if !reflect.DeepEqual(errs, expected) {
+		t.Errorf("expected %q, got %q", expected, errs)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"android/soong/response"
+)
+
+// This tool merges the outputs of the static analysis of C and C++ sources into a SARIF file:
+// the SARIF files written by the clang static analyzer, the outputs of clang-tidy, which are
+// converted to SARIF, and the SARIF files previously merged by this tool. The paths of the
+// results are made relative to the top of the tree, and the results outside of the included
+// directories are dropped.
+
+type multiString []string
+
+func (s *multiString) String() string     { return strings.Join(*s, ",") }
+func (s *multiString) Set(v string) error { *s = append(*s, v); return nil }
+
+func main() {
+	var expandedArgs []string
+	for _, arg := range os.Args[1:] {
+		if strings.HasPrefix(arg, "@") {
+			f, err := os.Open(strings.TrimPrefix(arg, "@"))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+
+			respArgs, err := response.ReadRspFile(f)
+			f.Close()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			expandedArgs = append(expandedArgs, respArgs...)
+		} else {
+			expandedArgs = append(expandedArgs, arg)
+		}
+	}
+
+	flags := flag.NewFlagSet("flags", flag.ExitOnError)
+
+	// Hide the flag package to prevent accidental references to flag instead of flags.
+	flag := struct{}{}
+	_ = flag
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of %s:\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "  %s -o <output file> [-include <dir>]... [-exclude <dir>]... [<.sarif or clang-tidy output file>...]\n", os.Args[0])
+		fmt.Fprintln(flags.Output())
+
+		flags.PrintDefaults()
+	}
+
+	output := flags.String("o", "", "output SARIF file")
+	var filter pathFilter
+	flags.Var((*multiString)(&filter.include), "include", "keep only the results in the directory, can be repeated")
+	flags.Var((*multiString)(&filter.exclude), "exclude", "drop the results in the directory, can be repeated")
+
+	flags.Parse(expandedArgs)
+
+	if *output == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	if err := mergeSarif(*output, flags.Args(), filter); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to merge SARIF files: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func mergeSarif(output string, inputs []string, filter pathFilter) error {
+	top, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	m := newMerger(top, filter)
+	for _, input := range inputs {
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(input, ".sarif") {
+			runs, err := parseSarif(f)
+			if err != nil {
+				f.Close()
+				return fmt.Errorf("%s: %w", input, err)
+			}
+			for _, run := range runs {
+				m.add(run)
+			}
+		} else {
+			results, err := parseTidyLog(f)
+			if err != nil {
+				f.Close()
+				return fmt.Errorf("%s: %w", input, err)
+			}
+			m.add(sarifRun{
+				Tool:    sarifTool{Driver: sarifDriver{Name: clangTidyToolName}},
+				Results: results,
+			})
+		}
+		f.Close()
+	}
+
+	data, err := json.MarshalIndent(m.log(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(output, append(data, '\n'), 0666)
+}
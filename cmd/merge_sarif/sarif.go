@@ -0,0 +1,246 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+
+	// The base of the URIs of the results, which are relative to the top of the tree.
+	srcRootBaseId = "%SRCROOT%"
+
+	clangTidyToolName = "clang-tidy"
+)
+
+// The subset of SARIF 2.1.0 that clang-tidy diagnostics are converted to and that is kept from
+// the outputs of the static analyzer. The code flows of the results and the rules of the tools
+// are kept as they are.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string          `json:"name"`
+	Version        string          `json:"version,omitempty"`
+	InformationUri string          `json:"informationUri,omitempty"`
+	Rules          json.RawMessage `json:"rules,omitempty"`
+}
+
+type sarifResult struct {
+	RuleId    string          `json:"ruleId,omitempty"`
+	Level     string          `json:"level,omitempty"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+	CodeFlows json.RawMessage `json:"codeFlows,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	Uri       string `json:"uri"`
+	UriBaseId string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// A clang-tidy diagnostic: <file>:<line>:<column>: <warning|error>: <message> [<check>]
+var tidyDiagnosticRegexp = regexp.MustCompile(`^(\S[^:]*):(\d+):(\d+): (warning|error): (.*) \[([^\]]+)\]$`)
+
+// parseTidyLog converts the diagnostics of the output of clang-tidy into the results of a SARIF
+// run. The notes that follow them and the other lines are ignored.
+func parseTidyLog(r io.Reader) ([]sarifResult, error) {
+	var results []sarifResult
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		match := tidyDiagnosticRegexp.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		line, _ := strconv.Atoi(match[2])
+		column, _ := strconv.Atoi(match[3])
+		results = append(results, sarifResult{
+			RuleId:  match[6],
+			Level:   match[4],
+			Message: sarifMessage{Text: match[5]},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{Uri: match[1]},
+					Region:           &sarifRegion{StartLine: line, StartColumn: column},
+				},
+			}},
+		})
+	}
+	return results, scanner.Err()
+}
+
+// parseSarif reads the runs of a SARIF file.
+func parseSarif(r io.Reader) ([]sarifRun, error) {
+	var log sarifLog
+	if err := json.NewDecoder(r).Decode(&log); err != nil {
+		return nil, err
+	}
+	return log.Runs, nil
+}
+
+// relativeUri returns the path of a result relative to the top of the tree, top being its
+// absolute path, or "" if the path is outside of the tree.
+func relativeUri(uri, top string) string {
+	path := strings.TrimPrefix(uri, "file://")
+	if !filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	rel, err := filepath.Rel(top, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return ""
+	}
+	return rel
+}
+
+// pathFilter selects the results by the path of their first location, relative to the top of
+// the tree.
+type pathFilter struct {
+	include, exclude []string
+}
+
+// longestMatchingDir returns the length of the longest of dirs that path is in, or -1.
+func longestMatchingDir(path string, dirs []string) int {
+	longest := -1
+	for _, dir := range dirs {
+		dir = strings.TrimSuffix(dir, "/") + "/"
+		if strings.HasPrefix(path, dir) && len(dir) > longest {
+			longest = len(dir)
+		}
+	}
+	return longest
+}
+
+// matches returns true if the path is in the included directories, or if there are none, and
+// not in a more specific excluded directory.
+func (f pathFilter) matches(path string) bool {
+	included := 0
+	if len(f.include) > 0 {
+		included = longestMatchingDir(path, f.include)
+	}
+	return included >= 0 && included > longestMatchingDir(path, f.exclude)
+}
+
+// merger merges the runs of the same tool, dropping the duplicate results of the headers included
+// by several sources.
+type merger struct {
+	top    string
+	filter pathFilter
+
+	runs map[string]*sarifRun
+	seen map[string]bool
+}
+
+func newMerger(top string, filter pathFilter) *merger {
+	return &merger{
+		top:    top,
+		filter: filter,
+		runs:   make(map[string]*sarifRun),
+		seen:   make(map[string]bool),
+	}
+}
+
+// add merges the results of a run, with their locations made relative to the top of the tree.
+func (m *merger) add(run sarifRun) {
+	merged := m.runs[run.Tool.Driver.Name]
+	if merged == nil {
+		merged = &sarifRun{Tool: run.Tool, Results: []sarifResult{}}
+		m.runs[run.Tool.Driver.Name] = merged
+	} else if len(merged.Tool.Driver.Rules) == 0 {
+		merged.Tool.Driver.Rules = run.Tool.Driver.Rules
+	}
+
+	for _, result := range run.Results {
+		for i := range result.Locations {
+			artifact := &result.Locations[i].PhysicalLocation.ArtifactLocation
+			if artifact.UriBaseId == "" {
+				artifact.Uri = relativeUri(artifact.Uri, m.top)
+				artifact.UriBaseId = srcRootBaseId
+			}
+		}
+		if len(result.Locations) > 0 {
+			uri := result.Locations[0].PhysicalLocation.ArtifactLocation.Uri
+			if uri == "" || !m.filter.matches(uri) {
+				continue
+			}
+		}
+
+		key, _ := json.Marshal(struct {
+			Tool   string
+			Result sarifResult
+		}{run.Tool.Driver.Name, result})
+		if m.seen[string(key)] {
+			continue
+		}
+		m.seen[string(key)] = true
+		merged.Results = append(merged.Results, result)
+	}
+}
+
+// log returns the merged runs, sorted by the names of their tools.
+func (m *merger) log() sarifLog {
+	log := sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{}}
+	names := make([]string, 0, len(m.runs))
+	for name := range m.runs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		log.Runs = append(log.Runs, *m.runs[name])
+	}
+	return log
+}
@@ -0,0 +1,103 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTidyLog(t *testing.T) {
+	log := `
+vendor/foo/foo.cpp:12:5: warning: use nullptr [modernize-use-nullptr]
+    return NULL;
+    ^~~~
+vendor/foo/foo.h:3:1: note: expanded from here
+vendor/foo/foo.cpp:20:10: error: do not use 'else' after 'return' [readability-else-after-return,-warnings-as-errors]
+1 warning and 1 error generated.
+`
+	results, err := parseTidyLog(strings.NewReader(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range results {
+		loc := r.Locations[0].PhysicalLocation
+		got = append(got, strings.Join([]string{r.RuleId, r.Level, r.Message.Text, loc.ArtifactLocation.Uri}, "|"))
+		if loc.Region.StartLine == 0 || loc.Region.StartColumn == 0 {
+			t.Errorf("missing region in %v", r)
+		}
+	}
+	want := []string{
+		"modernize-use-nullptr|warning|use nullptr|vendor/foo/foo.cpp",
+		"readability-else-after-return,-warnings-as-errors|error|do not use 'else' after 'return'|vendor/foo/foo.cpp",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestMerger(t *testing.T) {
+	analyzerRun := `{"runs": [{
+		"tool": {"driver": {"name": "clang", "rules": [{"id": "core.NullDereference"}]}},
+		"results": [
+			{"ruleId": "core.NullDereference", "message": {"text": "null"},
+			 "locations": [{"physicalLocation": {"artifactLocation": {"uri": "file:///top/vendor/foo/foo.h"},
+			                                     "region": {"startLine": 4}}}]},
+			{"ruleId": "core.NullDereference", "message": {"text": "null"},
+			 "locations": [{"physicalLocation": {"artifactLocation": {"uri": "file:///top/vendor/foo/legacy/old.c"},
+			                                     "region": {"startLine": 8}}}]},
+			{"ruleId": "core.NullDereference", "message": {"text": "null"},
+			 "locations": [{"physicalLocation": {"artifactLocation": {"uri": "file:///usr/include/stdio.h"},
+			                                     "region": {"startLine": 1}}}]}
+		]
+	}]}`
+
+	m := newMerger("/top", pathFilter{include: []string{"vendor/foo"}, exclude: []string{"vendor/foo/legacy"}})
+	for i := 0; i < 2; i++ {
+		runs, err := parseSarif(strings.NewReader(analyzerRun))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, run := range runs {
+			m.add(run)
+		}
+	}
+	tidyResults, err := parseTidyLog(strings.NewReader("vendor/foo/foo.cpp:1:1: warning: w [misc-check]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.add(sarifRun{Tool: sarifTool{Driver: sarifDriver{Name: clangTidyToolName}}, Results: tidyResults})
+
+	log := m.log()
+	var got []string
+	for _, run := range log.Runs {
+		for _, r := range run.Results {
+			artifact := r.Locations[0].PhysicalLocation.ArtifactLocation
+			got = append(got, run.Tool.Driver.Name+"|"+artifact.UriBaseId+"|"+artifact.Uri)
+		}
+	}
+	want := []string{
+		"clang|%SRCROOT%|vendor/foo/foo.h",
+		"clang-tidy|%SRCROOT%|vendor/foo/foo.cpp",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if string(log.Runs[0].Tool.Driver.Rules) != `[{"id": "core.NullDereference"}]` {
+		t.Errorf("expected the rules of the analyzer to be kept, got %s", log.Runs[0].Tool.Driver.Rules)
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// The subset of the JSON module graph written by soong_build --module_graph_file that is used by
+// the queries.
+
+type variation struct {
+	Mutator   string
+	Variation string
+}
+
+type jsonModuleName struct {
+	Name       string
+	Variant    string
+	Variations []variation
+}
+
+type jsonDep struct {
+	jsonModuleName
+	Tag string
+}
+
+type jsonModule struct {
+	jsonModuleName
+	Deps      []jsonDep
+	Type      string
+	Blueprint string
+	Module    map[string]interface{}
+}
+
+// moduleKey identifies a variant of a module.
+type moduleKey struct {
+	Name    string
+	Variant string
+}
+
+// Dep is a dependency edge returned by the deps and rdeps queries.
+type Dep struct {
+	Name    string
+	Variant string
+	Tag     string `json:",omitempty"`
+}
+
+// graph is the module graph indexed for the queries.
+type graph struct {
+	modules  map[moduleKey]*jsonModule
+	variants map[string][]*jsonModule
+	rdeps    map[moduleKey][]Dep
+}
+
+func variantOf(name jsonModuleName) string {
+	if name.Variant != "" {
+		return name.Variant
+	}
+	// Older module graphs don't have the variant names, build one from the variations.
+	variant := ""
+	for _, v := range name.Variations {
+		if v.Variation == "" {
+			continue
+		}
+		if variant != "" {
+			variant += "_"
+		}
+		variant += v.Variation
+	}
+	return variant
+}
+
+// loadGraph reads and indexes the JSON module graph.
+func loadGraph(r io.Reader) (*graph, error) {
+	var modules []*jsonModule
+	if err := json.NewDecoder(r).Decode(&modules); err != nil {
+		return nil, fmt.Errorf("failed to parse the module graph: %s", err)
+	}
+
+	g := &graph{
+		modules:  make(map[moduleKey]*jsonModule),
+		variants: make(map[string][]*jsonModule),
+		rdeps:    make(map[moduleKey][]Dep),
+	}
+	for _, m := range modules {
+		key := moduleKey{m.Name, variantOf(m.jsonModuleName)}
+		g.modules[key] = m
+		g.variants[m.Name] = append(g.variants[m.Name], m)
+		for _, d := range m.Deps {
+			depKey := moduleKey{d.Name, variantOf(d.jsonModuleName)}
+			g.rdeps[depKey] = append(g.rdeps[depKey], Dep{Name: key.Name, Variant: key.Variant, Tag: d.Tag})
+		}
+	}
+	return g, nil
+}
+
+// lookup returns the variants of the module, or only the given one if variant is not empty.
+func (g *graph) lookup(name, variant string) ([]*jsonModule, error) {
+	if variant != "" {
+		if m, ok := g.modules[moduleKey{name, variant}]; ok {
+			return []*jsonModule{m}, nil
+		}
+		return nil, fmt.Errorf("no variant %q of module %q", variant, name)
+	}
+	if variants, ok := g.variants[name]; ok {
+		return variants, nil
+	}
+	return nil, fmt.Errorf("no module %q", name)
+}
+
+// deps returns the direct dependencies of the module.
+func (g *graph) deps(name, variant string) ([]Dep, error) {
+	modules, err := g.lookup(name, variant)
+	if err != nil {
+		return nil, err
+	}
+	var deps []Dep
+	for _, m := range modules {
+		for _, d := range m.Deps {
+			deps = append(deps, Dep{Name: d.Name, Variant: variantOf(d.jsonModuleName), Tag: d.Tag})
+		}
+	}
+	return uniqueDeps(deps), nil
+}
+
+// reverseDeps returns the modules that depend directly on the module.
+func (g *graph) reverseDeps(name, variant string) ([]Dep, error) {
+	modules, err := g.lookup(name, variant)
+	if err != nil {
+		return nil, err
+	}
+	var rdeps []Dep
+	for _, m := range modules {
+		rdeps = append(rdeps, g.rdeps[moduleKey{m.Name, variantOf(m.jsonModuleName)}]...)
+	}
+	return uniqueDeps(rdeps), nil
+}
+
+// path returns a shortest dependency path from any variant of the module from to any variant of
+// the module to, or nil if from doesn't depend on to.
+func (g *graph) path(from, to string) ([]Dep, error) {
+	starts, err := g.lookup(from, "")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := g.lookup(to, ""); err != nil {
+		return nil, err
+	}
+
+	parents := make(map[moduleKey]moduleKey)
+	var queue []moduleKey
+	for _, m := range starts {
+		key := moduleKey{m.Name, variantOf(m.jsonModuleName)}
+		parents[key] = key
+		queue = append(queue, key)
+	}
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		if key.Name == to {
+			var path []Dep
+			for {
+				path = append([]Dep{{Name: key.Name, Variant: key.Variant}}, path...)
+				if parents[key] == key {
+					return path, nil
+				}
+				key = parents[key]
+			}
+		}
+		m, ok := g.modules[key]
+		if !ok {
+			continue
+		}
+		for _, d := range m.Deps {
+			depKey := moduleKey{d.Name, variantOf(d.jsonModuleName)}
+			if _, seen := parents[depKey]; !seen {
+				parents[depKey] = key
+				queue = append(queue, depKey)
+			}
+		}
+	}
+	return nil, nil
+}
+
+// ModuleProperties is returned by the properties query for each variant of the module.
+type ModuleProperties struct {
+	Name       string
+	Variant    string
+	Type       string
+	Blueprint  string
+	Properties map[string]interface{} `json:",omitempty"`
+}
+
+// properties returns the type, the Android.bp file and the properties of the module.
+func (g *graph) properties(name, variant string) ([]ModuleProperties, error) {
+	modules, err := g.lookup(name, variant)
+	if err != nil {
+		return nil, err
+	}
+	var ret []ModuleProperties
+	for _, m := range modules {
+		ret = append(ret, ModuleProperties{
+			Name:       m.Name,
+			Variant:    variantOf(m.jsonModuleName),
+			Type:       m.Type,
+			Blueprint:  m.Blueprint,
+			Properties: m.Module,
+		})
+	}
+	return ret, nil
+}
+
+func uniqueDeps(deps []Dep) []Dep {
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].Name != deps[j].Name {
+			return deps[i].Name < deps[j].Name
+		}
+		if deps[i].Variant != deps[j].Variant {
+			return deps[i].Variant < deps[j].Variant
+		}
+		return deps[i].Tag < deps[j].Tag
+	})
+	var ret []Dep
+	for i, d := range deps {
+		if i == 0 || d != deps[i-1] {
+			ret = append(ret, d)
+		}
+	}
+	return ret
+}
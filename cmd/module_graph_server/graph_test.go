@@ -0,0 +1,119 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testGraph = `[
+	{
+		"Name": "app", "Variant": "android_common", "Type": "android_app", "Blueprint": "app/Android.bp",
+		"Deps": [{"Name": "libfoo", "Variant": "android_arm64_shared", "Tag": "jni"}],
+		"Module": {"Sdk_version": "current"}
+	},
+	{
+		"Name": "libfoo", "Variant": "android_arm64_shared", "Type": "cc_library", "Blueprint": "foo/Android.bp",
+		"Deps": [{"Name": "libbar", "Variant": "android_arm64_shared", "Tag": "shared"}]
+	},
+	{
+		"Name": "libfoo", "Variant": "android_arm64_static", "Type": "cc_library", "Blueprint": "foo/Android.bp",
+		"Deps": [{"Name": "libbar", "Variant": "android_arm64_shared", "Tag": "shared"}]
+	},
+	{
+		"Name": "libbar", "Variant": "android_arm64_shared", "Type": "cc_library", "Blueprint": "bar/Android.bp"
+	}
+]`
+
+func TestGraphQueries(t *testing.T) {
+	g, err := loadGraph(strings.NewReader(testGraph))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deps, err := g.deps("libfoo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []Dep{{"libbar", "android_arm64_shared", "shared"}}; !reflect.DeepEqual(deps, expected) {
+		t.Errorf("deps: expected %v, got %v", expected, deps)
+	}
+
+	rdeps, err := g.reverseDeps("libbar", "android_arm64_shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedRdeps := []Dep{
+		{"libfoo", "android_arm64_shared", "shared"},
+		{"libfoo", "android_arm64_static", "shared"},
+	}
+	if !reflect.DeepEqual(rdeps, expectedRdeps) {
+		t.Errorf("rdeps: expected %v, got %v", expectedRdeps, rdeps)
+	}
+
+	path, err := g.path("app", "libbar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedPath := []Dep{
+		{Name: "app", Variant: "android_common"},
+		{Name: "libfoo", Variant: "android_arm64_shared"},
+		{Name: "libbar", Variant: "android_arm64_shared"},
+	}
+	if !reflect.DeepEqual(path, expectedPath) {
+		t.Errorf("path: expected %v, got %v", expectedPath, path)
+	}
+	if path, _ := g.path("libbar", "app"); path != nil {
+		t.Errorf("path: expected no path from libbar to app, got %v", path)
+	}
+
+	if _, err := g.deps("libfoo", "android_arm64_static_apex"); err == nil {
+		t.Errorf("deps: expected an error for a missing variant")
+	}
+}
+
+func TestServer(t *testing.T) {
+	graphFile := filepath.Join(t.TempDir(), "module-graph.json")
+	if err := os.WriteFile(graphFile, []byte(testGraph), 0666); err != nil {
+		t.Fatal(err)
+	}
+	s := &server{graphFile: graphFile}
+
+	for _, tc := range []struct {
+		query    string
+		status   int
+		contains string
+	}{
+		{"/properties?module=app", http.StatusOK, `"Sdk_version":"current"`},
+		{"/deps?module=app", http.StatusOK, `"Tag":"jni"`},
+		{"/deps?module=missing", http.StatusNotFound, `no module \"missing\"`},
+		{"/path?from=app", http.StatusBadRequest, `missing parameter \"to\"`},
+	} {
+		w := httptest.NewRecorder()
+		s.handler().ServeHTTP(w, httptest.NewRequest("GET", tc.query, nil))
+		if w.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.query, tc.status, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), tc.contains) {
+			t.Errorf("%s: expected %q in %q", tc.query, tc.contains, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"android/soong/shared"
+)
+
+// This tool serves queries on the JSON module graph written by `m json-module-graph` from memory,
+// so that developer tools don't need to run soong_build, or parse the large module graph, for
+// every query. The module graph is reloaded when it is regenerated. The queries are HTTP GET
+// requests that return JSON:
+//
+//	/deps?module=<name>[&variant=<variant>]        the direct dependencies of the module
+//	/rdeps?module=<name>[&variant=<variant>]       the modules that depend directly on the module
+//	/path?from=<name>&to=<name>                    a shortest dependency path between the modules
+//	/properties?module=<name>[&variant=<variant>]  the type, Android.bp file and properties
+
+type server struct {
+	graphFile string
+	zstd      string
+
+	mu      sync.Mutex
+	graph   *graph
+	modTime time.Time
+}
+
+// currentGraph returns the module graph, reloading it if the file changed since it was loaded.
+func (s *server) currentGraph() (*graph, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.graphFile)
+	if err != nil {
+		return nil, err
+	}
+	if s.graph != nil && info.ModTime().Equal(s.modTime) {
+		return s.graph, nil
+	}
+
+	start := time.Now()
+	r, err := shared.OpenDecompressed(s.graphFile, s.zstd)
+	if err != nil {
+		return nil, err
+	}
+	g, err := loadGraph(r)
+	if closeErr := r.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("loaded %d modules from %s in %s", len(g.modules), s.graphFile, time.Since(start))
+	s.graph, s.modTime = g, info.ModTime()
+	return g, nil
+}
+
+type queryFunc func(g *graph, query map[string]string) (interface{}, error)
+
+// handle returns the handler of a query with the given required and optional parameters.
+func (s *server) handle(query queryFunc, required []string, optional ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		params := make(map[string]string)
+		for _, p := range required {
+			params[p] = req.FormValue(p)
+			if params[p] == "" {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("missing parameter %q", p))
+				return
+			}
+		}
+		for _, p := range optional {
+			params[p] = req.FormValue(p)
+		}
+
+		g, err := s.currentGraph()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		result, err := query(g, params)
+		if err != nil {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct{ Error string }{err.Error()})
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/deps", s.handle(func(g *graph, q map[string]string) (interface{}, error) {
+		return g.deps(q["module"], q["variant"])
+	}, []string{"module"}, "variant"))
+	mux.Handle("/rdeps", s.handle(func(g *graph, q map[string]string) (interface{}, error) {
+		return g.reverseDeps(q["module"], q["variant"])
+	}, []string{"module"}, "variant"))
+	mux.Handle("/path", s.handle(func(g *graph, q map[string]string) (interface{}, error) {
+		return g.path(q["from"], q["to"])
+	}, []string{"from", "to"}))
+	mux.Handle("/properties", s.handle(func(g *graph, q map[string]string) (interface{}, error) {
+		return g.properties(q["module"], q["variant"])
+	}, []string{"module"}, "variant"))
+	return mux
+}
+
+func main() {
+	graphFile := flag.String("graph", "out/soong/module-graph.json", "JSON module graph written by soong_build")
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	zstd := flag.String("zstd", "", "zstd tool, to read a module graph compressed with zstd")
+	flag.Parse()
+
+	s := &server{graphFile: *graphFile, zstd: *zstd}
+	// Load the module graph before serving so that the first query is fast too.
+	if _, err := s.currentGraph(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	log.Printf("serving module graph queries on http://%s", *addr)
+	if err := http.ListenAndServe(*addr, s.handler()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
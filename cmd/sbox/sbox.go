@@ -43,6 +43,7 @@ var (
 	manifestFile   string
 	keepOutDir     bool
 	writeIfChanged bool
+	noNetwork      bool
 )
 
 const (
@@ -61,6 +62,8 @@ func init() {
 		"whether to keep the sandbox directory when done")
 	flag.BoolVar(&writeIfChanged, "write-if-changed", false,
 		"only write the output files if they have changed")
+	flag.BoolVar(&noNetwork, "no-network", false,
+		"run the commands without network access where the host supports it")
 }
 
 func usageViolation(violation string) {
@@ -309,7 +312,11 @@ func runCommand(command *sbox_proto.Command, tempDir string, commandIndex int) (
 			return "", fmt.Errorf("Failed to update PATH: %w", err)
 		}
 	}
-	err = cmd.Run()
+	if noNetwork {
+		err = runWithoutNetwork(cmd)
+	} else {
+		err = cmd.Run()
+	}
 
 	if err != nil {
 		// The command failed, do a best effort copy of output files out of the sandbox.  This is
@@ -67,11 +67,16 @@ func init() {
 	flag.StringVar(&cmdlineArgs.TraceFile, "trace", "", "write trace to file")
 	flag.StringVar(&cmdlineArgs.Memprofile, "memprofile", "", "write memory profile to file")
 	flag.BoolVar(&cmdlineArgs.NoGC, "nogc", false, "turn off GC for debugging")
+	flag.BoolVar(&cmdlineArgs.SerializeMutators, "serialize-mutators", false, "run mutators on one module at a time for debugging")
+	flag.StringVar(&cmdlineArgs.MutatorCheckpoints, "mutator-checkpoints", "", "dump the state of the modules after the given mutators to out/soong/mutator_checkpoints for comparison, builds can't be resumed from them. Comma-delimited")
 
 	// Flags representing various modes soong_build can run in
 	flag.StringVar(&cmdlineArgs.ModuleGraphFile, "module_graph_file", "", "JSON module graph file to output")
 	flag.StringVar(&cmdlineArgs.ModuleActionsFile, "module_actions_file", "", "JSON file to output inputs/outputs of actions of modules")
+	flag.StringVar(&cmdlineArgs.OutputCompression, "output-compression", "", "compress the JSON module graph and actions files with gzip or zstd. The ninja files are never compressed")
+	flag.StringVar(&cmdlineArgs.ZstdTool, "zstd", "", "path of the zstd tool used by --output-compression=zstd")
 	flag.StringVar(&cmdlineArgs.DocFile, "soong_docs", "", "build documentation file to output")
+	flag.StringVar(&cmdlineArgs.ConfigDumpFile, "config-dump", "", "If set, write the effective configuration as JSON to the specified file then exit")
 	flag.StringVar(&cmdlineArgs.BazelQueryViewDir, "bazel_queryview_dir", "", "path to the bazel queryview directory relative to --top")
 	flag.StringVar(&cmdlineArgs.BazelApiBp2buildDir, "bazel_api_bp2build_dir", "", "path to the bazel api_bp2build directory relative to --top")
 	flag.StringVar(&cmdlineArgs.Bp2buildMarker, "bp2build_marker", "", "If set, run bp2build, touch the specified marker file then exit")
@@ -85,6 +90,12 @@ func init() {
 	flag.BoolVar(&cmdlineArgs.BazelModeDev, "bazel-mode-dev", false, "use bazel for analysis of a large number of modules (less stable)")
 	flag.BoolVar(&cmdlineArgs.UseBazelProxy, "use-bazel-proxy", false, "communicate with bazel using unix socket proxy instead of spawning subprocesses")
 	flag.BoolVar(&cmdlineArgs.BuildFromTextStub, "build-from-text-stub", false, "build Java stubs from API text files instead of source files")
+	flag.BoolVar(&cmdlineArgs.ModuleEnvDepsReport, "module-env-deps-report", false, "write a report of the modules that read each environment variable")
+	flag.BoolVar(&cmdlineArgs.ProductVariableDepsReport, "product-variable-deps-report", false, "write a report of the modules that read each product variable")
+	flag.BoolVar(&cmdlineArgs.AnalysisTimesReport, "analysis-times-report", false, "write a report of the time spent generating the build actions of each directory")
+	flag.BoolVar(&cmdlineArgs.PathToolsAudit, "path-tools-audit", false, "write a report of the tools that the commands of each module run from the PATH")
+	flag.StringVar(&cmdlineArgs.ExtraVariablesFile, "extra-variables-file", "", "JSON product variables file applied on top of soong.variables and soong.variables.d/")
+	flag.StringVar(&cmdlineArgs.BuildFlagsFile, "build-flags-file", "", "JSON file that declares the build flags, defaults to build_flags.json in the soong output directory")
 
 	// Flags that probably shouldn't be flags of soong_build, but we haven't found
 	// the time to remove them yet
@@ -289,13 +300,19 @@ func writeMetrics(configuration android.Config, eventHandler *metrics.EventHandl
 }
 
 func writeJsonModuleGraphAndActions(ctx *android.Context, cmdArgs android.CmdArgs) {
-	graphFile, graphErr := os.Create(shared.JoinPath(topDir, cmdArgs.ModuleGraphFile))
+	compression, err := shared.ParseCompression(cmdArgs.OutputCompression)
+	maybeQuit(err, "invalid --output-compression")
+	zstd := cmdArgs.ZstdTool
+	if zstd != "" {
+		zstd = shared.JoinPath(topDir, zstd)
+	}
+	graphFile, graphErr := shared.CreateCompressed(shared.JoinPath(topDir, cmdArgs.ModuleGraphFile), compression, zstd)
 	maybeQuit(graphErr, "graph err")
-	defer graphFile.Close()
-	actionsFile, actionsErr := os.Create(shared.JoinPath(topDir, cmdArgs.ModuleActionsFile))
+	actionsFile, actionsErr := shared.CreateCompressed(shared.JoinPath(topDir, cmdArgs.ModuleActionsFile), compression, zstd)
 	maybeQuit(actionsErr, "actions err")
-	defer actionsFile.Close()
 	ctx.Context.PrintJSONGraphAndActions(graphFile, actionsFile)
+	maybeQuit(graphFile.Close(), "error writing module graph '%s'", cmdArgs.ModuleGraphFile)
+	maybeQuit(actionsFile.Close(), "error writing module actions '%s'", cmdArgs.ModuleActionsFile)
 }
 
 func writeBuildGlobsNinjaFile(ctx *android.Context) []string {
@@ -320,6 +337,20 @@ func writeDepFile(outputFile string, eventHandler *metrics.EventHandler, ninjaDe
 	maybeQuit(err, "error writing depfile '%s'", depFile)
 }
 
+// runConfigDump writes the effective configuration to the file passed with
+// --config-dump, without parsing any Android.bp files.
+func runConfigDump(ctx *android.Context, extraNinjaDeps []string) string {
+	ctx.EventHandler.Begin("config_dump")
+	defer ctx.EventHandler.End("config_dump")
+
+	data, err := ctx.Config().DumpEffectiveConfig()
+	maybeQuit(err, "")
+	err = os.WriteFile(shared.JoinPath(topDir, cmdlineArgs.ConfigDumpFile), data, 0666)
+	maybeQuit(err, "error writing config dump '%s'", cmdlineArgs.ConfigDumpFile)
+	writeDepFile(cmdlineArgs.ConfigDumpFile, ctx.EventHandler, extraNinjaDeps)
+	return cmdlineArgs.ConfigDumpFile
+}
+
 // runSoongOnlyBuild runs the standard Soong build in a number of different modes.
 func runSoongOnlyBuild(ctx *android.Context, extraNinjaDeps []string) string {
 	ctx.EventHandler.Begin("soong_build")
@@ -397,14 +428,36 @@ func main() {
 	shared.ReexecWithDelveMaybe(delveListen, delvePath)
 	android.InitSandbox(topDir)
 
+	if _, err := os.Stat(shared.JoinPath(topDir, cmdlineArgs.OutFile)); os.IsNotExist(err) {
+		// There is no ninja file yet, so this is a first build and the Blueprint files are
+		// likely not in the page cache. Read them ahead of the parser.
+		go prefetchBlueprintFiles(topDir, cmdlineArgs.ModuleListFile)
+	}
+
+	// Fail early, naming the plugins whose plugin API version this Soong doesn't support.
+	maybeQuit(android.CheckPluginApiVersions(), "")
+
 	availableEnv := parseAvailableEnv()
 	configuration, err := android.NewConfig(cmdlineArgs, availableEnv)
 	maybeQuit(err, "")
+	if unknown := configuration.UnknownMutatorCheckpoints(); len(unknown) > 0 {
+		fmt.Fprintf(os.Stderr, "--mutator-checkpoints: unknown mutators %s\n", strings.Join(unknown, ", "))
+		flag.Usage()
+		os.Exit(2)
+	}
 	if configuration.Getenv("ALLOW_MISSING_DEPENDENCIES") == "true" {
 		configuration.SetAllowMissingDependencies()
 	}
 
 	extraNinjaDeps := []string{configuration.ProductVariablesFileName, usedEnvFile}
+	extraNinjaDeps = append(extraNinjaDeps, configuration.ProductVariablesFragments()...)
+	if _, err := os.Stat(shared.JoinPath(topDir, configuration.ProductVariablesFragmentsDir())); err == nil {
+		// Depend on the directory itself so that adding or removing a fragment reruns soong_build.
+		extraNinjaDeps = append(extraNinjaDeps, configuration.ProductVariablesFragmentsDir())
+	}
+	if _, err := os.Stat(shared.JoinPath(topDir, configuration.BuildFlagsFile())); err == nil {
+		extraNinjaDeps = append(extraNinjaDeps, configuration.BuildFlagsFile())
+	}
 	if shared.IsDebugging() {
 		// Add a non-existent file to the dependencies so that soong_build will rerun when the debugger is
 		// enabled even if it completed successfully.
@@ -415,6 +468,18 @@ func main() {
 	// change between every CI build, so tracking it would require re-running Soong for every build.
 	metricsDir := availableEnv["LOG_DIR"]
 
+	// The memory limit doesn't change the outputs, SOONG_BUILD_MEMORY_LIMIT bypasses
+	// configuration.Getenv for the same reason as LOG_DIR.
+	memoryLimit := availableEnv["SOONG_BUILD_MEMORY_LIMIT"]
+	if memoryLimit == "" {
+		memoryLimit = configuration.SoongBuildMemoryLimit()
+	}
+	if memoryLimit != "" {
+		limit, err := parseMemoryLimit(memoryLimit)
+		maybeQuit(err, "invalid soong_build memory limit %q", memoryLimit)
+		startMemoryThrottle(limit)
+	}
+
 	ctx := newContext(configuration)
 
 	var finalOutputFile string
@@ -431,6 +496,8 @@ func main() {
 	case android.ApiBp2build:
 		finalOutputFile = runApiBp2build(ctx, extraNinjaDeps)
 		writeMetrics(configuration, ctx.EventHandler, metricsDir)
+	case android.DumpConfig:
+		finalOutputFile = runConfigDump(ctx, extraNinjaDeps)
 	default:
 		ctx.Register()
 		if configuration.IsMixedBuildsEnabled() {
@@ -444,6 +511,10 @@ func main() {
 		writeMetrics(configuration, ctx.EventHandler, metricsDir)
 	}
 	writeUsedEnvironmentFile(configuration)
+	writeModuleEnvDepsReport(configuration)
+	writeProductVariableDepsReport(configuration)
+	writeAnalysisTimesReport(configuration)
+	writePathToolsAuditReport(configuration)
 
 	// Touch the output file so that it's the newest file created by soong_build.
 	// This is necessary because, if soong_build generated any files which
@@ -473,6 +544,62 @@ func writeUsedEnvironmentFile(configuration android.Config) {
 	maybeQuit(err, "error writing used environment file '%s'", usedEnvFile)
 }
 
+// writeModuleEnvDepsReport writes the modules that read each environment variable to
+// out/soong/module_env_deps.json when --module-env-deps-report is passed.
+func writeModuleEnvDepsReport(configuration android.Config) {
+	if !configuration.ModuleEnvDepsReportEnabled() {
+		return
+	}
+
+	data, err := configuration.ModuleEnvDepsReport()
+	maybeQuit(err, "")
+	path := shared.JoinPath(topDir, configuration.SoongOutDir(), android.ModuleEnvDepsReportFileName)
+	err = os.WriteFile(path, data, 0666)
+	maybeQuit(err, "error writing module env deps report '%s'", path)
+}
+
+// writeProductVariableDepsReport writes the modules that read each product variable to
+// out/soong/product_variable_deps.json when --product-variable-deps-report is passed.
+func writeProductVariableDepsReport(configuration android.Config) {
+	if !configuration.ProductVariableDepsReportEnabled() {
+		return
+	}
+
+	data, err := configuration.ProductVariableDepsReport()
+	maybeQuit(err, "")
+	path := shared.JoinPath(topDir, configuration.SoongOutDir(), android.ProductVariableDepsReportFileName)
+	err = os.WriteFile(path, data, 0666)
+	maybeQuit(err, "error writing product variable deps report '%s'", path)
+}
+
+// writeAnalysisTimesReport writes the time spent generating the build actions of the modules of
+// each directory to out/soong/analysis_times.json when --analysis-times-report is passed.
+func writeAnalysisTimesReport(configuration android.Config) {
+	if !configuration.AnalysisTimesReportEnabled() {
+		return
+	}
+
+	data, err := configuration.AnalysisTimesReport()
+	maybeQuit(err, "")
+	path := shared.JoinPath(topDir, configuration.SoongOutDir(), android.AnalysisTimesReportFileName)
+	err = os.WriteFile(path, data, 0666)
+	maybeQuit(err, "error writing analysis times report '%s'", path)
+}
+
+// writePathToolsAuditReport writes the tools that the commands of each module run from the PATH
+// to out/soong/path_tools_audit.json when --path-tools-audit is passed.
+func writePathToolsAuditReport(configuration android.Config) {
+	if !configuration.PathToolsAuditEnabled() {
+		return
+	}
+
+	data, err := configuration.PathToolsAuditReport()
+	maybeQuit(err, "")
+	path := shared.JoinPath(topDir, configuration.SoongOutDir(), android.PathToolsAuditReportFileName)
+	err = os.WriteFile(path, data, 0666)
+	maybeQuit(err, "error writing path tools audit report '%s'", path)
+}
+
 func touch(path string) {
 	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
 	maybeQuit(err, "Error touching '%s'", path)
@@ -0,0 +1,102 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The analysis of large trees can need more memory than developer machines have when the mutators
+// run on all the CPUs at once. With a memory limit, soong_build watches its own RSS and lowers the
+// number of goroutines running the mutators in parallel when it gets close to the limit, raising
+// it again once the memory is freed, instead of being killed by the OOM killer.
+
+const (
+	// The period of the RSS measurements.
+	memoryThrottleInterval = 250 * time.Millisecond
+
+	// The fractions of the limit above which the parallelism is halved, and below which it is
+	// doubled.
+	memoryThrottleHigh = 0.85
+	memoryThrottleLow  = 0.65
+)
+
+// parseMemoryLimit parses a number of bytes with an optional k, m or g suffix.
+func parseMemoryLimit(s string) (uint64, error) {
+	multiplier := uint64(1)
+	switch {
+	case strings.HasSuffix(s, "k"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(s, "m"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(s, "g"):
+		multiplier = 1 << 30
+	}
+	if multiplier != 1 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("expected a number of bytes with an optional k, m or g suffix")
+	}
+	return n * multiplier, nil
+}
+
+// throttledProcs returns the number of threads running goroutines for a process using rss bytes
+// of memory out of limit, currently running procs of at most maxProcs threads.
+func throttledProcs(procs, maxProcs int, rss, limit uint64) int {
+	if float64(rss) > memoryThrottleHigh*float64(limit) && procs > 1 {
+		return procs / 2
+	}
+	if float64(rss) < memoryThrottleLow*float64(limit) && procs < maxProcs {
+		if procs*2 > maxProcs {
+			return maxProcs
+		}
+		return procs * 2
+	}
+	return procs
+}
+
+// startMemoryThrottle makes the garbage collector run more often near limit, and lowers the number
+// of threads running the goroutines of soong_build while its RSS is close to limit.
+func startMemoryThrottle(limit uint64) {
+	debug.SetMemoryLimit(int64(limit))
+	if readRSS() == 0 {
+		// The RSS isn't available on this OS, only the garbage collector is tuned.
+		return
+	}
+
+	maxProcs := runtime.GOMAXPROCS(0)
+	go func() {
+		procs := maxProcs
+		for range time.Tick(memoryThrottleInterval) {
+			rss := readRSS()
+			if newProcs := throttledProcs(procs, maxProcs, rss, limit); newProcs != procs {
+				if newProcs < procs {
+					fmt.Fprintf(os.Stderr, "soong_build is using %d MB out of its %d MB memory limit, running on %d threads\n",
+						rss>>20, limit>>20, newProcs)
+				}
+				runtime.GOMAXPROCS(newProcs)
+				procs = newProcs
+			}
+		}
+	}()
+}
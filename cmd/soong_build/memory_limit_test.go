@@ -0,0 +1,88 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+)
+
+func TestParseMemoryLimit(t *testing.T) {
+	testCases := []struct {
+		in   string
+		want uint64
+		err  bool
+	}{
+		{in: "1024", want: 1024},
+		{in: "4k", want: 4 << 10},
+		{in: "512m", want: 512 << 20},
+		{in: "16g", want: 16 << 30},
+		{in: "0", err: true},
+		{in: "16G", err: true},
+		{in: "", err: true},
+	}
+	for _, tc := range testCases {
+		got, err := parseMemoryLimit(tc.in)
+		if tc.err {
+			if err == nil {
+				t.Errorf("parseMemoryLimit(%q): expected an error, got %d", tc.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseMemoryLimit(%q): unexpected error %s", tc.in, err)
+		} else if got != tc.want {
+			t.Errorf("parseMemoryLimit(%q): expected %d, got %d", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestThrottledProcs(t *testing.T) {
+	const limit = 100 << 20
+	const maxProcs = 16
+
+	// The RSS measured at each tick, and the number of threads expected after it.
+	ticks := []struct {
+		rss   uint64
+		procs int
+	}{
+		{rss: 10 << 20, procs: 16},
+		{rss: 80 << 20, procs: 16},
+		{rss: 90 << 20, procs: 8},
+		{rss: 95 << 20, procs: 4},
+		{rss: 99 << 20, procs: 2},
+		{rss: 99 << 20, procs: 1},
+		{rss: 120 << 20, procs: 1},
+		{rss: 70 << 20, procs: 1},
+		{rss: 60 << 20, procs: 2},
+		{rss: 50 << 20, procs: 4},
+		{rss: 86 << 20, procs: 2},
+		{rss: 10 << 20, procs: 4},
+		{rss: 10 << 20, procs: 8},
+		{rss: 10 << 20, procs: 16},
+		{rss: 10 << 20, procs: 16},
+	}
+
+	procs := maxProcs
+	for i, tick := range ticks {
+		procs = throttledProcs(procs, maxProcs, tick.rss, limit)
+		if procs != tick.procs {
+			t.Errorf("tick %d with %d MB: expected %d threads, got %d", i, tick.rss>>20, tick.procs, procs)
+		}
+	}
+
+	if got := throttledProcs(6, 10, 0, limit); got != 10 {
+		t.Errorf("expected the threads to be raised up to the maximum 10, got %d", got)
+	}
+}
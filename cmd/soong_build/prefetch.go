@@ -0,0 +1,89 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+
+	"android/soong/shared"
+)
+
+const (
+	// The free memory below which the Blueprint files are not prefetched, to leave the page cache
+	// to soong_build itself.
+	prefetchMinFreeRAM = 2 << 30
+
+	// The free memory needed by each prefetching worker.
+	prefetchRAMPerWorker = 256 << 20
+)
+
+// prefetchWorkers returns the number of workers that prefetch the Blueprint files, based on the
+// number of CPUs and the free memory, or 0 if there is not enough free memory to prefetch.
+func prefetchWorkers() int {
+	freeRAM := detectFreeRAM()
+	if freeRAM < prefetchMinFreeRAM {
+		return 0
+	}
+	workers := 2 * runtime.NumCPU()
+	if byRAM := int(freeRAM / prefetchRAMPerWorker); byRAM < workers {
+		workers = byRAM
+	}
+	return workers
+}
+
+// prefetchBlueprintFiles reads the Blueprint files listed in moduleListFile in parallel so that
+// they are in the page cache when blueprint parses them. On a first build with a cold cache the
+// parser otherwise spends most of its time waiting for the disk one file at a time. Errors are
+// ignored, the parser reports them when it reads the files again.
+func prefetchBlueprintFiles(topDir, moduleListFile string) {
+	workers := prefetchWorkers()
+	if workers == 0 {
+		return
+	}
+
+	list, err := os.Open(shared.JoinPath(topDir, moduleListFile))
+	if err != nil {
+		return
+	}
+	defer list.Close()
+
+	files := make(chan string, workers)
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for file := range files {
+				if f, err := os.Open(shared.JoinPath(topDir, file)); err == nil {
+					io.Copy(io.Discard, f)
+					f.Close()
+				}
+			}
+		}()
+	}
+
+	scanner := bufio.NewScanner(list)
+	for scanner.Scan() {
+		if file := scanner.Text(); file != "" {
+			files <- file
+		}
+	}
+	close(files)
+	wg.Wait()
+}
@@ -19,6 +19,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sync"
 
 	"android/soong/android"
 	"android/soong/bp2build"
@@ -41,13 +43,10 @@ func createBazelWorkspace(ctx *bp2build.CodegenContext, outDir string, generateF
 		return err2
 	}
 	filesToWrite = append(filesToWrite, bazelRcFiles...)
-	for _, f := range filesToWrite {
-		if err := writeReadOnlyFile(outDir, f); err != nil {
-			return err
-		}
-	}
 
-	return nil
+	ctx.Context().EventHandler.Begin("write_files")
+	defer ctx.Context().EventHandler.End("write_files")
+	return writeReadOnlyFiles(outDir, filesToWrite)
 }
 
 // CopyBazelRcFiles creates BazelFiles for all the bazelrc files under
@@ -78,17 +77,44 @@ func CopyBazelRcFiles() ([]bp2build.BazelFile, error) {
 
 // The auto-conversion directory should be read-only, sufficient for bazel query. The files
 // are not intended to be edited by end users.
-func writeReadOnlyFile(dir string, f bp2build.BazelFile) error {
-	dir = filepath.Join(dir, f.Dir)
-	if err := createDirectoryIfNonexistent(dir); err != nil {
-		return err
+//
+// The workspace was just removed, so the directories are created once up front, and the files,
+// which are mostly thousands of small BUILD files, are then written in parallel.
+func writeReadOnlyFiles(outDir string, files []bp2build.BazelFile) error {
+	dirs := make(map[string]bool)
+	for _, f := range files {
+		dir := filepath.Join(outDir, f.Dir)
+		if dirs[dir] {
+			continue
+		}
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+		dirs[dir] = true
 	}
-	pathToFile := filepath.Join(dir, f.Basename)
 
-	// 0444 is read-only
-	err := ioutil.WriteFile(pathToFile, []byte(f.Contents), 0444)
+	errs := make(chan error, len(files))
+	limit := make(chan struct{}, runtime.NumCPU())
+	var wg sync.WaitGroup
+	for _, f := range files {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(f bp2build.BazelFile) {
+			defer wg.Done()
+			defer func() { <-limit }()
+			// 0444 is read-only
+			errs <- ioutil.WriteFile(filepath.Join(outDir, f.Dir, f.Basename), []byte(f.Contents), 0444)
+		}(f)
+	}
+	wg.Wait()
+	close(errs)
 
-	return err
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func writeReadWriteFile(dir string, f bp2build.BazelFile) error {
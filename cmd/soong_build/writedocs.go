@@ -16,6 +16,7 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"html/template"
 	"io/ioutil"
 	"path/filepath"
@@ -155,8 +156,49 @@ func writeDocs(ctx *android.Context, filename string) error {
 	// building syntax highlighters.
 	keywordsFilename := filepath.Join(filepath.Dir(filename), "keywords.txt")
 	err = ioutil.WriteFile(keywordsFilename, keywordsBuf.Bytes(), 0666)
+	if err != nil {
+		return err
+	}
+
+	// Write out the property schemas of the module types, which are consumed by bp_index for
+	// the language servers of Android.bp files.
+	return writeModuleTypeSchemas(packages, filepath.Join(filepath.Dir(filename), "module_types.json"))
+}
 
-	return err
+// propertySchema is the schema of a property of a module type in module_types.json.
+type propertySchema struct {
+	// The name of the property, with the names of the enclosing properties separated by dots.
+	Name string
+	Type string
+	Text string `json:",omitempty"`
+}
+
+func flattenPropertySchemas(prefix string, props []bpdoc.Property) []propertySchema {
+	var schemas []propertySchema
+	for _, prop := range props {
+		name := prefix + prop.Name
+		if len(prop.Properties) > 0 {
+			schemas = append(schemas, flattenPropertySchemas(name+".", prop.Properties)...)
+			continue
+		}
+		schemas = append(schemas, propertySchema{Name: name, Type: prop.Type, Text: string(prop.Text)})
+	}
+	return schemas
+}
+
+// writeModuleTypeSchemas writes the properties of each module type as JSON.
+func writeModuleTypeSchemas(packages []*bpdoc.Package, filename string) error {
+	schemas := make(map[string][]propertySchema)
+	for _, pkg := range packages {
+		for _, m := range moduleTypeDocsToTemplates(pkg.ModuleTypes) {
+			schemas[m.Name] = flattenPropertySchemas("", m.Properties)
+		}
+	}
+	data, err := json.Marshal(schemas)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0666)
 }
 
 // TODO(jungjw): Consider ordering by name.
@@ -0,0 +1,84 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// soong_config_accessors reads the JSON schemas of soong config namespaces and generates a Go
+// file that registers them with soong_build and declares typed accessors for their variables.
+//
+// Usage:
+//
+//	soong_config_accessors -pkg acme -o acme_config.go acme_audio.json acme_camera.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"android/soong/android/soongconfig"
+)
+
+var (
+	pkg = flag.String("pkg", "", "package of the generated Go file")
+	out = flag.String("o", "", "path of the generated Go file")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: soong_config_accessors -pkg <package> -o <out.go> <schema.json>...\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func readSchema(filename string) (*soongconfig.Schema, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	schema, err := soongconfig.ParseSchema(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	return schema, nil
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	if *pkg == "" || *out == "" || flag.NArg() == 0 {
+		usage()
+	}
+
+	var schemas []*soongconfig.Schema
+	for _, filename := range flag.Args() {
+		schema, err := readSchema(filename)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		schemas = append(schemas, schema)
+	}
+
+	data, err := soongconfig.GenerateAccessors(*pkg, schemas)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(*out, data, 0666); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -193,6 +193,12 @@ func main() {
 
 	config := c.config(buildCtx, args...)
 	config.SetLogsPrefix(c.logsPrefix)
+
+	// Scrub the values of the secret environment variables out of everything logged from now on.
+	redactor := status.NewRedactor(config.SecretEnvValues())
+	stat.SetRedactor(redactor)
+	log.SetRedact(redactor.Redact)
+
 	logsDir := config.LogsDir()
 	buildStarted = config.BuildStartedTimeOrDefault(buildStarted)
 
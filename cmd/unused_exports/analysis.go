@@ -0,0 +1,292 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// module is the entry of a module variant in cc_exports.json, see cc/exports_report.go.
+type module struct {
+	Name    string
+	Variant string
+	Objects []string
+	Deps    []dep
+}
+
+// dep is a library dependency of a module in cc_exports.json.
+type dep struct {
+	Name        string
+	Kind        string
+	IncludeDirs []string
+	Library     string
+}
+
+func loadModules(r io.Reader) ([]module, error) {
+	var modules []module
+	if err := json.NewDecoder(r).Decode(&modules); err != nil {
+		return nil, fmt.Errorf("failed to parse the cc exports: %w", err)
+	}
+	return modules, nil
+}
+
+// parseNinjaDeps parses the output of `ninja -t deps`, returning the inputs recorded in the ninja
+// deps log for each output:
+//
+//	out/soong/.intermediates/foo/obj/foo.o: #deps 2, deps mtime 1672531200 (VALID)
+//	    foo/foo.c
+//	    foo/include/foo.h
+func parseNinjaDeps(r io.Reader) (map[string][]string, error) {
+	deps := make(map[string][]string)
+	var output string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.TrimSpace(line) == "":
+			output = ""
+		case strings.HasPrefix(line, " "), strings.HasPrefix(line, "\t"):
+			if output == "" {
+				return nil, fmt.Errorf("input %q before an output", strings.TrimSpace(line))
+			}
+			deps[output] = append(deps[output], strings.TrimSpace(line))
+		default:
+			i := strings.Index(line, ": #deps")
+			if i < 0 {
+				return nil, fmt.Errorf("unexpected line %q", line)
+			}
+			output = line[:i]
+			deps[output] = nil
+		}
+	}
+	return deps, scanner.Err()
+}
+
+// symbolsFunc returns the global symbols of an object file or library, either the ones it defines
+// or the undefined ones it references.
+type symbolsFunc func(file string, defined bool) ([]string, error)
+
+// analyzer finds the exported include directories and the libraries that are not used by the
+// modules that depend on them.
+type analyzer struct {
+	modules   []module
+	ninjaDeps map[string][]string
+	symbols   symbolsFunc
+
+	// Caches the symbols of the libraries, which are linked by many modules.
+	definedSymbols map[string]map[string]bool
+}
+
+func newAnalyzer(modules []module, ninjaDeps map[string][]string, symbols symbolsFunc) *analyzer {
+	return &analyzer{
+		modules:        modules,
+		ninjaDeps:      ninjaDeps,
+		symbols:        symbols,
+		definedSymbols: make(map[string]map[string]bool),
+	}
+}
+
+// compileInputs returns the files read to compile the objects of the module, and false if the
+// ninja deps log doesn't have all of them, e.g. because they were not built.
+func (a *analyzer) compileInputs(m module) ([]string, bool) {
+	var inputs []string
+	for _, object := range m.Objects {
+		deps, ok := a.ninjaDeps[object]
+		if !ok {
+			return nil, false
+		}
+		inputs = append(inputs, deps...)
+	}
+	return inputs, len(m.Objects) > 0
+}
+
+// usesIncludeDir returns true if one of the inputs is in the directory.
+func usesIncludeDir(inputs []string, dir string) bool {
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	for _, input := range inputs {
+		if strings.HasPrefix(input, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *analyzer) libraryDefinedSymbols(library string) (map[string]bool, error) {
+	if symbols, ok := a.definedSymbols[library]; ok {
+		return symbols, nil
+	}
+	list, err := a.symbols(library, true)
+	if err != nil {
+		return nil, err
+	}
+	symbols := make(map[string]bool)
+	for _, symbol := range list {
+		symbols[symbol] = true
+	}
+	a.definedSymbols[library] = symbols
+	return symbols, nil
+}
+
+// undefinedSymbols returns the symbols the module references, from its objects and from the static
+// libraries it links, which may need the other libraries of the module.
+func (a *analyzer) undefinedSymbols(m module) ([]string, error) {
+	files := append([]string(nil), m.Objects...)
+	for _, d := range m.Deps {
+		if (d.Kind == "static" || d.Kind == "whole_static") && d.Library != "" {
+			files = append(files, d.Library)
+		}
+	}
+	var undefined []string
+	for _, file := range files {
+		symbols, err := a.symbols(file, false)
+		if err != nil {
+			return nil, err
+		}
+		undefined = append(undefined, symbols...)
+	}
+	return undefined, nil
+}
+
+// usesLibrary returns true if one of the symbols is defined by the library.
+func (a *analyzer) usesLibrary(undefined []string, library string) (bool, error) {
+	defined, err := a.libraryDefinedSymbols(library)
+	if err != nil {
+		return false, err
+	}
+	for _, symbol := range undefined {
+		if defined[symbol] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// report holds the findings of the analysis.
+type report struct {
+	// The unused dependencies, per module variant.
+	UnusedDeps []string
+
+	// The exported include directories that none of the dependents of a module use.
+	UnusedIncludeDirs []string
+
+	// The modules whose objects are missing from the ninja deps log, which are not analyzed.
+	Skipped []string
+}
+
+func (r *report) write(w io.Writer) {
+	for _, section := range []struct {
+		title    string
+		findings []string
+	}{
+		{"Unused library dependencies", r.UnusedDeps},
+		{"Unused exported include directories", r.UnusedIncludeDirs},
+		{"Not analyzed, their objects are not built", r.Skipped},
+	} {
+		if len(section.findings) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s:\n", section.title)
+		for _, finding := range section.findings {
+			fmt.Fprintf(w, "  %s\n", finding)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func (a *analyzer) analyze() (*report, error) {
+	r := &report{}
+	// The dependents of the exported include directories of each library, and whether one of them
+	// uses it.
+	dependents := make(map[string]map[string]int)
+	used := make(map[string]map[string]bool)
+
+	for _, m := range a.modules {
+		if len(m.Objects) == 0 {
+			continue
+		}
+		variant := fmt.Sprintf("%s (%s)", m.Name, m.Variant)
+		inputs, ok := a.compileInputs(m)
+		if !ok {
+			r.Skipped = append(r.Skipped, variant)
+			continue
+		}
+		var undefined []string
+		undefinedLoaded := false
+		for _, d := range m.Deps {
+			usesHeaders := false
+			for _, dir := range d.IncludeDirs {
+				if dependents[d.Name] == nil {
+					dependents[d.Name] = make(map[string]int)
+					used[d.Name] = make(map[string]bool)
+				}
+				dependents[d.Name][dir]++
+				if usesIncludeDir(inputs, dir) {
+					used[d.Name][dir] = true
+					usesHeaders = true
+				}
+			}
+
+			if d.Kind == "header" {
+				if !usesHeaders {
+					r.UnusedDeps = append(r.UnusedDeps,
+						fmt.Sprintf("%s: none of the headers of header library %s are used", variant, d.Name))
+				}
+				continue
+			}
+			if d.Library == "" {
+				continue
+			}
+			if !undefinedLoaded {
+				var err error
+				if undefined, err = a.undefinedSymbols(m); err != nil {
+					return nil, err
+				}
+				undefinedLoaded = true
+			}
+			usesSymbols, err := a.usesLibrary(undefined, d.Library)
+			if err != nil {
+				return nil, err
+			}
+			if !usesSymbols {
+				how := "none of its symbols are used"
+				if usesHeaders {
+					how = "only its headers are used"
+				}
+				r.UnusedDeps = append(r.UnusedDeps,
+					fmt.Sprintf("%s: %s library %s is linked but %s", variant, d.Kind, d.Name, how))
+			}
+		}
+	}
+
+	for library, dirs := range dependents {
+		for dir, count := range dirs {
+			if !used[library][dir] {
+				r.UnusedIncludeDirs = append(r.UnusedIncludeDirs,
+					fmt.Sprintf("%s: %s is not used by any of its %d dependents", library, dir, count))
+			}
+		}
+	}
+
+	sort.Strings(r.UnusedDeps)
+	sort.Strings(r.UnusedIncludeDirs)
+	sort.Strings(r.Skipped)
+	return r, nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testExports = `[
+	{
+		"Name": "foo",
+		"Variant": "android_arm64",
+		"Objects": ["obj/foo.o"],
+		"Deps": [
+			{"Name": "libbar", "Kind": "shared", "IncludeDirs": ["bar/include", "bar/internal"], "Library": "libbar.so"},
+			{"Name": "libbaz", "Kind": "static", "IncludeDirs": ["baz/include"], "Library": "libbaz.a"},
+			{"Name": "libqux", "Kind": "shared", "Library": "libqux.so"},
+			{"Name": "libheaders", "Kind": "header", "IncludeDirs": ["headers/include"]}
+		]
+	},
+	{
+		"Name": "unbuilt",
+		"Variant": "android_arm64",
+		"Objects": ["obj/unbuilt.o"]
+	}
+]`
+
+const testNinjaDeps = `obj/foo.o: #deps 3, deps mtime 1672531200 (VALID)
+    foo/foo.c
+    bar/include/bar.h
+    baz/include/baz.h
+
+obj/other.o: #deps 1, deps mtime 1672531200 (VALID)
+    other.c
+`
+
+var testSymbols = map[string][]string{
+	"libbar.so": {"bar"},
+	"libbaz.a":  {"baz"},
+	"libqux.so": {"qux"},
+}
+
+var testUndefinedSymbols = map[string][]string{
+	"obj/foo.o": {"bar"},
+	// libqux is only used by libbaz.
+	"libbaz.a": {"qux"},
+}
+
+func TestAnalyze(t *testing.T) {
+	modules, err := loadModules(strings.NewReader(testExports))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ninjaDeps, err := parseNinjaDeps(strings.NewReader(testNinjaDeps))
+	if err != nil {
+		t.Fatal(err)
+	}
+	symbols := func(file string, defined bool) ([]string, error) {
+		if defined {
+			return testSymbols[file], nil
+		}
+		return testUndefinedSymbols[file], nil
+	}
+
+	r, err := newAnalyzer(modules, ninjaDeps, symbols).analyze()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &report{
+		UnusedDeps: []string{
+			"foo (android_arm64): none of the headers of header library libheaders are used",
+			"foo (android_arm64): static library libbaz is linked but only its headers are used",
+		},
+		UnusedIncludeDirs: []string{
+			"libbar: bar/internal is not used by any of its 1 dependents",
+			"libheaders: headers/include is not used by any of its 1 dependents",
+		},
+		Skipped: []string{"unbuilt (android_arm64)"},
+	}
+	if !reflect.DeepEqual(r, expected) {
+		t.Errorf("expected %#v, got %#v", expected, r)
+	}
+}
+
+func TestParseNinjaDepsErrors(t *testing.T) {
+	for _, input := range []string{
+		"    foo.h\n",
+		"obj/foo.o\n",
+	} {
+		if _, err := parseNinjaDeps(strings.NewReader(input)); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+}
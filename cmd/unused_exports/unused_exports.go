@@ -0,0 +1,120 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// This tool reports the exported include directories and the libraries of the native modules that
+// are not used by the modules that depend on them. The compile inputs of the objects come from the
+// ninja deps log, and the symbols from llvm-nm, so the modules must have been built first:
+//
+//	SOONG_CC_EXPORTS_REPORT=true m <modules> cc_exports
+//	prebuilts/build-tools/linux-x86/bin/ninja -f out/combined-<product>.ninja -t deps > deps.txt
+//	unused_exports -exports out/soong/cc_exports.json -ninja_deps deps.txt \
+//	    -nm prebuilts/clang/host/linux-x86/<clang>/bin/llvm-nm
+
+func main() {
+	exportsFile := flag.String("exports", "out/soong/cc_exports.json", "cc exports written by `m cc_exports`")
+	ninjaDepsFile := flag.String("ninja_deps", "", "output of `ninja -t deps` for the build")
+	nm := flag.String("nm", "llvm-nm", "llvm-nm to read the symbols of the objects and libraries with")
+	output := flag.String("o", "", "file to write the report to, instead of stdout")
+	flag.Parse()
+
+	if *ninjaDepsFile == "" {
+		fmt.Fprintln(os.Stderr, "-ninja_deps is required")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if err := run(*exportsFile, *ninjaDepsFile, *nm, *output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(exportsFile, ninjaDepsFile, nm, output string) error {
+	f, err := os.Open(exportsFile)
+	if err != nil {
+		return err
+	}
+	modules, err := loadModules(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	f, err = os.Open(ninjaDepsFile)
+	if err != nil {
+		return err
+	}
+	ninjaDeps, err := parseNinjaDeps(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", ninjaDepsFile, err)
+	}
+
+	r, err := newAnalyzer(modules, ninjaDeps, nmSymbols(nm)).analyze()
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if output != "" {
+		if w, err = os.Create(output); err != nil {
+			return err
+		}
+		defer w.Close()
+	}
+	r.write(w)
+	return nil
+}
+
+// nmSymbols returns a symbolsFunc that reads the global symbols of the files with llvm-nm, the
+// dynamic ones for shared libraries.
+func nmSymbols(nm string) symbolsFunc {
+	return func(file string, defined bool) ([]string, error) {
+		args := []string{"--format=just-symbols", "--extern-only"}
+		if defined {
+			args = append(args, "--defined-only")
+		} else {
+			args = append(args, "--undefined-only")
+		}
+		if strings.HasSuffix(file, ".so") {
+			args = append(args, "-D")
+		}
+		cmd := exec.Command(nm, append(args, file)...)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("%s %s failed: %w\n%s", nm, file, err, stderr.String())
+		}
+		var symbols []string
+		for _, line := range strings.Split(string(out), "\n") {
+			// The members of archives are listed with "<member>:" headers.
+			if line = strings.TrimSpace(line); line != "" && !strings.HasSuffix(line, ":") {
+				symbols = append(symbols, line)
+			}
+		}
+		return symbols, nil
+	}
+}
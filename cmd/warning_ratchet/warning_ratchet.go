@@ -0,0 +1,234 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"android/soong/response"
+)
+
+// This tool checks the compiler warnings of the directories under a directory against the number
+// of warnings of each of them in a checked-in baseline file, failing if one of them has more
+// warnings than its baseline. The report lists the warnings of the directories that have more
+// warnings than their baseline. With -update the baseline is rewritten with the numbers of
+// warnings of the build instead.
+
+const baselineHeader = "# Number of compiler warnings of each directory, which must not increase.\n" +
+	"# Regenerate with UPDATE_WARNING_BASELINES=true m check_warning_baselines and review the changes.\n"
+
+// A warning printed by clang, e.g. "foo/foo.c:3:10: warning: unused variable 'x' [-Wunused-variable]".
+var warningRegexp = regexp.MustCompile(`^(.+?):\d+:\d+: warning: `)
+
+func main() {
+	var expandedArgs []string
+	for _, arg := range os.Args[1:] {
+		if strings.HasPrefix(arg, "@") {
+			f, err := os.Open(strings.TrimPrefix(arg, "@"))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+
+			respArgs, err := response.ReadRspFile(f)
+			f.Close()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			expandedArgs = append(expandedArgs, respArgs...)
+		} else {
+			expandedArgs = append(expandedArgs, arg)
+		}
+	}
+
+	flags := flag.NewFlagSet("flags", flag.ExitOnError)
+	dir := flags.String("dir", "", "directory whose warnings are checked")
+	baseline := flags.String("baseline", "", "file with the number of warnings of each directory")
+	report := flags.String("report", "", "file to write the warnings of the directories above their baseline to")
+	update := flags.Bool("update", false, "write the numbers of warnings to the baseline instead of checking them")
+	flags.Parse(expandedArgs)
+
+	if *dir == "" || *baseline == "" || *report == "" {
+		fmt.Fprintf(os.Stderr, "usage: %s -dir <dir> -baseline <baseline> -report <report> [-update] [<warnings file>...]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	if err := checkWarnings(*dir, *baseline, *report, *update, flags.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
+
+func checkWarnings(dir, baseline, report string, update bool, warningFiles []string) error {
+	warnings := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, file := range warningFiles {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		err = parseWarnings(f, dir, warnings, seen)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	counts := make(map[string]int)
+	for d, list := range warnings {
+		counts[d] = len(list)
+	}
+
+	if update {
+		if err := os.WriteFile(baseline, []byte(baselineHeader+formatBaseline(counts)), 0666); err != nil {
+			return err
+		}
+		return os.WriteFile(report, nil, 0666)
+	}
+
+	f, err := os.Open(baseline)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s doesn't exist, create it with UPDATE_WARNING_BASELINES=true m check_warning_baselines", baseline)
+	} else if err != nil {
+		return err
+	}
+	expected, err := parseBaseline(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", baseline, err)
+	}
+
+	contents, increased := newWarningsReport(expected, counts, warnings)
+	if err := os.WriteFile(report, []byte(contents), 0666); err != nil {
+		return err
+	}
+	if increased {
+		return fmt.Errorf("the number of warnings grew above %s:\n%s"+
+			"Fix the new warnings, the report is in %s", baseline, contents, report)
+	}
+	return nil
+}
+
+// parseWarnings adds the warnings in the output of clang for the files under dir to warnings, by
+// the directory of their file. The warnings of headers are printed by the compilations of all the
+// sources that include them, so the warnings already seen are skipped.
+func parseWarnings(r io.Reader, dir string, warnings map[string][]string, seen map[string]bool) error {
+	prefix := filepath.Clean(dir) + "/"
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		match := warningRegexp.FindStringSubmatch(line)
+		if match == nil || seen[line] {
+			continue
+		}
+		seen[line] = true
+		file := filepath.Clean(match[1])
+		if !strings.HasPrefix(file, prefix) {
+			continue
+		}
+		d := filepath.Dir(file)
+		warnings[d] = append(warnings[d], line)
+	}
+	return scanner.Err()
+}
+
+// parseBaseline parses the number of warnings of each directory, one "<dir> <count>" per line,
+// ignoring empty lines and comments.
+func parseBaseline(r io.Reader) (map[string]int, error) {
+	counts := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+		count, err := strconv.Atoi(fields[1])
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("invalid count in line %q", line)
+		}
+		counts[fields[0]] = count
+	}
+	return counts, scanner.Err()
+}
+
+// formatBaseline returns the number of warnings of each directory, sorted by directory.
+func formatBaseline(counts map[string]int) string {
+	var dirs []string
+	for d := range counts {
+		dirs = append(dirs, d)
+	}
+	sort.Strings(dirs)
+	var sb strings.Builder
+	for _, d := range dirs {
+		fmt.Fprintf(&sb, "%s %d\n", d, counts[d])
+	}
+	return sb.String()
+}
+
+// newWarningsReport returns the report of the directories with more warnings than their baseline,
+// with their warnings, and whether there are any. The directories with fewer warnings than their
+// baseline are listed too, as their baseline can be lowered.
+func newWarningsReport(expected, counts map[string]int, warnings map[string][]string) (string, bool) {
+	var dirs []string
+	for d := range counts {
+		dirs = append(dirs, d)
+	}
+	for d := range expected {
+		if _, ok := counts[d]; !ok {
+			dirs = append(dirs, d)
+		}
+	}
+	sort.Strings(dirs)
+
+	var sb strings.Builder
+	var lowered []string
+	increased := false
+	for _, d := range dirs {
+		if counts[d] > expected[d] {
+			increased = true
+			fmt.Fprintf(&sb, "%s: %d warnings, baseline %d\n", d, counts[d], expected[d])
+			list := append([]string(nil), warnings[d]...)
+			sort.Strings(list)
+			for _, warning := range list {
+				fmt.Fprintf(&sb, "    %s\n", warning)
+			}
+		} else if counts[d] < expected[d] {
+			lowered = append(lowered, fmt.Sprintf("%s: %d warnings, baseline %d", d, counts[d], expected[d]))
+		}
+	}
+	if len(lowered) > 0 {
+		sb.WriteString("The baseline of these directories can be lowered with UPDATE_WARNING_BASELINES=true:\n")
+		for _, l := range lowered {
+			fmt.Fprintf(&sb, "    %s\n", l)
+		}
+	}
+	return sb.String(), increased
+}
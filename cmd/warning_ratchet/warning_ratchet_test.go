@@ -0,0 +1,111 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testWarnings = `vendor/acme/foo/foo.c:3:10: warning: unused variable 'x' [-Wunused-variable]
+    int x;
+        ^
+vendor/acme/include/acme.h:5:1: warning: declaration does not declare anything [-Wmissing-declarations]
+vendor/acme/include/acme.h:5:1: warning: declaration does not declare anything [-Wmissing-declarations]
+external/other/other.h:1:1: warning: outside of the directory [-Wunused-macros]
+2 warnings generated.
+`
+
+func TestParseWarnings(t *testing.T) {
+	warnings := make(map[string][]string)
+	if err := parseWarnings(strings.NewReader(testWarnings), "vendor/acme/", warnings, make(map[string]bool)); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{
+		"vendor/acme/foo": {
+			"vendor/acme/foo/foo.c:3:10: warning: unused variable 'x' [-Wunused-variable]",
+		},
+		"vendor/acme/include": {
+			"vendor/acme/include/acme.h:5:1: warning: declaration does not declare anything [-Wmissing-declarations]",
+		},
+	}
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("expected %q, got %q", expected, warnings)
+	}
+}
+
+func TestCheckWarnings(t *testing.T) {
+	dir := t.TempDir()
+	warningsFile := filepath.Join(dir, "foo.o.warnings")
+	baseline := filepath.Join(dir, "baseline.txt")
+	report := filepath.Join(dir, "report.txt")
+	if err := os.WriteFile(warningsFile, []byte(testWarnings), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkWarnings("vendor/acme", baseline, report, false, []string{warningsFile}); err == nil ||
+		!strings.Contains(err.Error(), "doesn't exist") {
+		t.Errorf("expected an error for the missing baseline, got %v", err)
+	}
+
+	if err := checkWarnings("vendor/acme", baseline, report, true, []string{warningsFile}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(baseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := baselineHeader + "vendor/acme/foo 1\nvendor/acme/include 1\n"; string(data) != expected {
+		t.Errorf("expected baseline %q, got %q", expected, string(data))
+	}
+	if err := checkWarnings("vendor/acme", baseline, report, false, []string{warningsFile}); err != nil {
+		t.Errorf("unexpected error with the updated baseline: %s", err)
+	}
+
+	// A new warning in vendor/acme/foo and a fixed warning in vendor/acme/include.
+	if err := os.WriteFile(baseline, []byte("vendor/acme/foo 0\nvendor/acme/include 2\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	err = checkWarnings("vendor/acme", baseline, report, false, []string{warningsFile})
+	if err == nil {
+		t.Fatal("expected an error for the new warning")
+	}
+	data, err = os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedReport := "vendor/acme/foo: 1 warnings, baseline 0\n" +
+		"    vendor/acme/foo/foo.c:3:10: warning: unused variable 'x' [-Wunused-variable]\n" +
+		"The baseline of these directories can be lowered with UPDATE_WARNING_BASELINES=true:\n" +
+		"    vendor/acme/include: 1 warnings, baseline 2\n"
+	if string(data) != expectedReport {
+		t.Errorf("expected report %q, got %q", expectedReport, string(data))
+	}
+}
+
+func TestParseBaselineErrors(t *testing.T) {
+	for _, input := range []string{
+		"vendor/acme\n",
+		"vendor/acme many\n",
+		"vendor/acme -1\n",
+	} {
+		if _, err := parseBaseline(strings.NewReader(input)); err == nil {
+			t.Errorf("expected an error for %q", input)
+		}
+	}
+}
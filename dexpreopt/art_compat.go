@@ -0,0 +1,120 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dexpreopt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"android/soong/android"
+)
+
+// The ART APEX on the device may be a prebuilt that is older or newer than the ART sources in the
+// tree, e.g. after a mainline prebuilt bump. The dexpreopt config must not request a compiler
+// filter or a dex format that it doesn't support, or the device may fail to boot.
+
+// artApexVersionRange is a range of versions of the ART APEX, from min (inclusive) to max
+// (exclusive). A zero bound is unbounded.
+type artApexVersionRange struct {
+	min, max int64
+}
+
+func (r artApexVersionRange) contains(version int64) bool {
+	return version >= r.min && (r.max == 0 || version < r.max)
+}
+
+func (r artApexVersionRange) String() string {
+	switch {
+	case r.max == 0:
+		return fmt.Sprintf("%d or later", r.min)
+	case r.min == 0:
+		return fmt.Sprintf("before %d", r.max)
+	default:
+		return fmt.Sprintf("from %d and before %d", r.min, r.max)
+	}
+}
+
+// artCompilerFilters are the compiler filters accepted by dex2oat, with the versions of the ART
+// APEX that support them. "quicken" has been an alias of "verify" since ART became a mainline
+// module.
+var artCompilerFilters = map[string]artApexVersionRange{
+	"assume-verified":    {},
+	"extract":            {},
+	"verify":             {},
+	"quicken":            {},
+	"space-profile":      {},
+	"space":              {},
+	"speed-profile":      {},
+	"speed":              {},
+	"everything-profile": {},
+	"everything":         {},
+}
+
+// artDexContainerVersions are the versions of the ART APEX that can load dex files in the dex
+// container format.
+var artDexContainerVersions = artApexVersionRange{min: 350000000}
+
+// artApexVersion returns the version of the ART APEX on the device: ArtApexVersion if it is set,
+// or else the version of the ART APEX built from source.
+func (g *GlobalConfig) artApexVersion() (int64, error) {
+	version := g.ArtApexVersion
+	if version == "" {
+		version = android.DefaultUpdatableModuleVersion
+	}
+	v, err := strconv.ParseInt(version, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("ArtApexVersion %q is not a number", version)
+	}
+	return v, nil
+}
+
+// CheckArtApexCompatibility returns an error for each compiler filter or dex format requested by
+// the dexpreopt config that isn't supported by the ART APEX on the device.
+func CheckArtApexCompatibility(global *GlobalConfig) []error {
+	version, err := global.artApexVersion()
+	if err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+	checkCompilerFilter := func(name, filter string) {
+		if filter == "" {
+			return
+		}
+		versions, ok := artCompilerFilters[filter]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown compiler filter %q", name, filter))
+		} else if !versions.contains(version) {
+			errs = append(errs, fmt.Errorf("%s: compiler filter %q is not supported by ART APEX version %d, "+
+				"it requires a version %s", name, filter, version, versions))
+		}
+	}
+
+	checkCompilerFilter("DefaultCompilerFilter", global.DefaultCompilerFilter)
+	checkCompilerFilter("SystemServerCompilerFilter", global.SystemServerCompilerFilter)
+	for _, flag := range global.PreoptFlags {
+		if strings.HasPrefix(flag, "--compiler-filter=") {
+			checkCompilerFilter("PreoptFlags", strings.TrimPrefix(flag, "--compiler-filter="))
+		}
+	}
+
+	if global.DexContainer && !artDexContainerVersions.contains(version) {
+		errs = append(errs, fmt.Errorf("DexContainer: the dex container format is not supported by "+
+			"ART APEX version %d, it requires a version %s", version, artDexContainerVersions))
+	}
+
+	return errs
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dexpreopt
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestCheckArtApexCompatibility(t *testing.T) {
+	testCases := []struct {
+		name     string
+		modify   func(global *GlobalConfig)
+		expected []string
+	}{
+		{
+			name:   "default",
+			modify: func(global *GlobalConfig) {},
+		},
+		{
+			name: "compiler filters",
+			modify: func(global *GlobalConfig) {
+				global.DefaultCompilerFilter = "verify"
+				global.SystemServerCompilerFilter = "speed-profile"
+				global.PreoptFlags = []string{"--compiler-filter=everything"}
+			},
+		},
+		{
+			name: "unknown compiler filters",
+			modify: func(global *GlobalConfig) {
+				global.DefaultCompilerFilter = "interpret-only"
+				global.PreoptFlags = []string{"--compiler-filter=fast"}
+			},
+			expected: []string{
+				`DefaultCompilerFilter: unknown compiler filter "interpret-only"`,
+				`PreoptFlags: unknown compiler filter "fast"`,
+			},
+		},
+		{
+			name: "dex container on older ART APEX",
+			modify: func(global *GlobalConfig) {
+				global.ArtApexVersion = "340090000"
+				global.DexContainer = true
+			},
+			expected: []string{
+				"DexContainer: the dex container format is not supported by ART APEX version 340090000, " +
+					"it requires a version 350000000 or later",
+			},
+		},
+		{
+			name: "dex container on newer ART APEX",
+			modify: func(global *GlobalConfig) {
+				global.ArtApexVersion = "350090000"
+				global.DexContainer = true
+			},
+		},
+		{
+			name: "invalid ART APEX version",
+			modify: func(global *GlobalConfig) {
+				global.ArtApexVersion = "latest"
+			},
+			expected: []string{`ArtApexVersion "latest" is not a number`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := android.PathContextForTesting(android.TestConfig("out", nil, "", nil))
+			global := GlobalConfigForTests(ctx)
+			tc.modify(global)
+
+			var errs []string
+			for _, err := range CheckArtApexCompatibility(global) {
+				errs = append(errs, err.Error())
+			}
+			android.AssertDeepEquals(t, "errors", tc.expected, errs)
+		})
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/google/blueprint"
 
 	"android/soong/android"
+	"android/soong/remoteexec"
 )
 
 // GlobalConfig stores the configuration for dex preopting. The fields are set
@@ -98,6 +99,13 @@ type GlobalConfig struct {
 	RelaxUsesLibraryCheck bool
 
 	EnableUffdGc bool // preopt with the assumption that userfaultfd GC will be used on device.
+
+	// The version of the ART APEX on the device, e.g. "340090000", when it is a prebuilt whose
+	// version differs from the one built from source. The compiler filters and the dex format
+	// used by dexpreopt are checked against the ones it supports, see CheckArtApexCompatibility.
+	ArtApexVersion string
+
+	DexContainer bool // dex files use the dex container format (dex version 041)
 }
 
 var allPlatformSystemServerJarsKey = android.NewOnceKey("allPlatformSystemServerJars")
@@ -153,6 +161,13 @@ type GlobalSoongConfig struct {
 	Zip2zip          android.Path
 	ManifestCheck    android.Path
 	ConstructContext android.Path
+
+	// The rewrapper and its flags that run dex2oat remotely with RBE, or empty if dex2oat runs
+	// locally, and the exec strategy and the pool of the remote actions.
+	RBEWrapper          string
+	RBEWrapperFlags     string
+	Dex2oatExecStrategy string
+	Dex2oatPool         string
 }
 
 type ModuleConfig struct {
@@ -231,6 +246,13 @@ func constructWritablePath(ctx android.PathContext, path string) android.Writabl
 // ParseGlobalConfig parses the given data assumed to be read from the global
 // dexpreopt.config file into a GlobalConfig struct.
 func ParseGlobalConfig(ctx android.PathContext, data []byte) (*GlobalConfig, error) {
+	return parseGlobalConfig(ctx, data, json.Unmarshal)
+}
+
+// parseGlobalConfig is ParseGlobalConfig with the given JSON decoding function.
+func parseGlobalConfig(ctx android.PathContext, data []byte,
+	unmarshal func(data []byte, v interface{}) error) (*GlobalConfig, error) {
+
 	type GlobalJSONConfig struct {
 		*GlobalConfig
 
@@ -240,7 +262,7 @@ func ParseGlobalConfig(ctx android.PathContext, data []byte) (*GlobalConfig, err
 	}
 
 	config := GlobalJSONConfig{}
-	err := json.Unmarshal(data, &config)
+	err := unmarshal(data, &config)
 	if err != nil {
 		return config.GlobalConfig, err
 	}
@@ -290,7 +312,11 @@ func getGlobalConfigRaw(ctx android.PathContext) globalConfigAndRaw {
 			panic(err)
 		} else if data != nil {
 			pathErrorCollectorCtx := &pathContextErrorCollector{PathContext: ctx}
-			globalConfig, err := ParseGlobalConfig(pathErrorCollectorCtx, data)
+			// The decoded config is cached as dexpreopt.config is read on every run of soong_build.
+			decode := func(data []byte, v interface{}) error {
+				return ctx.Config().DecodeJSONWithCache("dexpreopt.config", data, v)
+			}
+			globalConfig, err := parseGlobalConfig(pathErrorCollectorCtx, data, decode)
 			if err != nil {
 				panic(err)
 			}
@@ -530,7 +556,7 @@ func dex2oatPathFromDep(ctx android.ModuleContext) android.Path {
 // createGlobalSoongConfig creates a GlobalSoongConfig from the current context.
 // Should not be used in dexpreopt_gen.
 func createGlobalSoongConfig(ctx android.ModuleContext) *GlobalSoongConfig {
-	return &GlobalSoongConfig{
+	config := &GlobalSoongConfig{
 		Profman:          ctx.Config().HostToolPath(ctx, "profman"),
 		Dex2oat:          dex2oatPathFromDep(ctx),
 		Aapt:             ctx.Config().HostToolPath(ctx, "aapt2"),
@@ -539,6 +565,14 @@ func createGlobalSoongConfig(ctx android.ModuleContext) *GlobalSoongConfig {
 		ManifestCheck:    ctx.Config().HostToolPath(ctx, "manifest_check"),
 		ConstructContext: ctx.Config().HostToolPath(ctx, "construct_context"),
 	}
+	if ctx.Config().UseRBE() && ctx.Config().IsEnvTrue("RBE_DEX2OAT") {
+		config.RBEWrapper = ctx.Config().RBEWrapper()
+		config.RBEWrapperFlags = ctx.Config().RBEWrapperFlags()
+		config.Dex2oatExecStrategy = ctx.Config().GetenvWithDefault("RBE_DEX2OAT_EXEC_STRATEGY",
+			remoteexec.DefaultExecStrategy("RBE_DEX2OAT_EXEC_STRATEGY"))
+		config.Dex2oatPool = ctx.Config().GetenvWithDefault("RBE_DEX2OAT_POOL", "default")
+	}
+	return config
 }
 
 // The main reason for this Once cache for GlobalSoongConfig is to make the
@@ -589,6 +623,11 @@ type globalJsonSoongConfig struct {
 	Zip2zip          string
 	ManifestCheck    string
 	ConstructContext string
+
+	RBEWrapper          string `json:",omitempty"`
+	RBEWrapperFlags     string `json:",omitempty"`
+	Dex2oatExecStrategy string `json:",omitempty"`
+	Dex2oatPool         string `json:",omitempty"`
 }
 
 // ParseGlobalSoongConfig parses the given data assumed to be read from the
@@ -610,6 +649,11 @@ func ParseGlobalSoongConfig(ctx android.PathContext, data []byte) (*GlobalSoongC
 		Zip2zip:          constructPath(ctx, jc.Zip2zip),
 		ManifestCheck:    constructPath(ctx, jc.ManifestCheck),
 		ConstructContext: constructPath(ctx, jc.ConstructContext),
+
+		RBEWrapper:          jc.RBEWrapper,
+		RBEWrapperFlags:     jc.RBEWrapperFlags,
+		Dex2oatExecStrategy: jc.Dex2oatExecStrategy,
+		Dex2oatPool:         jc.Dex2oatPool,
 	}
 
 	return config, nil
@@ -655,6 +699,11 @@ func (s *globalSoongConfigSingleton) GenerateBuildActions(ctx android.SingletonC
 		Zip2zip:          config.Zip2zip.String(),
 		ManifestCheck:    config.ManifestCheck.String(),
 		ConstructContext: config.ConstructContext.String(),
+
+		RBEWrapper:          config.RBEWrapper,
+		RBEWrapperFlags:     config.RBEWrapperFlags,
+		Dex2oatExecStrategy: config.Dex2oatExecStrategy,
+		Dex2oatPool:         config.Dex2oatPool,
 	}
 
 	data, err := json.Marshal(jc)
@@ -728,6 +777,8 @@ func GlobalConfigForTests(ctx android.PathContext) *GlobalConfig {
 		BootFlags:                          "",
 		Dex2oatImageXmx:                    "",
 		Dex2oatImageXms:                    "",
+		ArtApexVersion:                     "",
+		DexContainer:                       false,
 	}
 }
 
@@ -40,6 +40,7 @@ import (
 	"strings"
 
 	"android/soong/android"
+	"android/soong/remoteexec"
 
 	"github.com/google/blueprint/pathtools"
 )
@@ -263,6 +264,9 @@ func dexpreoptCommand(ctx android.PathContext, globalSoong *GlobalSoongConfig, g
 	rule.Command().FlagWithArg("mkdir -p ", filepath.Dir(odexPath.String()))
 	rule.Command().FlagWithOutput("rm -f ", odexPath)
 
+	// The host paths of the class loader context, which dex2oat reads.
+	var clcPaths android.Paths
+
 	if jarIndex := systemServerJars.IndexOfJar(module.Name); jarIndex >= 0 {
 		// System server jars should be dexpreopted together: class loader context of each jar
 		// should include all preceding jars on the system server classpath.
@@ -305,6 +309,7 @@ func dexpreoptCommand(ctx android.PathContext, globalSoong *GlobalSoongConfig, g
 			clcTargetString = "PCL[];" + clcTargetString
 		}
 
+		clcPaths = clcHost
 		rule.Command().
 			Text(`class_loader_context_arg=--class-loader-context="` + clcHostString + `"`).
 			Implicits(clcHost).
@@ -352,6 +357,7 @@ func dexpreoptCommand(ctx android.PathContext, globalSoong *GlobalSoongConfig, g
 
 		// Generate command that saves host and target class loader context in shell variables.
 		clc, paths := ComputeClassLoaderContext(module.ClassLoaderContexts)
+		clcPaths = paths
 		rule.Command().
 			Text(`eval "$(`).Tool(globalSoong.ConstructContext).
 			Text(` --target-sdk-version ${target_sdk_version}`).
@@ -368,8 +374,22 @@ func dexpreoptCommand(ctx android.PathContext, globalSoong *GlobalSoongConfig, g
 	}
 
 	cmd := rule.Command().
-		Text(`ANDROID_LOG_TAGS="*:e"`).
-		Tool(globalSoong.Dex2oat).
+		Text(`ANDROID_LOG_TAGS="*:e"`)
+	if globalSoong.RBEWrapper != "" {
+		// Run dex2oat remotely with RBE. Its inputs include the boot image it compiles against and
+		// the host shared libraries it loads.
+		cmd.Rewrapper(globalSoong.RBEWrapper, globalSoong.RBEWrapperFlags, &remoteexec.REParams{
+			Labels:               map[string]string{"type": "compile", "compiler": "dex2oat"},
+			Inputs:               []string{filepath.Join(filepath.Dir(filepath.Dir(globalSoong.Dex2oat.String())), "lib64")},
+			ExecStrategy:         globalSoong.Dex2oatExecStrategy,
+			ToolchainInputs:      []string{globalSoong.Dex2oat.String()},
+			EnvironmentVariables: []string{"ANDROID_LOG_TAGS"},
+			Platform:             map[string]string{remoteexec.PoolKey: globalSoong.Dex2oatPool},
+		})
+		cmd.Implicits(clcPaths)
+		rule.Remoteable(android.RemoteRuleSupports{RBE: true})
+	}
+	cmd.Tool(globalSoong.Dex2oat).
 		Flag("--avoid-storing-invocation").
 		FlagWithOutput("--write-invocation-to=", invocationPath).ImplicitOutput(invocationPath).
 		Flag("--runtime-arg").FlagWithArg("-Xms", global.Dex2oatXms).
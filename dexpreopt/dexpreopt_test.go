@@ -17,6 +17,7 @@ package dexpreopt
 import (
 	"android/soong/android"
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -294,6 +295,41 @@ func TestDexPreoptProfile(t *testing.T) {
 	}
 }
 
+func TestDexPreoptRemoteExecution(t *testing.T) {
+	config := android.TestConfig("out", nil, "", nil)
+	ctx := android.BuilderContextForTesting(config)
+	globalSoong := globalSoongConfigForTests()
+	globalSoong.RBEWrapper = "rewrapper"
+	globalSoong.Dex2oatExecStrategy = "remote_local_fallback"
+	globalSoong.Dex2oatPool = "default"
+	global := GlobalConfigForTests(ctx)
+	module := testSystemModuleConfig(ctx, "test")
+	bootImage := android.PathForOutput(ctx, "boot/arm/boot.art")
+	module.DexPreoptImagesDeps = []android.OutputPaths{{bootImage}}
+
+	rule, err := GenerateDexpreoptRule(ctx, globalSoong, global, module)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dex2oatCmd string
+	for _, cmd := range rule.Commands() {
+		if strings.Contains(cmd, "--dex-file=") {
+			dex2oatCmd = cmd
+		}
+	}
+
+	android.AssertStringDoesContain(t, "dex2oat command",
+		dex2oatCmd, `ANDROID_LOG_TAGS="*:e" rewrapper --labels=compiler=dex2oat,type=compile `)
+	android.AssertStringDoesContain(t, "dex2oat command", dex2oatCmd, " -- dex2oat --avoid-storing-invocation")
+	android.AssertStringDoesContain(t, "dex2oat command", dex2oatCmd, "--exec_strategy=remote_local_fallback")
+	android.AssertStringDoesContain(t, "dex2oat command", dex2oatCmd, "--toolchain_inputs=dex2oat")
+	android.AssertStringDoesContain(t, "dex2oat command", dex2oatCmd,
+		"--inputs=lib64,"+module.DexPath.String()+","+bootImage.String()+",dex2oat ")
+	android.AssertStringDoesContain(t, "dex2oat command", dex2oatCmd,
+		"--output_files=out/soong/test/oat/arm/package.invocation,out/soong/test/oat/arm/package.odex,out/soong/test/oat/arm/package.vdex ")
+}
+
 func TestDexPreoptConfigToJson(t *testing.T) {
 	config := android.TestConfig("out", nil, "", nil)
 	ctx := android.BuilderContextForTesting(config)
@@ -0,0 +1,138 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etc
+
+// This file implements the recovery_fstab module type, which generates the recovery.fstab of the
+// recovery image from the fstab of the device instead of keeping a hand-written copy in sync.
+
+import (
+	"strings"
+
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+)
+
+func init() {
+	RegisterRecoveryFstabBuildComponents(android.InitRegistrationContext)
+}
+
+func RegisterRecoveryFstabBuildComponents(ctx android.RegistrationContext) {
+	ctx.RegisterModuleType("recovery_fstab", RecoveryFstabFactory)
+}
+
+var PrepareForTestWithRecoveryFstab = android.FixtureRegisterWithContext(RegisterRecoveryFstabBuildComponents)
+
+// The awk script that generates the recovery fstab: it drops comments and blank lines, keeps the
+// entries of the selected mount points and fails on entries that don't have the 5 fields of the
+// fs_mgr fstab format.
+const recoveryFstabAwkScript = `BEGIN {
+  n = split(include, inc, " "); for (i = 1; i <= n; i++) want[inc[i]] = 1
+  m = split(exclude, exc, " "); for (i = 1; i <= m; i++) skip[exc[i]] = 1
+}
+/^[ \t]*(#|$)/ { next }
+NF != 5 { printf("%s:%d: expected 5 fields, found %d\n", FILENAME, FNR, NF) > "/dev/stderr"; exit 1 }
+(n == 0 || ($2 in want)) && !($2 in skip) { print $1 "\t" $2 "\t" $3 "\t" $4 "\t" $5 }`
+
+type recoveryFstabProperties struct {
+	// The fstab of the device that the recovery fstab is generated from. Can reference a genrule
+	// type module with the ":module" syntax.
+	Src *string `android:"path,arch_variant"`
+
+	// Optional name for the installed file. Defaults to recovery.fstab.
+	Filename *string
+
+	// The mount points to include in the recovery fstab. If empty, all the mount points of src are
+	// included.
+	Mount_points []string
+
+	// The mount points to leave out of the recovery fstab.
+	Exclude_mount_points []string
+}
+
+type RecoveryFstab struct {
+	android.ModuleBase
+
+	properties recoveryFstabProperties
+
+	outputFilePath android.OutputPath
+	installDirPath android.InstallPath
+}
+
+// recovery_fstab generates the recovery.fstab of the recovery image from the fstab of the device.
+// The entries of the selected mount points are copied without comments, and an entry that isn't in
+// the fs_mgr fstab format fails the build.
+func RecoveryFstabFactory() android.Module {
+	module := &RecoveryFstab{}
+	module.AddProperties(&module.properties)
+	android.InitAndroidArchModule(module, android.DeviceSupported, android.MultilibFirst)
+	return module
+}
+
+// InstallInRecovery returns true as the recovery fstab is only used by the recovery image.
+func (f *RecoveryFstab) InstallInRecovery() bool {
+	return true
+}
+
+func (f *RecoveryFstab) OutputFile() android.OutputPath {
+	return f.outputFilePath
+}
+
+func (f *RecoveryFstab) GenerateAndroidBuildActions(ctx android.ModuleContext) {
+	if f.properties.Src == nil {
+		ctx.PropertyErrorf("src", "missing source fstab")
+		return
+	}
+	filename := proptools.StringDefault(f.properties.Filename, "recovery.fstab")
+	if strings.Contains(filename, "/") {
+		ctx.PropertyErrorf("filename", "filename cannot contain separator '/'")
+		return
+	}
+	for _, mountPoint := range f.properties.Mount_points {
+		if android.InList(mountPoint, f.properties.Exclude_mount_points) {
+			ctx.PropertyErrorf("exclude_mount_points", "%q is also listed in mount_points", mountPoint)
+		}
+	}
+
+	src := android.PathForModuleSrc(ctx, proptools.String(f.properties.Src))
+	f.outputFilePath = android.PathForModuleOut(ctx, filename).OutputPath
+
+	rule := android.NewRuleBuilder(pctx, ctx)
+	rule.Command().
+		Text("awk").
+		FlagWithArg("-v include=", proptools.ShellEscape(strings.Join(f.properties.Mount_points, " "))).
+		FlagWithArg("-v exclude=", proptools.ShellEscape(strings.Join(f.properties.Exclude_mount_points, " "))).
+		Text(proptools.ShellEscape(recoveryFstabAwkScript)).
+		Input(src).
+		Text(">").Output(f.outputFilePath)
+	rule.Build("recovery_fstab", "generate recovery fstab "+filename)
+
+	f.installDirPath = android.PathForModuleInstall(ctx, "etc")
+	ctx.InstallFile(f.installDirPath, filename, f.outputFilePath)
+}
+
+func (f *RecoveryFstab) AndroidMkEntries() []android.AndroidMkEntries {
+	return []android.AndroidMkEntries{android.AndroidMkEntries{
+		Class:      "ETC",
+		OutputFile: android.OptionalPathForPath(f.outputFilePath),
+		ExtraEntries: []android.AndroidMkExtraEntriesFunc{
+			func(ctx android.AndroidMkExtraEntriesContext, entries *android.AndroidMkEntries) {
+				entries.SetString("LOCAL_MODULE_TAGS", "optional")
+				entries.SetString("LOCAL_MODULE_PATH", f.installDirPath.String())
+				entries.SetString("LOCAL_INSTALLED_MODULE_STEM", f.outputFilePath.Base())
+			},
+		},
+	}}
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etc
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+var prepareForRecoveryFstabTest = android.GroupFixturePreparers(
+	android.PrepareForTestWithArchMutator,
+	PrepareForTestWithRecoveryFstab,
+	android.FixtureMergeMockFs(android.MockFS{
+		"fstab.device": nil,
+	}),
+)
+
+func TestRecoveryFstab(t *testing.T) {
+	result := prepareForRecoveryFstabTest.RunTestWithBp(t, `
+		recovery_fstab {
+			name: "recovery_fstab",
+			src: "fstab.device",
+			mount_points: ["/data", "/metadata"],
+			exclude_mount_points: ["/vendor"],
+		}
+	`)
+
+	module := result.ModuleForTests("recovery_fstab", "android_arm64_armv8-a")
+	f := module.Module().(*RecoveryFstab)
+	android.AssertPathRelativeToTopEquals(t, "output", "out/soong/.intermediates/recovery_fstab/android_arm64_armv8-a/recovery.fstab", f.OutputFile())
+	android.AssertPathRelativeToTopEquals(t, "install dir", "out/soong/target/product/test_device/recovery/root/system/etc", f.installDirPath)
+
+	cmd := module.Rule("recovery_fstab").RuleParams.Command
+	android.AssertStringDoesContain(t, "include", cmd, "-v include='/data /metadata'")
+	android.AssertStringDoesContain(t, "exclude", cmd, "-v exclude=/vendor")
+	android.AssertStringDoesContain(t, "input", cmd, "fstab.device > ")
+}
+
+func TestRecoveryFstabErrors(t *testing.T) {
+	prepareForRecoveryFstabTest.
+		ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+			`exclude_mount_points: "/vendor" is also listed in mount_points`)).
+		RunTestWithBp(t, `
+			recovery_fstab {
+				name: "recovery_fstab",
+				src: "fstab.device",
+				mount_points: ["/vendor"],
+				exclude_mount_points: ["/vendor"],
+			}
+		`)
+
+	prepareForRecoveryFstabTest.
+		ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+			`src: missing source fstab`)).
+		RunTestWithBp(t, `
+			recovery_fstab {
+				name: "recovery_fstab",
+			}
+		`)
+}
@@ -112,6 +112,7 @@ func (a *avbAddHashFooter) GenerateAndroidBuildActions(ctx android.ModuleContext
 
 	algorithm := proptools.StringDefault(a.properties.Algorithm, "SHA256_RSA4096")
 	cmd.FlagWithArg("--algorithm ", algorithm)
+	cmd.Flags(ctx.Config().SigningBackend().AvbtoolFlags())
 
 	if a.properties.Salt == nil {
 		ctx.PropertyErrorf("salt", "missing salt value")
@@ -254,7 +254,8 @@ func (b *bootimg) buildPropFile(ctx android.ModuleContext) (propFile android.Out
 	addStr("avb_algorithm", algorithm)
 	key := android.PathForModuleSrc(ctx, proptools.String(b.properties.Avb_private_key))
 	addPath("avb_key_path", key)
-	addStr("avb_add_hash_footer_args", "") // TODO(jiyong): add --rollback_index
+	// TODO(jiyong): add --rollback_index
+	addStr("avb_add_hash_footer_args", strings.Join(ctx.Config().SigningBackend().AvbtoolFlags(), " "))
 	partitionName := proptools.StringDefault(b.properties.Partition_name, b.Name())
 	addStr("partition_name", partitionName)
 	addStr("avb_salt", b.salt())
@@ -0,0 +1,101 @@
+// Copyright (C) 2023 The Android Open Source Project
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystem
+
+import (
+	"fmt"
+	"strings"
+
+	"android/soong/android"
+
+	"github.com/google/blueprint/proptools"
+)
+
+type customPartition struct {
+	filesystem
+
+	properties customPartitionProperties
+}
+
+type copyFileDefinition struct {
+	// Source file to copy into the partition.
+	Src *string `android:"path"`
+
+	// Path of the copy, relative to the root of the partition.
+	Dest *string
+}
+
+type customPartitionProperties struct {
+	// Name of the partition, e.g. "preload". The image is mounted at /<partition> and installed as
+	// <partition>.img. Defaults to the name of this module.
+	Partition *string
+
+	// Files to be copied into the partition, in addition to the modules in deps.
+	Copy_files []copyFileDefinition
+
+	// Maximum size of the partition image in bytes. The build fails if the image is larger.
+	Max_size *int64
+}
+
+// android_custom_partition is a specialization of android_filesystem for the image of a partition
+// that isn't one of the partitions of the platform, like a preload partition of an OEM. The
+// modules in deps are placed at their install paths relative to their own partitions, so a
+// product_specific app is placed in app/<name> of the partition.
+func customPartitionFactory() android.Module {
+	module := &customPartition{}
+	module.AddProperties(&module.properties)
+	module.filesystem.buildExtraFiles = module.buildExtraFiles
+	initFilesystemModule(&module.filesystem)
+	return module
+}
+
+func (c *customPartition) partitionName() string {
+	return proptools.StringDefault(c.properties.Partition, c.BaseModuleName())
+}
+
+func (c *customPartition) GenerateAndroidBuildActions(ctx android.ModuleContext) {
+	if partition := c.partitionName(); strings.Contains(partition, "/") {
+		ctx.PropertyErrorf("partition", "%q must not contain a /", partition)
+		return
+	}
+	if c.properties.Max_size != nil && *c.properties.Max_size <= 0 {
+		ctx.PropertyErrorf("max_size", "must be a positive number of bytes")
+		return
+	}
+	c.filesystem.customPartition = c.partitionName()
+	c.filesystem.maxImageSize = proptools.Int64(c.properties.Max_size)
+	c.filesystem.GenerateAndroidBuildActions(ctx)
+}
+
+func (c *customPartition) buildExtraFiles(ctx android.ModuleContext, root android.OutputPath) android.OutputPaths {
+	var extraFiles android.OutputPaths
+	builder := android.NewRuleBuilder(pctx, ctx)
+	for _, copyFile := range c.properties.Copy_files {
+		src := proptools.String(copyFile.Src)
+		dest := strings.TrimPrefix(proptools.String(copyFile.Dest), "/")
+		if src == "" || dest == "" {
+			ctx.PropertyErrorf("copy_files", "src and dest must both be set")
+			continue
+		}
+		// OutputPath.Join verifies dest.
+		output := root.Join(ctx, dest)
+		builder.Command().Text("cp -f").Input(android.PathForModuleSrc(ctx, src)).Output(output)
+		extraFiles = append(extraFiles, output)
+	}
+	if len(extraFiles) > 0 {
+		builder.Build("copy_files", fmt.Sprintf("Copying files into partition %s", c.partitionName()))
+	}
+	return extraFiles
+}
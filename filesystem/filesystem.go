@@ -35,6 +35,7 @@ func init() {
 func registerBuildComponents(ctx android.RegistrationContext) {
 	ctx.RegisterModuleType("android_filesystem", filesystemFactory)
 	ctx.RegisterModuleType("android_system_image", systemImageFactory)
+	ctx.RegisterModuleType("android_custom_partition", customPartitionFactory)
 	ctx.RegisterModuleType("avb_add_hash_footer", avbAddHashFooterFactory)
 	ctx.RegisterModuleType("avb_gen_vbmeta_image", avbGenVbmetaImageFactory)
 }
@@ -51,9 +52,20 @@ type filesystem struct {
 	// Function that filters PackagingSpecs returned by PackagingBase.GatherPackagingSpecs()
 	filterPackagingSpecs func(specs map[string]android.PackagingSpec)
 
+	// Name of the partition if this is the image of a custom partition, or "". Custom partition
+	// images are mounted at /<partition> and installed as <partition>.img in the product out
+	// directory.
+	customPartition string
+
+	// Maximum size of the image in bytes, or 0 if it is unlimited.
+	maxImageSize int64
+
 	output     android.OutputPath
 	installDir android.InstallPath
 
+	// Lists the files of the image that are packaged because of install tags, with their tags.
+	installTagsReport android.OutputPath
+
 	// For testing. Keeps the result of CopyDepsToZip()
 	entries []string
 }
@@ -104,6 +116,23 @@ type filesystemProperties struct {
 	// When set, passed to mkuserimg_mke2fs --mke2fs_uuid & --mke2fs_hash_seed.
 	// Otherwise, they'll be set as random which might cause indeterministic build output.
 	Uuid *string
+
+	// Normalizations that make consecutive builds of identical inputs produce bit-identical
+	// images. Currently, only ext4 is supported. `m check_reproducible_images` builds the images
+	// that use any of them a second time and fails if the two builds differ.
+	Reproducible struct {
+		// When true, the timestamps of the file entries are set to the seconds since unix epoch
+		// in the BuildTimestampSource file of the product. Default is false.
+		Fixed_timestamp *bool
+
+		// When true, the files are added to the image in sorted order, so that their inodes
+		// don't depend on the order the deps were installed in. Default is false.
+		Stable_inode_order *bool
+
+		// When true and uuid isn't set, the uuid and the hash seed of the image are zeroed
+		// instead of being random. Default is false.
+		Zero_uuid *bool
+	}
 }
 
 // android_filesystem packages a set of modules and their transitive dependencies into a filesystem
@@ -157,11 +186,17 @@ func (f *filesystem) fsType(ctx android.ModuleContext) fsType {
 }
 
 func (f *filesystem) installFileName() string {
+	if f.customPartition != "" {
+		return f.customPartition + ".img"
+	}
 	return f.BaseModuleName() + ".img"
 }
 
 var pctx = android.NewPackageContext("android/soong/filesystem")
 
+// The uuid and hash seed of the images with reproducible.zero_uuid.
+const zeroUuid = "00000000-0000-0000-0000-000000000000"
+
 func (f *filesystem) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 	switch f.fsType(ctx) {
 	case ext4Type:
@@ -174,7 +209,11 @@ func (f *filesystem) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 		return
 	}
 
-	f.installDir = android.PathForModuleInstall(ctx, "etc")
+	if f.customPartition != "" {
+		f.installDir = android.PathForModuleInPartitionInstall(ctx, "")
+	} else {
+		f.installDir = android.PathForModuleInstall(ctx, "etc")
+	}
 	ctx.InstallFile(f.installDir, f.installFileName(), f.output)
 }
 
@@ -248,16 +287,23 @@ func (f *filesystem) buildRootZip(ctx android.ModuleContext) android.OutputPath
 
 func (f *filesystem) buildImageUsingBuildImage(ctx android.ModuleContext) android.OutputPath {
 	depsZipFile := android.PathForModuleOut(ctx, "deps.zip").OutputPath
-	f.entries = f.CopyDepsToZip(ctx, f.gatherFilteredPackagingSpecs(ctx), depsZipFile)
+	specs := f.gatherFilteredPackagingSpecs(ctx)
+	f.buildInstallTagsReport(ctx, specs)
+	f.entries = f.CopyDepsToZip(ctx, specs, depsZipFile)
 
 	builder := android.NewRuleBuilder(pctx, ctx)
 	depsBase := proptools.StringDefault(f.properties.Base_dir, ".")
 	rebasedDepsZip := android.PathForModuleOut(ctx, "rebased_deps.zip").OutputPath
-	builder.Command().
+	zip2zip := builder.Command().
 		BuiltTool("zip2zip").
 		FlagWithInput("-i ", depsZipFile).
-		FlagWithOutput("-o ", rebasedDepsZip).
-		Text("**/*:" + proptools.ShellEscape(depsBase)) // zip2zip verifies depsBase
+		FlagWithOutput("-o ", rebasedDepsZip)
+	if proptools.Bool(f.properties.Reproducible.Stable_inode_order) {
+		// zipsync creates the files in the order of the zip entries, and the image tools add
+		// them to the image in the order they were created in.
+		zip2zip.Flag("-s")
+	}
+	zip2zip.Text("**/*:" + proptools.ShellEscape(depsBase)) // zip2zip verifies depsBase
 
 	rootDir := android.PathForModuleOut(ctx, "root").OutputPath
 	rootZip := f.buildRootZip(ctx)
@@ -277,17 +323,61 @@ func (f *filesystem) buildImageUsingBuildImage(ctx android.ModuleContext) androi
 
 	propFile, toolDeps := f.buildPropFile(ctx)
 	output := android.PathForModuleOut(ctx, f.installFileName()).OutputPath
+	addBuildImageCommand(builder, rootDir, propFile, toolDeps, output)
+	f.checkImageSize(builder, output)
+
+	// rootDir is not deleted. Might be useful for quick inspection.
+	builder.Build("build_filesystem_image", fmt.Sprintf("Creating filesystem %s", f.BaseModuleName()))
+
+	if f.reproducible() {
+		f.checkReproducible(ctx, rootZip, rebasedDepsZip, propFile, toolDeps, output)
+	}
+
+	return output
+}
+
+func addBuildImageCommand(builder *android.RuleBuilder, rootDir android.OutputPath, propFile android.Path,
+	toolDeps android.Paths, output android.WritablePath) {
 	builder.Command().BuiltTool("build_image").
 		Text(rootDir.String()). // input directory
 		Input(propFile).
 		Implicits(toolDeps).
 		Output(output).
 		Text(rootDir.String()) // directory where to find fs_config_files|dirs
+}
 
-	// rootDir is not deleted. Might be useful for quick inspection.
-	builder.Build("build_filesystem_image", fmt.Sprintf("Creating filesystem %s", f.BaseModuleName()))
+// reproducible returns whether the image uses any of the reproducibility normalizations.
+func (f *filesystem) reproducible() bool {
+	r := f.properties.Reproducible
+	return proptools.Bool(r.Fixed_timestamp) || proptools.Bool(r.Stable_inode_order) ||
+		proptools.Bool(r.Zero_uuid)
+}
 
-	return output
+// checkReproducible builds the image a second time and fails if it isn't bit-identical to the
+// first build. The second build stages its own root directory from the same zips, so that its
+// files have other timestamps and inode numbers than the first one, which the reproducibility
+// normalizations must hide. The check is run by `m check_reproducible_images`.
+func (f *filesystem) checkReproducible(ctx android.ModuleContext, rootZip, rebasedDepsZip android.Path,
+	propFile android.Path, toolDeps android.Paths, output android.OutputPath) {
+	rootDir := android.PathForModuleOut(ctx, "reproducibility", "root").OutputPath
+	rebuilt := android.PathForModuleOut(ctx, "reproducibility", f.installFileName())
+	timestamp := android.PathForModuleOut(ctx, "reproducibility", "check.timestamp")
+
+	builder := android.NewRuleBuilder(pctx, ctx)
+	builder.Command().
+		BuiltTool("zipsync").
+		FlagWithArg("-d ", rootDir.String()). // zipsync wipes this. No need to clear.
+		Input(rootZip).
+		Input(rebasedDepsZip)
+	addBuildImageCommand(builder, rootDir, propFile, toolDeps, rebuilt)
+	builder.Command().
+		Text("cmp").Input(output).Input(rebuilt).
+		Textf(`|| (echo "%s is not reproducible, two builds of identical inputs differ" >&2; exit 1)`,
+			f.installFileName())
+	builder.Command().Text("touch").Output(timestamp)
+	builder.Build("check_reproducible_image", fmt.Sprintf("Checking reproducibility of %s", f.BaseModuleName()))
+
+	ctx.Phony("check_reproducible_images", timestamp)
 }
 
 func (f *filesystem) buildFileContexts(ctx android.ModuleContext) android.OutputPath {
@@ -313,6 +403,7 @@ func (f *filesystem) buildPropFile(ctx android.ModuleContext) (propFile android.
 
 	var props []prop
 	var deps android.Paths
+	var propDeps android.Paths
 	addStr := func(name string, value string) {
 		props = append(props, prop{name, value})
 	}
@@ -332,7 +423,11 @@ func (f *filesystem) buildPropFile(ctx android.ModuleContext) (propFile android.
 	}
 
 	addStr("fs_type", fsTypeStr(f.fsType(ctx)))
-	addStr("mount_point", "/")
+	if f.customPartition != "" {
+		addStr("mount_point", f.customPartition)
+	} else {
+		addStr("mount_point", "/")
+	}
 	addStr("use_dynamic_partition_size", "true")
 	addPath("ext_mkuserimg", ctx.Config().HostToolPath(ctx, "mkuserimg_mke2fs"))
 	// b/177813163 deps of the host tools have to be added. Remove this.
@@ -351,8 +446,14 @@ func (f *filesystem) buildPropFile(ctx android.ModuleContext) (propFile android.
 		if hashAlgorithm := proptools.String(f.properties.Avb_hash_algorithm); hashAlgorithm != "" {
 			avb_add_hashtree_footer_args += " --hash_algorithm " + hashAlgorithm
 		}
+		if signingFlags := ctx.Config().SigningBackend().AvbtoolFlags(); len(signingFlags) > 0 {
+			avb_add_hashtree_footer_args += " " + strings.Join(signingFlags, " ")
+		}
 		addStr("avb_add_hashtree_footer_args", avb_add_hashtree_footer_args)
 		partitionName := proptools.StringDefault(f.properties.Partition_name, f.Name())
+		if f.properties.Partition_name == nil && f.customPartition != "" {
+			partitionName = f.customPartition
+		}
 		addStr("partition_name", partitionName)
 		addStr("avb_salt", f.salt())
 	}
@@ -363,13 +464,28 @@ func (f *filesystem) buildPropFile(ctx android.ModuleContext) (propFile android.
 	if timestamp := proptools.String(f.properties.Fake_timestamp); timestamp != "" {
 		addStr("timestamp", timestamp)
 	}
+	if proptools.Bool(f.properties.Reproducible.Fixed_timestamp) {
+		if f.properties.Fake_timestamp != nil {
+			ctx.PropertyErrorf("reproducible.fixed_timestamp", "can't be used with fake_timestamp")
+		} else if source := ctx.Config().BuildTimestampSource(ctx); !source.Valid() {
+			ctx.PropertyErrorf("reproducible.fixed_timestamp", "requires the BuildTimestampSource of the product")
+		} else {
+			// Read when the prop file is built, so that only the images are rebuilt when the
+			// timestamp changes.
+			addStr("timestamp", "$(cat "+source.String()+")")
+			propDeps = append(propDeps, source.Path())
+		}
+	}
 	if uuid := proptools.String(f.properties.Uuid); uuid != "" {
 		addStr("uuid", uuid)
 		addStr("hash_seed", uuid)
+	} else if proptools.Bool(f.properties.Reproducible.Zero_uuid) {
+		addStr("uuid", zeroUuid)
+		addStr("hash_seed", zeroUuid)
 	}
 	propFile = android.PathForModuleOut(ctx, "prop").OutputPath
 	builder := android.NewRuleBuilder(pctx, ctx)
-	builder.Command().Text("rm").Flag("-rf").Output(propFile)
+	builder.Command().Text("rm").Flag("-rf").Output(propFile).Implicits(propDeps)
 	for _, p := range props {
 		builder.Command().
 			Text("echo").
@@ -390,8 +506,14 @@ func (f *filesystem) buildCpioImage(ctx android.ModuleContext, compressed bool)
 		ctx.PropertyErrorf("file_contexts", "file_contexts is not supported for compressed cpio image.")
 	}
 
+	if f.reproducible() {
+		ctx.PropertyErrorf("reproducible", "is not supported for cpio images, which are always reproducible.")
+	}
+
 	depsZipFile := android.PathForModuleOut(ctx, "deps.zip").OutputPath
-	f.entries = f.CopyDepsToZip(ctx, f.gatherFilteredPackagingSpecs(ctx), depsZipFile)
+	specs := f.gatherFilteredPackagingSpecs(ctx)
+	f.buildInstallTagsReport(ctx, specs)
+	f.entries = f.CopyDepsToZip(ctx, specs, depsZipFile)
 
 	builder := android.NewRuleBuilder(pctx, ctx)
 	depsBase := proptools.StringDefault(f.properties.Base_dir, ".")
@@ -424,6 +546,7 @@ func (f *filesystem) buildCpioImage(ctx android.ModuleContext, compressed bool)
 	} else {
 		cmd.Text(">").Output(output)
 	}
+	f.checkImageSize(builder, output)
 
 	// rootDir is not deleted. Might be useful for quick inspection.
 	builder.Build("build_cpio_image", fmt.Sprintf("Creating filesystem %s", f.BaseModuleName()))
@@ -431,6 +554,27 @@ func (f *filesystem) buildCpioImage(ctx android.ModuleContext, compressed bool)
 	return output
 }
 
+// checkImageSize fails the rule that builds the image if it is larger than maxImageSize.
+func (f *filesystem) checkImageSize(builder *android.RuleBuilder, image android.OutputPath) {
+	if f.maxImageSize <= 0 {
+		return
+	}
+	builder.Command().
+		Textf(`size=$(wc -c < %s) && if [ "$size" -gt %d ]; then`, image.String(), f.maxImageSize).
+		Textf(`echo "%s is $size bytes, which exceeds its size budget of %d bytes" >&2; exit 1; fi`,
+			f.installFileName(), f.maxImageSize)
+}
+
+var _ android.SizeBudgetModule = (*filesystem)(nil)
+
+// Implements android.SizeBudgetModule
+func (f *filesystem) SizeBudget() (android.Path, int64) {
+	if f.maxImageSize <= 0 {
+		return nil, 0
+	}
+	return f.output, f.maxImageSize
+}
+
 var _ android.AndroidMkEntriesProvider = (*filesystem)(nil)
 
 // Implements android.AndroidMkEntriesProvider
@@ -451,10 +595,14 @@ var _ android.OutputFileProducer = (*filesystem)(nil)
 
 // Implements android.OutputFileProducer
 func (f *filesystem) OutputFiles(tag string) (android.Paths, error) {
-	if tag == "" {
+	switch tag {
+	case "":
 		return []android.Path{f.output}, nil
+	case "install_tags_report":
+		return []android.Path{f.installTagsReport}, nil
+	default:
+		return nil, fmt.Errorf("unsupported module reference tag %q", tag)
 	}
-	return nil, fmt.Errorf("unsupported module reference tag %q", tag)
 }
 
 // Filesystem is the public interface for the filesystem struct. Currently, it's only for the apex
@@ -492,6 +640,20 @@ func (f *filesystem) gatherFilteredPackagingSpecs(ctx android.ModuleContext) map
 	return specs
 }
 
+// buildInstallTagsReport writes the files of the image that are packaged only because install
+// tags are enabled by the product, one per line followed by the tags.
+func (f *filesystem) buildInstallTagsReport(ctx android.ModuleContext, specs map[string]android.PackagingSpec) {
+	var lines []string
+	for _, relPath := range android.SortedKeys(specs) {
+		ps := specs[relPath]
+		if tags := ps.InstallTags(); len(tags) > 0 {
+			lines = append(lines, relPath+" "+strings.Join(tags, ","))
+		}
+	}
+	f.installTagsReport = android.PathForModuleOut(ctx, "install_tags_report.txt").OutputPath
+	android.WriteFileRule(ctx, f.installTagsReport, strings.Join(lines, "\n"))
+}
+
 func sha1sum(values []string) string {
 	h := sha256.New()
 	for _, value := range values {
@@ -282,3 +282,119 @@ func TestFileSystemWithCoverageVariants(t *testing.T) {
 		t.Error("prebuilt should use cov variant of filesystem")
 	}
 }
+
+func TestCustomPartition(t *testing.T) {
+	result := fixture.RunTestWithBp(t, `
+		android_custom_partition {
+			name: "mypreload",
+			partition: "preload",
+			deps: ["libfoo"],
+			copy_files: [
+				{
+					src: "Foo.apk",
+					dest: "app/Foo/Foo.apk",
+				},
+			],
+			max_size: 1048576,
+			use_avb: true,
+			avb_private_key: "mykey",
+		}
+
+		cc_library {
+			name: "libfoo",
+			stl: "none",
+		}
+	`)
+
+	module := result.ModuleForTests("mypreload", "android_common")
+	android.AssertStringListContains(t, "entries should have libfoo",
+		module.Module().(*customPartition).entries, "lib64/libfoo.so")
+
+	image := module.Output("preload.img")
+	android.AssertStringDoesContain(t, "image should be checked against max_size",
+		image.RuleParams.Command, "exceeds its size budget of 1048576 bytes")
+
+	prop := module.Output("prop")
+	android.AssertStringDoesContain(t, "prop should have the mount point",
+		prop.RuleParams.Command, `"mount_point=preload"`)
+	android.AssertStringDoesContain(t, "prop should have the partition name",
+		prop.RuleParams.Command, `"partition_name=preload"`)
+
+	copyFile := module.Output("out/soong/.intermediates/mypreload/android_common/gen/root-extra/app/Foo/Foo.apk")
+	android.AssertStringDoesContain(t, "copy_files should be copied",
+		copyFile.RuleParams.Command, "cp -f Foo.apk")
+
+	android.AssertPathRelativeToTopEquals(t, "install dir",
+		"out/soong/target/product/test_device",
+		module.Module().(*customPartition).installDir)
+}
+
+func TestReproducibleFileSystem(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		fixture,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.BuildTimestampSource = proptools.StringPtr("build/timestamp.txt")
+		}),
+		android.FixtureAddTextFile("build/timestamp.txt", "1672531200"),
+	).RunTestWithBp(t, `
+		android_filesystem {
+			name: "myfilesystem",
+			reproducible: {
+				fixed_timestamp: true,
+				stable_inode_order: true,
+				zero_uuid: true,
+			},
+		}
+	`)
+
+	module := result.ModuleForTests("myfilesystem", "android_common")
+	prop := module.Output("prop")
+	android.AssertStringDoesContain(t, "prop should read the timestamp from BuildTimestampSource",
+		prop.RuleParams.Command, `"timestamp=$$(cat build/timestamp.txt)"`)
+	android.AssertStringDoesContain(t, "prop should have the zeroed uuid",
+		prop.RuleParams.Command, `"uuid=`+zeroUuid+`"`)
+	android.AssertStringDoesContain(t, "image should sort the deps",
+		module.Output("myfilesystem.img").RuleParams.Command, "-s **/*:.")
+
+	check := module.Output("reproducibility/check.timestamp")
+	android.AssertStringDoesContain(t, "the second build should stage its own root",
+		check.RuleParams.Command, "zipsync -d out/soong/.intermediates/myfilesystem/android_common/reproducibility/root")
+	android.AssertStringDoesContain(t, "the image should be compared with its second build",
+		check.RuleParams.Command, "cmp out/soong/.intermediates/myfilesystem/android_common/myfilesystem.img")
+}
+
+func TestFileSystemInstallTagsReport(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		fixture,
+		android.FixtureRegisterWithContext(registerComponent),
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.ImageInstallTags = []string{"debug"}
+		}),
+	).RunTestWithBp(t, `
+		android_filesystem {
+			name: "myfilesystem",
+			multilib: {
+				common: {
+					deps: ["foo", "bar", "baz"],
+				},
+			},
+		}
+		component {
+			name: "foo",
+		}
+		component {
+			name: "bar",
+			install_tags: ["debug"],
+		}
+		component {
+			name: "baz",
+			install_tags: ["factory"],
+		}
+	`)
+
+	module := result.ModuleForTests("myfilesystem", "android_common")
+	android.AssertDeepEquals(t, "entries should skip the modules with disabled install tags",
+		[]string{"components/bar", "components/foo"}, module.Module().(*filesystem).entries)
+	android.AssertStringEquals(t, "install tags report", "components/bar debug",
+		android.ContentFromFileRuleForTests(t, module.Output("install_tags_report.txt")))
+}
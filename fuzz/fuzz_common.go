@@ -19,6 +19,7 @@ package fuzz
 import (
 	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -376,7 +377,17 @@ type FuzzPackagedModule struct {
 }
 
 func GetFramework(ctx android.LoadHookContext, lang Lang) Framework {
-	framework := ctx.Config().Getenv("FUZZ_FRAMEWORK")
+	framework := ConfiguredFramework(ctx.Config(), lang)
+	if framework == UnknownFramework {
+		ctx.ModuleErrorf(fmt.Sprintf("%s is not a valid fuzzing framework for %s", ctx.Config().Getenv("FUZZ_FRAMEWORK"), lang))
+	}
+	return framework
+}
+
+// ConfiguredFramework returns the fuzzing framework that FUZZ_FRAMEWORK selects for the fuzz
+// targets of the language, or UnknownFramework if it isn't valid for the language.
+func ConfiguredFramework(config android.Config, lang Lang) Framework {
+	framework := config.Getenv("FUZZ_FRAMEWORK")
 
 	if lang == Cc {
 		switch strings.ToLower(framework) {
@@ -393,7 +404,6 @@ func GetFramework(ctx android.LoadHookContext, lang Lang) Framework {
 		return Jazzer
 	}
 
-	ctx.ModuleErrorf(fmt.Sprintf("%s is not a valid fuzzing framework for %s", framework, lang))
 	return UnknownFramework
 }
 
@@ -437,7 +447,7 @@ func (s *FuzzPackager) PackageArtifacts(ctx android.SingletonContext, module and
 	// Package the corpora into a zipfile.
 	var files []FileToZip
 	if fuzzModule.Corpus != nil {
-		corpusZip := archDir.Join(ctx, module.Name()+"_seed_corpus.zip")
+		corpusZip := SeedCorpusZip(ctx, archDir, module.Name())
 		command := builder.Command().BuiltTool("soong_zip").
 			Flag("-j").
 			FlagWithOutput("-o ", corpusZip)
@@ -472,6 +482,12 @@ func (s *FuzzPackager) PackageArtifacts(ctx android.SingletonContext, module and
 	return files
 }
 
+// SeedCorpusZip returns the zip of the seed corpus of the fuzz target that PackageArtifacts
+// creates in archDir.
+func SeedCorpusZip(ctx android.PathContext, archDir android.OutputPath, name string) android.OutputPath {
+	return archDir.Join(ctx, name+"_seed_corpus.zip")
+}
+
 func (s *FuzzPackager) BuildZipFile(ctx android.SingletonContext, module android.Module, fuzzModule FuzzPackagedModule, files []FileToZip, builder *android.RuleBuilder, archDir android.OutputPath, archString string, hostOrTargetString string, archOs ArchOs, archDirs map[ArchOs][]FileToZip) ([]FileToZip, bool) {
 	fuzzZip := archDir.Join(ctx, module.Name()+".zip")
 
@@ -568,3 +584,97 @@ func (s *FuzzPackager) PreallocateSlice(ctx android.MakeVarsContext, targets str
 	sort.Strings(fuzzTargets)
 	ctx.Strict(targets, strings.Join(fuzzTargets, " "))
 }
+
+// FuzzTargetMetadata describes how to launch a fuzz target of a device fuzz artifact. The paths
+// are relative to the root of the artifact.
+type FuzzTargetMetadata struct {
+	Name      string    `json:"name"`
+	Arch      string    `json:"arch"`
+	Framework Framework `json:"framework"`
+	Binary    string    `json:"binary"`
+	// The directory of the shared libraries of the fuzz target, including the sanitizer runtimes,
+	// to launch it with in LD_LIBRARY_PATH.
+	LibDir     string      `json:"lib_dir"`
+	Corpus     string      `json:"corpus,omitempty"`
+	Dictionary string      `json:"dictionary,omitempty"`
+	Config     *FuzzConfig `json:"config,omitempty"`
+}
+
+// DeviceFuzzArtifact collects the fuzz targets of the device into a single zip, suitable for
+// uploading to fuzzing infrastructure, with the binaries, the seed corpora and the dictionaries of
+// the fuzz targets in <arch>/<name>/, their shared libraries in <arch>/lib/, and the metadata to
+// launch them in fuzz_targets.json.
+type DeviceFuzzArtifact struct {
+	Targets []FuzzTargetMetadata
+
+	files []FileToZip
+	// The paths in the zip of the files added to it, to package the shared libraries once.
+	entries map[string]bool
+}
+
+func (a *DeviceFuzzArtifact) add(file android.Path, prefix string) string {
+	entry := filepath.Join(prefix, file.Base())
+	if a.entries == nil {
+		a.entries = make(map[string]bool)
+	}
+	if !a.entries[entry] {
+		a.entries[entry] = true
+		a.files = append(a.files, FileToZip{file, prefix})
+	}
+	return entry
+}
+
+// AddTarget adds the fuzz target of the module, built for the arch, to the artifact, with its
+// packaged corpus and dictionary from archDir and its shared libraries.
+func (a *DeviceFuzzArtifact) AddTarget(ctx android.PathContext, module android.Module, fuzzModule FuzzPackagedModule,
+	framework Framework, arch string, archDir android.OutputPath, binary android.Path, sharedLibraries android.Paths) {
+	targetDir := filepath.Join(arch, module.Name())
+	libDir := filepath.Join(arch, "lib")
+	target := FuzzTargetMetadata{
+		Name:      module.Name(),
+		Arch:      arch,
+		Framework: framework,
+		Binary:    a.add(binary, targetDir),
+		LibDir:    libDir,
+		Config:    fuzzModule.FuzzProperties.Fuzz_config,
+	}
+	if fuzzModule.Corpus != nil {
+		target.Corpus = a.add(SeedCorpusZip(ctx, archDir, module.Name()), targetDir)
+	}
+	if fuzzModule.Dictionary != nil {
+		target.Dictionary = a.add(fuzzModule.Dictionary, targetDir)
+	}
+	for _, library := range sharedLibraries {
+		a.add(library, libDir)
+	}
+	a.Targets = append(a.Targets, target)
+}
+
+// Build creates the zip of the artifact.
+func (a *DeviceFuzzArtifact) Build(ctx android.SingletonContext, pctx android.PackageContext, outputFile android.WritablePath) {
+	sort.Slice(a.Targets, func(i, j int) bool {
+		if a.Targets[i].Arch != a.Targets[j].Arch {
+			return a.Targets[i].Arch < a.Targets[j].Arch
+		}
+		return a.Targets[i].Name < a.Targets[j].Name
+	})
+	metadata, err := json.MarshalIndent(a.Targets, "", "  ")
+	if err != nil {
+		ctx.Errorf("failed to marshal the fuzz target metadata: %s", err)
+		return
+	}
+	metadataFile := android.PathForOutput(ctx, "fuzz", "fuzz_targets.json")
+	android.WriteFileRule(ctx, metadataFile, string(metadata))
+
+	builder := android.NewRuleBuilder(pctx, ctx)
+	command := builder.Command().BuiltTool("soong_zip").
+		Flag("-j").
+		FlagWithOutput("-o ", outputFile).
+		Flag("-P ''").
+		FlagWithInput("-f ", metadataFile)
+	for _, file := range a.files {
+		command.FlagWithArg("-P ", file.DestinationPathPrefix)
+		command.FlagWithInput("-f ", file.SourceFilePath)
+	}
+	builder.Build("create-device-fuzz-artifact", "Create the device fuzz artifact")
+}
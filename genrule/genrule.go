@@ -33,6 +33,7 @@ import (
 
 	"android/soong/android"
 	"android/soong/bazel"
+	"android/soong/remoteexec"
 )
 
 func init() {
@@ -151,6 +152,21 @@ type generatorProperties struct {
 
 	// input files to exclude
 	Exclude_srcs []string `android:"path,arch_variant"`
+
+	// Run the command with network access when the product runs genrule actions without it. Only
+	// allowed for the modules listed in the AllowNetworkActions product variable.
+	Allow_network *bool
+
+	// Run the command in a sandbox containing only the srcs, tools and tool_files, so that the
+	// command fails when it reads an undeclared input, like it would when run remotely. Defaults
+	// to true when the product sets SandboxedGenrules, where only the modules listed in the
+	// UnsandboxedGenrules product variable may set it to false.
+	Sandbox *bool
+
+	// Run the command remotely with RBE when the build uses it, with the sandbox as the inputs of
+	// the remote action, so it can't be combined with sandbox: false. Defaults to true for the
+	// modules in the directories listed in the GenruleRemoteExecutionPaths product variable.
+	Remote_execution *bool
 }
 
 type Module struct {
@@ -189,6 +205,9 @@ type Module struct {
 
 	// Collect the module directory for IDE info in java/jdeps.go.
 	modulePaths []string
+
+	// Whether the commands run in a sandbox containing only the declared inputs.
+	sandboxed bool
 }
 
 var _ android.MixedBuildBuildable = (*Module)(nil)
@@ -277,6 +296,57 @@ func (g *Module) ProcessBazelQueryResponse(ctx android.ModuleContext) {
 	}
 }
 
+// noNetwork returns true if the commands of the module run without network access, because the
+// product isolates the genrule actions from the network and the module doesn't opt out of it.
+func (g *Module) noNetwork(ctx android.ModuleContext) bool {
+	allowNetwork := Bool(g.properties.Allow_network)
+	if allowNetwork && !android.InList(ctx.ModuleName(), ctx.Config().AllowNetworkActions()) {
+		ctx.PropertyErrorf("allow_network", "module %q must be listed in the AllowNetworkActions product variable to use network access",
+			ctx.ModuleName())
+		return false
+	}
+	return ctx.Config().NetworkIsolatedActions() && !allowNetwork
+}
+
+// sandboxInputs returns true if the commands of the module run in a sandbox containing only the
+// declared inputs, because the module opts in to it or the product sandboxes the genrule actions.
+func (g *Module) sandboxInputs(ctx android.ModuleContext) bool {
+	sandboxedByDefault := ctx.Config().SandboxedGenrules() &&
+		!android.InList(ctx.ModuleName(), ctx.Config().UnsandboxedGenrules())
+	if sandboxedByDefault && g.properties.Sandbox != nil && !*g.properties.Sandbox {
+		ctx.PropertyErrorf("sandbox", "module %q must be listed in the UnsandboxedGenrules product variable to run without a sandbox",
+			ctx.ModuleName())
+		return true
+	}
+	return proptools.BoolDefault(g.properties.Sandbox, sandboxedByDefault)
+}
+
+// remoteExecution returns true if the commands of the module run remotely with RBE.
+func (g *Module) remoteExecution(ctx android.ModuleContext) bool {
+	if !ctx.Config().UseRBE() {
+		return false
+	}
+	inRemoteExecutionPaths := false
+	for _, dir := range ctx.Config().GenruleRemoteExecutionPaths() {
+		if strings.HasPrefix(ctx.ModuleDir()+"/", strings.TrimSuffix(dir, "/")+"/") {
+			inRemoteExecutionPaths = true
+			break
+		}
+	}
+	return proptools.BoolDefault(g.properties.Remote_execution, inRemoteExecutionPaths)
+}
+
+// newSboxRuleBuilder returns a RuleBuilder running the commands in an sbox sandbox, which also
+// contains only the declared inputs when the module is sandboxed.
+func (g *Module) newSboxRuleBuilder(ctx android.ModuleContext, outputDir android.WritablePath,
+	manifestPath android.WritablePath) *android.RuleBuilder {
+	rule := android.NewRuleBuilder(pctx, ctx).Sbox(outputDir, manifestPath)
+	if g.sandboxed {
+		return rule.SandboxInputs()
+	}
+	return rule.SandboxTools()
+}
+
 // generateCommonBuildActions contains build action generation logic
 // common to both the mixed build case and the legacy case of genrule processing.
 // To fully support genrule in mixed builds, the contents of this function should
@@ -429,6 +499,17 @@ func (g *Module) generateCommonBuildActions(ctx android.ModuleContext) {
 		cmd = g.CmdModifier(ctx, cmd)
 	}
 
+	noNetwork := g.noNetwork(ctx)
+	g.sandboxed = g.sandboxInputs(ctx)
+	remote := g.remoteExecution(ctx)
+	if remote && !g.sandboxed {
+		// The remote action only gets the inputs in the sandbox.
+		if g.properties.Sandbox != nil {
+			ctx.PropertyErrorf("remote_execution", "can't be combined with sandbox: false")
+		}
+		g.sandboxed = true
+	}
+
 	// Generate tasks, either from genrule or gensrcs.
 	for _, task := range g.taskGenerator(ctx, cmd, srcFiles) {
 		if len(task.out) == 0 {
@@ -452,7 +533,18 @@ func (g *Module) generateCommonBuildActions(ctx android.ModuleContext) {
 		manifestPath := android.PathForModuleOut(ctx, manifestName)
 
 		// Use a RuleBuilder to create a rule that runs the command inside an sbox sandbox.
-		rule := android.NewRuleBuilder(pctx, ctx).Sbox(task.genDir, manifestPath).SandboxTools()
+		rule := g.newSboxRuleBuilder(ctx, task.genDir, manifestPath)
+		if noNetwork {
+			rule.NoNetwork()
+		}
+		if remote {
+			rule.Remoteable(android.RemoteRuleSupports{RBE: true})
+			rule.Rewrapper(&remoteexec.REParams{
+				Labels:       map[string]string{"type": "tool", "name": "genrule"},
+				ExecStrategy: ctx.Config().GetenvWithDefault("RBE_GENRULE_EXEC_STRATEGY", remoteexec.DefaultExecStrategy("RBE_GENRULE_EXEC_STRATEGY")),
+				Platform:     map[string]string{remoteexec.PoolKey: ctx.Config().GetenvWithDefault("RBE_GENRULE_POOL", remoteexec.DefaultPool)},
+			})
+		}
 		cmd := rule.Command()
 
 		for _, out := range task.out {
@@ -737,7 +829,7 @@ func NewGenSrcs() *Module {
 			// TODO(ccross): this RuleBuilder is a hack to be able to call
 			// rule.Command().PathForOutput.  Replace this with passing the rule into the
 			// generator.
-			rule := android.NewRuleBuilder(pctx, ctx).Sbox(genDir, nil).SandboxTools()
+			rule := ctx.Module().(*Module).newSboxRuleBuilder(ctx, genDir, nil)
 
 			for _, in := range shard {
 				outFile := android.GenPathWithExt(ctx, finalSubDir, in, String(properties.Output_extension))
@@ -758,7 +850,7 @@ func NewGenSrcs() *Module {
 				command, err := android.Expand(rawCommand, func(name string) (string, error) {
 					switch name {
 					case "in":
-						return in.String(), nil
+						return rule.Command().PathForInput(in), nil
 					case "out":
 						return rule.Command().PathForOutput(outFile), nil
 					case "depfile":
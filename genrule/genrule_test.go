@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"strings"
 	"testing"
 
 	"android/soong/android"
@@ -754,6 +755,210 @@ func TestGenruleAllowMissingDependencies(t *testing.T) {
 	}
 }
 
+func TestGenruleNoNetwork(t *testing.T) {
+	bp := `
+		genrule {
+			name: "gen",
+			out: ["out"],
+			cmd: "echo foo > $(out)",
+		}
+
+		genrule {
+			name: "gen_with_network",
+			out: ["out"],
+			cmd: "echo foo > $(out)",
+			allow_network: true,
+		}
+	`
+
+	testcases := []struct {
+		name                   string
+		networkIsolatedActions bool
+		allowNetworkActions    []string
+		expectedNoNetwork      map[string]bool
+		err                    string
+	}{
+		{
+			name:                   "not allowed",
+			networkIsolatedActions: true,
+			err:                    `module "gen_with_network" must be listed in the AllowNetworkActions product variable`,
+		},
+		{
+			name:                   "isolated",
+			networkIsolatedActions: true,
+			allowNetworkActions:    []string{"gen_with_network"},
+			expectedNoNetwork:      map[string]bool{"gen": true, "gen_with_network": false},
+		},
+		{
+			name:                "allowed without isolation",
+			allowNetworkActions: []string{"gen_with_network"},
+			expectedNoNetwork:   map[string]bool{"gen": false, "gen_with_network": false},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			errorHandler := android.FixtureExpectsNoErrors
+			if test.err != "" {
+				errorHandler = android.FixtureExpectsAtLeastOneErrorMatchingPattern(regexp.QuoteMeta(test.err))
+			}
+			result := android.GroupFixturePreparers(
+				prepareForGenRuleTest,
+				android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+					variables.NetworkIsolatedActions = proptools.BoolPtr(test.networkIsolatedActions)
+					variables.AllowNetworkActions = test.allowNetworkActions
+				}),
+			).ExtendWithErrorHandler(errorHandler).RunTestWithBp(t, bp)
+			if test.err != "" {
+				return
+			}
+
+			for name, expected := range test.expectedNoNetwork {
+				command := result.ModuleForTests(name, "").Output("out").RuleParams.Command
+				android.AssertBoolEquals(t, name+" --no-network", expected, strings.Contains(command, "--no-network"))
+			}
+		})
+	}
+}
+
+func TestGenruleSandbox(t *testing.T) {
+	bp := `
+		genrule {
+			name: "gen",
+			srcs: ["in1"],
+			out: ["out"],
+			cmd: "cat $(in) > $(out)",
+		}
+
+		genrule {
+			name: "gen_sandboxed",
+			srcs: ["in1"],
+			out: ["out"],
+			cmd: "cat $(in) > $(out)",
+			sandbox: true,
+		}
+
+		genrule {
+			name: "gen_unsandboxed",
+			srcs: ["in1"],
+			out: ["out"],
+			cmd: "cat $(in) > $(out)",
+			sandbox: false,
+		}
+	`
+
+	testcases := []struct {
+		name                string
+		sandboxedGenrules   bool
+		unsandboxedGenrules []string
+		expectedSandboxed   map[string]bool
+		err                 string
+	}{
+		{
+			name:              "opt in",
+			expectedSandboxed: map[string]bool{"gen": false, "gen_sandboxed": true, "gen_unsandboxed": false},
+		},
+		{
+			name:              "not allowed",
+			sandboxedGenrules: true,
+			err:               `module "gen_unsandboxed" must be listed in the UnsandboxedGenrules product variable`,
+		},
+		{
+			name:                "sandboxed by default",
+			sandboxedGenrules:   true,
+			unsandboxedGenrules: []string{"gen_unsandboxed"},
+			expectedSandboxed:   map[string]bool{"gen": true, "gen_sandboxed": true, "gen_unsandboxed": false},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			errorHandler := android.FixtureExpectsNoErrors
+			if test.err != "" {
+				errorHandler = android.FixtureExpectsAtLeastOneErrorMatchingPattern(regexp.QuoteMeta(test.err))
+			}
+			result := android.GroupFixturePreparers(
+				prepareForGenRuleTest,
+				android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+					variables.SandboxedGenrules = proptools.BoolPtr(test.sandboxedGenrules)
+					variables.UnsandboxedGenrules = test.unsandboxedGenrules
+				}),
+			).ExtendWithErrorHandler(errorHandler).RunTestWithBp(t, bp)
+			if test.err != "" {
+				return
+			}
+
+			for name, expected := range test.expectedSandboxed {
+				manifest := android.RuleBuilderSboxProtoForTests(t,
+					result.ModuleForTests(name, "").Output("genrule.sbox.textproto"))
+				android.AssertBoolEquals(t, name+" chdir", expected, manifest.Commands[0].GetChdir())
+			}
+		})
+	}
+}
+
+func TestGenruleRemoteExecution(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		prepareForGenRuleTest,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.UseRBE = proptools.BoolPtr(true)
+			variables.GenruleRemoteExecutionPaths = []string{"remote"}
+		}),
+		android.FixtureAddTextFile("remote/Android.bp", `
+			genrule {
+				name: "gen_in_remote_path",
+				srcs: ["in"],
+				out: ["out"],
+				cmd: "cat $(in) > $(out)",
+			}
+		`),
+		android.FixtureAddFile("remote/in", nil),
+	).RunTestWithBp(t, `
+		genrule {
+			name: "gen",
+			srcs: ["in1"],
+			out: ["out"],
+			cmd: "cat $(in) > $(out)",
+		}
+
+		genrule {
+			name: "gen_remote",
+			srcs: ["in1"],
+			out: ["out"],
+			cmd: "cat $(in) > $(out)",
+			remote_execution: true,
+		}
+	`)
+
+	for name, expected := range map[string]bool{"gen": false, "gen_remote": true, "gen_in_remote_path": true} {
+		command := result.ModuleForTests(name, "").Output("out").RuleParams.Command
+		android.AssertBoolEquals(t, name+" rewrapper", expected, strings.Contains(command, "--labels=name=genrule,type=tool"))
+	}
+}
+
+func TestGenSrcsSandbox(t *testing.T) {
+	result := prepareForGenRuleTest.RunTestWithBp(t, `
+		genrule {
+			name: "gen",
+			out: ["in.h"],
+			cmd: "touch $(out)",
+		}
+
+		gensrcs {
+			name: "gensrcs",
+			srcs: [":gen"],
+			output_extension: "out",
+			cmd: "cp $(in) $(out)",
+			sandbox: true,
+		}
+	`)
+
+	manifest := android.RuleBuilderSboxProtoForTests(t,
+		result.ModuleForTests("gensrcs", "").Output("genrule.sbox.textproto"))
+	android.AssertStringDoesContain(t, "command", manifest.Commands[0].GetCommand(),
+		"cp __SBOX_SANDBOX_DIR__/out/soong/.intermediates/gen/gen/in.h")
+}
+
 func TestGenruleOutputFiles(t *testing.T) {
 	bp := `
 				genrule {
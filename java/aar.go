@@ -97,6 +97,7 @@ type aapt struct {
 	aaptSrcJar              android.Path
 	exportPackage           android.Path
 	manifestPath            android.Path
+	sourceManifestPath      android.Path
 	transitiveManifestPaths android.Paths
 	proguardOptionsFile     android.Path
 	rroDirs                 []rroDir
@@ -300,6 +301,9 @@ func (a *aapt) buildActions(ctx android.ModuleContext, sdkContext android.SdkCon
 	// App manifest file
 	manifestFile := proptools.StringDefault(a.aaptProperties.Manifest, "AndroidManifest.xml")
 	manifestSrcPath := android.PathForModuleSrc(ctx, manifestFile)
+	if _, ok := manifestSrcPath.(android.SourcePath); ok {
+		a.sourceManifestPath = manifestSrcPath
+	}
 
 	manifestPath := ManifestFixer(ctx, manifestSrcPath, ManifestFixerParams{
 		SdkContext:                     sdkContext,
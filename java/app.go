@@ -42,6 +42,7 @@ func RegisterAppBuildComponents(ctx android.RegistrationContext) {
 	ctx.RegisterModuleType("android_app_certificate", AndroidAppCertificateFactory)
 	ctx.RegisterModuleType("override_android_app", OverrideAndroidAppModuleFactory)
 	ctx.RegisterModuleType("override_android_test", OverrideAndroidTestModuleFactory)
+	ctx.RegisterSingletonType("dexpreopt_app_profiles", dexpreoptAppProfilesSingletonFactory)
 }
 
 // AndroidManifest.xml merging
@@ -115,6 +116,10 @@ type appProperties struct {
 	// Prefer using other specific properties if build behaviour must be changed; avoid using this
 	// flag for anything but neverallow rules (unless the behaviour change is invisible to owners).
 	Updatable *bool
+
+	// The name of a java_baseline_profile module whose rules are compiled into the profile that
+	// guides the dexpreopt of this app. It can't be set with dex_preopt.profile.
+	Baseline_profile *string
 }
 
 // android_app properties that can be overridden by override_android_app
@@ -261,6 +266,10 @@ func (a *AndroidApp) DepsMutator(ctx android.BottomUpMutatorContext) {
 	}
 
 	a.usesLibrary.deps(ctx, sdkDep.hasFrameworkLibs())
+
+	if profile := String(a.appProperties.Baseline_profile); profile != "" {
+		ctx.AddDependency(ctx.Module(), baselineProfileTag, profile)
+	}
 }
 
 func (a *AndroidApp) OverridablePropertiesDepsMutator(ctx android.BottomUpMutatorContext) {
@@ -392,6 +401,16 @@ func (a *AndroidApp) OverriddenManifestPackageName() string {
 	return a.overriddenManifestPackageName
 }
 
+// manifestPackageNameOverride returns the package name that the app is renamed to, or "" if it
+// keeps the one in its manifest.
+func (a *AndroidApp) manifestPackageNameOverride(ctx android.BaseModuleContext) string {
+	// The product override variable has a priority over the package_name property.
+	if manifestPackageName, overridden := ctx.DeviceConfig().OverrideManifestPackageNameFor(ctx.ModuleName()); overridden {
+		return manifestPackageName
+	}
+	return proptools.String(a.overridableAppProperties.Package_name)
+}
+
 func (a *AndroidApp) renameResourcesPackage() bool {
 	return proptools.BoolDefault(a.overridableAppProperties.Rename_resources_package, true)
 }
@@ -415,23 +434,27 @@ func (a *AndroidApp) aaptBuildActions(ctx android.ModuleContext) {
 	}
 
 	if !Bool(a.aaptProperties.Aapt_include_all_resources) {
+		productAAPTConfig := ctx.Config().ProductAAPTConfig()
+		productAAPTPreferredConfig := ctx.Config().ProductAAPTPreferredConfig()
+
+		// The resource filtering policy of the app or of its partition replaces the product's.
+		if filter, ok := ctx.DeviceConfig().AAPTResourceFilter(ctx.ModuleName(), a.PartitionTag(ctx.DeviceConfig())); ok {
+			productAAPTConfig = filter.AAPTConfig(productAAPTConfig)
+			productAAPTPreferredConfig = filter.AAPTPreferredConfig(productAAPTPreferredConfig)
+		}
+
 		// Product AAPT config
-		for _, aaptConfig := range ctx.Config().ProductAAPTConfig() {
+		for _, aaptConfig := range productAAPTConfig {
 			aaptLinkFlags = append(aaptLinkFlags, "-c", aaptConfig)
 		}
 
 		// Product AAPT preferred config
-		if len(ctx.Config().ProductAAPTPreferredConfig()) > 0 {
-			aaptLinkFlags = append(aaptLinkFlags, "--preferred-density", ctx.Config().ProductAAPTPreferredConfig())
+		if len(productAAPTPreferredConfig) > 0 {
+			aaptLinkFlags = append(aaptLinkFlags, "--preferred-density", productAAPTPreferredConfig)
 		}
 	}
 
-	manifestPackageName, overridden := ctx.DeviceConfig().OverrideManifestPackageNameFor(ctx.ModuleName())
-	if overridden || a.overridableAppProperties.Package_name != nil {
-		// The product override variable has a priority over the package_name property.
-		if !overridden {
-			manifestPackageName = *a.overridableAppProperties.Package_name
-		}
+	if manifestPackageName := a.manifestPackageNameOverride(ctx); manifestPackageName != "" {
 		aaptLinkFlags = append(aaptLinkFlags, generateAaptRenamePackageFlags(manifestPackageName, a.renameResourcesPackage())...)
 		a.overriddenManifestPackageName = manifestPackageName
 	}
@@ -489,7 +512,15 @@ func (a *AndroidApp) dexBuildActions(ctx android.ModuleContext) android.Path {
 	a.dexpreopter.enforceUsesLibs = a.usesLibrary.enforceUsesLibraries()
 	a.dexpreopter.classLoaderContexts = a.classLoaderContexts
 	a.dexpreopter.manifestFile = a.mergedManifestFile
+	a.dexpreopter.sourceManifest = a.sourceManifestPath
+	a.dexpreopter.packageName = a.overriddenManifestPackageName
 	a.dexpreopter.preventInstall = a.appProperties.PreventInstall
+	if a.appProperties.Baseline_profile != nil {
+		if a.dexpreoptProperties.Dex_preopt.Profile != nil {
+			ctx.PropertyErrorf("baseline_profile", "cannot be set with dex_preopt.profile")
+		}
+		a.dexpreopter.baselineProfile = BaselineProfileRules(ctx, baselineProfileTag)
+	}
 
 	if ctx.ModuleName() != "framework-res" {
 		a.Module.compile(ctx, a.aaptSrcJar)
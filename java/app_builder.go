@@ -104,6 +104,17 @@ func SignAppPackage(ctx android.ModuleContext, signedApk android.WritablePath, u
 		flags = append(flags, "--rotation-min-sdk-version", rotationMinSdkVersion)
 	}
 
+	backend := ctx.Config().SigningBackend()
+	if backend.Deferred() {
+		if v4SignatureFile != nil {
+			ctx.ModuleErrorf("v4 signatures are not supported with Signing_backend %q", backend.Name())
+			return
+		}
+		android.BuildDeferredSigning(ctx, "apk", unsignedApk, signedApk, certificateArgs, flags, nil)
+		return
+	}
+	flags = append(flags, backend.SignapkFlags()...)
+
 	rule := Signapk
 	args := map[string]string{
 		"certificates": strings.Join(certificateArgs, " "),
@@ -145,8 +145,12 @@ func (a *AndroidAppImport) processVariants(ctx android.LoadHookContext) {
 	for i := len(config.ProductAAPTPrebuiltDPI()) - 1; i >= 0; i-- {
 		MergePropertiesFromVariant(ctx, &a.properties, dpiProps, config.ProductAAPTPrebuiltDPI()[i])
 	}
-	if config.ProductAAPTPreferredConfig() != "" {
-		MergePropertiesFromVariant(ctx, &a.properties, dpiProps, config.ProductAAPTPreferredConfig())
+	preferredConfig := config.ProductAAPTPreferredConfig()
+	if filter, ok := ctx.DeviceConfig().AAPTResourceFilter(ctx.ModuleName(), a.PartitionTag(ctx.DeviceConfig())); ok {
+		preferredConfig = filter.AAPTPreferredConfig(preferredConfig)
+	}
+	if preferredConfig != "" {
+		MergePropertiesFromVariant(ctx, &a.properties, dpiProps, preferredConfig)
 	}
 
 	archProps := reflect.ValueOf(a.archVariants).Elem().FieldByName("Arch")
@@ -1732,6 +1732,43 @@ func TestRequestV4SigningFlag(t *testing.T) {
 	}
 }
 
+func TestSigningBackend(t *testing.T) {
+	bp := `
+		android_app {
+			name: "foo",
+			srcs: ["a.java"],
+			sdk_version: "current",
+		}
+	`
+
+	t.Run("hsm", func(t *testing.T) {
+		result := android.GroupFixturePreparers(
+			PrepareForTestWithJavaDefaultModules,
+			android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+				variables.Signing_backend = proptools.StringPtr("hsm")
+				variables.Signing_hsm_provider_class = proptools.StringPtr("com.example.Pkcs11Provider")
+			}),
+		).RunTestWithBp(t, bp)
+
+		signapk := result.ModuleForTests("foo", "android_common").Output("foo.apk")
+		android.AssertStringEquals(t, "signing flags", "-providerClass com.example.Pkcs11Provider", signapk.Args["flags"])
+	})
+
+	t.Run("remote", func(t *testing.T) {
+		result := android.GroupFixturePreparers(
+			PrepareForTestWithJavaDefaultModules,
+			android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+				variables.Signing_backend = proptools.StringPtr("remote")
+			}),
+		).RunTestWithBp(t, bp)
+
+		foo := result.ModuleForTests("foo", "android_common")
+		signapk := foo.Output("foo.apk")
+		android.AssertStringEquals(t, "signing rule", "unsigned apk", signapk.Description)
+		android.AssertStringEquals(t, "unsigned input", "foo-unsigned.apk", signapk.Input.Base())
+	})
+}
+
 func TestPackageNameOverride(t *testing.T) {
 	testCases := []struct {
 		name                string
@@ -1848,6 +1885,61 @@ func TestInstrumentationTargetOverridden(t *testing.T) {
 	}
 }
 
+func TestAAPTResourceFilters(t *testing.T) {
+	bp := `
+		android_app {
+			name: "foo",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			product_specific: true,
+		}
+
+		android_app {
+			name: "bar",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			product_specific: true,
+		}
+
+		android_app {
+			name: "baz",
+			srcs: ["a.java"],
+			sdk_version: "current",
+		}
+		`
+
+	result := android.GroupFixturePreparers(
+		PrepareForTestWithJavaDefaultModules,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.AAPTResourceFilters = []android.AAPTResourceFilter{
+				{
+					Partitions:       []string{"product"},
+					Locales:          []string{"en_US", "fr"},
+					Densities:        []string{"mdpi"},
+					PreferredDensity: proptools.StringPtr("mdpi"),
+				},
+				{
+					Apps:    []string{"bar"},
+					Locales: []string{"de"},
+				},
+			}
+		}),
+	).RunTestWithBp(t, bp)
+
+	testCases := []struct {
+		app      string
+		expected string
+	}{
+		{"foo", "-c en_US -c fr -c mdpi --preferred-density mdpi"},
+		{"bar", "-c de -c normal -c large -c xlarge -c hdpi -c xhdpi -c xxhdpi --preferred-density xhdpi"},
+		{"baz", "-c normal -c large -c xlarge -c hdpi -c xhdpi -c xxhdpi --preferred-density xhdpi"},
+	}
+	for _, tc := range testCases {
+		aapt2Flags := result.ModuleForTests(tc.app, "android_common").Output("package-res.apk").Args["flags"]
+		android.AssertStringDoesContain(t, tc.app+" aapt2 link flags", aapt2Flags, tc.expected)
+	}
+}
+
 func TestOverrideAndroidApp(t *testing.T) {
 	result := PrepareForTestWithJavaDefaultModules.RunTestWithBp(
 		t, `
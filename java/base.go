@@ -912,6 +912,9 @@ func (j *Module) collectBuilderFlags(ctx android.ModuleContext, deps deps) javaB
 
 		flags.errorProneExtraJavacFlags = "${config.ErrorProneHeapFlags} ${config.ErrorProneFlags} " +
 			"'" + strings.Join(errorProneFlags, " ") + "'"
+		if config.UseOpenJdk21(ctx.Config()) {
+			flags.errorProneExtraJavacFlags += " ${config.ErrorProneJdk21Flags}"
+		}
 		flags.errorProneProcessorPath = classpath(android.PathsForSource(ctx, config.ErrorProneClasspath))
 	}
 
@@ -968,6 +971,9 @@ func (j *Module) collectJavacFlags(
 		javacFlags = append(javacFlags, "-g:source,lines")
 	}
 	javacFlags = append(javacFlags, "-Xlint:-dep-ann")
+	if config.UseOpenJdk21(ctx.Config()) {
+		javacFlags = append(javacFlags, "${config.Jdk21JavacFlags}")
+	}
 
 	if flags.javaVersion.usesJavaModules() {
 		javacFlags = append(javacFlags, j.properties.Openjdk9.Javacflags...)
@@ -0,0 +1,118 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"github.com/google/blueprint"
+
+	"android/soong/android"
+)
+
+// A java_baseline_profile module holds the human-readable rules of a baseline profile, the classes
+// and methods to compile ahead of time, which the android_app and apex modules setting it in their
+// baseline_profile property compile into binary ART profiles against their dex files with profman.
+
+func init() {
+	registerBaselineProfileBuildComponents(android.InitRegistrationContext)
+}
+
+func registerBaselineProfileBuildComponents(ctx android.RegistrationContext) {
+	ctx.RegisterModuleType("java_baseline_profile", BaselineProfileFactory)
+}
+
+type baselineProfileProperties struct {
+	// The files of human-readable profile rules, one rule per line: a class descriptor like
+	// "Landroid/app/Activity;", or a method prefixed with its flags like
+	// "HSPLandroid/app/Activity;->onCreate(Landroid/os/Bundle;)V". Empty lines and lines starting
+	// with # are ignored.
+	Srcs []string `android:"path"`
+}
+
+type BaselineProfile struct {
+	android.ModuleBase
+
+	properties baselineProfileProperties
+}
+
+// BaselineProfileInfo is provided by the java_baseline_profile modules.
+type BaselineProfileInfo struct {
+	// The rules of the profile merged into a single file, in the text format that profman compiles
+	// with --create-profile-from.
+	Rules android.Path
+}
+
+var BaselineProfileInfoProvider = blueprint.NewProvider(BaselineProfileInfo{})
+
+// java_baseline_profile merges files of human-readable profile rules into the baseline profile of
+// the android_app and apex modules that set it in their baseline_profile property.
+func BaselineProfileFactory() android.Module {
+	module := &BaselineProfile{}
+	module.AddProperties(&module.properties)
+	android.InitAndroidModule(module)
+	return module
+}
+
+func (p *BaselineProfile) GenerateAndroidBuildActions(ctx android.ModuleContext) {
+	srcs := android.PathsForModuleSrc(ctx, p.properties.Srcs)
+	if len(srcs) == 0 {
+		ctx.PropertyErrorf("srcs", "must not be empty")
+		return
+	}
+
+	rules := android.PathForModuleOut(ctx, ctx.ModuleName()+".txt")
+	rule := android.NewRuleBuilder(pctx, ctx)
+	rule.Command().
+		Text("sed -e '/^[[:space:]]*#/d' -e '/^[[:space:]]*$/d'").
+		Inputs(srcs).
+		Text("| LC_ALL=C sort -u >").
+		Output(rules)
+	rule.Build("baseline_profile", "baseline profile "+ctx.ModuleName())
+
+	ctx.SetProvider(BaselineProfileInfoProvider, BaselineProfileInfo{Rules: rules})
+}
+
+// BaselineProfileRules returns the rules of the java_baseline_profile module that the module
+// depends on with the given tag, or nil if it doesn't have one.
+func BaselineProfileRules(ctx android.ModuleContext, tag blueprint.DependencyTag) android.Path {
+	var rules android.Path
+	ctx.VisitDirectDepsWithTag(tag, func(m android.Module) {
+		if !ctx.OtherModuleHasProvider(m, BaselineProfileInfoProvider) {
+			ctx.ModuleErrorf("baseline_profile %q must be a java_baseline_profile module",
+				ctx.OtherModuleName(m))
+			return
+		}
+		rules = ctx.OtherModuleProvider(m, BaselineProfileInfoProvider).(BaselineProfileInfo).Rules
+	})
+	return rules
+}
+
+// BuildBaselineProfile compiles the rules of a baseline profile into a binary ART profile of the
+// dex file installed at dexLocation on the device, and returns it.
+func BuildBaselineProfile(ctx android.ModuleContext, rules, dexJar android.Path, dexLocation string,
+	name string) android.Path {
+
+	profile := android.PathForModuleOut(ctx, "baseline_profile", name+".prof")
+	rule := android.NewRuleBuilder(pctx, ctx)
+	rule.Command().
+		Text(`ANDROID_LOG_TAGS="*:e"`).
+		BuiltTool("profman").
+		FlagWithInput("--create-profile-from=", rules).
+		Flag("--output-profile-type=app").
+		FlagWithInput("--apk=", dexJar).
+		Flag("--dex-location="+dexLocation).
+		FlagWithOutput("--reference-profile-file=", profile)
+	rule.Build("baseline_profile_"+name, "baseline profile "+name)
+	return profile
+}
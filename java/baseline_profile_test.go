@@ -0,0 +1,74 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestBaselineProfile(t *testing.T) {
+	bp := `
+		java_baseline_profile {
+			name: "foo-profile",
+			srcs: ["rules/a.txt", "rules/b.txt"],
+		}
+
+		android_app {
+			name: "foo",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			baseline_profile: "foo-profile",
+		}
+	`
+
+	result := android.GroupFixturePreparers(
+		PrepareForTestWithDexpreopt,
+		android.FixtureMergeMockFs(android.MockFS{
+			"rules/a.txt": nil,
+			"rules/b.txt": nil,
+		}),
+	).RunTestWithBp(t, bp)
+
+	profile := result.ModuleForTests("foo-profile", "").Rule("baseline_profile")
+	android.AssertPathsRelativeToTopEquals(t, "profile inputs",
+		[]string{"rules/a.txt", "rules/b.txt"}, profile.Inputs)
+
+	foo := result.ModuleForTests("foo", "android_common")
+	android.AssertStringDoesContain(t, "foo dexpreopt", foo.Rule("dexpreopt").RuleParams.Command,
+		"--create-profile-from=out/soong/.intermediates/foo-profile/foo-profile.txt")
+
+	android.GroupFixturePreparers(
+		PrepareForTestWithDexpreopt,
+		android.FixtureMergeMockFs(android.MockFS{
+			"rules/a.txt": nil,
+			"rules/b.txt": nil,
+			"foo.txt":     nil,
+		}),
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`baseline_profile: cannot be set with dex_preopt.profile`,
+	)).RunTestWithBp(t, bp+`
+		android_app {
+			name: "bar",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			baseline_profile: "foo-profile",
+			dex_preopt: {
+				profile: "foo.txt",
+			},
+		}
+	`)
+}
@@ -104,6 +104,14 @@ func init() {
 		"-JDcom.android.tools.r8.emitRecordAnnotationsExInDex",
 	}, dexerJavaVmFlagsList...))
 
+	// The OpenJDK 21 javac warns about constructors leaking this to overridable methods and about
+	// the obsolete -source 8 and -target 8 of the device modules, which fails the modules built with
+	// -Werror.
+	exportedVars.ExportStringListStaticVariable("Jdk21JavacFlags", []string{
+		"-Xlint:-this-escape",
+		"-Xlint:-options",
+	})
+
 	exportedVars.ExportStringListStaticVariable("CommonJdkFlags", []string{
 		`-Xmaxerrs 9999999`,
 		`-encoding UTF-8`,
@@ -133,6 +141,9 @@ func init() {
 		if override := ctx.Config().Getenv("OVERRIDE_JLINK_VERSION_NUMBER"); override != "" {
 			return override
 		}
+		if UseOpenJdk21(ctx.Config()) {
+			return "21"
+		}
 		return "17"
 	})
 
@@ -185,13 +196,14 @@ func init() {
 	pctx.HostBinToolVariable("DexpreoptGen", "dexpreopt_gen")
 
 	pctx.StaticVariableWithEnvOverride("REJavaPool", "RBE_JAVA_POOL", "java16")
-	pctx.StaticVariableWithEnvOverride("REJavacExecStrategy", "RBE_JAVAC_EXEC_STRATEGY", remoteexec.RemoteLocalFallbackExecStrategy)
-	pctx.StaticVariableWithEnvOverride("RED8ExecStrategy", "RBE_D8_EXEC_STRATEGY", remoteexec.RemoteLocalFallbackExecStrategy)
-	pctx.StaticVariableWithEnvOverride("RER8ExecStrategy", "RBE_R8_EXEC_STRATEGY", remoteexec.RemoteLocalFallbackExecStrategy)
-	pctx.StaticVariableWithEnvOverride("RETurbineExecStrategy", "RBE_TURBINE_EXEC_STRATEGY", remoteexec.LocalExecStrategy)
-	pctx.StaticVariableWithEnvOverride("RESignApkExecStrategy", "RBE_SIGNAPK_EXEC_STRATEGY", remoteexec.LocalExecStrategy)
-	pctx.StaticVariableWithEnvOverride("REJarExecStrategy", "RBE_JAR_EXEC_STRATEGY", remoteexec.LocalExecStrategy)
-	pctx.StaticVariableWithEnvOverride("REZipExecStrategy", "RBE_ZIP_EXEC_STRATEGY", remoteexec.LocalExecStrategy)
+	pctx.StaticVariableWithEnvOverride("REJavacExecStrategy", "RBE_JAVAC_EXEC_STRATEGY", remoteexec.DefaultExecStrategy("RBE_JAVAC_EXEC_STRATEGY"))
+	pctx.StaticVariableWithEnvOverride("RED8ExecStrategy", "RBE_D8_EXEC_STRATEGY", remoteexec.DefaultExecStrategy("RBE_D8_EXEC_STRATEGY"))
+	pctx.StaticVariableWithEnvOverride("RER8ExecStrategy", "RBE_R8_EXEC_STRATEGY", remoteexec.DefaultExecStrategy("RBE_R8_EXEC_STRATEGY"))
+	pctx.StaticVariableWithEnvOverride("RETurbineExecStrategy", "RBE_TURBINE_EXEC_STRATEGY", remoteexec.DefaultExecStrategy("RBE_TURBINE_EXEC_STRATEGY"))
+	pctx.StaticVariableWithEnvOverride("REKotlincExecStrategy", "RBE_KOTLINC_EXEC_STRATEGY", remoteexec.DefaultExecStrategy("RBE_KOTLINC_EXEC_STRATEGY"))
+	pctx.StaticVariableWithEnvOverride("RESignApkExecStrategy", "RBE_SIGNAPK_EXEC_STRATEGY", remoteexec.DefaultExecStrategy("RBE_SIGNAPK_EXEC_STRATEGY"))
+	pctx.StaticVariableWithEnvOverride("REJarExecStrategy", "RBE_JAR_EXEC_STRATEGY", remoteexec.DefaultExecStrategy("RBE_JAR_EXEC_STRATEGY"))
+	pctx.StaticVariableWithEnvOverride("REZipExecStrategy", "RBE_ZIP_EXEC_STRATEGY", remoteexec.DefaultExecStrategy("RBE_ZIP_EXEC_STRATEGY"))
 
 	pctx.HostJavaToolVariable("JacocoCLIJar", "jacoco-cli.jar")
 
@@ -299,3 +311,9 @@ func javaHome(ctx android.PathContext) android.SourcePath {
 		return android.PathForSource(ctx, ctx.Config().Getenv("ANDROID_JAVA_HOME"))
 	})
 }
+
+// UseOpenJdk21 returns true if soong_ui selected the OpenJDK 21 toolchain instead of the default
+// OpenJDK 17 one, with EXPERIMENTAL_USE_OPENJDK21_TOOLCHAIN=true.
+func UseOpenJdk21(config android.Config) bool {
+	return config.IsEnvTrue("EXPERIMENTAL_USE_OPENJDK21_TOOLCHAIN")
+}
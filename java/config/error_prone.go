@@ -42,6 +42,12 @@ func init() {
 	exportedVars.ExportVariableFuncVariable("ErrorProneChecksDefaultDisabled", errorProneVar(&ErrorProneChecksDefaultDisabled, " "))
 	exportedVars.ExportVariableFuncVariable("ErrorProneChecksOff", errorProneVar(&ErrorProneChecksOff, " "))
 	exportedVars.ExportVariableFuncVariable("ErrorProneFlags", errorProneVar(&ErrorProneFlags, " "))
+	// Error Prone needs the simple compile policy and to stop after flow analysis on errors with the
+	// OpenJDK 21 javac.
+	exportedVars.ExportStringListStaticVariable("ErrorProneJdk21Flags", []string{
+		"-XDcompilePolicy=simple",
+		"--should-stop=ifError=FLOW",
+	})
 	exportedVars.ExportStringListStaticVariable("ErrorProneChecks", []string{
 		"${ErrorProneChecksOff}",
 		"${ErrorProneChecksError}",
@@ -99,21 +99,25 @@ func (d *dexer) effectiveOptimizeEnabled() bool {
 	return BoolDefault(d.dexProperties.Optimize.Enabled, d.dexProperties.Optimize.EnabledByDefault)
 }
 
-var d8, d8RE = pctx.MultiCommandRemoteStaticRules("d8",
-	blueprint.RuleParams{
-		Command: `rm -rf "$outDir" && mkdir -p "$outDir" && ` +
-			`mkdir -p $$(dirname $tmpJar) && ` +
-			`${config.Zip2ZipCmd} -i $in -o $tmpJar -x '**/*.dex' && ` +
-			`$d8Template${config.D8Cmd} ${config.D8Flags} --output $outDir $d8Flags $tmpJar && ` +
-			`$zipTemplate${config.SoongZipCmd} $zipFlags -o $outDir/classes.dex.jar -C $outDir -f "$outDir/classes*.dex" && ` +
-			`${config.MergeZipsCmd} -D -stripFile "**/*.class" $mergeZipsFlags $out $outDir/classes.dex.jar $in`,
-		CommandDeps: []string{
-			"${config.D8Cmd}",
-			"${config.Zip2ZipCmd}",
-			"${config.SoongZipCmd}",
-			"${config.MergeZipsCmd}",
-		},
-	}, map[string]*remoteexec.REParams{
+var d8Params = blueprint.RuleParams{
+	Command: `rm -rf "$outDir" && mkdir -p "$outDir" && ` +
+		`mkdir -p $$(dirname $tmpJar) && ` +
+		`${config.Zip2ZipCmd} -i $in -o $tmpJar -x '**/*.dex' && ` +
+		`$d8Template${config.D8Cmd} ${config.D8Flags} --output $outDir $d8Flags $tmpJar && ` +
+		`$zipTemplate${config.SoongZipCmd} $zipFlags -o $outDir/classes.dex.jar -C $outDir -f "$outDir/classes*.dex" && ` +
+		`${config.MergeZipsCmd} -D -stripFile "**/*.class" $mergeZipsFlags $out $outDir/classes.dex.jar $in`,
+	CommandDeps: []string{
+		"${config.D8Cmd}",
+		"${config.Zip2ZipCmd}",
+		"${config.SoongZipCmd}",
+		"${config.MergeZipsCmd}",
+	},
+}
+
+var d8Args = []string{"outDir", "d8Flags", "zipFlags", "tmpJar", "mergeZipsFlags"}
+
+var d8, d8RE = pctx.MultiCommandRemoteStaticRules("d8", d8Params,
+	map[string]*remoteexec.REParams{
 		"$d8Template": &remoteexec.REParams{
 			Labels:          map[string]string{"type": "compile", "compiler": "d8"},
 			Inputs:          []string{"${config.D8Jar}"},
@@ -128,7 +132,14 @@ var d8, d8RE = pctx.MultiCommandRemoteStaticRules("d8",
 			ExecStrategy: "${config.RED8ExecStrategy}",
 			Platform:     map[string]string{remoteexec.PoolKey: "${config.REJavaPool}"},
 		},
-	}, []string{"outDir", "d8Flags", "zipFlags", "tmpJar", "mergeZipsFlags"}, nil)
+	}, d8Args, nil)
+
+// d8 on the largest jars, like framework.jar, uses lots of memory. The product can restrict the
+// number of them that run in parallel by putting their modules in the d8 action pool.
+var (
+	d8Pool   = pctx.ActionPool("d8")
+	d8Pooled = d8Pool.StaticRule("d8Pooled", d8Params, []string{"$d8Template", "$zipTemplate"}, d8Args...)
+)
 
 var r8, r8RE = pctx.MultiCommandRemoteStaticRules("r8",
 	blueprint.RuleParams{
@@ -327,7 +338,7 @@ func (d *dexer) r8Flags(ctx android.ModuleContext, flags javaBuilderFlags) (r8Fl
 	// dictionary of the app and move the app from libraryjars to injars.
 
 	// Don't strip out debug information for eng builds.
-	if ctx.Config().Eng() {
+	if ctx.Config().BuildTypeSettings().R8KeepDebugInfo {
 		r8Flags = append(r8Flags, "--debug")
 	}
 
@@ -413,6 +424,8 @@ func (d *dexer) compileDex(ctx android.ModuleContext, dexParams *compileDexParam
 		rule := d8
 		if ctx.Config().UseRBE() && ctx.Config().IsEnvTrue("RBE_D8") {
 			rule = d8RE
+		} else if d8Pool.Contains(ctx) {
+			rule = d8Pooled
 		}
 		ctx.Build(pctx, android.BuildParams{
 			Rule:        rule,
@@ -327,6 +327,35 @@ func TestD8(t *testing.T) {
 		fooD8.Args["d8Flags"], staticLibHeader.String())
 }
 
+func TestD8ActionPool(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		PrepareForTestWithJavaDefaultModules,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.ActionPools = []android.ActionPoolConfig{
+				{Name: "d8", Depth: 1, Modules: []string{"foo"}},
+			}
+		}),
+	).RunTestWithBp(t, `
+		java_library {
+			name: "foo",
+			srcs: ["foo.java"],
+			installable: true,
+		}
+
+		java_library {
+			name: "bar",
+			srcs: ["bar.java"],
+			installable: true,
+		}
+	`)
+
+	fooD8 := result.ModuleForTests("foo", "android_common").Rule("d8")
+	barD8 := result.ModuleForTests("bar", "android_common").Rule("d8")
+
+	android.AssertStringEquals(t, "foo d8 rule", "android/soong/java.d8Pooled", fooD8.Rule.String())
+	android.AssertStringEquals(t, "bar d8 rule", "android/soong/java.d8", barD8.Rule.String())
+}
+
 func TestProguardFlagsInheritance(t *testing.T) {
 	result := PrepareForTestWithJavaDefaultModules.RunTestWithBp(t, `
 		android_app {
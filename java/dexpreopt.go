@@ -103,6 +103,7 @@ type dexpreopter struct {
 	preventInstall      bool
 
 	manifestFile        android.Path
+	packageName         string
 	statusFile          android.WritablePath
 	enforceUsesLibs     bool
 	classLoaderContexts dexpreopt.ClassLoaderContextMap
@@ -126,6 +127,21 @@ type dexpreopter struct {
 	// The path to the profile that dexpreopter accepts. It must be in the binary format. If this is
 	// set, it overrides the profile settings in `dexpreoptProperties`.
 	inputProfilePathOnHost android.Path
+
+	// The rules of the baseline profile of the module, from its baseline_profile property, in the
+	// text format. It is used when dex_preopt.profile isn't set.
+	baselineProfile android.Path
+
+	// The AndroidManifest.xml of the app in the source tree, which the package name of the app is
+	// read from to find its cloud profile. It is nil if the manifest is generated.
+	sourceManifest android.Path
+
+	// The package name of the app if it is compiled with its profile in the
+	// DexpreoptCloudProfilesDir directory of the product.
+	cloudProfiled string
+
+	// True if dexpreopt rules were generated for the module.
+	dexpreopted bool
 }
 
 type DexpreoptProperties struct {
@@ -142,7 +158,8 @@ type DexpreoptProperties struct {
 		Profile_guided *bool
 
 		// If set, provides the path to profile relative to the Android.bp file.  If not set,
-		// defaults to searching for a file that matches the name of this module in the default
+		// defaults to the profile of this module in the DexpreoptAppProfiles product variable,
+		// then to searching for a file that matches the name of this module in the default
 		// profile location set by PRODUCT_DEX_PREOPT_PROFILE_DIR, or empty if not found.
 		Profile *string `android:"path"`
 	}
@@ -180,6 +197,18 @@ func moduleName(ctx android.BaseModuleContext) string {
 	return android.RemoveOptionalPrebuiltPrefix(ctx.ModuleName())
 }
 
+// dexpreoptPackageName returns the name that DexpreoptDisabledPackages matches the module by: the
+// package name of the apps that the build renames, and the module name of the other modules, as the
+// package name of an app is only known once its manifest is merged.
+func dexpreoptPackageName(ctx android.BaseModuleContext) string {
+	if app, ok := ctx.Module().(*AndroidApp); ok {
+		if packageName := app.manifestPackageNameOverride(ctx); packageName != "" {
+			return packageName
+		}
+	}
+	return moduleName(ctx)
+}
+
 // Returns whether dexpreopt is applicable to the module.
 // When it returns true, neither profile nor dexpreopt artifacts will be generated.
 func (d *dexpreopter) dexpreoptDisabled(ctx android.BaseModuleContext) bool {
@@ -195,6 +224,10 @@ func (d *dexpreopter) dexpreoptDisabled(ctx android.BaseModuleContext) bool {
 		return true
 	}
 
+	if ctx.DeviceConfig().DexpreoptDisabledForPackage(dexpreoptPackageName(ctx)) {
+		return true
+	}
+
 	// If the module is from a prebuilt APEX, it shouldn't be installable, but it can still be
 	// dexpreopted.
 	if !ctx.Module().(DexpreopterInterface).IsInstallable() && !forPrebuiltApex(ctx) {
@@ -337,10 +370,28 @@ func (d *dexpreopter) dexpreopt(ctx android.ModuleContext, dexJarFile android.Wr
 			profileBootListing = android.ExistentPathForSource(ctx,
 				ctx.ModuleDir(), String(d.dexpreoptProperties.Dex_preopt.Profile)+"-boot")
 			profileIsTextListing = true
+		} else if d.baselineProfile != nil {
+			profileClassListing = android.OptionalPathForPath(d.baselineProfile)
+			profileIsTextListing = true
+		} else if profile := ctx.DeviceConfig().DexpreoptAppProfile(moduleName(ctx)); profile != "" {
+			// The product supplies a binary profile, e.g. a cloud profile in the device tree.
+			profileClassListing = android.ExistentPathForSource(ctx, profile)
+			if !profileClassListing.Valid() {
+				ctx.ModuleErrorf("profile %q in DexpreoptAppProfiles does not exist", profile)
+			}
 		} else if global.ProfileDir != "" {
 			profileClassListing = android.ExistentPathForSource(ctx,
 				global.ProfileDir, moduleName(ctx)+".prof")
 		}
+		if dir := ctx.DeviceConfig().DexpreoptCloudProfilesDir(); dir != "" && !profileClassListing.Valid() && d.isApp {
+			if packageName := d.cloudProfilePackageName(ctx); packageName != "" {
+				profileClassListing = android.ExistentPathForSource(ctx, dir, packageName+".prof")
+				if profileClassListing.Valid() {
+					d.cloudProfiled = packageName
+					ctx.Config().LogDexpreoptCloudProfile(ctx)
+				}
+			}
+		}
 	}
 
 	d.dexpreoptProperties.Dex_preopt_result.Profile_guided = profileClassListing.Valid()
@@ -387,6 +438,7 @@ func (d *dexpreopter) dexpreopt(ctx android.ModuleContext, dexJarFile android.Wr
 	if d.dexpreoptDisabled(ctx) {
 		return
 	}
+	d.dexpreopted = true
 
 	globalSoong := dexpreopt.GetGlobalSoongConfig(ctx)
 
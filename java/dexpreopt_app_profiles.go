@@ -0,0 +1,112 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package java
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"android/soong/android"
+)
+
+// The dexpreopt_app_profiles singleton reports the dexpreopted apps that are compiled without a
+// profile, so that products supplying profiles with DexpreoptAppProfiles can spot the critical
+// apps they are missing.
+//
+// The apps may also be compiled with the cloud profiles of the product, the profiles collected
+// from the devices in the field in the DexpreoptCloudProfilesDir directory, named after the
+// package of the app they guide. The package of an app is the one it is renamed to by the
+// package_name property or PRODUCT_MANIFEST_PACKAGE_NAME_OVERRIDES, or else the package attribute
+// of its AndroidManifest.xml. The apps without a cloud profile are compiled as usual and rely on
+// the JIT on the device. The singleton reports the packages compiled with their cloud profile in
+// dexpreopt/cloud_profiles.txt, and their number is recorded in the soong_build metrics.
+
+// cloudProfilePackageName returns the package name of the app that its cloud profile is named
+// after, or "" if it is only known once its generated manifest is built.
+func (d *dexpreopter) cloudProfilePackageName(ctx android.ModuleContext) string {
+	if d.packageName != "" {
+		return d.packageName
+	}
+	if d.sourceManifest == nil {
+		return ""
+	}
+	data, err := ctx.Config().ReadSourceFile(ctx, d.sourceManifest.String())
+	if err != nil {
+		// Missing manifests are reported by PathForModuleSrc.
+		return ""
+	}
+	packageName, err := manifestPackageName(data)
+	if err != nil {
+		ctx.ModuleErrorf("cannot read the package name of %s: %s", d.sourceManifest, err)
+	}
+	return packageName
+}
+
+// manifestPackageName returns the package attribute of the manifest element of an
+// AndroidManifest.xml file.
+func manifestPackageName(data []byte) (string, error) {
+	var manifest struct {
+		XMLName xml.Name `xml:"manifest"`
+		Package string   `xml:"package,attr"`
+	}
+	if err := xml.Unmarshal(data, &manifest); err != nil {
+		return "", err
+	}
+	return manifest.Package, nil
+}
+
+func dexpreoptAppProfilesSingletonFactory() android.Singleton {
+	return &dexpreoptAppProfilesSingleton{}
+}
+
+type dexpreoptAppProfilesSingleton struct {
+	report             android.WritablePath
+	cloudProfileReport android.WritablePath
+}
+
+func (s *dexpreoptAppProfilesSingleton) GenerateBuildActions(ctx android.SingletonContext) {
+	var apps []string
+	var cloudProfiled []string
+	ctx.VisitAllModules(func(module android.Module) {
+		app, ok := module.(*AndroidApp)
+		if !ok || !app.Enabled() || !app.dexpreopter.dexpreopted {
+			return
+		}
+		if !app.dexpreopter.dexpreoptProperties.Dex_preopt_result.Profile_guided {
+			apps = append(apps, ctx.ModuleName(app))
+		}
+		if app.dexpreopter.cloudProfiled != "" {
+			cloudProfiled = append(cloudProfiled, app.dexpreopter.cloudProfiled)
+		}
+	})
+
+	s.report = android.PathForOutput(ctx, "dexpreopt", "apps_without_profiles.txt")
+	android.WriteFileRule(ctx, s.report, strings.Join(android.SortedUniqueStrings(apps), "\n"))
+
+	if ctx.DeviceConfig().DexpreoptCloudProfilesDir() == "" {
+		return
+	}
+
+	s.cloudProfileReport = android.PathForOutput(ctx, "dexpreopt", "cloud_profiles.txt")
+	android.WriteFileRule(ctx, s.cloudProfileReport,
+		strings.Join(android.SortedUniqueStrings(cloudProfiled), "\n"))
+}
+
+func (s *dexpreoptAppProfilesSingleton) MakeVars(ctx android.MakeVarsContext) {
+	ctx.DistForGoal("droidcore", s.report)
+	if s.cloudProfileReport != nil {
+		ctx.DistForGoal("droidcore", s.cloudProfileReport)
+	}
+}
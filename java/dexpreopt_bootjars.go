@@ -455,7 +455,7 @@ func (image *bootImageVariant) imageLocations() (imageLocationsOnHost []string,
 }
 
 func (image *bootImageConfig) isProfileGuided() bool {
-	return image.compilerFilter == "speed-profile"
+	return strings.HasSuffix(image.compilerFilter, "-profile")
 }
 
 func dexpreoptBootJarsFactory() android.SingletonModule {
@@ -510,6 +510,12 @@ func (d *dexpreoptBootJars) GenerateSingletonBuildActions(ctx android.SingletonC
 	writeGlobalConfigForMake(ctx, d.dexpreoptConfigForMake)
 
 	global := dexpreopt.GetGlobalConfig(ctx)
+	if !global.DisablePreopt {
+		for _, err := range dexpreopt.CheckArtApexCompatibility(global) {
+			ctx.Errorf("invalid dexpreopt config: %s", err.Error())
+		}
+	}
+
 	if !shouldBuildBootImages(ctx.Config(), global) {
 		return
 	}
@@ -681,6 +687,15 @@ func buildBootImageVariant(ctx android.ModuleContext, image *bootImageVariant, p
 	oatLocation := dexpreopt.PathToLocation(outputPath, arch)
 	imagePath := outputPath.ReplaceExtension(ctx, "art")
 
+	imageFormat := "lz4hc"
+	if format := ctx.Config().BootImageFormat(arch.String()); format != "" {
+		if !android.InList(format, []string{"uncompressed", "lz4", "lz4hc"}) {
+			ctx.ModuleErrorf("boot image format %q of %s in BootImageFormats must be one of "+
+				"uncompressed, lz4 and lz4hc", format, arch)
+		}
+		imageFormat = format
+	}
+
 	rule := android.NewRuleBuilder(pctx, ctx)
 
 	rule.Command().Text("mkdir").Flag("-p").Flag(symbolsDir.String())
@@ -787,7 +802,7 @@ func buildBootImageVariant(ctx android.ModuleContext, image *bootImageVariant, p
 		FlagForEachArg("--dex-location=", image.dexLocations).
 		Flag("--generate-debug-info").
 		Flag("--generate-build-id").
-		Flag("--image-format=lz4hc").
+		FlagWithArg("--image-format=", imageFormat).
 		FlagWithArg("--oat-symbols=", symbolsFile.String()).
 		Flag("--strip").
 		FlagWithArg("--oat-file=", outputPath.String()).
@@ -901,6 +916,13 @@ func bootImageProfileRule(ctx android.ModuleContext, image *bootImageConfig) and
 	var profiles android.Paths
 	if len(global.BootImageProfiles) > 0 {
 		profiles = append(profiles, global.BootImageProfiles...)
+	} else if productProfile := ctx.Config().BootImageProfile(); productProfile != "" {
+		path := android.ExistentPathForSource(ctx, productProfile)
+		if !path.Valid() {
+			ctx.ModuleErrorf("boot image profile %q in BootImageProfile does not exist", productProfile)
+			return nil
+		}
+		profiles = append(profiles, path.Path())
 	} else if path := android.ExistentPathForSource(ctx, defaultProfile); path.Valid() {
 		profiles = append(profiles, path.Path())
 	} else {
@@ -83,6 +83,13 @@ func genBootImageConfigRaw(ctx android.PathContext) map[string]*bootImageConfig
 			profileImports:       []*bootImageConfig{&artCfg},
 		}
 
+		// The product may trade the speed of the boot images for their size, e.g. on low-RAM
+		// devices.
+		if filter := ctx.Config().BootImageCompilerFilter(); filter != "" {
+			artCfg.compilerFilter = filter
+			frameworkCfg.compilerFilter = filter
+		}
+
 		mainlineCfg := bootImageConfig{
 			extends:        &frameworkCfg,
 			name:           mainlineBootImageName,
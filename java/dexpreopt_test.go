@@ -20,6 +20,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/google/blueprint/proptools"
+
 	"android/soong/android"
 	"android/soong/cc"
 	"android/soong/dexpreopt"
@@ -463,3 +465,181 @@ func TestGenerateProfileEvenIfDexpreoptIsDisabled(t *testing.T) {
 
 	android.AssertArrayString(t, "outputs", expected, dexpreopt.AllOutputs())
 }
+
+func TestDexpreoptAppProfiles(t *testing.T) {
+	bp := `
+		android_app {
+			name: "foo",
+			srcs: ["a.java"],
+			sdk_version: "current",
+		}
+
+		android_app {
+			name: "bar",
+			srcs: ["a.java"],
+			sdk_version: "current",
+		}
+
+		android_app {
+			name: "baz",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			dex_preopt: {
+				profile: "baz.txt",
+			},
+		}
+	`
+
+	result := android.GroupFixturePreparers(
+		PrepareForTestWithDexpreopt,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.DexpreoptAppProfiles = []string{"foo:device/acme/profiles/foo.prof"}
+		}),
+		android.FixtureMergeMockFs(android.MockFS{
+			"device/acme/profiles/foo.prof": nil,
+			"baz.txt":                       nil,
+		}),
+	).RunTestWithBp(t, bp)
+
+	foo := result.ModuleForTests("foo", "android_common")
+	android.AssertStringDoesContain(t, "foo dexpreopt", foo.Rule("dexpreopt").RuleParams.Command,
+		"device/acme/profiles/foo.prof")
+
+	report := result.SingletonForTests("dexpreopt_app_profiles").Output("dexpreopt/apps_without_profiles.txt")
+	android.AssertStringEquals(t, "apps without profiles", "bar\n",
+		android.ContentFromFileRuleForTests(t, report))
+
+	android.GroupFixturePreparers(
+		PrepareForTestWithDexpreopt,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.DexpreoptAppProfiles = []string{"foo:device/acme/profiles/foo.prof"}
+		}),
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`profile "device/acme/profiles/foo.prof" in DexpreoptAppProfiles does not exist`,
+	)).RunTestWithBp(t, bp)
+}
+
+func TestDexpreoptDisabledPackages(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		PrepareForTestWithDexpreopt,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.DexpreoptDisabledPackages = []string{"com.acme.browser*", "bar"}
+		}),
+	).RunTestWithBp(t, `
+		android_app {
+			name: "foo",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			package_name: "com.acme.browser.beta",
+		}
+
+		android_app {
+			name: "bar",
+			srcs: ["a.java"],
+			sdk_version: "current",
+		}
+
+		android_app {
+			name: "baz",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			package_name: "com.acme.gallery",
+		}
+	`)
+
+	for name, dexpreopted := range map[string]bool{"foo": false, "bar": false, "baz": true} {
+		rule := result.ModuleForTests(name, "android_common").MaybeRule("dexpreopt")
+		android.AssertBoolEquals(t, name+" dexpreopted", dexpreopted, rule.Rule != nil)
+	}
+}
+
+func TestDexpreoptCloudProfiles(t *testing.T) {
+	bp := `
+		android_app {
+			name: "foo",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			manifest: "foo/AndroidManifest.xml",
+		}
+
+		android_app {
+			name: "bar",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			package_name: "com.acme.bar",
+		}
+
+		android_app {
+			name: "baz",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			dex_preopt: {
+				profile: "baz.txt",
+			},
+		}
+
+		android_app {
+			name: "qux",
+			srcs: ["a.java"],
+			sdk_version: "current",
+			manifest: "qux/AndroidManifest.xml",
+		}
+	`
+
+	result := android.GroupFixturePreparers(
+		PrepareForTestWithDexpreopt,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.DexpreoptCloudProfilesDir = proptools.StringPtr("device/acme/cloud_profiles")
+		}),
+		android.FixtureMergeMockFs(android.MockFS{
+			"device/acme/cloud_profiles/com.acme.bar.prof": nil,
+			"device/acme/cloud_profiles/com.acme.foo.prof": nil,
+			"foo/AndroidManifest.xml":                      []byte(`<manifest package="com.acme.foo"/>`),
+			"qux/AndroidManifest.xml":                      []byte(`<manifest package="com.acme.qux"/>`),
+			"baz.txt":                                      nil,
+		}),
+	).RunTestWithBp(t, bp)
+
+	// The package of foo is only in its manifest.
+	foo := result.ModuleForTests("foo", "android_common")
+	android.AssertStringDoesContain(t, "foo dexpreopt", foo.Rule("dexpreopt").RuleParams.Command,
+		"--profile-file=device/acme/cloud_profiles/com.acme.foo.prof")
+
+	bar := result.ModuleForTests("bar", "android_common")
+	android.AssertStringDoesContain(t, "bar dexpreopt", bar.Rule("dexpreopt").RuleParams.Command,
+		"--profile-file=device/acme/cloud_profiles/com.acme.bar.prof")
+
+	baz := result.ModuleForTests("baz", "android_common")
+	android.AssertStringDoesNotContain(t, "baz dexpreopt", baz.Rule("dexpreopt").RuleParams.Command,
+		"cloud_profiles")
+
+	// qux has no cloud profile, it is compiled as usual and relies on the JIT on the device.
+	qux := result.ModuleForTests("qux", "android_common")
+	android.AssertStringDoesNotContain(t, "qux dexpreopt", qux.Rule("dexpreopt").RuleParams.Command,
+		"--profile-file=")
+	android.AssertBoolEquals(t, "qux profile guided", false,
+		qux.Module().(*AndroidApp).dexpreopter.dexpreoptProperties.Dex_preopt_result.Profile_guided)
+
+	profiles := result.SingletonForTests("dexpreopt_app_profiles")
+	android.AssertStringEquals(t, "apps without profiles", "qux",
+		android.ContentFromFileRuleForTests(t, profiles.Output("dexpreopt/apps_without_profiles.txt")))
+	android.AssertStringEquals(t, "apps compiled with a cloud profile", "com.acme.bar\ncom.acme.foo",
+		android.ContentFromFileRuleForTests(t, profiles.Output("dexpreopt/cloud_profiles.txt")))
+}
+
+func TestManifestPackageName(t *testing.T) {
+	packageName, err := manifestPackageName([]byte(`<?xml version="1.0" encoding="utf-8"?>
+		<manifest xmlns:android="http://schemas.android.com/apk/res/android"
+			package="com.acme.foo">
+			<application android:label="Foo"/>
+		</manifest>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	android.AssertStringEquals(t, "package name", "com.acme.foo", packageName)
+
+	_, err = manifestPackageName([]byte(`<application/>`))
+	if err == nil {
+		t.Errorf("expected an error for a file without a manifest element")
+	}
+}
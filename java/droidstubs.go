@@ -67,6 +67,9 @@ type Droidstubs struct {
 
 	metadataZip android.WritablePath
 	metadataDir android.WritablePath
+
+	// The checked-in API files and nullability warnings, and the files generated for them.
+	goldenFiles []android.GoldenFile
 }
 
 type DroidstubsProperties struct {
@@ -483,6 +486,10 @@ func (d *Droidstubs) apiLevelsGenerationFlags(ctx android.ModuleContext, cmd *an
 	}
 }
 
+// metalava uses lots of memory. The product can restrict the number of metalava jobs that run in
+// parallel by putting their modules in the metalava action pool.
+var metalavaPool = pctx.ActionPool("metalava")
+
 func metalavaUseRbe(ctx android.ModuleContext) bool {
 	return ctx.Config().UseRBE() && ctx.Config().IsEnvTrue("RBE_METALAVA")
 }
@@ -497,7 +504,7 @@ func metalavaCmd(ctx android.ModuleContext, rule *android.RuleBuilder, javaVersi
 
 	if metalavaUseRbe(ctx) {
 		rule.Remoteable(android.RemoteRuleSupports{RBE: true})
-		execStrategy := ctx.Config().GetenvWithDefault("RBE_METALAVA_EXEC_STRATEGY", remoteexec.LocalExecStrategy)
+		execStrategy := ctx.Config().GetenvWithDefault("RBE_METALAVA_EXEC_STRATEGY", remoteexec.DefaultExecStrategy("RBE_METALAVA_EXEC_STRATEGY"))
 		labels := map[string]string{"type": "tool", "name": "metalava"}
 		// TODO: metalava pool rejects these jobs
 		pool := ctx.Config().GetenvWithDefault("RBE_METALAVA_POOL", "java16")
@@ -558,6 +565,7 @@ func (d *Droidstubs) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 		// This metalava run uses lots of memory, restrict the number of metalava jobs that can run in parallel.
 		rule.HighMem()
 	}
+	rule.ActionPool(ctx, metalavaPool)
 
 	generateStubs := BoolDefault(d.properties.Generate_stubs, true)
 	var stubsDir android.OptionalPath
@@ -728,6 +736,7 @@ func (d *Droidstubs) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 
 	rule.Build("metalava", "metalava merged")
 
+	d.goldenFiles = nil
 	if apiCheckEnabled(ctx, d.properties.Check_api.Current, "current") {
 
 		if len(d.Javadoc.properties.Out) > 0 {
@@ -743,6 +752,9 @@ func (d *Droidstubs) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 		}
 
 		d.checkCurrentApiTimestamp = android.PathForModuleOut(ctx, "metalava", "check_current_api.timestamp")
+		d.goldenFiles = append(d.goldenFiles,
+			android.GoldenFile{CheckedIn: apiFile, Generated: d.apiFile},
+			android.GoldenFile{CheckedIn: removedApiFile, Generated: d.removedApiFile})
 
 		rule := android.NewRuleBuilder(pctx, ctx)
 
@@ -814,6 +826,8 @@ func (d *Droidstubs) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 		}
 
 		checkNullabilityWarnings := android.PathForModuleSrc(ctx, String(d.properties.Check_nullability_warnings))
+		d.goldenFiles = append(d.goldenFiles,
+			android.GoldenFile{CheckedIn: checkNullabilityWarnings, Generated: d.nullabilityWarningsFile})
 
 		d.checkNullabilityWarningsTimestamp = android.PathForModuleOut(ctx, "metalava", "check_nullability_warnings.timestamp")
 
@@ -845,6 +859,14 @@ func (d *Droidstubs) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 
 var _ android.ApiProvider = (*Droidstubs)(nil)
 
+// GoldenFiles returns the checked-in API files and nullability warnings of the module, which
+// `m update-golden-files` updates.
+func (d *Droidstubs) GoldenFiles() []android.GoldenFile {
+	return d.goldenFiles
+}
+
+var _ android.GoldenFilesProducer = (*Droidstubs)(nil)
+
 type bazelJavaApiContributionAttributes struct {
 	Api         bazel.LabelAttribute
 	Api_surface *string
@@ -371,6 +371,38 @@ func TestDroidStubsApiContributionGeneration(t *testing.T) {
 	ctx.ModuleForTests("foo.api.contribution", "")
 }
 
+func TestDroidstubsGoldenFiles(t *testing.T) {
+	ctx, _ := testJavaWithFS(t, `
+		droidstubs {
+			name: "foo",
+			srcs: ["A/a.java"],
+			check_api: {
+				current: {
+					api_file: "A/current.txt",
+					removed_api_file: "A/removed.txt",
+				}
+			}
+		}
+		`,
+		map[string][]byte{
+			"A/a.java":      nil,
+			"A/current.txt": nil,
+			"A/removed.txt": nil,
+		},
+	)
+
+	var checkedIn, generated []string
+	for _, file := range ctx.ModuleForTests("foo", "android_common").Module().(*Droidstubs).GoldenFiles() {
+		checkedIn = append(checkedIn, file.CheckedIn.String())
+		generated = append(generated, android.PathRelativeToTop(file.Generated))
+	}
+	android.AssertDeepEquals(t, "checked-in golden files", []string{"A/current.txt", "A/removed.txt"}, checkedIn)
+	android.AssertDeepEquals(t, "generated golden files", []string{
+		"out/soong/.intermediates/foo/android_common/metalava/foo_api.txt",
+		"out/soong/.intermediates/foo/android_common/metalava/foo_removed.txt",
+	}, generated)
+}
+
 func TestGeneratedApiContributionVisibilityTest(t *testing.T) {
 	library_bp := `
 		java_api_library {
@@ -383,6 +383,7 @@ var (
 	kotlinPluginTag         = dependencyTag{name: "kotlin-plugin", toolchain: true}
 	proguardRaiseTag        = dependencyTag{name: "proguard-raise"}
 	certificateTag          = dependencyTag{name: "certificate"}
+	baselineProfileTag      = dependencyTag{name: "baseline-profile"}
 	instrumentationForTag   = dependencyTag{name: "instrumentation_for"}
 	extraLintCheckTag       = dependencyTag{name: "extra-lint-check", toolchain: true}
 	jniLibTag               = dependencyTag{name: "jnilib", runtimeLinked: true}
@@ -539,6 +540,7 @@ const (
 	JAVA_VERSION_9           = 9
 	JAVA_VERSION_11          = 11
 	JAVA_VERSION_17          = 17
+	JAVA_VERSION_21          = 21
 )
 
 func (v javaVersion) String() string {
@@ -555,6 +557,8 @@ func (v javaVersion) String() string {
 		return "11"
 	case JAVA_VERSION_17:
 		return "17"
+	case JAVA_VERSION_21:
+		return "21"
 	default:
 		return "unsupported"
 	}
@@ -569,6 +573,9 @@ func (v javaVersion) StringForKotlinc() string {
 		return "1.6"
 	case JAVA_VERSION_9:
 		return "9"
+	case JAVA_VERSION_21:
+		// The prebuilt kotlinc doesn't target 21 yet, the Java sources of the module still can.
+		return "17"
 	default:
 		return v.String()
 	}
@@ -593,7 +600,17 @@ func normalizeJavaVersion(ctx android.BaseModuleContext, javaVersion string) jav
 		return JAVA_VERSION_11
 	case "17":
 		return JAVA_VERSION_17
-	case "10", "12", "13", "14", "15", "16":
+	case "21":
+		// Only the host tools can use Java 21 for now, the device modules are still compiled
+		// against the Java 17 core libraries.
+		if !ctx.Host() {
+			ctx.PropertyErrorf("java_version", "Java language level 21 is only supported for host modules")
+		} else if !config.UseOpenJdk21(ctx.Config()) {
+			ctx.PropertyErrorf("java_version", "Java language level 21 requires the OpenJDK 21 toolchain, "+
+				"build with EXPERIMENTAL_USE_OPENJDK21_TOOLCHAIN=true")
+		}
+		return JAVA_VERSION_21
+	case "10", "12", "13", "14", "15", "16", "18", "19", "20":
 		ctx.PropertyErrorf("java_version", "Java language level %s is not supported", javaVersion)
 		return JAVA_VERSION_UNSUPPORTED
 	default:
@@ -1690,7 +1707,7 @@ func metalavaStubCmd(ctx android.ModuleContext, rule *android.RuleBuilder,
 
 	if metalavaUseRbe(ctx) {
 		rule.Remoteable(android.RemoteRuleSupports{RBE: true})
-		execStrategy := ctx.Config().GetenvWithDefault("RBE_METALAVA_EXEC_STRATEGY", remoteexec.LocalExecStrategy)
+		execStrategy := ctx.Config().GetenvWithDefault("RBE_METALAVA_EXEC_STRATEGY", remoteexec.DefaultExecStrategy("RBE_METALAVA_EXEC_STRATEGY"))
 		labels := map[string]string{"type": "tool", "name": "metalava"}
 
 		pool := ctx.Config().GetenvWithDefault("RBE_METALAVA_POOL", "java16")
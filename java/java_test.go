@@ -1573,6 +1573,52 @@ func TestErrorproneEnabledOnlyByEnvironmentVariable(t *testing.T) {
 	}
 }
 
+func TestJavaVersion21(t *testing.T) {
+	bp := `
+		java_library_host {
+			name: "foo",
+			srcs: ["a.java"],
+			java_version: "21",
+			errorprone: {
+				enabled: true,
+			},
+		}
+	`
+
+	result := android.GroupFixturePreparers(
+		PrepareForTestWithJavaDefaultModules,
+		android.FixtureMergeEnv(map[string]string{
+			"EXPERIMENTAL_USE_OPENJDK21_TOOLCHAIN": "true",
+		}),
+	).RunTestWithBp(t, bp)
+
+	javac := result.ModuleForTests("foo", result.Config.BuildOSCommonTarget.String()).Description("javac")
+	android.AssertStringEquals(t, "java version", "21", javac.Args["javaVersion"])
+	android.AssertStringDoesContain(t, "errorprone flags", javac.Args["javacFlags"],
+		"${config.ErrorProneJdk21Flags}")
+
+	android.GroupFixturePreparers(
+		PrepareForTestWithJavaDefaultModules,
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`Java language level 21 requires the OpenJDK 21 toolchain`,
+	)).RunTestWithBp(t, bp)
+
+	android.GroupFixturePreparers(
+		PrepareForTestWithJavaDefaultModules,
+		android.FixtureMergeEnv(map[string]string{
+			"EXPERIMENTAL_USE_OPENJDK21_TOOLCHAIN": "true",
+		}),
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`Java language level 21 is only supported for host modules`,
+	)).RunTestWithBp(t, `
+		java_library {
+			name: "bar",
+			srcs: ["a.java"],
+			java_version: "21",
+		}
+	`)
+}
+
 func TestDataDeviceBinsBuildsDeviceBinary(t *testing.T) {
 	testCases := []struct {
 		dataDeviceBinType  string
@@ -22,11 +22,12 @@ import (
 	"strings"
 
 	"android/soong/android"
+	"android/soong/remoteexec"
 
 	"github.com/google/blueprint"
 )
 
-var kotlinc = pctx.AndroidRemoteStaticRule("kotlinc", android.RemoteRuleSupports{Goma: true},
+var kotlinc, kotlincRE = pctx.RemoteStaticRules("kotlinc",
 	blueprint.RuleParams{
 		Command: `rm -rf "$classesDir" "$headerClassesDir" "$srcJarDir" "$kotlinBuildFile" "$emptyDir" && ` +
 			`mkdir -p "$classesDir" "$headerClassesDir" "$srcJarDir" "$emptyDir" && ` +
@@ -34,7 +35,7 @@ var kotlinc = pctx.AndroidRemoteStaticRule("kotlinc", android.RemoteRuleSupports
 			`${config.GenKotlinBuildFileCmd} --classpath "$classpath" --name "$name"` +
 			` --out_dir "$classesDir" --srcs "$out.rsp" --srcs "$srcJarDir/list"` +
 			` $commonSrcFilesArg --out "$kotlinBuildFile" && ` +
-			`${config.KotlincCmd} ${config.KotlincGlobalFlags} ` +
+			`$reTemplate${config.KotlincCmd} ${config.KotlincGlobalFlags} ` +
 			` ${config.KotlincSuppressJDK9Warnings} ${config.JavacHeapFlags} ` +
 			` $kotlincFlags -jvm-target $kotlinJvmTarget -Xbuild-file=$kotlinBuildFile ` +
 			` -kotlin-home $emptyDir ` +
@@ -61,8 +62,33 @@ var kotlinc = pctx.AndroidRemoteStaticRule("kotlinc", android.RemoteRuleSupports
 		RspfileContent: `$in`,
 		Restat:         true,
 	},
-	"kotlincFlags", "classpath", "srcJars", "commonSrcFilesArg", "srcJarDir", "classesDir",
-	"headerClassesDir", "headerJar", "kotlinJvmTarget", "kotlinBuildFile", "emptyDir", "name")
+	&remoteexec.REParams{
+		Labels:       map[string]string{"type": "compile", "lang": "kotlin", "compiler": "kotlinc"},
+		ExecStrategy: "${config.REKotlincExecStrategy}",
+		Inputs: []string{
+			"${config.KotlincCmd}",
+			"${config.KotlinCompilerJar}",
+			"${config.KotlinPreloaderJar}",
+			"${config.KotlinReflectJar}",
+			"${config.KotlinScriptRuntimeJar}",
+			"${config.KotlinStdlibJar}",
+			"${config.KotlinTrove4jJar}",
+			"${config.KotlinAnnotationJar}",
+			"${config.KotlinAbiGenPluginJar}",
+			"${out}.rsp",
+			"$kotlinBuildFile",
+			"$srcJarDir",
+			"$emptyDir",
+			"$implicits",
+		},
+		RSPFiles:          []string{"${out}.rsp"},
+		OutputDirectories: []string{"$classesDir", "$headerClassesDir"},
+		ToolchainInputs:   []string{"${config.JavaCmd}"},
+		Platform:          map[string]string{remoteexec.PoolKey: "${config.REJavaPool}"},
+	},
+	[]string{"kotlincFlags", "classpath", "srcJars", "commonSrcFilesArg", "srcJarDir", "classesDir",
+		"headerClassesDir", "headerJar", "kotlinJvmTarget", "kotlinBuildFile", "emptyDir", "name"},
+	[]string{"implicits"})
 
 func kotlinCommonSrcsList(ctx android.ModuleContext, commonSrcFiles android.Paths) android.OptionalPath {
 	if len(commonSrcFiles) > 0 {
@@ -101,27 +127,33 @@ func kotlinCompile(ctx android.ModuleContext, outputFile, headerOutputFile andro
 		commonSrcFilesArg = "--common_srcs " + commonSrcsList.String()
 	}
 
+	rule := kotlinc
+	args := map[string]string{
+		"classpath":         flags.kotlincClasspath.FormJavaClassPath(""),
+		"kotlincFlags":      flags.kotlincFlags,
+		"commonSrcFilesArg": commonSrcFilesArg,
+		"srcJars":           strings.Join(srcJars.Strings(), " "),
+		"classesDir":        android.PathForModuleOut(ctx, "kotlinc", "classes").String(),
+		"headerClassesDir":  android.PathForModuleOut(ctx, "kotlinc", "header_classes").String(),
+		"headerJar":         headerOutputFile.String(),
+		"srcJarDir":         android.PathForModuleOut(ctx, "kotlinc", "srcJars").String(),
+		"kotlinBuildFile":   android.PathForModuleOut(ctx, "kotlinc-build.xml").String(),
+		"emptyDir":          android.PathForModuleOut(ctx, "kotlinc", "empty").String(),
+		"kotlinJvmTarget":   flags.javaVersion.StringForKotlinc(),
+		"name":              kotlinName,
+	}
+	if ctx.Config().UseRBE() && ctx.Config().IsEnvTrue("RBE_KOTLINC") {
+		rule = kotlincRE
+		args["implicits"] = strings.Join(deps.Strings(), ",")
+	}
 	ctx.Build(pctx, android.BuildParams{
-		Rule:           kotlinc,
+		Rule:           rule,
 		Description:    "kotlinc",
 		Output:         outputFile,
 		ImplicitOutput: headerOutputFile,
 		Inputs:         srcFiles,
 		Implicits:      deps,
-		Args: map[string]string{
-			"classpath":         flags.kotlincClasspath.FormJavaClassPath(""),
-			"kotlincFlags":      flags.kotlincFlags,
-			"commonSrcFilesArg": commonSrcFilesArg,
-			"srcJars":           strings.Join(srcJars.Strings(), " "),
-			"classesDir":        android.PathForModuleOut(ctx, "kotlinc", "classes").String(),
-			"headerClassesDir":  android.PathForModuleOut(ctx, "kotlinc", "header_classes").String(),
-			"headerJar":         headerOutputFile.String(),
-			"srcJarDir":         android.PathForModuleOut(ctx, "kotlinc", "srcJars").String(),
-			"kotlinBuildFile":   android.PathForModuleOut(ctx, "kotlinc-build.xml").String(),
-			"emptyDir":          android.PathForModuleOut(ctx, "kotlinc", "empty").String(),
-			"kotlinJvmTarget":   flags.javaVersion.StringForKotlinc(),
-			"name":              kotlinName,
-		},
+		Args:           args,
 	})
 }
 
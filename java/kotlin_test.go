@@ -20,6 +20,8 @@ import (
 	"testing"
 
 	"android/soong/android"
+
+	"github.com/google/blueprint/proptools"
 )
 
 func TestKotlin(t *testing.T) {
@@ -389,3 +391,22 @@ func TestKotlinCompose(t *testing.T) {
 	android.AssertStringDoesNotContain(t, "unexpected compose compiler plugin",
 		noCompose.VariablesForTestsRelativeToTop()["kotlincFlags"], "-Xplugin="+composeCompiler.String())
 }
+
+func TestKotlinRemoteExecution(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		PrepareForTestWithJavaDefaultModules,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.UseRBE = proptools.BoolPtr(true)
+		}),
+		android.FixtureMergeEnv(map[string]string{"RBE_KOTLINC": "true"}),
+	).RunTestWithBp(t, `
+		java_library {
+			name: "foo",
+			srcs: ["a.java", "b.kt"],
+		}
+	`)
+
+	kotlinc := result.ModuleForTests("foo", "android_common").Rule("kotlinc")
+	android.AssertStringDoesContain(t, "kotlinc rule", kotlinc.Rule.String(), "kotlincRE")
+	android.AssertStringDoesContain(t, "kotlinc implicits", kotlinc.Args["implicits"], "kotlin-stdlib")
+}
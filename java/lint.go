@@ -254,7 +254,7 @@ type lintPaths struct {
 }
 
 func lintRBEExecStrategy(ctx android.ModuleContext) string {
-	return ctx.Config().GetenvWithDefault("RBE_LINT_EXEC_STRATEGY", remoteexec.LocalExecStrategy)
+	return ctx.Config().GetenvWithDefault("RBE_LINT_EXEC_STRATEGY", remoteexec.DefaultExecStrategy("RBE_LINT_EXEC_STRATEGY"))
 }
 
 func (l *linter) writeLintProjectXML(ctx android.ModuleContext, rule *android.RuleBuilder, srcsList android.Path) lintPaths {
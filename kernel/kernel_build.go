@@ -0,0 +1,215 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernel
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+	"android/soong/cc/config"
+)
+
+type kernelBuild struct {
+	android.ModuleBase
+
+	properties        kernelBuildProperties
+	signingProperties kernelModuleSigningProperties
+
+	image         android.WritablePath
+	dtbs          android.WritablePaths
+	dtboImage     android.OptionalPath
+	kernelModules android.Paths
+}
+
+type kernelBuildProperties struct {
+	// Path of the kernel source tree, relative to the directory of the module. Default is ".".
+	Kernel_dir *string
+
+	// Name of the defconfig of the kernel, e.g. "gki_defconfig".
+	Defconfig *string
+
+	// Config fragments merged into the .config generated from the defconfig.
+	Config_fragments []string `android:"path"`
+
+	// Name of the kernel image in arch/<arch>/boot of the kernel output. Default is "Image".
+	Image *string
+
+	// Device trees built by the kernel, relative to arch/<arch>/boot/dts of the kernel output.
+	Dtbs []string
+
+	// Device tree overlays built by the kernel, relative to arch/<arch>/boot/dts of the kernel
+	// output, that are packed into dtbo.img.
+	Dtbo_overlays []string
+
+	// Kernel modules built by the kernel, relative to the kernel output. Kernel modules are
+	// installed to /lib/modules/<kernel_version> directory in the corresponding partition.
+	Kernel_modules []string
+
+	// Kernel version of the kernel modules. Default is "".
+	Kernel_version *string
+
+	// Additional arguments passed to make, e.g. "LTO=thin".
+	Make_args []string
+}
+
+// kernel_build builds the kernel image, device trees and kernel modules from the kernel source
+// tree with the kernel's own make-based build, using the clang and make of the prebuilts. The
+// kernel image is the default output of the module, so it can be referenced with ":<name>", e.g.
+// in the kernel_prebuilt property of bootimg modules. The kernel modules are installed like the
+// ones of prebuilt_kernel_modules, so the module can be added to vendor_ramdisk_fragment modules
+// and to the deps of the dlkm partitions. When sign_modules is set, the kernel modules are signed by
+// default with the signing key generated by the kernel build.
+func kernelBuildFactory() android.Module {
+	module := &kernelBuild{}
+	module.AddProperties(&module.properties, &module.signingProperties)
+	android.InitAndroidArchModule(module, android.DeviceSupported, android.MultilibFirst)
+	return module
+}
+
+// The kernel ARCH of each Soong architecture.
+var kernelArch = map[android.ArchType]string{
+	android.Arm:     "arm",
+	android.Arm64:   "arm64",
+	android.Riscv64: "riscv",
+	android.X86:     "x86",
+	android.X86_64:  "x86",
+}
+
+func (k *kernelBuild) KernelVersion() string {
+	return proptools.StringDefault(k.properties.Kernel_version, "")
+}
+
+func (k *kernelBuild) DepsMutator(ctx android.BottomUpMutatorContext) {
+	// do nothing
+}
+
+func (k *kernelBuild) GenerateAndroidBuildActions(ctx android.ModuleContext) {
+	arch, ok := kernelArch[ctx.Arch().ArchType]
+	if !ok {
+		ctx.ModuleErrorf("unsupported architecture %q", ctx.Arch().ArchType)
+		return
+	}
+	defconfig := proptools.String(k.properties.Defconfig)
+	if defconfig == "" {
+		ctx.PropertyErrorf("defconfig", "must be set")
+		return
+	}
+
+	kernelDir := android.PathForModuleSrc(ctx, proptools.StringDefault(k.properties.Kernel_dir, "."))
+	kernelSrcs := ctx.GlobFiles(filepath.Join(kernelDir.String(), "**/*"),
+		[]string{filepath.Join(kernelDir.String(), ".git/**/*")})
+
+	objDir := android.PathForModuleOut(ctx, "obj")
+	bootDir := objDir.Join(ctx, "arch", arch, "boot")
+	k.image = bootDir.Join(ctx, proptools.StringDefault(k.properties.Image, "Image"))
+	k.dtbs = nil
+	for _, dtb := range k.properties.Dtbs {
+		k.dtbs = append(k.dtbs, bootDir.Join(ctx, "dts", dtb))
+	}
+	var dtboOverlays android.WritablePaths
+	for _, overlay := range k.properties.Dtbo_overlays {
+		dtboOverlays = append(dtboOverlays, bootDir.Join(ctx, "dts", overlay))
+	}
+	var kernelModules android.WritablePaths
+	for _, kernelModule := range k.properties.Kernel_modules {
+		kernelModules = append(kernelModules, objDir.Join(ctx, kernelModule))
+	}
+
+	makeTool := android.PathForSource(ctx, "prebuilts/build-tools", ctx.Config().PrebuiltOS(), "bin", "make")
+	clang := config.ClangPath(ctx, "bin/clang")
+	makeCmd := func(rule *android.RuleBuilder) *android.RuleBuilderCommand {
+		return rule.Command().
+			Textf("PATH=$PWD/%s:$PWD/prebuilts/build-tools/path/%s:$PATH",
+				filepath.Dir(clang.String()), ctx.Config().PrebuiltOS()).
+			Tool(makeTool).
+			FlagWithArg("-C ", kernelDir.String()).
+			Textf("O=$PWD/%s ARCH=%s LLVM=1 LLVM_IAS=1", objDir.String(), arch).
+			Flags(k.properties.Make_args)
+	}
+
+	rule := android.NewRuleBuilder(pctx, ctx)
+	rule.Command().Text("rm -rf").Text(objDir.String())
+	makeCmd(rule).Text(defconfig).Implicit(clang).Implicits(kernelSrcs)
+	if fragments := android.PathsForModuleSrc(ctx, k.properties.Config_fragments); len(fragments) > 0 {
+		mergeConfig := android.PathForModuleSrc(ctx,
+			proptools.StringDefault(k.properties.Kernel_dir, "."), "scripts/kconfig/merge_config.sh")
+		rule.Command().
+			Tool(mergeConfig).
+			FlagWithArg("-m -O ", objDir.String()).
+			Text(objDir.Join(ctx, ".config").String()).
+			Inputs(fragments)
+		makeCmd(rule).Text("olddefconfig")
+	}
+	cmd := makeCmd(rule).Text("-j$(nproc)").Text(filepath.Base(k.image.String())).ImplicitOutput(k.image)
+	if len(k.dtbs) > 0 || len(dtboOverlays) > 0 {
+		cmd.Text("dtbs").ImplicitOutputs(k.dtbs).ImplicitOutputs(dtboOverlays)
+	}
+	// The signing key of the modules generated by the kernel build, and sign-file.
+	var signFile, signingKey, signingCert android.Path
+	if len(kernelModules) > 0 {
+		cmd.Text("modules").ImplicitOutputs(kernelModules)
+		if proptools.Bool(k.signingProperties.Sign_modules) {
+			signFileOut := objDir.Join(ctx, "scripts", "sign-file")
+			keyOut := objDir.Join(ctx, "certs", "signing_key.pem")
+			certOut := objDir.Join(ctx, "certs", "signing_key.x509")
+			cmd.ImplicitOutput(signFileOut).ImplicitOutput(keyOut).ImplicitOutput(certOut)
+			signFile, signingKey, signingCert = signFileOut, keyOut, certOut
+		}
+	}
+	rule.Build("kernel_build", "kernel "+defconfig)
+
+	k.dtboImage = android.OptionalPath{}
+	if len(dtboOverlays) > 0 {
+		dtboImage := android.PathForModuleOut(ctx, "dtbo.img")
+		rule := android.NewRuleBuilder(pctx, ctx)
+		rule.Command().
+			BuiltTool("mkdtboimg").
+			Text("create").
+			Output(dtboImage).
+			Inputs(dtboOverlays.Paths())
+		rule.Build("dtbo_img", "dtbo.img")
+		k.dtboImage = android.OptionalPathForPath(dtboImage)
+	}
+
+	k.kernelModules = nil
+	if len(kernelModules) > 0 {
+		k.kernelModules = installKernelModules(ctx, kernelModules.Paths(), k.KernelVersion(),
+			k.signingProperties.signer(ctx, signFile, signingKey, signingCert))
+	}
+}
+
+// Implements android.OutputFileProducer
+func (k *kernelBuild) OutputFiles(tag string) (android.Paths, error) {
+	switch tag {
+	case "":
+		return android.Paths{k.image}, nil
+	case "dtbs":
+		return k.dtbs.Paths(), nil
+	case "dtbo.img":
+		if !k.dtboImage.Valid() {
+			return nil, fmt.Errorf("no dtbo_overlays")
+		}
+		return android.Paths{k.dtboImage.Path()}, nil
+	case "kernel_modules":
+		return k.kernelModules, nil
+	default:
+		return nil, fmt.Errorf("unsupported module reference tag %q", tag)
+	}
+}
+
+var _ android.OutputFileProducer = (*kernelBuild)(nil)
@@ -0,0 +1,69 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernel
+
+import (
+	"path/filepath"
+	"testing"
+
+	"android/soong/android"
+	"android/soong/cc"
+	"android/soong/cc/config"
+)
+
+func TestKernelBuild(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		cc.PrepareForTestWithCcDefaultModules,
+		android.FixtureRegisterWithContext(registerKernelBuildComponents),
+		android.MockFS{
+			"depmod.cpp": nil,
+			"prebuilts/build-tools/linux-x86/bin/make":                                                   nil,
+			filepath.Join(config.ClangDefaultBase, "linux-x86", config.ClangDefaultVersion, "bin/clang"): nil,
+			"kernel/Makefile":                        nil,
+			"kernel/arch/arm64/Kconfig":              nil,
+			"kernel/fragment.config":                 nil,
+			"kernel/scripts/kconfig/merge_config.sh": nil,
+		}.AddToFixture(),
+	).RunTestWithBp(t, `
+		kernel_build {
+			name: "kernel",
+			kernel_dir: "kernel",
+			defconfig: "gki_defconfig",
+			config_fragments: ["kernel/fragment.config"],
+			dtbo_overlays: ["vendor/board.dtbo"],
+			kernel_modules: ["drivers/foo/foo.ko"],
+			kernel_version: "6.1",
+		}
+	`)
+
+	module := result.ModuleForTests("kernel", "android_arm64_armv8-a")
+	build := module.Rule("kernel_build")
+	android.AssertStringDoesContain(t, "kernel build command", build.RuleParams.Command,
+		"ARCH=arm64 LLVM=1 LLVM_IAS=1 gki_defconfig")
+	android.AssertStringDoesContain(t, "kernel build command", build.RuleParams.Command,
+		"kernel/scripts/kconfig/merge_config.sh -m -O")
+	android.AssertStringDoesContain(t, "kernel build command", build.RuleParams.Command,
+		"-j$(nproc) Image dtbs modules")
+	module.Output("obj/arch/arm64/boot/Image")
+	module.Output("obj/arch/arm64/boot/dts/vendor/board.dtbo")
+	module.Output("obj/drivers/foo/foo.ko")
+	module.Output("dtbo.img")
+
+	var installed []string
+	for _, ps := range module.Module().PackagingSpecs() {
+		installed = append(installed, ps.RelPathInPackage())
+	}
+	android.AssertStringListContains(t, "kernel build installed modules", installed, "lib/modules/6.1/foo.ko")
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernel
+
+import (
+	"path/filepath"
+
+	"github.com/google/blueprint"
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+)
+
+var signRule = pctx.AndroidStaticRule("sign",
+	blueprint.RuleParams{
+		Command:     "$signFile $hash $key $cert $in $out",
+		CommandDeps: []string{"$signFile"},
+	}, "signFile", "hash", "key", "cert")
+
+type kernelModuleSigningProperties struct {
+	// When true, the kernel modules are signed with sign-file before they are installed. They
+	// are signed with signing_key and signing_cert if set, or else with the
+	// KernelModuleSigningKey and KernelModuleSigningCert of the product. Default is false.
+	Sign_modules *bool
+
+	// Private key the kernel modules are signed with, in PEM format.
+	Signing_key *string `android:"path"`
+
+	// X.509 certificate of signing_key.
+	Signing_cert *string `android:"path"`
+
+	// Hash algorithm of the signatures of the kernel modules. Default is "sha256".
+	Signing_hash *string
+}
+
+// kernelModuleSigner signs kernel modules with sign-file.
+type kernelModuleSigner struct {
+	signFile android.Path
+	key      android.Path
+	cert     android.Path
+	hash     string
+}
+
+// signer returns the signer of the kernel modules of the module, or nil if they aren't signed.
+// The key and certificate default to the ones of the product, and then to defaultKey and
+// defaultCert if they are not nil. sign-file defaults to the one of the kernel build tools
+// prebuilts.
+func (p *kernelModuleSigningProperties) signer(ctx android.ModuleContext, signFile, defaultKey, defaultCert android.Path) *kernelModuleSigner {
+	if !proptools.Bool(p.Sign_modules) {
+		return nil
+	}
+
+	var key, cert android.Path
+	if p.Signing_key != nil || p.Signing_cert != nil {
+		if p.Signing_key == nil || p.Signing_cert == nil {
+			ctx.PropertyErrorf("signing_key", "signing_key and signing_cert must be set together")
+			return nil
+		}
+		key = android.PathForModuleSrc(ctx, *p.Signing_key)
+		cert = android.PathForModuleSrc(ctx, *p.Signing_cert)
+	} else if productKey := ctx.Config().KernelModuleSigningKey(); productKey != "" {
+		key = android.PathForSource(ctx, productKey)
+		cert = android.PathForSource(ctx, ctx.Config().KernelModuleSigningCert())
+	} else if defaultKey != nil && defaultCert != nil {
+		key, cert = defaultKey, defaultCert
+	} else {
+		ctx.PropertyErrorf("sign_modules", "requires signing_key and signing_cert, or the "+
+			"KernelModuleSigningKey and KernelModuleSigningCert of the product")
+		return nil
+	}
+
+	if signFile == nil {
+		signFile = android.PathForSource(ctx, "prebuilts/kernel-build-tools", ctx.Config().PrebuiltOS(), "bin", "sign-file")
+	}
+	return &kernelModuleSigner{
+		signFile: signFile,
+		key:      key,
+		cert:     cert,
+		hash:     proptools.StringDefault(p.Signing_hash, "sha256"),
+	}
+}
+
+// sign signs the kernel modules. It must run after the modules are stripped, as stripping
+// removes the signatures.
+func (s *kernelModuleSigner) sign(ctx android.ModuleContext, modules android.OutputPaths) android.OutputPaths {
+	dir := android.PathForModuleOut(ctx, "signed").OutputPath
+	var outputs android.OutputPaths
+
+	for _, m := range modules {
+		signed := dir.Join(ctx, filepath.Base(m.String()))
+		ctx.Build(pctx, android.BuildParams{
+			Rule:      signRule,
+			Input:     m,
+			Implicits: android.Paths{s.signFile, s.key, s.cert},
+			Output:    signed,
+			Args: map[string]string{
+				"signFile": s.signFile.String(),
+				"hash":     s.hash,
+				"key":      s.key.String(),
+				"cert":     s.cert.String(),
+			},
+		})
+		outputs = append(outputs, signed)
+	}
+
+	return outputs
+}
@@ -32,13 +32,16 @@ func init() {
 }
 
 func registerKernelBuildComponents(ctx android.RegistrationContext) {
+	ctx.RegisterModuleType("kernel_build", kernelBuildFactory)
 	ctx.RegisterModuleType("prebuilt_kernel_modules", prebuiltKernelModulesFactory)
+	ctx.RegisterModuleType("vendor_ramdisk_fragment", vendorRamdiskFragmentFactory)
 }
 
 type prebuiltKernelModules struct {
 	android.ModuleBase
 
-	properties prebuiltKernelModulesProperties
+	properties        prebuiltKernelModulesProperties
+	signingProperties kernelModuleSigningProperties
 
 	installDir android.InstallPath
 }
@@ -57,11 +60,22 @@ type prebuiltKernelModulesProperties struct {
 // using depmod and installs them as well.
 func prebuiltKernelModulesFactory() android.Module {
 	module := &prebuiltKernelModules{}
-	module.AddProperties(&module.properties)
+	module.AddProperties(&module.properties, &module.signingProperties)
 	android.InitAndroidArchModule(module, android.DeviceSupported, android.MultilibFirst)
 	return module
 }
 
+// KernelModulesInfo is provided by prebuilt_kernel_modules modules.
+type KernelModulesInfo struct {
+	// The kernel module files, stripped of debug symbols.
+	Modules android.Paths
+
+	// The kernel version the modules are for.
+	KernelVersion string
+}
+
+var KernelModulesInfoProvider = blueprint.NewProvider(KernelModulesInfo{})
+
 func (pkm *prebuiltKernelModules) KernelVersion() string {
 	return proptools.StringDefault(pkm.properties.Kernel_version, "")
 }
@@ -72,22 +86,38 @@ func (pkm *prebuiltKernelModules) DepsMutator(ctx android.BottomUpMutatorContext
 
 func (pkm *prebuiltKernelModules) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 	modules := android.PathsForModuleSrc(ctx, pkm.properties.Srcs)
+	installKernelModules(ctx, modules, pkm.KernelVersion(), pkm.signingProperties.signer(ctx, nil, nil, nil))
+}
 
+// installKernelModules installs the kernel modules, stripped of debug symbols and signed by
+// signer if it is not nil, to /lib/modules/<kernel version> of the partition of the module along
+// with their modules.load, modules.dep, modules.softdep and modules.alias, and provides them with
+// KernelModulesInfo.
+func installKernelModules(ctx android.ModuleContext, modules android.Paths, kernelVersion string,
+	signer *kernelModuleSigner) android.Paths {
 	depmodOut := runDepmod(ctx, modules)
 	strippedModules := stripDebugSymbols(ctx, modules)
+	if signer != nil {
+		strippedModules = signer.sign(ctx, strippedModules)
+	}
 
 	installDir := android.PathForModuleInstall(ctx, "lib", "modules")
-	if pkm.KernelVersion() != "" {
-		installDir = installDir.Join(ctx, pkm.KernelVersion())
+	if kernelVersion != "" {
+		installDir = installDir.Join(ctx, kernelVersion)
 	}
 
 	for _, m := range strippedModules {
 		ctx.InstallFile(installDir, filepath.Base(m.String()), m)
 	}
+	ctx.SetProvider(KernelModulesInfoProvider, KernelModulesInfo{
+		Modules:       strippedModules.Paths(),
+		KernelVersion: kernelVersion,
+	})
 	ctx.InstallFile(installDir, "modules.load", depmodOut.modulesLoad)
 	ctx.InstallFile(installDir, "modules.dep", depmodOut.modulesDep)
 	ctx.InstallFile(installDir, "modules.softdep", depmodOut.modulesSoftdep)
 	ctx.InstallFile(installDir, "modules.alias", depmodOut.modulesAlias)
+	return strippedModules.Paths()
 }
 
 var (
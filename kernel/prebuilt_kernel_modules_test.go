@@ -20,6 +20,8 @@ import (
 
 	"android/soong/android"
 	"android/soong/cc"
+
+	"github.com/google/blueprint/proptools"
 )
 
 func TestKernelModulesFilelist(t *testing.T) {
@@ -57,6 +59,42 @@ func TestKernelModulesFilelist(t *testing.T) {
 	android.AssertDeepEquals(t, "foo packaging specs", expected, actual)
 }
 
+func TestKernelModulesSigning(t *testing.T) {
+	ctx := android.GroupFixturePreparers(
+		cc.PrepareForTestWithCcDefaultModules,
+		android.FixtureRegisterWithContext(registerKernelBuildComponents),
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.KernelModuleSigningKey = proptools.StringPtr("device/signing_key.pem")
+			variables.KernelModuleSigningCert = proptools.StringPtr("device/signing_key.x509")
+		}),
+		android.MockFS{
+			"depmod.cpp":              nil,
+			"mod1.ko":                 nil,
+			"device/signing_key.pem":  nil,
+			"device/signing_key.x509": nil,
+			"prebuilts/kernel-build-tools/linux-x86/bin/sign-file": nil,
+		}.AddToFixture(),
+	).RunTestWithBp(t, `
+		prebuilt_kernel_modules {
+			name: "foo",
+			srcs: ["*.ko"],
+			sign_modules: true,
+		}
+	`)
+
+	module := ctx.ModuleForTests("foo", "android_arm64_armv8-a")
+	sign := module.Output("signed/mod1.ko")
+	android.AssertStringEquals(t, "sign-file hash", "sha256", sign.Args["hash"])
+	android.AssertStringEquals(t, "sign-file key", "device/signing_key.pem", sign.Args["key"])
+	android.AssertStringEquals(t, "sign-file cert", "device/signing_key.x509", sign.Args["cert"])
+	android.AssertPathRelativeToTopEquals(t, "signed module input",
+		"out/soong/.intermediates/foo/android_arm64_armv8-a/stripped/mod1.ko", sign.Input)
+
+	install := module.Output("out/soong/target/product/test_device/system/lib/modules/mod1.ko")
+	android.AssertPathRelativeToTopEquals(t, "installed module",
+		"out/soong/.intermediates/foo/android_arm64_armv8-a/signed/mod1.ko", install.Input)
+}
+
 func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
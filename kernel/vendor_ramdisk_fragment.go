@@ -0,0 +1,234 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernel
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/google/blueprint"
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+)
+
+type vendorRamdiskFragment struct {
+	android.ModuleBase
+
+	properties vendorRamdiskFragmentProperties
+}
+
+type fstabEntry struct {
+	// The block device or logical partition to mount, e.g. "system" or
+	// "/dev/block/by-name/metadata". Required.
+	Src *string
+
+	// The directory to mount it on, e.g. "/system". Required.
+	Mnt_point *string
+
+	// The filesystem type, e.g. "ext4" or "erofs". Required.
+	Type *string
+
+	// The mount flags, e.g. ["ro", "barrier=1"]. Default is "defaults".
+	Mnt_flags []string
+
+	// The fs_mgr flags, e.g. ["wait", "logical", "avb=vbmeta"]. first_stage_mount is always
+	// added as every entry of the fragment is mounted by first stage init.
+	Fs_mgr_flags []string
+}
+
+type vendorRamdiskFragmentProperties struct {
+	// The kernel this fragment is for. Must be one of BoardKernelBinaries. Can be left unset if
+	// BoardKernelBinaries lists a single kernel.
+	Kernel *string
+
+	// prebuilt_kernel_modules modules whose kernel modules are included in this fragment. All
+	// of them must be for the same kernel version.
+	Kernel_modules []string
+
+	// The kernel modules loaded by first stage init, in order, e.g. ["foo.ko", "bar.ko"]. Each
+	// must be provided by one of kernel_modules. Default is all of them.
+	Modules_load []string
+
+	// Modules that are needed to mount the partitions of the fstab, e.g. a tool to unlock the
+	// metadata partition. They are installed in the vendor ramdisk.
+	First_stage_mount_deps []string
+
+	// The entries of the first stage fstab.
+	Fstab []fstabEntry
+
+	// The name of the generated fstab. Defaults to fstab.<module name>.
+	Fstab_name *string
+}
+
+// vendor_ramdisk_fragment installs the files first stage init needs from the vendor ramdisk: the
+// fstab of the partitions it mounts, the kernel modules it loads along with their modules.load,
+// modules.dep, modules.softdep and modules.alias, and the modules needed to mount the
+// partitions. It replaces the lists of files copied into the vendor ramdisk in BoardConfig.mk,
+// and is packaged by adding it to the deps of an android_filesystem of type compressed_cpio.
+func vendorRamdiskFragmentFactory() android.Module {
+	module := &vendorRamdiskFragment{}
+	module.AddProperties(&module.properties)
+	android.InitAndroidArchModule(module, android.DeviceSupported, android.MultilibFirst)
+	return module
+}
+
+type vendorRamdiskFragmentDepTag struct {
+	blueprint.BaseDependencyTag
+	name string
+}
+
+var (
+	kernelModulesTag       = vendorRamdiskFragmentDepTag{name: "kernel_modules"}
+	firstStageMountDepsTag = firstStageMountDepTag{
+		vendorRamdiskFragmentDepTag: vendorRamdiskFragmentDepTag{name: "first_stage_mount_deps"},
+	}
+)
+
+// The first stage mount dependencies are installed, and packaged, along with the fragment.
+type firstStageMountDepTag struct {
+	vendorRamdiskFragmentDepTag
+	android.InstallAlwaysNeededDependencyTag
+}
+
+func (f *vendorRamdiskFragment) InstallInVendorRamdisk() bool {
+	return true
+}
+
+func (f *vendorRamdiskFragment) InstallInRoot() bool {
+	return true
+}
+
+func (f *vendorRamdiskFragment) DepsMutator(ctx android.BottomUpMutatorContext) {
+	ctx.AddDependency(ctx.Module(), kernelModulesTag, f.properties.Kernel_modules...)
+	ctx.AddVariationDependencies([]blueprint.Variation{
+		{Mutator: "image", Variation: android.VendorRamdiskVariation},
+	}, firstStageMountDepsTag, f.properties.First_stage_mount_deps...)
+}
+
+func (f *vendorRamdiskFragment) fstabName() string {
+	return proptools.StringDefault(f.properties.Fstab_name, "fstab."+f.BaseModuleName())
+}
+
+func (f *vendorRamdiskFragment) GenerateAndroidBuildActions(ctx android.ModuleContext) {
+	f.checkKernel(ctx)
+
+	if len(f.properties.Fstab) > 0 {
+		fstab := android.PathForModuleOut(ctx, f.fstabName())
+		android.WriteFileRule(ctx, fstab, f.generateFstab(ctx))
+		ctx.InstallFile(android.PathForModuleInstall(ctx), f.fstabName(), fstab)
+	}
+
+	f.installKernelModules(ctx)
+}
+
+// checkKernel reports an error if the fragment isn't for one of BoardKernelBinaries.
+func (f *vendorRamdiskFragment) checkKernel(ctx android.ModuleContext) {
+	kernels := ctx.DeviceConfig().BoardKernelBinaries()
+	kernel := proptools.String(f.properties.Kernel)
+	if kernel == "" {
+		if len(kernels) > 1 {
+			ctx.PropertyErrorf("kernel", "must be set as BoardKernelBinaries lists more than one kernel: %q", kernels)
+		}
+		return
+	}
+	if !android.InList(kernel, kernels) {
+		ctx.PropertyErrorf("kernel", "%q is not one of BoardKernelBinaries %q", kernel, kernels)
+	}
+}
+
+func (f *vendorRamdiskFragment) generateFstab(ctx android.ModuleContext) string {
+	seen := make(map[string]bool)
+	var lines []string
+	for i, entry := range f.properties.Fstab {
+		src := proptools.String(entry.Src)
+		mntPoint := proptools.String(entry.Mnt_point)
+		fsType := proptools.String(entry.Type)
+		if src == "" || mntPoint == "" || fsType == "" {
+			ctx.PropertyErrorf("fstab", "entry %d must set src, mnt_point and type", i)
+			continue
+		}
+		if seen[mntPoint] {
+			ctx.PropertyErrorf("fstab", "%q is mounted more than once", mntPoint)
+		}
+		seen[mntPoint] = true
+
+		mntFlags := "defaults"
+		if len(entry.Mnt_flags) > 0 {
+			mntFlags = strings.Join(entry.Mnt_flags, ",")
+		}
+		fsMgrFlags := entry.Fs_mgr_flags
+		if !android.InList("first_stage_mount", fsMgrFlags) {
+			fsMgrFlags = append(fsMgrFlags, "first_stage_mount")
+		}
+		lines = append(lines, strings.Join([]string{src, mntPoint, fsType, mntFlags, strings.Join(fsMgrFlags, ",")}, " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (f *vendorRamdiskFragment) installKernelModules(ctx android.ModuleContext) {
+	var modules android.Paths
+	kernelVersion := ""
+	ctx.VisitDirectDepsWithTag(kernelModulesTag, func(dep android.Module) {
+		if !ctx.OtherModuleHasProvider(dep, KernelModulesInfoProvider) {
+			ctx.PropertyErrorf("kernel_modules", "%q is not a prebuilt_kernel_modules module", ctx.OtherModuleName(dep))
+			return
+		}
+		info := ctx.OtherModuleProvider(dep, KernelModulesInfoProvider).(KernelModulesInfo)
+		if len(modules) > 0 && info.KernelVersion != kernelVersion {
+			ctx.PropertyErrorf("kernel_modules", "%q is for kernel version %q, expected %q",
+				ctx.OtherModuleName(dep), info.KernelVersion, kernelVersion)
+			return
+		}
+		kernelVersion = info.KernelVersion
+		modules = append(modules, info.Modules...)
+	})
+	if len(modules) == 0 {
+		if len(f.properties.Modules_load) > 0 {
+			ctx.PropertyErrorf("modules_load", "cannot be set without kernel_modules")
+		}
+		return
+	}
+
+	installDir := android.PathForModuleInstall(ctx, "lib", "modules")
+	if kernelVersion != "" {
+		installDir = installDir.Join(ctx, kernelVersion)
+	}
+
+	depmodOut := runDepmod(ctx, modules)
+	for _, m := range modules {
+		ctx.InstallFile(installDir, filepath.Base(m.String()), m)
+	}
+
+	modulesLoad := android.Path(depmodOut.modulesLoad)
+	if len(f.properties.Modules_load) > 0 {
+		var basenames []string
+		for _, m := range modules {
+			basenames = append(basenames, m.Base())
+		}
+		for _, m := range f.properties.Modules_load {
+			if !android.InList(m, basenames) {
+				ctx.PropertyErrorf("modules_load", "%q is not provided by any of kernel_modules", m)
+			}
+		}
+		out := android.PathForModuleOut(ctx, "modules.load")
+		android.WriteFileRule(ctx, out, strings.Join(f.properties.Modules_load, "\n"))
+		modulesLoad = out
+	}
+	ctx.InstallFile(installDir, "modules.load", modulesLoad)
+	ctx.InstallFile(installDir, "modules.dep", depmodOut.modulesDep)
+	ctx.InstallFile(installDir, "modules.softdep", depmodOut.modulesSoftdep)
+	ctx.InstallFile(installDir, "modules.alias", depmodOut.modulesAlias)
+}
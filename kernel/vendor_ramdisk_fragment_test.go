@@ -0,0 +1,115 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernel
+
+import (
+	"testing"
+
+	"android/soong/android"
+	"android/soong/cc"
+)
+
+var prepareForVendorRamdiskFragmentTest = android.GroupFixturePreparers(
+	cc.PrepareForTestWithCcDefaultModules,
+	android.FixtureRegisterWithContext(registerKernelBuildComponents),
+	android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+		variables.BoardKernelBinaries = []string{"kernel-5.10", "kernel-5.15"}
+	}),
+	android.MockFS{
+		"depmod.cpp": nil,
+		"mod1.ko":    nil,
+		"mod2.ko":    nil,
+	}.AddToFixture(),
+)
+
+func TestVendorRamdiskFragment(t *testing.T) {
+	result := prepareForVendorRamdiskFragmentTest.RunTestWithBp(t, `
+		prebuilt_kernel_modules {
+			name: "foo_modules",
+			srcs: ["*.ko"],
+			kernel_version: "5.10",
+		}
+
+		vendor_ramdisk_fragment {
+			name: "foo",
+			kernel: "kernel-5.10",
+			kernel_modules: ["foo_modules"],
+			modules_load: ["mod2.ko"],
+			fstab: [
+				{
+					src: "system",
+					mnt_point: "/system",
+					type: "erofs",
+					mnt_flags: ["ro"],
+					fs_mgr_flags: ["wait", "logical"],
+				},
+				{
+					src: "/dev/block/by-name/metadata",
+					mnt_point: "/metadata",
+					type: "ext4",
+				},
+			],
+		}
+	`)
+
+	foo := result.ModuleForTests("foo", "android_arm64_armv8-a")
+
+	var actual []string
+	for _, ps := range foo.Module().PackagingSpecs() {
+		actual = append(actual, ps.RelPathInPackage())
+	}
+	android.AssertDeepEquals(t, "foo packaging specs", android.SortedUniqueStrings([]string{
+		"fstab.foo",
+		"lib/modules/5.10/mod1.ko",
+		"lib/modules/5.10/mod2.ko",
+		"lib/modules/5.10/modules.load",
+		"lib/modules/5.10/modules.dep",
+		"lib/modules/5.10/modules.softdep",
+		"lib/modules/5.10/modules.alias",
+	}), android.SortedUniqueStrings(actual))
+
+	android.AssertStringEquals(t, "fstab",
+		"system /system erofs ro wait,logical,first_stage_mount\n"+
+			"/dev/block/by-name/metadata /metadata ext4 defaults first_stage_mount\n",
+		android.ContentFromFileRuleForTests(t, foo.Output("fstab.foo")))
+	android.AssertStringEquals(t, "modules.load", "mod2.ko\n",
+		android.ContentFromFileRuleForTests(t, foo.Output("modules.load")))
+}
+
+func TestVendorRamdiskFragmentErrors(t *testing.T) {
+	prepareForVendorRamdiskFragmentTest.
+		ExtendWithErrorHandler(android.FixtureExpectsAllErrorsToMatchAPattern([]string{
+			`module "foo" .* kernel: "kernel-4.19" is not one of BoardKernelBinaries`,
+			`module "foo" .* fstab: "/system" is mounted more than once`,
+			`module "foo" .* modules_load: "mod3.ko" is not provided by any of kernel_modules`,
+		})).
+		RunTestWithBp(t, `
+		prebuilt_kernel_modules {
+			name: "foo_modules",
+			srcs: ["*.ko"],
+		}
+
+		vendor_ramdisk_fragment {
+			name: "foo",
+			kernel: "kernel-4.19",
+			kernel_modules: ["foo_modules"],
+			modules_load: ["mod3.ko"],
+			fstab: [
+				{src: "system", mnt_point: "/system", type: "erofs"},
+				{src: "system_b", mnt_point: "/system", type: "erofs"},
+			],
+		}
+	`)
+}
@@ -0,0 +1,275 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proprietary
+
+// The gpu_driver_prebuilt module type packages an updatable GPU driver delivered as a prebuilt APK
+// or APEX. Each version of the driver is in the directory of the same name next to the module, and
+// the product selects the version with a <module>:<version> entry in PRODUCT_GPU_DRIVER_VERSIONS,
+// falling back to default_version:
+//
+//	gpu_driver_prebuilt {
+//	    name: "AcmeGpuDriver",
+//	    versions: ["2023.1", "2023.2"],
+//	    default_version: "2023.2",
+//	    apk: "AcmeGpuDriver.apk",
+//	    target_sdk_version: "33",
+//	}
+//
+// The module generates an android_app_import or a prebuilt_apex module for the selected version,
+// named <module>_<version> and installed under the name of the module. The driver is checked
+// against the board when it is built: its uncompressed native libraries and APEX payload must be
+// aligned to the max page size supported by the device, and an APK must target the declared SDK
+// version and have native code for every ABI of the device.
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/google/blueprint"
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+	"android/soong/apex"
+	"android/soong/java"
+)
+
+var pctx = android.NewPackageContext("android/soong/proprietary")
+
+func init() {
+	pctx.Import("android/soong/java/config")
+	pctx.HostBinToolVariable("checkGpuDriverCmd", "check_gpu_driver")
+
+	RegisterGpuDriverBuildComponents(android.InitRegistrationContext)
+}
+
+func RegisterGpuDriverBuildComponents(ctx android.RegistrationContext) {
+	ctx.RegisterModuleType("gpu_driver_prebuilt", GpuDriverPrebuiltFactory)
+}
+
+var PrepareForTestWithGpuDriverPrebuilt = android.FixtureRegisterWithContext(RegisterGpuDriverBuildComponents)
+
+var (
+	checkGpuDriverApk = pctx.AndroidStaticRule("checkGpuDriverApk",
+		blueprint.RuleParams{
+			Command: "rm -f $out && " +
+				"${config.Aapt2Cmd} dump badging $in > ${out}.badging && " +
+				"$checkGpuDriverCmd -driver $in -badging ${out}.badging -target-sdk-version $targetSdkVersion " +
+				"-page-size $pageSize $abis && " +
+				"touch $out",
+			CommandDeps: []string{"${config.Aapt2Cmd}", "$checkGpuDriverCmd"},
+		},
+		"targetSdkVersion", "pageSize", "abis")
+
+	checkGpuDriverApex = pctx.AndroidStaticRule("checkGpuDriverApex",
+		blueprint.RuleParams{
+			Command:     "rm -f $out && $checkGpuDriverCmd -driver $in -page-size $pageSize && touch $out",
+			CommandDeps: []string{"$checkGpuDriverCmd"},
+		},
+		"pageSize")
+)
+
+type gpuDriverPrebuiltProperties struct {
+	// The versions of the driver, each in the directory of the same name next to the module.
+	Versions []string
+
+	// The version installed when the product doesn't select one in PRODUCT_GPU_DRIVER_VERSIONS.
+	// Defaults to the last of versions.
+	Default_version *string
+
+	// The file name of the driver APK in the directory of each version.
+	Apk *string
+
+	// The file name of the driver APEX in the directory of each version.
+	Apex *string
+
+	// The SDK version targeted by the driver APK, which must match its manifest and be at least
+	// the shipping API level of the device.
+	Target_sdk_version *string
+}
+
+type gpuDriverPrebuilt struct {
+	android.ModuleBase
+
+	properties gpuDriverPrebuiltProperties
+
+	checkTimestamp      android.WritablePath
+	requiredModuleNames []string
+}
+
+// GpuDriverPrebuiltFactory creates a gpu_driver_prebuilt module, which installs the version of an
+// updatable GPU driver selected by the product.
+func GpuDriverPrebuiltFactory() android.Module {
+	module := &gpuDriverPrebuilt{}
+	module.AddProperties(&module.properties)
+	android.InitAndroidArchModule(module, android.DeviceSupported, android.MultilibCommon)
+	android.AddLoadHook(module, func(ctx android.LoadHookContext) { module.createDriverModule(ctx) })
+	return module
+}
+
+// version returns the version of the driver selected by the product, or the default one.
+func (g *gpuDriverPrebuilt) version(ctx android.EarlyModuleContext) string {
+	if version := ctx.DeviceConfig().GpuDriverVersion(ctx.ModuleName()); version != "" {
+		return version
+	}
+	if g.properties.Default_version != nil {
+		return *g.properties.Default_version
+	}
+	if len(g.properties.Versions) > 0 {
+		return g.properties.Versions[len(g.properties.Versions)-1]
+	}
+	return ""
+}
+
+func (g *gpuDriverPrebuilt) createDriverModule(ctx android.LoadHookContext) {
+	if len(g.properties.Versions) == 0 {
+		ctx.PropertyErrorf("versions", "missing the versions of the driver")
+		return
+	}
+	version := g.version(ctx)
+	if !android.InList(version, g.properties.Versions) {
+		ctx.ModuleErrorf("version %q selected by PRODUCT_GPU_DRIVER_VERSIONS or default_version is not one of the versions %q",
+			version, g.properties.Versions)
+		return
+	}
+
+	name := ctx.ModuleName() + "_" + version
+	partitionProps, _ := newPartitionProperties(partitionOf(ctx))
+	switch {
+	case g.properties.Apk != nil && g.properties.Apex != nil:
+		ctx.PropertyErrorf("apex", "the driver is either an apk or an apex")
+		return
+	case g.properties.Apk != nil:
+		if g.properties.Target_sdk_version == nil {
+			ctx.PropertyErrorf("target_sdk_version", "missing the SDK version targeted by the driver apk")
+			return
+		}
+		ctx.CreateModule(java.AndroidAppImportFactory, &struct {
+			Name      *string
+			Apk       *string
+			Presigned *bool
+			Filename  *string
+		}{
+			Name:      proptools.StringPtr(name),
+			Apk:       proptools.StringPtr(path.Join(version, *g.properties.Apk)),
+			Presigned: proptools.BoolPtr(true),
+			Filename:  proptools.StringPtr(ctx.ModuleName() + ".apk"),
+		}, partitionProps)
+	case g.properties.Apex != nil:
+		ctx.CreateModule(apex.PrebuiltFactory, &struct {
+			Name     *string
+			Src      *string
+			Filename *string
+		}{
+			Name:     proptools.StringPtr(name),
+			Src:      proptools.StringPtr(path.Join(version, *g.properties.Apex)),
+			Filename: proptools.StringPtr(ctx.ModuleName() + ".apex"),
+		}, partitionProps)
+	default:
+		ctx.PropertyErrorf("apk", "missing the driver apk or apex")
+		return
+	}
+	ctx.AppendProperties(&struct{ Required []string }{[]string{name}})
+}
+
+// partitionOf returns the partition of a module, as used by newPartitionProperties.
+func partitionOf(ctx android.EarlyModuleContext) string {
+	switch {
+	case ctx.SocSpecific():
+		return "vendor"
+	case ctx.DeviceSpecific():
+		return "odm"
+	case ctx.ProductSpecific():
+		return "product"
+	case ctx.SystemExtSpecific():
+		return "system_ext"
+	}
+	return "system"
+}
+
+func (g *gpuDriverPrebuilt) GenerateAndroidBuildActions(ctx android.ModuleContext) {
+	g.requiredModuleNames = ctx.RequiredModuleNames()
+
+	pageSize := ctx.Config().MaxPageSizeSupported()
+	if pageSize == "" {
+		pageSize = "4096"
+	}
+	g.checkTimestamp = android.PathForModuleOut(ctx, "check_gpu_driver.timestamp")
+
+	version := g.version(ctx)
+	if g.properties.Apk != nil {
+		targetSdkVersion := proptools.String(g.properties.Target_sdk_version)
+		apiLevel, err := android.ApiLevelFromUser(ctx, targetSdkVersion)
+		if err != nil {
+			ctx.PropertyErrorf("target_sdk_version", "%s", err)
+			return
+		}
+		if shipping := ctx.DeviceConfig().ShippingApiLevel(); !shipping.IsNone() && apiLevel.LessThan(shipping) {
+			ctx.PropertyErrorf("target_sdk_version", "%s is below the shipping API level %s of the device",
+				targetSdkVersion, shipping)
+			return
+		}
+
+		// The driver must have native code for the primary ABI of every architecture of the device.
+		var abis []string
+		for _, target := range ctx.Config().Targets[android.Android] {
+			if target.NativeBridge == android.NativeBridgeDisabled && len(target.Arch.Abi) > 0 {
+				abis = append(abis, "-abi "+target.Arch.Abi[0])
+			}
+		}
+
+		ctx.Build(pctx, android.BuildParams{
+			Rule:        checkGpuDriverApk,
+			Description: "check gpu driver " + ctx.ModuleName(),
+			Input:       android.PathForModuleSrc(ctx, version, *g.properties.Apk),
+			Output:      g.checkTimestamp,
+			Args: map[string]string{
+				"targetSdkVersion": targetSdkVersion,
+				"pageSize":         pageSize,
+				"abis":             strings.Join(abis, " "),
+			},
+		})
+	} else {
+		ctx.Build(pctx, android.BuildParams{
+			Rule:        checkGpuDriverApex,
+			Description: "check gpu driver " + ctx.ModuleName(),
+			Input:       android.PathForModuleSrc(ctx, version, proptools.String(g.properties.Apex)),
+			Output:      g.checkTimestamp,
+			Args: map[string]string{
+				"pageSize": pageSize,
+			},
+		})
+	}
+}
+
+func (g *gpuDriverPrebuilt) AndroidMk() android.AndroidMkData {
+	return android.AndroidMkData{
+		Custom: func(w io.Writer, name, prefix, moduleDir string, data android.AndroidMkData) {
+			fmt.Fprintln(w, "\ninclude $(CLEAR_VARS)", " # proprietary.gpuDriverPrebuilt")
+			fmt.Fprintln(w, "LOCAL_PATH :=", moduleDir)
+			fmt.Fprintln(w, "LOCAL_MODULE :=", name)
+			data.Entries.WriteLicenseVariables(w)
+			if len(g.requiredModuleNames) > 0 {
+				fmt.Fprintln(w, "LOCAL_REQUIRED_MODULES :=", strings.Join(g.requiredModuleNames, " "))
+			}
+			// Installing the driver runs its checks.
+			if g.checkTimestamp != nil {
+				fmt.Fprintln(w, "LOCAL_ADDITIONAL_DEPENDENCIES :=", g.checkTimestamp.String())
+			}
+			fmt.Fprintln(w, "include $(BUILD_PHONY_PACKAGE)")
+		},
+	}
+}
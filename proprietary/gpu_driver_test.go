@@ -0,0 +1,81 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proprietary
+
+import (
+	"testing"
+
+	"android/soong/android"
+	"android/soong/java"
+
+	"github.com/google/blueprint/proptools"
+)
+
+const testGpuDriverBp = `
+	gpu_driver_prebuilt {
+		name: "AcmeGpuDriver",
+		versions: ["2023.1", "2023.2"],
+		default_version: "2023.2",
+		apk: "AcmeGpuDriver.apk",
+		target_sdk_version: "33",
+		soc_specific: true,
+	}
+`
+
+var prepareForGpuDriverTest = android.GroupFixturePreparers(
+	java.PrepareForTestWithJavaDefaultModules,
+	PrepareForTestWithGpuDriverPrebuilt,
+	android.MockFS{
+		"vendor/acme/gpu/2023.1/AcmeGpuDriver.apk": nil,
+		"vendor/acme/gpu/2023.2/AcmeGpuDriver.apk": nil,
+	}.AddToFixture(),
+)
+
+func TestGpuDriverPrebuilt(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		prepareForGpuDriverTest,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.GpuDriverVersions = []string{"AcmeGpuDriver:2023.1"}
+			variables.DeviceMaxPageSizeSupported = proptools.StringPtr("16384")
+		}),
+		android.FixtureAddTextFile("vendor/acme/gpu/Android.bp", testGpuDriverBp),
+	).RunTest(t)
+
+	driver := result.ModuleForTests("AcmeGpuDriver", "android_common")
+	android.AssertDeepEquals(t, "required modules", []string{"AcmeGpuDriver_2023.1"},
+		driver.Module().RequiredModuleNames())
+
+	check := driver.Rule("checkGpuDriverApk")
+	android.AssertStringEquals(t, "checked apk", "vendor/acme/gpu/2023.1/AcmeGpuDriver.apk", check.Input.String())
+	android.AssertStringEquals(t, "target sdk version", "33", check.Args["targetSdkVersion"])
+	android.AssertStringEquals(t, "page size", "16384", check.Args["pageSize"])
+	android.AssertStringEquals(t, "abis", "-abi arm64-v8a -abi armeabi-v7a", check.Args["abis"])
+
+	app := result.ModuleForTests("AcmeGpuDriver_2023.1", "android_common").Module().(*java.AndroidAppImport)
+	android.AssertBoolEquals(t, "driver is vendor", true, app.SocSpecific())
+	android.AssertStringEquals(t, "installed apk", "AcmeGpuDriver.apk", app.OutputFile().Base())
+}
+
+func TestGpuDriverPrebuiltVersionNotAllowed(t *testing.T) {
+	android.GroupFixturePreparers(
+		prepareForGpuDriverTest,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.GpuDriverVersions = []string{"AcmeGpuDriver:2022.4"}
+		}),
+		android.FixtureAddTextFile("vendor/acme/gpu/Android.bp", testGpuDriverBp),
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`version "2022.4" selected by PRODUCT_GPU_DRIVER_VERSIONS or default_version is not one of the versions`)).
+		RunTest(t)
+}
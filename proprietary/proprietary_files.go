@@ -0,0 +1,310 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proprietary
+
+// The proprietary_files module type generates the modules of the vendor blobs extracted from a
+// device, listed in a proprietary-files.txt manifest of the device tree, instead of a handwritten
+// module for each of them. The blobs are found in the blobs_dir of the module at the path listed
+// in the manifest, e.g. vendor/lib64/libfoo.so, and the module depends on the generated modules
+// so that listing it in PRODUCT_PACKAGES installs all of them:
+//
+//	proprietary_files {
+//	    name: "acme-vendor-blobs",
+//	    manifest: "device/acme/foo/proprietary-files.txt",
+//	    blobs_dir: "proprietary",
+//	    exclude: ["vendor/lib64/libhandwritten.so"],
+//	}
+//
+// The shared libraries of lib and lib64 become cc_prebuilt_library_shared modules named after the
+// library, the executables of bin cc_prebuilt_binary modules, the apps of app and priv-app
+// android_app_import modules named after the APK, and the files of etc and firmware prebuilt_etc
+// and prebuilt_firmware modules named after their path, e.g. vendor_etc_init_foo.rc.
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+	"android/soong/cc"
+	"android/soong/etc"
+	"android/soong/java"
+)
+
+func init() {
+	RegisterProprietaryFilesBuildComponents(android.InitRegistrationContext)
+}
+
+func RegisterProprietaryFilesBuildComponents(ctx android.RegistrationContext) {
+	ctx.RegisterModuleType("proprietary_files", ProprietaryFilesFactory)
+}
+
+var PrepareForTestWithProprietaryFiles = android.FixtureRegisterWithContext(RegisterProprietaryFilesBuildComponents)
+
+type proprietaryFilesProperties struct {
+	// The manifest listing the blobs, relative to the top of the tree, in the format of the
+	// proprietary-files.txt files of the device trees.
+	Manifest *string
+
+	// The directory containing the extracted blobs, relative to the directory of the module.
+	// Defaults to proprietary.
+	Blobs_dir *string
+
+	// The blobs of the manifest that aren't generated, usually because they have a handwritten
+	// module.
+	Exclude []string
+}
+
+type proprietaryFiles struct {
+	android.ModuleBase
+
+	properties proprietaryFilesProperties
+
+	requiredModuleNames []string
+}
+
+// ProprietaryFilesFactory creates a proprietary_files module, which generates the modules of the
+// blobs listed in a proprietary-files.txt manifest.
+func ProprietaryFilesFactory() android.Module {
+	module := &proprietaryFiles{}
+	module.AddProperties(&module.properties)
+	android.InitAndroidArchModule(module, android.DeviceSupported, android.MultilibCommon)
+	android.AddLoadHook(module, func(ctx android.LoadHookContext) { module.createBlobModules(ctx) })
+	return module
+}
+
+func (p *proprietaryFiles) GenerateAndroidBuildActions(ctx android.ModuleContext) {
+	p.requiredModuleNames = ctx.RequiredModuleNames()
+}
+
+func (p *proprietaryFiles) AndroidMk() android.AndroidMkData {
+	return android.AndroidMkData{
+		Custom: func(w io.Writer, name, prefix, moduleDir string, data android.AndroidMkData) {
+			fmt.Fprintln(w, "\ninclude $(CLEAR_VARS)", " # proprietary.proprietaryFiles")
+			fmt.Fprintln(w, "LOCAL_PATH :=", moduleDir)
+			fmt.Fprintln(w, "LOCAL_MODULE :=", name)
+			data.Entries.WriteLicenseVariables(w)
+			if len(p.requiredModuleNames) > 0 {
+				fmt.Fprintln(w, "LOCAL_REQUIRED_MODULES :=", strings.Join(p.requiredModuleNames, " "))
+			}
+			fmt.Fprintln(w, "include $(BUILD_PHONY_PACKAGE)")
+		},
+	}
+}
+
+// parseManifest returns the paths of the blobs listed in a proprietary-files.txt manifest. The
+// lines have the [-]<src>[:<dest>][;<args>][|<sha1sum>] format, the blob is installed at dest if
+// set, and lines starting with # are comments.
+func parseManifest(contents string) []string {
+	var blobs []string
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "-")
+		if i := strings.IndexAny(line, ";|"); i >= 0 {
+			line = line[:i]
+		}
+		if _, dest, found := strings.Cut(line, ":"); found {
+			line = dest
+		}
+		blobs = append(blobs, line)
+	}
+	return blobs
+}
+
+// The properties setting the partition of the generated modules.
+type partitionProperties struct {
+	Soc_specific        *bool
+	Device_specific     *bool
+	Product_specific    *bool
+	System_ext_specific *bool
+}
+
+func newPartitionProperties(partition string) (*partitionProperties, bool) {
+	props := &partitionProperties{}
+	switch partition {
+	case "vendor":
+		props.Soc_specific = proptools.BoolPtr(true)
+	case "odm":
+		props.Device_specific = proptools.BoolPtr(true)
+	case "product":
+		props.Product_specific = proptools.BoolPtr(true)
+	case "system_ext":
+		props.System_ext_specific = proptools.BoolPtr(true)
+	case "system":
+	default:
+		return nil, false
+	}
+	return props, true
+}
+
+// sharedLibrary is a shared library of the manifest, with its 32-bit and 64-bit variants.
+type sharedLibrary struct {
+	partition  string
+	installDir *string
+	lib32      string
+	lib64      string
+}
+
+func (p *proprietaryFiles) createBlobModules(ctx android.LoadHookContext) {
+	manifest := proptools.String(p.properties.Manifest)
+	if manifest == "" {
+		ctx.PropertyErrorf("manifest", "missing the proprietary-files.txt manifest")
+		return
+	}
+	contents, err := ctx.Config().ReadSourceFile(ctx, manifest)
+	if err != nil {
+		ctx.PropertyErrorf("manifest", "cannot read %s: %s", manifest, err)
+		return
+	}
+	blobsDir := proptools.StringDefault(p.properties.Blobs_dir, "proprietary")
+
+	var names []string
+	sharedLibs := make(map[string]*sharedLibrary)
+	for _, blob := range parseManifest(string(contents)) {
+		if android.InList(blob, p.properties.Exclude) {
+			continue
+		}
+		partition, file, _ := strings.Cut(blob, "/")
+		partitionProps, ok := newPartitionProperties(partition)
+		dir, rel, _ := strings.Cut(file, "/")
+		if !ok || rel == "" {
+			ctx.PropertyErrorf("manifest", "%s: %s is not in a partition", manifest, blob)
+			continue
+		}
+		src := path.Join(blobsDir, blob)
+		var installDir *string
+		if d := path.Dir(rel); d != "." {
+			installDir = proptools.StringPtr(d)
+		}
+
+		switch {
+		case (dir == "lib" || dir == "lib64") && strings.HasSuffix(rel, ".so"):
+			name := strings.TrimSuffix(path.Base(rel), ".so")
+			lib := sharedLibs[name]
+			if lib == nil {
+				lib = &sharedLibrary{partition: partition, installDir: installDir}
+				sharedLibs[name] = lib
+			} else if lib.partition != partition || proptools.String(lib.installDir) != proptools.String(installDir) {
+				ctx.PropertyErrorf("manifest", "%s: %s is installed in a different directory than the other variant of %s",
+					manifest, blob, name)
+				continue
+			}
+			if dir == "lib" {
+				lib.lib32 = src
+			} else {
+				lib.lib64 = src
+			}
+		case dir == "bin":
+			name := path.Base(rel)
+			ctx.CreateModule(cc.PrebuiltBinaryFactory, &struct {
+				Name                  *string
+				Srcs                  []string
+				Relative_install_path *string
+				Check_elf_files       *bool
+				Strip                 struct{ None *bool }
+			}{
+				Name:                  proptools.StringPtr(name),
+				Srcs:                  []string{src},
+				Relative_install_path: installDir,
+				Check_elf_files:       proptools.BoolPtr(false),
+				Strip:                 struct{ None *bool }{proptools.BoolPtr(true)},
+			}, partitionProps)
+			names = append(names, name)
+		case (dir == "app" || dir == "priv-app") && strings.HasSuffix(rel, ".apk"):
+			name := strings.TrimSuffix(path.Base(rel), ".apk")
+			ctx.CreateModule(java.AndroidAppImportFactory, &struct {
+				Name       *string
+				Apk        *string
+				Presigned  *bool
+				Privileged *bool
+			}{
+				Name:       proptools.StringPtr(name),
+				Apk:        proptools.StringPtr(src),
+				Presigned:  proptools.BoolPtr(true),
+				Privileged: proptools.BoolPtr(dir == "priv-app"),
+			}, partitionProps)
+			names = append(names, name)
+		case dir == "etc" || dir == "firmware":
+			name := strings.ReplaceAll(blob, "/", "_")
+			factory := etc.PrebuiltEtcFactory
+			if dir == "firmware" {
+				factory = etc.PrebuiltFirmwareFactory
+			}
+			ctx.CreateModule(factory, &struct {
+				Name                  *string
+				Src                   *string
+				Filename              *string
+				Relative_install_path *string
+			}{
+				Name:                  proptools.StringPtr(name),
+				Src:                   proptools.StringPtr(src),
+				Filename:              proptools.StringPtr(path.Base(rel)),
+				Relative_install_path: installDir,
+			}, partitionProps)
+			names = append(names, name)
+		default:
+			ctx.PropertyErrorf("manifest", "%s: don't know how to install %s, add it to exclude and write its module",
+				manifest, blob)
+		}
+	}
+
+	for _, name := range android.SortedKeys(sharedLibs) {
+		lib := sharedLibs[name]
+		partitionProps, _ := newPartitionProperties(lib.partition)
+		props := &struct {
+			Name                  *string
+			Compile_multilib      *string
+			Relative_install_path *string
+			Check_elf_files       *bool
+			Strip                 struct{ None *bool }
+			Multilib              struct {
+				Lib32 struct{ Srcs []string }
+				Lib64 struct{ Srcs []string }
+			}
+		}{
+			Name:                  proptools.StringPtr(name),
+			Relative_install_path: lib.installDir,
+			// The dependencies of the blobs aren't known, which the checks of the ELF files need.
+			Check_elf_files: proptools.BoolPtr(false),
+		}
+		props.Strip.None = proptools.BoolPtr(true)
+		switch {
+		case lib.lib32 != "" && lib.lib64 != "":
+			props.Compile_multilib = proptools.StringPtr("both")
+		case lib.lib32 != "":
+			props.Compile_multilib = proptools.StringPtr("32")
+		default:
+			props.Compile_multilib = proptools.StringPtr("64")
+		}
+		if lib.lib32 != "" {
+			props.Multilib.Lib32.Srcs = []string{lib.lib32}
+		}
+		if lib.lib64 != "" {
+			props.Multilib.Lib64.Srcs = []string{lib.lib64}
+		}
+		ctx.CreateModule(cc.PrebuiltSharedLibraryFactory, props, partitionProps)
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	ctx.AppendProperties(&struct{ Required []string }{names})
+}
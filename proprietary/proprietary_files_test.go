@@ -0,0 +1,95 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proprietary
+
+import (
+	"strings"
+	"testing"
+
+	"android/soong/android"
+	"android/soong/cc"
+	"android/soong/etc"
+	"android/soong/java"
+
+	"github.com/google/blueprint/proptools"
+)
+
+const testManifest = `# Blobs of the acme foo device
+vendor/lib/libacme.so
+vendor/lib64/libacme.so|0123456789abcdef0123456789abcdef01234567
+vendor/lib64/hw/camera.acme.so;FIX_SONAME
+-vendor/bin/hw/android.hardware.acme-service
+-vendor/priv-app/AcmeService/AcmeService.apk
+vendor/etc/init/acme.rc
+odm/firmware/acme/fw.bin
+vendor/lib64/libhandwritten.so
+`
+
+func TestProprietaryFiles(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		cc.PrepareForTestWithCcDefaultModules,
+		java.PrepareForTestWithJavaDefaultModules,
+		etc.PrepareForTestWithPrebuiltEtc,
+		PrepareForTestWithProprietaryFiles,
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.DeviceVndkVersion = proptools.StringPtr("current")
+			variables.Platform_vndk_version = proptools.StringPtr("29")
+		}),
+		android.MockFS{
+			"device/acme/foo/proprietary-files.txt":                                   []byte(testManifest),
+			"vendor/acme/foo/proprietary/vendor/lib/libacme.so":                       nil,
+			"vendor/acme/foo/proprietary/vendor/lib64/libacme.so":                     nil,
+			"vendor/acme/foo/proprietary/vendor/lib64/hw/camera.acme.so":              nil,
+			"vendor/acme/foo/proprietary/vendor/bin/hw/android.hardware.acme-service": nil,
+			"vendor/acme/foo/proprietary/vendor/priv-app/AcmeService/AcmeService.apk": nil,
+			"vendor/acme/foo/proprietary/vendor/etc/init/acme.rc":                     nil,
+			"vendor/acme/foo/proprietary/odm/firmware/acme/fw.bin":                    nil,
+		}.AddToFixture(),
+		android.FixtureAddTextFile("vendor/acme/foo/Android.bp", `
+			proprietary_files {
+				name: "acme-foo-vendor",
+				manifest: "device/acme/foo/proprietary-files.txt",
+				exclude: ["vendor/lib64/libhandwritten.so"],
+			}
+		`),
+	).RunTest(t)
+
+	blobs := result.ModuleForTests("acme-foo-vendor", "android_common").Module()
+	android.AssertDeepEquals(t, "required modules", []string{
+		"AcmeService",
+		"android.hardware.acme-service",
+		"camera.acme",
+		"libacme",
+		"odm_firmware_acme_fw.bin",
+		"vendor_etc_init_acme.rc",
+	}, blobs.RequiredModuleNames())
+
+	libacme := result.ModuleForTests("libacme", "android_vendor.29_arm64_armv8-a_shared").Module()
+	android.AssertBoolEquals(t, "libacme is vendor", true, libacme.(*cc.Module).InVendor())
+	result.ModuleForTests("libacme", "android_vendor.29_arm_armv7-a-neon_shared")
+
+	// The libraries of lib64 only have a 64-bit variant.
+	android.AssertDeepEquals(t, "camera.acme variants",
+		[]string{"android_vendor.29_arm64_armv8-a_shared"},
+		android.FilterListPred(result.ModuleVariantsForTests("camera.acme"), func(v string) bool {
+			return strings.HasSuffix(v, "_shared")
+		}))
+
+	app := result.ModuleForTests("AcmeService", "android_common").Module().(*java.AndroidAppImport)
+	android.AssertBoolEquals(t, "AcmeService is privileged", true, app.Privileged())
+
+	fw := result.ModuleForTests("odm_firmware_acme_fw.bin", "android_arm64_armv8-a").Module().(*etc.PrebuiltEtc)
+	android.AssertStringEquals(t, "firmware sub dir", "acme", fw.SubDir())
+}
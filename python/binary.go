@@ -54,6 +54,14 @@ type BinaryProperties struct {
 	// libraries automatically included in the PYTHONPATH.
 	Autorun *bool `android:"arch_variant"`
 
+	// whether the binary is a zipapp run in place by the host CPython prebuilt shared by the
+	// Python binaries of the tree, instead of embedding the interpreter and the standard library
+	// like embedded_launcher, or extracting the sources to run them with python3 from the PATH.
+	// The binary is only as large as its sources, but the sources are imported from the zip, so
+	// they can't read data files or load native extensions from it. Only supported for Python 3
+	// binaries with autorun, and can't be combined with embedded_launcher.
+	Shared_interpreter *bool
+
 	// Flag to indicate whether or not to create test config automatically. If AndroidTest.xml
 	// doesn't exist next to the Android.bp, this attribute doesn't need to be set to true
 	// explicitly.
@@ -107,6 +115,11 @@ func (p *PythonBinaryModule) GenerateAndroidBuildActions(ctx android.ModuleConte
 }
 
 func (p *PythonBinaryModule) buildBinary(ctx android.ModuleContext) {
+	if p.isSharedInterpreterEnabled() {
+		p.buildSharedInterpreterBinary(ctx)
+		return
+	}
+
 	embeddedLauncher := p.isEmbeddedLauncherEnabled()
 	depsSrcsZips := p.collectPathsFromTransitiveDeps(ctx, embeddedLauncher)
 	main := ""
@@ -198,6 +211,35 @@ func (p *PythonBinaryModule) OutputFiles(tag string) (android.Paths, error) {
 	}
 }
 
+// buildSharedInterpreterBinary builds the binary as a zipapp of its sources and the sources of its
+// dependencies, which runs with the host CPython prebuilt instead of embedding it.
+func (p *PythonBinaryModule) buildSharedInterpreterBinary(ctx android.ModuleContext) {
+	if p.isEmbeddedLauncherEnabled() {
+		ctx.PropertyErrorf("shared_interpreter", "can't be combined with embedded_launcher")
+	}
+	if p.properties.Actual_version != pyVersion3 {
+		ctx.PropertyErrorf("shared_interpreter", "is only supported for Python 3")
+	}
+	if !p.autorun() {
+		ctx.PropertyErrorf("shared_interpreter", "requires autorun")
+	}
+
+	srcsZips := android.Paths{p.srcsZip}
+	srcsZips = append(srcsZips, p.collectPathsFromTransitiveDeps(ctx, false)...)
+	p.installSource = registerBuildActionForSharedInterpreterParFile(ctx,
+		sharedInterpreterPath(ctx), p.getPyMainFile(ctx, p.srcsPathMappings), p.getStem(ctx), srcsZips)
+}
+
+// sharedInterpreterPath returns the path of the host CPython prebuilt that runs the binaries
+// with shared_interpreter, relative to the top of the source tree.
+func sharedInterpreterPath(ctx android.ModuleContext) string {
+	return filepath.Join("prebuilts/build-tools", ctx.Config().PrebuiltOS(), "bin/py3-cmd")
+}
+
+func (p *PythonBinaryModule) isSharedInterpreterEnabled() bool {
+	return Bool(p.binaryProperties.Shared_interpreter)
+}
+
 func (p *PythonBinaryModule) isEmbeddedLauncherEnabled() bool {
 	return Bool(p.properties.Embedded_launcher)
 }
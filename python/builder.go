@@ -71,6 +71,20 @@ var (
 		},
 		"srcsZips", "launcher")
 
+	// sharedInterpreterPar is a zipapp run in place by the host CPython prebuilt shared by the
+	// Python binaries, prefixed with a shell script finding it.
+	sharedInterpreterPar = pctx.AndroidStaticRule("sharedInterpreterPar",
+		blueprint.RuleParams{
+			Command: `rm -f $out.main && ` +
+				`sed 's/ENTRY_POINT/$main/' build/soong/python/scripts/main_non_embedded.py >$out.main && ` +
+				`sed 's/%interpreter%/$interp/g' build/soong/python/scripts/stub_shared_interpreter.sh >$out.prefix && ` +
+				`$mergeParCmd -p -pm $out.main --prefix $out.prefix $out $srcsZips && ` +
+				`chmod +x $out && rm -f $out.main $out.prefix`,
+			CommandDeps: []string{"$mergeParCmd", "build/soong/python/scripts/main_non_embedded.py",
+				"build/soong/python/scripts/stub_shared_interpreter.sh"},
+		},
+		"interp", "main", "srcsZips")
+
 	precompile = pctx.AndroidStaticRule("precompilePython", blueprint.RuleParams{
 		Command: `LD_LIBRARY_PATH="$ldLibraryPath" ` +
 			`PYTHONPATH=$stdlibZip/internal/stdlib ` +
@@ -145,3 +159,23 @@ func registerBuildActionForParFile(ctx android.ModuleContext, embeddedLauncher b
 
 	return binFile
 }
+
+// registerBuildActionForSharedInterpreterParFile builds a zipapp of the sources running main with
+// the host CPython prebuilt interpreter, which is a path relative to the top of the source tree.
+func registerBuildActionForSharedInterpreterParFile(ctx android.ModuleContext, interpreter, main,
+	binName string, srcsZips android.Paths) android.Path {
+
+	binFile := android.PathForModuleOut(ctx, binName)
+	ctx.Build(pctx, android.BuildParams{
+		Rule:        sharedInterpreterPar,
+		Description: "shared interpreter python archive",
+		Output:      binFile,
+		Implicits:   srcsZips,
+		Args: map[string]string{
+			"interp":   strings.Replace(interpreter, "/", `\/`, -1),
+			"main":     strings.Replace(strings.TrimSuffix(main, pyExt), "/", ".", -1),
+			"srcsZips": strings.Join(srcsZips.Strings(), " "),
+		},
+	})
+	return binFile
+}
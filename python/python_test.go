@@ -360,6 +360,60 @@ cc_binary {
 	}
 }
 
+func TestPythonBinaryHostSharedInterpreter(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		android.PrepareForTestWithDefaults,
+		android.PrepareForTestWithArchMutator,
+		PrepareForTestWithPythonBuildComponents,
+		android.FixtureWithRootAndroidBp(`
+			python_library_host {
+				name: "libfoo",
+				srcs: ["libfoo.py"],
+			}
+			python_binary_host {
+				name: "foo",
+				srcs: ["foo.py"],
+				libs: ["libfoo"],
+				shared_interpreter: true,
+			}
+		`),
+		android.FixtureAddFile("foo.py", nil),
+		android.FixtureAddFile("libfoo.py", nil),
+	).RunTest(t)
+
+	rule := result.ModuleForTests("foo", "linux_glibc_x86_64_PY3").Rule("sharedInterpreterPar")
+	android.AssertStringEquals(t, "interp", `prebuilts\/build-tools\/linux-x86\/bin\/py3-cmd`, rule.Args["interp"])
+	android.AssertStringEquals(t, "main", "foo", rule.Args["main"])
+	android.AssertPathsRelativeToTopEquals(t, "srcsZips", []string{
+		"out/soong/.intermediates/foo/linux_glibc_x86_64_PY3/foo.py.srcszip",
+		"out/soong/.intermediates/libfoo/linux_glibc_x86_64_PY3/libfoo.py.srcszip",
+	}, rule.Implicits)
+}
+
+func TestPythonBinaryHostSharedInterpreterEmbeddedLauncher(t *testing.T) {
+	android.GroupFixturePreparers(
+		android.PrepareForTestWithDefaults,
+		android.PrepareForTestWithArchMutator,
+		android.PrepareForTestWithAllowMissingDependencies,
+		PrepareForTestWithPythonBuildComponents,
+		android.FixtureWithRootAndroidBp(`
+			python_binary_host {
+				name: "foo",
+				srcs: ["foo.py"],
+				shared_interpreter: true,
+				version: {
+					py3: {
+						embedded_launcher: true,
+					},
+				},
+			}
+		`),
+		android.FixtureAddFile("foo.py", nil),
+	).ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+		`shared_interpreter: can't be combined with embedded_launcher`)).
+		RunTest(t)
+}
+
 func expectModule(t *testing.T, ctx *android.TestContext, name, variant, expectedSrcsZip string, expectedPyRunfiles []string) {
 	module := ctx.ModuleForTests(name, variant)
 
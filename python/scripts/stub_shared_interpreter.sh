@@ -0,0 +1,21 @@
+#!/bin/sh
+# Runs the Python zipapp this script is the prefix of in place, with the host CPython prebuilt
+# shared by the Python binaries of the source tree. The prebuilt is found from ANDROID_BUILD_TOP,
+# from the current directory, or from the directories above the binary, and python3 from the
+# PATH is used if it isn't found.
+
+interp='%interpreter%'
+if [ -n "$ANDROID_BUILD_TOP" ] && [ -x "$ANDROID_BUILD_TOP/$interp" ]; then
+  exec "$ANDROID_BUILD_TOP/$interp" -S "$0" "$@"
+fi
+if [ -x "$interp" ]; then
+  exec "./$interp" -S "$0" "$@"
+fi
+dir=$(cd "$(dirname "$0")" && pwd)
+while [ -n "$dir" ] && [ "$dir" != / ]; do
+  if [ -x "$dir/$interp" ]; then
+    exec "$dir/$interp" -S "$0" "$@"
+  fi
+  dir=$(dirname "$dir")
+done
+exec python3 -S "$0" "$@"
@@ -47,9 +47,37 @@ const (
 	// RemoteLocalFallbackExecStrategy is the exec strategy to indicate that the action should
 	// be run remotely and fallback to local execution if remote fails.
 	RemoteLocalFallbackExecStrategy = "remote_local_fallback"
+
+	// CacheOnlyFlags are the flags making the remote execution wrapper run the actions locally,
+	// while still reading their results from and writing them to the remote cache. They override
+	// the exec strategy of the actions, for the builds that use the remote cache without remote
+	// execution workers.
+	CacheOnlyFlags = "--exec_strategy=local --remote_accept_cache=true --remote_update_cache=true"
 )
 
 var (
+	// defaultExecStrategies are the exec strategies of the action types run with the remote
+	// execution wrapper, keyed by the environment variable that overrides them.
+	defaultExecStrategies = map[string]string{
+		"RBE_CXX_EXEC_STRATEGY":        LocalExecStrategy,
+		"RBE_CXX_LINKS_EXEC_STRATEGY":  LocalExecStrategy,
+		"RBE_CLANG_TIDY_EXEC_STRATEGY": LocalExecStrategy,
+		"RBE_ABI_DUMPER_EXEC_STRATEGY": LocalExecStrategy,
+		"RBE_ABI_LINKER_EXEC_STRATEGY": LocalExecStrategy,
+		"RBE_JAVAC_EXEC_STRATEGY":      RemoteLocalFallbackExecStrategy,
+		"RBE_KOTLINC_EXEC_STRATEGY":    RemoteLocalFallbackExecStrategy,
+		"RBE_TURBINE_EXEC_STRATEGY":    LocalExecStrategy,
+		"RBE_D8_EXEC_STRATEGY":         RemoteLocalFallbackExecStrategy,
+		"RBE_R8_EXEC_STRATEGY":         RemoteLocalFallbackExecStrategy,
+		"RBE_DEX2OAT_EXEC_STRATEGY":    RemoteLocalFallbackExecStrategy,
+		"RBE_METALAVA_EXEC_STRATEGY":   LocalExecStrategy,
+		"RBE_LINT_EXEC_STRATEGY":       LocalExecStrategy,
+		"RBE_SIGNAPK_EXEC_STRATEGY":    LocalExecStrategy,
+		"RBE_JAR_EXEC_STRATEGY":        LocalExecStrategy,
+		"RBE_ZIP_EXEC_STRATEGY":        LocalExecStrategy,
+		"RBE_GENRULE_EXEC_STRATEGY":    RemoteLocalFallbackExecStrategy,
+	}
+
 	defaultLabels               = map[string]string{"type": "tool"}
 	defaultExecStrategy         = LocalExecStrategy
 	defaultEnvironmentVariables = []string{
@@ -60,6 +88,15 @@ var (
 	}
 )
 
+// DefaultExecStrategy returns the exec strategy of the action type whose exec strategy is
+// overridden by the environment variable envVar, e.g. RBE_JAVAC_EXEC_STRATEGY, when it isn't set.
+func DefaultExecStrategy(envVar string) string {
+	if strategy, ok := defaultExecStrategies[envVar]; ok {
+		return strategy
+	}
+	panic("unknown exec strategy environment variable " + envVar)
+}
+
 // REParams holds information pertinent to the remote execution of a rule.
 type REParams struct {
 	// Platform is the key value pair used for remotely executing the action.
@@ -92,16 +129,16 @@ func init() {
 // Template generates the remote execution wrapper template to be added as a prefix to the rule's
 // command.
 func (r *REParams) Template() string {
-	return "${android.RBEWrapper}" + r.wrapperArgs()
+	return "${android.RBEWrapper}" + r.wrapperArgs("${android.RBEWrapperFlags}")
 }
 
 // NoVarTemplate generates the remote execution wrapper template without variables, to be used in
-// RuleBuilder.
-func (r *REParams) NoVarTemplate(wrapper string) string {
-	return wrapper + r.wrapperArgs()
+// RuleBuilder. The flags are added after the flags of the rule, and override them.
+func (r *REParams) NoVarTemplate(wrapper string, flags string) string {
+	return wrapper + r.wrapperArgs(flags)
 }
 
-func (r *REParams) wrapperArgs() string {
+func (r *REParams) wrapperArgs(flags string) string {
 	args := ""
 	var kvs []string
 	labels := r.Labels
@@ -161,5 +198,9 @@ func (r *REParams) wrapperArgs() string {
 		args += " --env_var_allowlist=" + strings.Join(envVarAllowlist, ",")
 	}
 
+	if flags != "" {
+		args += " " + flags
+	}
+
 	return args + " -- "
 }
@@ -36,7 +36,7 @@ func TestTemplate(t *testing.T) {
 					PoolKey:           "default",
 				},
 			},
-			want: fmt.Sprintf("${android.RBEWrapper} --labels=compiler=clang,lang=cpp,type=compile --platform=\"Pool=default,container-image=%s\" --exec_strategy=local --inputs=$in --output_files=$out --env_var_allowlist=LANG,LC_MESSAGES,PYTHONDONTWRITEBYTECODE -- ", DefaultImage),
+			want: fmt.Sprintf("${android.RBEWrapper} --labels=compiler=clang,lang=cpp,type=compile --platform=\"Pool=default,container-image=%s\" --exec_strategy=local --inputs=$in --output_files=$out --env_var_allowlist=LANG,LC_MESSAGES,PYTHONDONTWRITEBYTECODE ${android.RBEWrapperFlags} -- ", DefaultImage),
 		},
 		{
 			name: "all params",
@@ -52,7 +52,7 @@ func TestTemplate(t *testing.T) {
 					PoolKey:           "default",
 				},
 			},
-			want: fmt.Sprintf("${android.RBEWrapper} --labels=compiler=clang,lang=cpp,type=compile --platform=\"Pool=default,container-image=%s\" --exec_strategy=remote --inputs=$in --input_list_paths=$out.rsp,out2.rsp --output_files=$out --toolchain_inputs=clang++ --env_var_allowlist=LANG,LC_MESSAGES,PYTHONDONTWRITEBYTECODE -- ", DefaultImage),
+			want: fmt.Sprintf("${android.RBEWrapper} --labels=compiler=clang,lang=cpp,type=compile --platform=\"Pool=default,container-image=%s\" --exec_strategy=remote --inputs=$in --input_list_paths=$out.rsp,out2.rsp --output_files=$out --toolchain_inputs=clang++ --env_var_allowlist=LANG,LC_MESSAGES,PYTHONDONTWRITEBYTECODE ${android.RBEWrapperFlags} -- ", DefaultImage),
 		},
 	}
 	for _, test := range tests {
@@ -75,9 +75,15 @@ func TestNoVarTemplate(t *testing.T) {
 		},
 	}
 	want := fmt.Sprintf("prebuilts/remoteexecution-client/live/rewrapper --labels=compiler=clang,lang=cpp,type=compile --platform=\"Pool=default,container-image=%s\" --exec_strategy=local --inputs=$in --output_files=$out --env_var_allowlist=LANG,LC_MESSAGES,PYTHONDONTWRITEBYTECODE -- ", DefaultImage)
-	if got := params.NoVarTemplate(DefaultWrapperPath); got != want {
+	if got := params.NoVarTemplate(DefaultWrapperPath, ""); got != want {
 		t.Errorf("NoVarTemplate() returned\n%s\nwant\n%s", got, want)
 	}
+
+	params.ExecStrategy = RemoteExecStrategy
+	want = fmt.Sprintf("prebuilts/remoteexecution-client/live/rewrapper --labels=compiler=clang,lang=cpp,type=compile --platform=\"Pool=default,container-image=%s\" --exec_strategy=remote --inputs=$in --output_files=$out --env_var_allowlist=LANG,LC_MESSAGES,PYTHONDONTWRITEBYTECODE %s -- ", DefaultImage, CacheOnlyFlags)
+	if got := params.NoVarTemplate(DefaultWrapperPath, CacheOnlyFlags); got != want {
+		t.Errorf("NoVarTemplate() with the cache only flags returned\n%s\nwant\n%s", got, want)
+	}
 }
 
 func TestTemplateDeterminism(t *testing.T) {
@@ -90,10 +96,26 @@ func TestTemplateDeterminism(t *testing.T) {
 			PoolKey:           "default",
 		},
 	}
-	want := fmt.Sprintf("${android.RBEWrapper} --labels=compiler=clang,lang=cpp,type=compile --platform=\"Pool=default,container-image=%s\" --exec_strategy=local --inputs=$in --output_files=$out --env_var_allowlist=LANG,LC_MESSAGES,PYTHONDONTWRITEBYTECODE -- ", DefaultImage)
+	want := fmt.Sprintf("${android.RBEWrapper} --labels=compiler=clang,lang=cpp,type=compile --platform=\"Pool=default,container-image=%s\" --exec_strategy=local --inputs=$in --output_files=$out --env_var_allowlist=LANG,LC_MESSAGES,PYTHONDONTWRITEBYTECODE ${android.RBEWrapperFlags} -- ", DefaultImage)
 	for i := 0; i < 1000; i++ {
 		if got := r.Template(); got != want {
 			t.Fatalf("Template() returned\n%s\nwant\n%s", got, want)
 		}
 	}
 }
+
+func TestDefaultExecStrategy(t *testing.T) {
+	if got := DefaultExecStrategy("RBE_JAVAC_EXEC_STRATEGY"); got != RemoteLocalFallbackExecStrategy {
+		t.Errorf("DefaultExecStrategy(RBE_JAVAC_EXEC_STRATEGY) = %q, expected %q", got, RemoteLocalFallbackExecStrategy)
+	}
+	if got := DefaultExecStrategy("RBE_LINT_EXEC_STRATEGY"); got != LocalExecStrategy {
+		t.Errorf("DefaultExecStrategy(RBE_LINT_EXEC_STRATEGY) = %q, expected %q", got, LocalExecStrategy)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected a panic for an unknown environment variable")
+		}
+	}()
+	DefaultExecStrategy("RBE_UNKNOWN_EXEC_STRATEGY")
+}
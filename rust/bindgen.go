@@ -61,10 +61,12 @@ var (
 		"${cc_config.ClangBase}/${bindgenHostPrebuiltTag}/${bindgenClangVersion}/${bindgenClangLibdir}")
 
 	//TODO(ivanlozano) Switch this to RuleBuilder
+	// The depfile lists the headers included by the wrapper header, directly or transitively, so
+	// that the bindings are regenerated when any of them changes.
 	bindgen = pctx.AndroidStaticRule("bindgen",
 		blueprint.RuleParams{
 			Command: "CLANG_PATH=$bindgenClang LIBCLANG_PATH=$bindgenLibClang RUSTFMT=${config.RustBin}/rustfmt " +
-				"$cmd $flags $in -o $out -- -MD -MF $out.d $cflags",
+				"$cmd $flags $in -o $out -- $cflags",
 			CommandDeps: []string{"$cmd"},
 			Deps:        blueprint.DepsGCC,
 			Depfile:     "$out.d",
@@ -244,14 +246,21 @@ func (b *bindgenDecorator) GenerateSource(ctx ModuleContext, deps PathDeps) andr
 	}
 
 	outputFile := android.PathForModuleOut(ctx, b.BaseSourceProvider.getStem(ctx)+".rs")
+	depFile := outputFile.String() + ".d"
 
 	var cmd, cmdDesc string
 	if b.Properties.Custom_bindgen != "" {
 		cmd = ctx.GetDirectDepWithTag(b.Properties.Custom_bindgen, customBindgenDepTag).(*Module).HostToolPath().String()
 		cmdDesc = b.Properties.Custom_bindgen
+		// Custom bindgen binaries pass the clang flags to libclang, which writes the depfile. Its
+		// target has to be the bindings, as the default one is the object file of the wrapper header.
+		cflags = append([]string{"-MD -MF " + depFile + " -MT " + outputFile.String()}, cflags...)
 	} else {
 		cmd = "$bindgenCmd"
 		cmdDesc = "bindgen"
+		// bindgen writes the depfile from the headers that libclang opened while parsing the wrapper
+		// header, with the bindings as its target.
+		bindgenFlags = append(bindgenFlags, "--depfile "+depFile)
 	}
 
 	ctx.Build(pctx, android.BuildParams{
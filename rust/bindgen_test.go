@@ -17,6 +17,8 @@ package rust
 import (
 	"strings"
 	"testing"
+
+	"github.com/google/blueprint"
 )
 
 func TestRustBindgen(t *testing.T) {
@@ -71,6 +73,14 @@ func TestRustBindgen(t *testing.T) {
 	if !strings.Contains(libbindgen.Args["cflags"], "--default-flag") {
 		t.Errorf("rust_bindgen missing cflags defined in cc_defaults: cflags %#v", libbindgen.Args["cflags"])
 	}
+	depFlag := "--depfile out/soong/.intermediates/libbindgen/android_arm64_armv8-a_source/bindings.rs.d"
+	if !strings.Contains(libbindgen.Args["flags"], depFlag) {
+		t.Errorf("missing %q in rust_bindgen rule: flags %#v", depFlag, libbindgen.Args["flags"])
+	}
+	if libbindgen.RuleParams.Deps != blueprint.DepsGCC || libbindgen.RuleParams.Depfile != "$out.d" {
+		t.Errorf("rust_bindgen rule doesn't read the depfile: deps %#v, depfile %#v",
+			libbindgen.RuleParams.Deps, libbindgen.RuleParams.Depfile)
+	}
 }
 
 func TestRustBindgenCustomBindgen(t *testing.T) {
@@ -97,6 +107,16 @@ func TestRustBindgenCustomBindgen(t *testing.T) {
 		t.Errorf("Custom bindgen binary %s not used for libbindgen: rule description %#v", "my_bindgen",
 			libbindgen.Description)
 	}
+
+	// Custom bindgen binaries get the depfile flags of clang instead of the one of bindgen.
+	out := "out/soong/.intermediates/libbindgen/android_arm64_armv8-a_source/bindings.rs"
+	depFlags := "-MD -MF " + out + ".d -MT " + out
+	if !strings.Contains(libbindgen.Args["cflags"], depFlags) {
+		t.Errorf("missing %q in rust_bindgen rule: cflags %#v", depFlags, libbindgen.Args["cflags"])
+	}
+	if strings.Contains(libbindgen.Args["flags"], "--depfile") {
+		t.Errorf("unexpected --depfile in rust_bindgen rule with custom bindgen: flags %#v", libbindgen.Args["flags"])
+	}
 }
 
 func TestRustBindgenStdVersions(t *testing.T) {
@@ -25,7 +25,6 @@ import (
 )
 
 var (
-	_     = pctx.SourcePathVariable("rustcCmd", "${config.RustBin}/rustc")
 	_     = pctx.SourcePathVariable("mkcraterspCmd", "build/soong/scripts/mkcratersp.py")
 	rustc = pctx.AndroidStaticRule("rustc",
 		blueprint.RuleParams{
@@ -42,23 +41,21 @@ var (
 			Deps:    blueprint.DepsGCC,
 			Depfile: "$out.d",
 		},
-		"rustcFlags", "libFlags", "envVars")
+		"rustcCmd", "rustcFlags", "libFlags", "envVars")
 	rustLink = pctx.AndroidStaticRule("rustLink",
 		blueprint.RuleParams{
 			Command: "${config.RustLinker} -o $out ${crtBegin} ${config.RustLinkerArgs} @$in ${linkFlags} ${crtEnd}",
 		},
 		"linkFlags", "crtBegin", "crtEnd")
 
-	_       = pctx.SourcePathVariable("rustdocCmd", "${config.RustBin}/rustdoc")
 	rustdoc = pctx.AndroidStaticRule("rustdoc",
 		blueprint.RuleParams{
 			Command: "$envVars $rustdocCmd $rustdocFlags $in -o $outDir && " +
 				"touch $out",
 			CommandDeps: []string{"$rustdocCmd"},
 		},
-		"rustdocFlags", "outDir", "envVars")
+		"rustdocCmd", "rustdocFlags", "outDir", "envVars")
 
-	_            = pctx.SourcePathVariable("clippyCmd", "${config.RustBin}/clippy-driver")
 	clippyDriver = pctx.AndroidStaticRule("clippy",
 		blueprint.RuleParams{
 			Command: "$envVars $clippyCmd " +
@@ -71,7 +68,7 @@ var (
 			Deps:        blueprint.DepsGCC,
 			Depfile:     "$out.d",
 		},
-		"rustcFlags", "libFlags", "clippyFlags", "envVars")
+		"clippyCmd", "rustcFlags", "libFlags", "clippyFlags", "envVars")
 
 	zip = pctx.AndroidStaticRule("zip",
 		blueprint.RuleParams{
@@ -112,7 +109,7 @@ var (
 			Rspfile:        "${out}.rsp",
 			RspfileContent: "$in",
 		},
-		"rustcFlags", "libFlags", "envVars")
+		"rustcCmd", "rustcFlags", "libFlags", "envVars")
 )
 
 type buildOutput struct {
@@ -317,7 +314,11 @@ func transformSrctoCrate(ctx ModuleContext, main android.Path, deps PathDeps, fl
 		implicits = append(implicits, outputs.Paths()...)
 	}
 
-	envVars = append(envVars, "ANDROID_RUST_VERSION="+config.GetRustVersion(ctx))
+	if version := ctx.RustModule().Properties.RustToolchainVersion; version != "" {
+		envVars = append(envVars, "ANDROID_RUST_VERSION="+version)
+	} else {
+		envVars = append(envVars, "ANDROID_RUST_VERSION="+config.GetRustVersion(ctx))
+	}
 
 	if ctx.RustModule().compiler.CargoEnvCompat() {
 		if _, ok := ctx.RustModule().compiler.(*binaryDecorator); ok {
@@ -341,6 +342,7 @@ func transformSrctoCrate(ctx ModuleContext, main android.Path, deps PathDeps, fl
 			Inputs:      inputs,
 			Implicits:   implicits,
 			Args: map[string]string{
+				"clippyCmd":   rustBin(ctx) + "/clippy-driver",
 				"rustcFlags":  strings.Join(rustcFlags, " "),
 				"libFlags":    strings.Join(libFlags, " "),
 				"clippyFlags": strings.Join(flags.ClippyFlags, " "),
@@ -364,6 +366,7 @@ func transformSrctoCrate(ctx ModuleContext, main android.Path, deps PathDeps, fl
 		Inputs:      inputs,
 		Implicits:   implicits,
 		Args: map[string]string{
+			"rustcCmd":   rustBin(ctx) + "/rustc",
 			"rustcFlags": strings.Join(rustcFlags, " "),
 			"libFlags":   strings.Join(libFlags, " "),
 			"envVars":    strings.Join(envVars, " "),
@@ -395,6 +398,7 @@ func transformSrctoCrate(ctx ModuleContext, main android.Path, deps PathDeps, fl
 			Inputs:      inputs,
 			Implicits:   implicits,
 			Args: map[string]string{
+				"rustcCmd":   rustBin(ctx) + "/rustc",
 				"rustcFlags": strings.Join(rustcFlags, " "),
 				"libFlags":   strings.Join(libFlags, " "),
 				"envVars":    strings.Join(envVars, " "),
@@ -448,6 +452,7 @@ func Rustdoc(ctx ModuleContext, main android.Path, deps PathDeps,
 		Input:       main,
 		Implicit:    ctx.RustModule().UnstrippedOutputFile(),
 		Args: map[string]string{
+			"rustdocCmd":   rustBin(ctx) + "/rustdoc",
 			"rustdocFlags": strings.Join(rustdocFlags, " "),
 			"outDir":       docDir.String(),
 			"envVars":      strings.Join(rustEnvVars(ctx, deps), " "),
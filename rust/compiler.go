@@ -173,6 +173,12 @@ type BaseCompilerProperties struct {
 
 	// If cargo_env_compat is true, sets the CARGO_PKG_VERSION env var to this value.
 	Cargo_pkg_version *string
+
+	// Build with this version of the prebuilt rust toolchain instead of the default one, e.g.
+	// "1.69.0". It must be one of the versions listed in RustAllowedVersions. The rust
+	// dependencies of the module are built again with the same toolchain, so device modules must
+	// link them as rlibs with prefer_rlib: true.
+	Rust_toolchain_version *string
 }
 
 type baseCompiler struct {
@@ -306,6 +312,16 @@ func (compiler *baseCompiler) compilerFlags(ctx ModuleContext, flags Flags) Flag
 		}
 	}
 
+	if version := compiler.rustToolchainVersion(); version != "" {
+		if !android.InList(version, config.RustAllowedVersions) {
+			ctx.PropertyErrorf("rust_toolchain_version", "%q is not one of the allowed rust toolchain versions %q",
+				version, config.RustAllowedVersions)
+		} else if ctx.Device() && (!compiler.preferRlib() || len(compiler.Properties.Dylibs) > 0) {
+			ctx.PropertyErrorf("rust_toolchain_version", "device modules must link their rust dependencies as rlibs "+
+				"with prefer_rlib: true, the dylibs built with other toolchain versions aren't installed")
+		}
+	}
+
 	flags.RustFlags = append(flags.RustFlags, lintFlags)
 	flags.RustFlags = append(flags.RustFlags, compiler.Properties.Flags...)
 	flags.RustFlags = append(flags.RustFlags, "--edition="+compiler.edition())
@@ -349,6 +365,10 @@ func (compiler *baseCompiler) CargoPkgVersion() string {
 	return String(compiler.Properties.Cargo_pkg_version)
 }
 
+func (compiler *baseCompiler) rustToolchainVersion() string {
+	return String(compiler.Properties.Rust_toolchain_version)
+}
+
 func (compiler *baseCompiler) unstrippedOutputFilePath() android.Path {
 	return compiler.unstrippedOutputFile
 }
@@ -337,3 +337,45 @@ func TestManualLinkageRejection(t *testing.T) {
 		}
 	`)
 }
+
+// Test that rust_toolchain_version builds the module and its rust dependencies with the selected
+// toolchain.
+func TestRustToolchainVersion(t *testing.T) {
+	ctx := testRust(t, `
+		rust_binary {
+			name: "fizz-buzz",
+			srcs: ["foo.rs"],
+			rustlibs: ["libfoo"],
+			prefer_rlib: true,
+			rust_toolchain_version: "1.69.0",
+		}
+		rust_library {
+			name: "libfoo",
+			srcs: ["foo.rs"],
+			crate_name: "foo",
+		}`)
+
+	newBin := "${config.RustBase}/${config.HostPrebuiltTag}/1.69.0/bin"
+	fizzBuzz := ctx.ModuleForTests("fizz-buzz", "android_arm64_armv8-a_1.69.0").Rule("rustc")
+	android.AssertStringEquals(t, "fizz-buzz rustcCmd", newBin+"/rustc", fizzBuzz.Args["rustcCmd"])
+	android.AssertStringDoesContain(t, "fizz-buzz envVars", fizzBuzz.Args["envVars"], "ANDROID_RUST_VERSION=1.69.0")
+
+	libfooNew := ctx.ModuleForTests("libfoo", "android_arm64_armv8-a_rlib_rlib-std_1.69.0")
+	android.AssertStringEquals(t, "libfoo 1.69.0 rustcCmd", newBin+"/rustc", libfooNew.Rule("rustc").Args["rustcCmd"])
+	if !libfooNew.Module().(*Module).IsHideFromMake() {
+		t.Errorf("the variant of libfoo built for fizz-buzz should be hidden from make")
+	}
+
+	libfoo := ctx.ModuleForTests("libfoo", "android_arm64_armv8-a_rlib_rlib-std").Rule("rustc")
+	android.AssertStringEquals(t, "libfoo rustcCmd", "${config.RustBin}/rustc", libfoo.Args["rustcCmd"])
+}
+
+func TestRustToolchainVersionNotAllowed(t *testing.T) {
+	testRustError(t, `rust_toolchain_version: "1.0.0" is not one of the allowed rust toolchain versions`, `
+		rust_binary {
+			name: "fizz-buzz",
+			srcs: ["foo.rs"],
+			prefer_rlib: true,
+			rust_toolchain_version: "1.0.0",
+		}`)
+}
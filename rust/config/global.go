@@ -27,7 +27,15 @@ var (
 	RustDefaultVersion = "1.68.0"
 	RustDefaultBase    = "prebuilts/rust/"
 	DefaultEdition     = "2021"
-	Stdlibs            = []string{
+
+	// The versions of the prebuilt rust toolchain in RustDefaultBase that modules can select with
+	// the rust_toolchain_version property, to move a subset of the tree to a new rustc first.
+	RustAllowedVersions = []string{
+		RustDefaultVersion,
+		"1.69.0",
+	}
+
+	Stdlibs = []string{
 		"libstd",
 	}
 
@@ -110,6 +118,12 @@ func init() {
 
 }
 
+// RustBinForVersion returns the bin directory of the prebuilt rust toolchain version, one of
+// RustAllowedVersions, as a ninja string.
+func RustBinForVersion(version string) string {
+	return "${config.RustBase}/${config.HostPrebuiltTag}/" + version + "/bin"
+}
+
 func getRustVersionPctx(ctx android.PackageVarContext) string {
 	return GetRustVersion(ctx)
 }
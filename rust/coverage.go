@@ -23,9 +23,6 @@ import (
 var CovLibraryName = "libprofile-clang-extras"
 var ProfilerBuiltins = "libprofiler_builtins.rust_sysroot"
 
-// Add '%c' to default specifier after we resolve http://b/210012154
-const profileInstrFlag = "-fprofile-instr-generate=/data/misc/trace/clang-%p-%m.profraw"
-
 type coverage struct {
 	Properties cc.CoverageProperties
 
@@ -54,7 +51,8 @@ func (cov *coverage) deps(ctx DepsContext, deps Deps) Deps {
 
 func (cov *coverage) flags(ctx ModuleContext, flags Flags, deps PathDeps) (Flags, PathDeps) {
 
-	if !ctx.DeviceConfig().NativeCoverageEnabled() {
+	// -C instrument-coverage is source-based coverage, which only matches clang coverage builds.
+	if !ctx.DeviceConfig().ClangCoverageEnabled() {
 		return flags, deps
 	}
 
@@ -64,7 +62,7 @@ func (cov *coverage) flags(ctx ModuleContext, flags Flags, deps PathDeps) (Flags
 		flags.RustFlags = append(flags.RustFlags,
 			"-C instrument-coverage", "-g")
 		flags.LinkFlags = append(flags.LinkFlags,
-			profileInstrFlag, "-g", coverage.OutputFile().Path().String(), "-Wl,--wrap,open")
+			cc.ProfileInstrFlag, "-g", coverage.OutputFile().Path().String(), "-Wl,--wrap,open")
 		deps.LibDeps = append(deps.LibDeps, coverage.OutputFile().Path())
 
 		// no_std modules are missing libprofiler_builtins which provides coverage, so we need to add it as a dependency.
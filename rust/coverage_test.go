@@ -18,6 +18,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/google/blueprint/proptools"
+
 	"android/soong/android"
 )
 
@@ -112,3 +114,42 @@ func TestCoverageDeps(t *testing.T) {
 		t.Fatalf("missing expected coverage 'libprofile-clang-extras' dependency in linkFlags: %#v", fizz.Args["linkFlags"])
 	}
 }
+
+func TestCoveragePaths(t *testing.T) {
+	skipTestIfOsNotSupported(t)
+	result := android.GroupFixturePreparers(
+		prepareForRustTest,
+		rustMockedFiles.AddToFixture(),
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.ClangCoverage = proptools.BoolPtr(true)
+			variables.Native_coverage = proptools.BoolPtr(true)
+			variables.NativeCoveragePaths = []string{"foo"}
+		}),
+		android.FixtureAddTextFile("foo/Android.bp", `
+			rust_binary {
+				name: "fizz",
+				srcs: ["fizz.rs"],
+			}`),
+		android.FixtureAddTextFile("bar/Android.bp", `
+			rust_binary {
+				name: "buzz",
+				srcs: ["buzz.rs"],
+			}`),
+		android.MockFS{
+			"foo/fizz.rs": nil,
+			"bar/buzz.rs": nil,
+		}.AddToFixture(),
+	).RunTest(t)
+
+	fizz := result.ModuleForTests("fizz", "android_arm64_armv8-a_cov")
+	android.AssertStringDoesContain(t, "fizz rustc flags", fizz.Rule("rustc").Args["rustcFlags"], "-C instrument-coverage")
+	android.AssertPathRelativeToTopEquals(t, "fizz coverage report object",
+		"out/soong/.intermediates/foo/fizz/android_arm64_armv8-a_cov/unstripped/fizz",
+		fizz.Module().(*Module).CoverageReportObject())
+
+	buzz := result.ModuleForTests("buzz", "android_arm64_armv8-a_cov")
+	android.AssertStringDoesNotContain(t, "buzz rustc flags", buzz.Rule("rustc").Args["rustcFlags"], "-C instrument-coverage")
+	if object := buzz.Module().(*Module).CoverageReportObject(); object != nil {
+		t.Errorf("expected no coverage report object for buzz outside of the coverage paths, got %s", object)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rust
+
+import (
+	"path/filepath"
+
+	"github.com/google/blueprint/proptools"
+
+	"android/soong/android"
+	"android/soong/cc"
+	"android/soong/genrule"
+)
+
+// The rust_cxx_bridge module type builds a Rust library containing a #[cxx::bridge] module, and
+// runs cxxbridge on it to generate the C++ side of the bridge:
+//
+//	rust_cxx_bridge {
+//	    name: "libfoo_bridge",
+//	    crate_name: "foo_bridge",
+//	    srcs: ["src/lib.rs"],
+//	    cxx: {
+//	        header_libs: ["libfoo_headers"],
+//	    },
+//	}
+//
+// Rust modules depend on the bridge through rustlibs, and C++ modules through the static_libs
+// dependency on the generated cc_library_static <name>_cxx, which exports the generated header
+// src/lib.rs.h. The generated C++ code is compiled with ThinLTO, so that it is optimized together
+// with the C++ code calling into the bridge.
+
+var (
+	// The cxxbridge tool generating the C++ side of a bridge.
+	cxxBridgeTool = "cxxbridge"
+
+	// The header library of the rust/cxx.h header included by the generated code.
+	cxxBridgeHeaderLib = "cxx-bridge-header"
+
+	// The Rust library of the cxx crate used by the bridge.
+	cxxLib = "libcxx"
+)
+
+func init() {
+	android.RegisterModuleType("rust_cxx_bridge", RustCxxBridgeFactory)
+}
+
+type cxxBridgeProperties struct {
+	Cxx struct {
+		// The header libraries of the C++ headers included by the bridge.
+		Header_libs []string
+	}
+}
+
+type cxxBridgeDecorator struct {
+	*libraryDecorator
+	Properties cxxBridgeProperties
+}
+
+// rust_cxx_bridge produces an rlib of a Rust library declaring a #[cxx::bridge] module, and a
+// cc_library_static of the generated C++ side of the bridge.
+func RustCxxBridgeFactory() android.Module {
+	module, library := NewRustLibrary(android.HostAndDeviceSupported)
+	library.BuildOnlyRlib()
+
+	bridge := &cxxBridgeDecorator{
+		libraryDecorator: library,
+	}
+	module.compiler = bridge
+	module.AddProperties(&bridge.Properties)
+	android.AddLoadHook(module, func(ctx android.LoadHookContext) { bridge.createCxxModules(ctx, module) })
+
+	return module.Init()
+}
+
+// cxxModuleName returns the name of the cc_library_static of the C++ side of a bridge.
+func cxxModuleName(bridgeName string) string {
+	return bridgeName + "_cxx"
+}
+
+func (bridge *cxxBridgeDecorator) compilerDeps(ctx DepsContext, deps Deps) Deps {
+	deps = bridge.libraryDecorator.compilerDeps(ctx, deps)
+	deps.Rustlibs = append(deps.Rustlibs, cxxLib)
+	// The Rust side calls the generated C++ functions.
+	deps.StaticLibs = append(deps.StaticLibs, cxxModuleName(ctx.ModuleName()))
+	return deps
+}
+
+func (bridge *cxxBridgeDecorator) createCxxModules(ctx android.LoadHookContext, module *Module) {
+	srcs := bridge.baseCompiler.Properties.Srcs
+	if len(srcs) != 1 || android.SrcIsModule(srcs[0]) != "" {
+		ctx.PropertyErrorf("srcs", "expected the single source file of the bridge")
+		return
+	}
+	bridgeSrc := srcs[0]
+	name := ctx.ModuleName()
+	headerName := name + "_cxx_header"
+	sourceName := name + "_cxx_source"
+
+	type genruleProps struct {
+		Name  *string
+		Tools []string
+		Cmd   *string
+		Srcs  []string
+		Out   []string
+	}
+	ctx.CreateModule(genrule.GenRuleFactory, &genruleProps{
+		Name:  proptools.StringPtr(headerName),
+		Tools: []string{cxxBridgeTool},
+		Cmd:   proptools.StringPtr("$(location " + cxxBridgeTool + ") $(in) --header > $(out)"),
+		Srcs:  []string{bridgeSrc},
+		Out:   []string{bridgeSrc + ".h"},
+	})
+	ctx.CreateModule(genrule.GenRuleFactory, &genruleProps{
+		Name:  proptools.StringPtr(sourceName),
+		Tools: []string{cxxBridgeTool},
+		Cmd:   proptools.StringPtr("$(location " + cxxBridgeTool + ") $(in) > $(out)"),
+		Srcs:  []string{bridgeSrc},
+		Out:   []string{filepath.Base(bridgeSrc) + ".cc"},
+	})
+
+	headerLibs := append([]string{cxxBridgeHeaderLib}, bridge.Properties.Cxx.Header_libs...)
+	props := &struct {
+		Name                      *string
+		Host_supported            *bool
+		Srcs                      []string
+		Generated_headers         []string
+		Export_generated_headers  []string
+		Header_libs               []string
+		Export_header_lib_headers []string
+		Vendor_available          *bool
+		Product_available         *bool
+		Apex_available            []string
+		Min_sdk_version           *string
+		Lto                       struct {
+			Thin *bool
+		}
+	}{
+		Name:                      proptools.StringPtr(cxxModuleName(name)),
+		Host_supported:            proptools.BoolPtr(module.HostSupported()),
+		Srcs:                      []string{":" + sourceName},
+		Generated_headers:         []string{headerName},
+		Export_generated_headers:  []string{headerName},
+		Header_libs:               headerLibs,
+		Export_header_lib_headers: headerLibs,
+		Vendor_available:          module.VendorProperties.Vendor_available,
+		Product_available:         module.VendorProperties.Product_available,
+		Apex_available:            module.ApexProperties.Apex_available,
+		Min_sdk_version:           module.Properties.Min_sdk_version,
+	}
+	props.Lto.Thin = proptools.BoolPtr(true)
+	ctx.CreateModule(cc.LibraryStaticFactory, props)
+}
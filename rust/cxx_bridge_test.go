@@ -0,0 +1,80 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rust
+
+import (
+	"strings"
+	"testing"
+
+	"android/soong/android"
+)
+
+func TestRustCxxBridge(t *testing.T) {
+	ctx := testRust(t, `
+		rust_cxx_bridge {
+			name: "libfoo_bridge",
+			crate_name: "foo_bridge",
+			srcs: ["src/bar.rs"],
+			cxx: {
+				header_libs: ["libfoo_headers"],
+			},
+		}
+		rust_binary_host {
+			name: "cxxbridge",
+			srcs: ["foo.rs"],
+		}
+		rust_library {
+			name: "libcxx",
+			crate_name: "cxx",
+			srcs: ["foo.rs"],
+			host_supported: true,
+		}
+		cc_library_headers {
+			name: "cxx-bridge-header",
+			host_supported: true,
+		}
+		cc_library_headers {
+			name: "libfoo_headers",
+			export_include_dirs: ["c_includes"],
+		}
+		cc_binary {
+			name: "foo",
+			srcs: ["foo.c"],
+			static_libs: ["libfoo_bridge_cxx"],
+		}
+	`)
+
+	header := ctx.ModuleForTests("libfoo_bridge_cxx_header", "").Output("src/bar.rs.h")
+	if !strings.Contains(header.RuleParams.Command, "--header") {
+		t.Errorf("expected cxxbridge to generate the header, got %q", header.RuleParams.Command)
+	}
+
+	cxx := ctx.ModuleForTests("libfoo_bridge_cxx", "android_arm64_armv8-a_static")
+	cc := cxx.Rule("cc")
+	android.AssertStringEquals(t, "generated source", "bar.rs.cc", cc.Input.Base())
+	android.AssertStringDoesContain(t, "lto flags", cc.Args["cFlags"], "-flto=thin")
+	android.AssertStringDoesContain(t, "header_libs", cc.Args["cFlags"], "-Ic_includes")
+
+	foo := ctx.ModuleForTests("foo", "android_arm64_armv8-a").Rule("cc")
+	android.AssertStringDoesContain(t, "exported generated header", foo.Args["cFlags"], "libfoo_bridge_cxx_header")
+
+	bridge := ctx.ModuleForTests("libfoo_bridge", "android_arm64_armv8-a_rlib_rlib-std").Module().(*Module)
+	if !android.InList("libcxx", bridge.Properties.AndroidMkRlibs) {
+		t.Errorf("expected the bridge to depend on libcxx, got %q", bridge.Properties.AndroidMkRlibs)
+	}
+	if !android.InList("libfoo_bridge_cxx", bridge.Properties.AndroidMkStaticLibs) {
+		t.Errorf("expected the bridge to link the generated C++ code, got %q", bridge.Properties.AndroidMkStaticLibs)
+	}
+}
@@ -42,6 +42,7 @@ func init() {
 	})
 	android.PostDepsMutators(func(ctx android.RegisterMutatorsContext) {
 		ctx.BottomUp("rust_sanitizers", rustSanitizerRuntimeMutator).Parallel()
+		ctx.Transition("rust_toolchain_version", &toolchainVersionTransitionMutator{})
 	})
 	pctx.Import("android/soong/rust/config")
 	pctx.ImportAs("cc_config", "android/soong/cc/config")
@@ -77,6 +78,10 @@ type BaseProperties struct {
 	// appended before SubName.
 	RustSubName string `blueprint:"mutated"`
 
+	// The version of the prebuilt rust toolchain building this variant, empty for the default one.
+	// Set by the rust_toolchain_version mutator.
+	RustToolchainVersion string `blueprint:"mutated"`
+
 	// Set by imageMutator
 	CoreVariantNeeded          bool     `blueprint:"mutated"`
 	VendorRamdiskVariantNeeded bool     `blueprint:"mutated"`
@@ -478,6 +483,9 @@ type compiler interface {
 	// CargoEnvCompat returns whether Cargo environment variables should be used.
 	CargoEnvCompat() bool
 
+	// rustToolchainVersion returns the value of the Rust_toolchain_version property.
+	rustToolchainVersion() string
+
 	inData() bool
 	install(ctx ModuleContext)
 	relativeInstallPath() string
@@ -571,6 +579,20 @@ func (mod *Module) EnableCoverageIfNeeded() {
 	mod.coverage.Properties.CoverageEnabled = mod.coverage.Properties.NeedCoverageBuild
 }
 
+// CoverageReportObject implements cc.CoverageReportModule, to include the binaries and shared
+// libraries built with coverage in the clang coverage report.
+func (mod *Module) CoverageReportObject() android.Path {
+	if !mod.Device() || mod.coverage == nil || !mod.coverage.Properties.CoverageEnabled {
+		return nil
+	}
+	if !mod.Binary() && !mod.Shared() && !mod.Dylib() {
+		return nil
+	}
+	return mod.UnstrippedOutputFile()
+}
+
+var _ cc.CoverageReportModule = (*Module)(nil)
+
 func defaultsFactory() android.Module {
 	return DefaultsFactory()
 }
@@ -1205,6 +1227,12 @@ func (mod *Module) depsToPaths(ctx android.ModuleContext) PathDeps {
 		}
 		if rustDep, ok := dep.(*Module); ok && !rustDep.CcLibraryInterface() {
 			//Handle Rust Modules
+			if rustDep.Properties.RustToolchainVersion != mod.Properties.RustToolchainVersion {
+				ctx.ModuleErrorf("dependency %q is built with the %q rust toolchain version instead of %q",
+					depName, toolchainVersionName(rustDep.Properties.RustToolchainVersion),
+					toolchainVersionName(mod.Properties.RustToolchainVersion))
+				return
+			}
 			makeLibName := rustMakeLibName(ctx, mod, rustDep, depName+rustDep.Properties.RustSubName)
 
 			switch depTag {
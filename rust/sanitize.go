@@ -33,6 +33,9 @@ type SanitizeProperties struct {
 		Address   *bool `android:"arch_variant"`
 		Hwaddress *bool `android:"arch_variant"`
 
+		// Shadow-call-stack, only available on arm64
+		Scs *bool `android:"arch_variant"`
+
 		// Memory-tagging, only available on arm64
 		// if diag.memtag unset or false, enables async memory tagging
 		Memtag_heap *bool `android:"arch_variant"`
@@ -91,6 +94,10 @@ var hwasanFlags = []string{
 	"-C llvm-args=--hwasan-with-ifunc",
 }
 
+var scsFlags = []string{
+	"-Z sanitizer=shadow-call-stack",
+}
+
 func boolPtr(v bool) *bool {
 	if v {
 		return &v
@@ -159,6 +166,10 @@ func (sanitize *sanitize) begin(ctx BaseModuleContext) {
 			s.Address = proptools.BoolPtr(true)
 		}
 
+		if found, globalSanitizers = android.RemoveFromList("shadow-call-stack", globalSanitizers); found && s.Scs == nil {
+			s.Scs = proptools.BoolPtr(true)
+		}
+
 		if found, globalSanitizers = android.RemoveFromList("fuzzer", globalSanitizers); found && s.Fuzzer == nil {
 			// TODO(b/204776996): HWASan for static Rust binaries isn't supported yet, and fuzzer enables HWAsan
 			if !ctx.RustModule().StaticExecutable() {
@@ -189,11 +200,25 @@ func (sanitize *sanitize) begin(ctx BaseModuleContext) {
 		}
 	}
 
+	// Enable HWASan for all components in the include paths (for Aarch64 only)
+	if s.Hwaddress == nil && ctx.Config().HWASanEnabledForPath(ctx.ModuleDir()) &&
+		ctx.Arch().ArchType == android.Arm64 && ctx.Os().Bionic() {
+		// TODO(b/204776996): HWASan for static Rust binaries isn't supported yet.
+		if !ctx.RustModule().StaticExecutable() {
+			s.Hwaddress = proptools.BoolPtr(true)
+		}
+	}
+
 	// HWASan requires AArch64 hardware feature (top-byte-ignore).
 	if ctx.Arch().ArchType != android.Arm64 || !ctx.Os().Bionic() {
 		s.Hwaddress = nil
 	}
 
+	// SCS is only implemented on AArch64.
+	if ctx.Arch().ArchType != android.Arm64 || !ctx.Os().Bionic() {
+		s.Scs = nil
+	}
+
 	// HWASan ramdisk (which is built from recovery) goes over some bootloader limit.
 	// Keep libc instrumented so that ramdisk / vendor_ramdisk / recovery can run hwasan-instrumented code if necessary.
 	if (ctx.RustModule().InRamdisk() || ctx.RustModule().InVendorRamdisk() || ctx.RustModule().InRecovery()) && !strings.HasPrefix(ctx.ModuleDir(), "bionic/libc") {
@@ -211,7 +236,7 @@ func (sanitize *sanitize) begin(ctx BaseModuleContext) {
 
 	// TODO:(b/178369775)
 	// For now sanitizing is only supported on devices
-	if ctx.Os() == android.Android && (Bool(s.Hwaddress) || Bool(s.Address) || Bool(s.Memtag_heap) || Bool(s.Fuzzer)) {
+	if ctx.Os() == android.Android && (Bool(s.Hwaddress) || Bool(s.Address) || Bool(s.Memtag_heap) || Bool(s.Fuzzer) || Bool(s.Scs)) {
 		sanitize.Properties.SanitizerEnabled = true
 	}
 }
@@ -236,6 +261,9 @@ func (sanitize *sanitize) flags(ctx ModuleContext, flags Flags, deps PathDeps) (
 	} else if Bool(sanitize.Properties.Sanitize.Address) {
 		flags.RustFlags = append(flags.RustFlags, asanFlags...)
 	}
+	if Bool(sanitize.Properties.Sanitize.Scs) {
+		flags.RustFlags = append(flags.RustFlags, scsFlags...)
+	}
 	return flags, deps
 }
 
@@ -317,6 +345,9 @@ func (sanitize *sanitize) SetSanitizer(t cc.SanitizerType, b bool) {
 	case cc.Memtag_heap:
 		sanitize.Properties.Sanitize.Memtag_heap = boolPtr(b)
 		sanitizerSet = true
+	case cc.Scs:
+		sanitize.Properties.Sanitize.Scs = boolPtr(b)
+		sanitizerSet = true
 	default:
 		panic(fmt.Errorf("setting unsupported sanitizerType %d", t))
 	}
@@ -378,18 +409,23 @@ func (sanitize *sanitize) getSanitizerBoolPtr(t cc.SanitizerType) *bool {
 		return sanitize.Properties.Sanitize.Hwaddress
 	case cc.Memtag_heap:
 		return sanitize.Properties.Sanitize.Memtag_heap
+	case cc.Scs:
+		return sanitize.Properties.Sanitize.Scs
 	default:
 		return nil
 	}
 }
 
 func (sanitize *sanitize) AndroidMk(ctx AndroidMkContext, entries *android.AndroidMkEntries) {
-	// Add a suffix for hwasan rlib libraries to allow surfacing both the sanitized and
+	// Add a suffix for hwasan/scs rlib libraries to allow surfacing both the sanitized and
 	// non-sanitized variants to make without a name conflict.
 	if entries.Class == "RLIB_LIBRARIES" || entries.Class == "STATIC_LIBRARIES" {
 		if sanitize.isSanitizerEnabled(cc.Hwasan) {
 			entries.SubName += ".hwasan"
 		}
+		if sanitize.isSanitizerEnabled(cc.Scs) {
+			entries.SubName += ".scs"
+		}
 	}
 }
 
@@ -410,6 +446,8 @@ func (mod *Module) SanitizerSupported(t cc.SanitizerType) bool {
 		return true
 	case cc.Memtag_heap:
 		return true
+	case cc.Scs:
+		return true
 	default:
 		return false
 	}
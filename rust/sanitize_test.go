@@ -363,3 +363,77 @@ func TestSanitizeMemtagHeapWithSanitizeDeviceDiag(t *testing.T) {
 	checkHasMemtagNote(t, ctx.ModuleForTests("unset_test_override_default_disable", variant), Sync)
 	checkHasMemtagNote(t, ctx.ModuleForTests("unset_test_override_default_sync", variant), Sync)
 }
+
+func TestSanitizeDeviceShadowCallStack(t *testing.T) {
+	bp := `
+		rust_binary {
+			name: "foo",
+			srcs: ["foo.rs"],
+			compile_multilib: "both",
+		}
+
+		rust_binary {
+			name: "bar",
+			srcs: ["foo.rs"],
+			sanitize: { scs: false },
+		}
+	`
+	result := android.GroupFixturePreparers(
+		prepareForRustTest,
+		rustMockedFiles.AddToFixture(),
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.SanitizeDevice = []string{"shadow-call-stack"}
+		}),
+	).RunTestWithBp(t, bp)
+
+	foo := result.ModuleForTests("foo", "android_arm64_armv8-a_scs").Rule("rustc")
+	if !strings.Contains(foo.Args["rustcFlags"], "-Z sanitizer=shadow-call-stack") {
+		t.Errorf("Expected 'foo' to enable shadow-call-stack, but found flags %q", foo.Args["rustcFlags"])
+	}
+
+	bar := result.ModuleForTests("bar", "android_arm64_armv8-a").Rule("rustc")
+	if strings.Contains(bar.Args["rustcFlags"], "-Z sanitizer=shadow-call-stack") {
+		t.Errorf("Expected 'bar' not to enable shadow-call-stack, but found flags %q", bar.Args["rustcFlags"])
+	}
+
+	fooArm := result.ModuleForTests("foo", "android_arm_armv7-a-neon").Rule("rustc")
+	if strings.Contains(fooArm.Args["rustcFlags"], "-Z sanitizer=shadow-call-stack") {
+		t.Errorf("Expected shadow-call-stack to be arm64 only, but found flags %q", fooArm.Args["rustcFlags"])
+	}
+}
+
+func TestSanitizeHwasanIncludePaths(t *testing.T) {
+	result := android.GroupFixturePreparers(
+		prepareForRustTest,
+		rustMockedFiles.AddToFixture(),
+		android.FixtureModifyMockFS(func(fs android.MockFS) {
+			fs.Merge(android.MockFS{
+				"hwasan/Android.bp": []byte(`
+					rust_binary {
+						name: "foo",
+						srcs: ["foo.rs"],
+					}
+				`),
+				"other/Android.bp": []byte(`
+					rust_binary {
+						name: "bar",
+						srcs: ["foo.rs"],
+					}
+				`),
+			})
+		}),
+		android.FixtureModifyProductVariables(func(variables android.FixtureProductVariables) {
+			variables.HWASanIncludePaths = []string{"hwasan"}
+		}),
+	).RunTest(t)
+
+	foo := result.ModuleForTests("foo", "android_arm64_armv8-a_hwasan").Rule("rustc")
+	if !strings.Contains(foo.Args["rustcFlags"], "-Z sanitizer=hwaddress") {
+		t.Errorf("Expected 'foo' to enable hwasan, but found flags %q", foo.Args["rustcFlags"])
+	}
+
+	bar := result.ModuleForTests("bar", "android_arm64_armv8-a").Rule("rustc")
+	if strings.Contains(bar.Args["rustcFlags"], "-Z sanitizer=hwaddress") {
+		t.Errorf("Expected 'bar' not to enable hwasan, but found flags %q", bar.Args["rustcFlags"])
+	}
+}
@@ -198,6 +198,7 @@ func registerRequiredBuildComponentsForTest(ctx android.RegistrationContext) {
 	ctx.RegisterSingletonType("kythe_rust_extract", kytheExtractRustFactory)
 	ctx.PostDepsMutators(func(ctx android.RegisterMutatorsContext) {
 		ctx.BottomUp("rust_sanitizers", rustSanitizerRuntimeMutator).Parallel()
+		ctx.Transition("rust_toolchain_version", &toolchainVersionTransitionMutator{})
 	})
 	registerRustSnapshotModules(ctx)
 }
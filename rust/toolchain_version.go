@@ -0,0 +1,88 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rust
+
+import (
+	"path"
+
+	"android/soong/android"
+	"android/soong/rust/config"
+)
+
+// The crates built by different versions of rustc can't be linked together, so a module setting
+// rust_toolchain_version needs all its rust dependencies, including the toolchain libraries, to be
+// built by the same toolchain. The rust_toolchain_version mutator creates a variant of the
+// dependencies for each toolchain version requested by their dependents. The variants built for a
+// dependent aren't installed, the dependents link them statically.
+
+type toolchainVersionTransitionMutator struct{}
+
+var _ android.TransitionMutator = (*toolchainVersionTransitionMutator)(nil)
+
+func (t *toolchainVersionTransitionMutator) Split(ctx android.BaseModuleContext) []string {
+	if mod, ok := ctx.Module().(*Module); ok && mod.compiler != nil {
+		return []string{mod.compiler.rustToolchainVersion()}
+	}
+	return []string{""}
+}
+
+func (t *toolchainVersionTransitionMutator) OutgoingTransition(ctx android.OutgoingTransitionContext, sourceVariation string) string {
+	return sourceVariation
+}
+
+func (t *toolchainVersionTransitionMutator) IncomingTransition(ctx android.IncomingTransitionContext, incomingVariation string) string {
+	mod, ok := ctx.Module().(*Module)
+	if !ok || mod.compiler == nil || mod.IsPrebuilt() {
+		// The prebuilts are only available for the toolchain that built them.
+		return ""
+	}
+	if version := mod.compiler.rustToolchainVersion(); version != "" {
+		return version
+	}
+	return incomingVariation
+}
+
+func (t *toolchainVersionTransitionMutator) Mutate(ctx android.BottomUpMutatorContext, variation string) {
+	mod, ok := ctx.Module().(*Module)
+	if !ok || mod.compiler == nil {
+		return
+	}
+	mod.Properties.RustToolchainVersion = variation
+	if variation != mod.compiler.rustToolchainVersion() {
+		mod.HideFromMake()
+		mod.SkipInstall()
+	}
+	if toolchainLib, ok := mod.compiler.(*toolchainLibraryDecorator); ok && variation != "" {
+		toolchainLib.baseCompiler.Properties.Srcs = []string{
+			path.Join("linux-x86", variation, android.String(toolchainLib.Properties.Toolchain_src)),
+		}
+	}
+}
+
+// rustBin returns the bin directory of the rust toolchain building the module, as a ninja string.
+func rustBin(ctx ModuleContext) string {
+	if version := ctx.RustModule().Properties.RustToolchainVersion; version != "" {
+		return config.RustBinForVersion(version)
+	}
+	return "${config.RustBin}"
+}
+
+// toolchainVersionName returns a version of the rust toolchain for the error messages.
+func toolchainVersionName(version string) string {
+	if version == "" {
+		return "default"
+	}
+	return version
+}
@@ -147,7 +147,23 @@ type TestProperties struct {
 	Per_testcase_directory *bool
 
 	// Test options.
-	Test_options android.CommonTestOptions
+	Test_options TestOptions
+}
+
+// Test option struct.
+type TestOptions struct {
+	android.CommonTestOptions
+
+	// Add ArchModuleController to the auto generated test config, so that the test only runs on
+	// devices whose primary ABI is one of these architectures (for example "arm64", "x86_64").
+	// Only available for device sh_test modules.
+	Arches []string
+
+	// The arguments the auto generated test config runs the test with. When the test has
+	// arguments, data, data_bins or data_libs, the auto generated test config pushes them with the
+	// test to /data/local/tests/unrestricted/<module name>/ on the device, and runs the test from
+	// there. Only available for device sh_test modules.
+	Args []string
 }
 
 type ShBinary struct {
@@ -363,33 +379,6 @@ func (s *ShTest) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 
 	s.data = android.PathsForModuleSrc(ctx, s.testProperties.Data)
 
-	var configs []tradefed.Config
-	if Bool(s.testProperties.Require_root) {
-		configs = append(configs, tradefed.Object{"target_preparer", "com.android.tradefed.targetprep.RootTargetPreparer", nil})
-	} else {
-		options := []tradefed.Option{{Name: "force-root", Value: "false"}}
-		configs = append(configs, tradefed.Object{"target_preparer", "com.android.tradefed.targetprep.RootTargetPreparer", options})
-	}
-	if len(s.testProperties.Data_device_bins) > 0 {
-		moduleName := s.Name()
-		remoteDir := "/data/local/tests/unrestricted/" + moduleName + "/"
-		options := []tradefed.Option{{Name: "cleanup", Value: "true"}}
-		for _, bin := range s.testProperties.Data_device_bins {
-			options = append(options, tradefed.Option{Name: "push-file", Key: bin, Value: remoteDir + bin})
-		}
-		configs = append(configs, tradefed.Object{"target_preparer", "com.android.tradefed.targetprep.PushFilePreparer", options})
-	}
-	s.testConfig = tradefed.AutoGenTestConfig(ctx, tradefed.AutoGenTestConfigOptions{
-		TestConfigProp:         s.testProperties.Test_config,
-		TestConfigTemplateProp: s.testProperties.Test_config_template,
-		TestSuites:             s.testProperties.Test_suites,
-		Config:                 configs,
-		AutoGenConfig:          s.testProperties.Auto_gen_config,
-		OutputFileName:         s.outputFilePath.Base(),
-		DeviceTemplate:         "${ShellTestConfigTemplate}",
-		HostTemplate:           "${ShellTestConfigTemplate}",
-	})
-
 	s.dataModules = make(map[string]android.Path)
 	ctx.VisitDirectDeps(func(dep android.Module) {
 		depTag := ctx.OtherModuleDependencyTag(dep)
@@ -426,6 +415,80 @@ func (s *ShTest) GenerateAndroidBuildActions(ctx android.ModuleContext) {
 			ctx.PropertyErrorf(property, "%q of type %q is not supported", dep.Name(), ctx.OtherModuleType(dep))
 		}
 	})
+
+	s.testConfig = tradefed.AutoGenTestConfig(ctx, tradefed.AutoGenTestConfigOptions{
+		TestConfigProp:         s.testProperties.Test_config,
+		TestConfigTemplateProp: s.testProperties.Test_config_template,
+		TestSuites:             s.testProperties.Test_suites,
+		Config:                 s.tradefedConfigs(ctx),
+		AutoGenConfig:          s.testProperties.Auto_gen_config,
+		OutputFileName:         s.outputFilePath.Base(),
+		DeviceTemplate:         "${ShellTestConfigTemplate}",
+		HostTemplate:           "${ShellTestConfigTemplate}",
+	})
+}
+
+// tradefedConfigs returns the configurations added to the auto generated test config of the test.
+func (s *ShTest) tradefedConfigs(ctx android.ModuleContext) []tradefed.Config {
+	var configs []tradefed.Config
+	if Bool(s.testProperties.Require_root) {
+		configs = append(configs, tradefed.Object{"target_preparer", "com.android.tradefed.targetprep.RootTargetPreparer", nil})
+	} else {
+		options := []tradefed.Option{{Name: "force-root", Value: "false"}}
+		configs = append(configs, tradefed.Object{"target_preparer", "com.android.tradefed.targetprep.RootTargetPreparer", options})
+	}
+	remoteDir := "/data/local/tests/unrestricted/" + s.Name() + "/"
+	if len(s.testProperties.Data_device_bins) > 0 {
+		options := []tradefed.Option{{Name: "cleanup", Value: "true"}}
+		for _, bin := range s.testProperties.Data_device_bins {
+			options = append(options, tradefed.Option{Name: "push-file", Key: bin, Value: remoteDir + bin})
+		}
+		configs = append(configs, tradefed.Object{"target_preparer", "com.android.tradefed.targetprep.PushFilePreparer", options})
+	}
+
+	options := s.testProperties.Test_options
+	if ctx.Host() {
+		if len(options.Arches) > 0 {
+			ctx.PropertyErrorf("test_options.arches", "only available for device modules")
+		}
+		if len(options.Args) > 0 {
+			ctx.PropertyErrorf("test_options.args", "only available for device modules")
+		}
+		return configs
+	}
+
+	if len(options.Arches) > 0 {
+		var archOptions []tradefed.Option
+		for _, arch := range options.Arches {
+			archOptions = append(archOptions, tradefed.Option{Name: "arch", Value: arch})
+		}
+		configs = append(configs, tradefed.Object{"module_controller", "com.android.tradefed.testtype.suite.module.ArchModuleController", archOptions})
+	}
+	for _, arg := range options.Args {
+		// The arguments are written in an XML attribute by a sed command using & as its delimiter.
+		if strings.ContainsAny(arg, `&<>"`) {
+			ctx.PropertyErrorf("test_options.args", "%q must not contain any of &<>\"", arg)
+		}
+	}
+	if len(options.Args) > 0 || len(s.data) > 0 || len(s.dataModules) > 0 {
+		// Push the test next to its data, and run it from there.
+		out := s.outputFilePath.Base()
+		pushOptions := []tradefed.Option{
+			{Name: "cleanup", Value: "true"},
+			{Name: "push-file", Key: out, Value: remoteDir + out},
+		}
+		for _, d := range s.data {
+			pushOptions = append(pushOptions, tradefed.Option{Name: "push-file", Key: d.Rel(), Value: remoteDir + d.Rel()})
+		}
+		for _, relPath := range android.SortedKeys(s.dataModules) {
+			pushOptions = append(pushOptions, tradefed.Option{Name: "push-file", Key: relPath, Value: remoteDir + relPath})
+		}
+		pushOptions = append(pushOptions, tradefed.Option{Name: "post-push", Value: "chmod 755 " + remoteDir + out})
+		configs = append(configs, tradefed.Object{"target_preparer", "com.android.tradefed.targetprep.PushFilePreparer", pushOptions})
+		configs = append(configs, tradefed.Option{Name: "test-command-line", Key: s.Name(),
+			Value: strings.Join(append([]string{remoteDir + out}, options.Args...), " ")})
+	}
+	return configs
 }
 
 func (s *ShTest) InstallInData() bool {
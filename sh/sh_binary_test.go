@@ -255,3 +255,43 @@ func TestShTestHost_dataDeviceModulesAutogenTradefedConfig(t *testing.T) {
 		t.Errorf("foo extraConfings %v does not contain %q", autogen.Args["extraConfigs"], expectedBinAutogenConfig)
 	}
 }
+
+func TestShTest_autogenTradefedConfig(t *testing.T) {
+	result := prepareForShTest.RunTestWithBp(t, `
+		sh_test {
+			name: "foo",
+			src: "test.sh",
+			data: ["testdata/data1"],
+			test_options: {
+				arches: ["arm64"],
+				args: ["--verbose", "testdata/data1"],
+			},
+		}
+	`)
+
+	autogen := result.ModuleForTests("foo", "android_arm64_armv8-a").Rule("autogen")
+	for _, expected := range []string{
+		`<option name="push-file" key="test.sh" value="/data/local/tests/unrestricted/foo/test.sh" />`,
+		`<option name="push-file" key="testdata/data1" value="/data/local/tests/unrestricted/foo/testdata/data1" />`,
+		`<option name="test-command-line" key="foo" value="/data/local/tests/unrestricted/foo/test.sh --verbose testdata/data1" />`,
+		`<object type="module_controller" class="com.android.tradefed.testtype.suite.module.ArchModuleController">`,
+		`<option name="arch" value="arm64" />`,
+	} {
+		android.AssertStringDoesContain(t, "extraConfigs", autogen.Args["extraConfigs"], expected)
+	}
+}
+
+func TestShTestHost_autogenTradefedConfigArgs(t *testing.T) {
+	prepareForShTest.
+		ExtendWithErrorHandler(android.FixtureExpectsAtLeastOneErrorMatchingPattern(
+			`test_options.args: only available for device modules`)).
+		RunTestWithBp(t, `
+			sh_test_host {
+				name: "foo",
+				src: "test.sh",
+				test_options: {
+					args: ["--verbose"],
+				},
+			}
+		`)
+}
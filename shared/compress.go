@@ -0,0 +1,176 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Implements the optional compression of the large output files of soong_build
+// that no build step reads, like the JSON module graph. The ninja files are never
+// compressed, since ninja and the tools that query them read them directly.
+// Compressed files keep their name so that the ninja rules that produce them
+// don't change, and are recognized by their magic number when read back with
+// OpenDecompressed.
+//
+// There is no zstd implementation in the Go standard library, so zstd
+// compression runs the zstd tool at the path given by the caller, usually the
+// prebuilt in prebuilts/build-tools.
+package shared
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// Compression is a compression format of the output files of soong_build.
+type Compression string
+
+const (
+	CompressionNone Compression = ""
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ParseCompression returns the compression format with the given name, "none"
+// or "" for no compression.
+func ParseCompression(name string) (Compression, error) {
+	switch name {
+	case "", "none":
+		return CompressionNone, nil
+	case string(CompressionGzip), string(CompressionZstd):
+		return Compression(name), nil
+	default:
+		return CompressionNone, fmt.Errorf("unknown compression %q, expected one of none, gzip, zstd", name)
+	}
+}
+
+// CreateCompressed creates the file at path and returns a writer that
+// compresses its contents with the given compression. zstd is the path of the
+// zstd tool, only used with CompressionZstd. The file is only complete once the
+// writer is closed.
+func CreateCompressed(path string, compression Compression, zstd string) (io.WriteCloser, error) {
+	if compression == CompressionZstd && zstd == "" {
+		return nil, fmt.Errorf("can't compress %s with zstd, the path of the zstd tool is not set", path)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	switch compression {
+	case CompressionNone:
+		return f, nil
+	case CompressionGzip:
+		return &compressedWriter{WriteCloser: gzip.NewWriter(f), file: f}, nil
+	case CompressionZstd:
+		cmd := exec.Command(zstd, "-q", "-c")
+		cmd.Stdout = f
+		cmd.Stderr = os.Stderr
+		stdin, err := cmd.StdinPipe()
+		if err == nil {
+			err = cmd.Start()
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to run %s to compress %s: %s", zstd, path, err)
+		}
+		return &compressedWriter{WriteCloser: stdin, file: f, cmd: cmd}, nil
+	default:
+		f.Close()
+		return nil, fmt.Errorf("unknown compression %q", compression)
+	}
+}
+
+type compressedWriter struct {
+	io.WriteCloser
+	file *os.File
+	cmd  *exec.Cmd
+}
+
+func (w *compressedWriter) Close() error {
+	err := w.WriteCloser.Close()
+	if w.cmd != nil {
+		if waitErr := w.cmd.Wait(); err == nil {
+			err = waitErr
+		}
+	}
+	if closeErr := w.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// OpenDecompressed opens the file at path and returns a reader of its
+// decompressed contents, whether it was written with CreateCompressed with any
+// compression or is a plain file. zstd is the path of the zstd tool, only used
+// if the file is compressed with zstd.
+func OpenDecompressed(path string, zstd string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(f)
+	magic, _ := r.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to decompress %s: %s", path, err)
+		}
+		return &decompressedReader{Reader: gz, file: f}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		if zstd == "" {
+			f.Close()
+			return nil, fmt.Errorf("%s is compressed with zstd, but the path of the zstd tool is not set", path)
+		}
+		cmd := exec.Command(zstd, "-q", "-d", "-c")
+		cmd.Stdin = r
+		cmd.Stderr = os.Stderr
+		stdout, err := cmd.StdoutPipe()
+		if err == nil {
+			err = cmd.Start()
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to run %s to decompress %s: %s", zstd, path, err)
+		}
+		return &decompressedReader{Reader: stdout, file: f, cmd: cmd}, nil
+	default:
+		return &decompressedReader{Reader: r, file: f}, nil
+	}
+}
+
+type decompressedReader struct {
+	io.Reader
+	file *os.File
+	cmd  *exec.Cmd
+}
+
+func (r *decompressedReader) Close() error {
+	var err error
+	if r.cmd != nil {
+		err = r.cmd.Wait()
+	}
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shared
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompressedRoundTrip(t *testing.T) {
+	contents := `{"Name": "libfoo", "Deps": []}`
+	for _, compression := range []Compression{CompressionNone, CompressionGzip, CompressionZstd} {
+		t.Run(string(compression), func(t *testing.T) {
+			zstd, err := exec.LookPath("zstd")
+			if err != nil && compression == CompressionZstd {
+				t.Skip("zstd is not available")
+			}
+			path := filepath.Join(t.TempDir(), "module-graph.json")
+
+			w, err := CreateCompressed(path, compression, zstd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := io.WriteString(w, contents); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if compressed := string(raw) != contents; compressed != (compression != CompressionNone) {
+				t.Errorf("expected compressed to be %t for compression %q", !compressed, compression)
+			}
+
+			r, err := OpenDecompressed(path, zstd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != contents {
+				t.Errorf("expected %q, got %q", contents, string(got))
+			}
+		})
+	}
+}
+
+func TestZstdWithoutTool(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "module-graph.json")
+	if _, err := CreateCompressed(path, CompressionZstd, ""); err == nil {
+		t.Errorf("expected an error compressing with zstd without the zstd tool")
+	}
+
+	if err := os.WriteFile(path, append(zstdMagic, 0), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenDecompressed(path, ""); err == nil {
+		t.Errorf("expected an error decompressing a zstd file without the zstd tool")
+	}
+}
+
+func TestParseCompression(t *testing.T) {
+	for name, expected := range map[string]Compression{
+		"":     CompressionNone,
+		"none": CompressionNone,
+		"gzip": CompressionGzip,
+		"zstd": CompressionZstd,
+	} {
+		if got, err := ParseCompression(name); err != nil || got != expected {
+			t.Errorf("ParseCompression(%q) = %q, %v, expected %q", name, got, err, expected)
+		}
+	}
+	if _, err := ParseCompression("xz"); err == nil {
+		t.Errorf("expected an error for unknown compression xz")
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"io"
 	"os"
 	"path"
+	"regexp"
+	"strings"
 	"sync"
 
 	"android/soong/bazel"
@@ -252,6 +254,8 @@ func (m *syspropLibrary) GenerateAndroidBuildActions(ctx android.ModuleContext)
 		return
 	}
 
+	m.setSyspropDefinitions(ctx)
+
 	apiDirectoryPath := path.Join(ctx.ModuleDir(), "api")
 	currentApiFilePath := path.Join(apiDirectoryPath, baseModuleName+"-current.txt")
 	latestApiFilePath := path.Join(apiDirectoryPath, baseModuleName+"-latest.txt")
@@ -369,6 +373,69 @@ func (m *syspropLibrary) ShouldSupportSdkVersion(ctx android.BaseModuleContext,
 // `lib`, and it is this module that should be depended on from other C++
 // modules; i.e., if the sysprop_library module is named `foo`, C++ modules
 // should depend on `libfoo`.
+var (
+	syspropPropNameRegexp = regexp.MustCompile(`^prop_name\s*:\s*"([^"]*)"`)
+	syspropTypeRegexp     = regexp.MustCompile(`^type\s*:\s*(\w+)`)
+)
+
+// parseSyspropFile returns the names and types of the properties declared by the prop blocks of
+// a .sysprop file, in the text format of the sysprop protobuf.
+func parseSyspropFile(contents string) (names, types []string) {
+	inProp := false
+	var name, typ string
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "prop") && strings.HasSuffix(line, "{"):
+			inProp = true
+			name, typ = "", ""
+		case inProp && line == "}":
+			// Properties without a prop_name are named after their api_name by the
+			// sysprop generators, they can't be set by other modules.
+			if name != "" && typ != "" {
+				names = append(names, name)
+				types = append(types, typ)
+			}
+			inProp = false
+		case inProp:
+			if match := syspropPropNameRegexp.FindStringSubmatch(line); match != nil {
+				name = match[1]
+			} else if match := syspropTypeRegexp.FindStringSubmatch(line); match != nil {
+				typ = match[1]
+			}
+		}
+	}
+	return names, types
+}
+
+// setSyspropDefinitions provides the properties declared by the module to the sysprop_conflicts
+// singleton. Generated .sysprop files are skipped.
+func (m *syspropLibrary) setSyspropDefinitions(ctx android.ModuleContext) {
+	var defs []android.SyspropDefinition
+	for _, syspropFile := range android.PathsForModuleSrc(ctx, m.properties.Srcs) {
+		if _, ok := syspropFile.(android.SourcePath); !ok {
+			continue
+		}
+		contents, err := ctx.Config().ReadSourceFile(ctx, syspropFile.String())
+		if err != nil {
+			ctx.PropertyErrorf("srcs", "cannot read %s: %s", syspropFile, err)
+			continue
+		}
+		names, types := parseSyspropFile(string(contents))
+		for i := range names {
+			defs = append(defs, android.SyspropDefinition{
+				Name:      names[i],
+				Type:      types[i],
+				Owner:     m.properties.Property_owner,
+				Partition: m.PartitionTag(ctx.DeviceConfig()),
+				Module:    m.BaseModuleName(),
+				Source:    syspropFile.String(),
+			})
+		}
+	}
+	ctx.SetProvider(android.SyspropDefinitionsProvider, android.SyspropDefinitionsInfo{Definitions: defs})
+}
+
 func syspropLibraryFactory() android.Module {
 	m := &syspropLibrary{}
 
@@ -368,3 +368,33 @@ func TestMinSdkVersionIsForwarded(t *testing.T) {
 	propFromJava := javaModule.MinSdkVersionString()
 	android.AssertStringEquals(t, "min_sdk_version forwarding to java module", "30", propFromJava)
 }
+
+func TestParseSyspropFile(t *testing.T) {
+	names, types := parseSyspropFile(`
+owner: Platform
+module: "android.sysprop.PlatformProperties"
+prop {
+    api_name: "test_double"
+    type: Double
+    prop_name: "android.test_double"
+    scope: Internal
+    access: ReadWrite
+}
+prop {
+    api_name: "test_enum"
+    type: Enum
+    enum_values: "a|b|c"
+    scope: Public
+    access: Readonly
+}
+prop {
+    api_name: "test_list_int"
+    type: IntegerList
+    prop_name: "ro.android.test_list_int"
+    scope: Public
+    access: Readonly
+}
+`)
+	android.AssertDeepEquals(t, "names", []string{"android.test_double", "ro.android.test_list_int"}, names)
+	android.AssertDeepEquals(t, "types", []string{"Double", "IntegerList"}, types)
+}
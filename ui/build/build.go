@@ -330,6 +330,9 @@ func Build(ctx Context, config Config) {
 			installCleanIfNecessary(ctx, config)
 		}
 		runNinjaForBuild(ctx, config)
+		if config.BuildBudgets() != "" {
+			runBuildBudgets(ctx, config)
+		}
 	}
 
 	if what&RunDistActions != 0 {
@@ -413,7 +416,7 @@ func distGzipFile(ctx Context, config Config, src string, subDirs ...string) {
 	distWaitGroup.Add(1)
 	go func() {
 		defer distWaitGroup.Done()
-		if err := gzipFileToDir(src, destDir); err != nil {
+		if err := gzipFileToDir(src, destDir, config.PrebuiltBuildTool("zstd")); err != nil {
 			ctx.Printf("failed to dist %s: %s", filepath.Base(src), err.Error())
 		}
 	}()
@@ -446,4 +449,7 @@ func distFile(ctx Context, config Config, src string, subDirs ...string) {
 // Be careful, anything added here slows down EVERY CI build
 func runDistActions(ctx Context, config Config) {
 	runStagingSnapshot(ctx, config)
+	if config.DistDelta() {
+		runDistDelta(ctx, config)
+	}
 }
@@ -0,0 +1,254 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"android/soong/shared"
+)
+
+const (
+	// The file that declares the build time budgets of the project in its directory.
+	buildBudgetFileName = "BUILD_BUDGET.json"
+
+	// The build times of the projects with budgets, recorded in the Soong output directory for
+	// the regression checks of the next build and dist'ed.
+	buildBudgetReportFileName = "build_budget_report.json"
+
+	// The time soong_build spent generating the build actions of each directory, written by
+	// soong_build when it is run with --analysis-times-report.
+	analysisTimesFileName = "analysis_times.json"
+)
+
+// buildBudget is the content of a BUILD_BUDGET.json file. The budgets cover the modules in the
+// directory of the file and its subdirectories, and are ignored when 0.
+type buildBudget struct {
+	// Maximum time soong_build may spend generating the build actions of the modules.
+	AnalysisMs int64 `json:"analysis_ms"`
+
+	// Maximum total time of the Soong build actions of the modules, from the ninja log.
+	CompileMs int64 `json:"compile_ms"`
+
+	// Maximum growth of each of the times since the previous build, in percent.
+	MaxRegressionPercent int64 `json:"max_regression_percent"`
+}
+
+// buildTimes are the measured build times of a project.
+type buildTimes struct {
+	AnalysisMs int64 `json:"analysis_ms"`
+	CompileMs  int64 `json:"compile_ms"`
+}
+
+// readBuildBudgets reads the BUILD_BUDGET.json files in the list file written by FindSources,
+// and returns their budgets by the directory they are in.
+func readBuildBudgets(listFile string) (map[string]buildBudget, error) {
+	data, err := os.ReadFile(listFile)
+	if err != nil {
+		return nil, err
+	}
+	budgets := make(map[string]buildBudget)
+	for _, file := range strings.Fields(string(data)) {
+		buf, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var budget buildBudget
+		if err := json.Unmarshal(buf, &budget); err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err)
+		}
+		budgets[filepath.Dir(filepath.Clean(file))] = budget
+	}
+	return budgets, nil
+}
+
+// inProject returns true if dir, relative to the top of the tree, is the directory of project
+// or one of its subdirectories.
+func inProject(dir, project string) bool {
+	return project == "." || dir == project || strings.HasPrefix(dir, project+"/")
+}
+
+// parseNinjaLogTimes returns the duration in milliseconds of each build action in a ninja log,
+// by its first output. Actions with several outputs are logged once per output, and rebuilt
+// outputs are logged again with their new duration.
+func parseNinjaLogTimes(data string) map[string]int64 {
+	type action struct {
+		start, end, cmdHash string
+	}
+	firstOutputs := make(map[action]string)
+	times := make(map[string]int64)
+	// ninja log: <start>	<end>	<restat>	<name>	<cmdhash>
+	for _, line := range strings.Split(data, "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 5 {
+			continue
+		}
+		start, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		end, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		a := action{fields[0], fields[1], fields[4]}
+		if _, ok := firstOutputs[a]; ok {
+			continue
+		}
+		firstOutputs[a] = fields[3]
+		times[fields[3]] = end - start
+	}
+	return times
+}
+
+// measureBuildTimes returns the build times of each project, from the analysis times of the
+// directories and the durations of the build actions by their outputs. Build actions are
+// attributed to the projects by their outputs in the intermediates directory of Soong.
+func measureBuildTimes(projects []string, analysisTimes map[string]int64, actionTimes map[string]int64,
+	soongOutDir string) map[string]buildTimes {
+
+	intermediates := filepath.Join(soongOutDir, ".intermediates") + "/"
+	result := make(map[string]buildTimes)
+	for _, project := range projects {
+		var times buildTimes
+		for dir, ms := range analysisTimes {
+			if inProject(dir, project) {
+				times.AnalysisMs += ms
+			}
+		}
+		for output, ms := range actionTimes {
+			if dir := strings.TrimPrefix(output, intermediates); dir != output && inProject(dir, project) {
+				times.CompileMs += ms
+			}
+		}
+		result[project] = times
+	}
+	return result
+}
+
+// checkBuildBudgets returns a message for each budget exceeded by the current build times, and
+// for each time that grew more than allowed since the previous build times.
+func checkBuildBudgets(budgets map[string]buildBudget, current, previous map[string]buildTimes) []string {
+	var violations []string
+	check := func(project, kind string, ms, budgetMs, previousMs, maxRegressionPercent int64) {
+		if budgetMs > 0 && ms > budgetMs {
+			violations = append(violations, fmt.Sprintf("%s: %s time of %dms exceeds its budget of %dms",
+				project, kind, ms, budgetMs))
+		}
+		if maxRegressionPercent > 0 && previousMs > 0 && (ms-previousMs)*100 > previousMs*maxRegressionPercent {
+			violations = append(violations, fmt.Sprintf("%s: %s time regressed by %d%% from %dms to %dms, more than %d%%",
+				project, kind, (ms-previousMs)*100/previousMs, previousMs, ms, maxRegressionPercent))
+		}
+	}
+
+	projects := make([]string, 0, len(budgets))
+	for project := range budgets {
+		projects = append(projects, project)
+	}
+	sort.Strings(projects)
+	for _, project := range projects {
+		budget := budgets[project]
+		times, prev := current[project], previous[project]
+		check(project, "analysis", times.AnalysisMs, budget.AnalysisMs, prev.AnalysisMs, budget.MaxRegressionPercent)
+		check(project, "compile", times.CompileMs, budget.CompileMs, prev.CompileMs, budget.MaxRegressionPercent)
+	}
+	return violations
+}
+
+// readJsonFile reads the JSON file into v, and leaves v unchanged if the file doesn't exist.
+func readJsonFile(filename string, v interface{}) error {
+	buf, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, v)
+}
+
+// Check the build times of the projects that declare budgets in a BUILD_BUDGET.json file against
+// their budgets, and against the build times of the previous build. The previous build times are
+// read from BUILD_BUDGET_BASE_REPORT if it is set, which lets CI compare against the last build
+// of the branch, and from out/soong otherwise.
+func runBuildBudgets(ctx Context, config Config) {
+	ctx.BeginTrace("soong_ui", "build_budgets")
+	defer ctx.EndTrace()
+
+	mode := config.BuildBudgets()
+	if mode != "warn" && mode != "enforce" {
+		ctx.Fatalf("BUILD_BUDGETS must be warn or enforce, not %q", mode)
+	}
+
+	budgets, err := readBuildBudgets(filepath.Join(config.FileListDir(), buildBudgetFileName+".list"))
+	if err != nil {
+		ctx.Fatalf("failed to read build budgets: %s", err)
+	}
+	if len(budgets) == 0 {
+		return
+	}
+	projects := make([]string, 0, len(budgets))
+	for project := range budgets {
+		projects = append(projects, project)
+	}
+
+	analysisTimes := make(map[string]int64)
+	if err := readJsonFile(shared.JoinPath(config.SoongOutDir(), analysisTimesFileName), &analysisTimes); err != nil {
+		ctx.Fatalf("failed to read analysis times: %s", err)
+	}
+	ninjaLog, err := os.ReadFile(filepath.Join(config.OutDir(), ninjaLogFileName))
+	if err != nil && !os.IsNotExist(err) {
+		ctx.Fatalf("failed to read ninja log: %s", err)
+	}
+	current := measureBuildTimes(projects, analysisTimes, parseNinjaLogTimes(string(ninjaLog)), config.SoongOutDir())
+
+	reportFile := shared.JoinPath(config.SoongOutDir(), buildBudgetReportFileName)
+	previous := make(map[string]buildTimes)
+	if base, ok := config.Environment().Get("BUILD_BUDGET_BASE_REPORT"); ok && base != "" {
+		// Unlike the report of the previous build, an explicit base report must exist, or the
+		// regression checks would silently be skipped.
+		if buf, err := os.ReadFile(base); err != nil {
+			ctx.Fatalf("failed to read BUILD_BUDGET_BASE_REPORT: %s", err)
+		} else if err := json.Unmarshal(buf, &previous); err != nil {
+			ctx.Fatalf("failed to read BUILD_BUDGET_BASE_REPORT %s: %s", base, err)
+		}
+	} else if err := readJsonFile(reportFile, &previous); err != nil {
+		ctx.Fatalf("failed to read previous build times: %s", err)
+	}
+
+	if err := writeJson(reportFile, current); err != nil {
+		ctx.Fatalf("failed to write build budget report: %s", err)
+	}
+	distFile(ctx, config, reportFile)
+
+	violations := checkBuildBudgets(budgets, current, previous)
+	if len(violations) == 0 {
+		return
+	}
+	if mode == "enforce" {
+		ctx.Fatalf("build budgets exceeded:\n  %s", strings.Join(violations, "\n  "))
+	}
+	for _, violation := range violations {
+		ctx.Println("warning: build budget exceeded:", violation)
+	}
+}
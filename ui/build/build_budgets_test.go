@@ -0,0 +1,77 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"testing"
+)
+
+// Make sure each action is counted once, with its latest duration
+func TestParseNinjaLogTimes(t *testing.T) {
+	log := "# ninja log v5\n" +
+		"0\t100\t0\tout/soong/.intermediates/a/liba/obj/a.o\t1111\n" +
+		"0\t100\t0\tout/soong/.intermediates/a/liba/obj/a.d\t1111\n" +
+		"100\t400\t0\tout/soong/.intermediates/b/libb/obj/b.o\t2222\n" +
+		"500\t550\t0\tout/soong/.intermediates/a/liba/obj/a.o\t3333\n"
+
+	assertDeepEqual(t, map[string]int64{
+		"out/soong/.intermediates/a/liba/obj/a.o": 50,
+		"out/soong/.intermediates/b/libb/obj/b.o": 300,
+	}, parseNinjaLogTimes(log))
+}
+
+func TestMeasureBuildTimes(t *testing.T) {
+	analysisTimes := map[string]int64{
+		"vendor/foo":     10,
+		"vendor/foo/bar": 20,
+		"vendor/foobar":  40,
+	}
+	actionTimes := map[string]int64{
+		"out/soong/.intermediates/vendor/foo/libfoo/obj/foo.o":     100,
+		"out/soong/.intermediates/vendor/foo/bar/libbar/obj/bar.o": 200,
+		"out/soong/.intermediates/vendor/foobar/libx/obj/x.o":      400,
+		"out/target/product/device/obj/vendor/foo/foo.o":           800,
+	}
+
+	assertDeepEqual(t, map[string]buildTimes{
+		"vendor/foo": {AnalysisMs: 30, CompileMs: 300},
+		".":          {AnalysisMs: 70, CompileMs: 700},
+	}, measureBuildTimes([]string{"vendor/foo", "."}, analysisTimes, actionTimes, "out/soong"))
+}
+
+func TestCheckBuildBudgets(t *testing.T) {
+	budgets := map[string]buildBudget{
+		"vendor/foo": {AnalysisMs: 100, CompileMs: 1000, MaxRegressionPercent: 20},
+		"vendor/bar": {CompileMs: 1000},
+	}
+	current := map[string]buildTimes{
+		"vendor/foo": {AnalysisMs: 150, CompileMs: 900},
+		"vendor/bar": {AnalysisMs: 150, CompileMs: 900},
+	}
+	previous := map[string]buildTimes{
+		"vendor/foo": {AnalysisMs: 140, CompileMs: 600},
+		"vendor/bar": {AnalysisMs: 10, CompileMs: 100},
+	}
+
+	assertDeepEqual(t, []string{
+		"vendor/foo: analysis time of 150ms exceeds its budget of 100ms",
+		"vendor/foo: compile time regressed by 50% from 600ms to 900ms, more than 20%",
+	}, checkBuildBudgets(budgets, current, previous))
+
+	// Without previous build times, only the budgets are checked.
+	assertDeepEqual(t, []string{
+		"vendor/foo: analysis time of 150ms exceeds its budget of 100ms",
+	}, checkBuildBudgets(budgets, current, nil))
+}
@@ -61,6 +61,7 @@ type configImpl struct {
 	// (bp2build, json-module-graph) are not here and have their own bits below.
 	arguments     []string
 	goma          bool
+	gomaShim      bool
 	environ       *Environment
 	distDir       string
 	buildDateTime string
@@ -424,8 +425,7 @@ func NewConfig(ctx Context, args ...string) Config {
 	)
 
 	if ret.UseGoma() || ret.ForceUseGoma() {
-		ctx.Println("Goma for Android has been deprecated and replaced with RBE. See go/rbe_for_android for instructions on how to use RBE.")
-		ctx.Fatalln("USE_GOMA / FORCE_USE_GOMA flag is no longer supported.")
+		ret.mapGomaToRBE(ctx)
 	}
 
 	// Tell python not to spam the source tree with .pyc files.
@@ -474,6 +474,7 @@ func NewConfig(ctx Context, args ...string) Config {
 	java9Home := filepath.Join("prebuilts/jdk/jdk9", ret.HostPrebuiltTag())
 	java11Home := filepath.Join("prebuilts/jdk/jdk11", ret.HostPrebuiltTag())
 	java17Home := filepath.Join("prebuilts/jdk/jdk17", ret.HostPrebuiltTag())
+	java21Home := filepath.Join("prebuilts/jdk/jdk21", ret.HostPrebuiltTag())
 	javaHome := func() string {
 		if override, ok := ret.environ.Get("OVERRIDE_ANDROID_JAVA_HOME"); ok {
 			return override
@@ -484,6 +485,9 @@ func NewConfig(ctx Context, args ...string) Config {
 		if toolchain17, ok := ret.environ.Get("EXPERIMENTAL_USE_OPENJDK17_TOOLCHAIN"); ok && toolchain17 != "true" {
 			ctx.Fatalln("The environment variable EXPERIMENTAL_USE_OPENJDK17_TOOLCHAIN is no longer supported. An OpenJDK 17 toolchain is now the global default.")
 		}
+		if ret.environ.IsEnvTrue("EXPERIMENTAL_USE_OPENJDK21_TOOLCHAIN") {
+			return java21Home
+		}
 		return java17Home
 	}()
 	absJavaHome := absPath(ctx, javaHome)
@@ -585,6 +589,10 @@ func buildConfig(config Config) *smpb.BuildConfig {
 		NinjaWeightListSource:       getNinjaWeightListSourceInMetric(config.NinjaWeightListSource()),
 	}
 	c.Targets = append(c.Targets, config.arguments...)
+	if config.gomaShim {
+		c.GomaShim = proto.Bool(true)
+		c.RbeLocalActionTypes = rbeLocalActionTypes(config)
+	}
 
 	return c
 }
@@ -1081,6 +1089,9 @@ func (c *configImpl) PrebuiltOS() string {
 	case "linux":
 		return "linux-x86"
 	case "darwin":
+		if runtime.GOARCH == "arm64" {
+			return "darwin-arm64"
+		}
 		return "darwin-x86"
 	default:
 		panic("Unknown GOOS")
@@ -1156,6 +1167,82 @@ func (c *configImpl) Dist() bool {
 	return c.dist
 }
 
+// DistDelta returns true if the dist actions should record which dist artifacts
+// changed since the previous dist manifest.
+func (c *configImpl) DistDelta() bool {
+	return c.Environment().IsEnvTrue("DIST_DELTA")
+}
+
+// ModuleEnvDepsReport returns true if soong_build should report which modules read each
+// environment variable, in out/soong/module_env_deps.json.
+func (c *configImpl) ModuleEnvDepsReport() bool {
+	return c.Environment().IsEnvTrue("SOONG_MODULE_ENV_DEPS_REPORT")
+}
+
+// ProductVariableDepsReport returns true if soong_build should report which modules read each
+// product variable, in out/soong/product_variable_deps.json.
+func (c *configImpl) ProductVariableDepsReport() bool {
+	return c.Environment().IsEnvTrue("SOONG_PRODUCT_VARIABLE_DEPS_REPORT")
+}
+
+// PathToolsAudit returns true if soong_build should report the tools that the commands of each
+// module run from the PATH, in out/soong/path_tools_audit.json.
+func (c *configImpl) PathToolsAudit() bool {
+	return c.Environment().IsEnvTrue("SOONG_PATH_TOOLS_AUDIT")
+}
+
+// BuildBudgets returns "warn" or "enforce" if the build times of the projects should be checked
+// against the budgets in their BUILD_BUDGET.json files, or "" otherwise. Exceeded budgets only
+// print warnings in the "warn" mode, and fail the build in the "enforce" mode.
+func (c *configImpl) BuildBudgets() string {
+	if mode, ok := c.Environment().Get("BUILD_BUDGETS"); ok {
+		return mode
+	}
+	return ""
+}
+
+// SerializeMutators returns true if soong_build should run mutators on one module at a time, for
+// debugging interactions between mutators.
+func (c *configImpl) SerializeMutators() bool {
+	return c.Environment().IsEnvTrue("SOONG_SERIALIZE_MUTATORS")
+}
+
+// MutatorCheckpoints returns the comma-delimited names of the mutators after which soong_build
+// should dump the state of the modules to out/soong/mutator_checkpoints.
+func (c *configImpl) MutatorCheckpoints() string {
+	v, _ := c.Environment().Get("SOONG_MUTATOR_CHECKPOINTS")
+	return v
+}
+
+// OutputCompression returns the compression of the JSON module graph and actions of soong_build:
+// "gzip", "zstd" or "" for none. The outputs keep their names when compressed. The ninja files are
+// never compressed, since ninja reads them.
+func (c *configImpl) OutputCompression() string {
+	v, _ := c.Environment().Get("SOONG_OUTPUT_COMPRESSION")
+	return v
+}
+
+// SecretEnvVars returns the names of the environment variables that hold secrets, e.g. the
+// tokens used by remote signing actions, as declared in BUILD_SECRET_ENV_VARS. Their values are
+// redacted from logs, metrics and error messages, and must not appear in any command line
+// written to the ninja files.
+func (c *configImpl) SecretEnvVars() []string {
+	v, _ := c.Environment().Get("BUILD_SECRET_ENV_VARS")
+	return strings.Fields(v)
+}
+
+// SecretEnvValues returns the values of the environment variables returned by SecretEnvVars
+// that are set, keyed by name.
+func (c *configImpl) SecretEnvValues() map[string]string {
+	values := make(map[string]string)
+	for _, name := range c.SecretEnvVars() {
+		if value, ok := c.Environment().Get(name); ok && value != "" {
+			values[name] = value
+		}
+	}
+	return values
+}
+
 func (c *configImpl) JsonModuleGraph() bool {
 	return c.jsonModuleGraph
 }
@@ -1602,6 +1689,8 @@ func (c *configImpl) hostCrossOut() string {
 func (c *configImpl) HostPrebuiltTag() string {
 	if runtime.GOOS == "linux" {
 		return "linux-x86"
+	} else if runtime.GOOS == "darwin" && runtime.GOARCH == "arm64" {
+		return "darwin-arm64"
 	} else if runtime.GOOS == "darwin" {
 		return "darwin-x86"
 	} else {
@@ -0,0 +1,181 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"android/soong/shared"
+	"android/soong/ui/metrics"
+)
+
+const (
+	// The manifest of every file in the dist directory, including their hashes.
+	distManifestFileName = "dist_manifest.json"
+
+	// The list of dist files that were added, changed or removed since the previous
+	// dist manifest.
+	distDeltaFileName = "dist_delta.json"
+
+	// A zip of the dist files that were added or changed since the previous dist
+	// manifest.
+	distDeltaZipFileName = "dist_delta.zip"
+)
+
+// Files in the dist directory that are never part of the delta.
+var distDeltaExcludes = []string{
+	distManifestFileName,
+	distDeltaFileName,
+	distDeltaZipFileName,
+	"logs/",
+	"soong_ui/",
+}
+
+func isDistDeltaExcluded(name string) bool {
+	for _, exclude := range distDeltaExcludes {
+		if name == exclude || (strings.HasSuffix(exclude, "/") && strings.HasPrefix(name, exclude)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Return an array of fileEntrys, one for each file in the dist directory that can be
+// part of the delta.
+func takeDistSnapshot(ctx Context, distDir string) ([]fileEntry, error) {
+	all, err := takeStagingSnapshot(ctx, distDir, []string{""})
+	result := []fileEntry{}
+	for _, entry := range all {
+		if !isDistDeltaExcluded(entry.Name) {
+			result = append(result, entry)
+		}
+	}
+	return result, err
+}
+
+// Write the files in names, relative to distDir, to a zip file.
+func writeDistDeltaZip(zipFile string, distDir string, names []string) error {
+	out, err := os.Create(zipFile)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	w := zip.NewWriter(out)
+	for _, name := range names {
+		if err := addFileToZip(w, distDir, name); err != nil {
+			w.Close()
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+func addFileToZip(w *zip.Writer, dir string, name string) error {
+	in, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+
+	entry, err := w.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, in)
+	return err
+}
+
+// Write to dist:
+//   - A manifest of all files in the dist directory, including their hashes.
+//   - A list of which dist files have changed since the previous dist manifest.
+//   - A zip of the dist files that were added or changed.
+//
+// The previous dist manifest is read from DIST_DELTA_BASE_MANIFEST if it is set,
+// which lets CI compare against the manifest of the last uploaded build, and from
+// out/soong otherwise. The current manifest is recorded in out/soong for the next
+// build.
+func runDistDelta(ctx Context, config Config) {
+	ctx.BeginTrace(metrics.RunSoong, "runDistDelta")
+	defer ctx.EndTrace()
+
+	distDir := config.RealDistDir()
+	manifestFilename := shared.JoinPath(config.SoongOutDir(), distManifestFileName)
+
+	baseManifest := manifestFilename
+	if base, ok := config.Environment().Get("DIST_DELTA_BASE_MANIFEST"); ok && base != "" {
+		// Unlike the manifest of the previous build, an explicit base manifest must exist, or
+		// every dist file would silently be reported as new.
+		f, err := os.Open(base)
+		if err != nil {
+			ctx.Fatalf("failed to read DIST_DELTA_BASE_MANIFEST: %s", err)
+		}
+		f.Close()
+		baseManifest = base
+	}
+
+	// If there is no previous manifest, every dist file is treated as new.
+	previous, err := readJson(baseManifest)
+	if err != nil {
+		ctx.Fatal(err)
+		return
+	}
+
+	current, err := takeDistSnapshot(ctx, distDir)
+	if err != nil {
+		ctx.Fatal(err)
+		return
+	}
+
+	diff := diffSnapshots(previous, current)
+
+	if err := writeJson(shared.JoinPath(distDir, distDeltaFileName), diff); err != nil {
+		ctx.Fatal(err)
+		return
+	}
+
+	changed := append(append([]string{}, diff.Added...), diff.Changed...)
+	if err := writeDistDeltaZip(shared.JoinPath(distDir, distDeltaZipFileName), distDir, changed); err != nil {
+		ctx.Fatal(err)
+		return
+	}
+
+	if err := writeJson(shared.JoinPath(distDir, distManifestFileName), current); err != nil {
+		ctx.Fatal(err)
+		return
+	}
+	if err := writeJson(manifestFilename, current); err != nil {
+		ctx.Fatal(err)
+		return
+	}
+}
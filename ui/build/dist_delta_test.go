@@ -0,0 +1,63 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Make sure the outputs of the dist delta itself and logs are not part of the snapshot
+func TestDistSnapshotExcludes(t *testing.T) {
+	ctx := testContext()
+
+	temp := makeTempDir([]string{"a.zip", "b/c.img", "logs/verbose.log", "soong_ui/build.trace.gz",
+		distManifestFileName, distDeltaFileName, distDeltaZipFileName}, nil, nil)
+	defer os.RemoveAll(temp)
+
+	actual, _ := takeDistSnapshot(ctx, temp)
+
+	var names []string
+	for _, entry := range actual {
+		names = append(names, entry.Name)
+	}
+
+	assertDeepEqual(t, []string{"a.zip", "b/c.img"}, names)
+}
+
+// Make sure the delta zip contains exactly the requested files
+func TestDistDeltaZip(t *testing.T) {
+	temp := makeTempDir([]string{"a.zip", "b/c.img", "d.txt"}, nil, nil)
+	defer os.RemoveAll(temp)
+
+	zipFile := filepath.Join(temp, distDeltaZipFileName)
+	if err := writeDistDeltaZip(zipFile, temp, []string{"a.zip", "b/c.img"}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.OpenReader(zipFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+	assertDeepEqual(t, []string{"a.zip", "b/c.img"}, names)
+}
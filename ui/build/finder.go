@@ -89,6 +89,8 @@ func NewSourceFinder(ctx Context, config Config) (f *finder.Finder) {
 			"WORKSPACE",
 			// METADATA file of packages
 			"METADATA",
+			// Build time budgets of projects.
+			"BUILD_BUDGET.json",
 		},
 		// Bazel Starlark configuration files and all .mk files for product/board configuration.
 		IncludeSuffixes: []string{".bzl", ".mk"},
@@ -191,6 +193,13 @@ func FindSources(ctx Context, config Config, f *finder.Finder) {
 		ctx.Fatalf("Could not find OWNERS: %v", err)
 	}
 
+	// Recursively look for all BUILD_BUDGET.json files.
+	budgets := f.FindNamedAt(".", buildBudgetFileName)
+	err = dumpListToFile(ctx, config, budgets, filepath.Join(dumpDir, buildBudgetFileName+".list"))
+	if err != nil {
+		ctx.Fatalf("Could not find %s: %v", buildBudgetFileName, err)
+	}
+
 	// Recursively look for all METADATA files.
 	metadataFiles := f.FindNamedAt(".", "METADATA")
 	err = dumpListToFile(ctx, config, metadataFiles, filepath.Join(dumpDir, "METADATA.list"))
@@ -0,0 +1,90 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"android/soong/remoteexec"
+)
+
+// The Goma deprecation shim runs the builds that still set USE_GOMA or FORCE_USE_GOMA with RBE
+// instead of failing them. The C and C++ compiles that Goma ran remotely run remotely with RBE,
+// with a local fallback like Goma, and the metrics of the build record the action types that still
+// run locally with the RBE settings of the build and why, to find what blocks the migration of
+// the fleets that used Goma.
+
+// rbeActionType is a type of action that can run with RBE.
+type rbeActionType struct {
+	name string
+
+	// The environment variable enabling RBE for the action type, or empty if USE_RBE enables it.
+	enableVar string
+
+	// The environment variable overriding the exec strategy of the action type, see
+	// remoteexec.DefaultExecStrategy for its default.
+	strategyVar string
+}
+
+var rbeActionTypes = []rbeActionType{
+	{"cxx", "", "RBE_CXX_EXEC_STRATEGY"},
+	{"cxx_links", "RBE_CXX_LINKS", "RBE_CXX_LINKS_EXEC_STRATEGY"},
+	{"clang_tidy", "RBE_CLANG_TIDY", "RBE_CLANG_TIDY_EXEC_STRATEGY"},
+	{"abi_dumper", "RBE_ABI_DUMPER", "RBE_ABI_DUMPER_EXEC_STRATEGY"},
+	{"abi_linker", "RBE_ABI_LINKER", "RBE_ABI_LINKER_EXEC_STRATEGY"},
+	{"javac", "RBE_JAVAC", "RBE_JAVAC_EXEC_STRATEGY"},
+	{"kotlinc", "RBE_KOTLINC", "RBE_KOTLINC_EXEC_STRATEGY"},
+	{"turbine", "RBE_TURBINE", "RBE_TURBINE_EXEC_STRATEGY"},
+	{"d8", "RBE_D8", "RBE_D8_EXEC_STRATEGY"},
+	{"r8", "RBE_R8", "RBE_R8_EXEC_STRATEGY"},
+	{"dex2oat", "RBE_DEX2OAT", "RBE_DEX2OAT_EXEC_STRATEGY"},
+	{"metalava", "RBE_METALAVA", "RBE_METALAVA_EXEC_STRATEGY"},
+	{"lint", "RBE_LINT", "RBE_LINT_EXEC_STRATEGY"},
+	{"signapk", "RBE_SIGNAPK", "RBE_SIGNAPK_EXEC_STRATEGY"},
+	{"jar", "RBE_JAR", "RBE_JAR_EXEC_STRATEGY"},
+	{"zip", "RBE_ZIP", "RBE_ZIP_EXEC_STRATEGY"},
+}
+
+// mapGomaToRBE replaces USE_GOMA and FORCE_USE_GOMA with the RBE settings running the actions
+// that Goma ran remotely.
+func (c *configImpl) mapGomaToRBE(ctx Context) {
+	ctx.Println("Goma for Android has been deprecated and replaced with RBE. See go/rbe_for_android for instructions on how to use RBE.")
+	ctx.Println("USE_GOMA / FORCE_USE_GOMA are mapped to USE_RBE for this build.")
+
+	c.environ.Unset("USE_GOMA", "FORCE_USE_GOMA")
+	c.environ.Set("USE_RBE", "true")
+	if _, ok := c.environ.Get("RBE_CXX_EXEC_STRATEGY"); !ok {
+		c.environ.Set("RBE_CXX_EXEC_STRATEGY", remoteexec.RemoteLocalFallbackExecStrategy)
+	}
+	c.gomaShim = true
+}
+
+// rbeLocalActionTypes returns the action types that run locally with the RBE settings of the
+// build, with the reason why, as "<action type>: <reason>".
+func rbeLocalActionTypes(config Config) []string {
+	var localActionTypes []string
+	for _, t := range rbeActionTypes {
+		if t.enableVar != "" && !config.environ.IsEnvTrue(t.enableVar) {
+			localActionTypes = append(localActionTypes, t.name+": "+t.enableVar+" is not set")
+			continue
+		}
+		strategy, ok := config.environ.Get(t.strategyVar)
+		if !ok {
+			strategy = remoteexec.DefaultExecStrategy(t.strategyVar)
+		}
+		if strategy == remoteexec.LocalExecStrategy {
+			localActionTypes = append(localActionTypes, t.name+": "+t.strategyVar+" is "+strategy)
+		}
+	}
+	return localActionTypes
+}
@@ -0,0 +1,115 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package build
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMapGomaToRBE(t *testing.T) {
+	tests := []struct {
+		name                 string
+		environ              Environment
+		wantCXXStrategy      string
+		wantLocalActionTypes []string
+	}{
+		{
+			name:            "use goma",
+			environ:         Environment{"USE_GOMA=1"},
+			wantCXXStrategy: "remote_local_fallback",
+			wantLocalActionTypes: []string{
+				"cxx_links: RBE_CXX_LINKS is not set",
+				"clang_tidy: RBE_CLANG_TIDY is not set",
+				"abi_dumper: RBE_ABI_DUMPER is not set",
+				"abi_linker: RBE_ABI_LINKER is not set",
+				"javac: RBE_JAVAC is not set",
+				"kotlinc: RBE_KOTLINC is not set",
+				"turbine: RBE_TURBINE is not set",
+				"d8: RBE_D8 is not set",
+				"r8: RBE_R8 is not set",
+				"dex2oat: RBE_DEX2OAT is not set",
+				"metalava: RBE_METALAVA is not set",
+				"lint: RBE_LINT is not set",
+				"signapk: RBE_SIGNAPK is not set",
+				"jar: RBE_JAR is not set",
+				"zip: RBE_ZIP is not set",
+			},
+		},
+		{
+			name: "force use goma with rbe settings",
+			environ: Environment{
+				"FORCE_USE_GOMA=1",
+				"RBE_CXX_EXEC_STRATEGY=local",
+				"RBE_JAVAC=1",
+				"RBE_KOTLINC=1",
+				"RBE_D8=1",
+				"RBE_R8=1",
+				"RBE_DEX2OAT=1",
+				"RBE_R8_EXEC_STRATEGY=local",
+				"RBE_CXX_LINKS=1",
+				"RBE_CXX_LINKS_EXEC_STRATEGY=remote",
+				"RBE_CLANG_TIDY=1",
+				"RBE_ABI_DUMPER=1",
+				"RBE_ABI_LINKER=1",
+				"RBE_TURBINE=1",
+				"RBE_METALAVA=1",
+				"RBE_LINT=1",
+				"RBE_SIGNAPK=1",
+				"RBE_JAR=1",
+				"RBE_ZIP=1",
+			},
+			wantCXXStrategy: "local",
+			wantLocalActionTypes: []string{
+				"cxx: RBE_CXX_EXEC_STRATEGY is local",
+				"clang_tidy: RBE_CLANG_TIDY_EXEC_STRATEGY is local",
+				"abi_dumper: RBE_ABI_DUMPER_EXEC_STRATEGY is local",
+				"abi_linker: RBE_ABI_LINKER_EXEC_STRATEGY is local",
+				"turbine: RBE_TURBINE_EXEC_STRATEGY is local",
+				"r8: RBE_R8_EXEC_STRATEGY is local",
+				"metalava: RBE_METALAVA_EXEC_STRATEGY is local",
+				"lint: RBE_LINT_EXEC_STRATEGY is local",
+				"signapk: RBE_SIGNAPK_EXEC_STRATEGY is local",
+				"jar: RBE_JAR_EXEC_STRATEGY is local",
+				"zip: RBE_ZIP_EXEC_STRATEGY is local",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := Config{&configImpl{environ: &tc.environ}}
+			config.mapGomaToRBE(testContext())
+
+			if config.UseGoma() || config.ForceUseGoma() {
+				t.Errorf("expected USE_GOMA and FORCE_USE_GOMA to be unset")
+			}
+			if !config.UseRBE() {
+				t.Errorf("expected USE_RBE to be set")
+			}
+			if got, _ := config.environ.Get("RBE_CXX_EXEC_STRATEGY"); got != tc.wantCXXStrategy {
+				t.Errorf("expected RBE_CXX_EXEC_STRATEGY %q, got %q", tc.wantCXXStrategy, got)
+			}
+
+			buildMetrics := buildConfig(config)
+			if !buildMetrics.GetGomaShim() {
+				t.Errorf("expected goma_shim to be set in the build config metrics")
+			}
+			if got := buildMetrics.GetRbeLocalActionTypes(); !reflect.DeepEqual(got, tc.wantLocalActionTypes) {
+				t.Errorf("expected rbe_local_action_types\n%q\ngot\n%q", tc.wantLocalActionTypes, got)
+			}
+		})
+	}
+}
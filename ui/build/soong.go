@@ -288,6 +288,24 @@ func bootstrapBlueprint(ctx Context, config Config) {
 	if config.buildFromTextStub {
 		mainSoongBuildExtraArgs = append(mainSoongBuildExtraArgs, "--build-from-text-stub")
 	}
+	if config.ModuleEnvDepsReport() {
+		mainSoongBuildExtraArgs = append(mainSoongBuildExtraArgs, "--module-env-deps-report")
+	}
+	if config.ProductVariableDepsReport() {
+		mainSoongBuildExtraArgs = append(mainSoongBuildExtraArgs, "--product-variable-deps-report")
+	}
+	if config.BuildBudgets() != "" {
+		mainSoongBuildExtraArgs = append(mainSoongBuildExtraArgs, "--analysis-times-report")
+	}
+	if config.PathToolsAudit() {
+		mainSoongBuildExtraArgs = append(mainSoongBuildExtraArgs, "--path-tools-audit")
+	}
+	if config.SerializeMutators() {
+		mainSoongBuildExtraArgs = append(mainSoongBuildExtraArgs, "--serialize-mutators")
+	}
+	if checkpoints := config.MutatorCheckpoints(); checkpoints != "" {
+		mainSoongBuildExtraArgs = append(mainSoongBuildExtraArgs, "--mutator-checkpoints="+checkpoints)
+	}
 
 	queryviewDir := filepath.Join(config.SoongOutDir(), "queryview")
 	// The BUILD files will be generated in out/soong/.api_bp2build (no symlinks to src files)
@@ -324,6 +342,8 @@ func bootstrapBlueprint(ctx Context, config Config) {
 			specificArgs: []string{
 				"--module_graph_file", config.ModuleGraphFile(),
 				"--module_actions_file", config.ModuleActionsFile(),
+				"--output-compression=" + config.OutputCompression(),
+				"--zstd=" + config.PrebuiltBuildTool("zstd"),
 			},
 		},
 		{
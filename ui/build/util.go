@@ -21,6 +21,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"android/soong/shared"
 )
 
 func absPath(ctx Context, p string) string {
@@ -145,9 +147,11 @@ func copyFile(src, dst string) (int64, error) {
 	return io.Copy(destination, source)
 }
 
-// gzipFileToDir writes a compressed copy of src to destDir with the suffix ".gz".
-func gzipFileToDir(src, destDir string) error {
-	in, err := os.Open(src)
+// gzipFileToDir writes a compressed copy of src to destDir with the suffix ".gz". zstd is the
+// path of the zstd tool used to decompress src if soong_build compressed it with zstd, see
+// OutputCompression.
+func gzipFileToDir(src, destDir, zstd string) error {
+	in, err := shared.OpenDecompressed(src, zstd)
 	if err != nil {
 		return fmt.Errorf("failed to open %s: %s", src, err.Error())
 	}
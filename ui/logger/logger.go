@@ -143,6 +143,9 @@ type stdLogger struct {
 	mutex      sync.Mutex
 	file       *os.File
 	metrics    *metrics.Metrics
+
+	// Scrubs secrets out of everything that is logged, if set.
+	redact func(string) string
 }
 
 var _ Logger = &stdLogger{}
@@ -187,6 +190,13 @@ func (s *stdLogger) SetOutput(path string) *stdLogger {
 	return s
 }
 
+// SetRedact sets the function used to scrub secrets out of everything logged
+// to stderr, the file log and metrics.
+func (s *stdLogger) SetRedact(redact func(string) string) *stdLogger {
+	s.redact = redact
+	return s
+}
+
 type panicWriter struct{}
 
 func (panicWriter) Write([]byte) (int, error) { panic("write to panicWriter") }
@@ -240,6 +250,9 @@ func (s *stdLogger) Output(calldepth int, str string) error {
 
 // output writes string to stderr, the file log, and if fatal or panic, to metrics.
 func (s *stdLogger) output(calldepth int, str string, level verbosityLevel) error {
+	if s.redact != nil {
+		str = s.redact(str)
+	}
 	if level != verboseLog || s.verbose {
 		s.stderr.Output(calldepth+1, str)
 	}
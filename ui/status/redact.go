@@ -0,0 +1,134 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Values shorter than this are not redacted, as they would match too much
+// unrelated text to be useful, e.g. a secret set to "1".
+const minSecretLength = 4
+
+// Redactor replaces the values of secrets with a placeholder naming the
+// secret, e.g. "<redacted:SIGNING_TOKEN>".
+type Redactor struct {
+	replacer *strings.Replacer
+}
+
+// NewRedactor returns a Redactor for the given secrets, keyed by name. It
+// returns nil if there is nothing to redact, which is a valid Redactor that
+// leaves everything unchanged.
+func NewRedactor(secrets map[string]string) *Redactor {
+	var names []string
+	for name, value := range secrets {
+		if len(value) >= minSecretLength {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	// Replace longer values first so that a secret containing another secret
+	// is redacted as a whole.
+	sort.Slice(names, func(i, j int) bool {
+		if len(secrets[names[i]]) != len(secrets[names[j]]) {
+			return len(secrets[names[i]]) > len(secrets[names[j]])
+		}
+		return names[i] < names[j]
+	})
+
+	var oldnew []string
+	for _, name := range names {
+		oldnew = append(oldnew, secrets[name], "<redacted:"+name+">")
+	}
+	return &Redactor{replacer: strings.NewReplacer(oldnew...)}
+}
+
+// Redact returns s with the values of all the secrets replaced.
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	return r.replacer.Replace(s)
+}
+
+func (r *Redactor) redactStrings(list []string) []string {
+	if r == nil || list == nil {
+		return list
+	}
+	ret := make([]string, len(list))
+	for i, s := range list {
+		ret[i] = r.Redact(s)
+	}
+	return ret
+}
+
+func (r *Redactor) redactError(err error) error {
+	if r == nil || err == nil {
+		return err
+	}
+	if msg := r.Redact(err.Error()); msg != err.Error() {
+		return errors.New(msg)
+	}
+	return err
+}
+
+// SetRedactor sets the Redactor applied to everything passed on to the
+// outputs of this Status: action descriptions and command lines, action
+// output and errors, and messages.
+func (s *Status) SetRedactor(r *Redactor) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.redactor = r
+}
+
+// redactAction returns a redacted copy of action. The copy is reused when the
+// action finishes, as outputs may track running actions by pointer.
+func (s *Status) redactAction(action *Action) *Action {
+	if s.redactor == nil || action == nil {
+		return action
+	}
+	if redacted, ok := s.redactedActions[action]; ok {
+		return redacted
+	}
+	redacted := &Action{
+		Description: s.redactor.Redact(action.Description),
+		Outputs:     s.redactor.redactStrings(action.Outputs),
+		Inputs:      s.redactor.redactStrings(action.Inputs),
+		Command:     s.redactor.Redact(action.Command),
+	}
+	if s.redactedActions == nil {
+		s.redactedActions = make(map[*Action]*Action)
+	}
+	s.redactedActions[action] = redacted
+	return redacted
+}
+
+func (s *Status) redactResult(result ActionResult) ActionResult {
+	if s.redactor == nil {
+		return result
+	}
+	original := result.Action
+	result.Action = s.redactAction(original)
+	delete(s.redactedActions, original)
+	result.Output = s.redactor.Redact(result.Output)
+	result.Error = s.redactor.redactError(result.Error)
+	return result
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"errors"
+	"testing"
+)
+
+type recordingOutput struct {
+	counterOutput
+	started  *Action
+	finished ActionResult
+	messages []string
+}
+
+func (r *recordingOutput) StartAction(action *Action, counts Counts) {
+	r.started = action
+}
+
+func (r *recordingOutput) FinishAction(result ActionResult, counts Counts) {
+	r.finished = result
+}
+
+func (r *recordingOutput) Message(level MsgLevel, msg string) {
+	r.messages = append(r.messages, msg)
+}
+
+func TestRedactor(t *testing.T) {
+	r := NewRedactor(map[string]string{
+		"TOKEN":      "abcd1234",
+		"LONG_TOKEN": "xxabcd1234yy",
+		"SHORT":      "1",
+	})
+
+	if got, want := r.Redact("a 1 xxabcd1234yy abcd1234"), "a 1 <redacted:LONG_TOKEN> <redacted:TOKEN>"; got != want {
+		t.Errorf("Redact() = %q, want %q", got, want)
+	}
+
+	if NewRedactor(map[string]string{"SHORT": "1"}) != nil {
+		t.Errorf("expected a nil Redactor when there is nothing to redact")
+	}
+	var nilRedactor *Redactor
+	if got := nilRedactor.Redact("abcd1234"); got != "abcd1234" {
+		t.Errorf("nil Redactor changed %q to %q", "abcd1234", got)
+	}
+}
+
+func TestStatusRedaction(t *testing.T) {
+	status := &Status{}
+	output := &recordingOutput{}
+	status.AddOutput(output)
+	status.SetRedactor(NewRedactor(map[string]string{"TOKEN": "abcd1234"}))
+	s := status.StartTool()
+
+	action := &Action{Description: "sign", Command: "sign --token abcd1234"}
+	s.StartAction(action)
+	if got, want := output.started.Command, "sign --token <redacted:TOKEN>"; got != want {
+		t.Errorf("started command = %q, want %q", got, want)
+	}
+	if action.Command != "sign --token abcd1234" {
+		t.Errorf("the original action was modified")
+	}
+
+	s.FinishAction(ActionResult{
+		Action: action,
+		Output: "bad token abcd1234",
+		Error:  errors.New("exit status 1"),
+	})
+	if output.finished.Action != output.started {
+		t.Errorf("expected the same redacted action when starting and finishing")
+	}
+	if got, want := output.finished.Output, "bad token <redacted:TOKEN>"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	s.Error("failed with abcd1234")
+	if got, want := output.messages, []string{"failed with <redacted:TOKEN>"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("messages = %q, want %q", got, want)
+	}
+}
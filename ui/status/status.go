@@ -224,6 +224,10 @@ type Status struct {
 	// Protects counts and outputs, and allows each output to
 	// expect only a single caller at a time.
 	lock sync.Mutex
+
+	// Scrubs secrets out of everything passed on to the outputs.
+	redactor        *Redactor
+	redactedActions map[*Action]*Action
 }
 
 // AddOutput attaches an output to this object. It's generally expected that an
@@ -272,6 +276,7 @@ func (s *Status) startAction(action *Action) {
 	s.counts.RunningActions += 1
 	s.counts.StartedActions += 1
 
+	action = s.redactAction(action)
 	for _, o := range s.outputs {
 		o.StartAction(action, s.counts)
 	}
@@ -284,6 +289,7 @@ func (s *Status) finishAction(result ActionResult) {
 	s.counts.RunningActions -= 1
 	s.counts.FinishedActions += 1
 
+	result = s.redactResult(result)
 	for _, o := range s.outputs {
 		o.FinishAction(result, s.counts)
 	}
@@ -293,6 +299,7 @@ func (s *Status) message(level MsgLevel, msg string) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
+	msg = s.redactor.Redact(msg)
 	for _, o := range s.outputs {
 		o.Message(level, msg)
 	}